@@ -1,10 +1,16 @@
 package agent
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
+// maxSocketPathLen is the longest unix socket path every platform accepts,
+// macOS being the most restrictive with 104 bytes including the terminator.
+const maxSocketPathLen = 103
+
 // TODO: deprecate
 func PathToSocket() string {
 	dir, err := os.UserHomeDir()
@@ -12,7 +18,19 @@ func PathToSocket() string {
 		panic(err)
 	}
 
-	return filepath.Join(dir, ".fly", "fly-agent.sock")
+	return socketPath(dir, os.TempDir())
+}
+
+// socketPath returns the agent socket path under the .fly directory of home,
+// or under tmp when that one would be too long to bind.
+func socketPath(home, tmp string) string {
+	if path := filepath.Join(home, ".fly", "fly-agent.sock"); len(path) <= maxSocketPathLen {
+		return path
+	}
+
+	// Keep the path unique to the user, whose home directory it derives from
+	sum := sha256.Sum256([]byte(home))
+	return filepath.Join(tmp, fmt.Sprintf("fly-agent-%x.sock", sum[:6]))
 }
 
 type Instances struct {
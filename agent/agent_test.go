@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocketPath(t *testing.T) {
+	tmp := os.TempDir()
+
+	home := filepath.Join("/home", "user")
+	assert.Equal(t, filepath.Join(home, ".fly", "fly-agent.sock"), socketPath(home, tmp))
+
+	long := filepath.Join("/home", strings.Repeat("a", maxSocketPathLen))
+	path := socketPath(long, tmp)
+	assert.Equal(t, tmp, filepath.Dir(path))
+	assert.True(t, strings.HasPrefix(filepath.Base(path), "fly-agent-"))
+	assert.True(t, strings.HasSuffix(path, ".sock"))
+	assert.LessOrEqual(t, len(path), maxSocketPathLen)
+	assert.Equal(t, path, socketPath(long, tmp))
+
+	other := filepath.Join("/home", strings.Repeat("b", maxSocketPathLen))
+	assert.NotEqual(t, path, socketPath(other, tmp))
+}
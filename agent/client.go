@@ -12,7 +12,6 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/azazeal/pause"
@@ -104,8 +103,7 @@ func Dial(ctx context.Context, network, addr string) (*Client, error) {
 	if _, err := client.Ping(ctx); err != nil {
 		// if the agen't isn't running the error will be "connect: file or directory not found"
 		// catch it and return a sentinel error
-		var syscallErr *os.SyscallError
-		if errors.As(err, &syscallErr) && syscallErr.Err == syscall.ENOENT {
+		if isAgentNotRunning(err) {
 			return nil, ErrAgentNotRunning
 		}
 		return nil, err
@@ -5,9 +5,17 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"net"
+	"syscall"
 )
 
 func (c *Client) dialContext(ctx context.Context) (conn net.Conn, err error) {
 	return c.dialer.DialContext(ctx, c.network, c.address)
 }
+
+// isAgentNotRunning reports whether err dialing the agent's socket means the
+// agent isn't running: the socket is missing, or left behind by a crash.
+func isAgentNotRunning(err error) bool {
+	return errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -5,9 +5,12 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"net"
+	"syscall"
 
 	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
 )
 
 func (c *Client) dialContext(ctx context.Context) (conn net.Conn, err error) {
@@ -22,3 +25,13 @@ func (c *Client) dialContext(ctx context.Context) (conn net.Conn, err error) {
 
 	return winio.DialPipeContext(ctx, pipe)
 }
+
+// isAgentNotRunning reports whether err dialing the agent's socket or named
+// pipe means the agent isn't running. Windows reports these with its own
+// error codes rather than the POSIX ones.
+func isAgentNotRunning(err error) bool {
+	return errors.Is(err, windows.ERROR_FILE_NOT_FOUND) ||
+		errors.Is(err, windows.ERROR_PATH_NOT_FOUND) ||
+		errors.Is(err, windows.WSAECONNREFUSED) ||
+		errors.Is(err, syscall.ENOENT)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os/exec"
 	"os/user"
+	"strings"
 	"syscall"
 
 	"golang.org/x/sys/windows"
@@ -22,8 +23,10 @@ func setSysProcAttributes(cmd *exec.Cmd) {
 // Use UNIX sockets since 10.0.17063
 // https://devblogs.microsoft.com/commandline/af_unix-comes-to-windows/
 func UseUnixSockets() bool {
-	maj, _, patch := windows.RtlGetNtVersionNumbers()
-	if maj > 10 || maj == 10 && patch >= 17063 {
+	maj, _, build := windows.RtlGetNtVersionNumbers()
+	// The high bits of the build number flag free and checked builds
+	build &= 0xffff
+	if maj > 10 || maj == 10 && build >= 17063 {
 		return true
 	}
 
@@ -36,5 +39,11 @@ func PipeName() (string, error) {
 		return "", fmt.Errorf("can't query current username: %w", err)
 	}
 
-	return `\\.\pipe\fly-agent-` + user.Username, nil
+	return pipeName(user.Username), nil
+}
+
+// pipeName returns the agent's named pipe for username, which is qualified
+// with its domain as in DOMAIN\user, a separator pipe names can't contain.
+func pipeName(username string) string {
+	return `\\.\pipe\fly-agent-` + strings.ReplaceAll(username, `\`, "-")
 }
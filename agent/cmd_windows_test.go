@@ -0,0 +1,14 @@
+//go:build windows
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeName(t *testing.T) {
+	assert.Equal(t, `\\.\pipe\fly-agent-user`, pipeName("user"))
+	assert.Equal(t, `\\.\pipe\fly-agent-DOMAIN-user`, pipeName(`DOMAIN\user`))
+}
@@ -49,7 +49,7 @@ func bindSocket(socket string) (net.Listener, error) {
 	// establish a connection.
 	l, err := winio.ListenPipe(pipe, nil)
 	if err != nil {
-		err = fmt.Errorf("failed binding: %w", err)
+		return nil, fmt.Errorf("failed binding: %w", err)
 	}
 
 	return l, nil
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // CLISessionAuth holds access information
@@ -14,6 +15,14 @@ type CLISessionAuth struct {
 	ID          string `json:"id"`
 	AuthURL     string `json:"auth_url"`
 	AccessToken string `json:"access_token"`
+
+	// UserCode is entered at AuthURL to confirm device code logins.
+	UserCode string `json:"user_code,omitempty"`
+
+	// RefreshToken and ExpiresAt are set for the short-lived access tokens
+	// of SSO logins.
+	RefreshToken string     `json:"refresh_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
 }
 
 // StartCLISessionWebAuth starts a session with the platform via web auth
@@ -43,31 +52,103 @@ func StartCLISessionWebAuth(machineName string, signup bool) (CLISessionAuth, er
 	return result, nil
 }
 
+// StartCLISessionSSO starts a session with the platform via the SSO of the
+// organization with the given slug. With deviceCode, the session is
+// confirmed with a user code from any device, for headless machines.
+func StartCLISessionSSO(machineName, orgSlug string, deviceCode bool) (CLISessionAuth, error) {
+	var result CLISessionAuth
+
+	postData, _ := json.Marshal(map[string]interface{}{
+		"name":             machineName,
+		"sso_organization": orgSlug,
+		"device_code":      deviceCode,
+	})
+
+	url := fmt.Sprintf("%s/api/v1/cli_sessions", baseURL)
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(postData))
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		return result, err
+	case http.StatusNotFound:
+		return result, fmt.Errorf("organization %s doesn't have SSO enabled", orgSlug)
+	default:
+		return result, ErrUnknown
+	}
+}
+
 // GetAccessTokenForCLISession Obtains the access token for the session
 func GetAccessTokenForCLISession(ctx context.Context, id string) (string, error) {
+	auth, err := GetCLISession(ctx, id)
+	return auth.AccessToken, err
+}
+
+// GetCLISession obtains the session, with its access token once the user
+// logged in.
+func GetCLISession(ctx context.Context, id string) (CLISessionAuth, error) {
+	var auth CLISessionAuth
+
 	url := fmt.Sprintf("%s/api/v1/cli_sessions/%s", baseURL, id)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", err
+		return auth, err
 	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return auth, err
 	}
 	defer res.Body.Close()
 
 	switch res.StatusCode {
 	case http.StatusOK:
-		var auth CLISessionAuth
 		if err = json.NewDecoder(res.Body).Decode(&auth); err != nil {
-			return "", fmt.Errorf("Failed to decode auth token, please try again: %w", err)
+			return auth, fmt.Errorf("Failed to decode auth token, please try again: %w", err)
 		}
-		return auth.AccessToken, nil
+		return auth, nil
 	case http.StatusNotFound:
-		return "", ErrNotFound
+		return auth, ErrNotFound
+	default:
+		return auth, ErrUnknown
+	}
+}
+
+// RefreshCLISession exchanges the refresh token of an SSO session for a new
+// access token.
+func RefreshCLISession(ctx context.Context, refreshToken string) (CLISessionAuth, error) {
+	var auth CLISessionAuth
+
+	postData, _ := json.Marshal(map[string]string{
+		"refresh_token": refreshToken,
+	})
+
+	url := fmt.Sprintf("%s/api/v1/cli_sessions/refresh", baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(postData))
+	if err != nil {
+		return auth, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return auth, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		err = json.NewDecoder(res.Body).Decode(&auth)
+		return auth, err
+	case http.StatusUnauthorized, http.StatusNotFound:
+		return auth, ErrNotFound
 	default:
-		return "", ErrUnknown
+		return auth, ErrUnknown
 	}
 }
 
@@ -14,10 +14,15 @@ const (
 	MachineConfigMetadataKeyFlyReleaseVersion  = "fly_release_version"
 	MachineConfigMetadataKeyFlyProcessGroup    = "fly_process_group"
 	MachineConfigMetadataKeyFlyPreviousAlloc   = "fly_previous_alloc"
+	MachineConfigMetadataKeyFlySidecars        = "fly_sidecars"
+	MachineConfigMetadataKeyManagedByFlyDeploy = "managed-by-fly-deploy"
 	MachineFlyPlatformVersion2                 = "v2"
 	MachineProcessGroupApp                     = "app"
 	MachineProcessGroupFlyAppReleaseCommand    = "fly_app_release_command"
 	MachineProcessGroupFlyAppConsole           = "fly_app_console"
+	MachineProcessGroupFlyAppMaintenance       = "fly_app_maintenance"
+	MachineProcessGroupFlyAppTrafficProxy      = "fly_app_traffic_proxy"
+	MachineProcessGroupFlyAppMirrorCandidate   = "fly_app_mirror_candidate"
 	MachineStateDestroyed                      = "destroyed"
 	MachineStateDestroying                     = "destroying"
 	MachineStateStarted                        = "started"
@@ -86,6 +91,18 @@ func (m *Machine) IsFlyAppsConsole() bool {
 	return m.IsFlyAppsPlatform() && m.HasProcessGroup(MachineProcessGroupFlyAppConsole)
 }
 
+func (m *Machine) IsFlyAppsMaintenance() bool {
+	return m.IsFlyAppsPlatform() && m.HasProcessGroup(MachineProcessGroupFlyAppMaintenance)
+}
+
+func (m *Machine) IsFlyAppsTrafficProxy() bool {
+	return m.IsFlyAppsPlatform() && m.HasProcessGroup(MachineProcessGroupFlyAppTrafficProxy)
+}
+
+func (m *Machine) IsFlyAppsMirrorCandidate() bool {
+	return m.IsFlyAppsPlatform() && m.HasProcessGroup(MachineProcessGroupFlyAppMirrorCandidate)
+}
+
 func (m *Machine) IsActive() bool {
 	return m.State != MachineStateDestroyed && m.State != MachineStateDestroying
 }
@@ -276,10 +293,42 @@ type MachineMount struct {
 	Name      string `json:"name,omitempty"`
 }
 
+// IsReservedMetadataKey reports whether key is a metadata key flyctl or the
+// platform manage, rather than a label of the user.
+func IsReservedMetadataKey(key string) bool {
+	return strings.HasPrefix(key, "fly_") || strings.HasPrefix(key, "fly-") ||
+		key == MachineConfigMetadataKeyManagedByFlyDeploy || key == "process_group"
+}
+
+// MachinesVolume is a volume as the Machines API describes it, including the
+// usage of its file system.
+type MachinesVolume struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	State             string `json:"state"`
+	SizeGb            int    `json:"size_gb"`
+	Region            string `json:"region"`
+	AttachedMachineID string `json:"attached_machine_id"`
+	BlockSize         int64  `json:"block_size"`
+	Blocks            int64  `json:"blocks"`
+	BlocksAvail       int64  `json:"blocks_avail"`
+	BlocksFree        int64  `json:"blocks_free"`
+}
+
+// UsedBytes returns how many bytes of the file system of v are used, and
+// whether the Machines API reported it.
+func (v *MachinesVolume) UsedBytes() (int64, bool) {
+	if v.BlockSize == 0 || v.Blocks == 0 {
+		return 0, false
+	}
+	return (v.Blocks - v.BlocksFree) * v.BlockSize, true
+}
+
 type MachineGuest struct {
 	CPUKind  string `json:"cpu_kind,omitempty"`
 	CPUs     int    `json:"cpus,omitempty"`
 	MemoryMB int    `json:"memory_mb,omitempty"`
+	GPUKind  string `json:"gpu_kind,omitempty"`
 
 	KernelArgs []string `json:"kernel_args,omitempty"`
 }
@@ -350,6 +399,82 @@ var MachinePresets map[string]*MachineGuest = map[string]*MachineGuest{
 	"performance-16x": {CPUKind: "performance", CPUs: 16, MemoryMB: 16 * MIN_MEMORY_MB_PER_CPU},
 }
 
+// MachineGPUKinds maps the GPU kinds a machine can be attached to the regions
+// they are available in.
+var MachineGPUKinds = map[string][]string{
+	"a10":            {"ord"},
+	"a100-pcie-40gb": {"ord"},
+	"a100-sxm4-80gb": {"ams", "iad", "sjc", "syd"},
+	"l40s":           {"ord"},
+}
+
+// MemoryRange returns the minimum and maximum memory, in megabytes, a machine
+// with the given cpu kind and number of cpus may be given.
+func MemoryRange(cpuKind string, cpus int) (min, max int) {
+	if cpuKind == "performance" {
+		return cpus * MIN_MEMORY_MB_PER_CPU, cpus * MAX_MEMORY_MB_PER_CPU
+	}
+	return cpus * MIN_MEMORY_MB_PER_SHARED_CPU, cpus * MAX_MEMORY_MB_PER_SHARED_CPU
+}
+
+// Validate checks the guest against the catalog of valid cpu kinds, cpu
+// counts, memory sizes and GPU kinds, so invalid combinations are rejected
+// before they reach the API.
+func (mg *MachineGuest) Validate() error {
+	if mg == nil {
+		return nil
+	}
+
+	switch mg.CPUKind {
+	case "", "shared", "performance":
+	default:
+		return fmt.Errorf("invalid cpu kind '%s', expected 'shared' or 'performance'", mg.CPUKind)
+	}
+
+	if mg.CPUs != 0 {
+		validCPUs := []int{}
+		for _, preset := range MachinePresets {
+			if preset.CPUKind == mg.CPUKind || (mg.CPUKind == "" && preset.CPUKind == "shared") {
+				validCPUs = append(validCPUs, preset.CPUs)
+			}
+		}
+		sort.Ints(validCPUs)
+		if i := sort.SearchInts(validCPUs, mg.CPUs); i == len(validCPUs) || validCPUs[i] != mg.CPUs {
+			return fmt.Errorf("%d is an invalid number of cpus, choose one of: %v", mg.CPUs, validCPUs)
+		}
+	}
+
+	if mg.CPUs != 0 && mg.MemoryMB != 0 {
+		min, max := MemoryRange(mg.CPUKind, mg.CPUs)
+		switch {
+		case mg.MemoryMB < min || mg.MemoryMB > max:
+			return fmt.Errorf("%d MB of memory is out of range for %d %s cpus, expected between %d MB and %d MB", mg.MemoryMB, mg.CPUs, mg.cpuKind(), min, max)
+		case mg.MemoryMB%MIN_MEMORY_MB_PER_SHARED_CPU != 0:
+			return fmt.Errorf("memory must be a multiple of %d MB", MIN_MEMORY_MB_PER_SHARED_CPU)
+		}
+	}
+
+	if mg.GPUKind != "" {
+		if _, ok := MachineGPUKinds[mg.GPUKind]; !ok {
+			kinds := make([]string, 0, len(MachineGPUKinds))
+			for kind := range MachineGPUKinds {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			return fmt.Errorf("'%s' is an invalid gpu kind, choose one of: %v", mg.GPUKind, kinds)
+		}
+	}
+
+	return nil
+}
+
+func (mg *MachineGuest) cpuKind() string {
+	if mg.CPUKind == "" {
+		return "shared"
+	}
+	return mg.CPUKind
+}
+
 type MachineMetrics struct {
 	Port int    `toml:"port" json:"port,omitempty"`
 	Path string `toml:"path" json:"path,omitempty"`
@@ -157,3 +157,32 @@ func TestMachineGuest_ToSize(t *testing.T) {
 		t.Errorf("want 'unknown', got '%s'", got)
 	}
 }
+
+func TestMachineGuest_Validate(t *testing.T) {
+	for name, guest := range MachinePresets {
+		if err := guest.Validate(); err != nil {
+			t.Errorf("got error for preset %s: %v", name, err)
+		}
+	}
+
+	invalid := map[string]*MachineGuest{
+		"cpu kind":     {CPUKind: "turbo", CPUs: 1, MemoryMB: 256},
+		"cpus":         {CPUKind: "shared", CPUs: 3, MemoryMB: 768},
+		"memory":       {CPUKind: "performance", CPUs: 1, MemoryMB: 1024},
+		"memory step":  {CPUKind: "shared", CPUs: 1, MemoryMB: 300},
+		"too much":     {CPUKind: "shared", CPUs: 1, MemoryMB: 4096},
+		"gpu kind":     {CPUKind: "performance", CPUs: 8, MemoryMB: 32768, GPUKind: "v100"},
+		"perf cpus":    {CPUKind: "performance", CPUs: 32, MemoryMB: 65536},
+		"default kind": {CPUs: 16, MemoryMB: 4096},
+	}
+	for name, guest := range invalid {
+		if err := guest.Validate(); err == nil {
+			t.Errorf("want error for invalid %s", name)
+		}
+	}
+
+	valid := &MachineGuest{CPUKind: "performance", CPUs: 8, MemoryMB: 32768, GPUKind: "a100-pcie-40gb"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("got error for valid guest: %v", err)
+	}
+}
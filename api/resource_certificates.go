@@ -29,6 +29,40 @@ func (c *Client) GetAppCertificates(ctx context.Context, appName string) ([]AppC
 	return data.AppCertsCompact.Certificates.Nodes, nil
 }
 
+// GetAppIssuedCertificates returns the certificates of an app with their
+// issued certificates and when they expire.
+func (c *Client) GetAppIssuedCertificates(ctx context.Context, appName string) ([]AppCertificate, error) {
+	query := `
+		query($appName: String!) {
+			app(name: $appName) {
+				certificates {
+					nodes {
+						hostname
+						clientStatus
+						issued {
+							nodes {
+								type
+								expiresAt
+							}
+						}
+					}
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	req.Var("appName", appName)
+
+	data, err := c.RunWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.App.Certificates.Nodes, nil
+}
+
 func (c *Client) CheckAppCertificate(ctx context.Context, appName, hostname string) (*AppCertificate, *HostnameCheck, error) {
 	query := `
 		mutation($input: CheckCertificateInput!) {
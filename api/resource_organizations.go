@@ -32,6 +32,7 @@ func (client *Client) GetOrganizations(ctx context.Context, filters ...Organizat
 			organizations(admin: $admin) {
 				nodes {
 					id
+					internalNumericId
 					slug
 					name
 					type
@@ -75,6 +75,7 @@ func (c *Client) PlatformVMSizes(ctx context.Context) ([]VMSize, error) {
 					cpuCores
 					memoryGb
 					memoryMb
+					maxMemoryMb
 					priceMonth
 					priceSecond
 				}
@@ -91,3 +92,28 @@ func (c *Client) PlatformVMSizes(ctx context.Context) ([]VMSize, error) {
 
 	return data.Platform.VMSizes, nil
 }
+
+// Products returns the products of the platform and their prices.
+func (c *Client) Products(ctx context.Context) ([]Product, error) {
+	query := `
+		query {
+			products {
+				name
+				type
+				unitLabel
+				tiers {
+					unitAmount
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+
+	data, err := c.RunWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Products, nil
+}
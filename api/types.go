@@ -49,6 +49,8 @@ type Query struct {
 
 	NearestRegion *Region
 
+	Products []Product
+
 	LatestImageTag     string
 	LatestImageDetails ImageVersion
 	// aliases & nodes
@@ -280,15 +282,16 @@ func (img *ImageVersion) FullImageRef() string {
 }
 
 type App struct {
-	ID        string
-	Name      string
-	State     string
-	Status    string
-	Deployed  bool
-	Hostname  string
-	AppURL    string
-	Version   int
-	NetworkID int
+	ID                string
+	InternalNumericID int
+	Name              string
+	State             string
+	Status            string
+	Deployed          bool
+	Hostname          string
+	AppURL            string
+	Version           int
+	NetworkID         int
 
 	Release        *Release
 	Organization   Organization
@@ -1024,12 +1027,26 @@ type AutoscaleRegionConfigInput struct {
 	Reset    *bool  `json:"reset"`
 }
 
+// Product is a Fly.io product and its price tiers.
+type Product struct {
+	Name      string
+	Type      string
+	UnitLabel string
+	Tiers     []PriceTier
+}
+
+// PriceTier is the price of a unit of a product, in USD, up to a usage.
+type PriceTier struct {
+	UnitAmount string
+}
+
 type VMSize struct {
 	Name        string
 	CPUCores    float32
 	CPUClass    string
 	MemoryGB    float32
 	MemoryMB    int
+	MaxMemoryMB int
 	PriceMonth  float32
 	PriceSecond float32
 	// MemoryIncrementsMB []int
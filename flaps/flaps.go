@@ -23,6 +23,7 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/buildinfo"
+	"github.com/superfly/flyctl/internal/cache"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/httptracing"
 	"github.com/superfly/flyctl/internal/instrument"
@@ -60,12 +61,18 @@ type NewClientOpts struct {
 
 	// optional:
 	Logger api.Logger
+
+	// optional, defaults to the environment or DefaultTimeouts:
+	Timeouts Timeouts
 }
 
 func NewWithOptions(ctx context.Context, opts NewClientOpts) (*Client, error) {
 	// FIXME: do this once we setup config for `fly config ...` commands, and then use cfg.FlapsBaseURL below
 	// cfg := config.FromContext(ctx)
-	var err error
+	timeouts, err := resolveTimeouts(opts.Timeouts)
+	if err != nil {
+		return nil, err
+	}
 	flapsBaseURL := os.Getenv("FLY_FLAPS_BASE_URL")
 	if strings.TrimSpace(strings.ToLower(flapsBaseURL)) == "peer" {
 		orgSlug, err := resolveOrgSlugForApp(ctx, opts.AppCompact, opts.AppName)
@@ -73,8 +80,9 @@ func NewWithOptions(ctx context.Context, opts NewClientOpts) (*Client, error) {
 			return nil, fmt.Errorf("failed to resolve org for app '%s': %w", opts.AppName, err)
 		}
 		return newWithUsermodeWireguard(ctx, wireguardConnectionParams{
-			appName: opts.AppName,
-			orgSlug: orgSlug,
+			appName:  opts.AppName,
+			orgSlug:  orgSlug,
+			timeouts: timeouts,
 		})
 	} else if flapsBaseURL == "" {
 		flapsBaseURL = "https://api.machines.dev"
@@ -87,7 +95,8 @@ func NewWithOptions(ctx context.Context, opts NewClientOpts) (*Client, error) {
 	if opts.Logger != nil {
 		logger = opts.Logger
 	}
-	httpClient, err := api.NewHTTPClient(logger, httptracing.NewTransport(http.DefaultTransport))
+	transport := pooledTransport(flapsUrl.Scheme+"://"+flapsUrl.Host, timeouts)
+	httpClient, err := api.NewHTTPClient(logger, httptracing.NewTransport(transport))
 	if err != nil {
 		return nil, fmt.Errorf("flaps: can't setup HTTP client to %s: %w", flapsUrl.String(), err)
 	}
@@ -101,25 +110,20 @@ func NewWithOptions(ctx context.Context, opts NewClientOpts) (*Client, error) {
 }
 
 func resolveOrgSlugForApp(ctx context.Context, app *api.AppCompact, appName string) (string, error) {
-	app, err := resolveApp(ctx, app, appName)
+	if app != nil {
+		return app.Organization.Slug, nil
+	}
+	basicApp, err := cache.GetAppBasic(ctx, appName)
 	if err != nil {
 		return "", err
 	}
-	return app.Organization.Slug, nil
-}
-
-func resolveApp(ctx context.Context, app *api.AppCompact, appName string) (*api.AppCompact, error) {
-	var err error
-	if app == nil {
-		client := client.FromContext(ctx).API()
-		app, err = client.GetAppCompact(ctx, appName)
-	}
-	return app, err
+	return basicApp.Organization.Slug, nil
 }
 
 type wireguardConnectionParams struct {
-	appName string
-	orgSlug string
+	appName  string
+	orgSlug  string
+	timeouts Timeouts
 }
 
 func newWithUsermodeWireguard(ctx context.Context, params wireguardConnectionParams) (*Client, error) {
@@ -136,11 +140,7 @@ func newWithUsermodeWireguard(ctx context.Context, params wireguardConnectionPar
 		return nil, fmt.Errorf("flaps: can't build tunnel for %s: %w", params.orgSlug, err)
 	}
 
-	transport := &http.Transport{
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return dialer.DialContext(ctx, network, addr)
-		},
-	}
+	transport := newTransport(params.timeouts, dialer.DialContext)
 
 	httpClient, err := api.NewHTTPClient(logger, httptracing.NewTransport(transport))
 	if err != nil {
@@ -354,6 +354,41 @@ func (f *Client) List(ctx context.Context, state string) ([]*api.Machine, error)
 	return out, nil
 }
 
+// ListCached returns the machines of the app like List(ctx, ""), reusing
+// those listed in the last cache.MachinesTTL when they didn't change since,
+// unless refresh is set. It returns when the machines were cached, or the zero
+// time when they were just listed. Commands polling machines should use List
+// instead.
+func (f *Client) ListCached(ctx context.Context, refresh bool) ([]*api.Machine, time.Time, error) {
+	lookups := cache.LookupsFromContext(ctx)
+	if lookups != nil && !refresh {
+		if machines, cachedAt := lookups.Machines(f.appName); machines != nil {
+			return machines, cachedAt, nil
+		}
+	}
+
+	machines, err := f.List(ctx, "")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if lookups != nil {
+		lookups.SetMachines(f.appName, machines)
+	}
+	return machines, time.Time{}, nil
+}
+
+// GetVolumes returns the volumes of the app, with the usage of their file
+// systems.
+func (f *Client) GetVolumes(ctx context.Context) ([]api.MachinesVolume, error) {
+	var out []api.MachinesVolume
+
+	err := f.sendRequest(ctx, http.MethodGet, "/v1/apps/{app}/volumes", nil, &out, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+	return out, nil
+}
+
 // ListActive returns only non-destroyed that aren't in a reserved process group.
 func (f *Client) ListActive(ctx context.Context) ([]*api.Machine, error) {
 	getEndpoint := ""
@@ -549,10 +584,36 @@ func (f *Client) UnCordon(ctx context.Context, machineID string) (err error) {
 	return nil
 }
 
+// SetMetadata sets the metadata key of the machine with machineID to value,
+// without updating its config nor restarting it.
+func (f *Client) SetMetadata(ctx context.Context, machineID, key, value string) error {
+	in := map[string]string{"value": value}
+
+	if err := f.sendRequest(ctx, http.MethodPost, fmt.Sprintf("/%s/metadata/%s", machineID, url.PathEscape(key)), in, nil, nil); err != nil {
+		return fmt.Errorf("failed to set metadata %s of VM %s: %w", key, machineID, err)
+	}
+	return nil
+}
+
+// DeleteMetadata removes the metadata key of the machine with machineID.
+func (f *Client) DeleteMetadata(ctx context.Context, machineID, key string) error {
+	if err := f.sendRequest(ctx, http.MethodDelete, fmt.Sprintf("/%s/metadata/%s", machineID, url.PathEscape(key)), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete metadata %s of VM %s: %w", key, machineID, err)
+	}
+	return nil
+}
+
 func (f *Client) sendRequest(ctx context.Context, method, endpoint string, in, out interface{}, headers map[string][]string) error {
 	timing := instrument.Flaps.Begin()
 	defer timing.End()
 
+	if method != http.MethodGet {
+		// The cached machines of the app may be about to change.
+		if lookups := cache.LookupsFromContext(ctx); lookups != nil {
+			lookups.ForgetMachines(f.appName)
+		}
+	}
+
 	req, err := f.NewRequest(ctx, method, endpoint, in, headers)
 	if err != nil {
 		return err
@@ -564,6 +625,8 @@ func (f *Client) sendRequest(ctx context.Context, method, endpoint string, in, o
 		return err
 	}
 	defer func() {
+		// Drain the body for the connection to be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
 		err := resp.Body.Close()
 		if err != nil {
 			terminal.Debugf("error closing response body: %v\n", err)
@@ -606,7 +669,11 @@ func (f *Client) NewRequest(ctx context.Context, method, path string, in interfa
 		headers = make(map[string][]string)
 	}
 
-	targetEndpoint, err := f.urlFromBaseUrl(fmt.Sprintf("/v1/apps/%s/machines%s", f.appName, path))
+	// Like with RawRequest, paths starting with /v1/ aren't machines endpoints
+	if !strings.HasPrefix(path, "/v1/") {
+		path = fmt.Sprintf("/v1/apps/%s/machines%s", f.appName, path)
+	}
+	targetEndpoint, err := f.urlFromBaseUrl(strings.ReplaceAll(path, "{app}", f.appName))
 	if err != nil {
 		return nil, err
 	}
@@ -631,6 +698,37 @@ func (f *Client) NewRequest(ctx context.Context, method, path string, in interfa
 	return req, nil
 }
 
+// RawRequest sends a request to the Machines API as is and returns the
+// response, whatever its status. Paths starting with /v1/ are resolved against
+// the API root, {app} being replaced with the app name; other paths are
+// relative to the app's machines endpoint.
+func (f *Client) RawRequest(ctx context.Context, method, path string, body io.Reader, headers http.Header) (*http.Response, error) {
+	if !strings.HasPrefix(path, "/v1/") {
+		path = fmt.Sprintf("/v1/apps/%s/machines%s", f.appName, path)
+	}
+	path = strings.ReplaceAll(path, "{app}", f.appName)
+
+	targetEndpoint, err := f.urlFromBaseUrl(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, targetEndpoint.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create new request, %w", err)
+	}
+	if headers != nil {
+		req.Header = headers.Clone()
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", api.AuthorizationHeader(f.authToken))
+	req.Header.Set("User-Agent", f.userAgent)
+
+	return f.httpClient.Do(req)
+}
+
 func handleAPIError(statusCode int, responseBody []byte) error {
 	switch statusCode / 100 {
 	case 1, 3:
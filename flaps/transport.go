@@ -0,0 +1,123 @@
+package flaps
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Timeouts configures the connections of clients to flaps. Zero fields default
+// to the FLY_FLAPS_DIAL_TIMEOUT, FLY_FLAPS_TLS_HANDSHAKE_TIMEOUT and
+// FLY_FLAPS_IDLE_TIMEOUT environment variables, then to DefaultTimeouts.
+type Timeouts struct {
+	// Dial bounds establishing a connection.
+	Dial time.Duration
+	// TLSHandshake bounds the TLS handshake of a connection.
+	TLSHandshake time.Duration
+	// Idle is how long an idle connection is kept for reuse.
+	Idle time.Duration
+}
+
+// DefaultTimeouts are the timeouts of clients to flaps unless configured
+// otherwise.
+var DefaultTimeouts = Timeouts{
+	Dial:         30 * time.Second,
+	TLSHandshake: 10 * time.Second,
+	Idle:         90 * time.Second,
+}
+
+// maxIdleConnsPerHost is how many idle connections to a flaps host are kept
+// for reuse, enough for the concurrency of bulk machine operations.
+const maxIdleConnsPerHost = 32
+
+type transportKey struct {
+	target   string
+	timeouts Timeouts
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = map[transportKey]*http.Transport{}
+)
+
+// resolveTimeouts fills the zero fields of t from the environment, then from
+// DefaultTimeouts.
+func resolveTimeouts(t Timeouts) (Timeouts, error) {
+	fields := []struct {
+		value *time.Duration
+		env   string
+		def   time.Duration
+	}{
+		{&t.Dial, "FLY_FLAPS_DIAL_TIMEOUT", DefaultTimeouts.Dial},
+		{&t.TLSHandshake, "FLY_FLAPS_TLS_HANDSHAKE_TIMEOUT", DefaultTimeouts.TLSHandshake},
+		{&t.Idle, "FLY_FLAPS_IDLE_TIMEOUT", DefaultTimeouts.Idle},
+	}
+
+	for _, f := range fields {
+		if *f.value != 0 {
+			continue
+		}
+		*f.value = f.def
+		if s := os.Getenv(f.env); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				return t, fmt.Errorf("invalid %s '%s', expected a positive duration like 30s", f.env, s)
+			}
+			*f.value = d
+		}
+	}
+	return t, nil
+}
+
+// pooledTransport returns the transport shared by the clients to target, so
+// that bulk machine operations reuse connections rather than opening new ones.
+func pooledTransport(target string, timeouts Timeouts) *http.Transport {
+	key := transportKey{target: target, timeouts: timeouts}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if t, ok := transports[key]; ok {
+		return t
+	}
+
+	t := newTransport(timeouts, nil)
+	transports[key] = t
+	return t
+}
+
+// newTransport returns a transport establishing connections with dial, or
+// directly when nil. Transports dialing through the agent aren't pooled: their
+// dialer is tied to a tunnel which may not outlive the client.
+func newTransport(timeouts Timeouts, dial func(ctx context.Context, network, addr string) (net.Conn, error)) *http.Transport {
+	var proxy func(*http.Request) (*url.URL, error)
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: timeouts.Dial, KeepAlive: 30 * time.Second}).DialContext
+		proxy = http.ProxyFromEnvironment
+	} else {
+		tunneled := dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeouts.Dial)
+			defer cancel()
+			return tunneled(ctx, network, addr)
+		}
+	}
+
+	return &http.Transport{
+		Proxy:       proxy,
+		DialContext: dial,
+		// Custom dialers disable HTTP/2 unless forced; flaps negotiates it
+		// over TLS, multiplexing requests over a single connection.
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       timeouts.Idle,
+		TLSHandshakeTimeout:   timeouts.TLSHandshake,
+		ExpectContinueTimeout: time.Second,
+	}
+}
@@ -0,0 +1,50 @@
+package flaps
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTimeouts(t *testing.T) {
+	t.Setenv("FLY_FLAPS_DIAL_TIMEOUT", "5s")
+
+	timeouts, err := resolveTimeouts(Timeouts{Idle: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, Timeouts{Dial: 5 * time.Second, TLSHandshake: DefaultTimeouts.TLSHandshake, Idle: time.Minute}, timeouts)
+
+	t.Setenv("FLY_FLAPS_DIAL_TIMEOUT", "soon")
+	_, err = resolveTimeouts(Timeouts{})
+	assert.ErrorContains(t, err, "FLY_FLAPS_DIAL_TIMEOUT")
+}
+
+func TestPooledTransport(t *testing.T) {
+	a := pooledTransport("https://api.machines.dev", DefaultTimeouts)
+	b := pooledTransport("https://api.machines.dev", DefaultTimeouts)
+	assert.Same(t, a, b)
+	assert.True(t, a.ForceAttemptHTTP2)
+
+	other := pooledTransport("https://example.com", DefaultTimeouts)
+	assert.NotSame(t, a, other)
+}
+
+func TestNewTransportDialer(t *testing.T) {
+	dialed := 0
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed++
+		return nil, errors.New("tunnel closed")
+	}
+
+	a := newTransport(DefaultTimeouts, dial)
+	b := newTransport(DefaultTimeouts, dial)
+	assert.NotSame(t, a, b, "transports dialing through the agent aren't shared")
+
+	_, err := a.DialContext(context.Background(), "tcp", "[fdaa::3]:4280")
+	assert.Error(t, err)
+	assert.Equal(t, 1, dialed)
+}
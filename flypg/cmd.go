@@ -5,6 +5,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/api"
@@ -119,6 +121,74 @@ func (pc *Command) ListEvents(ctx context.Context, leaderIP string, flagsName []
 	return nil
 }
 
+// replicationLagQuery lists the standbys streaming from the primary, with
+// their replay lag in seconds and bytes. It can't contain single quotes.
+const replicationLagQuery = "SELECT client_addr, state, " +
+	"COALESCE(EXTRACT(EPOCH FROM replay_lag), 0), " +
+	"COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn), 0) " +
+	"FROM pg_stat_replication"
+
+// ReplicationLag returns the lag of the standbys replicating from the
+// primary at leaderIP, read from pg_stat_replication.
+func (pc *Command) ReplicationLag(ctx context.Context, leaderIP string) ([]ReplicaLag, error) {
+	cmd := fmt.Sprintf("gosu postgres psql -p 5433 -At -F , -c '%s'", replicationLagQuery)
+
+	resp, err := ssh.RunSSHCommand(ctx, pc.app, pc.dialer, leaderIP, cmd, ssh.DefaultSshUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseReplicationLag(resp)
+}
+
+// RunSQL runs statements in a transaction on database as the postgres
+// superuser of the primary at leaderIP. Statements can't contain single
+// quotes.
+func (pc *Command) RunSQL(ctx context.Context, leaderIP, database string, statements []string) error {
+	// Notices go to stderr, which fails SSH commands
+	cmd := fmt.Sprintf("gosu postgres psql -p 5433 -d %s -v ON_ERROR_STOP=1 -1 -q -c 'SET client_min_messages TO error'", database)
+	for _, stmt := range statements {
+		if strings.Contains(stmt, "'") {
+			return fmt.Errorf("statement contains a single quote: %s", stmt)
+		}
+		cmd += fmt.Sprintf(" -c '%s'", stmt)
+	}
+
+	_, err := ssh.RunSSHCommand(ctx, pc.app, pc.dialer, leaderIP, cmd, ssh.DefaultSshUsername)
+	return err
+}
+
+func parseReplicationLag(out []byte) ([]ReplicaLag, error) {
+	var lags []ReplicaLag
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected pg_stat_replication row: %s", line)
+		}
+
+		lagSeconds, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay lag %q: %w", fields[2], err)
+		}
+		behindBytes, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay lsn diff %q: %w", fields[3], err)
+		}
+
+		lags = append(lags, ReplicaLag{
+			Address:     fields[0],
+			State:       fields[1],
+			LagSeconds:  lagSeconds,
+			BehindBytes: int64(behindBytes),
+		})
+	}
+	return lags, nil
+}
+
 // encodeCommand will base64 encode a command string so it can be passed
 // in with  exec.Command.
 func encodeCommand(command string) string {
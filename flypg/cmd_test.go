@@ -0,0 +1,24 @@
+package flypg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplicationLag(t *testing.T) {
+	lags, err := parseReplicationLag([]byte("fdaa:0:1::2,streaming,0.004512,0\nfdaa:0:1::3,catchup,12.5,1048576\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []ReplicaLag{
+		{Address: "fdaa:0:1::2", State: "streaming", LagSeconds: 0.004512},
+		{Address: "fdaa:0:1::3", State: "catchup", LagSeconds: 12.5, BehindBytes: 1048576},
+	}, lags)
+
+	lags, err = parseReplicationLag([]byte("\n"))
+	require.NoError(t, err)
+	assert.Empty(t, lags)
+
+	_, err = parseReplicationLag([]byte("fdaa:0:1::2,streaming"))
+	assert.Error(t, err)
+}
@@ -342,7 +342,7 @@ func (l *Launcher) getPostgresConfig(config *CreateClusterInput) *api.MachineCon
 	machineConfig.Metadata = map[string]string{
 		api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
 		api.MachineConfigMetadataKeyFlyManagedPostgres: "true",
-		"managed-by-fly-deploy":                        "true",
+		api.MachineConfigMetadataKeyManagedByFlyDeploy: "true",
 	}
 
 	// Restart policy
@@ -72,6 +72,15 @@ type ReplicationStat struct {
 	Name string `json:"name"`
 	Diff int    `json:"diff"`
 }
+
+// ReplicaLag is how far a standby streaming from the primary is behind it.
+type ReplicaLag struct {
+	Address     string  `json:"address"`
+	State       string  `json:"state"`
+	LagSeconds  float64 `json:"lag_seconds"`
+	BehindBytes int64   `json:"behind_bytes"`
+}
+
 type ReplicationStatsResponse struct {
 	Result []ReplicationStat
 }
@@ -279,6 +279,14 @@ func (v *AppDataOrganization) GetRawSlug() string { return v.RawSlug }
 // GetPaidPlan returns AppDataOrganization.PaidPlan, and is useful for accessing the field via an interface.
 func (v *AppDataOrganization) GetPaidPlan() bool { return v.PaidPlan }
 
+type BillingStatus string
+
+const (
+	BillingStatusCurrent        BillingStatus = "CURRENT"
+	BillingStatusPastDue        BillingStatus = "PAST_DUE"
+	BillingStatusSourceRequired BillingStatus = "SOURCE_REQUIRED"
+)
+
 type BuildFinalImageInput struct {
 	// Sha256 id of docker image
 	Id string `json:"id"`
@@ -1056,6 +1064,87 @@ type FlyctlDeployGetLatestImageResponse struct {
 // GetApp returns FlyctlDeployGetLatestImageResponse.App, and is useful for accessing the field via an interface.
 func (v *FlyctlDeployGetLatestImageResponse) GetApp() FlyctlDeployGetLatestImageApp { return v.App }
 
+// FlyctlReleasesConfigsApp includes the requested fields of the GraphQL type App.
+type FlyctlReleasesConfigsApp struct {
+	// Individual releases for this application, without any config processing
+	ReleasesUnprocessed FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnection `json:"releasesUnprocessed"`
+}
+
+// GetReleasesUnprocessed returns FlyctlReleasesConfigsApp.ReleasesUnprocessed, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsApp) GetReleasesUnprocessed() FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnection {
+	return v.ReleasesUnprocessed
+}
+
+// FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnection includes the requested fields of the GraphQL type ReleaseUnprocessedConnection.
+// The GraphQL type's documentation follows.
+//
+// The connection type for ReleaseUnprocessed.
+type FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnection struct {
+	// A list of nodes.
+	Nodes []FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed `json:"nodes"`
+}
+
+// GetNodes returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnection) GetNodes() []FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed {
+	return v.Nodes
+}
+
+// FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed includes the requested fields of the GraphQL type ReleaseUnprocessed.
+type FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed struct {
+	// The version of the release
+	Version int `json:"version"`
+	// Docker image URI
+	ImageRef         string      `json:"imageRef"`
+	CreatedAt        time.Time   `json:"createdAt"`
+	ConfigDefinition interface{} `json:"configDefinition"`
+	// Docker image
+	Image FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessedImage `json:"image"`
+}
+
+// GetVersion returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.Version, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetVersion() int {
+	return v.Version
+}
+
+// GetImageRef returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.ImageRef, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetImageRef() string {
+	return v.ImageRef
+}
+
+// GetCreatedAt returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.CreatedAt, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetCreatedAt() time.Time {
+	return v.CreatedAt
+}
+
+// GetConfigDefinition returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.ConfigDefinition, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetConfigDefinition() interface{} {
+	return v.ConfigDefinition
+}
+
+// GetImage returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.Image, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetImage() FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessedImage {
+	return v.Image
+}
+
+// FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessedImage includes the requested fields of the GraphQL type Image.
+type FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessedImage struct {
+	Digest string `json:"digest"`
+}
+
+// GetDigest returns FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessedImage.Digest, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessedImage) GetDigest() string {
+	return v.Digest
+}
+
+// FlyctlReleasesConfigsResponse is returned by FlyctlReleasesConfigs on success.
+type FlyctlReleasesConfigsResponse struct {
+	// Find an app by name
+	App FlyctlReleasesConfigsApp `json:"app"`
+}
+
+// GetApp returns FlyctlReleasesConfigsResponse.App, and is useful for accessing the field via an interface.
+func (v *FlyctlReleasesConfigsResponse) GetApp() FlyctlReleasesConfigsApp { return v.App }
+
 // GetAddOnAddOn includes the requested fields of the GraphQL type AddOn.
 type GetAddOnAddOn struct {
 	Id string `json:"id"`
@@ -1556,6 +1645,42 @@ func (v *GetNearestRegionResponse) GetNearestRegion() GetNearestRegionNearestReg
 	return v.NearestRegion
 }
 
+// GetOrganizationBillingOrganization includes the requested fields of the GraphQL type Organization.
+type GetOrganizationBillingOrganization struct {
+	Id string `json:"id"`
+	// Unique organization slug
+	Slug              string            `json:"slug"`
+	BillingStatus     BillingStatus     `json:"billingStatus"`
+	IsCreditCardSaved bool              `json:"isCreditCardSaved"`
+	Trust             OrganizationTrust `json:"trust"`
+}
+
+// GetId returns GetOrganizationBillingOrganization.Id, and is useful for accessing the field via an interface.
+func (v *GetOrganizationBillingOrganization) GetId() string { return v.Id }
+
+// GetSlug returns GetOrganizationBillingOrganization.Slug, and is useful for accessing the field via an interface.
+func (v *GetOrganizationBillingOrganization) GetSlug() string { return v.Slug }
+
+// GetBillingStatus returns GetOrganizationBillingOrganization.BillingStatus, and is useful for accessing the field via an interface.
+func (v *GetOrganizationBillingOrganization) GetBillingStatus() BillingStatus { return v.BillingStatus }
+
+// GetIsCreditCardSaved returns GetOrganizationBillingOrganization.IsCreditCardSaved, and is useful for accessing the field via an interface.
+func (v *GetOrganizationBillingOrganization) GetIsCreditCardSaved() bool { return v.IsCreditCardSaved }
+
+// GetTrust returns GetOrganizationBillingOrganization.Trust, and is useful for accessing the field via an interface.
+func (v *GetOrganizationBillingOrganization) GetTrust() OrganizationTrust { return v.Trust }
+
+// GetOrganizationBillingResponse is returned by GetOrganizationBilling on success.
+type GetOrganizationBillingResponse struct {
+	// Find an organization by ID
+	Organization GetOrganizationBillingOrganization `json:"organization"`
+}
+
+// GetOrganization returns GetOrganizationBillingResponse.Organization, and is useful for accessing the field via an interface.
+func (v *GetOrganizationBillingResponse) GetOrganization() GetOrganizationBillingOrganization {
+	return v.Organization
+}
+
 // GetOrganizationOrganization includes the requested fields of the GraphQL type Organization.
 type GetOrganizationOrganization struct {
 	Id string `json:"id"`
@@ -1885,6 +2010,21 @@ func (v *MigrateMachinesCreateReleaseResponse) GetCreateRelease() MigrateMachine
 	return v.CreateRelease
 }
 
+type OrganizationTrust string
+
+const (
+	// Organization cannot use our services
+	OrganizationTrustBanned OrganizationTrust = "BANNED"
+	// Organization proved that it's safe to use our services
+	OrganizationTrustHigh OrganizationTrust = "HIGH"
+	// Organization has to prove that is not fraud over time but can use our services
+	OrganizationTrustLow OrganizationTrust = "LOW"
+	// Organization has limited access to our service
+	OrganizationTrustRestricted OrganizationTrust = "RESTRICTED"
+	// We haven't set a trust level yet
+	OrganizationTrustUnknown OrganizationTrust = "UNKNOWN"
+)
+
 type PlatformVersionEnum string
 
 const (
@@ -2548,6 +2688,18 @@ type __FlyctlDeployGetLatestImageInput struct {
 // GetAppName returns __FlyctlDeployGetLatestImageInput.AppName, and is useful for accessing the field via an interface.
 func (v *__FlyctlDeployGetLatestImageInput) GetAppName() string { return v.AppName }
 
+// __FlyctlReleasesConfigsInput is used internally by genqlient
+type __FlyctlReleasesConfigsInput struct {
+	AppName string `json:"appName"`
+	Limit   int    `json:"limit"`
+}
+
+// GetAppName returns __FlyctlReleasesConfigsInput.AppName, and is useful for accessing the field via an interface.
+func (v *__FlyctlReleasesConfigsInput) GetAppName() string { return v.AppName }
+
+// GetLimit returns __FlyctlReleasesConfigsInput.Limit, and is useful for accessing the field via an interface.
+func (v *__FlyctlReleasesConfigsInput) GetLimit() int { return v.Limit }
+
 // __GetAddOnInput is used internally by genqlient
 type __GetAddOnInput struct {
 	Name string `json:"name"`
@@ -2604,6 +2756,14 @@ func (v *__GetAppsByRoleInput) GetRole() string { return v.Role }
 // GetOrganizationId returns __GetAppsByRoleInput.OrganizationId, and is useful for accessing the field via an interface.
 func (v *__GetAppsByRoleInput) GetOrganizationId() string { return v.OrganizationId }
 
+// __GetOrganizationBillingInput is used internally by genqlient
+type __GetOrganizationBillingInput struct {
+	Slug string `json:"slug"`
+}
+
+// GetSlug returns __GetOrganizationBillingInput.Slug, and is useful for accessing the field via an interface.
+func (v *__GetOrganizationBillingInput) GetSlug() string { return v.Slug }
+
 // __GetOrganizationInput is used internally by genqlient
 type __GetOrganizationInput struct {
 	Slug string `json:"slug"`
@@ -3137,6 +3297,50 @@ query FlyctlDeployGetLatestImage ($appName: String!) {
 	return &data, err
 }
 
+func FlyctlReleasesConfigs(
+	ctx context.Context,
+	client graphql.Client,
+	appName string,
+	limit int,
+) (*FlyctlReleasesConfigsResponse, error) {
+	req := &graphql.Request{
+		OpName: "FlyctlReleasesConfigs",
+		Query: `
+query FlyctlReleasesConfigs ($appName: String!, $limit: Int!) {
+	app(name: $appName) {
+		releasesUnprocessed(first: $limit) {
+			nodes {
+				version
+				imageRef
+				createdAt
+				configDefinition
+				image {
+					digest
+				}
+			}
+		}
+	}
+}
+`,
+		Variables: &__FlyctlReleasesConfigsInput{
+			AppName: appName,
+			Limit:   limit,
+		},
+	}
+	var err error
+
+	var data FlyctlReleasesConfigsResponse
+	resp := &graphql.Response{Data: &data}
+
+	err = client.MakeRequest(
+		ctx,
+		req,
+		resp,
+	)
+
+	return &data, err
+}
+
 func GetAddOn(
 	ctx context.Context,
 	client graphql.Client,
@@ -3466,6 +3670,42 @@ query GetOrganization ($slug: String!) {
 	return &data, err
 }
 
+func GetOrganizationBilling(
+	ctx context.Context,
+	client graphql.Client,
+	slug string,
+) (*GetOrganizationBillingResponse, error) {
+	req := &graphql.Request{
+		OpName: "GetOrganizationBilling",
+		Query: `
+query GetOrganizationBilling ($slug: String!) {
+	organization(slug: $slug) {
+		id
+		slug
+		billingStatus
+		isCreditCardSaved
+		trust
+	}
+}
+`,
+		Variables: &__GetOrganizationBillingInput{
+			Slug: slug,
+		},
+	}
+	var err error
+
+	var data GetOrganizationBillingResponse
+	resp := &graphql.Response{Data: &data}
+
+	err = client.MakeRequest(
+		ctx,
+		req,
+		resp,
+	)
+
+	return &data, err
+}
+
 func ListAddOnPlans(
 	ctx context.Context,
 	client graphql.Client,
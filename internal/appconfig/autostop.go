@@ -0,0 +1,171 @@
+package appconfig
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"github.com/superfly/flyctl/api"
+)
+
+// Autostop tunes how the proxy stops idle machines, on top of the
+// auto_stop_machines, auto_start_machines and min_machines_running settings
+// of services, which min_machines_running applies to the primary region only:
+//
+//	[autostop]
+//	  exclude_processes = ["worker"]
+//
+//	  [autostop.min_machines_per_region]
+//	    ams = 1
+//	    ord = 2
+type Autostop struct {
+	// ExcludeProcesses are the process groups whose machines never stop.
+	ExcludeProcesses []string `toml:"exclude_processes,omitempty" json:"exclude_processes,omitempty"`
+
+	// MinMachinesPerRegion are the machines of each process group kept
+	// running in regions, by region.
+	MinMachinesPerRegion map[string]int `toml:"min_machines_per_region,omitempty" json:"min_machines_per_region,omitempty"`
+}
+
+// IsEmpty reports whether a tunes nothing.
+func (a *Autostop) IsEmpty() bool {
+	return a == nil || (len(a.ExcludeProcesses) == 0 && len(a.MinMachinesPerRegion) == 0)
+}
+
+// Excludes reports whether the machines of group never stop.
+func (a *Autostop) Excludes(group string) bool {
+	return a != nil && lo.Contains(a.ExcludeProcesses, group)
+}
+
+func (cfg *Config) validateAutostopSection() (extraInfo string, err error) {
+	if cfg.Autostop == nil {
+		return
+	}
+	processNames := cfg.ProcessNames()
+	for _, group := range cfg.Autostop.ExcludeProcesses {
+		if !lo.Contains(processNames, group) {
+			extraInfo += fmt.Sprintf("Autostop excludes process group '%s', which isn't defined in [processes]\n", group)
+			err = ValidationError
+		}
+	}
+	for region, min := range cfg.Autostop.MinMachinesPerRegion {
+		if min < 0 {
+			extraInfo += fmt.Sprintf("Autostop keeps %d machines running in region '%s', which can't be negative\n", min, region)
+			err = ValidationError
+		}
+	}
+	return
+}
+
+// AutostopPinned returns the IDs of the machines kept running to honor the
+// per region minimums of [autostop]. Of the machines of a process group in a
+// region, it keeps the started ones first, then the ones of lower IDs.
+func (c *Config) AutostopPinned(machines []*api.Machine) map[string]bool {
+	pinned := map[string]bool{}
+	if c.Autostop.IsEmpty() {
+		return pinned
+	}
+
+	byGroupRegion := map[[2]string][]*api.Machine{}
+	for _, m := range machines {
+		key := [2]string{m.ProcessGroup(), m.Region}
+		byGroupRegion[key] = append(byGroupRegion[key], m)
+	}
+
+	for key, ms := range byGroupRegion {
+		min := c.Autostop.MinMachinesPerRegion[key[1]]
+		if min <= 0 {
+			continue
+		}
+		sort.Slice(ms, func(i, j int) bool {
+			iStarted, jStarted := ms[i].State == api.MachineStateStarted, ms[j].State == api.MachineStateStarted
+			if iStarted != jStarted {
+				return iStarted
+			}
+			return ms[i].ID < ms[j].ID
+		})
+		for _, m := range lo.Slice(ms, 0, min) {
+			pinned[m.ID] = true
+		}
+	}
+	return pinned
+}
+
+// DisableAutostop turns off autostop for the services of mConfig, keeping
+// its machine running.
+func DisableAutostop(mConfig *api.MachineConfig) {
+	for i := range mConfig.Services {
+		mConfig.Services[i].Autostop = api.Pointer(false)
+	}
+}
+
+// AutostopPolicy is the autostop policy in effect for the machines of a
+// process group, as set on their services.
+type AutostopPolicy struct {
+	ProcessGroup string `json:"process_group"`
+	// Autostop and Autostart are "on", "off" or "mixed" when they differ
+	// across machines.
+	Autostop           string `json:"autostop"`
+	Autostart          string `json:"autostart"`
+	MinMachinesRunning int    `json:"min_machines_running"`
+	// KeptRunning counts the machines never stopped, by region.
+	KeptRunning map[string]int `json:"kept_running,omitempty"`
+}
+
+// EffectiveAutostop returns the autostop policies of the process groups of
+// machines, sorted by group. Machines without services are left out, as the
+// proxy never stops them.
+func EffectiveAutostop(machines []*api.Machine) []AutostopPolicy {
+	type state struct {
+		policy        AutostopPolicy
+		stops, starts []bool
+	}
+	byGroup := map[string]*state{}
+	for _, m := range machines {
+		if m.Config == nil || len(m.Config.Services) == 0 {
+			continue
+		}
+		group := m.ProcessGroup()
+		s, ok := byGroup[group]
+		if !ok {
+			s = &state{policy: AutostopPolicy{ProcessGroup: group, KeptRunning: map[string]int{}}}
+			byGroup[group] = s
+		}
+
+		var stops, starts bool
+		for _, svc := range m.Config.Services {
+			stops = stops || lo.FromPtr(svc.Autostop)
+			starts = starts || lo.FromPtr(svc.Autostart)
+			s.policy.MinMachinesRunning = lo.Max([]int{s.policy.MinMachinesRunning, lo.FromPtr(svc.MinMachinesRunning)})
+		}
+		if !stops {
+			s.policy.KeptRunning[m.Region]++
+		}
+		s.stops = append(s.stops, stops)
+		s.starts = append(s.starts, starts)
+	}
+
+	onOff := func(values []bool) string {
+		switch {
+		case !lo.Contains(values, false):
+			return "on"
+		case !lo.Contains(values, true):
+			return "off"
+		default:
+			return "mixed"
+		}
+	}
+
+	policies := make([]AutostopPolicy, 0, len(byGroup))
+	for _, s := range byGroup {
+		s.policy.Autostop = onOff(s.stops)
+		s.policy.Autostart = onOff(s.starts)
+		// Machines of groups which never stop aren't kept running by policy
+		if s.policy.Autostop == "off" {
+			s.policy.KeptRunning = nil
+		}
+		policies = append(policies, s.policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ProcessGroup < policies[j].ProcessGroup })
+	return policies
+}
@@ -0,0 +1,93 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+)
+
+func autostopMachine(id, group, region, state string, autostop bool) *api.Machine {
+	return &api.Machine{
+		ID:     id,
+		Region: region,
+		State:  state,
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: group},
+			Services: []api.MachineService{{InternalPort: 8080, Autostop: api.Pointer(autostop), Autostart: api.Pointer(true)}},
+		},
+	}
+}
+
+func TestAutostopPinned(t *testing.T) {
+	machines := []*api.Machine{
+		autostopMachine("m4", "app", "ams", api.MachineStateStopped, true),
+		autostopMachine("m3", "app", "ams", api.MachineStateStarted, true),
+		autostopMachine("m1", "app", "ams", api.MachineStateStopped, true),
+		autostopMachine("m2", "app", "ord", api.MachineStateStopped, true),
+		autostopMachine("m5", "worker", "ams", api.MachineStateStopped, true),
+	}
+
+	cfg := NewConfig()
+	assert.Empty(t, cfg.AutostopPinned(machines))
+
+	cfg.Autostop = &Autostop{MinMachinesPerRegion: map[string]int{"ams": 2, "ord": 3}}
+	assert.Equal(t, map[string]bool{"m3": true, "m1": true, "m2": true, "m5": true}, cfg.AutostopPinned(machines))
+}
+
+func TestAutostopExcludes(t *testing.T) {
+	var a *Autostop
+	assert.False(t, a.Excludes("app"))
+	assert.True(t, a.IsEmpty())
+
+	a = &Autostop{ExcludeProcesses: []string{"worker"}}
+	assert.True(t, a.Excludes("worker"))
+	assert.False(t, a.Excludes("app"))
+	assert.False(t, a.IsEmpty())
+}
+
+func TestToMachineConfig_autostopExcluded(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/tomachine-services.toml")
+	require.NoError(t, err)
+	cfg.Autostop = &Autostop{ExcludeProcesses: []string{cfg.DefaultProcessName()}}
+
+	got, err := cfg.ToMachineConfig("", nil)
+	require.NoError(t, err)
+	for _, s := range got.Services {
+		assert.Equal(t, api.Pointer(false), s.Autostop)
+	}
+}
+
+func TestValidateAutostopSection(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Processes = map[string]string{"app": "bin/server", "worker": "bin/worker"}
+	cfg.platformVersion = MachinesPlatform
+	cfg.Autostop = &Autostop{ExcludeProcesses: []string{"worker"}, MinMachinesPerRegion: map[string]int{"ams": 1}}
+
+	extraInfo, err := cfg.validateAutostopSection()
+	assert.NoError(t, err)
+	assert.Empty(t, extraInfo)
+
+	cfg.Autostop = &Autostop{ExcludeProcesses: []string{"cron"}, MinMachinesPerRegion: map[string]int{"ams": -1}}
+	extraInfo, err = cfg.validateAutostopSection()
+	assert.ErrorIs(t, err, ValidationError)
+	assert.Contains(t, extraInfo, "process group 'cron'")
+	assert.Contains(t, extraInfo, "region 'ams'")
+}
+
+func TestEffectiveAutostop(t *testing.T) {
+	machines := []*api.Machine{
+		autostopMachine("m1", "app", "ams", api.MachineStateStarted, true),
+		autostopMachine("m2", "app", "ams", api.MachineStateStarted, false),
+		autostopMachine("m3", "app", "ord", api.MachineStateStopped, true),
+		autostopMachine("m4", "worker", "ams", api.MachineStateStarted, false),
+		{ID: "m5", Region: "ams", Config: &api.MachineConfig{}},
+	}
+	machines[0].Config.Services[0].MinMachinesRunning = api.Pointer(1)
+
+	assert.Equal(t, []AutostopPolicy{
+		{ProcessGroup: "app", Autostop: "mixed", Autostart: "on", MinMachinesRunning: 1, KeptRunning: map[string]int{"ams": 1}},
+		{ProcessGroup: "worker", Autostop: "off", Autostart: "on"},
+	}, EffectiveAutostop(machines))
+}
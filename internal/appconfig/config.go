@@ -43,7 +43,10 @@ type Config struct {
 	Experimental *Experimental     `toml:"experimental,omitempty" json:"experimental,omitempty"`
 	Build        *Build            `toml:"build,omitempty" json:"build,omitempty"`
 	Deploy       *Deploy           `toml:"deploy, omitempty" json:"deploy,omitempty"`
+	Init         *Init             `toml:"init,omitempty" json:"init,omitempty"`
+	Seed         *Seed             `toml:"seed,omitempty" json:"seed,omitempty"`
 	Env          map[string]string `toml:"env,omitempty" json:"env,omitempty"`
+	Metadata     map[string]string `toml:"metadata,omitempty" json:"metadata,omitempty"`
 
 	// Fields that are process group aware must come after Processes
 	Processes   map[string]string         `toml:"processes,omitempty" json:"processes,omitempty"`
@@ -51,6 +54,9 @@ type Config struct {
 	HTTPService *HTTPService              `toml:"http_service,omitempty" json:"http_service,omitempty"`
 	Services    []Service                 `toml:"services,omitempty" json:"services,omitempty"`
 	Checks      map[string]*ToplevelCheck `toml:"checks,omitempty" json:"checks,omitempty"`
+	Sidecars    []Sidecar                 `toml:"sidecars,omitempty" json:"sidecars,omitempty"`
+	SmokeTests  []SmokeTest               `toml:"smoke_tests,omitempty" json:"smoke_tests,omitempty"`
+	Autostop    *Autostop                 `toml:"autostop,omitempty" json:"autostop,omitempty"`
 
 	// Others, less important.
 	Statics []Static            `toml:"statics,omitempty" json:"statics,omitempty"`
@@ -79,6 +85,15 @@ type Deploy struct {
 	Strategy       string `toml:"strategy,omitempty" json:"strategy,omitempty"`
 }
 
+// Init lists one-shot commands run before the main process of machines on
+// every boot, e.g. for migrations or fixing volume permissions. They run from
+// a /bin/sh script replacing the image's ENTRYPOINT, so they require an image
+// with /bin/sh and the entrypoint to run last set in [experimental] entrypoint.
+type Init struct {
+	Commands  []string `toml:"commands,omitempty" json:"commands,omitempty"`
+	Processes []string `toml:"processes,omitempty" json:"processes,omitempty"`
+}
+
 type Static struct {
 	GuestPath string `toml:"guest_path" json:"guest_path,omitempty" validate:"required"`
 	UrlPrefix string `toml:"url_prefix" json:"url_prefix,omitempty" validate:"required"`
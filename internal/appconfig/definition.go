@@ -38,5 +38,9 @@ func (c *Config) SanitizedDefinition() map[string]any {
 	delete(definition, "primary_region")
 	delete(definition, "http_service")
 	delete(definition, "console_command")
+	delete(definition, "init")
+	delete(definition, "sidecars")
+	delete(definition, "smoke_tests")
+	delete(definition, "seed")
 	return definition
 }
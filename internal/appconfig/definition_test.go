@@ -246,6 +246,9 @@ func TestToDefinition(t *testing.T) {
 		"env": map[string]any{
 			"FOO": "BAR",
 		},
+		"metadata": map[string]any{
+			"team": "platform",
+		},
 		"metrics": map[string]any{
 			"port": int64(9999),
 			"path": "/metrics",
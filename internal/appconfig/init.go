@@ -0,0 +1,134 @@
+package appconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/superfly/flyctl/api"
+	"golang.org/x/exp/slices"
+)
+
+// initScriptPath is where the script running the [init] commands is written
+// on machines.
+const initScriptPath = "/.fly/init.sh"
+
+var initScriptEntrypoint = []string{"/bin/sh", initScriptPath}
+
+// initScript returns a shell script running commands in order, stopping at
+// the first failure, then starting sidecars in the background and replacing
+// itself with the main process.
+func initScript(commands []string, sidecars []Sidecar) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+	for _, cmd := range commands {
+		b.WriteString(cmd + "\n")
+	}
+	for _, sc := range sidecars {
+		b.WriteString(sc.script())
+	}
+	b.WriteString("exec \"$@\"\n")
+	return b.String()
+}
+
+// setInitScript wraps the entrypoint of mConfig with the script running the
+// [init] commands and [[sidecars]], which then execs the entrypoint and the
+// command. The image's ENTRYPOINT is replaced, so validation requires an
+// explicit [experimental] entrypoint, and the image must ship /bin/sh.
+// Machines previously set up with a script no longer needed are unwrapped.
+func (c *Config) setInitScript(mConfig *api.MachineConfig) {
+	mConfig.Files = lo.Reject(mConfig.Files, func(f *api.File, _ int) bool {
+		return f.GuestPath == initScriptPath
+	})
+	if len(mConfig.Files) == 0 {
+		mConfig.Files = nil
+	}
+	if len(mConfig.Init.Entrypoint) >= len(initScriptEntrypoint) &&
+		slices.Equal(mConfig.Init.Entrypoint[:len(initScriptEntrypoint)], initScriptEntrypoint) {
+		mConfig.Init.Entrypoint = mConfig.Init.Entrypoint[len(initScriptEntrypoint):]
+		if len(mConfig.Init.Entrypoint) == 0 {
+			mConfig.Init.Entrypoint = nil
+		}
+	}
+
+	delete(mConfig.Metadata, api.MachineConfigMetadataKeyFlySidecars)
+
+	var commands []string
+	if c.Init != nil {
+		commands = c.Init.Commands
+	}
+	if len(commands) == 0 && len(c.Sidecars) == 0 {
+		return
+	}
+
+	if len(c.Sidecars) > 0 {
+		// Recorded so `fly machine status` can report on them
+		sidecars, _ := json.Marshal(c.Sidecars)
+		mConfig.Metadata = lo.Assign(mConfig.Metadata, map[string]string{
+			api.MachineConfigMetadataKeyFlySidecars: string(sidecars),
+		})
+	}
+
+	script := base64.StdEncoding.EncodeToString([]byte(initScript(commands, c.Sidecars)))
+	mConfig.Files = append(mConfig.Files, &api.File{
+		GuestPath: initScriptPath,
+		RawValue:  &script,
+	})
+	mConfig.Init.Entrypoint = append(slices.Clone(initScriptEntrypoint), mConfig.Init.Entrypoint...)
+}
+
+func (cfg *Config) validateInitSection() (extraInfo string, err error) {
+	if (cfg.Init == nil || len(cfg.Init.Commands) == 0) && len(cfg.Sidecars) == 0 {
+		return
+	}
+
+	if cfg.Experimental != nil && len(cfg.Experimental.Exec) > 0 {
+		extraInfo += "The [init] and [[sidecars]] sections can't be used along with [experimental] exec\n"
+		err = ValidationError
+	}
+
+	seen := map[string]bool{}
+	for _, sc := range cfg.Sidecars {
+		switch {
+		case sc.Name == "":
+			extraInfo += "Sidecars must have a name\n"
+			err = ValidationError
+		case seen[sc.Name]:
+			extraInfo += fmt.Sprintf("Sidecar '%s' is defined more than once\n", sc.Name)
+			err = ValidationError
+		}
+		seen[sc.Name] = true
+
+		if sc.Command == "" {
+			extraInfo += fmt.Sprintf("Sidecar '%s' has no command\n", sc.Name)
+			err = ValidationError
+		}
+		if !slices.Contains(sidecarRestartPolicies, sc.RestartPolicy()) {
+			extraInfo += fmt.Sprintf("Sidecar '%s' has an invalid restart policy '%s', valid policies are %s\n", sc.Name, sc.Restart, strings.Join(sidecarRestartPolicies, ", "))
+			err = ValidationError
+		}
+	}
+
+	// The init script replaces the image's ENTRYPOINT, which flyctl doesn't
+	// know of, so the one the script execs must be set explicitly
+	if cfg.Experimental == nil || len(cfg.Experimental.Entrypoint) == 0 {
+		extraInfo += "The [init] and [[sidecars]] sections replace the image's ENTRYPOINT with a /bin/sh script, set the entrypoint it runs last in [experimental] entrypoint, e.g. [\"/docker-entrypoint.sh\"], or [\"/usr/bin/env\"] for images without one\n"
+		err = ValidationError
+	}
+
+	// Nor can it fall back to the image's CMD
+	for _, name := range cfg.ProcessNames() {
+		if fc, fErr := cfg.Flatten(name); fErr != nil || (fc.Init == nil && len(fc.Sidecars) == 0) {
+			continue
+		}
+		cmd, _ := cfg.InitCmd(name)
+		if len(cmd) == 0 && (cfg.Experimental == nil || len(cfg.Experimental.Cmd) == 0) {
+			extraInfo += fmt.Sprintf("The [init] and [[sidecars]] sections require a command for process group '%s', set it in [processes] or [experimental] cmd\n", name)
+			err = ValidationError
+		}
+	}
+
+	return
+}
@@ -114,9 +114,10 @@ func (c *Config) updateMachineConfig(src *api.MachineConfig) (*api.MachineConfig
 		mConfig.Init.Exec = c.Experimental.Exec
 	}
 	mConfig.Init.Cmd = cmd
+	c.setInitScript(mConfig)
 
-	// Metadata
-	mConfig.Metadata = lo.Assign(mConfig.Metadata, map[string]string{
+	// Metadata, with the labels of fly.toml under the keys of the platform
+	mConfig.Metadata = lo.Assign(mConfig.Metadata, c.Metadata, map[string]string{
 		api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
 		api.MachineConfigMetadataKeyFlyProcessGroup:    processGroup,
 	})
@@ -128,6 +129,9 @@ func (c *Config) updateMachineConfig(src *api.MachineConfig) (*api.MachineConfig
 			return *s.toMachineService()
 		})
 	}
+	if c.Autostop.Excludes(processGroup) {
+		DisableAutostop(mConfig)
+	}
 
 	// Checks
 	mConfig.Checks = nil
@@ -1,6 +1,7 @@
 package appconfig
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,7 +25,7 @@ func TestToMachineConfig(t *testing.T) {
 				},
 			},
 		},
-		Metadata: map[string]string{"fly_platform_version": "v2", "fly_process_group": "app"},
+		Metadata: map[string]string{"fly_platform_version": "v2", "fly_process_group": "app", "team": "core"},
 		Metrics:  &api.MachineMetrics{Port: 9999, Path: "/metrics"},
 		Statics:  []*api.Static{{GuestPath: "/guest/path", UrlPrefix: "/url/prefix"}},
 		Mounts:   []api.MachineMount{{Name: "data", Path: "/data"}},
@@ -335,3 +336,47 @@ func TestToMachineConfig_services(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, want, got.Services)
 }
+
+func TestToMachineConfig_init(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/tomachine-init.toml")
+	require.NoError(t, err)
+
+	script := "#!/bin/sh\nset -e\nchown -R app /data\nbin/migrate\nexec \"$@\"\n"
+
+	got, err := cfg.ToMachineConfig("app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, api.MachineInit{
+		Entrypoint: []string{"/bin/sh", "/.fly/init.sh", "/docker-entrypoint.sh"},
+		Cmd:        []string{"bin/server"},
+	}, got.Init)
+	require.Len(t, got.Files, 1)
+	assert.Equal(t, "/.fly/init.sh", got.Files[0].GuestPath)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(script)), *got.Files[0].RawValue)
+
+	// Updating keeps a single wrapped entrypoint
+	got, err = cfg.ToMachineConfig("app", got)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/bin/sh", "/.fly/init.sh", "/docker-entrypoint.sh"}, got.Init.Entrypoint)
+	assert.Len(t, got.Files, 1)
+
+	// Other process groups are unwrapped
+	got, err = cfg.ToMachineConfig("worker", got)
+	require.NoError(t, err)
+	assert.Equal(t, api.MachineInit{Entrypoint: []string{"/docker-entrypoint.sh"}, Cmd: []string{"bin/worker"}}, got.Init)
+	assert.Empty(t, got.Files)
+}
+
+func TestValidateInitSection(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/tomachine-init.toml")
+	require.NoError(t, err)
+
+	extraInfo, err := cfg.validateInitSection()
+	assert.NoError(t, err)
+	assert.Empty(t, extraInfo)
+
+	// The image's entrypoint is replaced, so it must be given
+	cfg.Experimental = nil
+	extraInfo, err = cfg.validateInitSection()
+	assert.ErrorIs(t, err, ValidationError)
+	assert.Contains(t, extraInfo, "[experimental] entrypoint")
+}
@@ -149,6 +149,16 @@ func (c *Config) Flatten(groupName string) (*Config, error) {
 		return matchesGroups(x.Processes)
 	})
 
+	// [[sidecars]]
+	dst.Sidecars = lo.Filter(c.Sidecars, func(x Sidecar, _ int) bool {
+		return matchesGroups(x.Processes)
+	})
+
+	// [init]
+	if c.Init != nil && !matchesGroups(c.Init.Processes) {
+		dst.Init = nil
+	}
+
 	return dst, nil
 }
 
@@ -0,0 +1,144 @@
+package appconfig
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/google/shlex"
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// MachineProcessGroupSeed is the process group of the temporary machines
+// seeding the data of apps. They're left out of the platform version
+// metadata so they aren't taken for machines of the app.
+const MachineProcessGroupSeed = "fly_app_seed"
+
+const (
+	defaultSeedDatabaseURLEnv = "DATABASE_URL"
+	defaultSeedPostgresImage  = "postgres:16-alpine"
+)
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Seed fills the data of an app on its first deploy, such as review apps
+// deployed for each pull request: a sanitized Postgres snapshot is restored
+// into the database of DatabaseURLEnv, then Command runs in a temporary
+// machine, both after the release command.
+type Seed struct {
+	Command          string `toml:"command,omitempty" json:"command,omitempty"`
+	PostgresSnapshot string `toml:"postgres_snapshot,omitempty" json:"postgres_snapshot,omitempty"`
+	DatabaseURLEnv   string `toml:"database_url_env,omitempty" json:"database_url_env,omitempty"`
+	PostgresImage    string `toml:"postgres_image,omitempty" json:"postgres_image,omitempty"`
+}
+
+// DatabaseURLEnvOrDefault returns the environment variable with the URL of
+// the database snapshots are restored into, DATABASE_URL by default.
+func (s *Seed) DatabaseURLEnvOrDefault() string {
+	if s.DatabaseURLEnv == "" {
+		return defaultSeedDatabaseURLEnv
+	}
+	return s.DatabaseURLEnv
+}
+
+// PostgresImageOrDefault returns the image restoring snapshots, which needs
+// psql, pg_restore and wget.
+func (s *Seed) PostgresImageOrDefault() string {
+	if s.PostgresImage == "" {
+		return defaultSeedPostgresImage
+	}
+	return s.PostgresImage
+}
+
+// restoreScript downloads the snapshot and restores it, with psql for plain
+// SQL dumps and pg_restore otherwise.
+func (s *Seed) restoreScript() string {
+	return fmt.Sprintf(`set -e
+wget -qO /tmp/seed "$FLY_SEED_SNAPSHOT"
+case "${FLY_SEED_SNAPSHOT%%%%\?*}" in
+  *.sql) psql -v ON_ERROR_STOP=1 "$%[1]s" -f /tmp/seed ;;
+  *.sql.gz) gunzip -c /tmp/seed | psql -v ON_ERROR_STOP=1 "$%[1]s" ;;
+  *) pg_restore --no-owner --no-acl --clean --if-exists -d "$%[1]s" /tmp/seed ;;
+esac
+`, s.DatabaseURLEnvOrDefault())
+}
+
+func (c *Config) seedMachineConfig() *api.MachineConfig {
+	mConfig := &api.MachineConfig{
+		Restart: api.MachineRestart{
+			Policy: api.MachineRestartPolicyNo,
+		},
+		AutoDestroy: true,
+		DNS: &api.DNSConfig{
+			SkipRegistration: true,
+		},
+		Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyProcessGroup: MachineProcessGroupSeed,
+		},
+		Env: lo.Assign(c.Env),
+	}
+
+	mConfig.Env["FLY_PROCESS_GROUP"] = MachineProcessGroupSeed
+	if c.PrimaryRegion != "" {
+		mConfig.Env["PRIMARY_REGION"] = c.PrimaryRegion
+	}
+	return mConfig
+}
+
+// ToSeedRestoreMachineConfig returns the config of the machine restoring the
+// Postgres snapshot of [seed].
+func (c *Config) ToSeedRestoreMachineConfig() *api.MachineConfig {
+	mConfig := c.seedMachineConfig()
+	mConfig.Image = c.Seed.PostgresImageOrDefault()
+	mConfig.Init.Entrypoint = []string{"sh", "-c"}
+	mConfig.Init.Cmd = []string{c.Seed.restoreScript()}
+	mConfig.Env["FLY_SEED_SNAPSHOT"] = c.Seed.PostgresSnapshot
+	return mConfig
+}
+
+// ToSeedCommandMachineConfig returns the config of the machine running the
+// command of [seed], without its image.
+func (c *Config) ToSeedCommandMachineConfig() (*api.MachineConfig, error) {
+	cmd, err := shlex.Split(c.Seed.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	mConfig := c.seedMachineConfig()
+	mConfig.Init.Cmd = cmd
+	if c.Experimental != nil {
+		mConfig.Init.Entrypoint = c.Experimental.Entrypoint
+	}
+	return mConfig, nil
+}
+
+func (cfg *Config) validateSeedSection() (extraInfo string, err error) {
+	s := cfg.Seed
+	if s == nil {
+		return
+	}
+
+	if s.Command == "" && s.PostgresSnapshot == "" {
+		extraInfo += "The [seed] section needs a command or a postgres_snapshot\n"
+		err = ValidationError
+	}
+	if s.Command != "" {
+		if _, splitErr := shlex.Split(s.Command); splitErr != nil {
+			extraInfo += fmt.Sprintf("Can't parse [seed] command: %s\n", splitErr)
+			err = ValidationError
+		}
+	}
+	if s.PostgresSnapshot != "" {
+		if u, uErr := url.Parse(s.PostgresSnapshot); uErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			extraInfo += "[seed] postgres_snapshot must be an http or https url\n"
+			err = ValidationError
+		}
+	}
+	if s.DatabaseURLEnv != "" && !envNameRegexp.MatchString(s.DatabaseURLEnv) {
+		extraInfo += fmt.Sprintf("[seed] database_url_env '%s' isn't a valid environment variable name\n", s.DatabaseURLEnv)
+		err = ValidationError
+	}
+	return
+}
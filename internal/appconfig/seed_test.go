@@ -0,0 +1,68 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestValidateSeedSection(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Seed = &Seed{Command: "bin/rails db:seed", PostgresSnapshot: "https://example.com/seed.dump"}
+
+	extraInfo, err := cfg.validateSeedSection()
+	assert.NoError(t, err)
+	assert.Empty(t, extraInfo)
+
+	cfg.Seed = &Seed{}
+	extraInfo, err = cfg.validateSeedSection()
+	assert.ErrorIs(t, err, ValidationError)
+	assert.Contains(t, extraInfo, "needs a command or a postgres_snapshot")
+
+	cfg.Seed = &Seed{Command: "echo 'unterminated", PostgresSnapshot: "s3://bucket/seed.dump", DatabaseURLEnv: "1DB"}
+	extraInfo, err = cfg.validateSeedSection()
+	assert.ErrorIs(t, err, ValidationError)
+	assert.Contains(t, extraInfo, "Can't parse [seed] command")
+	assert.Contains(t, extraInfo, "must be an http or https url")
+	assert.Contains(t, extraInfo, "database_url_env '1DB'")
+}
+
+func TestToSeedRestoreMachineConfig(t *testing.T) {
+	cfg := NewConfig()
+	cfg.PrimaryRegion = "ams"
+	cfg.Env = map[string]string{"RAILS_ENV": "production"}
+	cfg.Seed = &Seed{PostgresSnapshot: "https://example.com/seed.sql.gz"}
+
+	got := cfg.ToSeedRestoreMachineConfig()
+	assert.Equal(t, "postgres:16-alpine", got.Image)
+	assert.Equal(t, []string{"sh", "-c"}, got.Init.Entrypoint)
+	require.Len(t, got.Init.Cmd, 1)
+	assert.Contains(t, got.Init.Cmd[0], `"$DATABASE_URL"`)
+	assert.Equal(t, map[string]string{
+		"RAILS_ENV":         "production",
+		"FLY_PROCESS_GROUP": MachineProcessGroupSeed,
+		"PRIMARY_REGION":    "ams",
+		"FLY_SEED_SNAPSHOT": "https://example.com/seed.sql.gz",
+	}, got.Env)
+	assert.True(t, got.AutoDestroy)
+	assert.Equal(t, api.MachineRestartPolicyNo, got.Restart.Policy)
+	assert.NotContains(t, got.Metadata, api.MachineConfigMetadataKeyFlyPlatformVersion)
+	assert.NotContains(t, cfg.Env, "FLY_SEED_SNAPSHOT")
+}
+
+func TestToSeedCommandMachineConfig(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Seed = &Seed{Command: "bin/rails db:seed", DatabaseURLEnv: "PG_URL"}
+	cfg.Experimental = &Experimental{Entrypoint: []string{"/entry"}}
+
+	got, err := cfg.ToSeedCommandMachineConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bin/rails", "db:seed"}, got.Init.Cmd)
+	assert.Equal(t, []string{"/entry"}, got.Init.Entrypoint)
+	assert.Empty(t, got.Image)
+	assert.Equal(t, MachineProcessGroupSeed, got.Metadata[api.MachineConfigMetadataKeyFlyProcessGroup])
+	assert.Contains(t, cfg.Seed.restoreScript(), `"$PG_URL"`)
+}
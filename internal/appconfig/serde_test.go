@@ -409,6 +409,10 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 			"FOO": "BAR",
 		},
 
+		Metadata: map[string]string{
+			"team": "platform",
+		},
+
 		Metrics: &api.MachineMetrics{
 			Port: 9999,
 			Path: "/metrics",
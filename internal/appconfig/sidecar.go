@@ -0,0 +1,78 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// Restart policies of sidecars.
+const (
+	SidecarRestartAlways    = "always"
+	SidecarRestartOnFailure = "on-failure"
+	SidecarRestartNo        = "no"
+)
+
+var sidecarRestartPolicies = []string{SidecarRestartAlways, SidecarRestartOnFailure, SidecarRestartNo}
+
+// Sidecar is a process run in the background of machines alongside their
+// main process, e.g. a log forwarder or a cron runner. Like [init] commands,
+// sidecars are started from a /bin/sh script replacing the image's ENTRYPOINT.
+type Sidecar struct {
+	Name      string   `toml:"name" json:"name"`
+	Command   string   `toml:"command" json:"command"`
+	Restart   string   `toml:"restart,omitempty" json:"restart,omitempty"`
+	Processes []string `toml:"processes,omitempty" json:"processes,omitempty"`
+}
+
+// RestartPolicy returns the restart policy of the sidecar, defaulting to
+// always.
+func (sc Sidecar) RestartPolicy() string {
+	if sc.Restart == "" {
+		return SidecarRestartAlways
+	}
+	return sc.Restart
+}
+
+// script returns the shell snippet supervising the sidecar in the background
+// according to its restart policy.
+func (sc Sidecar) script() string {
+	var loop string
+	switch sc.RestartPolicy() {
+	case SidecarRestartNo:
+		loop = sc.Command
+	case SidecarRestartOnFailure:
+		loop = fmt.Sprintf("until %s; do sleep 1; done", sc.Command)
+	default:
+		loop = fmt.Sprintf("while true; do %s; sleep 1; done", sc.Command)
+	}
+	return fmt.Sprintf("# sidecar %s\n(set +e; %s) &\n", sc.Name, loop)
+}
+
+// Executable returns the program run by the sidecar.
+func (sc Sidecar) Executable() string {
+	fields := strings.Fields(sc.Command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// SidecarsFromMachine returns the sidecars a machine was deployed with.
+func SidecarsFromMachine(m *api.Machine) ([]Sidecar, error) {
+	if m.Config == nil {
+		return nil, nil
+	}
+	raw := m.Config.Metadata[api.MachineConfigMetadataKeyFlySidecars]
+	if raw == "" {
+		return nil, nil
+	}
+
+	var sidecars []Sidecar
+	if err := json.Unmarshal([]byte(raw), &sidecars); err != nil {
+		return nil, fmt.Errorf("invalid sidecars metadata on machine %s: %w", m.ID, err)
+	}
+	return sidecars, nil
+}
@@ -0,0 +1,33 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestSidecarScript(t *testing.T) {
+	assert.Equal(t, "# sidecar vector\n(set +e; while true; do vector -c /etc/vector.toml; sleep 1; done) &\n",
+		Sidecar{Name: "vector", Command: "vector -c /etc/vector.toml"}.script())
+	assert.Equal(t, "# sidecar cron\n(set +e; until supercronic /etc/crontab; do sleep 1; done) &\n",
+		Sidecar{Name: "cron", Command: "supercronic /etc/crontab", Restart: SidecarRestartOnFailure}.script())
+	assert.Equal(t, "# sidecar once\n(set +e; warmup) &\n",
+		Sidecar{Name: "once", Command: "warmup", Restart: SidecarRestartNo}.script())
+}
+
+func TestToMachineConfig_sidecars(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AppName = "foo"
+	cfg.Processes = map[string]string{"app": "bin/server"}
+	cfg.Sidecars = []Sidecar{{Name: "vector", Command: "vector"}}
+
+	got, err := cfg.ToMachineConfig("app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/bin/sh", "/.fly/init.sh"}, got.Init.Entrypoint)
+
+	sidecars, err := SidecarsFromMachine(&api.Machine{Config: got})
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Sidecars, sidecars)
+}
@@ -0,0 +1,137 @@
+package appconfig
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"golang.org/x/exp/slices"
+)
+
+// Kinds of smoke tests.
+const (
+	SmokeTestHTTP    = "http"
+	SmokeTestCommand = "command"
+)
+
+const defaultSmokeTestTimeout = 10 * time.Second
+
+// SmokeTest is an assertion on a deployed app, run by `fly checks run` and
+// after deploys: either an HTTP request expecting a status and a body, or a
+// command run on a machine expecting it to exit with 0. Failing tests are
+// retried up to Retries times.
+type SmokeTest struct {
+	Name string `toml:"name" json:"name"`
+
+	// HTTP requests, to path on the app's hostname or to url
+	Path         string            `toml:"path,omitempty" json:"path,omitempty"`
+	URL          string            `toml:"url,omitempty" json:"url,omitempty"`
+	Method       string            `toml:"method,omitempty" json:"method,omitempty"`
+	Headers      map[string]string `toml:"headers,omitempty" json:"headers,omitempty"`
+	Status       int               `toml:"status,omitempty" json:"status,omitempty"`
+	BodyContains string            `toml:"body_contains,omitempty" json:"body_contains,omitempty"`
+	MaxLatency   *api.Duration     `toml:"max_latency,omitempty" json:"max_latency,omitempty"`
+
+	// Commands, run on a machine of processes
+	Command string `toml:"command,omitempty" json:"command,omitempty"`
+
+	Timeout   *api.Duration `toml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retries   int           `toml:"retries,omitempty" json:"retries,omitempty"`
+	Processes []string      `toml:"processes,omitempty" json:"processes,omitempty"`
+}
+
+// Kind returns SmokeTestCommand for tests with a command, SmokeTestHTTP
+// otherwise.
+func (t SmokeTest) Kind() string {
+	if t.Command != "" {
+		return SmokeTestCommand
+	}
+	return SmokeTestHTTP
+}
+
+// ExpectedStatus returns the HTTP status the test expects, defaulting to 200.
+func (t SmokeTest) ExpectedStatus() int {
+	if t.Status == 0 {
+		return http.StatusOK
+	}
+	return t.Status
+}
+
+// HTTPMethod returns the method of the test's request, defaulting to GET.
+func (t SmokeTest) HTTPMethod() string {
+	if t.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(t.Method)
+}
+
+// TimeoutOrDefault returns how long the test may take, 10 seconds by default.
+func (t SmokeTest) TimeoutOrDefault() time.Duration {
+	if t.Timeout == nil || t.Timeout.Duration <= 0 {
+		return defaultSmokeTestTimeout
+	}
+	return t.Timeout.Duration
+}
+
+func (cfg *Config) validateSmokeTests() (extraInfo string, err error) {
+	processNames := cfg.ProcessNames()
+	seen := map[string]bool{}
+
+	for _, t := range cfg.SmokeTests {
+		switch {
+		case t.Name == "":
+			extraInfo += "Smoke tests must have a name\n"
+			err = ValidationError
+		case seen[t.Name]:
+			extraInfo += fmt.Sprintf("Smoke test '%s' is defined more than once\n", t.Name)
+			err = ValidationError
+		}
+		seen[t.Name] = true
+
+		isHTTP := t.Path != "" || t.URL != ""
+		switch {
+		case isHTTP && t.Command != "":
+			extraInfo += fmt.Sprintf("Smoke test '%s' must have either a command or a path or url, not both\n", t.Name)
+			err = ValidationError
+		case !isHTTP && t.Command == "":
+			extraInfo += fmt.Sprintf("Smoke test '%s' needs a path, a url or a command\n", t.Name)
+			err = ValidationError
+		case t.Path != "" && t.URL != "":
+			extraInfo += fmt.Sprintf("Smoke test '%s' can't have both a path and a url\n", t.Name)
+			err = ValidationError
+		case t.Path != "" && !strings.HasPrefix(t.Path, "/"):
+			extraInfo += fmt.Sprintf("Smoke test '%s' path must start with '/'\n", t.Name)
+			err = ValidationError
+		}
+
+		if t.URL != "" {
+			if u, uErr := url.Parse(t.URL); uErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				extraInfo += fmt.Sprintf("Smoke test '%s' url must be an absolute http or https url\n", t.Name)
+				err = ValidationError
+			}
+		}
+		if t.Status != 0 && (t.Status < 100 || t.Status > 599) {
+			extraInfo += fmt.Sprintf("Smoke test '%s' has an invalid status %d\n", t.Name, t.Status)
+			err = ValidationError
+		}
+		if t.Retries < 0 {
+			extraInfo += fmt.Sprintf("Smoke test '%s' can't have negative retries\n", t.Name)
+			err = ValidationError
+		}
+		if t.Command != "" && (t.Method != "" || len(t.Headers) > 0 || t.Status != 0 || t.BodyContains != "" || t.MaxLatency != nil) {
+			extraInfo += fmt.Sprintf("Smoke test '%s' runs a command, method, headers, status, body_contains and max_latency only apply to HTTP tests\n", t.Name)
+			err = ValidationError
+		}
+		for _, name := range t.Processes {
+			if !slices.Contains(processNames, name) {
+				extraInfo += fmt.Sprintf("Smoke test '%s' refers to the non-existent process group '%s'\n", t.Name, name)
+				err = ValidationError
+			}
+		}
+	}
+
+	return
+}
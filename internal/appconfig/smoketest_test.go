@@ -0,0 +1,51 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSmokeTests(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Processes = map[string]string{"app": "bin/server", "worker": "bin/worker"}
+	cfg.platformVersion = MachinesPlatform
+	cfg.SmokeTests = []SmokeTest{
+		{Name: "home", Path: "/", BodyContains: "Welcome"},
+		{Name: "external", URL: "https://example.com/health", Status: 204},
+		{Name: "migrations", Command: "bin/migrate status", Processes: []string{"worker"}},
+	}
+
+	extraInfo, err := cfg.validateSmokeTests()
+	assert.NoError(t, err)
+	assert.Empty(t, extraInfo)
+
+	cfg.SmokeTests = []SmokeTest{
+		{Path: "/"},
+		{Name: "home", Path: "/"},
+		{Name: "home", Path: "/"},
+		{Name: "empty"},
+		{Name: "both", Path: "/", Command: "true"},
+		{Name: "relative", Path: "health"},
+		{Name: "bad-url", URL: "example.com/health"},
+		{Name: "bad-status", Path: "/", Status: 42},
+		{Name: "cmd-status", Command: "true", Status: 200},
+		{Name: "group", Command: "true", Processes: []string{"web"}},
+	}
+
+	extraInfo, err = cfg.validateSmokeTests()
+	assert.ErrorIs(t, err, ValidationError)
+	for _, want := range []string{
+		"Smoke tests must have a name",
+		"Smoke test 'home' is defined more than once",
+		"Smoke test 'empty' needs a path, a url or a command",
+		"Smoke test 'both' must have either a command or a path or url",
+		"Smoke test 'relative' path must start with '/'",
+		"Smoke test 'bad-url' url must be an absolute http or https url",
+		"Smoke test 'bad-status' has an invalid status 42",
+		"Smoke test 'cmd-status' runs a command",
+		"Smoke test 'group' refers to the non-existent process group 'web'",
+	} {
+		assert.Contains(t, extraInfo, want)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/logrusorgru/aurora"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/cache"
 	"github.com/superfly/flyctl/internal/sentry"
 	"golang.org/x/exp/slices"
 )
@@ -22,7 +23,6 @@ var (
 
 func (cfg *Config) Validate(ctx context.Context) (err error, extra_info string) {
 	appName := NameFromContext(ctx)
-	apiClient := client.FromContext(ctx).API()
 
 	if cfg == nil {
 		return errors.New("App config file not found"), ""
@@ -32,7 +32,7 @@ func (cfg *Config) Validate(ctx context.Context) (err error, extra_info string)
 
 	platformVersion := cfg.platformVersion
 	if platformVersion == "" {
-		app, err := apiClient.GetAppBasic(ctx, appName)
+		app, err := cache.GetAppBasic(ctx, appName)
 		switch {
 		case err == nil:
 			platformVersion = app.PlatformVersion
@@ -101,6 +101,11 @@ func (cfg *Config) ValidateForMachinesPlatform(ctx context.Context) (err error,
 		cfg.validateProcessesSection,
 		cfg.validateMachineConversion,
 		cfg.validateConsoleCommand,
+		cfg.validateInitSection,
+		cfg.validateSmokeTests,
+		cfg.validateAutostopSection,
+		cfg.validateSeedSection,
+		cfg.validateMetadataSection,
 	}
 
 	for _, vFunc := range validators {
@@ -289,3 +294,13 @@ func (cfg *Config) validateConsoleCommand() (extraInfo string, err error) {
 	}
 	return
 }
+
+func (cfg *Config) validateMetadataSection() (extraInfo string, err error) {
+	for key := range cfg.Metadata {
+		if api.IsReservedMetadataKey(key) {
+			extraInfo += fmt.Sprintf("Metadata key '%s' is reserved for machines managed by Fly.io\n", key)
+			err = ValidationError
+		}
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+)
+
+// billingURL returns the URL of the billing page of the organization orgSlug.
+func billingURL(orgSlug string) string {
+	return fmt.Sprintf("https://fly.io/dashboard/%s/billing", orgSlug)
+}
+
+// CheckBilling fails with a message telling what to do when the organization
+// orgSlug can't be billed for new resources: it has no payment method, unpaid
+// invoices, or its access is restricted.
+func CheckBilling(ctx context.Context, orgSlug string) error {
+	if orgSlug == "" {
+		return nil
+	}
+
+	resp, err := gql.GetOrganizationBilling(ctx, client.FromContext(ctx).API().GenqClient, orgSlug)
+	if err != nil {
+		return fmt.Errorf("failed retrieving the billing status of %s: %w", orgSlug, err)
+	}
+	return billingError(resp.Organization)
+}
+
+func billingError(org gql.GetOrganizationBillingOrganization) error {
+	switch {
+	case org.Trust == gql.OrganizationTrustBanned:
+		return fmt.Errorf("organization %s is suspended, contact billing@fly.io", org.Slug)
+	case org.Trust == gql.OrganizationTrustRestricted:
+		return fmt.Errorf("organization %s has restricted access until it's verified, add a payment method at %s or contact billing@fly.io", org.Slug, billingURL(org.Slug))
+	case org.BillingStatus == gql.BillingStatusSourceRequired:
+		return fmt.Errorf("organization %s needs a payment method before creating resources, add one at %s", org.Slug, billingURL(org.Slug))
+	case org.BillingStatus == gql.BillingStatusPastDue:
+		return fmt.Errorf("organization %s has past due invoices, pay them at %s before creating resources", org.Slug, billingURL(org.Slug))
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,300 @@
+// Package budget estimates the monthly cost of apps and checks the cost
+// provisioning commands add against the budgets of organizations and apps.
+// Budgets are kept in flyctl's configuration file: they are a local safeguard
+// of this flyctl, not spending limits enforced by the platform.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// List prices in USD per month, used when the products API doesn't report
+// them.
+const (
+	defaultVolumePricePerGB      = 0.15
+	defaultDedicatedIPv4Price    = 2.0
+	defaultAdditionalMemoryPerGB = 5.0
+)
+
+// maxConcurrentCostLookups bounds the apps whose cost OrgCost estimates at once.
+const maxConcurrentCostLookups = 8
+
+// Prices holds the monthly prices of machines, volumes and IP addresses.
+type Prices struct {
+	// Machines maps the machine presets to their monthly price.
+	Machines map[string]float64
+	// AdditionalMemoryPerGB is the price of memory added to a preset.
+	AdditionalMemoryPerGB float64
+	// VolumePerGB is the price of a GB of volume storage.
+	VolumePerGB float64
+	// DedicatedIPv4 is the price of a dedicated IPv4 address.
+	DedicatedIPv4 float64
+}
+
+// FetchPrices returns the monthly prices of the platform, from the machine
+// sizes and products the API reports.
+func FetchPrices(ctx context.Context) (Prices, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	sizes, err := apiClient.PlatformVMSizes(ctx)
+	if err != nil {
+		return Prices{}, fmt.Errorf("failed fetching machine prices: %w", err)
+	}
+
+	products, err := apiClient.Products(ctx)
+	if err != nil {
+		return Prices{}, fmt.Errorf("failed fetching product prices: %w", err)
+	}
+
+	return newPrices(sizes, products), nil
+}
+
+func newPrices(sizes []api.VMSize, products []api.Product) Prices {
+	prices := Prices{
+		Machines:              map[string]float64{},
+		AdditionalMemoryPerGB: productPrice(products, "memory", defaultAdditionalMemoryPerGB),
+		VolumePerGB:           productPrice(products, "volume", defaultVolumePricePerGB),
+		DedicatedIPv4:         productPrice(products, "ipv4", defaultDedicatedIPv4Price),
+	}
+	for _, s := range sizes {
+		prices.Machines[s.Name] = float64(s.PriceMonth)
+	}
+	return prices
+}
+
+// productPrice returns the unit price of the first product whose name
+// contains keyword, from its last and unbounded tier, or def when no product
+// matches.
+func productPrice(products []api.Product, keyword string, def float64) float64 {
+	for _, p := range products {
+		if !strings.Contains(strings.ToLower(p.Name), keyword) || len(p.Tiers) == 0 {
+			continue
+		}
+		if price, err := strconv.ParseFloat(p.Tiers[len(p.Tiers)-1].UnitAmount, 64); err == nil {
+			return price
+		}
+	}
+	return def
+}
+
+// Volume returns the monthly price of a volume of sizeGB.
+func (p Prices) Volume(sizeGB int) float64 {
+	return float64(sizeGB) * p.VolumePerGB
+}
+
+// Machine returns the monthly price of a machine of the given size running
+// all month: the price of its CPU preset plus the memory it adds.
+func (p Prices) Machine(guest *api.MachineGuest) float64 {
+	if guest == nil {
+		return 0
+	}
+
+	name := guest.ToSize()
+	price := p.Machines[name]
+	if preset, ok := api.MachinePresets[name]; ok && guest.MemoryMB > preset.MemoryMB {
+		price += float64(guest.MemoryMB-preset.MemoryMB) / 1024 * p.AdditionalMemoryPerGB
+	}
+	return price
+}
+
+// AppCost estimates the monthly cost of the app from its started machines,
+// volumes and dedicated IPv4 addresses.
+func AppCost(ctx context.Context, prices Prices, appName string) (float64, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	flapsClient, err := flaps.NewFromAppName(ctx, appName)
+	if err != nil {
+		return 0, err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	var cost float64
+	for _, m := range machines {
+		if m.State == api.MachineStateStarted && m.Config != nil {
+			cost += prices.Machine(m.Config.Guest)
+		}
+	}
+
+	volumes, err := apiClient.GetVolumes(ctx, appName)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range volumes {
+		cost += prices.Volume(v.SizeGb)
+	}
+
+	ips, err := apiClient.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return 0, err
+	}
+	for _, ip := range ips {
+		if ip.Type == "v4" {
+			cost += prices.DedicatedIPv4
+		}
+	}
+
+	return cost, nil
+}
+
+// OrgCost estimates the monthly cost of the organization's apps, looking up
+// several apps at once.
+func OrgCost(ctx context.Context, prices Prices, orgSlug string) (float64, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	org, err := apiClient.GetOrganizationBySlug(ctx, orgSlug)
+	if err != nil {
+		return 0, err
+	}
+
+	apps, err := apiClient.GetAppsForOrganization(ctx, org.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu   sync.Mutex
+		cost float64
+	)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxConcurrentCostLookups)
+
+	for _, app := range apps {
+		app := app
+		eg.Go(func() error {
+			appCost, err := AppCost(ctx, prices, app.Name)
+			if err != nil {
+				return fmt.Errorf("failed estimating the cost of %s: %w", app.Name, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			cost += appCost
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return 0, err
+	}
+	return cost, nil
+}
+
+// exceededBudget describes a budget a change would exceed.
+type exceededBudget struct {
+	Kind   string
+	Name   string
+	Budget float64
+	Cost   float64
+}
+
+// Delta computes the monthly cost a command adds.
+type Delta func(Prices) float64
+
+// Fixed returns the Delta of a resource of a fixed monthly price.
+func Fixed(price float64) Delta {
+	return func(Prices) float64 { return price }
+}
+
+// Check prints the monthly cost what adds to the app appName of the
+// organization orgSlug, when either has a budget. When the cost would exceed
+// a budget it asks for confirmation, which --yes skips. Budgets whose cost
+// can't be estimated print a warning rather than blocking the command. It
+// fails when the organization can't be billed, see CheckBilling.
+func Check(ctx context.Context, orgSlug, appName, what string, costDelta Delta) error {
+	if err := CheckBilling(ctx, orgSlug); err != nil {
+		return err
+	}
+
+	cfg := config.FromContext(ctx)
+
+	orgBudget, hasOrgBudget := cfg.Budget(config.BudgetOrg, orgSlug)
+	appBudget, hasAppBudget := cfg.Budget(config.BudgetApp, appName)
+	if !hasOrgBudget && !hasAppBudget {
+		return nil
+	}
+
+	io := iostreams.FromContext(ctx)
+	colorize := io.ColorScheme()
+
+	prices, err := FetchPrices(ctx)
+	if err != nil {
+		fmt.Fprintf(io.ErrOut, "Warning: couldn't check budgets: %v\n", err)
+		return nil
+	}
+
+	delta := costDelta(prices)
+	if delta <= 0 {
+		return nil
+	}
+
+	fmt.Fprintf(io.ErrOut, "%s adds an estimated %s/month\n", what, formatUSD(delta))
+
+	var exceeded []exceededBudget
+	if hasAppBudget && appName != "" {
+		cost, err := AppCost(ctx, prices, appName)
+		switch {
+		case err != nil:
+			fmt.Fprintf(io.ErrOut, "Warning: couldn't check the budget of %s: failed estimating its cost: %v\n", appName, err)
+		case cost+delta > appBudget:
+			exceeded = append(exceeded, exceededBudget{Kind: "app", Name: appName, Budget: appBudget, Cost: cost + delta})
+		}
+	}
+	if hasOrgBudget && orgSlug != "" {
+		cost, err := OrgCost(ctx, prices, orgSlug)
+		switch {
+		case err != nil:
+			fmt.Fprintf(io.ErrOut, "Warning: couldn't check the budget of %s: %v\n", orgSlug, err)
+		case cost+delta > orgBudget:
+			exceeded = append(exceeded, exceededBudget{Kind: "organization", Name: orgSlug, Budget: orgBudget, Cost: cost + delta})
+		}
+	}
+
+	if len(exceeded) == 0 {
+		return nil
+	}
+
+	for _, e := range exceeded {
+		fmt.Fprintf(io.ErrOut, "%s %s %s would cost an estimated %s/month, over its budget of %s/month\n",
+			colorize.Yellow("Budget exceeded:"), e.Kind, e.Name, formatUSD(e.Cost), formatUSD(e.Budget))
+	}
+
+	if flag.GetYes(ctx) {
+		return nil
+	}
+
+	switch confirmed, err := prompt.Confirm(ctx, "Continue anyway?"); {
+	case err == nil:
+		if !confirmed {
+			return errors.New("aborted to stay within budget")
+		}
+		return nil
+	case prompt.IsNonInteractive(err):
+		return prompt.NonInteractiveError("yes flag must be specified to exceed a budget when not running interactively")
+	default:
+		return err
+	}
+}
+
+func formatUSD(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
@@ -0,0 +1,57 @@
+package budget
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/gql"
+)
+
+func TestMachinePrice(t *testing.T) {
+	prices := Prices{Machines: map[string]float64{"shared-cpu-1x": 1.94, "performance-2x": 62}, AdditionalMemoryPerGB: 5}
+
+	assert.Equal(t, 1.94, prices.Machine(&api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256}))
+	assert.InDelta(t, 1.94+5*1.75, prices.Machine(&api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 2048}), 0.001)
+	assert.Equal(t, 62.0, prices.Machine(&api.MachineGuest{CPUKind: "performance", CPUs: 2, MemoryMB: 4096}))
+	assert.Zero(t, prices.Machine(nil))
+	assert.Equal(t, 7.0, Fixed(7)(prices))
+}
+
+func TestNewPrices(t *testing.T) {
+	sizes := []api.VMSize{{Name: "shared-cpu-1x", PriceMonth: 1.94}}
+	products := []api.Product{
+		{Name: "Volume Storage", Tiers: []api.PriceTier{{UnitAmount: "0"}, {UnitAmount: "0.18"}}},
+		{Name: "Dedicated IPv4", Tiers: []api.PriceTier{{UnitAmount: "3"}}},
+	}
+
+	prices := newPrices(sizes, products)
+	assert.InDelta(t, 1.94, prices.Machines["shared-cpu-1x"], 0.001)
+	assert.Equal(t, 0.18, prices.VolumePerGB)
+	assert.InDelta(t, 1.8, prices.Volume(10), 0.001)
+	assert.Equal(t, 3.0, prices.DedicatedIPv4)
+	assert.Equal(t, defaultAdditionalMemoryPerGB, prices.AdditionalMemoryPerGB)
+
+	prices = newPrices(sizes, nil)
+	assert.Equal(t, defaultVolumePricePerGB, prices.VolumePerGB)
+	assert.Equal(t, defaultDedicatedIPv4Price, prices.DedicatedIPv4)
+}
+
+func TestBillingError(t *testing.T) {
+	org := gql.GetOrganizationBillingOrganization{
+		Slug:          "acme",
+		BillingStatus: gql.BillingStatusCurrent,
+		Trust:         gql.OrganizationTrustHigh,
+	}
+	assert.NoError(t, billingError(org))
+
+	org.BillingStatus = gql.BillingStatusSourceRequired
+	assert.EqualError(t, billingError(org), "organization acme needs a payment method before creating resources, add one at https://fly.io/dashboard/acme/billing")
+
+	org.BillingStatus = gql.BillingStatusPastDue
+	assert.ErrorContains(t, billingError(org), "past due invoices")
+
+	org.Trust = gql.OrganizationTrustRestricted
+	assert.ErrorContains(t, billingError(org), "restricted access")
+}
@@ -137,14 +137,16 @@ func (t *tracer) write(msg jsonmessage.JSONMessage) {
 	t.displayCh <- &s
 }
 
-func newBuildkitAuthProvider(token string) session.Attachable {
+func newBuildkitAuthProvider(token string, registries *registryAuths) session.Attachable {
 	return &buildkitAuthProvider{
-		token: token,
+		token:      token,
+		registries: registries,
 	}
 }
 
 type buildkitAuthProvider struct {
-	token string
+	token      string
+	registries *registryAuths
 }
 
 func (ap *buildkitAuthProvider) Register(server *grpc.Server) {
@@ -157,6 +159,21 @@ func (ap *buildkitAuthProvider) Credentials(ctx context.Context, req *auth.Crede
 	if a, ok := auths[req.Host]; ok {
 		res.Username = a.Username
 		res.Secret = a.Password
+		return res, nil
+	}
+
+	// Resolved on every request, so that expired ECR tokens are refreshed
+	// during long builds
+	a, ok, err := ap.registries.Get(ctx, req.Host)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+	if ok {
+		res.Username = a.Username
+		res.Secret = a.Password
+		if a.IdentityToken != "" {
+			res.Username, res.Secret = "", a.IdentityToken
+		}
 	}
 
 	return res, nil
@@ -214,6 +214,13 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 			return nil, "", errors.Wrap(err, "error building")
 		}
 	} else {
+		if len(opts.BuildSecrets) > 0 {
+			// The classic builder can't mount secrets, refuse rather than
+			// building without them
+			build.ImageBuildFinish()
+			build.BuildFinish()
+			return nil, "", errors.New("build secrets require BuildKit, which the Docker daemon has disabled. Enable it, e.g. with DOCKER_BUILDKIT=1, or build remotely")
+		}
 		imageID, err = runClassicBuild(ctx, streams, docker, r, opts, relativedockerfilePath, buildArgs)
 		if err != nil {
 			if dockerFactory.IsRemote() {
@@ -265,10 +272,15 @@ func normalizeBuildArgsForDocker(buildArgs map[string]string) (map[string]*strin
 }
 
 func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *dockerclient.Client, r io.ReadCloser, opts ImageOptions, dockerfilePath string, buildArgs map[string]*string) (imageID string, err error) {
+	auths, err := buildAuthConfigs(ctx, newRegistryAuths(opts.RegistryAuths))
+	if err != nil {
+		return "", err
+	}
+
 	options := types.ImageBuildOptions{
 		Tags:        []string{opts.Tag},
 		BuildArgs:   buildArgs,
-		AuthConfigs: authConfigs(config.FromContext(ctx).AccessToken),
+		AuthConfigs: auths,
 		Platform:    "linux/amd64",
 		Dockerfile:  dockerfilePath,
 		Target:      opts.Target,
@@ -304,7 +316,8 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 	if err != nil {
 		panic(err)
 	}
-	s.Allow(newBuildkitAuthProvider(config.FromContext(ctx).AccessToken))
+	registries := newRegistryAuths(opts.RegistryAuths)
+	s.Allow(newBuildkitAuthProvider(config.FromContext(ctx).AccessToken, registries))
 
 	if s == nil {
 		panic("buildkit not supported")
@@ -318,6 +331,11 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 
 	s.Allow(secretsprovider.FromMap(finalSecrets))
 
+	auths, err := buildAuthConfigs(ctx, registries)
+	if err != nil {
+		return "", err
+	}
+
 	eg, errCtx := errgroup.WithContext(ctx)
 
 	dialSession := func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error) {
@@ -349,7 +367,7 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 			Tags:          []string{opts.Tag},
 			BuildArgs:     buildArgs,
 			Version:       types.BuilderBuildKit,
-			AuthConfigs:   authConfigs(config.FromContext(ctx).AccessToken),
+			AuthConfigs:   auths,
 			SessionID:     s.ID(),
 			RemoteContext: uploadRequestRemote,
 			BuildID:       buildID,
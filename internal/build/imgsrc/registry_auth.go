@@ -0,0 +1,170 @@
+package imgsrc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/docker/docker/api/types"
+
+	"github.com/superfly/flyctl/internal/config"
+)
+
+// ecrHostPattern matches the hosts of private ECR registries, capturing
+// their region.
+var ecrHostPattern = regexp.MustCompile(`^\d{12}\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// ecrTokenTTL is how long ECR authorization tokens are reused, short of the
+// 12 hours they're valid for.
+const ecrTokenTTL = 11 * time.Hour
+
+// registryAuths resolves the credentials of the private registries builds
+// pull base images from, passed through to the builder. Credentials come from
+// the local Docker config and its credential helpers, or for ECR registries
+// without credentials there, from a token of the AWS CLI, refreshed before it
+// expires.
+type registryAuths struct {
+	hosts []string
+
+	// dockerConfig returns the credentials of a host in the local Docker
+	// config, empty when there are none.
+	dockerConfig func(host string) (types.AuthConfig, error)
+	// ecrPassword returns an authorization token for the ECR registries of
+	// region.
+	ecrPassword func(ctx context.Context, region string) (string, error)
+	now         func() time.Time
+
+	mu        sync.Mutex
+	ecrTokens map[string]ecrToken
+}
+
+type ecrToken struct {
+	password  string
+	expiresAt time.Time
+}
+
+func newRegistryAuths(hosts []string) *registryAuths {
+	return &registryAuths{
+		hosts:        hosts,
+		dockerConfig: dockerConfigAuth,
+		ecrPassword:  awsECRPassword,
+		now:          time.Now,
+		ecrTokens:    map[string]ecrToken{},
+	}
+}
+
+// Get returns the credentials of host, reporting false for hosts which
+// weren't passed through.
+func (r *registryAuths) Get(ctx context.Context, host string) (types.AuthConfig, bool, error) {
+	if r == nil || !r.passesThrough(host) {
+		return types.AuthConfig{}, false, nil
+	}
+
+	auth, err := r.dockerConfig(host)
+	if err != nil {
+		return types.AuthConfig{}, false, fmt.Errorf("failed reading the Docker credentials of %s: %w", host, err)
+	}
+	if auth.Username != "" || auth.Password != "" || auth.IdentityToken != "" || auth.RegistryToken != "" {
+		auth.ServerAddress = host
+		return auth, true, nil
+	}
+
+	if m := ecrHostPattern.FindStringSubmatch(host); m != nil {
+		password, err := r.ecrToken(ctx, host, m[1])
+		if err != nil {
+			return types.AuthConfig{}, false, err
+		}
+		return types.AuthConfig{Username: "AWS", Password: password, ServerAddress: host}, true, nil
+	}
+
+	return types.AuthConfig{}, false, fmt.Errorf("no Docker credentials found for registry %s, run docker login %s first", host, host)
+}
+
+// All returns the credentials of every host passed through, by host.
+func (r *registryAuths) All(ctx context.Context) (map[string]types.AuthConfig, error) {
+	auths := map[string]types.AuthConfig{}
+	if r == nil {
+		return auths, nil
+	}
+	for _, host := range r.hosts {
+		auth, ok, err := r.Get(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			auths[host] = auth
+		}
+	}
+	return auths, nil
+}
+
+func (r *registryAuths) passesThrough(host string) bool {
+	for _, h := range r.hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *registryAuths) ecrToken(ctx context.Context, host, region string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token, ok := r.ecrTokens[host]; ok && r.now().Before(token.expiresAt) {
+		return token.password, nil
+	}
+
+	password, err := r.ecrPassword(ctx, region)
+	if err != nil {
+		return "", fmt.Errorf("failed getting an ECR token for %s: %w", host, err)
+	}
+	r.ecrTokens[host] = ecrToken{password: password, expiresAt: r.now().Add(ecrTokenTTL)}
+	return password, nil
+}
+
+// buildAuthConfigs returns the credentials of the Fly.io registry and of the
+// private registries passed through to builds.
+func buildAuthConfigs(ctx context.Context, registries *registryAuths) (map[string]types.AuthConfig, error) {
+	auths := authConfigs(config.FromContext(ctx).AccessToken)
+	passedThrough, err := registries.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for host, auth := range passedThrough {
+		auths[host] = auth
+	}
+	return auths, nil
+}
+
+func dockerConfigAuth(host string) (types.AuthConfig, error) {
+	auth, err := dockerconfig.LoadDefaultConfigFile(io.Discard).GetAuthConfig(host)
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+	return types.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		Auth:          auth.Auth,
+		ServerAddress: auth.ServerAddress,
+		IdentityToken: auth.IdentityToken,
+		RegistryToken: auth.RegistryToken,
+	}, nil
+}
+
+func awsECRPassword(ctx context.Context, region string) (string, error) {
+	out, err := exec.CommandContext(ctx, "aws", "ecr", "get-login-password", "--region", region).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("aws ecr get-login-password failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("aws ecr get-login-password failed, is the AWS CLI installed? %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
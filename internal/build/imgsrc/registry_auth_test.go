@@ -0,0 +1,67 @@
+package imgsrc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryAuths(t *testing.T) {
+	const ecrHost = "123456789012.dkr.ecr.eu-west-1.amazonaws.com"
+
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	var ecrCalls []string
+	r := newRegistryAuths([]string{"ghcr.io", ecrHost, "registry.example.com"})
+	r.now = func() time.Time { return now }
+	r.dockerConfig = func(host string) (types.AuthConfig, error) {
+		if host == "ghcr.io" {
+			return types.AuthConfig{Username: "octocat", Password: "secret"}, nil
+		}
+		return types.AuthConfig{}, nil
+	}
+	r.ecrPassword = func(ctx context.Context, region string) (string, error) {
+		ecrCalls = append(ecrCalls, region)
+		return fmt.Sprintf("token-%d", len(ecrCalls)), nil
+	}
+	ctx := context.Background()
+
+	auth, ok, err := r.Get(ctx, "ghcr.io")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, types.AuthConfig{Username: "octocat", Password: "secret", ServerAddress: "ghcr.io"}, auth)
+
+	_, ok, err = r.Get(ctx, "docker.io")
+	require.NoError(t, err)
+	assert.False(t, ok, "hosts not passed through get no credentials")
+
+	_, _, err = r.Get(ctx, "registry.example.com")
+	assert.ErrorContains(t, err, "docker login registry.example.com")
+
+	auth, ok, err = r.Get(ctx, ecrHost)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, types.AuthConfig{Username: "AWS", Password: "token-1", ServerAddress: ecrHost}, auth)
+
+	now = now.Add(time.Hour)
+	auth, _, err = r.Get(ctx, ecrHost)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", auth.Password, "ECR tokens are reused until they're about to expire")
+
+	now = now.Add(ecrTokenTTL)
+	auth, _, err = r.Get(ctx, ecrHost)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", auth.Password, "ECR tokens are refreshed before they expire")
+	assert.Equal(t, []string{"eu-west-1", "eu-west-1"}, ecrCalls)
+}
+
+func TestRegistryAuthsNil(t *testing.T) {
+	var r *registryAuths
+	auths, err := r.All(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, auths)
+}
@@ -34,6 +34,7 @@ type ImageOptions struct {
 	BuildArgs       map[string]string
 	ExtraBuildArgs  map[string]string
 	BuildSecrets    map[string]string
+	RegistryAuths   []string
 	ImageLabel      string
 	Publish         bool
 	Tag             string
@@ -58,6 +59,8 @@ type DeploymentImage struct {
 	ID   string
 	Tag  string
 	Size int64
+	// Digest is the registry digest of the image, when known.
+	Digest string
 }
 
 type Resolver struct {
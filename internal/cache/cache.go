@@ -12,7 +12,6 @@ import (
 
 	"gopkg.in/yaml.v3"
 
-	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/filemu"
 	"github.com/superfly/flyctl/internal/update"
 )
@@ -142,8 +141,10 @@ type wrapper struct {
 	LatestRelease *update.Release `yaml:"latest_release,omitempty"`
 }
 
-func lockPath() string {
-	return filepath.Join(flyctl.ConfigDir(), "flyctl.cache.lock")
+// lockPath returns the path of the lock guarding the files of the directory
+// path is in, the config directory outside of tests.
+func lockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "flyctl.cache.lock")
 }
 
 // Save writes the YAML-encoded representation of c to the named file path via
@@ -165,7 +166,7 @@ func (c *cache) Save(path string) (err error) {
 	}
 
 	var unlock filemu.UnlockFunc
-	if unlock, err = filemu.Lock(context.Background(), lockPath()); err != nil {
+	if unlock, err = filemu.Lock(context.Background(), lockPath(path)); err != nil {
 		return
 	}
 	defer func() {
@@ -183,7 +184,7 @@ func (c *cache) Save(path string) (err error) {
 // Load loads the YAML-encoded cache file at the given path.
 func Load(path string) (c Cache, err error) {
 	var unlock filemu.UnlockFunc
-	if unlock, err = filemu.RLock(context.Background(), lockPath()); err != nil {
+	if unlock, err = filemu.RLock(context.Background(), lockPath(path)); err != nil {
 		return
 	}
 	defer func() {
@@ -0,0 +1,307 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/filemu"
+)
+
+// LookupsFileName denotes the name of the file API lookups are cached in,
+// by each auth context.
+const LookupsFileName = "lookups.json"
+
+const (
+	// AppTTL is how long the organization and platform version of an app are
+	// cached for.
+	AppTTL = 5 * time.Minute
+
+	// MachinesTTL is how long the machines of an app are cached for.
+	MachinesTTL = 15 * time.Second
+)
+
+// Lookups caches the results of API lookups repeated across commands, for
+// short periods of time, on behalf of the identity of a single access token.
+type Lookups struct {
+	mu       sync.RWMutex // protects below
+	dirty    bool
+	identity string
+	apps     map[string]appEntry
+	machines map[string]machinesEntry
+}
+
+type appEntry struct {
+	App      *api.AppBasic `json:"app"`
+	CachedAt time.Time     `json:"cached_at"`
+}
+
+type machinesEntry struct {
+	Machines []*api.Machine `json:"machines"`
+	CachedAt time.Time      `json:"cached_at"`
+}
+
+type lookupsWrapper struct {
+	Identity string                   `json:"identity,omitempty"`
+	Apps     map[string]appEntry      `json:"apps,omitempty"`
+	Machines map[string]machinesEntry `json:"machines,omitempty"`
+}
+
+// LookupsIdentity returns the identity lookups made with the given access
+// token are cached under.
+func LookupsIdentity(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NewLookups initializes and returns a reference to a new, empty, Lookups
+// caching lookups on behalf of the given identity.
+func NewLookups(identity string) *Lookups {
+	return &Lookups{
+		identity: identity,
+		apps:     map[string]appEntry{},
+		machines: map[string]machinesEntry{},
+	}
+}
+
+// Dirty reports whether l has been mutated since being loaded or initialized.
+func (l *Lookups) Dirty() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.dirty
+}
+
+// App returns the app named name, unless it was cached more than AppTTL ago.
+func (l *Lookups) App(name string) *api.AppBasic {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if e, ok := l.apps[name]; ok && time.Since(e.CachedAt) < AppTTL {
+		return e.App
+	}
+	return nil
+}
+
+// SetApp caches app.
+func (l *Lookups) SetApp(app *api.AppBasic) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dirty = true
+	l.apps[app.Name] = appEntry{App: app, CachedAt: time.Now()}
+}
+
+// Machines returns the machines of the app named appName and when they were
+// cached, unless they were cached more than MachinesTTL ago.
+func (l *Lookups) Machines(appName string) ([]*api.Machine, time.Time) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if e, ok := l.machines[appName]; ok && time.Since(e.CachedAt) < MachinesTTL {
+		return e.Machines, e.CachedAt
+	}
+	return nil, time.Time{}
+}
+
+// SetMachines caches the machines of the app named appName.
+func (l *Lookups) SetMachines(appName string, machines []*api.Machine) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dirty = true
+	l.machines[appName] = machinesEntry{Machines: machines, CachedAt: time.Now()}
+}
+
+// ForgetMachines drops the cached machines of the app named appName, after
+// they changed.
+func (l *Lookups) ForgetMachines(appName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.machines[appName]; ok {
+		l.dirty = true
+		delete(l.machines, appName)
+	}
+}
+
+// ForgetApp drops everything cached about the app named name.
+func (l *Lookups) ForgetApp(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dirty = true
+	delete(l.apps, name)
+	delete(l.machines, name)
+}
+
+// Clear drops everything l caches.
+func (l *Lookups) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dirty = true
+	l.apps = map[string]appEntry{}
+	l.machines = map[string]machinesEntry{}
+}
+
+// LookupStatus describes a cached lookup.
+type LookupStatus struct {
+	Kind     string    `json:"kind"`
+	Key      string    `json:"key"`
+	CachedAt time.Time `json:"cached_at"`
+	Fresh    bool      `json:"fresh"`
+}
+
+// Status describes the lookups l caches, fresh or not.
+func (l *Lookups) Status() []LookupStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var status []LookupStatus
+	for name, e := range l.apps {
+		status = append(status, LookupStatus{
+			Kind:     "app",
+			Key:      name,
+			CachedAt: e.CachedAt,
+			Fresh:    time.Since(e.CachedAt) < AppTTL,
+		})
+	}
+	for name, e := range l.machines {
+		status = append(status, LookupStatus{
+			Kind:     "machines",
+			Key:      name,
+			CachedAt: e.CachedAt,
+			Fresh:    time.Since(e.CachedAt) < MachinesTTL,
+		})
+	}
+	return status
+}
+
+// Save writes the JSON-encoded representation of l to the named file path,
+// leaving out the lookups which expired.
+func (l *Lookups) Save(path string) (err error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	w := lookupsWrapper{
+		Identity: l.identity,
+		Apps:     map[string]appEntry{},
+		Machines: map[string]machinesEntry{},
+	}
+	for name, e := range l.apps {
+		if time.Since(e.CachedAt) < AppTTL {
+			w.Apps[name] = e
+		}
+	}
+	for name, e := range l.machines {
+		if time.Since(e.CachedAt) < MachinesTTL {
+			w.Machines[name] = e
+		}
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return
+	}
+
+	var unlock filemu.UnlockFunc
+	if unlock, err = filemu.Lock(context.Background(), lockPath(path)); err != nil {
+		return
+	}
+	defer func() {
+		if e := unlock(); err == nil {
+			err = e
+		}
+	}()
+
+	err = os.WriteFile(path, data, 0o600)
+
+	return
+}
+
+// LoadLookups loads the JSON-encoded lookups file at the given path on behalf
+// of the given identity. A missing file, or one cached on behalf of another
+// identity, loads empty Lookups.
+func LoadLookups(path, identity string) (l *Lookups, err error) {
+	var unlock filemu.UnlockFunc
+	if unlock, err = filemu.RLock(context.Background(), lockPath(path)); err != nil {
+		return
+	}
+	defer func() {
+		if e := unlock(); err == nil {
+			err = e
+		}
+	}()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewLookups(identity), nil
+	} else if err != nil {
+		return
+	}
+
+	var w lookupsWrapper
+	if err = json.Unmarshal(data, &w); err != nil {
+		return
+	}
+
+	l = NewLookups(identity)
+	if w.Identity != identity {
+		// The token changed, so drop what the previous one looked up
+		l.dirty = true
+		return
+	}
+	for name, e := range w.Apps {
+		l.apps[name] = e
+	}
+	for name, e := range w.Machines {
+		l.machines[name] = e
+	}
+	return
+}
+
+type lookupsContextKey struct{}
+
+// NewLookupsContext derives a context that carries l from ctx.
+func NewLookupsContext(ctx context.Context, l *Lookups) context.Context {
+	return context.WithValue(ctx, lookupsContextKey{}, l)
+}
+
+// LookupsFromContext returns the Lookups ctx carries, or nil in case it
+// carries none.
+func LookupsFromContext(ctx context.Context) *Lookups {
+	l, _ := ctx.Value(lookupsContextKey{}).(*Lookups)
+	return l
+}
+
+// GetAppBasic returns the app named appName, from the Lookups ctx carries when
+// it was looked up in the last AppTTL.
+func GetAppBasic(ctx context.Context, appName string) (*api.AppBasic, error) {
+	l := LookupsFromContext(ctx)
+	if l != nil {
+		if app := l.App(appName); app != nil {
+			return app, nil
+		}
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppBasic(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	if l != nil {
+		l.SetApp(app)
+	}
+	return app, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestLookupsExpire(t *testing.T) {
+	l := NewLookups("id")
+	l.SetApp(&api.AppBasic{Name: "app", PlatformVersion: "machines"})
+	l.SetMachines("app", []*api.Machine{{ID: "m1"}})
+
+	assert.NotNil(t, l.App("app"))
+	machines, cachedAt := l.Machines("app")
+	assert.Len(t, machines, 1)
+	assert.WithinDuration(t, time.Now(), cachedAt, time.Second)
+
+	l.machines["app"] = machinesEntry{CachedAt: time.Now().Add(-MachinesTTL)}
+	machines, cachedAt = l.Machines("app")
+	assert.Nil(t, machines)
+	assert.True(t, cachedAt.IsZero())
+
+	l.ForgetApp("app")
+	assert.Nil(t, l.App("app"))
+}
+
+func TestLookupsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LookupsFileName)
+
+	l := NewLookups("id")
+	l.SetApp(&api.AppBasic{Name: "fresh"})
+	l.apps["stale"] = appEntry{App: &api.AppBasic{Name: "stale"}, CachedAt: time.Now().Add(-AppTTL)}
+	require.NoError(t, l.Save(path))
+
+	loaded, err := LoadLookups(path, "id")
+	require.NoError(t, err)
+	assert.False(t, loaded.Dirty())
+	assert.NotNil(t, loaded.App("fresh"))
+	assert.Len(t, loaded.Status(), 1)
+
+	loaded, err = LoadLookups(filepath.Join(t.TempDir(), LookupsFileName), "id")
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Status())
+}
+
+func TestLookupsOfAnotherIdentity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LookupsFileName)
+
+	l := NewLookups(LookupsIdentity("token"))
+	l.SetApp(&api.AppBasic{Name: "app"})
+	l.SetMachines("app", []*api.Machine{{ID: "m1"}})
+	require.NoError(t, l.Save(path))
+
+	loaded, err := LoadLookups(path, LookupsIdentity("other token"))
+	require.NoError(t, err)
+	assert.True(t, loaded.Dirty())
+	assert.Nil(t, loaded.App("app"))
+	assert.Empty(t, loaded.Status())
+
+	require.NoError(t, loaded.Save(path))
+	loaded, err = LoadLookups(path, LookupsIdentity("token"))
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Status())
+}
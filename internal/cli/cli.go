@@ -46,17 +46,17 @@ func Run(ctx context.Context, io *iostreams.IOStreams, args ...string) int {
 	switch {
 	case err == nil:
 		metrics.RecordCommandFinish(cmd)
-		return 0
+		return flyerr.ExitCodeOK
 	case errors.Is(err, context.Canceled), errors.Is(err, terminal.InterruptErr):
-		return 127
+		return flyerr.ExitCodeCanceled
 	case errors.Is(err, context.DeadlineExceeded):
 		printError(io.ErrOut, cs, cmd, err)
-		return 126
+		return flyerr.ExitCodeTimeout
 	case isUnchangedError(err):
 		// This means the deployment was a noop, which is noteworthy but not something we should
 		// fail CI on. Print a warning and exit 0. Remove this once we're fully on Machines!
 		printError(io.ErrOut, cs, cmd, err)
-		return 0
+		return flyerr.ExitCodeOK
 	default:
 		printError(io.ErrOut, cs, cmd, err)
 
@@ -64,9 +64,11 @@ func Run(ctx context.Context, io *iostreams.IOStreams, args ...string) int {
 		if e != nil {
 			fmt.Printf("Run '%v --help' for usage.\n", cmd.CommandPath())
 			fmt.Println()
+
+			return flyerr.ExitCodeValidation
 		}
 
-		return 1
+		return flyerr.ExitCode(err)
 	}
 }
 
@@ -84,6 +86,8 @@ func isUnchangedError(err error) bool {
 func printError(w io.Writer, cs *iostreams.ColorScheme, cmd *cobra.Command, err error) {
 	var b bytes.Buffer
 
+	err = flyerr.Remediate(err)
+
 	fmt.Fprintln(&b, cs.Red("Error:"), err)
 	fmt.Fprintln(&b)
 
@@ -9,12 +9,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag/flagctx"
+	"github.com/superfly/flyctl/internal/flag/flagnames"
 	"github.com/superfly/flyctl/internal/httptracing"
 	"github.com/superfly/flyctl/internal/instrument"
 	"github.com/superfly/flyctl/internal/logger"
@@ -34,9 +36,39 @@ func LoadConfig(ctx context.Context) (context.Context, error) {
 
 	cfg := config.New()
 
-	// Apply config from the config file, if it exists
-	path := filepath.Join(state.ConfigDirectory(ctx), config.FileName)
-	if err := cfg.ApplyFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+	name, err := determineAuthContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = state.WithAuthContext(ctx, name)
+	cfg.Context = name
+
+	// Apply config from the config file of the auth context, if it exists
+	path := state.ConfigFile(ctx)
+	var fallback *config.KeyringFallbackError
+	switch migrated, err := config.MigrateAccessToken(path); {
+	case errors.As(err, &fallback):
+		logger.Warn(err)
+	case err != nil:
+		logger.Debugf("failed moving the access token of %s to the keyring: %v", path, err)
+	case migrated:
+		logger.Infof("Moved the access token of %s to the keyring of the operating system", path)
+	}
+	switch err := cfg.ApplyFile(path); {
+	case err == nil, errors.Is(err, fs.ErrNotExist):
+		break
+	case errors.Is(err, config.ErrKeyringUnavailable):
+		// Carry on logged out, for auth login to store a new token
+		logger.Warnf("%v, run `fly auth login` to log in again", err)
+	default:
+		return nil, err
+	}
+
+	// Apply the settings shared by all auth contexts, such as deletion locks
+	switch err := cfg.ApplySharedFile(state.SharedConfigFile(ctx)); {
+	case err == nil, errors.Is(err, fs.ErrNotExist):
+		break
+	default:
 		return nil, err
 	}
 
@@ -51,6 +83,39 @@ func LoadConfig(ctx context.Context) (context.Context, error) {
 	return config.NewContext(ctx, cfg), nil
 }
 
+// determineAuthContext returns the name of the auth context set by flag,
+// environment or the configuration file of the config directory, in that
+// order of precedence.
+func determineAuthContext(ctx context.Context) (string, error) {
+	dir := state.ConfigDirectory(ctx)
+
+	name, _ := flagctx.FromContext(ctx).GetString(flagnames.Context)
+	if name == "" {
+		name = os.Getenv(config.ContextEnvKey)
+	}
+	if name == "" {
+		current, err := config.CurrentContext(config.ContextFile(dir, config.DefaultContext))
+		if err != nil {
+			return "", err
+		}
+		name = current
+	}
+
+	if name == config.DefaultContext {
+		return name, nil
+	}
+	if err := config.ValidateContextName(name); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(config.ContextFile(dir, name)); errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("unknown auth context %s, create it with fly auth switch %s", name, name)
+	}
+
+	logger.FromContext(ctx).Debugf("determined auth context: %q", name)
+
+	return name, nil
+}
+
 func InitClient(ctx context.Context) (context.Context, error) {
 	logger := logger.FromContext(ctx)
 	cfg := config.FromContext(ctx)
@@ -61,12 +126,54 @@ func InitClient(ctx context.Context) (context.Context, error) {
 	api.SetInstrumenter(instrument.ApiAdapter)
 	api.SetTransport(httptracing.NewTransport(http.DefaultTransport))
 
+	refreshSSOSession(ctx, cfg)
+
 	c := client.FromToken(cfg.AccessToken)
 	logger.Debug("client initialized.")
 
 	return client.NewContext(ctx, c), nil
 }
 
+// refreshSSOSession silently renews the short-lived access token of SSO
+// logins before it expires.
+func refreshSSOSession(ctx context.Context, cfg *config.Config) {
+	session := cfg.SSOSession
+	if !session.NeedsRefresh(time.Now()) {
+		return
+	}
+
+	logger := logger.FromContext(ctx)
+
+	auth, err := api.RefreshCLISession(ctx, session.RefreshToken)
+	if err != nil {
+		logger.Warnf("failed refreshing the SSO session of %s, log in again with fly auth login --sso %s: %v",
+			session.Organization, session.Organization, err)
+		return
+	}
+
+	renewed := &config.SSOSession{
+		Organization: session.Organization,
+		RefreshToken: session.RefreshToken,
+	}
+	if auth.RefreshToken != "" {
+		renewed.RefreshToken = auth.RefreshToken
+	}
+	if auth.ExpiresAt != nil {
+		renewed.ExpiresAt = *auth.ExpiresAt
+	}
+	cfg.RenewSSOSession(auth.AccessToken, renewed)
+
+	path := state.ConfigFile(ctx)
+	var fallback *config.KeyringFallbackError
+	switch err := config.SetSSOSession(path, auth.AccessToken, renewed); {
+	case errors.As(err, &fallback):
+		logger.Warn(err)
+	case err != nil:
+		logger.Warnf("failed persisting the refreshed SSO session in %s: %v", path, err)
+	}
+	logger.Debug("SSO session refreshed.")
+}
+
 func DetermineConfigDir(ctx context.Context) (context.Context, error) {
 	dir := filepath.Join(state.UserHomeDirectory(ctx), ".fly")
 
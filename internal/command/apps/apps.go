@@ -41,6 +41,14 @@ The LIST command will list all currently registered applications.
 		NewOpen(),
 		NewReleases(),
 		newSetPlatformVersion(),
+		newProtect(),
+		newUnprotect(),
+		newMaintenance(),
+		newInventory(),
+		newFork(),
+		newRename(),
+		newStats(),
+		newScaffold(),
 	)
 
 	return apps
@@ -10,9 +10,14 @@ import (
 	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/cache"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/protect"
+	"github.com/superfly/flyctl/terminal"
 )
 
 func newDestroy() *cobra.Command {
@@ -31,6 +36,11 @@ from the Fly platform.
 
 	flag.Add(destroy,
 		flag.Yes(),
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Only show the resources and apps affected by destroying the app",
+		},
+		protect.ForceFlag(),
 	)
 
 	destroy.ValidArgsFunction = completion.Adapt(completion.CompleteApps)
@@ -45,7 +55,29 @@ func RunDestroy(ctx context.Context) error {
 	appName := flag.FirstArg(ctx)
 	client := client.FromContext(ctx).API()
 
+	if flag.GetBool(ctx, "dry-run") {
+		report, err := buildDestroyReport(ctx, appName)
+		if err != nil {
+			return err
+		}
+		return report.render(io.Out)
+	}
+
+	if err := protect.CheckDestroy(ctx, config.ProtectedApp, appName); err != nil {
+		return err
+	}
+	if err := checkProtectedResources(ctx, appName); err != nil {
+		return err
+	}
+
 	if !flag.GetYes(ctx) {
+		// Show what's affected before asking, without blocking on failures
+		if report, err := buildDestroyReport(ctx, appName); err == nil {
+			report.render(io.ErrOut)
+		} else {
+			terminal.Debugf("failed building destroy report for %s: %v\n", appName, err)
+		}
+
 		const msg = "Destroying an app is not reversible."
 		fmt.Fprintln(io.ErrOut, colorize.Red(msg))
 
@@ -64,8 +96,47 @@ func RunDestroy(ctx context.Context) error {
 	if err := client.DeleteApp(ctx, appName); err != nil {
 		return err
 	}
+	if lookups := cache.LookupsFromContext(ctx); lookups != nil {
+		lookups.ForgetApp(appName)
+	}
 
 	fmt.Fprintf(io.Out, "Destroyed app %s\n", appName)
 
 	return nil
 }
+
+// checkProtectedResources runs the deletion checks of the machines and volumes
+// of the app named appName, which are destroyed along with it.
+func checkProtectedResources(ctx context.Context, appName string) error {
+	cfg := config.FromContext(ctx)
+
+	if cfg.HasProtected(config.ProtectedMachine) {
+		flapsClient, err := flaps.NewFromAppName(ctx, appName)
+		if err != nil {
+			return err
+		}
+		machines, err := flapsClient.List(ctx, "")
+		if err != nil {
+			return fmt.Errorf("failed retrieving machines: %w", err)
+		}
+		for _, m := range machines {
+			if err := protect.CheckDestroy(ctx, config.ProtectedMachine, m.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.HasProtected(config.ProtectedVolume) {
+		volumes, err := client.FromContext(ctx).API().GetVolumes(ctx, appName)
+		if err != nil {
+			return fmt.Errorf("failed retrieving volumes: %w", err)
+		}
+		for _, v := range volumes {
+			if err := protect.CheckDestroy(ctx, config.ProtectedVolume, v.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
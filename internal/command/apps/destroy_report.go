@@ -0,0 +1,167 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command/logs"
+	"github.com/superfly/flyctl/internal/render"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentAttachmentLookups bounds the number of apps checked for
+// postgres attachments at once.
+const maxConcurrentAttachmentLookups = 8
+
+// destroyReport lists everything affected by destroying an app.
+type destroyReport struct {
+	app           *api.AppCompact
+	resources     [][]string
+	dependents    [][]string
+	isLogShipper  bool
+	shippedByApps int
+}
+
+// buildDestroyReport gathers the resources destroyed along with app and the
+// apps depending on it.
+func buildDestroyReport(ctx context.Context, appName string) (*destroyReport, error) {
+	var (
+		client    = client.FromContext(ctx).API()
+		genqlient = client.GenqClient
+	)
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	report := &destroyReport{app: app}
+
+	volumes, err := client.GetVolumes(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving volumes: %w", err)
+	}
+	for _, v := range volumes {
+		report.resources = append(report.resources, []string{"Volume", v.ID, fmt.Sprintf("%s, %dGB in %s", v.Name, v.SizeGb, v.Region)})
+	}
+
+	ips, err := client.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving IP addresses: %w", err)
+	}
+	for _, ip := range ips {
+		report.resources = append(report.resources, []string{"IP Address", ip.Address, ip.Type})
+	}
+
+	certs, err := client.GetAppCertificates(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving certificates: %w", err)
+	}
+	for _, cert := range certs {
+		report.resources = append(report.resources, []string{"Certificate", cert.Hostname, cert.ClientStatus})
+	}
+
+	for _, addOnType := range []gql.AddOnType{
+		gql.AddOnTypeLogtail,
+		gql.AddOnTypePlanetscale,
+		gql.AddOnTypeRedis,
+		gql.AddOnTypeSentry,
+		gql.AddOnTypeUpstashRedis,
+	} {
+		resp, err := gql.GetAppWithAddons(ctx, genqlient, appName, addOnType)
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving %s add-ons: %w", addOnType, err)
+		}
+		for _, addOn := range resp.App.AddOns.Nodes {
+			report.resources = append(report.resources, []string{"Add-on", addOn.Name, string(addOnType)})
+		}
+	}
+
+	if app.IsPostgresApp() {
+		if report.dependents, err = postgresConsumers(ctx, app); err != nil {
+			return nil, err
+		}
+	}
+
+	shippers, err := gql.GetAppsByRole(ctx, genqlient, logs.ShipperAppRole, app.Organization.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving log shippers: %w", err)
+	}
+	for _, shipper := range shippers.Apps.Nodes {
+		if shipper.Name == appName {
+			report.isLogShipper = true
+		}
+	}
+
+	return report, nil
+}
+
+// postgresConsumers returns the apps of the organization attached to the
+// postgres app pg, along with the secret holding their connection string.
+func postgresConsumers(ctx context.Context, pg *api.AppCompact) ([][]string, error) {
+	client := client.FromContext(ctx).API()
+
+	apps, err := client.GetAppsForOrganization(ctx, pg.Organization.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving organization apps: %w", err)
+	}
+
+	var (
+		mu        sync.Mutex
+		consumers [][]string
+	)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxConcurrentAttachmentLookups)
+
+	for _, app := range apps {
+		app := app
+		if app.ID == pg.ID {
+			continue
+		}
+
+		eg.Go(func() error {
+			attachments, err := client.ListPostgresClusterAttachments(ctx, app.ID, pg.ID)
+			if err != nil {
+				return fmt.Errorf("failed retrieving postgres attachments of %s: %w", app.Name, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, a := range attachments {
+				consumers = append(consumers, []string{app.Name, fmt.Sprintf("%s secret (database %s)", a.EnvironmentVariableName, a.DatabaseName)})
+			}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+func (r *destroyReport) render(w io.Writer) error {
+	fmt.Fprintf(w, "Destroying %s will also remove:\n", r.app.Name)
+	if len(r.resources) == 0 {
+		fmt.Fprintln(w, "  No volumes, IP addresses, certificates or add-ons")
+	} else if err := render.Table(w, "", r.resources, "Kind", "Name", "Details"); err != nil {
+		return err
+	}
+
+	if len(r.dependents) > 0 {
+		if err := render.Table(w, "Apps depending on "+r.app.Name, r.dependents, "App", "Reference"); err != nil {
+			return err
+		}
+	}
+
+	if r.isLogShipper {
+		fmt.Fprintf(w, "%s is the log shipper of the %s organization; logs of apps shipped through it will stop being delivered.\n", r.app.Name, r.app.Organization.Slug)
+	}
+
+	return nil
+}
@@ -0,0 +1,335 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// restoreBytesPerSecond is a conservative rate at which volumes are restored
+// from snapshots, to estimate how long forking takes.
+const restoreBytesPerSecond = 50 << 20
+
+func newFork() *cobra.Command {
+	const (
+		long = `Fork an app into a new app, launching a copy of each of its machines with
+the same image and configuration.
+
+With --with-volumes, the volumes of the new app are restored from the latest
+snapshot of the volumes they copy, to reproduce production issues on an
+isolated copy of its data. Otherwise they start empty. The size of the data and
+an estimate of the time it takes to restore are shown before starting.
+
+Secrets and IP addresses aren't copied: the new app only gets the names of the
+secrets to set, and is only reachable over the private network until it's
+allocated addresses.`
+		short = "Fork an app into a new app"
+		usage = "fork <new app name>"
+	)
+
+	cmd := command.New(usage, short, long, runFork,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Org(),
+		flag.Yes(),
+		flag.Bool{
+			Name:        "with-volumes",
+			Description: "Restore the volumes of the new app from snapshots of the app's volumes",
+		},
+	)
+
+	return cmd
+}
+
+// forkedVolume is a volume of the new app, copying a volume of the app.
+type forkedVolume struct {
+	source   *api.Volume
+	snapshot *api.Snapshot
+}
+
+// forkPlan lists what forking an app creates.
+type forkPlan struct {
+	machines []*api.Machine
+	volumes  map[string]*forkedVolume
+}
+
+// restoreSize returns the number of bytes restored from snapshots.
+func (p *forkPlan) restoreSize() (size uint64) {
+	for _, v := range p.volumes {
+		if v.snapshot == nil {
+			continue
+		}
+		if n, err := strconv.ParseUint(v.snapshot.Size, 10, 64); err == nil {
+			size += n
+		}
+	}
+	return
+}
+
+// estimateRestore returns roughly how long restoring size bytes takes.
+func estimateRestore(size uint64) time.Duration {
+	return (time.Duration(size/restoreBytesPerSecond) * time.Second).Round(time.Minute) + time.Minute
+}
+
+func runFork(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		newName   = flag.FirstArg(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("only apps on the machines platform can be forked")
+	}
+	if app.IsPostgresApp() {
+		return fmt.Errorf("fork Postgres apps with fly postgres create --fork-from")
+	}
+
+	orgID, orgSlug := app.Organization.ID, app.Organization.Slug
+	if flag.GetOrg(ctx) != "" {
+		org, err := prompt.Org(ctx)
+		if err != nil {
+			return err
+		}
+		orgID, orgSlug = org.ID, org.Slug
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	plan, err := planFork(ctx, flapsClient, flag.GetBool(ctx, "with-volumes"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Forking %s into %s in %s: %d machines and %d volumes\n", appName, newName, orgSlug, len(plan.machines), len(plan.volumes))
+	if flag.GetBool(ctx, "with-volumes") {
+		size := plan.restoreSize()
+		fmt.Fprintf(io.Out, "Restoring %s from snapshots, which takes about %s\n", humanize.Bytes(size), estimateRestore(size))
+	}
+	for _, v := range plan.volumes {
+		if flag.GetBool(ctx, "with-volumes") && v.snapshot == nil {
+			fmt.Fprintf(io.ErrOut, "Volume %s has no snapshot, its copy will start empty\n", v.source.ID)
+		}
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirm(ctx, "Fork the app?"); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	costDelta := func(prices budget.Prices) float64 {
+		var cost float64
+		for _, m := range plan.machines {
+			cost += prices.Machine(m.Config.Guest)
+		}
+		for _, v := range plan.volumes {
+			cost += prices.Volume(v.source.SizeGb)
+		}
+		return cost
+	}
+	if err := budget.Check(ctx, orgSlug, newName, "Forking "+appName, costDelta); err != nil {
+		return err
+	}
+
+	if _, err := forkApp(ctx, plan, appName, orgID, newName); err != nil {
+		return err
+	}
+
+	if err := printSecretsToSet(ctx, appName, newName); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.Out, "Make it reachable from the Internet with fly ips allocate-v6 -a %s\n", newName)
+
+	return nil
+}
+
+// forkApp creates the app newName in the organization orgID, with the
+// machines and volumes of plan copying those of appName.
+func forkApp(ctx context.Context, plan *forkPlan, appName, orgID, newName string) (*api.AppCompact, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = orgID
+	input.Name = newName
+
+	if _, err := gql.CreateApp(ctx, apiClient.GenqClient, input); err != nil {
+		return nil, fmt.Errorf("failed creating app %s: %w", newName, err)
+	}
+	fmt.Fprintf(io.Out, "Created app %s\n", newName)
+
+	newApp, err := apiClient.GetAppCompact(ctx, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := executeFork(ctx, plan, newApp); err != nil {
+		return nil, fmt.Errorf("failed forking %s, destroy the partial copy with fly apps destroy %s: %w", appName, newName, err)
+	}
+
+	fmt.Fprintf(io.Out, "Forked %s into %s\n", appName, newName)
+	return newApp, nil
+}
+
+// printSecretsToSet prints the command setting the secrets of appName on
+// newName, as their values can't be read back.
+func printSecretsToSet(ctx context.Context, appName, newName string) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	secrets, err := apiClient.GetAppSecrets(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if len(secrets) > 0 {
+		names := lo.Map(secrets, func(s api.Secret, _ int) string { return s.Name + "=..." })
+		fmt.Fprintf(io.Out, "Set its secrets with fly secrets set -a %s %s\n", newName, strings.Join(names, " "))
+	}
+	return nil
+}
+
+// planFork lists the machines and volumes to copy. Release command and
+// console machines are left out, as they're transient.
+func planFork(ctx context.Context, flapsClient *flaps.Client, withVolumes bool) (*forkPlan, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &forkPlan{volumes: map[string]*forkedVolume{}}
+	for _, m := range machines {
+		if m.Config == nil || m.IsReleaseCommandMachine() || m.HasProcessGroup(api.MachineProcessGroupFlyAppConsole) {
+			continue
+		}
+		plan.machines = append(plan.machines, m)
+
+		for _, mount := range m.Config.Mounts {
+			vol, err := apiClient.GetVolume(ctx, mount.Volume)
+			if err != nil {
+				return nil, fmt.Errorf("failed retrieving volume %s: %w", mount.Volume, err)
+			}
+			fv := &forkedVolume{source: vol}
+
+			if withVolumes {
+				snapshots, err := apiClient.GetVolumeSnapshots(ctx, vol.ID)
+				if err != nil {
+					return nil, fmt.Errorf("failed retrieving snapshots of volume %s: %w", vol.ID, err)
+				}
+				if len(snapshots) > 0 {
+					latest := lo.MaxBy(snapshots, func(i, j api.Snapshot) bool { return i.CreatedAt.After(j.CreatedAt) })
+					fv.snapshot = &latest
+				}
+			}
+
+			plan.volumes[vol.ID] = fv
+		}
+	}
+
+	if len(plan.machines) == 0 {
+		return nil, fmt.Errorf("the app has no machines to fork")
+	}
+	return plan, nil
+}
+
+// executeFork creates the volumes and machines of plan in newApp.
+func executeFork(ctx context.Context, plan *forkPlan, newApp *api.AppCompact) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	flapsClient, err := flaps.New(ctx, newApp)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range plan.machines {
+		config := mach.CloneConfig(m.Config)
+		delete(config.Metadata, api.MachineConfigMetadataKeyFlyReleaseId)
+		delete(config.Metadata, api.MachineConfigMetadataKeyFlyReleaseVersion)
+		// Standbys point at machines of the app
+		config.Standbys = nil
+
+		for i, mount := range config.Mounts {
+			fv := plan.volumes[mount.Volume]
+
+			input := api.CreateVolumeInput{
+				AppID:     newApp.ID,
+				Name:      fv.source.Name,
+				Region:    fv.source.Region,
+				SizeGb:    fv.source.SizeGb,
+				Encrypted: fv.source.Encrypted,
+			}
+			if fv.snapshot != nil {
+				input.SnapshotID = &fv.snapshot.ID
+			}
+
+			vol, err := apiClient.CreateVolume(ctx, input)
+			if err != nil {
+				return fmt.Errorf("failed creating copy of volume %s: %w", fv.source.ID, err)
+			}
+			config.Mounts[i].Volume = vol.ID
+			fmt.Fprintf(io.Out, "Created volume %s from %s\n", vol.ID, fv.source.ID)
+		}
+
+		machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+			Name:   m.Name,
+			Region: m.Region,
+			Config: config,
+		})
+		if err != nil {
+			return fmt.Errorf("failed launching copy of machine %s: %w", m.ID, err)
+		}
+		fmt.Fprintf(io.Out, "Launched machine %s from %s\n", machine.ID, m.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestForkRestoreEstimate(t *testing.T) {
+	plan := &forkPlan{volumes: map[string]*forkedVolume{
+		"vol_a": {source: &api.Volume{ID: "vol_a"}, snapshot: &api.Snapshot{Size: "3221225472"}},
+		"vol_b": {source: &api.Volume{ID: "vol_b"}, snapshot: &api.Snapshot{Size: "1073741824"}},
+		"vol_c": {source: &api.Volume{ID: "vol_c"}},
+	}}
+
+	size := plan.restoreSize()
+	assert.Equal(t, uint64(4<<30), size)
+	assert.Equal(t, 2*time.Minute, estimateRestore(size))
+	assert.Equal(t, time.Minute, estimateRestore(0))
+}
@@ -0,0 +1,339 @@
+package apps
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newInventory() *cobra.Command {
+	const (
+		long = `Export every resource of an organization: apps, machines, volumes, IP
+addresses, certificates, secret names, add-ons and access tokens, as JSON or
+CSV for asset inventories and compliance reports.
+
+Secret values and tokens are never exported, only their names and metadata.`
+		short = "Export the resources of an organization"
+	)
+
+	cmd := command.New("inventory", short, long, runInventory,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly apps inventory --org my-org > inventory.json
+  fly apps inventory --org my-org --format csv --output inventory.csv`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.String{
+			Name:        "format",
+			Description: "Output format, json or csv",
+			Default:     "json",
+		},
+		flag.String{
+			Name:        "output",
+			Description: "Write the inventory to this file instead of stdout",
+		},
+	)
+
+	return cmd
+}
+
+// inventoryItem is a resource of an organization.
+type inventoryItem struct {
+	Type      string            `json:"type"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	App       string            `json:"app,omitempty"`
+	Region    string            `json:"region,omitempty"`
+	State     string            `json:"state,omitempty"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+type inventory struct {
+	Organization string          `json:"organization"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+	Resources    []inventoryItem `json:"resources"`
+}
+
+// inventoryAddOnTypes are the types of add-ons listed in inventories.
+var inventoryAddOnTypes = []gql.AddOnType{
+	gql.AddOnTypeLogtail,
+	gql.AddOnTypePlanetscale,
+	gql.AddOnTypeRedis,
+	gql.AddOnTypeSentry,
+	gql.AddOnTypeUpstashRedis,
+}
+
+func runInventory(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		format    = flag.GetString(ctx, "format")
+	)
+
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("invalid format %q, expected json or csv", format)
+	}
+
+	selected, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	// OrgFromFlagOrSelect doesn't fetch tokens
+	org, err := apiClient.GetOrganizationBySlug(ctx, selected.Slug)
+	if err != nil {
+		return err
+	}
+
+	inv := inventory{Organization: org.Slug, GeneratedAt: time.Now().UTC()}
+
+	apps, err := apiClient.GetAppsForOrganization(ctx, org.ID)
+	if err != nil {
+		return fmt.Errorf("failed listing apps: %w", err)
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+
+	for _, app := range apps {
+		fmt.Fprintf(io.ErrOut, "Collecting resources of %s\n", app.Name)
+		items, err := appInventory(ctx, app)
+		if err != nil {
+			return fmt.Errorf("failed collecting resources of %s: %w", app.Name, err)
+		}
+		inv.Resources = append(inv.Resources, items...)
+	}
+
+	for _, addOnType := range inventoryAddOnTypes {
+		resp, err := gql.ListAddOns(ctx, apiClient.GenqClient, addOnType)
+		if err != nil {
+			return fmt.Errorf("failed listing %s add-ons: %w", addOnType, err)
+		}
+		for _, addOn := range resp.AddOns.Nodes {
+			if addOn.Organization.Slug != org.Slug {
+				continue
+			}
+			inv.Resources = append(inv.Resources, inventoryItem{
+				Type:   "add_on",
+				ID:     addOn.Id,
+				Name:   addOn.Name,
+				Region: addOn.PrimaryRegion,
+				Details: map[string]string{
+					"type":         string(addOnType),
+					"plan":         addOn.AddOnPlan.DisplayName,
+					"read_regions": strings.Join(addOn.ReadRegions, " "),
+				},
+			})
+		}
+	}
+
+	if org.LimitedAccessTokens != nil {
+		for _, token := range org.LimitedAccessTokens.Nodes {
+			expiresAt := token.ExpiresAt
+			inv.Resources = append(inv.Resources, inventoryItem{
+				Type:    "token",
+				ID:      token.Id,
+				Name:    token.Name,
+				Details: map[string]string{"expires_at": expiresAt.Format(time.RFC3339)},
+			})
+		}
+	}
+
+	out := io.Out
+	if path := flag.GetString(ctx, "output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if format == "csv" {
+		return writeInventoryCSV(out, inv.Resources)
+	}
+	return render.JSON(out, inv)
+}
+
+// appInventory returns the app and the resources it holds.
+func appInventory(ctx context.Context, app api.App) ([]inventoryItem, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	items := []inventoryItem{{
+		Type:  "app",
+		ID:    app.ID,
+		Name:  app.Name,
+		State: app.Status,
+		Details: map[string]string{
+			"platform_version": app.PlatformVersion,
+			"hostname":         app.Hostname,
+		},
+	}}
+
+	if app.PlatformVersion == "machines" {
+		flapsClient, err := flaps.NewFromAppName(ctx, app.Name)
+		if err != nil {
+			return nil, err
+		}
+		machines, err := flapsClient.List(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range machines {
+			item := inventoryItem{
+				Type:   "machine",
+				ID:     m.ID,
+				Name:   m.Name,
+				App:    app.Name,
+				Region: m.Region,
+				State:  m.State,
+			}
+			if createdAt, err := time.Parse(time.RFC3339, m.CreatedAt); err == nil {
+				item.CreatedAt = &createdAt
+			}
+			if m.Config != nil {
+				item.Details = map[string]string{
+					"image":         m.Config.Image,
+					"process_group": m.ProcessGroup(),
+				}
+				if guest := m.Config.Guest; guest != nil {
+					item.Details["size"] = guest.ToSize()
+					item.Details["memory_mb"] = strconv.Itoa(guest.MemoryMB)
+				}
+			}
+			items = append(items, item)
+		}
+	}
+
+	volumes, err := apiClient.GetVolumes(ctx, app.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range volumes {
+		createdAt := v.CreatedAt
+		details := map[string]string{
+			"size_gb":   strconv.Itoa(v.SizeGb),
+			"encrypted": strconv.FormatBool(v.Encrypted),
+		}
+		if v.AttachedMachine != nil {
+			details["attached_machine"] = v.AttachedMachine.ID
+		}
+		items = append(items, inventoryItem{
+			Type:      "volume",
+			ID:        v.ID,
+			Name:      v.Name,
+			App:       app.Name,
+			Region:    v.Region,
+			State:     v.State,
+			CreatedAt: &createdAt,
+			Details:   details,
+		})
+	}
+
+	ips, err := apiClient.GetIPAddresses(ctx, app.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		createdAt := ip.CreatedAt
+		items = append(items, inventoryItem{
+			Type:      "ip_address",
+			ID:        ip.ID,
+			Name:      ip.Address,
+			App:       app.Name,
+			Region:    ip.Region,
+			CreatedAt: &createdAt,
+			Details:   map[string]string{"type": ip.Type},
+		})
+	}
+
+	certs, err := apiClient.GetAppCertificates(ctx, app.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		createdAt := cert.CreatedAt
+		items = append(items, inventoryItem{
+			Type:      "certificate",
+			ID:        cert.Hostname,
+			Name:      cert.Hostname,
+			App:       app.Name,
+			State:     cert.ClientStatus,
+			CreatedAt: &createdAt,
+		})
+	}
+
+	secrets, err := apiClient.GetAppSecrets(ctx, app.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		createdAt := secret.CreatedAt
+		items = append(items, inventoryItem{
+			Type:      "secret",
+			ID:        secret.Name,
+			Name:      secret.Name,
+			App:       app.Name,
+			CreatedAt: &createdAt,
+			Details:   map[string]string{"digest": secret.Digest},
+		})
+	}
+
+	return items, nil
+}
+
+// writeInventoryCSV writes items as CSV, with details as key=value pairs.
+func writeInventoryCSV(w io.Writer, items []inventoryItem) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"type", "id", "name", "app", "region", "state", "created_at", "details"}); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var createdAt string
+		if item.CreatedAt != nil {
+			createdAt = item.CreatedAt.UTC().Format(time.RFC3339)
+		}
+
+		keys := make([]string, 0, len(item.Details))
+		for k := range item.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		details := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if item.Details[k] != "" {
+				details = append(details, k+"="+item.Details[k])
+			}
+		}
+
+		record := []string{item.Type, item.ID, item.Name, item.App, item.Region, item.State, createdAt, strings.Join(details, ";")}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,28 @@
+package apps
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteInventoryCSV(t *testing.T) {
+	createdAt := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	items := []inventoryItem{
+		{Type: "app", ID: "app1", Name: "my-app", State: "deployed", Details: map[string]string{"platform_version": "machines", "hostname": ""}},
+		{Type: "volume", ID: "vol_1", Name: "data", App: "my-app", Region: "ams", CreatedAt: &createdAt, Details: map[string]string{"size_gb": "1", "encrypted": "true"}},
+		{Type: "secret", ID: "A,B", Name: "A,B", App: "my-app"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeInventoryCSV(&buf, items))
+
+	assert.Equal(t, `type,id,name,app,region,state,created_at,details
+app,app1,my-app,,,deployed,,platform_version=machines
+volume,vol_1,data,my-app,ams,,2023-05-01T12:00:00Z,encrypted=true;size_gb=1
+secret,"A,B","A,B",my-app,,,,
+`, buf.String())
+}
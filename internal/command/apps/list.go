@@ -16,6 +16,7 @@ import (
 	"github.com/superfly/flyctl/internal/flag/flagnames"
 	"github.com/superfly/flyctl/internal/format"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/schema"
 )
 
 func newList() *cobra.Command {
@@ -32,7 +33,7 @@ be shown with its name, owner and when it was last deployed.
 		command.RequireSession,
 	)
 
-	flag.Add(cmd, flag.JSONOutput())
+	flag.Add(cmd, flag.JSONOutput(), flag.JSONSchemaVersion())
 	flag.Add(cmd, flag.Org())
 
 	cmd.Aliases = []string{"ls"}
@@ -42,6 +43,10 @@ be shown with its name, owner and when it was last deployed.
 func runList(ctx context.Context) (err error) {
 	client := client.FromContext(ctx)
 	cfg := config.FromContext(ctx)
+	schemaVersion := flag.GetJSONSchemaVersion(ctx)
+	if err := schema.CheckVersion("apps", schemaVersion); err != nil {
+		return err
+	}
 	org, err := getOrg(ctx)
 	if err != nil {
 		return fmt.Errorf("error getting organization: %w", err)
@@ -59,6 +64,9 @@ func runList(ctx context.Context) (err error) {
 	}
 
 	out := iostreams.FromContext(ctx).Out
+	if schemaVersion > 0 {
+		return schema.Render(out, "apps", schemaVersion, schema.NewAppsV1(apps))
+	}
 	if cfg.JSONOutput {
 		_ = render.JSON(out, apps)
 
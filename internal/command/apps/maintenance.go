@@ -0,0 +1,374 @@
+package apps
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	maintenanceImage = "nginx:1.25-alpine"
+	maintenancePort  = 8080
+
+	// Metadata of the maintenance machine recording the app machines taken
+	// out of service, so they can be restored afterwards.
+	maintenanceMetadataCordoned = "fly_maintenance_cordoned"
+	maintenanceMetadataStopped  = "fly_maintenance_stopped"
+)
+
+const defaultMaintenancePage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Down for maintenance</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 10%%;">
+<h1>Down for maintenance</h1>
+<p>%s is undergoing scheduled maintenance and will be back shortly.</p>
+</body>
+</html>
+`
+
+// maintenanceNginxConfig answers every request with the maintenance page and
+// a 503 so clients and crawlers know the outage is temporary.
+const maintenanceNginxConfig = `server {
+    listen 8080 default_server;
+    listen [::]:8080 default_server;
+    root /usr/share/nginx/html;
+    error_page 503 /maintenance.html;
+    location = /maintenance.html {
+        internal;
+        add_header Retry-After 300 always;
+    }
+    location / {
+        return 503;
+    }
+}
+`
+
+func newMaintenance() *cobra.Command {
+	const (
+		long = `Switch an app in and out of maintenance mode. While in maintenance mode,
+every request to the app's services is answered with a static maintenance
+page served by a lightweight machine, leaving the app's machines free to be
+stopped, updated or migrated.`
+		short = "Put an app in or out of maintenance mode"
+	)
+
+	cmd := command.New("maintenance", short, long, nil)
+
+	cmd.AddCommand(
+		newMaintenanceOn(),
+		newMaintenanceOff(),
+	)
+
+	return cmd
+}
+
+func newMaintenanceOn() *cobra.Command {
+	const (
+		long = `Launch a machine serving a maintenance page on the app's services and take
+the app's machines out of service. With --stop, the machines are stopped too.`
+		short = "Serve a maintenance page instead of the app"
+	)
+
+	cmd := command.New("on", short, long, runMaintenanceOn,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "page",
+			Description: "Path to an HTML file to serve as the maintenance page",
+		},
+		flag.Bool{
+			Name:        "stop",
+			Description: "Stop the app's machines once the maintenance page is up",
+		},
+	)
+
+	return cmd
+}
+
+func newMaintenanceOff() *cobra.Command {
+	const (
+		long = `Restart the machines stopped by "maintenance on", put them back in service
+and destroy the maintenance machine.`
+		short = "Restore traffic to the app"
+	)
+
+	cmd := command.New("off", short, long, runMaintenanceOff,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runMaintenanceOn(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		appName  = appconfig.NameFromContext(ctx)
+		stop     = flag.GetBool(ctx, "stop")
+	)
+
+	page := fmt.Sprintf(defaultMaintenancePage, html.EscapeString(appName))
+	if path := flag.GetString(ctx, "page"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed reading maintenance page: %w", err)
+		}
+		page = string(data)
+	}
+
+	ctx, flapsClient, err := maintenanceContext(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	if existing, err := findMaintenanceMachine(ctx); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("app %s is already in maintenance mode, served by machine %s", appName, existing.ID)
+	}
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed listing machines: %w", err)
+	}
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return len(m.Config.Services) > 0
+	})
+	if len(machines) == 0 {
+		return fmt.Errorf("app %s has no machines with services to put in maintenance mode", appName)
+	}
+
+	ids := lo.Map(machines, func(m *api.Machine, _ int) string { return m.ID })
+	machineConfig := maintenanceMachineConfig(machines, page)
+	machineConfig.Metadata[maintenanceMetadataCordoned] = strings.Join(ids, ",")
+	if stop {
+		machineConfig.Metadata[maintenanceMetadataStopped] = strings.Join(ids, ",")
+	}
+
+	maintenance, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Region: machines[0].Region,
+		Config: machineConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching maintenance machine: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "Waiting for maintenance machine %s to start\n", colorize.Bold(maintenance.ID))
+	if err := mach.WaitForStartOrStop(ctx, maintenance, "start", 2*time.Minute); err != nil {
+		return fmt.Errorf("maintenance machine %s failed to start: %w", maintenance.ID, err)
+	}
+
+	for _, m := range machines {
+		if err := flapsClient.Cordon(ctx, m.ID); err != nil {
+			return fmt.Errorf("failed taking machine %s out of service: %w", m.ID, err)
+		}
+		fmt.Fprintf(io.Out, "  Machine %s taken out of service\n", colorize.Bold(m.ID))
+	}
+
+	if stop {
+		for _, m := range machines {
+			if err := stopMaintainedMachine(ctx, m); err != nil {
+				return fmt.Errorf("failed stopping machine %s: %w", m.ID, err)
+			}
+			fmt.Fprintf(io.Out, "  Machine %s stopped\n", colorize.Bold(m.ID))
+		}
+	}
+
+	fmt.Fprintf(io.Out, "App %s is in maintenance mode, run `fly apps maintenance off` to restore traffic\n", colorize.Bold(appName))
+	return nil
+}
+
+func runMaintenanceOff(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		appName  = appconfig.NameFromContext(ctx)
+	)
+
+	ctx, flapsClient, err := maintenanceContext(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	maintenance, err := findMaintenanceMachine(ctx)
+	if err != nil {
+		return err
+	}
+	if maintenance == nil {
+		return fmt.Errorf("app %s is not in maintenance mode", appName)
+	}
+
+	for _, id := range splitIDs(maintenance.Config.Metadata[maintenanceMetadataStopped]) {
+		if err := startMaintainedMachine(ctx, id); err != nil {
+			return fmt.Errorf("failed starting machine %s: %w", id, err)
+		}
+		fmt.Fprintf(io.Out, "  Machine %s started\n", colorize.Bold(id))
+	}
+
+	for _, id := range splitIDs(maintenance.Config.Metadata[maintenanceMetadataCordoned]) {
+		if err := flapsClient.UnCordon(ctx, id); err != nil {
+			return fmt.Errorf("failed putting machine %s back in service: %w", id, err)
+		}
+		fmt.Fprintf(io.Out, "  Machine %s back in service\n", colorize.Bold(id))
+	}
+
+	if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: maintenance.ID, Kill: true}, ""); err != nil {
+		return fmt.Errorf("failed destroying maintenance machine %s: %w", maintenance.ID, err)
+	}
+
+	fmt.Fprintf(io.Out, "App %s is out of maintenance mode\n", colorize.Bold(appName))
+	return nil
+}
+
+// stopMaintainedMachine stops m while holding its lease.
+func stopMaintainedMachine(ctx context.Context, m *api.Machine) error {
+	m, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	return flaps.FromContext(ctx).Stop(ctx, api.StopMachineInput{ID: m.ID}, m.LeaseNonce)
+}
+
+// startMaintainedMachine starts the machine id unless it's already started,
+// holding its lease until it is.
+func startMaintainedMachine(ctx context.Context, id string) error {
+	flapsClient := flaps.FromContext(ctx)
+
+	m, err := flapsClient.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	m, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, m)
+	if err != nil {
+		return err
+	}
+	if m.State == api.MachineStateStarted {
+		return nil
+	}
+
+	if _, err := flapsClient.Start(ctx, id, m.LeaseNonce); err != nil {
+		return err
+	}
+
+	// Wait on the machine as it is after starting, not the stopped instance.
+	started, err := flapsClient.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return mach.WaitForStartOrStop(ctx, started, "start", 5*time.Minute)
+}
+
+func maintenanceContext(ctx context.Context, appName string) (context.Context, *flaps.Client, error) {
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return nil, nil, errors.New("maintenance mode is only supported for machines apps")
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flaps.NewContext(ctx, flapsClient), flapsClient, nil
+}
+
+func findMaintenanceMachine(ctx context.Context) (*api.Machine, error) {
+	machines, err := flaps.FromContext(ctx).List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed listing machines: %w", err)
+	}
+
+	m, _ := lo.Find(machines, func(m *api.Machine) bool {
+		return m.Config != nil && m.IsFlyAppsMaintenance()
+	})
+	return m, nil
+}
+
+// maintenanceMachineConfig returns the config of a machine answering on the
+// services of machines with page.
+func maintenanceMachineConfig(machines []*api.Machine, page string) *api.MachineConfig {
+	var services []api.MachineService
+	for _, m := range machines {
+		for _, s := range m.Config.Services {
+			s := *helpers.Clone(&s)
+			s.InternalPort = maintenancePort
+			s.Checks = nil
+			s.Autostop = api.Pointer(false)
+			s.Autostart = api.Pointer(true)
+			s.MinMachinesRunning = nil
+			services = append(services, s)
+		}
+	}
+	services = lo.UniqBy(services, func(s api.MachineService) string {
+		return fmt.Sprintf("%s/%v", s.Protocol, lo.Map(s.Ports, func(p api.MachinePort, _ int) string {
+			return fmt.Sprintf("%d", lo.FromPtr(p.Port))
+		}))
+	})
+
+	return &api.MachineConfig{
+		Image: maintenanceImage,
+		Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
+			api.MachineConfigMetadataKeyFlyProcessGroup:    api.MachineProcessGroupFlyAppMaintenance,
+		},
+		Services: services,
+		Guest:    helpers.Clone(api.MachinePresets["shared-cpu-1x"]),
+		Files: []*api.File{
+			{
+				GuestPath: "/usr/share/nginx/html/maintenance.html",
+				RawValue:  api.Pointer(base64.StdEncoding.EncodeToString([]byte(page))),
+			},
+			{
+				GuestPath: "/etc/nginx/conf.d/default.conf",
+				RawValue:  api.Pointer(base64.StdEncoding.EncodeToString([]byte(maintenanceNginxConfig))),
+			},
+		},
+		Restart: api.MachineRestart{
+			Policy: api.MachineRestartPolicyAlways,
+		},
+	}
+}
+
+func splitIDs(s string) []string {
+	return lo.Filter(strings.Split(s, ","), func(id string, _ int) bool { return id != "" })
+}
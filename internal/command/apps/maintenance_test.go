@@ -0,0 +1,40 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestMaintenanceMachineConfig(t *testing.T) {
+	service := api.MachineService{
+		Protocol:     "tcp",
+		InternalPort: 3000,
+		Autostop:     api.Pointer(true),
+		Ports: []api.MachinePort{
+			{Port: api.Pointer(80), Handlers: []string{"http"}},
+			{Port: api.Pointer(443), Handlers: []string{"tls", "http"}},
+		},
+		Checks: []api.MachineCheck{{Type: api.Pointer("http")}},
+	}
+	machines := []*api.Machine{
+		{ID: "m1", Config: &api.MachineConfig{Services: []api.MachineService{service}}},
+		{ID: "m2", Config: &api.MachineConfig{Services: []api.MachineService{service}}},
+	}
+
+	config := maintenanceMachineConfig(machines, "<h1>down</h1>")
+
+	assert.Equal(t, api.MachineProcessGroupFlyAppMaintenance, config.ProcessGroup())
+	assert.Len(t, config.Services, 1)
+	assert.Equal(t, maintenancePort, config.Services[0].InternalPort)
+	assert.Equal(t, service.Ports, config.Services[0].Ports)
+	assert.Empty(t, config.Services[0].Checks)
+	assert.False(t, *config.Services[0].Autostop)
+	assert.Len(t, config.Files, 2)
+
+	// The app's machines are left untouched
+	assert.Equal(t, 3000, machines[0].Config.Services[0].InternalPort)
+	assert.True(t, *machines[0].Config.Services[0].Autostop)
+}
@@ -2,14 +2,22 @@ package apps
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/skratchdot/open-golang/open"
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/iostreams"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
@@ -21,6 +29,12 @@ func NewOpen() (cmd *cobra.Command) {
 	const (
 		long = `Open browser to current deployed application. If an optional relative URI is specified, it is appended
 to the root URL of the deployed application.
+
+The URL is on the hostname of the app's custom domain when it has an issued
+certificate, or on its fly.dev hostname otherwise, so opening the app of each
+environment, e.g. with --config fly.staging.toml, leads to its own hostname.
+Before opening the browser, the URL is requested to make sure its certificate
+is valid and the app responds.
 `
 		short = "Open browser to current deployed application"
 
@@ -37,16 +51,35 @@ to the root URL of the deployed application.
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
+		flag.String{
+			Name:        "path",
+			Description: "Path and query to open, e.g. /admin?tab=users",
+		},
+		flag.String{
+			Name:        "hostname",
+			Description: "Hostname to open, one of the app's certificates or its fly.dev hostname",
+		},
+		flag.Bool{
+			Name:        "print",
+			Description: "Print the URL instead of opening it",
+		},
+		flag.Bool{
+			Name:        "skip-check",
+			Description: "Don't check the URL responds before opening it",
+		},
 	)
 
 	return
 }
 
 func runOpen(ctx context.Context) error {
-	iostream := iostreams.FromContext(ctx)
-	appName := appconfig.NameFromContext(ctx)
+	var (
+		iostream  = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
 
-	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	app, err := apiClient.GetAppCompact(ctx, appName)
 	if err != nil {
 		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
 	}
@@ -56,7 +89,7 @@ func runOpen(ctx context.Context) error {
 	}
 
 	appConfig := appconfig.ConfigFromContext(ctx)
-	if appConfig == nil {
+	if appConfig == nil || appConfig.AppName != appName {
 		if appConfig, err = appconfig.FromAppCompact(ctx, app); err != nil {
 			return errors.New("The app config could not be found")
 		}
@@ -67,14 +100,44 @@ func runOpen(ctx context.Context) error {
 		return errors.New("The app doesn't exspose a public http service")
 	}
 
-	if relURI := flag.FirstArg(ctx); relURI != "" {
+	// Custom domains are only served on the default ports
+	if appURL.Port() == "" {
+		certs, err := apiClient.GetAppCertificates(ctx, appName)
+		if err != nil {
+			return fmt.Errorf("failed retrieving certificates of %s: %w", appName, err)
+		}
+		if appURL.Host, err = resolveHostname(certs, appURL.Host, flag.GetString(ctx, "hostname")); err != nil {
+			return err
+		}
+	} else if hostname := flag.GetString(ctx, "hostname"); hostname != "" {
+		return fmt.Errorf("%s is served on port %s, only its fly.dev hostname can be opened", appName, appURL.Port())
+	}
+
+	for _, relURI := range []string{flag.FirstArg(ctx), flag.GetString(ctx, "path")} {
+		if relURI == "" {
+			continue
+		}
 		newURL, err := appURL.Parse(relURI)
 		if err != nil {
 			return fmt.Errorf("failed to parse relative URI '%s': %w", relURI, err)
 		}
+		if newURL.Host != appURL.Host {
+			return fmt.Errorf("'%s' isn't a relative URI", relURI)
+		}
 		appURL = newURL
 	}
 
+	if !flag.GetBool(ctx, "skip-check") {
+		if err := checkURL(ctx, appURL); err != nil {
+			return fmt.Errorf("%w\nUse --skip-check to open it anyway", err)
+		}
+	}
+
+	if flag.GetBool(ctx, "print") {
+		fmt.Fprintln(iostream.Out, appURL)
+		return nil
+	}
+
 	fmt.Fprintf(iostream.Out, "opening %s ...\n", appURL)
 	if err := open.Run(appURL.String()); err != nil {
 		return fmt.Errorf("failed opening %s: %w", appURL, err)
@@ -82,3 +145,57 @@ func runOpen(ctx context.Context) error {
 
 	return nil
 }
+
+// resolveHostname returns hostname when set, and otherwise the oldest custom
+// domain with an issued certificate, falling back to defaultHost.
+func resolveHostname(certs []api.AppCertificateCompact, defaultHost, hostname string) (string, error) {
+	if hostname != "" {
+		if hostname == defaultHost {
+			return hostname, nil
+		}
+		for _, cert := range certs {
+			if cert.Hostname == hostname {
+				if cert.ClientStatus != "Ready" {
+					return "", fmt.Errorf("the certificate for %s isn't issued yet, its status is %s", hostname, cert.ClientStatus)
+				}
+				return hostname, nil
+			}
+		}
+		return "", fmt.Errorf("the app has no certificate for %s, see fly certs list", hostname)
+	}
+
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].CreatedAt.Before(certs[j].CreatedAt) })
+	for _, cert := range certs {
+		if cert.ClientStatus == "Ready" && !strings.HasPrefix(cert.Hostname, "*.") {
+			return cert.Hostname, nil
+		}
+	}
+	return defaultHost, nil
+}
+
+// checkURL requests u, failing on certificate errors and server errors.
+func checkURL(ctx context.Context, u *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		var hostErr x509.HostnameError
+		if errors.As(err, &certErr) || errors.As(err, &hostErr) {
+			return fmt.Errorf("the certificate of %s isn't valid, check it with fly certs show %s: %w", u.Hostname(), u.Hostname(), err)
+		}
+		return fmt.Errorf("failed requesting %s: %w", u, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%s responded with %s, check the app with fly status and fly logs", u, resp.Status)
+	}
+	return nil
+}
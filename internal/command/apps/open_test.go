@@ -0,0 +1,41 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestResolveHostname(t *testing.T) {
+	now := time.Now()
+	certs := []api.AppCertificateCompact{
+		{Hostname: "*.example.com", ClientStatus: "Ready", CreatedAt: now.Add(-3 * time.Hour)},
+		{Hostname: "www.example.com", ClientStatus: "Ready", CreatedAt: now.Add(-time.Hour)},
+		{Hostname: "example.com", ClientStatus: "Ready", CreatedAt: now.Add(-2 * time.Hour)},
+		{Hostname: "new.example.com", ClientStatus: "Awaiting certificates", CreatedAt: now.Add(-4 * time.Hour)},
+	}
+
+	host, err := resolveHostname(certs, "my-app.fly.dev", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", host)
+
+	host, err = resolveHostname(nil, "my-app.fly.dev", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-app.fly.dev", host)
+
+	host, err = resolveHostname(certs, "my-app.fly.dev", "my-app.fly.dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-app.fly.dev", host)
+
+	host, err = resolveHostname(certs, "my-app.fly.dev", "www.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "www.example.com", host)
+
+	_, err = resolveHostname(certs, "my-app.fly.dev", "new.example.com")
+	assert.ErrorContains(t, err, "isn't issued yet")
+
+	_, err = resolveHostname(certs, "my-app.fly.dev", "other.com")
+	assert.ErrorContains(t, err, "has no certificate for other.com")
+}
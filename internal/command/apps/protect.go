@@ -0,0 +1,115 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/completion"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newProtect() *cobra.Command {
+	const (
+		long = `Protect an app, or some of its volumes and machines, from deletion by
+this flyctl. Destroy commands refuse to remove protected resources unless
+--force-unprotect is passed and the resource name is re-typed.
+
+Protection is a local safeguard against mistakes, recorded in the flyctl
+configuration file of the current user and shared by all of its auth contexts.
+Destroying an app along with protected machines or volumes, or scaling
+protected machines away, is refused the same way. The platform doesn't enforce it: the API,
+the dashboard and other flyctl installs can still delete the resources.
+`
+		short = "Locally protect an app, volumes or machines from deletion"
+		usage = "protect [APPNAME]"
+	)
+
+	return newProtectCommand(usage, short, long, true)
+}
+
+func newUnprotect() *cobra.Command {
+	const (
+		long = `Remove the local deletion protection of an app, or some of its volumes
+and machines.
+`
+		short = "Remove the local deletion protection of an app, volumes or machines"
+		usage = "unprotect [APPNAME]"
+	)
+
+	return newProtectCommand(usage, short, long, false)
+}
+
+func newProtectCommand(usage, short, long string, protected bool) *cobra.Command {
+	cmd := command.New(usage, short, long,
+		func(ctx context.Context) error {
+			return runProtect(ctx, protected)
+		},
+		command.RequireSession,
+		command.LoadAppNameIfPresentNoFlag,
+	)
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.StringArray{
+			Name:        "volume",
+			Description: "ID of a volume to apply to instead of the app. Can be specified multiple times.",
+		},
+		flag.StringArray{
+			Name:        "machine",
+			Description: "ID of a machine to apply to instead of the app. Can be specified multiple times.",
+		},
+	)
+
+	cmd.ValidArgsFunction = completion.Adapt(completion.CompleteApps)
+
+	return cmd
+}
+
+func runProtect(ctx context.Context, protected bool) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		path     = state.SharedConfigFile(ctx)
+		volumes  = flag.GetStringArray(ctx, "volume")
+		machines = flag.GetStringArray(ctx, "machine")
+	)
+
+	resources := map[string][]string{
+		config.ProtectedVolume:  volumes,
+		config.ProtectedMachine: machines,
+	}
+
+	if len(volumes) == 0 && len(machines) == 0 {
+		appName := flag.FirstArg(ctx)
+		if appName == "" {
+			appName = appconfig.NameFromContext(ctx)
+		}
+		if appName == "" {
+			return errors.New("no app name was provided, and none is available from the environment or fly.toml")
+		}
+		resources = map[string][]string{config.ProtectedApp: {appName}}
+	}
+
+	action := "Protected"
+	if !protected {
+		action = "Unprotected"
+	}
+
+	for kind, names := range resources {
+		for _, name := range names {
+			if err := config.SetProtected(path, kind, name, protected); err != nil {
+				return fmt.Errorf("failed updating protection of %s in %s: %w", name, path, err)
+			}
+			fmt.Fprintf(io.Out, "%s %s in %s\n", action, name, path)
+		}
+	}
+
+	return nil
+}
@@ -35,6 +35,8 @@ including type, when, success/fail and which user triggered the release.
 
 	cmd.Args = cobra.NoArgs
 
+	cmd.AddCommand(newReleasesDiff())
+
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
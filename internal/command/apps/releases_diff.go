@@ -0,0 +1,347 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// releasesDiffLimit is the number of recent releases searched for the
+// versions being compared.
+const releasesDiffLimit = 100
+
+func newReleasesDiff() *cobra.Command {
+	const (
+		short = "Show the changes between two releases"
+		long  = `Show the changes between two releases of a machines app: the image and its
+digest, the environment variables and secrets changed, the config sections
+modified and a unified diff of the release configs.
+
+When only one version is given, it's compared against the current release.
+Secrets are versioned separately from releases, so the secrets listed are
+those set between the two releases.`
+		usage = "diff <version> [<version>]"
+	)
+
+	cmd := command.New(usage, short, long, runReleasesDiff,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.RangeArgs(1, 2)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+// releaseConfig is the state of an app captured by a release.
+type releaseConfig struct {
+	Version     int
+	ImageRef    string
+	ImageDigest string
+	CreatedAt   time.Time
+	Definition  map[string]any
+}
+
+type valueChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type keyChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+type releaseDiff struct {
+	From        int          `json:"from"`
+	To          int          `json:"to"`
+	Image       *valueChange `json:"image,omitempty"`
+	ImageDigest *valueChange `json:"image_digest,omitempty"`
+	Env         keyChanges   `json:"env"`
+	Secrets     []string     `json:"secrets"`
+	Sections    []string     `json:"sections"`
+	Diff        string       `json:"diff"`
+}
+
+func runReleasesDiff(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		args      = flag.Args(ctx)
+	)
+
+	versions := make([]int, len(args))
+	for i, arg := range args {
+		v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(arg), "v"))
+		if err != nil {
+			return fmt.Errorf("invalid release version %s", arg)
+		}
+		versions[i] = v
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("releases diff is only supported for machines apps")
+	}
+
+	releases, err := releaseConfigs(ctx, apiClient, appName)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 1 {
+		versions = append(versions, releases[0].Version)
+	}
+
+	var pair [2]*releaseConfig
+	for i, v := range versions {
+		r, found := lo.Find(releases, func(r releaseConfig) bool { return r.Version == v })
+		if !found {
+			return fmt.Errorf("release v%d not found among the latest %d releases of %s", v, releasesDiffLimit, appName)
+		}
+		pair[i] = &r
+	}
+
+	diff, err := diffReleases(*pair[0], *pair[1])
+	if err != nil {
+		return err
+	}
+
+	secrets, err := apiClient.GetAppSecrets(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving secrets of %s: %w", appName, err)
+	}
+	diff.Secrets = secretsSetBetween(secrets, pair[0].CreatedAt, pair[1].CreatedAt)
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, diff)
+	}
+
+	return renderReleaseDiff(io, diff)
+}
+
+// releaseConfigs returns the recent releases of the app that captured a
+// config, newest first.
+func releaseConfigs(ctx context.Context, apiClient *api.Client, appName string) ([]releaseConfig, error) {
+	_ = `# @genqlient
+	query FlyctlReleasesConfigs($appName: String!, $limit: Int!) {
+		app(name:$appName) {
+			releasesUnprocessed(first:$limit) {
+				nodes {
+					version
+					imageRef
+					createdAt
+					configDefinition
+					image {
+						digest
+					}
+				}
+			}
+		}
+	}
+	`
+	resp, err := gql.FlyctlReleasesConfigs(ctx, apiClient.GenqClient, appName, releasesDiffLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving releases of %s: %w", appName, err)
+	}
+
+	var releases []releaseConfig
+	for _, node := range resp.App.ReleasesUnprocessed.Nodes {
+		definition, ok := node.ConfigDefinition.(map[string]any)
+		if !ok {
+			continue
+		}
+		releases = append(releases, releaseConfig{
+			Version:     node.Version,
+			ImageRef:    node.ImageRef,
+			ImageDigest: node.Image.Digest,
+			CreatedAt:   node.CreatedAt,
+			Definition:  definition,
+		})
+	}
+
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("app %s has no releases with a recorded config", appName)
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version > releases[j].Version
+	})
+
+	return releases, nil
+}
+
+func diffReleases(from, to releaseConfig) (*releaseDiff, error) {
+	diff := &releaseDiff{
+		From:     from.Version,
+		To:       to.Version,
+		Secrets:  []string{},
+		Sections: []string{},
+	}
+
+	if from.ImageRef != to.ImageRef {
+		diff.Image = &valueChange{From: from.ImageRef, To: to.ImageRef}
+	}
+	if from.ImageDigest != to.ImageDigest {
+		diff.ImageDigest = &valueChange{From: from.ImageDigest, To: to.ImageDigest}
+	}
+
+	diff.Env = diffKeys(sectionMap(from.Definition, "env"), sectionMap(to.Definition, "env"))
+
+	sections := lo.Uniq(append(lo.Keys(from.Definition), lo.Keys(to.Definition)...))
+	for _, section := range sections {
+		if !reflect.DeepEqual(from.Definition[section], to.Definition[section]) {
+			diff.Sections = append(diff.Sections, section)
+		}
+	}
+	sort.Strings(diff.Sections)
+
+	fromToml, err := toml.TreeFromMap(from.Definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed rendering config of v%d: %w", from.Version, err)
+	}
+	toToml, err := toml.TreeFromMap(to.Definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed rendering config of v%d: %w", to.Version, err)
+	}
+
+	diff.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromToml.String()),
+		B:        difflib.SplitLines(toToml.String()),
+		FromFile: fmt.Sprintf("v%d", from.Version),
+		ToFile:   fmt.Sprintf("v%d", to.Version),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+func sectionMap(definition map[string]any, section string) map[string]any {
+	m, _ := definition[section].(map[string]any)
+	return m
+}
+
+func diffKeys(from, to map[string]any) keyChanges {
+	changes := keyChanges{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for k, v := range to {
+		prev, ok := from[k]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, k)
+		case !reflect.DeepEqual(prev, v):
+			changes.Changed = append(changes.Changed, k)
+		}
+	}
+	for k := range from {
+		if _, ok := to[k]; !ok {
+			changes.Removed = append(changes.Removed, k)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+	return changes
+}
+
+// secretsSetBetween returns the names of the secrets set after the older of
+// the two times, up to the newer one.
+func secretsSetBetween(secrets []api.Secret, a, b time.Time) []string {
+	if b.Before(a) {
+		a, b = b, a
+	}
+	names := []string{}
+	for _, s := range secrets {
+		if s.CreatedAt.After(a) && !s.CreatedAt.After(b) {
+			names = append(names, s.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func renderReleaseDiff(io *iostreams.IOStreams, diff *releaseDiff) error {
+	colorize := io.ColorScheme()
+
+	var rows [][]string
+	if diff.Image != nil {
+		rows = append(rows, []string{"Image", diff.Image.From + " -> " + diff.Image.To})
+	}
+	if diff.ImageDigest != nil {
+		rows = append(rows, []string{"Image digest", diff.ImageDigest.From + " -> " + diff.ImageDigest.To})
+	}
+	for _, kind := range []struct {
+		label string
+		names []string
+	}{
+		{"Env added", diff.Env.Added},
+		{"Env removed", diff.Env.Removed},
+		{"Env changed", diff.Env.Changed},
+		{"Secrets set", diff.Secrets},
+		{"Sections modified", diff.Sections},
+	} {
+		if len(kind.names) > 0 {
+			rows = append(rows, []string{kind.label, strings.Join(kind.names, ", ")})
+		}
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintf(io.Out, "No changes between v%d and v%d\n", diff.From, diff.To)
+		return nil
+	}
+
+	if err := render.Table(io.Out, fmt.Sprintf("Changes from v%d to v%d", diff.From, diff.To), rows, "Change", "Details"); err != nil {
+		return err
+	}
+
+	if diff.Diff == "" {
+		return nil
+	}
+
+	fmt.Fprintln(io.Out)
+	for _, line := range strings.Split(strings.TrimSuffix(diff.Diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Fprintln(io.Out, colorize.Bold(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprintln(io.Out, colorize.Green(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintln(io.Out, colorize.Red(line))
+		default:
+			fmt.Fprintln(io.Out, line)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestDiffReleases(t *testing.T) {
+	from := releaseConfig{
+		Version:  3,
+		ImageRef: "registry.fly.io/app:deployment-1",
+		Definition: map[string]any{
+			"kill_signal": "SIGINT",
+			"env":         map[string]any{"LOG_LEVEL": "info", "REMOVED": "x", "SAME": "y"},
+		},
+	}
+	to := releaseConfig{
+		Version:  5,
+		ImageRef: "registry.fly.io/app:deployment-2",
+		Definition: map[string]any{
+			"kill_signal": "SIGINT",
+			"env":         map[string]any{"LOG_LEVEL": "debug", "ADDED": "z", "SAME": "y"},
+			"http_service": map[string]any{
+				"internal_port": float64(8080),
+			},
+		},
+	}
+
+	diff, err := diffReleases(from, to)
+	require.NoError(t, err)
+
+	assert.Equal(t, &valueChange{From: from.ImageRef, To: to.ImageRef}, diff.Image)
+	assert.Nil(t, diff.ImageDigest)
+	assert.Equal(t, keyChanges{Added: []string{"ADDED"}, Removed: []string{"REMOVED"}, Changed: []string{"LOG_LEVEL"}}, diff.Env)
+	assert.Equal(t, []string{"env", "http_service"}, diff.Sections)
+	assert.Contains(t, diff.Diff, "--- v3\n+++ v5\n")
+	assert.Contains(t, diff.Diff, "+  LOG_LEVEL = \"debug\"")
+	assert.Contains(t, diff.Diff, "-  LOG_LEVEL = \"info\"")
+
+	same, err := diffReleases(from, from)
+	require.NoError(t, err)
+	assert.Nil(t, same.Image)
+	assert.Empty(t, same.Sections)
+	assert.Empty(t, same.Diff)
+}
+
+func TestSecretsSetBetween(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	secrets := []api.Secret{
+		{Name: "OLD", CreatedAt: t0},
+		{Name: "B", CreatedAt: t0.Add(2 * time.Hour)},
+		{Name: "A", CreatedAt: t0.Add(time.Hour)},
+		{Name: "NEW", CreatedAt: t0.Add(4 * time.Hour)},
+	}
+
+	assert.Equal(t, []string{"A", "B"}, secretsSetBetween(secrets, t0, t0.Add(3*time.Hour)))
+	assert.Equal(t, []string{"A", "B"}, secretsSetBetween(secrets, t0.Add(3*time.Hour), t0))
+}
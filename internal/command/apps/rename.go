@@ -0,0 +1,197 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/logs"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newRename() *cobra.Command {
+	const (
+		long = `Rename an app, along with the references to its name flyctl manages.
+
+Apps can't be renamed in place, so the app is forked into a new app with the
+new name: a copy of each of its machines is launched, with volumes restored
+from their latest snapshots. Then the app field of fly.toml and the log
+shipper add-on are updated to the new name.
+
+The app itself is left running, so traffic can be moved first. The secrets,
+IP addresses, certificates and DNS records to move by hand are listed last,
+with the commands to do it.`
+		short = "Rename an app and update references to its name"
+		usage = "rename <new app name>"
+	)
+
+	cmd := command.New(usage, short, long, runRename,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func runRename(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		newName   = flag.FirstArg(ctx)
+	)
+
+	if newName == appName {
+		return fmt.Errorf("the app is already named %s", appName)
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("only apps on the machines platform can be renamed")
+	}
+	if app.IsPostgresApp() {
+		return fmt.Errorf("Postgres apps can't be renamed, fork them with fly postgres create --fork-from")
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	plan, err := planFork(ctx, flapsClient, true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Renaming %s to %s by forking its %d machines and %d volumes\n", appName, newName, len(plan.machines), len(plan.volumes))
+	for _, v := range plan.volumes {
+		if v.snapshot == nil {
+			fmt.Fprintf(io.ErrOut, "Volume %s has no snapshot, its copy will start empty\n", v.source.ID)
+		}
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirm(ctx, "Rename the app?"); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	newApp, err := forkApp(ctx, plan, appName, app.Organization.ID, newName)
+	if err != nil {
+		return err
+	}
+
+	if cfg := appconfig.ConfigFromContext(ctx); cfg != nil && cfg.AppName == appName && cfg.ConfigFilePath() != "" {
+		switch renamed, err := renameAppInConfigFile(cfg.ConfigFilePath(), appName, newName); {
+		case err != nil:
+			fmt.Fprintf(io.ErrOut, "Failed updating the app name in %s, set app = %q by hand: %v\n", cfg.ConfigFilePath(), newName, err)
+		case renamed:
+			fmt.Fprintf(io.Out, "Updated the app name in %s\n", cfg.ConfigFilePath())
+		}
+	}
+
+	// The log shipper add-on is named after the app, shipping the logs of the
+	// new app needs one with the new name.
+	if _, err := gql.GetAddOn(ctx, apiClient.GenqClient, logs.LoggerAddOnName(appName)); err == nil {
+		input := gql.CreateAddOnInput{
+			OrganizationId: app.Organization.ID,
+			Name:           logs.LoggerAddOnName(newName),
+			AppId:          newApp.ID,
+			Type:           gql.AddOnType("logtail"),
+		}
+		if _, err := gql.CreateAddOn(ctx, apiClient.GenqClient, input); err != nil {
+			fmt.Fprintf(io.ErrOut, "Failed creating the log shipper add-on %s: %v\n", input.Name, err)
+		} else {
+			fmt.Fprintf(io.Out, "Created the log shipper add-on %s, ship logs to it with fly logs ship -a %s --provider logtail\n", input.Name, newName)
+		}
+	}
+
+	fmt.Fprintf(io.Out, "\nTo finish renaming %s to %s:\n", appName, newName)
+
+	if err := printSecretsToSet(ctx, appName, newName); err != nil {
+		return err
+	}
+
+	ips, err := apiClient.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		switch ip.Type {
+		case "shared_v4":
+			fmt.Fprintf(io.Out, "Allocate a shared IPv4 address with fly ips allocate-v4 --shared -a %s, and point the A records of %s to it\n", newName, ip.Address)
+		case "v4":
+			fmt.Fprintf(io.Out, "Allocate an IPv4 address with fly ips allocate-v4 -a %s, and point the A records of %s to it\n", newName, ip.Address)
+		case "v6":
+			fmt.Fprintf(io.Out, "Allocate an IPv6 address with fly ips allocate-v6 -a %s, and point the AAAA records of %s to it\n", newName, ip.Address)
+		case "private_v6":
+			fmt.Fprintf(io.Out, "Allocate a private IPv6 address with fly ips allocate-v6 --private -a %s\n", newName)
+		}
+	}
+
+	certs, err := apiClient.GetAppCertificates(ctx, appName)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		fmt.Fprintf(io.Out, "Add the certificate of %s with fly certs add %s -a %s, and point its CNAME record to %s.fly.dev\n", cert.Hostname, cert.Hostname, newName, newName)
+	}
+
+	fmt.Fprintf(io.Out, "Once traffic moved to %s, destroy %s with fly apps destroy %s\n", newName, appName, appName)
+
+	return nil
+}
+
+var appNameLine = regexp.MustCompile(`(?m)^(\s*app\s*=\s*)(["'])([^"']*)(["'])`)
+
+// renameAppInConfigFile sets the app field of the config file at path to
+// newName when it's appName, leaving the rest of the file untouched.
+func renameAppInConfigFile(path, appName, newName string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	loc := appNameLine.FindSubmatchIndex(data)
+	if loc == nil || string(data[loc[6]:loc[7]]) != appName {
+		return false, nil
+	}
+
+	out := append([]byte{}, data[:loc[6]]...)
+	out = append(out, newName...)
+	out = append(out, data[loc[7]:]...)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, out, info.Mode())
+}
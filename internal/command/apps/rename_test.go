@@ -0,0 +1,40 @@
+package apps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameAppInConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fly.toml")
+	const config = `# fly.toml app configuration file
+app = "old-name"
+primary_region = "ord"
+
+[env]
+  app = "old-name"
+`
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+
+	renamed, err := renameAppInConfigFile(path, "other-app", "new-name")
+	require.NoError(t, err)
+	assert.False(t, renamed)
+
+	renamed, err = renameAppInConfigFile(path, "old-name", "new-name")
+	require.NoError(t, err)
+	assert.True(t, renamed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# fly.toml app configuration file
+app = "new-name"
+primary_region = "ord"
+
+[env]
+  app = "old-name"
+`, string(data))
+}
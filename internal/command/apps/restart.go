@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/flag/completion"
@@ -16,11 +18,16 @@ import (
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/iostreams"
+	"golang.org/x/exp/slices"
 )
 
 func newRestart() *cobra.Command {
 	const (
-		long  = `The APPS RESTART command will perform a rolling restart against all running VMs`
+		long = `The APPS RESTART command will perform a rolling restart against all running VMs.
+
+With --rolling, machines are restarted in batches of --max-unavailable,
+optionally limited to some process groups, and every batch must be started
+and healthy before the next one is restarted.`
 		short = "Restart an application"
 		usage = "restart [APPNAME]"
 	)
@@ -43,6 +50,19 @@ func newRestart() *cobra.Command {
 			Description: "Restarts app without waiting for health checks. ( Machines only )",
 			Default:     false,
 		},
+		flag.Bool{
+			Name:        "rolling",
+			Description: "Restart machines in batches, waiting for each batch to become healthy before moving on. ( Machines only )",
+		},
+		flag.Int{
+			Name:        "max-unavailable",
+			Description: "Number of machines restarted at once with --rolling",
+			Default:     1,
+		},
+		flag.StringSlice{
+			Name:        "process-group",
+			Description: "Only restart machines in these process groups with --rolling. Can be specified multiple times.",
+		},
 	)
 
 	cmd.ValidArgsFunction = completion.Adapt(completion.CompleteApps)
@@ -100,6 +120,18 @@ func runNomadRestart(ctx context.Context, app *api.AppCompact) error {
 }
 
 func runMachinesRestart(ctx context.Context, app *api.AppCompact) error {
+	var (
+		rolling        = flag.GetBool(ctx, "rolling")
+		maxUnavailable = flag.GetInt(ctx, "max-unavailable")
+		processGroups  = flag.GetStringSlice(ctx, "process-group")
+	)
+
+	switch {
+	case !rolling && (flag.IsSpecified(ctx, "max-unavailable") || len(processGroups) > 0):
+		return errors.New("--max-unavailable and --process-group can only be used with --rolling")
+	case maxUnavailable < 1:
+		return errors.New("--max-unavailable must be at least 1")
+	}
 
 	input := &api.RestartMachineInput{
 		ForceStop:        flag.GetBool(ctx, "force-stop"),
@@ -117,12 +149,28 @@ func runMachinesRestart(ctx context.Context, app *api.AppCompact) error {
 		return err
 	}
 
+	if len(processGroups) > 0 {
+		machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+			return slices.Contains(processGroups, m.ProcessGroup())
+		})
+		if len(machines) == 0 {
+			return fmt.Errorf("no machines found in process groups %s", strings.Join(processGroups, ", "))
+		}
+	}
+
 	machines, releaseFunc, err := machine.AcquireLeases(ctx, machines)
 	defer releaseFunc(ctx, machines)
 	if err != nil {
 		return err
 	}
 
+	if rolling {
+		return machine.RollingRestartMachines(ctx, machines, machine.RollingRestartOptions{
+			Input:          input,
+			MaxUnavailable: maxUnavailable,
+		})
+	}
+
 	for _, m := range machines {
 		if err := machine.Restart(ctx, m, input, m.LeaseNonce); err != nil {
 			return err
@@ -0,0 +1,268 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// scaffoldKinds are the kinds of process groups fly apps scaffold adds, with
+// the guest size suggested for their machines.
+var scaffoldKinds = map[string]struct {
+	Size   string
+	Memory int
+}{
+	"worker":  {"shared-cpu-1x", 512},
+	"cron":    {"shared-cpu-1x", 256},
+	"release": {},
+}
+
+// supercronicDockerfile installs supercronic, which runs the crontab of cron
+// process groups, in Debian based images.
+const supercronicDockerfile = `ARG SUPERCRONIC_VERSION=v0.2.29
+ADD https://github.com/aptible/supercronic/releases/download/${SUPERCRONIC_VERSION}/supercronic-linux-amd64 /usr/local/bin/supercronic
+RUN chmod +x /usr/local/bin/supercronic
+COPY crontab /app/crontab`
+
+func newScaffold() *cobra.Command {
+	const (
+		long = `Add a process group to the app's fly.toml, for machines running another
+command of the same image:
+
+  worker   runs --command, without services
+  cron     runs the jobs of a crontab with supercronic, without services. The
+           job --command is added to a crontab file next to fly.toml, on
+           --schedule
+  release  runs --command in a temporary machine before each deploy updates
+           the machines, such as database migrations
+
+Services, checks and mounts of fly.toml stay on the existing process groups.
+The next deploy creates the machines of the new group, after which the
+suggested guest size can be set with fly scale vm.`
+		short = "Add a worker, cron or release process to fly.toml"
+		usage = "scaffold <worker|cron|release>"
+	)
+
+	cmd := command.New(usage, short, long, runScaffold,
+		command.LoadAppConfigIfPresent,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.ValidArgs = []string{"worker", "cron", "release"}
+	cmd.Example = `  fly apps scaffold worker --command "bin/jobs --queue default"
+  fly apps scaffold cron --command "bin/cleanup" --schedule "*/15 * * * *"
+  fly apps scaffold release --command "bin/rails db:migrate"`
+
+	flag.Add(cmd,
+		flag.AppConfig(),
+		flag.String{
+			Name:        "command",
+			Description: "Command the process runs",
+		},
+		flag.String{
+			Name:        "name",
+			Description: "Name of the process group, the kind of process by default",
+		},
+		flag.String{
+			Name:        "schedule",
+			Description: "Cron schedule of the job of cron processes",
+			Default:     "@hourly",
+		},
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Only show the changes, without writing fly.toml",
+		},
+	)
+
+	return cmd
+}
+
+func runScaffold(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		kind    = flag.FirstArg(ctx)
+		name    = flag.GetString(ctx, "name")
+		command = strings.TrimSpace(flag.GetString(ctx, "command"))
+		cfg     = appconfig.ConfigFromContext(ctx)
+	)
+
+	if _, ok := scaffoldKinds[kind]; !ok {
+		return fmt.Errorf("unknown kind of process %s, expected worker, cron or release", kind)
+	}
+	if command == "" {
+		return errors.New("--command is required, the command the process runs")
+	}
+	if name == "" {
+		name = kind
+	}
+	if cfg == nil || cfg.ConfigFilePath() == "" {
+		return errors.New("no fly.toml found, run fly launch first or pass --config")
+	}
+	if err := cfg.SetMachinesPlatform(); err != nil {
+		return fmt.Errorf("fly.toml isn't a valid machines config: %w", err)
+	}
+
+	var crontab string
+	if kind == "cron" {
+		crontab = filepath.Join(filepath.Dir(cfg.ConfigFilePath()), "crontab")
+	}
+
+	changes, err := scaffold(cfg, kind, name, command)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Changes to %s:\n", helpers.PathRelativeToCWD(cfg.ConfigFilePath()))
+	for _, change := range changes {
+		fmt.Fprintf(io.Out, "  * %s\n", change)
+	}
+	if crontab != "" {
+		fmt.Fprintf(io.Out, "  * %s: %s %s\n", helpers.PathRelativeToCWD(crontab), flag.GetString(ctx, "schedule"), command)
+	}
+
+	if flag.GetBool(ctx, "dry-run") {
+		return nil
+	}
+
+	if crontab != "" {
+		if err := appendCrontab(crontab, flag.GetString(ctx, "schedule"), command); err != nil {
+			return err
+		}
+	}
+	if err := cfg.WriteToDisk(ctx, cfg.ConfigFilePath()); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(io.Out)
+	fmt.Fprintln(io.Out, "On the next deploy:")
+	for _, step := range scaffoldNextSteps(cfg, kind, name) {
+		fmt.Fprintf(io.Out, "  * %s\n", step)
+	}
+	if kind == "cron" {
+		fmt.Fprintf(io.Out, "\nThe image needs supercronic and the crontab, for instance with these Dockerfile lines:\n\n%s\n", supercronicDockerfile)
+	}
+	return nil
+}
+
+// scaffold adds a process of kind named name, running command, to cfg and
+// returns the changes made.
+func scaffold(cfg *appconfig.Config, kind, name, command string) ([]string, error) {
+	if kind == "release" {
+		if cfg.Deploy != nil && cfg.Deploy.ReleaseCommand != "" && cfg.Deploy.ReleaseCommand != command {
+			return nil, fmt.Errorf("fly.toml already has the release command %q, edit it instead", cfg.Deploy.ReleaseCommand)
+		}
+		if cfg.Deploy == nil {
+			cfg.Deploy = &appconfig.Deploy{}
+		}
+		cfg.Deploy.ReleaseCommand = command
+		return []string{fmt.Sprintf("[deploy] release_command = %q", command)}, nil
+	}
+
+	if _, ok := cfg.Processes[name]; ok {
+		return nil, fmt.Errorf("fly.toml already has a %s process group", name)
+	}
+	if kind == "cron" {
+		command = "supercronic /app/crontab"
+	}
+
+	var changes []string
+	defaultGroup := cfg.DefaultProcessName()
+	if len(cfg.Processes) == 0 {
+		// The machines running the image command so far keep doing so
+		cfg.SetProcess(defaultGroup, "")
+		changes = append(changes, fmt.Sprintf("[processes] %s = \"\", running the command of the image as before", defaultGroup))
+	}
+	cfg.SetProcess(name, command)
+	changes = append(changes, fmt.Sprintf("[processes] %s = %q", name, command))
+
+	// Sections without processes belong to the default group, which a new
+	// group sorting first would become
+	if cfg.DefaultProcessName() != defaultGroup {
+		if pinned := pinSections(cfg, defaultGroup); len(pinned) > 0 {
+			changes = append(changes, fmt.Sprintf("processes = [%q] on %s, to keep them on %s", defaultGroup, strings.Join(pinned, ", "), defaultGroup))
+		}
+	}
+	return changes, nil
+}
+
+// pinSections sets the processes of the sections of cfg without any to
+// group, returning the sections changed.
+func pinSections(cfg *appconfig.Config, group string) (pinned []string) {
+	pin := func(processes *[]string, section string) {
+		if len(*processes) == 0 {
+			*processes = []string{group}
+			pinned = append(pinned, section)
+		}
+	}
+
+	if cfg.HTTPService != nil {
+		pin(&cfg.HTTPService.Processes, "[http_service]")
+	}
+	for i := range cfg.Services {
+		pin(&cfg.Services[i].Processes, "[[services]]")
+	}
+	checks := lo.Keys(cfg.Checks)
+	sort.Strings(checks)
+	for _, name := range checks {
+		pin(&cfg.Checks[name].Processes, fmt.Sprintf("[checks.%s]", name))
+	}
+	for i := range cfg.Mounts {
+		pin(&cfg.Mounts[i].Processes, "[[mounts]]")
+	}
+	for i := range cfg.Sidecars {
+		pin(&cfg.Sidecars[i].Processes, "[[sidecars]]")
+	}
+	if cfg.Init != nil {
+		pin(&cfg.Init.Processes, "[init]")
+	}
+	return lo.Uniq(pinned)
+}
+
+// scaffoldNextSteps describes what the next deploy does with the process
+// added to cfg.
+func scaffoldNextSteps(cfg *appconfig.Config, kind, name string) []string {
+	if kind == "release" {
+		return []string{
+			"the release command runs in a temporary machine, with the new image and secrets, before the machines are updated",
+			"the deploy is aborted when it fails, leaving the machines on the previous release",
+		}
+	}
+
+	region := cfg.PrimaryRegion
+	if region == "" {
+		region = "the primary region"
+	}
+	size := scaffoldKinds[kind]
+	return []string{
+		fmt.Sprintf("a machine of the %s group is created in %s, without services", name, region),
+		fmt.Sprintf("size it afterwards with: fly scale vm %s --vm-memory %d --group %s", size.Size, size.Memory, name),
+		fmt.Sprintf("scale it with: fly scale count %s=N", name),
+	}
+}
+
+// appendCrontab adds the job running command on schedule to the crontab at
+// path, creating it if needed.
+func appendCrontab(path, schedule, command string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s %s\n", schedule, command); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
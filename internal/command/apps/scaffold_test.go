@@ -0,0 +1,78 @@
+package apps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestScaffoldWorker(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	require.NoError(t, cfg.SetMachinesPlatform())
+	cfg.HTTPService = &appconfig.HTTPService{InternalPort: 8080}
+
+	changes, err := scaffold(cfg, "worker", "worker", "bin/jobs")
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, map[string]string{"app": "", "worker": "bin/jobs"}, cfg.Processes)
+	assert.Empty(t, cfg.HTTPService.Processes, "app stays the default group")
+
+	worker, err := cfg.Flatten("worker")
+	require.NoError(t, err)
+	assert.Nil(t, worker.HTTPService)
+
+	_, err = scaffold(cfg, "worker", "worker", "bin/other")
+	assert.Error(t, err)
+}
+
+func TestScaffoldPinsSections(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	require.NoError(t, cfg.SetMachinesPlatform())
+	cfg.Processes = map[string]string{"web": "bin/web"}
+	cfg.HTTPService = &appconfig.HTTPService{InternalPort: 8080}
+	cfg.Mounts = []appconfig.Mount{{Source: "data", Destination: "/data"}}
+	cfg.Checks = map[string]*appconfig.ToplevelCheck{"alive": {}}
+
+	changes, err := scaffold(cfg, "cron", "cron", "bin/cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, "supercronic /app/crontab", cfg.Processes["cron"])
+	require.Len(t, changes, 2)
+	assert.Contains(t, changes[1], "[http_service], [checks.alive], [[mounts]]")
+
+	assert.Equal(t, []string{"web"}, cfg.HTTPService.Processes)
+	assert.Equal(t, []string{"web"}, cfg.Mounts[0].Processes)
+	assert.Equal(t, []string{"web"}, cfg.Checks["alive"].Processes)
+
+	cron, err := cfg.Flatten("cron")
+	require.NoError(t, err)
+	assert.Nil(t, cron.HTTPService)
+	assert.Empty(t, cron.Mounts)
+}
+
+func TestScaffoldRelease(t *testing.T) {
+	cfg := appconfig.NewConfig()
+
+	_, err := scaffold(cfg, "release", "release", "bin/migrate")
+	require.NoError(t, err)
+	assert.Equal(t, "bin/migrate", cfg.Deploy.ReleaseCommand)
+	assert.Empty(t, cfg.Processes)
+
+	_, err = scaffold(cfg, "release", "release", "bin/other")
+	assert.Error(t, err)
+}
+
+func TestAppendCrontab(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crontab")
+
+	require.NoError(t, appendCrontab(path, "@hourly", "bin/cleanup"))
+	require.NoError(t, appendCrontab(path, "*/5 * * * *", "bin/sync"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "@hourly bin/cleanup\n*/5 * * * * bin/sync\n", string(data))
+}
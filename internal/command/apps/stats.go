@@ -0,0 +1,320 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/prometheus"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newStats() *cobra.Command {
+	const (
+		short = "Summarize the traffic of an app"
+		long  = short + `
+
+With --http, summarizes the HTTP traffic the Fly.io edge proxied to the app
+over the last --window: requests by status class, response times, bandwidth,
+TLS handshakes by protocol version, and the countries clients connected from.
+
+The edge doesn't cache responses, so every request reaches the app. Client
+countries are the countries of the edge regions clients connected to, which
+are the regions closest to them.
+`
+	)
+
+	cmd := command.New("stats", short, long, runStats,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly apps stats --http
+  fly apps stats --http --window 7d`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.Bool{
+			Name:        "http",
+			Description: "Summarize the HTTP traffic of the app",
+		},
+		flag.String{
+			Name:        "window",
+			Description: "How far back to summarize, such as 1h, 24h or 7d",
+			Default:     "24h",
+		},
+		flag.Int{
+			Name:        "top",
+			Description: "Number of client countries to list",
+			Default:     10,
+		},
+	)
+
+	return cmd
+}
+
+// httpStats is the HTTP traffic of an app over a window.
+type httpStats struct {
+	App             string         `json:"app"`
+	Window          string         `json:"window"`
+	Requests        float64        `json:"requests"`
+	StatusClasses   map[string]int `json:"status_classes"`
+	ResponseTimeP50 float64        `json:"response_time_p50_seconds"`
+	ResponseTimeP95 float64        `json:"response_time_p95_seconds"`
+	BytesIn         float64        `json:"bytes_in"`
+	BytesOut        float64        `json:"bytes_out"`
+	TLSVersions     []share        `json:"tls_versions"`
+	Countries       []share        `json:"countries"`
+}
+
+// share is the part of a total going to a name.
+type share struct {
+	Name    string  `json:"name"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+func runStats(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		appName = appconfig.NameFromContext(ctx)
+		top     = flag.GetInt(ctx, "top")
+	)
+
+	if !flag.GetBool(ctx, "http") {
+		return flyerr.Validation(errors.New("specify the traffic to summarize, such as --http"))
+	}
+
+	window, err := parseWindow(flag.GetString(ctx, "window"))
+	if err != nil {
+		return flyerr.Validation(err)
+	}
+
+	apiClient := client.FromContext(ctx).API()
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+
+	regions, _, err := apiClient.PlatformRegions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get regions: %w", err)
+	}
+
+	stats, err := queryHTTPStats(ctx, app, window, regions)
+	if err != nil {
+		return err
+	}
+	stats.Window = flag.GetString(ctx, "window")
+	if top > 0 && len(stats.Countries) > top {
+		stats.Countries = stats.Countries[:top]
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, stats)
+	}
+
+	if stats.Requests == 0 {
+		fmt.Fprintf(out, "%s served no HTTP requests over the last %s\n", appName, stats.Window)
+		return nil
+	}
+
+	classes := make([]string, 0, len(stats.StatusClasses))
+	for _, class := range []string{"2xx", "3xx", "4xx", "5xx"} {
+		if n, ok := stats.StatusClasses[class]; ok {
+			classes = append(classes, fmt.Sprintf("%s: %s", class, humanize.Comma(int64(n))))
+		}
+	}
+
+	summary := []string{
+		humanize.Comma(int64(stats.Requests)),
+		strings.Join(classes, ", "),
+		fmt.Sprintf("p50 %s, p95 %s", formatSeconds(stats.ResponseTimeP50), formatSeconds(stats.ResponseTimeP95)),
+		fmt.Sprintf("%s in, %s out", humanize.Bytes(uint64(stats.BytesIn)), humanize.Bytes(uint64(stats.BytesOut))),
+		"none, the edge proxies every request to the app",
+	}
+	title := fmt.Sprintf("HTTP traffic of %s over the last %s", appName, stats.Window)
+	if err := render.VerticalTable(out, title, [][]string{summary}, "Requests", "Responses", "Response time", "Bandwidth", "Caching"); err != nil {
+		return err
+	}
+
+	if err := render.Table(out, "TLS versions", shareRows(stats.TLSVersions), "Version", "Handshakes", "Share"); err != nil {
+		return err
+	}
+
+	return render.Table(out, "Client countries", shareRows(stats.Countries), "Country", "Requests", "Share")
+}
+
+// queryHTTPStats queries the edge metrics of app over window.
+func queryHTTPStats(ctx context.Context, app *api.AppCompact, window time.Duration, regions []api.Region) (*httpStats, error) {
+	var (
+		sel   = fmt.Sprintf(`app=%q`, app.Name)
+		rng   = fmt.Sprintf("[%ds]", int(window.Seconds()))
+		stats = &httpStats{App: app.Name}
+	)
+
+	query := func(q string) ([]prometheus.Sample, error) {
+		return prometheus.Query(ctx, app.Organization.Slug, q)
+	}
+	scalar := func(q string) (float64, error) {
+		samples, err := query(q)
+		if err != nil || len(samples) == 0 {
+			return 0, err
+		}
+		return samples[0].Value, nil
+	}
+
+	byStatus, err := query(fmt.Sprintf(`sum by (status) (increase(fly_edge_http_responses_count{%s}%s))`, sel, rng))
+	if err != nil {
+		return nil, err
+	}
+	stats.StatusClasses = statusClasses(byStatus)
+	for _, n := range stats.StatusClasses {
+		stats.Requests += float64(n)
+	}
+
+	for _, q := range []struct {
+		dst   *float64
+		query string
+	}{
+		{&stats.ResponseTimeP50, fmt.Sprintf(`histogram_quantile(0.5, sum by (le) (rate(fly_edge_http_response_time_seconds_bucket{%s}%s)))`, sel, rng)},
+		{&stats.ResponseTimeP95, fmt.Sprintf(`histogram_quantile(0.95, sum by (le) (rate(fly_edge_http_response_time_seconds_bucket{%s}%s)))`, sel, rng)},
+		{&stats.BytesIn, fmt.Sprintf(`sum(increase(fly_edge_data_in{%s}%s))`, sel, rng)},
+		{&stats.BytesOut, fmt.Sprintf(`sum(increase(fly_edge_data_out{%s}%s))`, sel, rng)},
+	} {
+		if *q.dst, err = scalar(q.query); err != nil {
+			return nil, err
+		}
+	}
+
+	byVersion, err := query(fmt.Sprintf(`sum by (tls_version) (increase(fly_edge_tls_handshake_time_seconds_count{%s}%s))`, sel, rng))
+	if err != nil {
+		return nil, err
+	}
+	stats.TLSVersions = shares(byVersion, func(labels map[string]string) string { return labels["tls_version"] })
+
+	byRegion, err := query(fmt.Sprintf(`sum by (region) (increase(fly_edge_http_responses_count{%s}%s))`, sel, rng))
+	if err != nil {
+		return nil, err
+	}
+	countries := regionCountries(regions)
+	stats.Countries = shares(byRegion, func(labels map[string]string) string { return countries[labels["region"]] })
+
+	return stats, nil
+}
+
+// parseWindow parses windows in minutes, hours or days, the last of which Go
+// durations don't have.
+func parseWindow(s string) (time.Duration, error) {
+	var (
+		window time.Duration
+		err    error
+	)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(s)
+	}
+
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid --window %s, expected a duration such as 1h or 7d", s)
+	case window < 5*time.Minute || window > 30*24*time.Hour:
+		return 0, fmt.Errorf("--window must be between 5m and 30d")
+	}
+	return window, nil
+}
+
+// statusClasses sums the responses of samples by status code into classes
+// such as 2xx.
+func statusClasses(samples []prometheus.Sample) map[string]int {
+	classes := map[string]int{}
+	for _, s := range samples {
+		status := s.Labels["status"]
+		if status == "" {
+			continue
+		}
+		classes[status[:1]+"xx"] += int(s.Value + 0.5)
+	}
+	return classes
+}
+
+// regionCountries maps the codes of regions to their countries, the end of
+// their names, such as "Amsterdam, Netherlands" or "Ashburn, Virginia (US)".
+func regionCountries(regions []api.Region) map[string]string {
+	countries := make(map[string]string, len(regions))
+	for _, r := range regions {
+		name := r.Name
+		if i := strings.LastIndex(name, "("); i >= 0 && strings.HasSuffix(name, ")") {
+			name = name[i+1 : len(name)-1]
+		} else if i := strings.LastIndex(name, ","); i >= 0 {
+			name = name[i+1:]
+		}
+		countries[r.Code] = strings.TrimSpace(name)
+	}
+	return countries
+}
+
+// shares sums samples by the name keyOf returns for their labels, largest
+// first. Samples without a name count as unknown.
+func shares(samples []prometheus.Sample, keyOf func(map[string]string) string) []share {
+	var (
+		counts = map[string]float64{}
+		total  float64
+	)
+	for _, s := range samples {
+		key := keyOf(s.Labels)
+		if key == "" {
+			key = "unknown"
+		}
+		counts[key] += s.Value
+		total += s.Value
+	}
+
+	out := make([]share, 0, len(counts))
+	for name, count := range counts {
+		if count < 0.5 {
+			continue
+		}
+		out = append(out, share{Name: name, Count: int(count + 0.5), Percent: 100 * count / total})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func shareRows(shares []share) [][]string {
+	rows := make([][]string, 0, len(shares))
+	for _, s := range shares {
+		rows = append(rows, []string{s.Name, humanize.Comma(int64(s.Count)), fmt.Sprintf("%.1f%%", s.Percent)})
+	}
+	return rows
+}
+
+func formatSeconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond).String()
+}
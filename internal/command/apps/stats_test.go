@@ -0,0 +1,66 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/prometheus"
+)
+
+func TestParseWindow(t *testing.T) {
+	window, err := parseWindow("7d")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, window)
+
+	window, err = parseWindow("90m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, window)
+
+	_, err = parseWindow("a week")
+	assert.ErrorContains(t, err, "invalid --window")
+
+	_, err = parseWindow("60d")
+	assert.ErrorContains(t, err, "between 5m and 30d")
+}
+
+func TestStatusClasses(t *testing.T) {
+	classes := statusClasses([]prometheus.Sample{
+		{Labels: map[string]string{"status": "200"}, Value: 100.4},
+		{Labels: map[string]string{"status": "204"}, Value: 10},
+		{Labels: map[string]string{"status": "503"}, Value: 2.6},
+		{Labels: map[string]string{}, Value: 5},
+	})
+	assert.Equal(t, map[string]int{"2xx": 110, "5xx": 3}, classes)
+}
+
+func TestShares(t *testing.T) {
+	countries := regionCountries([]api.Region{
+		{Code: "ams", Name: "Amsterdam, Netherlands"},
+		{Code: "iad", Name: "Ashburn, Virginia (US)"},
+		{Code: "ord", Name: "Chicago, Illinois (US)"},
+		{Code: "nrt", Name: "Tokyo, Japan"},
+	})
+	assert.Equal(t, "Netherlands", countries["ams"])
+	assert.Equal(t, "US", countries["iad"])
+
+	samples := []prometheus.Sample{
+		{Labels: map[string]string{"region": "iad"}, Value: 50},
+		{Labels: map[string]string{"region": "ord"}, Value: 25},
+		{Labels: map[string]string{"region": "ams"}, Value: 20},
+		{Labels: map[string]string{"region": "xyz"}, Value: 5},
+		{Labels: map[string]string{"region": "nrt"}, Value: 0.1},
+	}
+	got := shares(samples, func(labels map[string]string) string { return countries[labels["region"]] })
+
+	require.Len(t, got, 3, "shares round to whole requests")
+	assert.Equal(t, "US", got[0].Name)
+	assert.Equal(t, 75, got[0].Count)
+	assert.InDelta(t, 74.9, got[0].Percent, 0.1)
+	assert.Equal(t, "Netherlands", got[1].Name)
+	assert.Equal(t, "unknown", got[2].Name)
+	assert.Equal(t, 5, got[2].Count)
+}
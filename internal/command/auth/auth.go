@@ -19,6 +19,7 @@ import (
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/internal/state"
 )
@@ -42,6 +43,8 @@ If you do have an account, begin with the AUTH LOGIN subcommand.
 		newDocker(),
 		newLogout(),
 		newSignup(),
+		newSwitch(),
+		newContexts(),
 	)
 
 	return auth
@@ -66,21 +69,21 @@ func runWebLogin(ctx context.Context, signup bool) error {
 	colorize := io.ColorScheme()
 	fmt.Fprintf(io.Out, "Opening %s ...\n\n", colorize.Bold(auth.AuthURL))
 
-	token, err := waitForCLISession(ctx, logger, io.ErrOut, auth.ID)
+	session, err := waitForCLISession(ctx, logger, io.ErrOut, auth.ID)
 	switch {
 	case errors.Is(err, context.DeadlineExceeded):
 		return errors.New("Login expired, please try again")
 	case err != nil:
 		return err
-	case token == "":
+	case session.AccessToken == "":
 		return errors.New("failed to log in, please try again")
 	}
 
-	if err := persistAccessToken(ctx, token); err != nil {
+	if err := persistAccessToken(ctx, session.AccessToken); err != nil {
 		return err
 	}
 
-	client := client.FromToken(token).API()
+	client := client.FromToken(session.AccessToken).API()
 
 	user, err := client.GetCurrentUser(ctx)
 	if err != nil {
@@ -93,7 +96,7 @@ func runWebLogin(ctx context.Context, signup bool) error {
 }
 
 // TODO: this does NOT break on interrupts
-func waitForCLISession(parent context.Context, logger *logger.Logger, w io.Writer, id string) (token string, err error) {
+func waitForCLISession(parent context.Context, logger *logger.Logger, w io.Writer, id string) (session api.CLISessionAuth, err error) {
 	ctx, cancel := context.WithTimeout(parent, 15*time.Minute)
 	defer cancel()
 
@@ -103,7 +106,7 @@ func waitForCLISession(parent context.Context, logger *logger.Logger, w io.Write
 	s.Start()
 
 	for ctx.Err() == nil {
-		if token, err = api.GetAccessTokenForCLISession(ctx, id); err != nil {
+		if session, err = api.GetCLISession(ctx, id); err != nil {
 			logger.Debugf("failed retrieving token: %v", err)
 
 			pause.For(ctx, time.Second)
@@ -125,10 +128,51 @@ func waitForCLISession(parent context.Context, logger *logger.Logger, w io.Write
 func persistAccessToken(ctx context.Context, token string) (err error) {
 	path := state.ConfigFile(ctx)
 
-	if err = config.SetAccessToken(path, token); err != nil {
+	if err = applyCredentialStore(ctx, path); err != nil {
+		return
+	}
+
+	if err = warnKeyringFallback(ctx, config.SetAccessToken(path, token)); err != nil {
 		err = fmt.Errorf("failed persisting %s in %s: %w\n",
 			config.AccessTokenFileKey, path, err)
 	}
 
 	return
 }
+
+// insecureFileStore returns the flag opting out of storing access tokens in
+// the keyring of the operating system.
+func insecureFileStore() flag.Bool {
+	return flag.Bool{
+		Name:        "insecure-file-store",
+		Description: "Store the access and refresh tokens in plain text in the config file instead of the keyring",
+	}
+}
+
+// applyCredentialStore sets where the access token of the configuration file
+// found at path is stored, following the --insecure-file-store flag.
+func applyCredentialStore(ctx context.Context, path string) error {
+	var err error
+	switch {
+	case flag.GetBool(ctx, "insecure-file-store"):
+		err = config.SetCredentialStore(path, config.CredentialStoreFile)
+	case config.HasKeyring():
+		err = config.SetCredentialStore(path, config.CredentialStoreKeyring)
+	}
+	if err = warnKeyringFallback(ctx, err); err != nil {
+		return fmt.Errorf("failed setting the credential store of %s: %w", path, err)
+	}
+	return nil
+}
+
+// warnKeyringFallback prints err as a warning when the access token was
+// stored in the config file because the keyring failed, and returns any other
+// error.
+func warnKeyringFallback(ctx context.Context, err error) error {
+	var fallback *config.KeyringFallbackError
+	if !errors.As(err, &fallback) {
+		return err
+	}
+	fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Warning: %v\n", err)
+	return nil
+}
@@ -17,6 +17,15 @@ func newLogin() *cobra.Command {
 		long = `Logs a user into the Fly platform. Supports browser-based,
 email/password and one-time-password authentication. Defaults to using
 browser-based authentication.
+
+With --sso, log in through the single sign-on of an organization. Its access
+tokens are short-lived and silently refreshed. On machines without a browser,
+or with --device-code, open the printed URL on any device and enter the code.
+
+The access token, and the refresh token of SSO logins, are stored in the keyring
+of the operating system: the Keychain on macOS, the Credential Manager on
+Windows, or the Secret Service on Linux desktops. Elsewhere, or with
+--insecure-file-store, they're stored in plain text in the config file.
 `
 		short = "Log in a user"
 	)
@@ -41,6 +50,15 @@ browser-based authentication.
 			Name:        "otp",
 			Description: "One time password",
 		},
+		flag.String{
+			Name:        "sso",
+			Description: "Log in through the single sign-on of this organization",
+		},
+		flag.Bool{
+			Name:        "device-code",
+			Description: "Confirm the SSO login with a code from another device",
+		},
+		insecureFileStore(),
 	)
 
 	return cmd
@@ -52,9 +70,12 @@ func runLogin(ctx context.Context) error {
 		email       = flag.GetString(ctx, "email")
 		password    = flag.GetString(ctx, "password")
 		otp         = flag.GetString(ctx, "otp")
+		sso         = flag.GetString(ctx, "sso")
 	)
 
 	switch {
+	case sso != "":
+		return runSSOLogin(ctx, sso, flag.GetBool(ctx, "device-code") || !canOpenBrowser())
 	case interactive, email != "", password != "", otp != "":
 		return runShellLogin(ctx, email, password, otp)
 	default:
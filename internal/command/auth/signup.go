@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
 )
 
 func newSignup() *cobra.Command {
@@ -16,7 +17,11 @@ and sends the user to a form to provide appropriate credentials.
 		short = "Create a new fly account"
 	)
 
-	return command.New("signup", short, long, runSignup)
+	cmd := command.New("signup", short, long, runSignup)
+
+	flag.Add(cmd, insecureFileStore())
+
+	return cmd
 }
 
 func runSignup(ctx context.Context) error {
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/skratchdot/open-golang/open"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// canOpenBrowser reports whether a browser can likely be opened on this
+// machine, as opposed to over SSH or on a headless server.
+func canOpenBrowser() bool {
+	return browserAvailable(runtime.GOOS, os.Getenv)
+}
+
+func browserAvailable(goos string, getenv func(string) string) bool {
+	if getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != "" {
+		return false
+	}
+	switch goos {
+	case "darwin", "windows":
+		return true
+	default:
+		return getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != ""
+	}
+}
+
+func runSSOLogin(ctx context.Context, orgSlug string, deviceCode bool) error {
+	auth, err := api.StartCLISessionSSO(state.Hostname(ctx), orgSlug, deviceCode)
+	if err != nil {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	colorize := io.ColorScheme()
+
+	if !deviceCode {
+		if err := open.Run(auth.AuthURL); err == nil {
+			fmt.Fprintf(io.Out, "Opening %s ...\n\n", colorize.Bold(auth.AuthURL))
+		} else {
+			deviceCode = true
+		}
+	}
+	if deviceCode {
+		fmt.Fprintf(io.Out, "Open %s on any device", colorize.Bold(auth.AuthURL))
+		if auth.UserCode != "" {
+			fmt.Fprintf(io.Out, " and enter the code %s", colorize.Bold(auth.UserCode))
+		}
+		fmt.Fprint(io.Out, "\n\n")
+	}
+
+	session, err := waitForCLISession(ctx, logger.FromContext(ctx), io.ErrOut, auth.ID)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errors.New("Login expired, please try again")
+	case err != nil:
+		return err
+	case session.AccessToken == "":
+		return errors.New("failed to log in, please try again")
+	}
+
+	if session.RefreshToken == "" {
+		err = persistAccessToken(ctx, session.AccessToken)
+	} else {
+		sso := &config.SSOSession{
+			Organization: orgSlug,
+			RefreshToken: session.RefreshToken,
+		}
+		if session.ExpiresAt != nil {
+			sso.ExpiresAt = *session.ExpiresAt
+		}
+		path := state.ConfigFile(ctx)
+		if err = applyCredentialStore(ctx, path); err != nil {
+			return err
+		}
+		if err = warnKeyringFallback(ctx, config.SetSSOSession(path, session.AccessToken, sso)); err != nil {
+			err = fmt.Errorf("failed persisting %s in %s: %w", config.SSOSessionFileKey, path, err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := client.FromToken(session.AccessToken).API().GetCurrentUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed retrieving current user: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "successfully logged in as %s through the SSO of %s\n", colorize.Bold(user.Email), orgSlug)
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowserAvailable(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	assert.True(t, browserAvailable("darwin", env(nil)))
+	assert.False(t, browserAvailable("darwin", env(map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"})))
+	assert.False(t, browserAvailable("linux", env(nil)))
+	assert.True(t, browserAvailable("linux", env(map[string]string{"DISPLAY": ":0"})))
+	assert.True(t, browserAvailable("linux", env(map[string]string{"WAYLAND_DISPLAY": "wayland-0"})))
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/iostreams"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
+)
+
+const contextsHelp = `Auth contexts keep separate sets of credentials, like one per client, so you
+don't have to log in and out to switch between them. Each context has its own
+configuration file, holding its access token and default organization. The
+default context uses ~/.fly/config.yml, the others ~/.fly/contexts/<name>.yml.
+
+The current context applies to every command. Override it for one command with
+the --context flag or the FLY_CONTEXT environment variable.`
+
+func newSwitch() *cobra.Command {
+	const (
+		long = `Switch to the auth context with the given name, creating it when it doesn't
+exist. Log in to a new context with fly auth login once switched to it.
+
+` + contextsHelp
+		short = "Switch to another auth context"
+		usage = "switch <context>"
+	)
+
+	cmd := command.New(usage, short, long, runSwitch)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly auth switch client-acme --org acme
+  fly auth switch default`
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "org",
+			Shorthand:   "o",
+			Description: "Set the default organization of the context",
+		},
+	)
+
+	return cmd
+}
+
+func newContexts() *cobra.Command {
+	const (
+		long = `List the auth contexts, their default organization and whether they're logged
+in. The current context is marked with an asterisk.
+
+` + contextsHelp
+		short = "List auth contexts"
+	)
+
+	cmd := command.New("contexts", short, long, runContexts)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd, flag.JSONOutput())
+
+	return cmd
+}
+
+func runSwitch(ctx context.Context) error {
+	var (
+		io   = iostreams.FromContext(ctx)
+		dir  = state.ConfigDirectory(ctx)
+		name = flag.FirstArg(ctx)
+	)
+
+	if name != config.DefaultContext {
+		if err := config.ValidateContextName(name); err != nil {
+			return err
+		}
+		if err := config.CreateContext(dir, name); err != nil {
+			return fmt.Errorf("failed creating auth context %s: %w", name, err)
+		}
+	}
+
+	path := config.ContextFile(dir, name)
+	if org := flag.GetString(ctx, "org"); org != "" {
+		if err := config.SetOrganization(path, org); err != nil {
+			return fmt.Errorf("failed setting the default organization of %s: %w", name, err)
+		}
+	}
+
+	if err := config.SetCurrentContext(config.ContextFile(dir, config.DefaultContext), name); err != nil {
+		return fmt.Errorf("failed switching to auth context %s: %w", name, err)
+	}
+
+	// The agent keeps the tunnels of the previous context open
+	if ac, err := agent.DefaultClient(ctx); err == nil && name != state.AuthContext(ctx) {
+		_ = ac.Kill(ctx)
+	}
+
+	fmt.Fprintf(io.Out, "Switched to auth context %s\n", name)
+
+	cfg := config.New()
+	if err := cfg.ApplyFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if cfg.AccessToken == "" {
+		fmt.Fprintln(io.Out, "Log in to it with fly auth login")
+	}
+
+	return nil
+}
+
+// contextSummary describes an auth context.
+type contextSummary struct {
+	Name         string `json:"name"`
+	Current      bool   `json:"current"`
+	Organization string `json:"organization"`
+	LoggedIn     bool   `json:"logged_in"`
+	Path         string `json:"path"`
+}
+
+func runContexts(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		dir     = state.ConfigDirectory(ctx)
+		current = state.AuthContext(ctx)
+	)
+
+	names, err := config.Contexts(dir)
+	if err != nil {
+		return fmt.Errorf("failed listing auth contexts: %w", err)
+	}
+
+	summaries := make([]contextSummary, 0, len(names))
+	for _, name := range names {
+		path := config.ContextFile(dir, name)
+
+		cfg := config.New()
+		if err := cfg.ApplyFile(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed reading auth context %s: %w", name, err)
+		}
+
+		summaries = append(summaries, contextSummary{
+			Name:         name,
+			Current:      name == current,
+			Organization: cfg.Organization,
+			LoggedIn:     cfg.AccessToken != "",
+			Path:         path,
+		})
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, summaries)
+	}
+
+	rows := make([][]string, 0, len(summaries))
+	for _, s := range summaries {
+		marker := ""
+		if s.Current {
+			marker = "*"
+		}
+		loggedIn := "no"
+		if s.LoggedIn {
+			loggedIn = "yes"
+		}
+		rows = append(rows, []string{marker, s.Name, s.Organization, loggedIn, s.Path})
+	}
+
+	return render.Table(out, "", rows, "", "Name", "Organization", "Logged In", "Config")
+}
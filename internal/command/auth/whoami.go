@@ -3,34 +3,55 @@ package auth
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/iostreams"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/env"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/flagnames"
+	"github.com/superfly/flyctl/internal/macaroon"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
 )
 
 func newWhoAmI() *cobra.Command {
 	const (
 		long = `Displays the users email address/service identity currently
 authenticated and in use.
+
+With --verbose, also shows where the token comes from, its type and expiry,
+and the organizations and apps it can access. The caveats of macaroon tokens,
+which restrict what they grant, are listed too. Inspect another token, like
+the one of a CI pipeline, with --access-token.
 `
 		short = "Show the currently authenticated user"
 	)
 
-	cmd := command.New("whoami", long, short, runWhoAmI,
+	cmd := command.New("whoami", short, long, runWhoAmI,
 		command.RequireSession)
+	cmd.Example = `  fly auth whoami --verbose
+  fly auth whoami --verbose --access-token "$FLY_API_TOKEN"`
 	flag.Add(cmd, flag.JSONOutput())
 	return cmd
 }
 
 func runWhoAmI(ctx context.Context) error {
 	client := client.FromContext(ctx).API()
+	cfg := config.FromContext(ctx)
+
+	if cfg.VerboseOutput {
+		return runWhoAmIVerbose(ctx)
+	}
 
 	user, err := client.GetCurrentUser(ctx)
 	if err != nil {
@@ -38,7 +59,6 @@ func runWhoAmI(ctx context.Context) error {
 	}
 
 	io := iostreams.FromContext(ctx)
-	cfg := config.FromContext(ctx)
 
 	if cfg.JSONOutput {
 		_ = render.JSON(io.Out, map[string]string{"email": user.Email})
@@ -48,3 +68,191 @@ func runWhoAmI(ctx context.Context) error {
 
 	return nil
 }
+
+// tokenInfo describes the access token in use.
+type tokenInfo struct {
+	Email         string         `json:"email,omitempty"`
+	Type          string         `json:"type"`
+	Source        string         `json:"source"`
+	Context       string         `json:"context"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+	Organizations []tokenAccess  `json:"organizations"`
+	Apps          []tokenAccess  `json:"apps"`
+	AllApps       bool           `json:"all_apps"`
+	Macaroons     []macaroonInfo `json:"macaroons,omitempty"`
+}
+
+// tokenAccess is an organization or app a token can access.
+type tokenAccess struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Actions string `json:"actions"`
+}
+
+// macaroonInfo describes a macaroon of a token.
+type macaroonInfo struct {
+	Location string   `json:"location"`
+	Caveats  []string `json:"caveats"`
+}
+
+func runWhoAmIVerbose(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		cfg       = config.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	info := tokenInfo{
+		Source:  tokenSource(ctx),
+		Context: state.AuthContext(ctx),
+	}
+
+	if user, err := apiClient.GetCurrentUser(ctx); err == nil {
+		info.Email = user.Email
+	}
+
+	// Organizations and apps the token can see, to name those of caveats
+	orgs, _ := apiClient.GetOrganizations(ctx)
+
+	if macaroon.IsMacaroon(cfg.AccessToken) {
+		macaroons, err := macaroon.Parse(cfg.AccessToken)
+		if err != nil {
+			return fmt.Errorf("failed decoding the access token: %w", err)
+		}
+		describeMacaroons(ctx, apiClient, &info, macaroons, orgs)
+	} else {
+		info.Type = "user token, with the access of the user"
+		info.AllApps = true
+		for _, org := range orgs {
+			info.Organizations = append(info.Organizations, tokenAccess{ID: org.InternalNumericID, Name: org.Slug, Actions: "all"})
+		}
+		if session := cfg.SSOSession; session != nil && !session.ExpiresAt.IsZero() {
+			info.Type = fmt.Sprintf("SSO token of %s, refreshed automatically", session.Organization)
+			info.ExpiresAt = &session.ExpiresAt
+		}
+	}
+
+	if cfg.JSONOutput {
+		return render.JSON(io.Out, info)
+	}
+
+	email := info.Email
+	if email == "" {
+		email = "unknown, the token doesn't identify a user"
+	}
+	expiry := "never"
+	if info.ExpiresAt != nil {
+		expiry = fmt.Sprintf("%s (%s)", info.ExpiresAt.Format(time.RFC3339), humanize.Time(*info.ExpiresAt))
+	}
+
+	fmt.Fprintf(io.Out, "User:     %s\n", email)
+	fmt.Fprintf(io.Out, "Token:    %s\n", info.Type)
+	fmt.Fprintf(io.Out, "Source:   %s\n", info.Source)
+	fmt.Fprintf(io.Out, "Context:  %s\n", info.Context)
+	fmt.Fprintf(io.Out, "Expires:  %s\n", expiry)
+
+	fmt.Fprintln(io.Out)
+	rows := make([][]string, 0, len(info.Organizations))
+	for _, a := range info.Organizations {
+		rows = append(rows, []string{a.Name, a.ID, a.Actions})
+	}
+	if err := render.Table(io.Out, "Organizations", rows, "Name", "ID", "Actions"); err != nil {
+		return err
+	}
+
+	if info.AllApps {
+		fmt.Fprintln(io.Out, "Apps: every app of these organizations")
+	} else {
+		rows = make([][]string, 0, len(info.Apps))
+		for _, a := range info.Apps {
+			rows = append(rows, []string{a.Name, a.ID, a.Actions})
+		}
+		if err := render.Table(io.Out, "Apps", rows, "Name", "ID", "Actions"); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range info.Macaroons {
+		fmt.Fprintf(io.Out, "\nCaveats of %s:\n", m.Location)
+		for _, c := range m.Caveats {
+			fmt.Fprintf(io.Out, "  %s\n", c)
+		}
+	}
+
+	return nil
+}
+
+// describeMacaroons fills info with what macaroons grant, naming organizations
+// and apps after orgs.
+func describeMacaroons(ctx context.Context, apiClient *api.Client, info *tokenInfo, macaroons []*macaroon.Macaroon, orgs []api.Organization) {
+	var discharges int
+	for _, m := range macaroons {
+		if m.Location != macaroon.LocationPermission {
+			discharges++
+		}
+
+		mi := macaroonInfo{Location: m.Location}
+		for _, c := range m.Caveats {
+			mi.Caveats = append(mi.Caveats, c.String())
+		}
+		info.Macaroons = append(info.Macaroons, mi)
+	}
+	info.Type = fmt.Sprintf("macaroon, with %d discharge tokens", discharges)
+
+	if expiry, ok := macaroon.Expiry(macaroons); ok {
+		info.ExpiresAt = &expiry
+	}
+
+	orgsByID := map[string]api.Organization{}
+	for _, org := range orgs {
+		orgsByID[org.InternalNumericID] = org
+	}
+
+	appNames := map[string]string{}
+	for id, mask := range macaroon.Organizations(macaroons) {
+		key := strconv.FormatUint(id, 10)
+		org := orgsByID[key]
+		info.Organizations = append(info.Organizations, tokenAccess{ID: key, Name: org.Slug, Actions: macaroon.ActionString(mask)})
+
+		if org.ID == "" {
+			continue
+		}
+		apps, err := apiClient.GetAppsForOrganization(ctx, org.ID)
+		if err != nil {
+			continue
+		}
+		for _, app := range apps {
+			appNames[strconv.Itoa(app.InternalNumericID)] = app.Name
+		}
+	}
+	sort.Slice(info.Organizations, func(i, j int) bool { return info.Organizations[i].ID < info.Organizations[j].ID })
+
+	apps := macaroon.Apps(macaroons)
+	if apps == nil {
+		info.AllApps = true
+		return
+	}
+	for id, mask := range apps {
+		key := strconv.FormatUint(id, 10)
+		info.Apps = append(info.Apps, tokenAccess{ID: key, Name: appNames[key], Actions: macaroon.ActionString(mask)})
+	}
+	sort.Slice(info.Apps, func(i, j int) bool { return info.Apps[i].ID < info.Apps[j].ID })
+}
+
+// tokenSource returns where the access token in use comes from.
+func tokenSource(ctx context.Context) string {
+	switch {
+	case flag.IsSpecified(ctx, flagnames.AccessToken):
+		return "--access-token flag"
+	case env.IsSet(config.AccessTokenEnvKey):
+		return config.AccessTokenEnvKey + " environment variable"
+	case env.IsSet(config.APITokenEnvKey):
+		return config.APITokenEnvKey + " environment variable"
+	}
+
+	source := state.ConfigFile(ctx)
+	if config.FromContext(ctx).CredentialStore == config.CredentialStoreKeyring {
+		source = "keyring, for " + source
+	}
+	return source
+}
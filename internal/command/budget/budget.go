@@ -0,0 +1,242 @@
+// Package budget implements the budget command chain.
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new budget Command.
+func New() *cobra.Command {
+	const (
+		long = `Manage monthly budgets of organizations and apps. Commands adding cost,
+such as scale count, scale vm, volumes create, ips allocate-v4 and logs ship,
+print the estimated monthly cost they add when a budget applies, and ask for
+confirmation when it would be exceeded.
+
+Costs are estimated from the list prices of started machines, volumes and
+dedicated IPv4 addresses, and leave out usage such as bandwidth.
+
+Budgets are stored in flyctl's configuration file and checked by this flyctl
+only. They are a local safeguard, not spending limits: the platform doesn't
+enforce them, and the API, the dashboard and other flyctl installs bypass them.`
+		short = "Manage cost budgets"
+	)
+
+	cmd := command.New("budget", short, long, nil)
+
+	cmd.AddCommand(
+		newSet(),
+		newShow(),
+		newRemove(),
+	)
+
+	return cmd
+}
+
+func budgetTargetFlags() []flag.Flag {
+	return []flag.Flag{
+		flag.String{
+			Name:        "org",
+			Shorthand:   "o",
+			Description: "The organization the budget applies to",
+		},
+		flag.String{
+			Name:        "app",
+			Shorthand:   "a",
+			Description: "The app the budget applies to",
+		},
+	}
+}
+
+func newSet() *cobra.Command {
+	const (
+		long  = `Set the monthly budget in USD of an organization or app.`
+		short = "Set a monthly budget"
+		usage = "set <amount>"
+	)
+
+	cmd := command.New(usage, short, long, runSet)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly budget set 50 --org my-org
+  fly budget set 10 --app my-app`
+
+	flag.Add(cmd, budgetTargetFlags()...)
+
+	return cmd
+}
+
+func newShow() *cobra.Command {
+	const (
+		long  = `List the budgets along with the estimated monthly cost of what they cover.`
+		short = "List budgets"
+	)
+
+	cmd := command.New("show", short, long, runShow,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"list", "ls"}
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newRemove() *cobra.Command {
+	const (
+		long  = `Remove the budget of an organization or app.`
+		short = "Remove a budget"
+	)
+
+	cmd := command.New("remove", short, long, runRemove)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(cmd, budgetTargetFlags()...)
+
+	return cmd
+}
+
+// budgetTarget returns the kind and name of the budget the flags select.
+func budgetTarget(ctx context.Context) (kind, name string, err error) {
+	var (
+		org = flag.GetString(ctx, "org")
+		app = flag.GetString(ctx, "app")
+	)
+	switch {
+	case org != "" && app != "":
+		return "", "", errors.New("set either --org or --app, not both")
+	case org != "":
+		return config.BudgetOrg, org, nil
+	case app != "":
+		return config.BudgetApp, app, nil
+	default:
+		return "", "", errors.New("--org or --app is required")
+	}
+}
+
+func runSet(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	amount, err := strconv.ParseFloat(flag.FirstArg(ctx), 64)
+	if err != nil || amount <= 0 {
+		return fmt.Errorf("invalid amount %q, expected a positive number of USD", flag.FirstArg(ctx))
+	}
+
+	kind, name, err := budgetTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := config.SetBudget(state.ConfigFile(ctx), kind, name, amount); err != nil {
+		return fmt.Errorf("failed saving budget: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "Monthly budget of %s set to $%.2f\n", name, amount)
+	return nil
+}
+
+func runRemove(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	kind, name, err := budgetTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := config.FromContext(ctx).Budget(kind, name); !ok {
+		return fmt.Errorf("%s has no budget", name)
+	}
+
+	if err := config.SetBudget(state.ConfigFile(ctx), kind, name, 0); err != nil {
+		return fmt.Errorf("failed removing budget: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "Budget of %s removed\n", name)
+	return nil
+}
+
+type budgetStatus struct {
+	Kind          string  `json:"kind"`
+	Name          string  `json:"name"`
+	Budget        float64 `json:"budget"`
+	EstimatedCost float64 `json:"estimated_cost"`
+}
+
+func runShow(ctx context.Context) error {
+	var (
+		out = iostreams.FromContext(ctx).Out
+		cfg = config.FromContext(ctx)
+	)
+
+	prices, err := budget.FetchPrices(ctx)
+	if err != nil {
+		return err
+	}
+
+	var statuses []budgetStatus
+	for _, kind := range []string{config.BudgetOrg, config.BudgetApp} {
+		names := make([]string, 0, len(cfg.Budgets[kind]))
+		for name := range cfg.Budgets[kind] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			amount, _ := cfg.Budget(kind, name)
+
+			var cost float64
+			if kind == config.BudgetOrg {
+				cost, err = budget.OrgCost(ctx, prices, name)
+			} else {
+				cost, err = budget.AppCost(ctx, prices, name)
+			}
+			if err != nil {
+				return fmt.Errorf("failed estimating the cost of %s: %w", name, err)
+			}
+
+			statuses = append(statuses, budgetStatus{Kind: kind, Name: name, Budget: amount, EstimatedCost: cost})
+		}
+	}
+
+	if cfg.JSONOutput {
+		return render.JSON(out, statuses)
+	}
+
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No budgets are set")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(statuses))
+	for _, s := range statuses {
+		rows = append(rows, []string{
+			s.Kind,
+			s.Name,
+			fmt.Sprintf("$%.2f", s.Budget),
+			fmt.Sprintf("$%.2f", s.EstimatedCost),
+			fmt.Sprintf("%.0f%%", s.EstimatedCost/s.Budget*100),
+		})
+	}
+
+	return render.Table(out, "Monthly budgets", rows, "Kind", "Name", "Budget", "Estimated Cost", "Used")
+}
@@ -0,0 +1,115 @@
+// Package cache implements the cache command chain.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/cache"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new cache Command.
+func New() *cobra.Command {
+	const (
+		short = "Manage the cached API lookups"
+		long  = `Manage the organizations, platform versions and machines of apps flyctl
+caches for a short while, to avoid looking them up at the start of every command.
+Lookups are cached separately for each auth context, and dropped when its
+access token changes.`
+	)
+
+	cmd := command.New("cache", short, long, nil)
+
+	cmd.AddCommand(
+		newStatus(),
+		newClear(),
+	)
+
+	return cmd
+}
+
+func newStatus() *cobra.Command {
+	const (
+		short = "Show the cached API lookups"
+		long  = short + "\n"
+	)
+
+	cmd := command.New("status", short, long, runStatus)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd, flag.JSONOutput())
+
+	return cmd
+}
+
+func runStatus(ctx context.Context) error {
+	var (
+		io     = iostreams.FromContext(ctx)
+		cfg    = config.FromContext(ctx)
+		status = cache.LookupsFromContext(ctx).Status()
+	)
+
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Kind != status[j].Kind {
+			return status[i].Kind < status[j].Kind
+		}
+		return status[i].Key < status[j].Key
+	})
+
+	if cfg.JSONOutput {
+		return render.JSON(io.Out, status)
+	}
+
+	if len(status) == 0 {
+		fmt.Fprintln(io.Out, "No API lookups are cached.")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(status))
+	for _, s := range status {
+		rows = append(rows, []string{
+			s.Kind,
+			s.Key,
+			humanize.Time(s.CachedAt),
+			lo.Ternary(s.Fresh, "fresh", "expired"),
+		})
+	}
+
+	fmt.Fprintf(io.Out, "Apps are cached for %s, machines for %s.\n\n",
+		cache.AppTTL, cache.MachinesTTL.Round(time.Second))
+
+	return render.Table(io.Out, "", rows, "Kind", "Key", "Cached", "State")
+}
+
+func newClear() *cobra.Command {
+	const (
+		short = "Clear the cached API lookups"
+		long  = short + "\n"
+	)
+
+	cmd := command.New("clear", short, long, runClear)
+	cmd.Args = cobra.NoArgs
+
+	return cmd
+}
+
+func runClear(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	cache.LookupsFromContext(ctx).Clear()
+
+	fmt.Fprintln(io.Out, "Cleared the cached API lookups.")
+
+	return nil
+}
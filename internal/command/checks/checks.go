@@ -6,6 +6,31 @@ import (
 	"github.com/superfly/flyctl/internal/flag"
 )
 
+const runLong = `Run the smoke tests declared in [[smoke_tests]] sections of fly.toml against
+the deployed app, and exit with an error if any fails. Each test either
+requests a path of the app, or a url, expecting a status and a body, or runs a
+command on a started machine expecting it to exit with 0:
+
+  [[smoke_tests]]
+    name = "homepage"
+    path = "/"
+    body_contains = "Welcome"
+    max_latency = "500ms"
+    retries = 2
+
+  [[smoke_tests]]
+    name = "migrations"
+    command = "bin/rails db:migrate:status"
+    processes = ["app"]
+
+Deploys run the smoke tests after updating machines, and mark the release
+failed when one fails. With the canary strategy they first run against the
+canary machines, aborting the deploy before any machine is updated.
+Use --smoke-tests=false with fly deploy to skip them. Failing tests are
+retried up to retries times, 5 seconds apart. fly deploy also requests the
+urls of --smoke-url after updating machines, and with --auto-rollback rolls
+the machines back to their previous release when a test or request fails.`
+
 func New() *cobra.Command {
 	commonFlags := flag.Set{flag.App(), flag.AppConfig()}
 
@@ -20,5 +45,24 @@ func New() *cobra.Command {
 	)
 	flag.Add(listCmd, flag.JSONOutput())
 	cmd.AddCommand(listCmd)
+
+	// fly checks run
+	runCmd := command.New("run", "Run smoke tests", runLong, runSmokeTests, command.RequireSession, command.RequireAppName)
+	flag.Add(runCmd, commonFlags,
+		flag.StringArray{Name: "test", Description: "Only run the smoke test with this name, can be repeated"},
+		flag.String{Name: "machine", Description: "Run the smoke tests against this machine only"},
+		flag.JSONOutput(),
+	)
+	cmd.AddCommand(runCmd)
+
+	// fly checks probe
+	probeCmd := command.New("probe", "Probe the health endpoint of an app from the edges of regions", probeLong, runProbe, command.RequireSession, command.RequireAppName)
+	flag.Add(probeCmd, commonFlags,
+		flag.StringSlice{Name: "region", Shorthand: "r", Description: "Probe from the edges of these regions only, comma separated or repeated"},
+		flag.String{Name: "path", Description: "Path of the health endpoint, instead of the one of the first HTTP health check in fly.toml"},
+		flag.String{Name: "protocol", Description: "Protocol of the health endpoint, http or grpc", Default: "http"},
+		flag.JSONOutput(),
+	)
+	cmd.AddCommand(probeCmd)
 	return cmd
 }
@@ -0,0 +1,223 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command/curl"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const probeLong = `Probe the health endpoint of the app from the Fly edges of regions, and
+report the status and latency of each. Every region is probed unless --region
+is set.
+
+The path probed is the one of the first HTTP health check in fly.toml, unless
+--path is set. When the probe fails from every region, the app itself is
+likely unhealthy; when it fails from some regions only, the network of those
+regions is the likely culprit. The latter exits with the partial-success code
+of 'fly help exit-codes'.
+
+With --protocol grpc, the edges request the standard gRPC health service
+instead. They don't speak gRPC, so the probe checks the gRPC server answers
+through the edge, rather than that it reports SERVING.`
+
+const grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+// probeResult is the outcome of probing the health endpoint of an app from
+// the edge of a region.
+type probeResult struct {
+	Region  string  `json:"region"`
+	Status  int     `json:"status,omitempty"`
+	TTFBMs  float64 `json:"ttfb_ms,omitempty"`
+	TotalMs float64 `json:"total_ms,omitempty"`
+	Healthy bool    `json:"healthy"`
+	Error   string  `json:"error,omitempty"`
+}
+
+func runProbe(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		protocol  = flag.GetString(ctx, "protocol")
+		path      = flag.GetString(ctx, "path")
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+
+	switch protocol {
+	case "http":
+		if path == "" {
+			cfg := appconfig.ConfigFromContext(ctx)
+			if cfg == nil {
+				if cfg, err = appconfig.FromRemoteApp(ctx, appName); err != nil {
+					return fmt.Errorf("failed to fetch app config from backend: %w", err)
+				}
+			}
+			if path = healthCheckPath(cfg); path == "" {
+				return flyerr.Validation(fmt.Errorf("%s has no HTTP health checks, pass the path to probe with --path", appName))
+			}
+		}
+	case "grpc":
+		if path == "" {
+			path = grpcHealthPath
+		}
+	default:
+		return flyerr.Validation(fmt.Errorf("unknown protocol %q, valid options are: http, grpc", protocol))
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u, err := url.Parse("https://" + app.Hostname + path)
+	if err != nil {
+		return flyerr.Validation(fmt.Errorf("invalid path %q: %w", path, err))
+	}
+
+	timings, err := curl.TimeFromRegions(ctx, u, flag.GetStringSlice(ctx, "region"))
+	if err != nil {
+		return err
+	}
+	results := newProbeResults(timings, protocol)
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(io.Out, results); err != nil {
+			return err
+		}
+		return probeError(appName, results)
+	}
+
+	cs := io.ColorScheme()
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status, outcome := "-", cs.Green("healthy")
+		if r.Status > 0 {
+			status = strconv.Itoa(r.Status)
+		}
+		if !r.Healthy {
+			outcome = cs.Red(r.Error)
+		}
+		rows = append(rows, []string{
+			r.Region,
+			status,
+			fmt.Sprintf("%.1fms", r.TTFBMs),
+			fmt.Sprintf("%.1fms", r.TotalMs),
+			outcome,
+		})
+	}
+	if err := render.Table(io.Out, u.String(), rows, "Region", "Status", "TTFB", "Total", "Result"); err != nil {
+		return err
+	}
+
+	return probeError(appName, results)
+}
+
+// healthCheckPath returns the path of the first HTTP health check of cfg, of
+// the http_service, then of services, then of top level checks by name.
+func healthCheckPath(cfg *appconfig.Config) string {
+	if cfg.HTTPService != nil {
+		for _, check := range cfg.HTTPService.HTTPChecks {
+			if check.HTTPPath != nil {
+				return *check.HTTPPath
+			}
+		}
+	}
+
+	for _, service := range cfg.Services {
+		for _, check := range service.HTTPChecks {
+			if check.HTTPPath != nil {
+				return *check.HTTPPath
+			}
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Checks))
+	for name := range cfg.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check := cfg.Checks[name]
+		if check.Type != nil && *check.Type == "http" && check.HTTPPath != nil {
+			return *check.HTTPPath
+		}
+	}
+
+	return ""
+}
+
+// newProbeResults returns the results of probing with protocol from the
+// timings of the edges of regions.
+func newProbeResults(timings []curl.RegionTiming, protocol string) []probeResult {
+	results := make([]probeResult, 0, len(timings))
+	for _, t := range timings {
+		r := probeResult{
+			Region:  t.Region,
+			Status:  t.Status,
+			TTFBMs:  float64(t.TTFB.Microseconds()) / 1000,
+			TotalMs: float64(t.Total.Microseconds()) / 1000,
+		}
+
+		switch {
+		case t.Err != nil:
+			r.Error = t.Err.Error()
+		case protocol == "grpc":
+			// gRPC servers answer plain HTTP requests with client errors
+			r.Healthy = t.Status > 0 && t.Status < 500
+		default:
+			r.Healthy = t.Status >= 200 && t.Status < 300
+		}
+		if !r.Healthy && r.Error == "" {
+			r.Error = fmt.Sprintf("unhealthy, status %d", t.Status)
+		}
+
+		results = append(results, r)
+	}
+	return results
+}
+
+// probeError returns the error of probing the health endpoint of appName
+// with results, telling apart failures of the app from the ones of regions.
+func probeError(appName string, results []probeResult) error {
+	var (
+		errs    []error
+		regions []string
+		healthy int
+	)
+	for _, r := range results {
+		if r.Healthy {
+			healthy++
+			continue
+		}
+		regions = append(regions, r.Region)
+		errs = append(errs, fmt.Errorf("%s: %s", r.Region, r.Error))
+	}
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case healthy == 0:
+		return fmt.Errorf("%s failed its health probe from every region, the app itself is likely unhealthy:\n%w", appName, errors.Join(errs...))
+	default:
+		return &flyerr.PartialError{
+			Succeeded: healthy,
+			Failed:    len(errs),
+			Err:       fmt.Errorf("%s failed its health probe from %s only, likely a regional network issue:\n%w", appName, strings.Join(regions, ", "), errors.Join(errs...)),
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package checks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command/curl"
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+func TestHealthCheckPath(t *testing.T) {
+	cfg := &appconfig.Config{
+		Checks: map[string]*appconfig.ToplevelCheck{
+			"tcp":   {Type: api.Pointer("tcp")},
+			"ready": {Type: api.Pointer("http"), HTTPPath: api.Pointer("/ready")},
+		},
+	}
+	assert.Equal(t, "/ready", healthCheckPath(cfg))
+
+	cfg.Services = []appconfig.Service{{HTTPChecks: []*appconfig.ServiceHTTPCheck{{HTTPPath: api.Pointer("/svc")}}}}
+	assert.Equal(t, "/svc", healthCheckPath(cfg))
+
+	cfg.HTTPService = &appconfig.HTTPService{HTTPChecks: []*appconfig.ServiceHTTPCheck{{HTTPPath: api.Pointer("/healthz")}}}
+	assert.Equal(t, "/healthz", healthCheckPath(cfg))
+
+	assert.Equal(t, "", healthCheckPath(&appconfig.Config{}))
+}
+
+func TestProbeResults(t *testing.T) {
+	timings := []curl.RegionTiming{
+		{Region: "ams", Status: 200, TTFB: 12 * time.Millisecond, Total: 15500 * time.Microsecond},
+		{Region: "ord", Status: 503},
+		{Region: "syd", Err: errors.New("timeout")},
+	}
+
+	results := newProbeResults(timings, "http")
+	assert.Equal(t, probeResult{Region: "ams", Status: 200, TTFBMs: 12, TotalMs: 15.5, Healthy: true}, results[0])
+	assert.Equal(t, "unhealthy, status 503", results[1].Error)
+	assert.Equal(t, "timeout", results[2].Error)
+
+	err := probeError("web", results)
+	var partial *flyerr.PartialError
+	assert.ErrorAs(t, err, &partial)
+	assert.Equal(t, 2, partial.Failed)
+	assert.Contains(t, err.Error(), "from ord, syd only")
+
+	results = newProbeResults([]curl.RegionTiming{{Region: "ams", Status: 415}, {Region: "ord", Status: 503}}, "grpc")
+	assert.True(t, results[0].Healthy)
+	assert.False(t, results[1].Healthy)
+	assert.NoError(t, probeError("web", results[:1]))
+
+	err = probeError("web", newProbeResults(timings[1:], "http"))
+	assert.ErrorContains(t, err, "from every region")
+	assert.Equal(t, flyerr.ExitCodeError, flyerr.ExitCode(err))
+}
@@ -0,0 +1,91 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/smoketest"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func runSmokeTests(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+
+	cfg := appconfig.ConfigFromContext(ctx)
+	if cfg == nil {
+		if cfg, err = appconfig.FromRemoteApp(ctx, appName); err != nil {
+			return fmt.Errorf("failed to fetch app config from backend: %w", err)
+		}
+	}
+
+	tests := cfg.SmokeTests
+	if names := flag.GetStringArray(ctx, "test"); len(names) > 0 {
+		for _, name := range names {
+			if !slices.ContainsFunc(tests, func(t appconfig.SmokeTest) bool { return t.Name == name }) {
+				return fmt.Errorf("no smoke test named %s", name)
+			}
+		}
+		tests = lo.Filter(tests, func(t appconfig.SmokeTest, _ int) bool {
+			return slices.Contains(names, t.Name)
+		})
+	}
+	if len(tests) == 0 {
+		return fmt.Errorf("%s has no smoke tests, declare them in [[smoke_tests]] sections of fly.toml", appName)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	runner := &smoketest.Runner{App: app, Flaps: flapsClient}
+
+	if machineID := flag.GetString(ctx, "machine"); machineID != "" {
+		machine, err := flapsClient.Get(ctx, machineID)
+		if err != nil {
+			return fmt.Errorf("failed to get machine %s: %w", machineID, err)
+		}
+		if machine.State != api.MachineStateStarted {
+			return fmt.Errorf("machine %s is %s, it must be started to run smoke tests", machineID, machine.State)
+		}
+		runner.Machine = machine
+		tests = smoketest.ForGroup(tests, machine.ProcessGroup(), true)
+		if len(tests) == 0 {
+			return fmt.Errorf("no smoke tests apply to the %s group of machine %s", machine.ProcessGroup(), machineID)
+		}
+	}
+
+	results := runner.Run(ctx, tests)
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(io.Out, results); err != nil {
+			return err
+		}
+	} else if err := smoketest.Render(io.Out, results); err != nil {
+		return err
+	}
+
+	if failed := smoketest.Failed(results); failed > 0 {
+		return fmt.Errorf("%d of %d smoke tests failed", failed, len(results))
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/blang/semver"
@@ -63,6 +64,7 @@ var commonPreparers = []preparers.Preparer{
 	startQueryingForNewRelease,
 	promptToUpdate,
 	preparers.InitClient,
+	loadLookups,
 	killOldAgent,
 	recordMetricsCommandContext,
 }
@@ -145,6 +147,15 @@ func finalize(ctx context.Context) {
 				Warnf("failed saving cache to %s: %v", path, err)
 		}
 	}
+
+	if l := cache.LookupsFromContext(ctx); l != nil && l.Dirty() {
+		path := lookupsPath(ctx)
+
+		if err := l.Save(path); err != nil {
+			logger.FromContext(ctx).
+				Warnf("failed saving cached lookups to %s: %v", path, err)
+		}
+	}
 }
 
 func determineHostname(ctx context.Context) (context.Context, error) {
@@ -241,6 +252,39 @@ func loadCache(ctx context.Context) (context.Context, error) {
 	return cache.NewContext(ctx, c), nil
 }
 
+// loadLookups loads the API lookups cached on behalf of the access token of
+// the auth context ctx carries.
+func loadLookups(ctx context.Context) (context.Context, error) {
+	logger := logger.FromContext(ctx)
+
+	var (
+		path     = lookupsPath(ctx)
+		identity = cache.LookupsIdentity(config.FromContext(ctx).AccessToken)
+	)
+
+	lookups, err := cache.LoadLookups(path, identity)
+	if err != nil {
+		lookups = cache.NewLookups(identity)
+
+		logger.Warnf("failed loading cached lookups from %s: %v", path, err)
+	}
+
+	logger.Debug("cached lookups loaded.")
+
+	return cache.NewLookupsContext(ctx, lookups), nil
+}
+
+// lookupsPath returns the path to the lookups file of the auth context ctx
+// carries, next to its config file.
+func lookupsPath(ctx context.Context) string {
+	if state.AuthContext(ctx) == config.DefaultContext {
+		return filepath.Join(state.ConfigDirectory(ctx), cache.LookupsFileName)
+	}
+
+	path := state.ConfigFile(ctx)
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + cache.LookupsFileName
+}
+
 func initTaskManager(ctx context.Context) (context.Context, error) {
 	tm := task.New(ctx)
 
@@ -250,8 +294,7 @@ func initTaskManager(ctx context.Context) (context.Context, error) {
 }
 
 func IsMachinesPlatform(ctx context.Context, appName string) (bool, error) {
-	apiClient := client.FromContext(ctx).API()
-	app, err := apiClient.GetAppBasic(ctx, appName)
+	app, err := cache.GetAppBasic(ctx, appName)
 	if err != nil {
 		return false, fmt.Errorf("failed to retrieve app: %w", err)
 	}
@@ -487,12 +530,11 @@ func LoadAppConfigIfPresent(ctx context.Context) (context.Context, error) {
 }
 
 func determinePlatform(ctx context.Context, appName string) (string, error) {
-	client := client.FromContext(ctx)
 	if appName == "" {
 		return "", fmt.Errorf("Can't determine platform without an application name")
 	}
 
-	basicApp, err := client.API().GetAppBasic(ctx, appName)
+	basicApp, err := cache.GetAppBasic(ctx, appName)
 	if err != nil {
 		return "", err
 	}
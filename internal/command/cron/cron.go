@@ -0,0 +1,403 @@
+// Package cron implements the cron command chain.
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new cron Command.
+func New() *cobra.Command {
+	const (
+		long = `Run commands on a schedule against apps. Jobs of an organization are run by
+a cron runner app, created in the organization along with its first job, with
+an organization deploy token.
+
+Jobs either execute their command in a started machine of the app, or run it
+in a one-shot machine with the configuration of one of the app's machines,
+which is destroyed once the command exits. Schedules are cron expressions in
+UTC.`
+		short = "Run commands on a schedule"
+	)
+
+	cmd := command.New("cron", short, long, nil)
+
+	cmd.AddCommand(
+		newAdd(),
+		newList(),
+		newRemove(),
+		newRuns(),
+		newRunner(),
+	)
+
+	return cmd
+}
+
+func newAdd() *cobra.Command {
+	const (
+		long = `Add a job running a command against the app on a schedule, or replace the
+job of the same name.`
+		short = "Add a scheduled job"
+		usage = "add <name> <command>..."
+	)
+
+	cmd := command.New(usage, short, long, runAdd,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(2)
+	cmd.Example = `  fly cron add cleanup --schedule "0 3 * * *" -- bin/rails db:cleanup
+  fly cron add report --schedule @hourly --mode machine --timeout 30m -- ./report.sh`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "schedule",
+			Description: `Cron expression in UTC, such as "*/15 * * * *", or @hourly, @daily, @weekly, @monthly`,
+		},
+		flag.String{
+			Name:        "mode",
+			Description: "How to run the command: exec in a started machine, or machine to run it in a one-shot machine",
+			Default:     modeExec,
+		},
+		flag.String{
+			Name:        "process-group",
+			Description: "The process group whose machines run the command",
+		},
+		flag.Duration{
+			Name:        "timeout",
+			Description: "How long the command may run",
+			Default:     10 * time.Minute,
+		},
+	)
+
+	return cmd
+}
+
+func newList() *cobra.Command {
+	const (
+		long  = `List the scheduled jobs of an organization and their next run.`
+		short = "List scheduled jobs"
+	)
+
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"ls"}
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newRemove() *cobra.Command {
+	const (
+		long  = `Remove a scheduled job of an organization.`
+		short = "Remove a scheduled job"
+		usage = "remove <name>"
+	)
+
+	cmd := command.New(usage, short, long, runRemove,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(cmd,
+		flag.Org(),
+	)
+
+	return cmd
+}
+
+func newRuns() *cobra.Command {
+	const (
+		long = `List the recent runs of the jobs of an organization, as recorded by the cron
+runner since it last started. The logs of the runner app keep older runs.`
+		short = "List recent job runs"
+	)
+
+	cmd := command.New("runs", short, long, runRuns,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "job",
+			Description: "Only list the runs of this job",
+		},
+	)
+
+	return cmd
+}
+
+func runAdd(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		args      = flag.Args(ctx)
+	)
+
+	job := Job{
+		Name:     args[0],
+		Schedule: flag.GetString(ctx, "schedule"),
+		App:      appName,
+		Command:  args[1:],
+		Mode:     flag.GetString(ctx, "mode"),
+		Group:    flag.GetString(ctx, "process-group"),
+		Timeout:  flag.GetDuration(ctx, "timeout"),
+	}
+
+	if job.Schedule == "" {
+		return errors.New("--schedule is required")
+	}
+	if _, err := parseSchedule(job.Schedule); err != nil {
+		return err
+	}
+	if job.Mode != modeExec && job.Mode != modeMachine {
+		return fmt.Errorf("invalid mode %q, expected %s or %s", job.Mode, modeExec, modeMachine)
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	org, err := orgs.OrgFromSlug(ctx, app.Organization.Slug)
+	if err != nil {
+		return err
+	}
+
+	r, err := ensureRunner(ctx, org)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := r.jobs()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range jobs {
+		if jobs[i].Name == job.Name {
+			jobs[i] = job
+			replaced = true
+		}
+	}
+	if !replaced {
+		jobs = append(jobs, job)
+	}
+
+	if err := r.setJobs(ctx, jobs); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Job %s scheduled at %q against %s\n", job.Name, job.Schedule, job.App)
+	return nil
+}
+
+func runList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := findRunner(ctx, org.Slug)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := r.jobs()
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, jobs)
+	}
+
+	if len(jobs) == 0 {
+		fmt.Fprintf(out, "%s has no scheduled jobs\n", org.Slug)
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([][]string, 0, len(jobs))
+	for _, job := range jobs {
+		next := ""
+		if s, err := parseSchedule(job.Schedule); err == nil {
+			if t := s.next(now); !t.IsZero() {
+				next = t.Format(time.RFC3339)
+			}
+		}
+		rows = append(rows, []string{job.Name, job.Schedule, job.App, job.Mode, strings.Join(job.Command, " "), next})
+	}
+
+	return render.Table(out, "", rows, "Name", "Schedule", "App", "Mode", "Command", "Next Run")
+}
+
+func runRemove(ctx context.Context) error {
+	var (
+		io   = iostreams.FromContext(ctx)
+		name = flag.FirstArg(ctx)
+	)
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := findRunner(ctx, org.Slug)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := r.jobs()
+	if err != nil {
+		return err
+	}
+
+	kept := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Name != name {
+			kept = append(kept, job)
+		}
+	}
+	if len(kept) == len(jobs) {
+		return fmt.Errorf("%s has no job named %s", org.Slug, name)
+	}
+
+	if err := r.setJobs(ctx, kept); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Job %s removed\n", name)
+	return nil
+}
+
+func runRuns(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := findRunner(ctx, org.Slug)
+	switch {
+	case err != nil:
+		return err
+	case r == nil || r.machine == nil:
+		return fmt.Errorf("%s has no scheduled jobs", org.Slug)
+	}
+
+	runs, err := fetchRuns(ctx, org.Slug, r.machine, flag.GetString(ctx, "job"))
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, runs)
+	}
+
+	rows := make([][]string, 0, len(runs))
+	for _, run := range runs {
+		status := "ok"
+		switch {
+		case run.Error != "":
+			status = run.Error
+		case run.ExitCode != 0:
+			status = "exit code " + strconv.Itoa(run.ExitCode)
+		}
+		rows = append(rows, []string{
+			run.Job,
+			run.StartedAt.Format(time.RFC3339),
+			run.FinishedAt.Sub(run.StartedAt).Round(time.Second).String(),
+			status,
+		})
+	}
+
+	return render.Table(out, "", rows, "Job", "Started", "Duration", "Status")
+}
+
+// fetchRuns asks the runner machine for its recent runs over the private
+// network of the organization.
+func fetchRuns(ctx context.Context, orgSlug string, machine *api.Machine, job string) ([]Run, error) {
+	agentclient, err := agent.Establish(ctx, client.FromContext(ctx).API())
+	if err != nil {
+		return nil, err
+	}
+
+	dialer, err := agentclient.ConnectToTunnel(ctx, orgSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+
+	endpoint := fmt.Sprintf("http://%s/runs", net.JoinHostPort(machine.PrivateIP, strconv.Itoa(runnerPort)))
+	if job != "" {
+		endpoint += "?" + url.Values{"job": {job}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed reaching the cron runner: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cron runner responded with %s", resp.Status)
+	}
+
+	var runs []Run
+	if err := json.NewDecoder(resp.Body).Decode(&runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
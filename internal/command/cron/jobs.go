@@ -0,0 +1,224 @@
+package cron
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	runnerAppSuffix   = "-cron-runner"
+	runnerMachineName = "cron-runner"
+	runnerJobsPath    = "/etc/fly-cron/jobs.json"
+	runnerPort        = 8080
+
+	modeExec    = "exec"
+	modeMachine = "machine"
+)
+
+// Job is a command run on a schedule against an app.
+type Job struct {
+	Name     string        `json:"name"`
+	Schedule string        `json:"schedule"`
+	App      string        `json:"app"`
+	Command  []string      `json:"command"`
+	Mode     string        `json:"mode"`
+	Group    string        `json:"process_group,omitempty"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// Run is a run of a job, as recorded by the runner.
+type Run struct {
+	Job        string    `json:"job"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	ExitCode   int       `json:"exit_code"`
+	Error      string    `json:"error,omitempty"`
+	Output     string    `json:"output,omitempty"`
+}
+
+func runnerAppName(orgSlug string) string {
+	return orgSlug + runnerAppSuffix
+}
+
+// runner is the cron runner app of an organization and its machine.
+type runner struct {
+	app         *api.AppCompact
+	flapsClient *flaps.Client
+	machine     *api.Machine
+}
+
+// findRunner returns the runner of the organization, or nil if it has none.
+func findRunner(ctx context.Context, orgSlug string) (*runner, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	app, err := apiClient.GetAppCompact(ctx, runnerAppName(orgSlug))
+	switch {
+	case api.IsNotFoundError(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &runner{app: app, flapsClient: flapsClient}
+	for _, m := range machines {
+		if m.Name == runnerMachineName {
+			r.machine = m
+		}
+	}
+	return r, nil
+}
+
+// ensureRunner returns the runner of the organization, creating its app with
+// an organization deploy token to run jobs with.
+func ensureRunner(ctx context.Context, org *api.Organization) (*runner, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	r, err := findRunner(ctx, org.Slug)
+	if err != nil || r != nil {
+		return r, err
+	}
+
+	name := runnerAppName(org.Slug)
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = org.ID
+	input.Name = name
+
+	if _, err := gql.CreateApp(ctx, apiClient.GenqClient, input); err != nil {
+		return nil, fmt.Errorf("failed creating cron runner app %s: %w", name, err)
+	}
+	fmt.Fprintf(io.ErrOut, "Created cron runner app %s\n", name)
+
+	token, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient, name, org.ID, "deploy_organization", &gql.LimitedAccessTokenOptions{}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the token of the cron runner: %w", err)
+	}
+
+	if _, err := apiClient.SetSecrets(ctx, name, map[string]string{
+		"FLY_API_TOKEN": token.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader,
+	}); err != nil {
+		return nil, fmt.Errorf("failed setting the token of the cron runner: %w", err)
+	}
+
+	return findRunner(ctx, org.Slug)
+}
+
+// jobs returns the jobs configured on the runner machine.
+func (r *runner) jobs() ([]Job, error) {
+	if r == nil || r.machine == nil || r.machine.Config == nil {
+		return nil, nil
+	}
+
+	for _, f := range r.machine.Config.Files {
+		if f.GuestPath != runnerJobsPath || f.RawValue == nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(*f.RawValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding jobs: %w", err)
+		}
+		return decodeJobs(data)
+	}
+	return nil, nil
+}
+
+func decodeJobs(data []byte) ([]Job, error) {
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed decoding jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// setJobs launches or updates the runner machine to run jobs.
+func (r *runner) setJobs(ctx context.Context, jobs []Job) error {
+	io := iostreams.FromContext(ctx)
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	config := &api.MachineConfig{
+		Init: api.MachineInit{
+			Entrypoint: []string{"flyctl"},
+			Cmd:        []string{"cron", "runner", "--jobs", runnerJobsPath},
+		},
+		Guest: &api.MachineGuest{
+			CPUKind:  "shared",
+			CPUs:     1,
+			MemoryMB: 256,
+		},
+		Files: []*api.File{{GuestPath: runnerJobsPath, RawValue: &encoded}},
+	}
+	infra.Configure(config, infra.ComponentFlyctl, r.machine)
+
+	ctx = flaps.NewContext(ctx, r.flapsClient)
+
+	if r.machine != nil {
+		machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, r.machine)
+		defer releaseLeaseFunc(ctx, machine)
+		if err != nil {
+			return err
+		}
+
+		return mach.Update(ctx, machine, &api.LaunchMachineInput{
+			Name:   runnerMachineName,
+			Region: machine.Region,
+			Config: config,
+		})
+	}
+
+	costDelta := func(prices budget.Prices) float64 { return prices.Machine(config.Guest) }
+	if err := budget.Check(ctx, r.app.Organization.Slug, r.app.Name, "Launching a cron runner machine", costDelta); err != nil {
+		return err
+	}
+
+	region, err := gql.GetNearestRegion(ctx, client.FromContext(ctx).API().GenqClient)
+	if err != nil {
+		return err
+	}
+
+	machine, err := r.flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Name:   runnerMachineName,
+		Region: region.NearestRegion.Code,
+		Config: config,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching cron runner machine: %w", err)
+	}
+	fmt.Fprintf(io.ErrOut, "Launched cron runner machine %s in %s\n", machine.ID, machine.Region)
+
+	r.machine = machine
+	return nil
+}
@@ -0,0 +1,308 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// maxRuns is the number of runs the runner remembers.
+const maxRuns = 200
+
+func newRunner() *cobra.Command {
+	const (
+		short = "Run the jobs of an organization"
+		long  = `Run the jobs of an organization, in the cron runner app's machine.`
+	)
+
+	cmd := command.New("runner", short, long, runRunner)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "jobs",
+			Description: "Path of the jobs file",
+			Default:     runnerJobsPath,
+		},
+	)
+
+	return cmd
+}
+
+func runRunner(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	data, err := os.ReadFile(flag.GetString(ctx, "jobs"))
+	if err != nil {
+		return err
+	}
+
+	jobs, err := decodeJobs(data)
+	if err != nil {
+		return err
+	}
+
+	s := &scheduler{
+		out:     io.Out,
+		running: map[string]bool{},
+		run:     runJob,
+	}
+	for _, job := range jobs {
+		sched, err := parseSchedule(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		s.jobs = append(s.jobs, scheduledJob{Job: job, schedule: sched})
+		fmt.Fprintf(io.Out, "Scheduled %s at %q against %s\n", job.Name, job.Schedule, job.App)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", runnerPort))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	for {
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+			s.tick(ctx, t)
+		}
+	}
+}
+
+type scheduledJob struct {
+	Job
+	schedule *schedule
+}
+
+// scheduler starts the jobs whose schedule matches each minute, and serves
+// their recent runs.
+type scheduler struct {
+	out  io.Writer
+	jobs []scheduledJob
+	run  func(context.Context, Job) Run
+
+	mu      sync.Mutex
+	running map[string]bool
+	runs    []Run
+}
+
+// tick starts the jobs scheduled in the minute of t that aren't still
+// running from a previous minute.
+func (s *scheduler) tick(ctx context.Context, t time.Time) {
+	for _, job := range s.jobs {
+		if !job.schedule.matches(t) {
+			continue
+		}
+
+		s.mu.Lock()
+		if s.running[job.Name] {
+			s.mu.Unlock()
+			fmt.Fprintf(s.out, "Skipped %s, its previous run is still running\n", job.Name)
+			continue
+		}
+		s.running[job.Name] = true
+		s.mu.Unlock()
+
+		go func(job Job) {
+			run := s.run(ctx, job)
+			s.record(run)
+		}(job.Job)
+	}
+}
+
+func (s *scheduler) record(run Run) {
+	status := "exit code " + strconv.Itoa(run.ExitCode)
+	if run.Error != "" {
+		status = run.Error
+	}
+	fmt.Fprintf(s.out, "Ran %s in %s: %s\n", run.Job, run.FinishedAt.Sub(run.StartedAt).Round(time.Second), status)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, run.Job)
+	s.runs = append(s.runs, run)
+	if len(s.runs) > maxRuns {
+		s.runs = s.runs[len(s.runs)-maxRuns:]
+	}
+}
+
+// ServeHTTP serves the recent runs, newest first, on GET /runs.
+func (s *scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Path != "/runs" {
+		http.NotFound(w, r)
+		return
+	}
+
+	job := r.URL.Query().Get("job")
+
+	s.mu.Lock()
+	runs := make([]Run, 0, len(s.runs))
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		if job == "" || s.runs[i].Job == job {
+			runs = append(runs, s.runs[i])
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// runJob runs job against its app and returns the run.
+func runJob(ctx context.Context, job Job) Run {
+	run := Run{Job: job.Name, StartedAt: time.Now().UTC()}
+
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	var err error
+	if job.Mode == modeMachine {
+		run.ExitCode, err = runJobMachine(ctx, job)
+	} else {
+		run.ExitCode, run.Output, err = execJob(ctx, job)
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	run.FinishedAt = time.Now().UTC()
+	return run
+}
+
+// jobMachines returns the machines of the app in the job's process group.
+func jobMachines(ctx context.Context, flapsClient *flaps.Client, job Job) ([]*api.Machine, error) {
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*api.Machine
+	for _, m := range machines {
+		if job.Group == "" || m.ProcessGroup() == job.Group {
+			matching = append(matching, m)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, fmt.Errorf("%s has no machines to run the job", job.App)
+	}
+	return matching, nil
+}
+
+// execJob executes the job's command in a started machine of its app.
+func execJob(ctx context.Context, job Job) (int, string, error) {
+	flapsClient, err := flaps.NewFromAppName(ctx, job.App)
+	if err != nil {
+		return 0, "", err
+	}
+
+	machines, err := jobMachines(ctx, flapsClient, job)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var machine *api.Machine
+	for _, m := range machines {
+		if m.State == api.MachineStateStarted {
+			machine = m
+			break
+		}
+	}
+	if machine == nil {
+		return 0, "", fmt.Errorf("%s has no started machines to run the job", job.App)
+	}
+
+	response, err := flapsClient.Exec(ctx, machine.ID, &api.MachineExecRequest{
+		Cmd:     strings.Join(job.Command, " "),
+		Timeout: int(job.Timeout.Seconds()),
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return int(response.ExitCode), response.StdOut + response.StdErr, nil
+}
+
+// runJobMachine runs the job's command in a one-shot machine with the
+// configuration of one of its app's machines.
+func runJobMachine(ctx context.Context, job Job) (int, error) {
+	flapsClient, err := flaps.NewFromAppName(ctx, job.App)
+	if err != nil {
+		return 0, err
+	}
+
+	machines, err := jobMachines(ctx, flapsClient, job)
+	if err != nil {
+		return 0, err
+	}
+	source := machines[0]
+
+	config := helpers.Clone(source.Config)
+	config.Init.Cmd = job.Command
+	config.Services = nil
+	config.Checks = nil
+	config.Mounts = nil
+	config.Standbys = nil
+	config.AutoDestroy = true
+	config.Restart = api.MachineRestart{Policy: api.MachineRestartPolicyNo}
+	config.DNS = &api.DNSConfig{SkipRegistration: true}
+	// deploys leave console machines alone
+	config.Metadata = map[string]string{
+		api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
+		api.MachineConfigMetadataKeyFlyProcessGroup:    api.MachineProcessGroupFlyAppConsole,
+	}
+
+	machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Name:   "cron-" + job.Name,
+		Region: source.Region,
+		Config: config,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed launching job machine: %w", err)
+	}
+
+	if err := flapsClient.Wait(ctx, machine, "stopped", job.Timeout); err != nil {
+		if kerr := flapsClient.Kill(context.Background(), machine.ID); kerr != nil {
+			return 0, fmt.Errorf("%w, and failed stopping machine %s: %v", err, machine.ID, kerr)
+		}
+		return 0, fmt.Errorf("machine %s didn't finish: %w", machine.ID, err)
+	}
+
+	machine, err = flapsClient.Get(ctx, machine.ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range machine.Events {
+		if event.Type == "exit" && event.Request != nil {
+			if code, err := event.Request.GetExitCode(); err == nil {
+				return code, nil
+			}
+		}
+	}
+	return 0, errors.New("machine exited without an exit code")
+}
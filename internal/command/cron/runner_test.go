@@ -0,0 +1,65 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerTick(t *testing.T) {
+	hourly, err := parseSchedule("@hourly")
+	require.NoError(t, err)
+	daily, err := parseSchedule("@daily")
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	started := make(chan string, 4)
+	s := &scheduler{
+		out:     io.Discard,
+		running: map[string]bool{},
+		jobs: []scheduledJob{
+			{Job: Job{Name: "hourly"}, schedule: hourly},
+			{Job: Job{Name: "daily"}, schedule: daily},
+		},
+		run: func(_ context.Context, job Job) Run {
+			started <- job.Name
+			<-release
+			return Run{Job: job.Name, ExitCode: 1}
+		},
+	}
+
+	at := time.Date(2023, 5, 10, 10, 0, 0, 0, time.UTC)
+	s.tick(context.Background(), at)
+	assert.Equal(t, "hourly", <-started)
+
+	// the previous run still runs
+	s.tick(context.Background(), at.Add(time.Hour))
+	close(release)
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.runs) == 1 && !s.running["hourly"]
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, started)
+
+	s.record(Run{Job: "daily"})
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/runs?job=hourly")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var runs []Run
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&runs))
+	assert.Equal(t, []Run{{Job: "hourly", ExitCode: 1}}, runs)
+}
@@ -0,0 +1,47 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "*/15 9-17 * * 1-5", "0 0 1,15 * *", "@daily", "5 4 * * 7"} {
+		_, err := parseSchedule(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := parseSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2023, 5, 10, 10, 7, 30, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"* * * * *":         time.Date(2023, 5, 10, 10, 8, 0, 0, time.UTC),
+		"*/15 * * * *":      time.Date(2023, 5, 10, 10, 15, 0, 0, time.UTC),
+		"0 3 * * *":         time.Date(2023, 5, 11, 3, 0, 0, 0, time.UTC),
+		"@hourly":           time.Date(2023, 5, 10, 11, 0, 0, 0, time.UTC),
+		"@weekly":           time.Date(2023, 5, 14, 0, 0, 0, 0, time.UTC),
+		"30 9 * * 1-5":      time.Date(2023, 5, 11, 9, 30, 0, 0, time.UTC),
+		"0 0 1 * 3":         time.Date(2023, 5, 17, 0, 0, 0, 0, time.UTC),
+		"0 12 29 2 *":       time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+		"10-20/5 10 10 5 *": time.Date(2023, 5, 10, 10, 10, 0, 0, time.UTC),
+	}
+	for expr, want := range cases {
+		s, err := parseSchedule(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, s.next(now), expr)
+	}
+
+	s, err := parseSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.next(now).IsZero())
+}
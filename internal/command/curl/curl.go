@@ -32,7 +32,14 @@ import (
 func New() (cmd *cobra.Command) {
 	const (
 		short = "Run a performance test against a URL"
-		long  = short + "\n"
+		long  = short + `
+
+Public URLs are requested from every region. URLs of private hosts, such as
+my-app.internal or my-app.flycast, are requested once through the WireGuard
+tunnel of their organization instead, printing the response and the time
+spent resolving the host, connecting through the tunnel and waiting for the
+response.
+`
 	)
 
 	cmd = command.New("curl <URL>", short, long, run,
@@ -41,7 +48,35 @@ func New() (cmd *cobra.Command) {
 
 	cmd.Args = cobra.ExactArgs(1)
 
-	flag.Add(cmd, flag.JSONOutput())
+	flag.Add(cmd,
+		flag.JSONOutput(),
+		flag.Org(),
+		flag.String{
+			Name:        "request",
+			Shorthand:   "X",
+			Description: "HTTP method of requests to private hosts",
+		},
+		flag.StringArray{
+			Name:        "header",
+			Shorthand:   "H",
+			Description: "Header of requests to private hosts, as NAME: VALUE. Can be specified multiple times.",
+		},
+		flag.String{
+			Name:        "data",
+			Shorthand:   "d",
+			Description: "Body of requests to private hosts, sent with POST unless --request is set",
+		},
+		flag.Bool{
+			Name:        "include",
+			Shorthand:   "i",
+			Description: "Print the response headers of private hosts",
+		},
+		flag.Bool{
+			Name:        "insecure",
+			Shorthand:   "k",
+			Description: "Skip verifying the TLS certificates of private hosts",
+		},
+	)
 	return
 }
 
@@ -51,6 +86,10 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("invalid URL specified: %w", err)
 	}
 
+	if isPrivateHost(url.Hostname()) {
+		return runPrivate(ctx, url)
+	}
+
 	regionCodes, err := fetchRegionCodes(ctx)
 	if err != nil {
 		return err
@@ -326,3 +365,45 @@ func renderJSONTimings(w io.Writer, timings []*timing) {
 
 	render.JSON(w, items)
 }
+
+// RegionTiming is the outcome of requesting a URL from the edge of a region.
+type RegionTiming struct {
+	Region string
+	Status int
+	TTFB   time.Duration
+	Total  time.Duration
+	Err    error
+}
+
+// TimeFromRegions requests u from the Fly edges of regions, or of every
+// region when regions is empty, and returns the outcomes by region.
+func TimeFromRegions(ctx context.Context, u *url.URL, regions []string) ([]RegionTiming, error) {
+	if len(regions) == 0 {
+		var err error
+		if regions, err = fetchRegionCodes(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	rws, err := prepareRequestWrappers(ctx, u, regions)
+	if err != nil {
+		return nil, err
+	}
+
+	timings := gatherTimings(ctx, rws)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]RegionTiming, 0, len(timings))
+	for _, t := range timings {
+		out = append(out, RegionTiming{
+			Region: t.region,
+			Status: t.HTTPCode,
+			TTFB:   time.Duration(t.TimeStartTransfer * float64(time.Second)),
+			Total:  time.Duration(t.TimeTotal * float64(time.Second)),
+			Err:    t.error,
+		})
+	}
+	return out, nil
+}
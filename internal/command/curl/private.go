@@ -0,0 +1,234 @@
+package curl
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/ip"
+)
+
+// isPrivateHost reports whether host is only reachable over the private
+// network of an organization.
+func isPrivateHost(host string) bool {
+	return strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".flycast") ||
+		(ip.IsV6(host) && strings.HasPrefix(strings.ToLower(host), "fdaa:"))
+}
+
+// hostApp returns the app named by a private host such as my-app.internal,
+// top1.nearest.of.my-app.internal or my-app.flycast.
+func hostApp(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return ""
+	}
+	return labels[len(labels)-2]
+}
+
+// privateTiming breaks down a request over the private network.
+type privateTiming struct {
+	Status         int                 `json:"status"`
+	Gateway        string              `json:"gateway_region"`
+	ProxyRegion    string              `json:"proxy_region,omitempty"`
+	RemoteAddr     string              `json:"remote_addr"`
+	Resolve        time.Duration       `json:"resolve"`
+	Connect        time.Duration       `json:"connect"`
+	TLS            time.Duration       `json:"tls,omitempty"`
+	FirstByte      time.Duration       `json:"ttfb"`
+	Total          time.Duration       `json:"total"`
+	ResponseHeader map[string][]string `json:"headers"`
+	Body           string              `json:"body"`
+}
+
+func runPrivate(ctx context.Context, target *url.URL) error {
+	var (
+		streams   = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		host      = target.Hostname()
+	)
+
+	orgSlug := flag.GetOrg(ctx)
+	if orgSlug == "" {
+		appName := hostApp(host)
+		if appName == "" {
+			return fmt.Errorf("can't tell the organization of %s, specify it with --org", host)
+		}
+		app, err := apiClient.GetAppCompact(ctx, appName)
+		if err != nil {
+			return fmt.Errorf("can't tell the organization of %s, specify it with --org: %w", host, err)
+		}
+		orgSlug = app.Organization.Slug
+	}
+
+	agentclient, err := agent.Establish(ctx, apiClient)
+	if err != nil {
+		return err
+	}
+
+	dialer, err := agentclient.ConnectToTunnel(ctx, orgSlug)
+	if err != nil {
+		return err
+	}
+
+	req, err := newPrivateRequest(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	t := &privateTiming{}
+	if state := dialer.State(); state != nil {
+		t.Gateway = state.Region
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+
+			start := time.Now()
+			if !ip.IsV6(host) {
+				if host, err = agentclient.Resolve(ctx, orgSlug, host); err != nil {
+					return nil, fmt.Errorf("failed resolving %s: %w", host, err)
+				}
+			}
+			t.Resolve = time.Since(start)
+
+			start = time.Now()
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(host, port))
+			t.Connect = time.Since(start)
+			t.RemoteAddr = net.JoinHostPort(host, port)
+			return conn, err
+		},
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: flag.GetBool(ctx, "insecure")}, // skipcq: GSC-G402
+		DisableKeepAlives: true,
+	}
+
+	var tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.TLS = time.Since(tlsStart) },
+	}
+
+	start := time.Now()
+	trace.GotFirstResponseByte = func() { t.FirstByte = time.Since(start) }
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	t.Total = time.Since(start)
+	t.Status = res.StatusCode
+	t.ResponseHeader = res.Header
+	t.ProxyRegion = proxyRegion(res.Header)
+	t.Body = string(body)
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(streams.Out, t)
+	}
+
+	if flag.GetBool(ctx, "include") {
+		fmt.Fprintf(streams.Out, "%s %s\n", res.Proto, res.Status)
+		keys := make([]string, 0, len(res.Header))
+		for k := range res.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range res.Header[k] {
+				fmt.Fprintf(streams.Out, "%s: %s\n", k, v)
+			}
+		}
+		fmt.Fprintln(streams.Out)
+	}
+	streams.Out.Write(body)
+
+	renderPrivateTiming(streams.ErrOut, t)
+	return nil
+}
+
+func newPrivateRequest(ctx context.Context, target *url.URL) (*http.Request, error) {
+	method := flag.GetString(ctx, "request")
+
+	var body io.Reader
+	if data := flag.GetString(ctx, "data"); data != "" {
+		body = strings.NewReader(data)
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range flag.GetStringArray(ctx, "header") {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q, expected NAME: VALUE", h)
+		}
+		req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return req, nil
+}
+
+// proxyRegion returns the region of the Fly proxy that served a response
+// through Flycast, which suffixes request IDs with it.
+func proxyRegion(header http.Header) string {
+	id := header.Get("Fly-Request-Id")
+	if i := strings.LastIndex(id, "-"); i >= 0 {
+		return id[i+1:]
+	}
+	return ""
+}
+
+func renderPrivateTiming(w io.Writer, t *privateTiming) {
+	hops := []string{"WireGuard gateway " + t.Gateway}
+	if t.ProxyRegion != "" {
+		hops = append(hops, "Flycast proxy "+t.ProxyRegion)
+	}
+	hops = append(hops, t.RemoteAddr)
+
+	fmt.Fprintf(w, "\nRoute: %s\n", strings.Join(hops, " -> "))
+
+	row := []string{
+		fmt.Sprint(t.Status),
+		formatDuration(t.Resolve),
+		formatDuration(t.Connect),
+		formatDuration(t.TLS),
+		formatDuration(t.FirstByte),
+		formatDuration(t.Total),
+	}
+	render.Table(w, "", [][]string{row}, "Status", "Resolve", "Connect", "TLS", "TTFB", "Total")
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
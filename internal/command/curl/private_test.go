@@ -0,0 +1,32 @@
+package curl
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPrivateHost(t *testing.T) {
+	assert.True(t, isPrivateHost("my-app.internal"))
+	assert.True(t, isPrivateHost("top1.nearest.of.my-app.internal"))
+	assert.True(t, isPrivateHost("my-app.flycast"))
+	assert.True(t, isPrivateHost("fdaa:0:1::3"))
+	assert.False(t, isPrivateHost("my-app.fly.dev"))
+	assert.False(t, isPrivateHost("2a09:8280:1::1"))
+}
+
+func TestHostApp(t *testing.T) {
+	assert.Equal(t, "my-app", hostApp("my-app.internal"))
+	assert.Equal(t, "my-app", hostApp("top1.nearest.of.my-app.internal"))
+	assert.Equal(t, "my-app", hostApp("my-app.flycast"))
+	assert.Equal(t, "", hostApp("localhost"))
+}
+
+func TestProxyRegion(t *testing.T) {
+	header := http.Header{}
+	assert.Equal(t, "", proxyRegion(header))
+
+	header.Set("Fly-Request-Id", "01H2ZKQ3X5-iad")
+	assert.Equal(t, "iad", proxyRegion(header))
+}
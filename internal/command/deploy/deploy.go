@@ -2,7 +2,10 @@ package deploy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -19,13 +22,17 @@ import (
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/notify"
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/internal/sentry"
 
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/cmdutil"
 	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/internal/watch"
+	"github.com/superfly/flyctl/terminal"
 )
 
 var CommonFlags = flag.Set{
@@ -41,7 +48,10 @@ var CommonFlags = flag.Set{
 	flag.Ignorefile(),
 	flag.ImageLabel(),
 	flag.BuildArg(),
+	flag.BuildArgFile(),
 	flag.BuildSecret(),
+	flag.BuildSecretFile(),
+	flag.RegistryAuth(),
 	flag.BuildTarget(),
 	flag.NoCache(),
 	flag.Nixpacks(),
@@ -97,6 +107,41 @@ var CommonFlags = flag.Set{
 		Description: "Perform smoke checks during deployment",
 		Default:     true,
 	},
+	flag.Bool{
+		Name:        "smoke-tests",
+		Description: "Run the smoke tests of fly.toml after deploying, see fly checks run",
+		Default:     true,
+	},
+	flag.StringArray{
+		Name:        "smoke-url",
+		Description: "URL, or path on the app's hostname, to request after deploying to verify the deployment. Can be specified multiple times",
+	},
+	flag.Int{
+		Name:        "smoke-status",
+		Description: "HTTP status the --smoke-url requests must respond with",
+		Default:     http.StatusOK,
+	},
+	flag.String{
+		Name:        "smoke-contains",
+		Description: "Text the responses of the --smoke-url requests must contain",
+	},
+	flag.Duration{
+		Name:        "smoke-max-latency",
+		Description: "Longest the --smoke-url requests may take to respond",
+	},
+	flag.Int{
+		Name:        "smoke-retries",
+		Description: "How many times to retry failing --smoke-url requests, 5 seconds apart",
+		Default:     3,
+	},
+	flag.Bool{
+		Name:        "auto-rollback",
+		Description: "Roll updated machines back to their previous release when the smoke tests or --smoke-url requests fail after deploying. Also enabled by auto_rollback in the [experimental] section of fly.toml",
+	},
+	flag.Bool{
+		Name:        "seed",
+		Description: "Run the [seed] section of fly.toml, as on the first deploy of the app",
+	},
 	flag.Bool{
 		Name:        "no-public-ips",
 		Description: "Do not allocate any new public IP addresses",
@@ -124,6 +169,54 @@ var CommonFlags = flag.Set{
 		),
 		Default: true,
 	},
+	flag.StopSignal(),
+	flag.StopGracePeriod(),
+	flag.PreStopCommand(),
+	flag.Bool{
+		Name:        "update-only-changed",
+		Description: "Skip existing machines whose image and configuration already match the deployment",
+	},
+	flag.Bool{
+		Name:        "resume",
+		Description: "Resume an interrupted deployment, skipping the machines it already updated and reusing its image unless --image is set",
+	},
+	flag.Bool{
+		Name:        "watch-logs",
+		Description: "Stream the logs of the app's machines while deploying, interleaved with the deployment progress",
+	},
+	flag.Bool{
+		Name:        "failure-snapshot",
+		Description: "Save the logs, check outputs and events of machines failing their checks to a deploy-failure-<timestamp> folder, or print them with --json",
+		Default:     true,
+	},
+	flag.Bool{
+		Name:        "sign",
+		Description: "Sign the deployed image with cosign, keylessly unless --signing-key is set",
+	},
+	flag.String{
+		Name:        "signing-key",
+		Description: "Cosign key reference, e.g. a key file or a KMS URI, to sign the image with when using --sign",
+	},
+	flag.String{
+		Name:        "fail-on",
+		Description: "Scan the image for vulnerabilities and refuse to deploy when some of at least this severity are found: negligible, low, medium, high or critical",
+	},
+	flag.String{
+		Name:        "scanner",
+		Description: "Vulnerability scanner to use with --fail-on, either grype or trivy. Defaults to the first one installed",
+	},
+	flag.String{
+		Name:        "secrets-lint",
+		Description: "Look for values that look like secrets in the build context and image layers, and either 'warn' or 'block' the deploy when some are found",
+	},
+	flag.String{
+		Name:        "require-signed-by",
+		Description: "Refuse to deploy unless the image has a valid cosign signature from this key reference or keyless signer identity",
+	},
+	flag.String{
+		Name:        "require-signed-by-issuer",
+		Description: "OIDC issuer of the certificate of the keyless signer set with --require-signed-by, e.g. https://token.actions.githubusercontent.com",
+	},
 }
 
 func New() (cmd *cobra.Command) {
@@ -147,6 +240,7 @@ func New() (cmd *cobra.Command) {
 		CommonFlags,
 		flag.App(),
 		flag.AppConfig(),
+		flag.JSONOutput(),
 	)
 
 	return
@@ -196,6 +290,18 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, forceYes
 		return fmt.Errorf("failed to fetch an image or build from source: %w", err)
 	}
 
+	if err := signAndVerifyImage(ctx, appConfig.AppName, img); err != nil {
+		return err
+	}
+
+	if err := scanImage(ctx, img); err != nil {
+		return err
+	}
+
+	if err := lintImage(ctx, appName, img); err != nil {
+		return err
+	}
+
 	if flag.GetBuildOnly(ctx) {
 		return nil
 	}
@@ -232,6 +338,47 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, forceYes
 	return err
 }
 
+// deploymentImageDigest returns the registry digest of img, resolving it when
+// the image was built, or an empty string when it can't be resolved.
+func deploymentImageDigest(ctx context.Context, appName string, img *imgsrc.DeploymentImage) string {
+	if img.Digest != "" {
+		return img.Digest
+	}
+
+	resolved, err := client.FromContext(ctx).API().ResolveImageForApp(ctx, appName, img.Tag)
+	if err != nil || resolved == nil {
+		terminal.Debugf("failed resolving the digest of %s: %v\n", img.Tag, err)
+		return ""
+	}
+	return resolved.Digest
+}
+
+// notifyDeploy posts the result of deploying img to the notification
+// channels of the app.
+func notifyDeploy(ctx context.Context, appCompact *api.AppCompact, img *imgsrc.DeploymentImage, err error) {
+	msg := notify.Message{
+		App:   appCompact.Name,
+		Event: notify.EventDeploySucceeded,
+		Title: fmt.Sprintf("Deployed %s", appCompact.Name),
+		Text:  "Image " + img.Tag,
+		URL:   fmt.Sprintf("https://fly.io/apps/%s/monitoring", appCompact.Name),
+	}
+	var rolledBack rolledBackError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return
+	case errors.As(err, &rolledBack):
+		msg.Event = notify.EventDeployRolledBack
+		msg.Title = fmt.Sprintf("Rolled back the deploy of %s", appCompact.Name)
+		msg.Text = fmt.Sprintf("Image %s failed verification: %v", img.Tag, rolledBack.error)
+	case err != nil:
+		msg.Event = notify.EventDeployFailed
+		msg.Title = fmt.Sprintf("Failed deploying %s", appCompact.Name)
+		msg.Text = fmt.Sprintf("Image %s: %v", img.Tag, err)
+	}
+	notify.Notify(ctx, appCompact.Organization.Slug, msg)
+}
+
 func determineRelCmdTimeout(timeout string) (time.Duration, error) {
 	if timeout == "none" {
 		return 0, nil
@@ -251,20 +398,39 @@ func deployToMachines(ctx context.Context, appConfig *appconfig.Config, appCompa
 	defer func() {
 		metrics.Status(ctx, "deploy_machines", err == nil)
 	}()
+	defer func() {
+		notifyDeploy(ctx, appCompact, img, err)
+	}()
 
 	releaseCmdTimeout, err := determineRelCmdTimeout(flag.GetString(ctx, "release-command-timeout"))
 	if err != nil {
 		return err
 	}
 
+	if err := checkPolicy(ctx, appCompact, appConfig); err != nil {
+		return err
+	}
+
+	smokeURLs, err := smokeURLsFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	var imgDigest string
+	if flag.GetBool(ctx, "update-only-changed") {
+		imgDigest = deploymentImageDigest(ctx, appCompact.Name, img)
+	}
+
 	md, err := NewMachineDeployment(ctx, MachineDeploymentArgs{
 		AppCompact:            appCompact,
 		DeploymentImage:       img.Tag,
+		DeploymentImageDigest: imgDigest,
 		Strategy:              flag.GetString(ctx, "strategy"),
 		BatchMachineWaits:     flag.GetBool(ctx, "strategy-batch"),
 		EnvFromFlags:          flag.GetStringArray(ctx, "env"),
 		PrimaryRegionFlag:     appConfig.PrimaryRegion,
 		SkipSmokeChecks:       flag.GetDetach(ctx) || !flag.GetBool(ctx, "smoke-checks"),
+		SkipSmokeTests:        flag.GetDetach(ctx) || !flag.GetBool(ctx, "smoke-tests"),
 		SkipHealthChecks:      flag.GetDetach(ctx),
 		WaitTimeout:           time.Duration(flag.GetInt(ctx, "wait-timeout")) * time.Second,
 		LeaseTimeout:          time.Duration(flag.GetInt(ctx, "lease-timeout")) * time.Second,
@@ -275,6 +441,14 @@ func deployToMachines(ctx context.Context, appConfig *appconfig.Config, appCompa
 		VMCPUKind:             flag.GetString(ctx, "vm-cpukind"),
 		IncreasedAvailability: flag.GetBool(ctx, "ha"),
 		AllocPublicIP:         !flag.GetBool(ctx, "no-public-ips"),
+		Drain:                 drainOptionsFromFlags(ctx),
+		UpdateOnlyChanged:     flag.GetBool(ctx, "update-only-changed"),
+		Resume:                flag.GetBool(ctx, "resume"),
+		WatchLogs:             flag.GetBool(ctx, "watch-logs") && !flag.GetDetach(ctx),
+		FailureSnapshots:      flag.GetBool(ctx, "failure-snapshot"),
+		SmokeURLs:             smokeURLs,
+		AutoRollback:          flag.GetBool(ctx, "auto-rollback") || (appConfig.Experimental != nil && appConfig.Experimental.AutoRollback),
+		Seed:                  flag.GetBool(ctx, "seed"),
 	})
 	if err != nil {
 		sentry.CaptureExceptionWithAppInfo(err, "deploy", appCompact)
@@ -288,6 +462,53 @@ func deployToMachines(ctx context.Context, appConfig *appconfig.Config, appCompa
 	return err
 }
 
+// smokeURLsFromFlags returns the smoke tests of the --smoke-url requests, or
+// none when detached.
+func smokeURLsFromFlags(ctx context.Context) ([]appconfig.SmokeTest, error) {
+	if flag.GetDetach(ctx) {
+		return nil, nil
+	}
+
+	status := flag.GetInt(ctx, "smoke-status")
+	if status < 100 || status > 599 {
+		return nil, flyerr.Validation(fmt.Errorf("invalid --smoke-status %d", status))
+	}
+
+	var tests []appconfig.SmokeTest
+	for _, u := range flag.GetStringArray(ctx, "smoke-url") {
+		if !strings.HasPrefix(u, "/") {
+			if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return nil, flyerr.Validation(fmt.Errorf("invalid --smoke-url %s, it must be an absolute http or https url or a path starting with '/'", u))
+			}
+		}
+
+		t := appconfig.SmokeTest{
+			Name:         u,
+			Status:       status,
+			BodyContains: flag.GetString(ctx, "smoke-contains"),
+			Retries:      flag.GetInt(ctx, "smoke-retries"),
+		}
+		if max := flag.GetDuration(ctx, "smoke-max-latency"); max > 0 {
+			t.MaxLatency = &api.Duration{Duration: max}
+		}
+		if strings.HasPrefix(u, "/") {
+			t.Path = u
+		} else {
+			t.URL = u
+		}
+		tests = append(tests, t)
+	}
+	return tests, nil
+}
+
+func drainOptionsFromFlags(ctx context.Context) machine.DrainOptions {
+	return machine.DrainOptions{
+		Signal:         flag.GetString(ctx, "stop-signal"),
+		GracePeriod:    flag.GetDuration(ctx, "stop-grace-period"),
+		PreStopCommand: flag.GetString(ctx, "pre-stop-command"),
+	}
+}
+
 func deployToNomad(ctx context.Context, appConfig *appconfig.Config, appCompact *api.AppCompact, img *imgsrc.DeploymentImage) (err error) {
 	apiClient := client.FromContext(ctx).API()
 
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/build/imgsrc"
@@ -97,14 +102,10 @@ func determineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgs
 		Buildpacks:      build.Buildpacks,
 	}
 
-	cliBuildSecrets, err := cmdutil.ParseKVStringsToMap(flag.GetStringArray(ctx, "build-secret"))
-	if err != nil {
+	if opts.BuildSecrets, err = buildSecrets(ctx); err != nil {
 		return
 	}
-
-	if cliBuildSecrets != nil {
-		opts.BuildSecrets = cliBuildSecrets
-	}
+	opts.RegistryAuths = flag.GetStringArray(ctx, "registry-auth")
 
 	var buildArgs map[string]string
 	if buildArgs, err = mergeBuildArgs(ctx, build.Args); err != nil {
@@ -112,6 +113,7 @@ func determineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgs
 	}
 
 	opts.BuildArgs = buildArgs
+	warnSecretBuildArgs(io, buildArgs)
 
 	if opts.DockerfilePath, err = resolveDockerfilePath(ctx, appConfig); err != nil {
 		return
@@ -127,6 +129,10 @@ func determineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgs
 		opts.Target = target
 	}
 
+	if err = lintBuildContext(ctx, opts); err != nil {
+		return
+	}
+
 	// finally, build the image
 	heartbeat, err := resolver.StartHeartbeat(ctx)
 	if err != nil {
@@ -195,7 +201,18 @@ func mergeBuildArgs(ctx context.Context, args map[string]string) (map[string]str
 		args = make(map[string]string)
 	}
 
-	// set additional Docker build args from the command line, overriding similar ones from the config
+	// set Docker build args from files, overriding similar ones from the config
+	for _, path := range flag.GetStringArray(ctx, "build-arg-file") {
+		fileBuildArgs, err := cmdutil.ParseKVFileToMap(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid build arg file: %w", err)
+		}
+		for k, v := range fileBuildArgs {
+			args[k] = v
+		}
+	}
+
+	// set additional Docker build args from the command line, overriding similar ones from files and the config
 	cliBuildArgs, err := cmdutil.ParseKVStringsToMap(flag.GetStringArray(ctx, "build-arg"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid build args: %w", err)
@@ -207,11 +224,73 @@ func mergeBuildArgs(ctx context.Context, args map[string]string) (map[string]str
 	return args, nil
 }
 
+// secretBuildArgPattern matches the names of build args which likely hold
+// credentials.
+var secretBuildArgPattern = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|CREDENTIALS|API_?KEY|PRIVATE_?KEY)`)
+
+// warnSecretBuildArgs warns of build args which look like credentials, as
+// build args are recorded in the history of images.
+func warnSecretBuildArgs(io *iostreams.IOStreams, args map[string]string) {
+	names := lo.Filter(lo.Keys(args), func(name string, _ int) bool {
+		return secretBuildArgPattern.MatchString(name)
+	})
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(io.ErrOut, "Warning: build arg %s looks like a secret, which the image history would reveal. Pass it with --build-secret and mount it with RUN --mount=type=secret,id=%s instead.\n", name, name)
+	}
+}
+
+// buildSecrets returns the BuildKit secrets of --build-secret and
+// --build-secret-file, by name.
+func buildSecrets(ctx context.Context) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	for _, arg := range flag.GetStringArray(ctx, "build-secret") {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			if value, ok = os.LookupEnv(name); !ok {
+				return nil, fmt.Errorf("invalid build secret '%s': the environment variable %s isn't set, pass it as NAME=VALUE", arg, name)
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid build secret '%s': must be in the format NAME=VALUE or NAME", arg)
+		}
+		secrets[name] = value
+	}
+
+	for _, arg := range flag.GetStringArray(ctx, "build-secret-file") {
+		name, path, ok := strings.Cut(arg, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid build secret file '%s': must be in the format NAME=PATH", arg)
+		}
+		value, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading build secret %s: %w", name, err)
+		}
+		secrets[name] = string(value)
+	}
+
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	return secrets, nil
+}
+
 func fetchImageRef(ctx context.Context, cfg *appconfig.Config) (ref string, err error) {
 	if ref = flag.GetString(ctx, "image"); ref != "" {
 		return
 	}
 
+	if flag.GetBool(ctx, "resume") && cfg != nil {
+		checkpoint, err := loadCheckpoint(ctx, cfg.AppName)
+		if err != nil {
+			return "", fmt.Errorf("failed loading the progress of the previous deployment: %w", err)
+		}
+		if checkpoint != nil {
+			return checkpoint.Image, nil
+		}
+	}
+
 	if cfg != nil && cfg.Build != nil {
 		if ref = cfg.Build.Image; ref != "" {
 			return
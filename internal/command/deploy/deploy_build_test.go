@@ -2,13 +2,17 @@ package deploy
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/state"
-	"os"
-	"path/filepath"
-	"testing"
+	"github.com/superfly/flyctl/iostreams"
 )
 
 func TestMultipleDockerfile(t *testing.T) {
@@ -32,3 +36,69 @@ func TestMultipleDockerfile(t *testing.T) {
 	)
 	assert.Error(t, err)
 }
+
+func flagContext(t *testing.T, args ...string) context.Context {
+	fs := pflag.NewFlagSet("deploy", pflag.ContinueOnError)
+	for _, name := range []string{"build-arg", "build-arg-file", "build-secret", "build-secret-file"} {
+		fs.StringArray(name, nil, "")
+	}
+	require.NoError(t, fs.Parse(args))
+	return flag.NewContext(context.Background(), fs)
+}
+
+func TestMergeBuildArgs(t *testing.T) {
+	dir := t.TempDir()
+	argFile := filepath.Join(dir, "build.env")
+	require.NoError(t, os.WriteFile(argFile, []byte("# versions\nexport NODE_VERSION=18\nRUBY_VERSION=\"3.2\"\n\nENV='staging'\n"), 0o600))
+
+	ctx := flagContext(t, "--build-arg-file", argFile, "--build-arg", "ENV=production")
+	args, err := mergeBuildArgs(ctx, map[string]string{"NODE_VERSION": "16", "DEBUG": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"NODE_VERSION": "18",
+		"RUBY_VERSION": "3.2",
+		"ENV":          "production",
+		"DEBUG":        "1",
+	}, args)
+
+	require.NoError(t, os.WriteFile(argFile, []byte("NODE_VERSION\n"), 0o600))
+	_, err = mergeBuildArgs(flagContext(t, "--build-arg-file", argFile), nil)
+	assert.ErrorContains(t, err, "build.env:1")
+}
+
+func TestBuildSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "npmrc")
+	require.NoError(t, os.WriteFile(secretFile, []byte("//registry.npmjs.org/:_authToken=abc\n"), 0o600))
+	t.Setenv("GITHUB_TOKEN", "ghp_123")
+
+	secrets, err := buildSecrets(flagContext(t,
+		"--build-secret", "API_KEY=key",
+		"--build-secret", "GITHUB_TOKEN",
+		"--build-secret-file", "npmrc="+secretFile,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"API_KEY":      "key",
+		"GITHUB_TOKEN": "ghp_123",
+		"npmrc":        "//registry.npmjs.org/:_authToken=abc\n",
+	}, secrets)
+
+	secrets, err = buildSecrets(flagContext(t))
+	require.NoError(t, err)
+	assert.Nil(t, secrets)
+
+	_, err = buildSecrets(flagContext(t, "--build-secret", "FLYCTL_TEST_UNSET_SECRET"))
+	assert.ErrorContains(t, err, "isn't set")
+
+	_, err = buildSecrets(flagContext(t, "--build-secret-file", "npmrc"))
+	assert.ErrorContains(t, err, "NAME=PATH")
+}
+
+func TestWarnSecretBuildArgs(t *testing.T) {
+	ios, _, _, errOut := iostreams.Test()
+	warnSecretBuildArgs(ios, map[string]string{"NODE_VERSION": "18", "NPM_TOKEN": "abc", "db_password": "x"})
+	assert.Contains(t, errOut.String(), "build arg NPM_TOKEN looks like a secret")
+	assert.Contains(t, errOut.String(), "build arg db_password looks like a secret")
+	assert.NotContains(t, errOut.String(), "NODE_VERSION")
+}
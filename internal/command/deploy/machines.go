@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Khan/genqlient/graphql"
@@ -35,11 +36,13 @@ type MachineDeployment interface {
 type MachineDeploymentArgs struct {
 	AppCompact            *api.AppCompact
 	DeploymentImage       string
+	DeploymentImageDigest string
 	Strategy              string
 	BatchMachineWaits     bool
 	EnvFromFlags          []string
 	PrimaryRegionFlag     string
 	SkipSmokeChecks       bool
+	SkipSmokeTests        bool
 	SkipHealthChecks      bool
 	RestartOnly           bool
 	WaitTimeout           time.Duration
@@ -51,6 +54,15 @@ type MachineDeploymentArgs struct {
 	VMCPUKind             string
 	IncreasedAvailability bool
 	AllocPublicIP         bool
+	Drain                 machine.DrainOptions
+	UpdateOnlyChanged     bool
+	SkipReleaseCommand    bool
+	Resume                bool
+	WatchLogs             bool
+	FailureSnapshots      bool
+	SmokeURLs             []appconfig.SmokeTest
+	AutoRollback          bool
+	Seed                  bool
 }
 
 type machineDeployment struct {
@@ -62,6 +74,7 @@ type machineDeployment struct {
 	app                   *api.AppCompact
 	appConfig             *appconfig.Config
 	img                   string
+	imgDigest             string
 	machineSet            machine.MachineSet
 	releaseCommandMachine machine.MachineSet
 	volumes               map[string][]api.Volume
@@ -70,6 +83,7 @@ type machineDeployment struct {
 	releaseId             string
 	releaseVersion        int
 	skipSmokeChecks       bool
+	skipSmokeTests        bool
 	skipHealthChecks      bool
 	restartOnly           bool
 	waitTimeout           time.Duration
@@ -80,6 +94,19 @@ type machineDeployment struct {
 	machineGuest          *api.MachineGuest
 	increasedAvailability bool
 	listenAddressChecked  map[string]struct{}
+	drain                 machine.DrainOptions
+	updateOnlyChanged     bool
+	skipReleaseCommand    bool
+	resume                bool
+	checkpoint            *deployCheckpoint
+	watchLogs             bool
+	failureSnapshots      bool
+	failureDirOnce        sync.Once
+	failureDir            string
+	autostopPinned        map[string]bool
+	smokeURLs             []appconfig.SmokeTest
+	autoRollback          bool
+	seed                  bool
 }
 
 func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (MachineDeployment, error) {
@@ -138,8 +165,10 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (Mach
 		app:                   args.AppCompact,
 		appConfig:             appConfig,
 		img:                   args.DeploymentImage,
+		imgDigest:             args.DeploymentImageDigest,
 		batchMachineWaits:     args.BatchMachineWaits,
 		skipSmokeChecks:       args.SkipSmokeChecks,
+		skipSmokeTests:        args.SkipSmokeTests,
 		skipHealthChecks:      args.SkipHealthChecks,
 		restartOnly:           args.RestartOnly,
 		waitTimeout:           waitTimeout,
@@ -148,6 +177,15 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (Mach
 		releaseCmdTimeout:     args.ReleaseCmdTimeout,
 		increasedAvailability: args.IncreasedAvailability,
 		listenAddressChecked:  make(map[string]struct{}),
+		drain:                 args.Drain,
+		updateOnlyChanged:     args.UpdateOnlyChanged,
+		skipReleaseCommand:    args.SkipReleaseCommand,
+		resume:                args.Resume,
+		watchLogs:             args.WatchLogs,
+		failureSnapshots:      args.FailureSnapshots,
+		smokeURLs:             args.SmokeURLs,
+		seed:                  args.Seed,
+		autoRollback:          args.AutoRollback,
 	}
 	if err := md.setStrategy(); err != nil {
 		return nil, err
@@ -192,11 +230,27 @@ func (md *machineDeployment) setFirstDeploy(ctx context.Context) error {
 	return nil
 }
 
+// checkTrafficProxy refuses to deploy while traffic is mirrored or split,
+// since the traffic proxy forwards requests to the current machines and
+// deploys would update the proxy and candidate machines with the app's config.
+func checkTrafficProxy(machines []*api.Machine) error {
+	proxy, ok := lo.Find(machines, func(m *api.Machine) bool {
+		return m.IsFlyAppsTrafficProxy() || m.IsFlyAppsMirrorCandidate()
+	})
+	if !ok {
+		return nil
+	}
+	return fmt.Errorf("traffic is being mirrored or split through machine %s, run `fly services mirror stop` or `fly services weights reset` before deploying", proxy.ID)
+}
+
 func (md *machineDeployment) setMachinesForDeployment(ctx context.Context) error {
 	machines, releaseCmdMachine, err := md.flapsClient.ListFlyAppsMachines(ctx)
 	if err != nil {
 		return err
 	}
+	if err := checkTrafficProxy(machines); err != nil {
+		return err
+	}
 
 	// migrate non-platform machines into fly platform
 	if len(machines) == 0 {
@@ -227,6 +281,7 @@ func (md *machineDeployment) setMachinesForDeployment(ctx context.Context) error
 	}
 
 	md.machineSet = machine.NewMachineSet(md.flapsClient, md.io, machines)
+	md.autostopPinned = md.appConfig.AutostopPinned(machines)
 	var releaseCmdSet []*api.Machine
 	if releaseCmdMachine != nil {
 		releaseCmdSet = []*api.Machine{releaseCmdMachine}
@@ -411,7 +466,7 @@ func (md *machineDeployment) setMachineGuest(vmSize string, vmCPUKind string, vm
 	if vmMem > 0 {
 		md.machineGuest.MemoryMB = vmMem
 	}
-	return nil
+	return md.machineGuest.Validate()
 }
 
 func (md *machineDeployment) setStrategy() error {
@@ -471,7 +526,8 @@ func (md *machineDeployment) updateReleaseInBackend(ctx context.Context, status
 }
 
 func (md *machineDeployment) logClearLinesAbove(count int) {
-	if md.io.IsInteractive() {
+	// Log lines may have been printed since, don't erase them.
+	if md.io.IsInteractive() && !md.watchLogs {
 		builder := aec.EmptyBuilder
 		str := builder.Up(uint(count)).EraseLine(aec.EraseModes.All).ANSI
 		fmt.Fprint(md.io.ErrOut, str.String())
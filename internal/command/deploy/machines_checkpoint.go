@@ -0,0 +1,93 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/superfly/flyctl/internal/state"
+	"golang.org/x/exp/slices"
+)
+
+// checkpointDirectory is the directory of the configuration directory holding
+// the progress of deployments, one file per app.
+const checkpointDirectory = "deploys"
+
+// deployCheckpoint records which machines a deployment already updated, for
+// deploy --resume to pick up where an interrupted one left off.
+type deployCheckpoint struct {
+	App       string    `json:"app"`
+	Image     string    `json:"image"`
+	Machines  []string  `json:"machines"`
+	StartedAt time.Time `json:"started_at"`
+
+	path string
+}
+
+func checkpointPath(ctx context.Context, appName string) string {
+	return filepath.Join(state.ConfigDirectory(ctx), checkpointDirectory, appName+".json")
+}
+
+// loadCheckpoint returns the checkpoint of the last interrupted deployment of
+// appName, or nil when there's none.
+func loadCheckpoint(ctx context.Context, appName string) (*deployCheckpoint, error) {
+	p := checkpointPath(ctx, appName)
+
+	data, err := os.ReadFile(p)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	c := &deployCheckpoint{path: p}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed decoding %s: %w", p, err)
+	}
+	return c, nil
+}
+
+// newCheckpoint returns an empty checkpoint for deploying img to appName.
+func newCheckpoint(ctx context.Context, appName, img string) *deployCheckpoint {
+	return &deployCheckpoint{
+		App:       appName,
+		Image:     img,
+		StartedAt: time.Now().UTC(),
+		path:      checkpointPath(ctx, appName),
+	}
+}
+
+// Done reports whether the machine with id was already updated.
+func (c *deployCheckpoint) Done(id string) bool {
+	return slices.Contains(c.Machines, id)
+}
+
+// Record records the machine with id as updated.
+func (c *deployCheckpoint) Record(id string) error {
+	if c.Done(id) {
+		return nil
+	}
+	c.Machines = append(c.Machines, id)
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o600)
+}
+
+// Clear removes the checkpoint, once the deployment completed.
+func (c *deployCheckpoint) Clear() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/state"
+)
+
+func Test_deployCheckpoint(t *testing.T) {
+	ctx := state.WithConfigDirectory(context.Background(), t.TempDir())
+
+	c, err := loadCheckpoint(ctx, "my-app")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	c = newCheckpoint(ctx, "my-app", "registry.fly.io/my-app:deployment-1")
+	require.NoError(t, c.Record("m1"))
+	require.NoError(t, c.Record("m2"))
+	require.NoError(t, c.Record("m1"))
+
+	loaded, err := loadCheckpoint(ctx, "my-app")
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "registry.fly.io/my-app:deployment-1", loaded.Image)
+	assert.Equal(t, []string{"m1", "m2"}, loaded.Machines)
+	assert.True(t, loaded.Done("m2"))
+	assert.False(t, loaded.Done("m3"))
+
+	require.NoError(t, loaded.Clear())
+	c, err = loadCheckpoint(ctx, "my-app")
+	require.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func Test_isTransientError(t *testing.T) {
+	flapsErr := func(status int) error {
+		return fmt.Errorf("failed to update VM: %w", &flaps.FlapsError{OriginalError: errors.New("boom"), ResponseStatusCode: status})
+	}
+
+	assert.True(t, isTransientError(flapsErr(http.StatusServiceUnavailable)))
+	assert.True(t, isTransientError(flapsErr(http.StatusTooManyRequests)))
+	assert.True(t, isTransientError(fmt.Errorf("request failed: %w", syscall.ECONNREFUSED)))
+	assert.False(t, isTransientError(fmt.Errorf("request failed: %w", syscall.ECONNRESET)), "the request may have been applied")
+	assert.False(t, isTransientError(flapsErr(http.StatusBadGateway)))
+	assert.False(t, isTransientError(flapsErr(http.StatusGatewayTimeout)))
+	assert.False(t, isTransientError(flapsErr(http.StatusBadRequest)))
+	assert.False(t, isTransientError(flapsErr(http.StatusConflict)))
+	assert.False(t, isTransientError(context.Canceled))
+	assert.False(t, isTransientError(errors.New("invalid config")))
+
+	assert.True(t, isTransientError(&net.OpError{Op: "dial", Err: errors.New("no route to host")}))
+	assert.False(t, isTransientError(&net.OpError{Op: "read", Err: errors.New("broken pipe")}), "the request may have been applied")
+}
@@ -0,0 +1,52 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/machine"
+)
+
+// machineConfigHash returns a digest of the config a machine runs with,
+// leaving out the image, compared by digest instead, and the release metadata
+// that changes on every deploy.
+func machineConfigHash(config *api.MachineConfig) (string, error) {
+	config = machine.CloneConfig(config)
+	config.Image = ""
+	delete(config.Metadata, api.MachineConfigMetadataKeyFlyReleaseId)
+	delete(config.Metadata, api.MachineConfigMetadataKeyFlyReleaseVersion)
+
+	// json.Marshal sorts map keys so equal configs produce equal digests
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// machineUpToDate reports whether updating a machine with entry would leave
+// its image and config unchanged. The image the machine runs is compared with
+// imgDigest, the digest of the deployment image, so rebuilds of the same image
+// under a new tag are detected while pushes of a new image under the same tag
+// aren't skipped.
+func machineUpToDate(entry *machineUpdateEntry, imgDigest string) (bool, error) {
+	if entry.launchInput.RequiresReplacement {
+		return false, nil
+	}
+	if imgDigest == "" || entry.leasableMachine.Machine().ImageRef.Digest != imgDigest {
+		return false, nil
+	}
+
+	currentHash, err := machineConfigHash(entry.leasableMachine.Machine().Config)
+	if err != nil {
+		return false, err
+	}
+	targetHash, err := machineConfigHash(entry.launchInput.Config)
+	if err != nil {
+		return false, err
+	}
+	return currentHash == targetHash, nil
+}
@@ -35,6 +35,11 @@ type ProcessGroupsDiff struct {
 func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 	ctx = flaps.NewContext(ctx, md.flapsClient)
 
+	if md.watchLogs {
+		stop := md.streamLogs(ctx)
+		defer stop()
+	}
+
 	if err := md.updateReleaseInBackend(ctx, "running"); err != nil {
 		return fmt.Errorf("failed to set release status to 'running': %w", err)
 	}
@@ -107,14 +112,21 @@ func (md *machineDeployment) inferCanaryGuest(name string) *api.MachineGuest {
 
 // deployMachinesApp executes the following flow:
 //   - Run release command
+//   - Seed the data of the app on its first deploy
 //   - Remove spare machines from removed groups
 //   - Launch new machines on new groups
 //   - Update existing machines
+//   - Run smoke tests and --smoke-url requests, rolling back on failures
+//     with auto-rollback
 func (md *machineDeployment) deployMachinesApp(ctx context.Context) error {
 	if err := md.runReleaseCommand(ctx); err != nil {
 		return fmt.Errorf("release command failed - aborting deployment. %w", err)
 	}
 
+	if err := md.runSeed(ctx); err != nil {
+		return fmt.Errorf("%w - aborting deployment", err)
+	}
+
 	if err := md.machineSet.AcquireLeases(ctx, md.leaseTimeout); err != nil {
 		return err
 	}
@@ -141,12 +153,20 @@ func (md *machineDeployment) deployMachinesApp(ctx context.Context) error {
 			canaryMachines = append(canaryMachines, machine)
 		}
 
-		fmt.Fprintf(md.io.Out, "Canary machines successfully created and healthy, destroying before continuing\n")
+		testErr := md.runCanarySmokeTests(ctx, canaryMachines)
+		if testErr == nil {
+			fmt.Fprintf(md.io.Out, "Canary machines successfully created and healthy, destroying before continuing\n")
+		} else {
+			fmt.Fprintf(md.io.Out, "Smoke tests failed against canary machines, destroying them\n")
+		}
 		for _, mach := range canaryMachines {
 			if err := machcmd.Destroy(ctx, md.app, mach.Machine(), true); err != nil {
 				return err
 			}
 		}
+		if testErr != nil {
+			return fmt.Errorf("canary smoke tests failed - aborting deployment. %w", testErr)
+		}
 	}
 
 	// Destroy machines that don't fit the current process groups
@@ -230,7 +250,29 @@ func (md *machineDeployment) deployMachinesApp(ctx context.Context) error {
 		machineUpdateEntries = append(machineUpdateEntries, &machineUpdateEntry{leasableMachine: lm, launchInput: li})
 	}
 
-	return md.updateExistingMachines(ctx, machineUpdateEntries)
+	if md.updateOnlyChanged {
+		var err error
+		machineUpdateEntries, err = md.skipUnchangedMachines(machineUpdateEntries)
+		if err != nil {
+			return err
+		}
+	}
+
+	machineUpdateEntries, err := md.resumeFromCheckpoint(ctx, machineUpdateEntries)
+	if err != nil {
+		return err
+	}
+
+	previous := md.previousLaunchInputs(machineUpdateEntries)
+	if err := md.updateExistingMachines(ctx, machineUpdateEntries); err != nil {
+		return err
+	}
+
+	if err := md.checkpoint.Clear(); err != nil {
+		terminal.Warnf("failed to clear the progress of the deployment: %v\n", err)
+	}
+
+	return md.verifyDeployment(ctx, machineUpdateEntries, previous)
 }
 
 type machineUpdateEntry struct {
@@ -238,6 +280,77 @@ type machineUpdateEntry struct {
 	launchInput     *api.LaunchMachineInput
 }
 
+// skipUnchangedMachines drops the entries of machines already running the
+// target image and config, reporting how many were skipped.
+func (md *machineDeployment) skipUnchangedMachines(entries []*machineUpdateEntry) ([]*machineUpdateEntry, error) {
+	if md.imgDigest == "" {
+		fmt.Fprintf(md.io.ErrOut, "Updating all machines, the digest of %s couldn't be resolved to compare their images\n", md.img)
+		return entries, nil
+	}
+
+	var changed []*machineUpdateEntry
+	for _, e := range entries {
+		upToDate, err := machineUpToDate(e, md.imgDigest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare configuration of machine %s: %w", e.leasableMachine.FormattedMachineId(), err)
+		}
+		if !upToDate {
+			changed = append(changed, e)
+		}
+	}
+
+	if skipped := len(entries) - len(changed); skipped > 0 {
+		fmt.Fprintf(md.io.Out, "Skipping %d of %d machines whose image and configuration are unchanged\n", skipped, len(entries))
+	}
+	return changed, nil
+}
+
+// resumeFromCheckpoint starts recording which machines the deployment
+// updates. With --resume, it drops the entries of machines an interrupted
+// deployment of the same image already updated.
+func (md *machineDeployment) resumeFromCheckpoint(ctx context.Context, entries []*machineUpdateEntry) ([]*machineUpdateEntry, error) {
+	previous, err := loadCheckpoint(ctx, md.app.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading the progress of the previous deployment: %w", err)
+	}
+	md.checkpoint = newCheckpoint(ctx, md.app.Name, md.img)
+
+	switch {
+	case previous == nil:
+		if md.resume {
+			fmt.Fprintf(md.io.ErrOut, "No interrupted deployment of %s to resume, updating all machines\n", md.app.Name)
+		}
+		return entries, nil
+	case !md.resume:
+		fmt.Fprintf(md.io.ErrOut, "Not resuming the interrupted deployment of %s, which updated %d machines to %s\n",
+			md.app.Name, len(previous.Machines), previous.Image)
+		return entries, nil
+	case previous.Image != md.img:
+		fmt.Fprintf(md.io.ErrOut, "The interrupted deployment of %s was of image %s, not %s; updating all machines\n",
+			md.app.Name, previous.Image, md.img)
+		return entries, nil
+	}
+
+	md.checkpoint = previous
+	remaining := lo.Filter(entries, func(e *machineUpdateEntry, _ int) bool {
+		return !previous.Done(e.leasableMachine.Machine().ID)
+	})
+	fmt.Fprintf(md.io.Out, "Resuming the deployment started at %s, skipping %d of %d machines already updated\n",
+		previous.StartedAt.Local().Format(time.RFC822), len(entries)-len(remaining), len(entries))
+	return remaining, nil
+}
+
+// recordProgress records lm as updated, for an interrupted deployment to be
+// resumed.
+func (md *machineDeployment) recordProgress(lm machine.LeasableMachine) {
+	if md.checkpoint == nil {
+		return
+	}
+	if err := md.checkpoint.Record(lm.Machine().ID); err != nil {
+		terminal.Warnf("failed to record the progress of the deployment: %v\n", err)
+	}
+}
+
 func formatIndex(n, total int) string {
 	pad := 0
 	for i := total; i != 0; i /= 10 {
@@ -314,12 +427,14 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 		}
 
 		if err := md.doSmokeChecks(ctx, lm, indexStr); err != nil {
+			md.snapshotFailure(ctx, lm, err)
 			return err
 		}
 
 		if !md.skipHealthChecks {
 			if err := lm.WaitForHealthchecksToPass(ctx, md.waitTimeout, indexStr); err != nil {
 				md.warnAboutIncorrectListenAddress(ctx, lm)
+				md.snapshotFailure(ctx, lm, err)
 				err = suggestChangeWaitTimeout(err, "wait-timeout")
 				return err
 			}
@@ -344,6 +459,20 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 		launchInput := e.launchInput
 		indexStr := formatIndex(i, len(updateEntries))
 
+		if err := machine.RunPreStopHook(ctx, lm.Machine(), md.drain); err != nil {
+			if md.strategy != "immediate" {
+				return err
+			}
+			fmt.Fprintf(md.io.ErrOut, "Continuing after error: %s\n", err)
+		}
+
+		if err := md.drain.Stop(ctx, lm, md.waitTimeout, indexStr); err != nil {
+			if md.strategy != "immediate" {
+				return err
+			}
+			fmt.Fprintf(md.io.ErrOut, "Continuing after error: %s\n", err)
+		}
+
 		if launchInput.RequiresReplacement {
 			// If machine requires replacement, destroy old machine and launch a new one
 			// This can be the case for machines that changes its volumes.
@@ -374,7 +503,10 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 
 		} else {
 			fmt.Fprintf(md.io.ErrOut, "  %s Updating %s\n", indexStr, md.colorize.Bold(lm.FormattedMachineId()))
-			if err := lm.Update(ctx, *launchInput); err != nil {
+			err := md.retryTransient(ctx, "updating "+lm.FormattedMachineId(), func() error {
+				return lm.Update(ctx, *launchInput)
+			})
+			if err != nil {
 				if md.strategy != "immediate" {
 					return err
 				}
@@ -390,6 +522,7 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 				md.colorize.Bold(lm.FormattedMachineId()),
 				md.colorize.Green("success"),
 			)
+			md.recordProgress(lm)
 			continue
 		}
 
@@ -400,12 +533,14 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 				if err := waitForMachine(job.lm, true, job.indexStr); err != nil {
 					return err
 				}
+				md.recordProgress(job.lm)
 			}
 			md.logClearLinesAbove(1)
 		} else {
 			if err := waitForMachine(lm, false, indexStr); err != nil {
 				return err
 			}
+			md.recordProgress(lm)
 		}
 	}
 
@@ -501,6 +636,7 @@ func (md *machineDeployment) spawnMachineInGroup(ctx context.Context, groupName
 	}
 
 	if err := md.doSmokeChecks(ctx, lm, indexStr); err != nil {
+		md.snapshotFailure(ctx, lm, err)
 		return nil, err
 	}
 
@@ -508,6 +644,7 @@ func (md *machineDeployment) spawnMachineInGroup(ctx context.Context, groupName
 	if !md.skipHealthChecks {
 		if err := lm.WaitForHealthchecksToPass(ctx, md.waitTimeout, indexStr); err != nil {
 			md.warnAboutIncorrectListenAddress(ctx, lm)
+			md.snapshotFailure(ctx, lm, err)
 			err = suggestChangeWaitTimeout(err, "wait-timeout")
 			return nil, err
 		}
@@ -0,0 +1,134 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// failureSnapshot is the evidence captured when a machine fails its checks
+// during a deploy, before a rollback or a later deploy replaces it.
+type failureSnapshot struct {
+	App        string                    `json:"app"`
+	MachineID  string                    `json:"machine_id"`
+	Region     string                    `json:"region"`
+	Image      string                    `json:"image"`
+	State      string                    `json:"state"`
+	Reason     string                    `json:"reason"`
+	CapturedAt time.Time                 `json:"captured_at"`
+	Checks     []*api.MachineCheckStatus `json:"checks"`
+	Events     []*api.MachineEvent       `json:"events"`
+	Logs       []api.LogEntry            `json:"logs"`
+}
+
+// snapshotFailure captures the recent logs, check outputs and events of the
+// machine of lm, which failed with cause, into the deploy-failure-<ts>
+// folder, or prints them as JSON with --json. Failing to capture them is
+// only reported, the deploy fails with cause anyway.
+func (md *machineDeployment) snapshotFailure(ctx context.Context, lm machine.LeasableMachine, cause error) {
+	if !md.failureSnapshots {
+		return
+	}
+
+	snapshot, err := md.captureFailure(ctx, lm, cause)
+	if err != nil {
+		fmt.Fprintf(md.io.ErrOut, "Failed capturing a snapshot of machine %s: %v\n", lm.Machine().ID, err)
+		return
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(md.io.Out, snapshot); err != nil {
+			fmt.Fprintf(md.io.ErrOut, "Failed printing the snapshot of machine %s: %v\n", snapshot.MachineID, err)
+		}
+		return
+	}
+
+	dir, err := md.writeFailureSnapshot(snapshot)
+	if err != nil {
+		fmt.Fprintf(md.io.ErrOut, "Failed saving the snapshot of machine %s: %v\n", snapshot.MachineID, err)
+		return
+	}
+	fmt.Fprintf(md.io.ErrOut, "Saved the logs, checks and events of machine %s to %s\n", md.colorize.Bold(snapshot.MachineID), dir)
+}
+
+func (md *machineDeployment) captureFailure(ctx context.Context, lm machine.LeasableMachine, cause error) (*failureSnapshot, error) {
+	m, err := md.flapsClient.Get(ctx, lm.Machine().ID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &failureSnapshot{
+		App:        md.app.Name,
+		MachineID:  m.ID,
+		Region:     m.Region,
+		Image:      m.FullImageRef(),
+		State:      m.State,
+		Reason:     cause.Error(),
+		CapturedAt: time.Now().UTC(),
+		Checks:     m.Checks,
+		Events:     m.Events,
+	}
+
+	logs, _, err := md.apiClient.GetAppLogs(ctx, md.app.Name, "", m.Region, m.ID)
+	switch {
+	case api.IsNotAuthenticatedError(err):
+		// Deploy tokens can't read logs, keep the rest of the evidence.
+	case err != nil:
+		return nil, fmt.Errorf("failed retrieving logs: %w", err)
+	default:
+		snapshot.Logs = logs
+	}
+
+	return snapshot, nil
+}
+
+// writeFailureSnapshot writes snapshot under the deploy-failure-<ts> folder of
+// this deploy, one folder per machine, and returns the folder it wrote to.
+func (md *machineDeployment) writeFailureSnapshot(snapshot *failureSnapshot) (string, error) {
+	md.failureDirOnce.Do(func() {
+		md.failureDir = "deploy-failure-" + snapshot.CapturedAt.Format("20060102T150405Z")
+	})
+
+	dir := filepath.Join(md.failureDir, snapshot.MachineID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	var logs, checks strings.Builder
+	for _, l := range snapshot.Logs {
+		fmt.Fprintf(&logs, "%s %s [%s] %s\n", l.Timestamp, l.Region, l.Level, l.Message)
+	}
+	for _, c := range snapshot.Checks {
+		var updated string
+		if c.UpdatedAt != nil {
+			updated = c.UpdatedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&checks, "%s: %s (%s)\n%s\n\n", c.Name, c.Status, updated, c.Output)
+	}
+
+	files := map[string][]byte{
+		"snapshot.json": data,
+		"logs.txt":      []byte(logs.String()),
+		"checks.txt":    []byte(checks.String()),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestWriteFailureSnapshot(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	updated := time.Date(2023, 7, 1, 12, 0, 5, 0, time.UTC)
+	snapshot := &failureSnapshot{
+		App:        "my-app",
+		MachineID:  "148ed599c14189",
+		Region:     "ord",
+		Reason:     "timeout reached waiting for health checks to pass",
+		CapturedAt: time.Date(2023, 7, 1, 12, 0, 10, 0, time.UTC),
+		Checks: []*api.MachineCheckStatus{
+			{Name: "servicecheck-00-http-8080", Status: api.Critical, Output: "connection refused", UpdatedAt: &updated},
+		},
+		Logs: []api.LogEntry{{Timestamp: "2023-07-01T12:00:01Z", Region: "ord", Level: "info", Message: "missing DATABASE_URL"}},
+	}
+
+	md := &machineDeployment{}
+	dir, err := md.writeFailureSnapshot(snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("deploy-failure-20230701T120010Z", "148ed599c14189"), dir)
+
+	logs, err := os.ReadFile(filepath.Join(dir, "logs.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "2023-07-01T12:00:01Z ord [info] missing DATABASE_URL\n", string(logs))
+
+	checks, err := os.ReadFile(filepath.Join(dir, "checks.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(checks), "servicecheck-00-http-8080: critical (2023-07-01T12:00:05Z)\nconnection refused")
+
+	data, err := os.ReadFile(filepath.Join(dir, "snapshot.json"))
+	require.NoError(t, err)
+	var decoded failureSnapshot
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, snapshot.Reason, decoded.Reason)
+
+	// Later failures of the same deploy go to the same folder.
+	snapshot.MachineID = "e2865641be9386"
+	snapshot.CapturedAt = snapshot.CapturedAt.Add(time.Minute)
+	dir, err = md.writeFailureSnapshot(snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("deploy-failure-20230701T120010Z", "e2865641be9386"), dir)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/samber/lo"
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/terminal"
 )
@@ -64,6 +65,9 @@ func (md *machineDeployment) launchInputForUpdate(origMachineRaw *api.Machine) (
 	}
 	mConfig.Image = md.img
 	md.setMachineReleaseData(mConfig)
+	if md.autostopPinned[mID] {
+		appconfig.DisableAutostop(mConfig)
+	}
 	// Get the final process group and prevent empty string
 	processGroup = mConfig.ProcessGroup()
 
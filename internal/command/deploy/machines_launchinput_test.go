@@ -8,6 +8,8 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
 )
 
 // Test the basic flow of launching, restarting and updating a machine for default process group
@@ -239,3 +241,55 @@ func Test_launchInputForUpdate_clearStandbysWithServices(t *testing.T) {
 
 	assert.Equal(t, 0, len(li.Config.Standbys))
 }
+
+func Test_machineUpToDate(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		AppName:       "my-cool-app",
+		PrimaryRegion: "scl",
+	})
+	require.NoError(t, err)
+	md.releaseId = "release_id"
+	md.releaseVersion = 3
+
+	li, err := md.launchInputForLaunch("", nil, nil)
+	require.NoError(t, err)
+	origMachineRaw := &api.Machine{
+		ID:       "ab1234567890",
+		Region:   li.Region,
+		Config:   helpers.Clone(li.Config),
+		ImageRef: api.MachineImageRef{Digest: "sha256:aaa"},
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	upToDate := func(li *api.LaunchMachineInput, imgDigest string) bool {
+		upToDate, err := machineUpToDate(&machineUpdateEntry{
+			leasableMachine: machine.NewLeasableMachine(nil, ios, origMachineRaw),
+			launchInput:     li,
+		}, imgDigest)
+		require.NoError(t, err)
+		return upToDate
+	}
+
+	// A new release of the same image and config is up to date
+	md.releaseId = "new_release_id"
+	md.releaseVersion = 4
+	li, err = md.launchInputForUpdate(origMachineRaw)
+	require.NoError(t, err)
+	assert.True(t, upToDate(li, "sha256:aaa"))
+
+	// Unless the image was pushed again under the same tag
+	assert.False(t, upToDate(li, "sha256:bbb"))
+
+	// Or its digest couldn't be resolved
+	assert.False(t, upToDate(li, ""))
+
+	// The same image under a new tag is up to date
+	md.img = "super/balloon:deployment-2"
+	li, err = md.launchInputForUpdate(origMachineRaw)
+	require.NoError(t, err)
+	assert.True(t, upToDate(li, "sha256:aaa"))
+
+	// A different config is not
+	li.Config.Env = map[string]string{"CHANGED": "1"}
+	assert.False(t, upToDate(li, "sha256:aaa"))
+}
@@ -0,0 +1,64 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/logs"
+)
+
+// streamLogs prints the logs of the app's machines, interleaved with the
+// progress of the deployment, until the returned func is called. Logs are
+// streamed over NATS when the WireGuard tunnel is up, and polled otherwise.
+func (md *machineDeployment) streamLogs(ctx context.Context) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		opts := &logs.LogOptions{AppName: md.app.Name}
+		entries := make(chan logs.LogEntry)
+
+		if stream, err := logs.NewNatsStream(ctx, md.apiClient, opts); err == nil {
+			go func() {
+				defer close(entries)
+				for entry := range stream.Stream(ctx, opts) {
+					entries <- entry
+				}
+			}()
+		} else {
+			logger.FromContext(ctx).Debugf("falling back to polling logs: %v", err)
+			go func() {
+				defer close(entries)
+				_ = logs.Poll(ctx, entries, md.apiClient, opts)
+			}()
+		}
+
+		for entry := range entries {
+			md.printLogEntry(entry)
+		}
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// printLogEntry prints entry in a single write, so it isn't split by the
+// progress of the deployment.
+func (md *machineDeployment) printLogEntry(entry logs.LogEntry) {
+	var buf bytes.Buffer
+	buf.WriteString(md.colorize.Gray("logs "))
+	if err := render.LogEntry(&buf, entry, render.HideAllocID(), render.RemoveNewlines()); err != nil {
+		return
+	}
+	md.io.ErrOut.Write(buf.Bytes())
+}
@@ -16,7 +16,7 @@ import (
 )
 
 func (md *machineDeployment) runReleaseCommand(ctx context.Context) error {
-	if md.appConfig.Deploy == nil || md.appConfig.Deploy.ReleaseCommand == "" {
+	if md.skipReleaseCommand || md.appConfig.Deploy == nil || md.appConfig.Deploy.ReleaseCommand == "" {
 		return nil
 	}
 
@@ -0,0 +1,65 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/superfly/flyctl/flaps"
+)
+
+const (
+	transientRetryAttempts = 5
+	transientRetryDelay    = time.Second
+	transientRetryMaxDelay = 30 * time.Second
+)
+
+// retryTransient calls fn until it succeeds or fails with an error that isn't
+// transient, up to transientRetryAttempts times with exponential backoff. Only
+// failures of requests flaps didn't apply are transient, so an update isn't
+// replayed after flaps started replacing the machine.
+func (md *machineDeployment) retryTransient(ctx context.Context, what string, fn func() error) error {
+	return retry.Do(fn,
+		retry.Context(ctx),
+		retry.Attempts(transientRetryAttempts),
+		retry.Delay(transientRetryDelay),
+		retry.MaxDelay(transientRetryMaxDelay),
+		retry.LastErrorOnly(true),
+		retry.RetryIf(isTransientError),
+		retry.OnRetry(func(n uint, err error) {
+			fmt.Fprintf(md.io.ErrOut, "  Retrying %s after error (attempt %d of %d): %s\n", what, n+2, transientRetryAttempts, err)
+		}),
+	)
+}
+
+// isTransientError reports whether err is likely to go away when retrying,
+// and the request failed without being applied: flaps turning it away as
+// overloaded or unavailable, or the connection to flaps failing to open.
+// Errors which may follow an applied request, such as other 5xx responses,
+// timeouts or connections dropped mid-request, aren't retried.
+func isTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var flapsErr *flaps.FlapsError
+	if errors.As(err, &flapsErr) {
+		switch flapsErr.ResponseStatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		}
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	// Requests failing to dial were never sent, unlike ones failing later on
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
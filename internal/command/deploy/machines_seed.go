@@ -0,0 +1,93 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/machine"
+)
+
+// runSeed fills the data of the app with the [seed] section of fly.toml on
+// its first deploy, or when asked to with --seed: the Postgres snapshot is
+// restored, then the seed command run, each in a temporary machine.
+func (md *machineDeployment) runSeed(ctx context.Context) error {
+	seed := md.appConfig.Seed
+	if seed == nil || md.restartOnly || (!md.isFirstDeploy && !md.seed) {
+		return nil
+	}
+
+	if seed.PostgresSnapshot != "" {
+		fmt.Fprintf(md.io.ErrOut, "Restoring the seed Postgres snapshot into %s\n", md.colorize.Bold("$"+seed.DatabaseURLEnvOrDefault()))
+		if err := md.runSeedMachine(ctx, "restoring the snapshot", md.appConfig.ToSeedRestoreMachineConfig()); err != nil {
+			return err
+		}
+	}
+
+	if seed.Command != "" {
+		fmt.Fprintf(md.io.ErrOut, "Running %s seed command: %s\n", md.colorize.Bold(md.app.Name), seed.Command)
+		mConfig, err := md.appConfig.ToSeedCommandMachineConfig()
+		if err != nil {
+			return err
+		}
+		mConfig.Image = md.img
+		if err := md.runSeedMachine(ctx, "the seed command", mConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runSeedMachine runs a temporary machine with mConfig until it exits,
+// failing when it exits with an error.
+func (md *machineDeployment) runSeedMachine(ctx context.Context, what string, mConfig *api.MachineConfig) error {
+	mConfig.Guest = md.inferReleaseCommandGuest()
+
+	m, err := md.flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Config: mConfig,
+		Region: md.appConfig.PrimaryRegion,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating the machine %s: %w", what, err)
+	}
+	fmt.Fprintf(md.io.ErrOut, "  Created machine %s %s\n", md.colorize.Bold(m.ID), what)
+
+	lm := machine.NewLeasableMachine(md.flapsClient, md.io, m)
+	if err := lm.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout, "", false); err != nil {
+		var flapsErr *flaps.FlapsError
+		// Machines exiting right away are destroyed before being seen started
+		if !errors.As(err, &flapsErr) || flapsErr.ResponseStatusCode != http.StatusNotFound {
+			return fmt.Errorf("error waiting for machine %s to start: %w", m.ID, suggestChangeWaitTimeout(err, "wait-timeout"))
+		}
+	} else if err := lm.WaitForState(ctx, api.MachineStateDestroyed, md.releaseCmdTimeout, "", true); err != nil {
+		return fmt.Errorf("error waiting for machine %s to finish: %w", m.ID, suggestChangeWaitTimeout(err, "release-command-timeout"))
+	}
+	exit, err := lm.WaitForEventTypeAfterType(ctx, "exit", "start", md.releaseCmdTimeout, true)
+	if err != nil {
+		return fmt.Errorf("error finding the exit event of machine %s: %w", m.ID, err)
+	}
+	exitCode, err := exit.Request.GetExitCode()
+	if err != nil {
+		return fmt.Errorf("error getting the exit code of machine %s: %w", m.ID, err)
+	}
+
+	if exitCode != 0 {
+		time.Sleep(2 * time.Second) // Wait for logs to reach OpenSearch
+		fmt.Fprintf(md.io.ErrOut, "Error %s failed on machine %s with exit code %s, its last logs:\n",
+			what, md.colorize.Bold(m.ID), md.colorize.Red(strconv.Itoa(exitCode)))
+		if logs, _, err := md.apiClient.GetAppLogs(ctx, md.app.Name, "", md.appConfig.PrimaryRegion, m.ID); err == nil {
+			for _, l := range logs {
+				fmt.Fprintf(md.io.ErrOut, "  %s\n", l.Message)
+			}
+		}
+		return fmt.Errorf("seeding failed: machine %s %s exited with status %d", m.ID, what, exitCode)
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Seed machine %s completed successfully\n", md.colorize.Bold(m.ID))
+	return nil
+}
@@ -0,0 +1,138 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/smoketest"
+)
+
+// runSmokeTests runs tests against the app, or against m only when set.
+func (md *machineDeployment) runSmokeTests(ctx context.Context, tests []appconfig.SmokeTest, m *api.Machine) error {
+	if md.skipSmokeTests {
+		return nil
+	}
+	return md.runTests(ctx, tests, m)
+}
+
+func (md *machineDeployment) runTests(ctx context.Context, tests []appconfig.SmokeTest, m *api.Machine) error {
+	if len(tests) == 0 {
+		return nil
+	}
+
+	target := md.app.Name
+	if m != nil {
+		target = "machine " + m.ID
+	}
+	fmt.Fprintf(md.io.Out, "Running %d smoke tests against %s\n", len(tests), md.colorize.Bold(target))
+
+	runner := &smoketest.Runner{App: md.app, Flaps: md.flapsClient, Machine: m}
+	results := runner.Run(ctx, tests)
+
+	if failed := smoketest.Failed(results); failed > 0 {
+		fmt.Fprintln(md.io.ErrOut)
+		smoketest.Render(md.io.ErrOut, results)
+		return fmt.Errorf("%d of %d smoke tests failed against %s", failed, len(results), target)
+	}
+
+	fmt.Fprintf(md.io.Out, "Smoke tests passed against %s\n", md.colorize.Bold(target))
+	return nil
+}
+
+// runCanarySmokeTests runs the smoke tests of each canary's group against it.
+func (md *machineDeployment) runCanarySmokeTests(ctx context.Context, canaries []machine.LeasableMachine) error {
+	for _, lm := range canaries {
+		m := lm.Machine()
+		groupConfig, err := md.appConfig.Flatten(m.ProcessGroup())
+		if err != nil {
+			return err
+		}
+
+		tests := smoketest.ForGroup(md.appConfig.SmokeTests, m.ProcessGroup(), len(groupConfig.AllServices()) > 0)
+		if err := md.runSmokeTests(ctx, tests, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyDeployment runs the smoke tests of fly.toml, then the requests of
+// --smoke-url, against the app once its machines are updated. With
+// auto-rollback, failures roll the machines of entries back to their config
+// before the deployment.
+func (md *machineDeployment) verifyDeployment(ctx context.Context, entries []*machineUpdateEntry, previous map[string]*api.LaunchMachineInput) error {
+	err := md.runSmokeTests(ctx, md.appConfig.SmokeTests, nil)
+	if err == nil {
+		err = md.runTests(ctx, md.smokeURLs, nil)
+	}
+	if err == nil || !md.autoRollback || len(entries) == 0 {
+		return err
+	}
+
+	// Blue-green deployments replace machines, leaving none to roll back
+	if md.strategy == "bluegreen" {
+		return fmt.Errorf("%w\nmachines updated with the bluegreen strategy can't be rolled back automatically, deploy the previous image with fly deploy --image", err)
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "Deployment failed verification, rolling back %d machines to their previous release\n", len(entries))
+	if rbErr := md.rollback(ctx, entries, previous); rbErr != nil {
+		return fmt.Errorf("%w\nrollback failed: %v", err, rbErr)
+	}
+	fmt.Fprintf(md.io.ErrOut, "Rolled back %d machines to their previous release\n", len(entries))
+	return rolledBackError{err}
+}
+
+// rolledBackError is the error of a deployment rolled back after failing
+// verification.
+type rolledBackError struct {
+	error
+}
+
+func (e rolledBackError) Unwrap() error {
+	return e.error
+}
+
+// previousLaunchInputs returns the launch inputs restoring the machines of
+// entries as they are, by machine ID, when auto-rollback is enabled.
+func (md *machineDeployment) previousLaunchInputs(entries []*machineUpdateEntry) map[string]*api.LaunchMachineInput {
+	if !md.autoRollback {
+		return nil
+	}
+	previous := make(map[string]*api.LaunchMachineInput, len(entries))
+	for _, e := range entries {
+		m := e.leasableMachine.Machine()
+		mConfig := helpers.Clone(m.Config)
+		previous[m.ID] = &api.LaunchMachineInput{
+			ID:         m.ID,
+			Name:       m.Name,
+			Region:     m.Region,
+			Config:     mConfig,
+			SkipLaunch: len(mConfig.Standbys) > 0,
+		}
+	}
+	return previous
+}
+
+func (md *machineDeployment) rollback(ctx context.Context, entries []*machineUpdateEntry, previous map[string]*api.LaunchMachineInput) error {
+	for _, e := range entries {
+		lm := e.leasableMachine
+		input, ok := previous[lm.Machine().ID]
+		if !ok {
+			continue
+		}
+		if err := lm.Update(ctx, *input); err != nil {
+			return fmt.Errorf("failed rolling back machine %s: %w", lm.FormattedMachineId(), err)
+		}
+		if input.SkipLaunch {
+			continue
+		}
+		if err := lm.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout, "", false); err != nil {
+			return fmt.Errorf("failed rolling back machine %s: %w", lm.FormattedMachineId(), err)
+		}
+	}
+	return nil
+}
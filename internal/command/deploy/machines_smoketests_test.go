@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestVerifyDeployment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ios, _, _, _ := iostreams.Test()
+	m := &api.Machine{
+		ID:     "ab1234567890",
+		Region: "ord",
+		Config: &api.MachineConfig{Image: "super/balloon:1"},
+	}
+	entries := []*machineUpdateEntry{{leasableMachine: machine.NewLeasableMachine(nil, ios, m)}}
+
+	md := &machineDeployment{
+		io:        ios,
+		colorize:  ios.ColorScheme(),
+		app:       &api.AppCompact{Name: "my-app"},
+		appConfig: appconfig.NewConfig(),
+		strategy:  "bluegreen",
+		smokeURLs: []appconfig.SmokeTest{{Name: "health", URL: server.URL + "/health"}},
+	}
+	assert.Nil(t, md.previousLaunchInputs(entries))
+	assert.NoError(t, md.verifyDeployment(context.Background(), entries, nil))
+
+	md.smokeURLs = append(md.smokeURLs, appconfig.SmokeTest{Name: "missing", URL: server.URL + "/missing"})
+	err := md.verifyDeployment(context.Background(), entries, nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "can't be rolled back")
+
+	md.autoRollback = true
+	previous := md.previousLaunchInputs(entries)
+	require.Contains(t, previous, m.ID)
+	assert.Equal(t, "super/balloon:1", previous[m.ID].Config.Image)
+	assert.NotSame(t, m.Config, previous[m.ID].Config)
+
+	err = md.verifyDeployment(context.Background(), entries, previous)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't be rolled back automatically")
+}
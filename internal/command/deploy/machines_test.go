@@ -358,3 +358,20 @@ func Test_resolveUpdatedMachineConfig_restartOnlyProcessGroup(t *testing.T) {
 		},
 	}, md.launchInputForRestart(origMachine))
 }
+
+func TestCheckTrafficProxy(t *testing.T) {
+	newMachine := func(id, group string) *api.Machine {
+		return &api.Machine{ID: id, Config: &api.MachineConfig{Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
+			api.MachineConfigMetadataKeyFlyProcessGroup:    group,
+		}}}
+	}
+	machines := []*api.Machine{newMachine("m1", "app"), newMachine("m2", "worker")}
+	assert.NoError(t, checkTrafficProxy(machines))
+
+	err := checkTrafficProxy(append(machines, newMachine("p1", api.MachineProcessGroupFlyAppTrafficProxy)))
+	assert.ErrorContains(t, err, "through machine p1")
+
+	err = checkTrafficProxy(append(machines, newMachine("c1", api.MachineProcessGroupFlyAppMirrorCandidate)))
+	assert.ErrorContains(t, err, "through machine c1")
+}
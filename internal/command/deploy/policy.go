@@ -0,0 +1,108 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/policy"
+)
+
+// PolicyPlan returns the machines a deploy of appConfig would run: the app's
+// machines with their updated config, and a new machine in the primary region
+// for each process group without machines. guest replaces the size of the
+// machines unless nil.
+func PolicyPlan(ctx context.Context, appCompact *api.AppCompact, appConfig *appconfig.Config, guest *api.MachineGuest) (policy.Plan, error) {
+	plan := policy.Plan{AppName: appCompact.Name}
+
+	flapsClient, err := flaps.New(ctx, appCompact)
+	if err != nil {
+		return plan, err
+	}
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return plan, err
+	}
+
+	groups := appConfig.ProcessNames()
+	deployed := map[string]bool{}
+	for _, m := range machines {
+		group := m.ProcessGroup()
+		if !lo.Contains(groups, group) {
+			continue
+		}
+		config, err := appConfig.ToMachineConfig(group, m.Config)
+		if err != nil {
+			return plan, err
+		}
+		if guest != nil {
+			config.Guest = guest
+		}
+		plan.Machines = append(plan.Machines, &api.LaunchMachineInput{ID: m.ID, Region: m.Region, Config: config})
+		deployed[group] = true
+	}
+
+	for _, group := range groups {
+		if deployed[group] {
+			continue
+		}
+		config, err := appConfig.ToMachineConfig(group, nil)
+		if err != nil {
+			return plan, err
+		}
+		config.Guest = guest
+		if config.Guest == nil {
+			config.Guest = helpers.Clone(api.MachinePresets[DefaultVMSize])
+		}
+		plan.Machines = append(plan.Machines, &api.LaunchMachineInput{Region: appConfig.PrimaryRegion, Config: config})
+	}
+
+	return plan, nil
+}
+
+// guestFromFlags returns the machine size set by the --vm-* flags, or nil if
+// none is set.
+func guestFromFlags(ctx context.Context) (*api.MachineGuest, error) {
+	if !flag.IsSpecified(ctx, "vm-size") && !flag.IsSpecified(ctx, "vm-cpus") && !flag.IsSpecified(ctx, "vm-memory") && !flag.IsSpecified(ctx, "vm-cpukind") {
+		return nil, nil
+	}
+
+	md := &machineDeployment{}
+	if err := md.setMachineGuest(flag.GetString(ctx, "vm-size"), flag.GetString(ctx, "vm-cpukind"), flag.GetInt(ctx, "vm-cpus"), flag.GetInt(ctx, "vm-memory")); err != nil {
+		return nil, err
+	}
+	return md.machineGuest, nil
+}
+
+// checkPolicy checks a deploy of appConfig against the policy of the app's
+// organization, listing the app's machines only when it has one.
+func checkPolicy(ctx context.Context, appCompact *api.AppCompact, appConfig *appconfig.Config) error {
+	orgSlug := appCompact.Organization.Slug
+
+	p, err := policy.Load(ctx, orgSlug)
+	if err != nil {
+		return fmt.Errorf("failed loading the policy of %s: %w", orgSlug, err)
+	}
+	if p == nil {
+		return nil
+	}
+
+	guest, err := guestFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	plan, err := PolicyPlan(ctx, appCompact, appConfig, guest)
+	if err != nil {
+		return err
+	}
+
+	return policy.Enforce(ctx, p, orgSlug, plan)
+}
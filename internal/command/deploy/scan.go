@@ -0,0 +1,43 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/vulnscan"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// scanImage scans img for vulnerabilities when --fail-on is set, refusing to
+// go on when some at least as severe are found.
+func scanImage(ctx context.Context, img *imgsrc.DeploymentImage) error {
+	failOn := flag.GetString(ctx, "fail-on")
+	if failOn == "" {
+		return nil
+	}
+
+	failOn, _, err := vulnscan.ParseSeverity(failOn)
+	if err != nil {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.ErrOut, "Scanning image %s for vulnerabilities\n", io.ColorScheme().Bold(img.Tag))
+
+	report, err := vulnscan.Scan(ctx, img.Tag, flag.GetString(ctx, "scanner"))
+	if err != nil {
+		return err
+	}
+
+	if err := report.Render(io.ErrOut, failOn); err != nil {
+		return err
+	}
+
+	if vulns := report.AtOrAbove(failOn); len(vulns) > 0 {
+		return fmt.Errorf("refusing to deploy: found %d vulnerabilities of severity %s or higher", len(vulns), failOn)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/secretscan"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// Values of --secrets-lint.
+const (
+	secretsLintWarn  = "warn"
+	secretsLintBlock = "block"
+)
+
+// secretsScanner returns a scanner for the secrets of appName when
+// --secrets-lint is set, or nil otherwise.
+func secretsScanner(ctx context.Context, appName string) (*secretscan.Scanner, error) {
+	switch mode := flag.GetString(ctx, "secrets-lint"); mode {
+	case "":
+		return nil, nil
+	case secretsLintWarn, secretsLintBlock:
+	default:
+		return nil, fmt.Errorf("invalid --secrets-lint %s, expected %s or %s", mode, secretsLintWarn, secretsLintBlock)
+	}
+
+	secrets, err := client.FromContext(ctx).API().GetAppSecrets(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving secrets of %s: %w", appName, err)
+	}
+
+	names := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		names = append(names, s.Name)
+	}
+
+	return secretscan.New(names), nil
+}
+
+// lintBuildContext looks for leaked secrets in the build context of opts
+// before it's built.
+func lintBuildContext(ctx context.Context, opts imgsrc.ImageOptions) error {
+	scanner, err := secretsScanner(ctx, opts.AppName)
+	if scanner == nil || err != nil {
+		return err
+	}
+
+	findings, err := scanner.ScanBuildContext(opts.WorkingDir, opts.DockerfilePath, opts.IgnorefilePath)
+	if err != nil {
+		return err
+	}
+
+	return reportLeakedSecrets(ctx, findings, "build context")
+}
+
+// lintImage looks for leaked secrets in the layers of img.
+func lintImage(ctx context.Context, appName string, img *imgsrc.DeploymentImage) error {
+	scanner, err := secretsScanner(ctx, appName)
+	if scanner == nil || err != nil {
+		return err
+	}
+
+	findings, err := scanner.ScanImage(ctx, img.Tag)
+	if err != nil {
+		return err
+	}
+
+	return reportLeakedSecrets(ctx, findings, "image "+img.Tag)
+}
+
+func reportLeakedSecrets(ctx context.Context, findings []secretscan.Finding, where string) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+	)
+
+	fmt.Fprintf(io.ErrOut, "%s found %d values that look like secrets in the %s:\n", colorize.Yellow("WARN"), len(findings), where)
+	if err := secretscan.Render(io.ErrOut, findings); err != nil {
+		return err
+	}
+
+	if flag.GetString(ctx, "secrets-lint") == secretsLintBlock {
+		return fmt.Errorf("refusing to deploy: found %d values that look like secrets in the %s", len(findings), where)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/cosign"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// signAndVerifyImage signs img when --sign is set and, with
+// --require-signed-by, refuses to go on unless img carries a valid signature
+// from the required signer. Signatures are made and checked on the digest of
+// img, which the deployment is then pinned to.
+func signAndVerifyImage(ctx context.Context, appName string, img *imgsrc.DeploymentImage) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		sign     = flag.GetBool(ctx, "sign")
+		key      = flag.GetString(ctx, "signing-key")
+		signer   = flag.GetString(ctx, "require-signed-by")
+		issuer   = flag.GetString(ctx, "require-signed-by-issuer")
+	)
+
+	switch {
+	case key != "" && !sign:
+		return errors.New("--signing-key can only be used with --sign")
+	case issuer != "" && signer == "":
+		return errors.New("--require-signed-by-issuer can only be used with --require-signed-by")
+	case !sign && signer == "":
+		return nil
+	}
+
+	if img.Digest = deploymentImageDigest(ctx, appName, img); img.Digest == "" {
+		return fmt.Errorf("failed resolving the digest of %s to sign or verify it", img.Tag)
+	}
+	ref := cosign.DigestRef(img.Tag, img.Digest)
+
+	if sign {
+		fmt.Fprintf(io.ErrOut, "Signing image %s\n", colorize.Bold(ref))
+		if err := cosign.Sign(ctx, ref, key); err != nil {
+			return err
+		}
+	}
+
+	if signer != "" {
+		fmt.Fprintf(io.ErrOut, "Verifying image %s is signed by %s\n", colorize.Bold(ref), colorize.Bold(signer))
+		if err := cosign.Verify(ctx, ref, signer, issuer); err != nil {
+			return fmt.Errorf("refusing to deploy: %w", err)
+		}
+		fmt.Fprintf(io.ErrOut, "  %s\n", colorize.Green("Signature verified"))
+
+		// Deploy the verified image, even if the tag is pushed again
+		img.Tag = ref
+	}
+
+	return nil
+}
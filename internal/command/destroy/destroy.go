@@ -6,6 +6,7 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/command/apps"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/protect"
 )
 
 // TODO: deprecate & remove
@@ -25,6 +26,7 @@ from the Fly platform.
 
 	flag.Add(destroy,
 		flag.Yes(),
+		protect.ForceFlag(),
 	)
 
 	return destroy
@@ -0,0 +1,458 @@
+// Package dev implements the dev command.
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/proxy"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// New initializes and returns a new dev Command.
+func New() *cobra.Command {
+	const (
+		short = "Run the app locally"
+		long  = `Build the app's image locally the same way fly deploy does, from its
+Dockerfile, buildpacks or Nixpacks, and run it in Docker with the non-secret
+environment of fly.toml.
+
+Private services of the organization are reachable from the app with
+--internal, which forwards a .internal host and port through the WireGuard
+tunnel, so the app can keep using addresses such as my-db.internal:5432.
+
+The image is rebuilt and the container restarted whenever a file of the
+working directory changes, unless --no-watch is passed.`
+		usage = "dev"
+	)
+
+	cmd := command.New(usage, short, long, run,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly dev
+  fly dev --internal my-db.internal:5432 -e LOG_LEVEL=debug`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "nixpacks",
+			Description: "Build the image with nixpacks",
+		},
+		flag.StringArray{
+			Name:        "env",
+			Shorthand:   "e",
+			Description: "Set of environment variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
+		},
+		flag.Int{
+			Name:        "port",
+			Shorthand:   "p",
+			Description: "Local port the app is served on, defaults to its internal port",
+		},
+		flag.StringArray{
+			Name:        "internal",
+			Description: "Forward a private host of the organization as HOST:PORT, e.g. my-db.internal:5432. Can be specified multiple times.",
+		},
+		flag.Bool{
+			Name:        "no-watch",
+			Description: "Don't rebuild and restart the app when files change",
+		},
+	)
+
+	cmd.AddCommand(newTunnel())
+
+	return cmd
+}
+
+func run(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		colorize  = io.ColorScheme()
+		appName   = appconfig.NameFromContext(ctx)
+		appConfig = appconfig.ConfigFromContext(ctx)
+		watch     = !flag.GetBool(ctx, "no-watch")
+	)
+
+	if appConfig == nil {
+		return errors.New("fly dev runs the app described by fly.toml, and none was found")
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		return errors.New("fly dev needs docker, install it from https://docs.docker.com/get-docker/")
+	}
+
+	env := map[string]string{}
+	for k, v := range appConfig.Env {
+		env[k] = v
+	}
+	extraEnv, err := cmdutil.ParseKVStringsToMap(flag.GetStringArray(ctx, "env"))
+	if err != nil {
+		return fmt.Errorf("failed parsing environment: %w", err)
+	}
+	for k, v := range extraEnv {
+		env[k] = v
+	}
+
+	hosts, err := startInternalProxies(ctx, appName, flag.GetStringArray(ctx, "internal"))
+	if err != nil {
+		return err
+	}
+
+	params := runParams{
+		Name:         "fly-dev-" + appName,
+		Env:          env,
+		InternalPort: appConfig.InternalPort(),
+		Port:         flag.GetInt(ctx, "port"),
+		Hosts:        hosts,
+		// The proxies listen on the host's loopback, which containers only
+		// reach through the host network on Linux
+		HostNetwork: len(hosts) > 0 && runtime.GOOS == "linux",
+	}
+	if params.Port == 0 {
+		params.Port = params.InternalPort
+	}
+
+	workDir := state.WorkingDirectory(ctx)
+	for {
+		var (
+			fingerprint, _ = workDirFingerprint(workDir)
+			exited         = make(chan error, 1)
+		)
+
+		params.Image, err = buildImage(ctx, appConfig)
+		switch {
+		case err != nil && !watch:
+			return err
+		case err != nil:
+			fmt.Fprintf(io.ErrOut, "%s %v\n", colorize.Red("Build failed:"), err)
+			close(exited)
+		default:
+			if err := startContainer(ctx, io, params, exited); err != nil {
+				return err
+			}
+			if port := params.localPort(); port != 0 {
+				fmt.Fprintf(io.Out, "App running at %s\n", colorize.Bold(fmt.Sprintf("http://localhost:%d", port)))
+			}
+		}
+
+		if !watch {
+			select {
+			case err := <-exited:
+				return err
+			case <-ctx.Done():
+				return stopContainer(params.Name, exited)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return stopContainer(params.Name, exited)
+		case <-waitForChange(ctx, workDir, fingerprint):
+			if ctx.Err() != nil {
+				return stopContainer(params.Name, exited)
+			}
+			fmt.Fprintln(io.Out, colorize.Yellow("Files changed, rebuilding..."))
+			if err := stopContainer(params.Name, exited); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// startInternalProxies forwards each HOST:PORT from 127.0.0.1:PORT over the
+// organization's WireGuard tunnel and returns the forwarded hosts.
+func startInternalProxies(ctx context.Context, appName string, internals []string) ([]string, error) {
+	if len(internals) == 0 {
+		return nil, nil
+	}
+
+	apiClient := client.FromContext(ctx).API()
+
+	app, err := apiClient.GetAppBasic(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	orgSlug := app.Organization.Slug
+
+	agentclient, err := agent.Establish(ctx, apiClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := agentclient.Establish(ctx, orgSlug); err != nil {
+		return nil, err
+	}
+
+	dialer, err := agentclient.ConnectToTunnel(ctx, orgSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, internal := range internals {
+		host, port, err := parseInternal(internal)
+		if err != nil {
+			return nil, err
+		}
+
+		err = proxy.Start(ctx, &proxy.ConnectParams{
+			Ports:            []string{port},
+			AppName:          appName,
+			OrganizationSlug: orgSlug,
+			Dialer:           dialer,
+			RemoteHost:       host,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed forwarding %s: %w", internal, err)
+		}
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
+
+func parseInternal(internal string) (host, port string, err error) {
+	host, port, ok := strings.Cut(internal, ":")
+	if !ok || host == "" {
+		return "", "", fmt.Errorf("invalid internal host %q, expected HOST:PORT", internal)
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return "", "", fmt.Errorf("invalid internal host %q, expected HOST:PORT", internal)
+	}
+	return host, port, nil
+}
+
+// buildImage builds the app's image in the local Docker daemon without
+// pushing it, or returns the prebuilt image fly.toml refers to.
+func buildImage(ctx context.Context, appConfig *appconfig.Config) (string, error) {
+	build := appConfig.Build
+	if build == nil {
+		build = new(appconfig.Build)
+	}
+
+	if build.Image != "" {
+		return build.Image, nil
+	}
+
+	var (
+		io         = iostreams.FromContext(ctx)
+		apiClient  = client.FromContext(ctx).API()
+		daemonType = imgsrc.NewDockerDaemonType(true, false, true, flag.GetBool(ctx, "nixpacks"))
+		resolver   = imgsrc.NewResolver(daemonType, apiClient, appConfig.AppName, io)
+		configDir  = filepath.Dir(appConfig.ConfigFilePath())
+	)
+
+	opts := imgsrc.ImageOptions{
+		AppName:         appConfig.AppName,
+		WorkingDir:      state.WorkingDirectory(ctx),
+		Tag:             fmt.Sprintf("fly-dev/%s:latest", appConfig.AppName),
+		BuiltIn:         build.Builtin,
+		BuiltInSettings: build.Settings,
+		Builder:         build.Builder,
+		Buildpacks:      build.Buildpacks,
+		BuildArgs:       build.Args,
+		Target:          appConfig.DockerBuildTarget(),
+	}
+	if path := appConfig.Dockerfile(); path != "" {
+		opts.DockerfilePath = filepath.Join(configDir, path)
+	}
+	if path := appConfig.Ignorefile(); path != "" {
+		opts.IgnorefilePath = filepath.Join(configDir, path)
+	}
+
+	img, err := resolver.BuildImage(ctx, io, opts)
+	switch {
+	case err != nil:
+		return "", err
+	case img == nil:
+		return "", errors.New("no image specified, add a Dockerfile or a [build] section to fly.toml")
+	}
+	return img.Tag, nil
+}
+
+// runParams describes how the app's container is run.
+type runParams struct {
+	Name         string
+	Image        string
+	Env          map[string]string
+	InternalPort int
+	Port         int
+	Hosts        []string
+	HostNetwork  bool
+}
+
+// localPort returns the port the app is reachable on from the host.
+func (p runParams) localPort() int {
+	if p.HostNetwork {
+		return p.InternalPort
+	}
+	return p.Port
+}
+
+// dockerRunArgs returns the arguments of docker run for p.
+func dockerRunArgs(p runParams) []string {
+	args := []string{"run", "--rm", "--init", "--name", p.Name}
+
+	keys := make([]string, 0, len(p.Env))
+	for k := range p.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, p.Env[k]))
+	}
+
+	if p.HostNetwork {
+		args = append(args, "--network", "host")
+		for _, host := range p.Hosts {
+			args = append(args, "--add-host", host+":127.0.0.1")
+		}
+	} else {
+		if p.InternalPort != 0 {
+			args = append(args, "-p", fmt.Sprintf("%d:%d", p.Port, p.InternalPort))
+		}
+		for _, host := range p.Hosts {
+			args = append(args, "--add-host", host+":host-gateway")
+		}
+	}
+
+	return append(args, p.Image)
+}
+
+// startContainer runs the app's container in the background, sending the
+// result of docker run to exited once it's gone.
+func startContainer(ctx context.Context, io *iostreams.IOStreams, p runParams, exited chan<- error) error {
+	args := dockerRunArgs(p)
+	terminal.Debugf("Running docker %s\n", strings.Join(args, " "))
+
+	// The container is stopped with docker stop rather than by killing the
+	// docker CLI, so it isn't bound to ctx
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = io.Out
+	cmd.Stderr = io.ErrOut
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed running docker: %w", err)
+	}
+
+	go func() {
+		exited <- cmd.Wait()
+		close(exited)
+	}()
+	return nil
+}
+
+// stopContainer stops the app's container and waits for it to be gone.
+func stopContainer(name string, exited <-chan error) error {
+	select {
+	case <-exited:
+		return nil
+	default:
+	}
+
+	if out, err := exec.Command("docker", "stop", "-t", "5", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed stopping container %s: %s", name, strings.TrimSpace(string(out)))
+	}
+	<-exited
+	return nil
+}
+
+// waitForChange returns a channel closed once the files of dir differ from
+// fingerprint and have settled, or once ctx is done.
+func waitForChange(ctx context.Context, dir string, fingerprint uint64) <-chan struct{} {
+	changed := make(chan struct{})
+
+	go func() {
+		defer close(changed)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		last := fingerprint
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := workDirFingerprint(dir)
+			if err != nil {
+				terminal.Debugf("failed watching %s: %v\n", dir, err)
+				continue
+			}
+			// Wait for a quiet tick so that a batch of writes triggers a
+			// single rebuild
+			if current != fingerprint && current == last {
+				return
+			}
+			last = current
+		}
+	}()
+
+	return changed
+}
+
+// skippedDirs are never watched for changes.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".fly":         true,
+	"node_modules": true,
+	"tmp":          true,
+	"log":          true,
+}
+
+// workDirFingerprint returns a hash of the paths, sizes and modification
+// times of the files under dir.
+func workDirFingerprint(dir string) (uint64, error) {
+	h := fnv.New64a()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+
+	return h.Sum64(), err
+}
@@ -0,0 +1,74 @@
+package dev
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	params := runParams{
+		Name:         "fly-dev-app",
+		Image:        "fly-dev/app:latest",
+		Env:          map[string]string{"PORT": "8080", "LOG_LEVEL": "debug"},
+		InternalPort: 8080,
+		Port:         3000,
+		Hosts:        []string{"db.internal"},
+	}
+
+	assert.Equal(t, []string{
+		"run", "--rm", "--init", "--name", "fly-dev-app",
+		"-e", "LOG_LEVEL=debug", "-e", "PORT=8080",
+		"-p", "3000:8080",
+		"--add-host", "db.internal:host-gateway",
+		"fly-dev/app:latest",
+	}, dockerRunArgs(params))
+	assert.Equal(t, 3000, params.localPort())
+
+	params.HostNetwork = true
+	assert.Equal(t, []string{
+		"run", "--rm", "--init", "--name", "fly-dev-app",
+		"-e", "LOG_LEVEL=debug", "-e", "PORT=8080",
+		"--network", "host",
+		"--add-host", "db.internal:127.0.0.1",
+		"fly-dev/app:latest",
+	}, dockerRunArgs(params))
+	assert.Equal(t, 8080, params.localPort())
+}
+
+func TestParseInternal(t *testing.T) {
+	host, port, err := parseInternal("my-db.internal:5432")
+	require.NoError(t, err)
+	assert.Equal(t, "my-db.internal", host)
+	assert.Equal(t, "5432", port)
+
+	for _, invalid := range []string{"my-db.internal", ":5432", "my-db.internal:pg"} {
+		_, _, err := parseInternal(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWorkDirFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "node_modules"), 0o755))
+
+	before, err := workDirFingerprint(dir)
+	require.NoError(t, err)
+
+	// Skipped directories don't count
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "dep.js"), []byte("x"), 0o644))
+	after, err := workDirFingerprint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "main.go"), later, later))
+	after, err = workDirFingerprint(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
@@ -0,0 +1,464 @@
+package dev
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	tunnelPublicPort  = 8080
+	tunnelControlPort = 10000
+
+	tunnelTokenEnv = "FLY_TUNNEL_TOKEN"
+	tunnelUser     = "fly-tunnel"
+)
+
+func newTunnel() *cobra.Command {
+	const (
+		short = "Expose a local port on a public Fly.io URL"
+		long  = `Serve a local port on a public https://<app>.fly.dev URL, with automatic
+TLS, to demo local work or receive webhooks.
+
+A small relay app is launched in the organization and forwards the requests
+it receives to this machine over the WireGuard tunnel. Unless --name selects
+an existing relay app, the app is destroyed when the tunnel is closed.`
+		usage = "tunnel <port>"
+	)
+
+	cmd := command.New(usage, short, long, runTunnel,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly dev tunnel 3000
+  fly dev tunnel 3000 --name my-demo`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.Region(),
+		flag.String{
+			Name:        "name",
+			Description: "Name of the relay app, kept once the tunnel is closed. Created if it doesn't exist.",
+		},
+	)
+
+	cmd.AddCommand(newTunnelRelay())
+
+	return cmd
+}
+
+func newTunnelRelay() *cobra.Command {
+	const (
+		short = "Run the relay end of a tunnel"
+		long  = `Run the relay end of a tunnel, in the relay app's machine.`
+	)
+
+	cmd := command.New("relay", short, long, runTunnelRelay)
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	return cmd
+}
+
+func runTunnel(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		colorize  = io.ColorScheme()
+		apiClient = client.FromContext(ctx).API()
+		name      = flag.GetString(ctx, "name")
+	)
+
+	port, err := strconv.Atoi(flag.FirstArg(ctx))
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q", flag.FirstArg(ctx))
+	}
+	localAddr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	app, created, err := ensureTunnelApp(ctx, org, name)
+	// Named relay apps are kept for the next tunnel
+	keepApp := !created || name != ""
+	if !keepApp {
+		// ctx is done by the time the tunnel is closed
+		defer func() {
+			if err := apiClient.DeleteApp(context.Background(), app.Name); err != nil {
+				terminal.Warnf("failed destroying relay app %s: %v\n", app.Name, err)
+				return
+			}
+			fmt.Fprintf(io.Out, "Destroyed relay app %s\n", app.Name)
+		}()
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	relay, err := launchTunnelRelay(ctx, token)
+	if err != nil {
+		return err
+	}
+	if keepApp {
+		defer func() {
+			if err := flapsClient.Destroy(context.Background(), api.RemoveMachineInput{ID: relay.ID, Kill: true}, ""); err != nil {
+				terminal.Warnf("failed destroying relay machine %s: %v\n", relay.ID, err)
+			}
+		}()
+	}
+
+	agentclient, err := agent.Establish(ctx, apiClient)
+	if err != nil {
+		return err
+	}
+	if _, err := agentclient.Establish(ctx, org.Slug); err != nil {
+		return err
+	}
+	dialer, err := agentclient.ConnectToTunnel(ctx, org.Slug)
+	if err != nil {
+		return err
+	}
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		return err
+	}
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == tunnelUser && subtle.ConstantTimeCompare(password, []byte(token)) == 1 {
+				return nil, nil
+			}
+			return nil, errors.New("invalid tunnel token")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	controlAddr := net.JoinHostPort(relay.PrivateIP, strconv.Itoa(tunnelControlPort))
+	announced := false
+	for ctx.Err() == nil {
+		conn, err := dialer.DialContext(ctx, "tcp", controlAddr)
+		if err != nil {
+			terminal.Debugf("failed connecting to relay %s: %v\n", controlAddr, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+
+		if !announced {
+			fmt.Fprintf(io.Out, "Forwarding %s to %s\n", colorize.Bold(fmt.Sprintf("https://%s.fly.dev", app.Name)), localAddr)
+			fmt.Fprintln(io.Out, "Press Ctrl+C to close the tunnel")
+			announced = true
+		}
+
+		err = serveTunnel(ctx, conn, serverConfig, localAddr)
+		if ctx.Err() == nil {
+			fmt.Fprintf(io.ErrOut, "Tunnel disconnected (%v), reconnecting...\n", err)
+		}
+	}
+
+	return nil
+}
+
+// ensureTunnelApp returns the relay app named name, creating it with public
+// IPs if needed, and whether it was created. Created apps are returned even
+// on error so that they can be cleaned up.
+func ensureTunnelApp(ctx context.Context, org *api.Organization, name string) (*api.AppCompact, bool, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	if name != "" {
+		app, err := apiClient.GetAppCompact(ctx, name)
+		if err == nil {
+			return app, false, nil
+		}
+		if !api.IsNotFoundError(err) {
+			return nil, false, err
+		}
+	} else {
+		suffix, err := randomToken()
+		if err != nil {
+			return nil, false, err
+		}
+		name = fmt.Sprintf("tunnel-%s-%s", org.RawSlug, suffix[:8])
+	}
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = org.ID
+	input.Name = name
+
+	if _, err := gql.CreateApp(ctx, apiClient.GenqClient, input); err != nil {
+		return nil, false, fmt.Errorf("failed creating relay app %s: %w", name, err)
+	}
+
+	created := &api.AppCompact{Name: name}
+	if _, err := apiClient.AllocateIPAddress(ctx, name, "v6", "", nil, ""); err != nil {
+		return created, true, fmt.Errorf("failed allocating an IPv6 address for %s: %w", name, err)
+	}
+	if _, err := apiClient.AllocateSharedIPAddress(ctx, name); err != nil {
+		return created, true, fmt.Errorf("failed allocating a shared IPv4 address for %s: %w", name, err)
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, name)
+	if err != nil {
+		return created, true, err
+	}
+	return app, true, nil
+}
+
+// launchTunnelRelay launches the relay machine of the app, serving HTTPS on
+// its public IPs and accepting tunnels authenticated with token.
+func launchTunnelRelay(ctx context.Context, token string) (*api.Machine, error) {
+	var (
+		io          = iostreams.FromContext(ctx)
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	region := flag.GetRegion(ctx)
+	if region == "" {
+		nearest, err := gql.GetNearestRegion(ctx, client.FromContext(ctx).API().GenqClient)
+		if err != nil {
+			return nil, err
+		}
+		region = nearest.NearestRegion.Code
+	}
+
+	var (
+		httpPort  = 80
+		httpsPort = 443
+	)
+	config := &api.MachineConfig{
+		Init: api.MachineInit{
+			Entrypoint: []string{"flyctl"},
+			Cmd:        []string{"dev", "tunnel", "relay"},
+		},
+		Env: map[string]string{tunnelTokenEnv: token},
+		Guest: &api.MachineGuest{
+			CPUKind:  "shared",
+			CPUs:     1,
+			MemoryMB: 256,
+		},
+		Services: []api.MachineService{{
+			Protocol:     "tcp",
+			InternalPort: tunnelPublicPort,
+			Ports: []api.MachinePort{
+				{Port: &httpPort, Handlers: []string{"http"}, ForceHTTPS: true},
+				{Port: &httpsPort, Handlers: []string{"tls", "http"}},
+			},
+		}},
+	}
+	infra.Configure(config, infra.ComponentFlyctl, nil)
+
+	machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Name:   "tunnel-relay",
+		Region: region,
+		Config: config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed launching relay machine: %w", err)
+	}
+	fmt.Fprintf(io.Out, "Launched relay machine %s in %s\n", machine.ID, machine.Region)
+
+	if err := mach.WaitForStartOrStop(ctx, machine, "start", 2*time.Minute); err != nil {
+		return nil, fmt.Errorf("relay machine %s failed to start: %w", machine.ID, err)
+	}
+	return machine, nil
+}
+
+// serveTunnel serves the tunnel carried by conn, forwarding each channel the
+// relay opens to localAddr, until conn or ctx is closed.
+func serveTunnel(ctx context.Context, conn net.Conn, config *ssh.ServerConfig, localAddr string) error {
+	defer conn.Close()
+
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return err
+	}
+	go ssh.DiscardRequests(reqs)
+
+	go func() {
+		<-ctx.Done()
+		sshConn.Close()
+	}()
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+
+		go func() {
+			local, err := net.Dial("tcp", localAddr)
+			if err != nil {
+				terminal.Debugf("failed connecting to %s: %v\n", localAddr, err)
+				channel.Close()
+				return
+			}
+			pipe(channel, local)
+		}()
+	}
+
+	return sshConn.Wait()
+}
+
+func runTunnelRelay(ctx context.Context) error {
+	token := os.Getenv(tunnelTokenEnv)
+	if token == "" {
+		return fmt.Errorf("%s is not set", tunnelTokenEnv)
+	}
+
+	public, err := net.Listen("tcp", fmt.Sprintf(":%d", tunnelPublicPort))
+	if err != nil {
+		return err
+	}
+	control, err := net.Listen("tcp", fmt.Sprintf(":%d", tunnelControlPort))
+	if err != nil {
+		return err
+	}
+
+	relay := &tunnelRelay{}
+	go relay.acceptTunnels(control, &ssh.ClientConfig{
+		User:            tunnelUser,
+		Auth:            []ssh.AuthMethod{ssh.Password(token)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         10 * time.Second,
+	})
+
+	go func() {
+		<-ctx.Done()
+		public.Close()
+		control.Close()
+	}()
+
+	for {
+		conn, err := public.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go relay.forward(conn)
+	}
+}
+
+// tunnelRelay forwards public connections over the latest tunnel.
+type tunnelRelay struct {
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func (r *tunnelRelay) acceptTunnels(l net.Listener, config *ssh.ClientConfig) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		sshConn, chans, reqs, err := ssh.NewClientConn(conn, conn.RemoteAddr().String(), config)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		client := ssh.NewClient(sshConn, chans, reqs)
+
+		r.mu.Lock()
+		previous := r.client
+		r.client = client
+		r.mu.Unlock()
+
+		if previous != nil {
+			previous.Close()
+		}
+	}
+}
+
+func (r *tunnelRelay) forward(conn net.Conn) {
+	r.mu.Lock()
+	client := r.client
+	r.mu.Unlock()
+
+	if client != nil {
+		if channel, err := client.Dial("tcp", "127.0.0.1:0"); err == nil {
+			pipe(conn, channel)
+			return
+		}
+	}
+
+	io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\nContent-Type: text/plain\r\nConnection: close\r\n\r\nThe tunnel is not connected.\n")
+	conn.Close()
+}
+
+// pipe copies between a and b until either is done, then closes both.
+func pipe(a, b io.ReadWriteCloser) {
+	var once sync.Once
+	closeBoth := func() {
+		a.Close()
+		b.Close()
+	}
+
+	go func() {
+		io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	io.Copy(b, a)
+	once.Do(closeBoth)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
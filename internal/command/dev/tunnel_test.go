@@ -0,0 +1,89 @@
+package dev
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestTunnel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The local app echoes lines back
+	local, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer local.Close()
+	go func() {
+		for {
+			conn, err := local.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				conn.Write([]byte("echo " + line))
+			}()
+		}
+	}()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "token" {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	control, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer control.Close()
+
+	relay := &tunnelRelay{}
+
+	// Without a tunnel, the relay answers with an error
+	public, relayPublic := net.Pipe()
+	go relay.forward(relayPublic)
+	status, err := bufio.NewReader(public).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 502 Bad Gateway\r\n", status)
+
+	go relay.acceptTunnels(control, &ssh.ClientConfig{
+		User:            tunnelUser,
+		Auth:            []ssh.AuthMethod{ssh.Password("token")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+
+	conn, err := net.Dial("tcp", control.Addr().String())
+	require.NoError(t, err)
+	go serveTunnel(ctx, conn, serverConfig, local.Addr().String())
+
+	require.Eventually(t, func() bool {
+		relay.mu.Lock()
+		defer relay.mu.Unlock()
+		return relay.client != nil
+	}, time.Second*5, 10*time.Millisecond)
+
+	public, relayPublic = net.Pipe()
+	go relay.forward(relayPublic)
+	_, err = public.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	reply, err := bufio.NewReader(public).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "echo hello\n", reply)
+}
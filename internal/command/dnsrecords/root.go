@@ -28,6 +28,7 @@ func New() *cobra.Command {
 		newDNSRecordsList(),
 		newDNSRecordsExport(),
 		newDNSRecordsImport(),
+		newDNSRecordsSet(),
 	)
 	return cmd
 }
@@ -62,12 +63,19 @@ func newDNSRecordsExport() *cobra.Command {
 func newDNSRecordsImport() *cobra.Command {
 	const (
 		short = "Import DNS records"
-		long  = `Import DNS records. Will import from a file is a filename is given, otherwise imports from StdIn.`
+		long  = `Import DNS records. Will import from a file is a filename is given, otherwise imports from StdIn.
+The records of the domain are replaced with the ones of the zone file, pass --dry-run to preview the changes.`
 	)
 	cmd := command.New("import <domain> [filename]", short, long, runDNSRecordsImport,
 		command.RequireSession,
 	)
 	cmd.Args = cobra.RangeArgs(1, 2)
+	flag.Add(cmd,
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Only preview the changes of the import",
+		},
+	)
 	return cmd
 }
 
@@ -179,6 +187,26 @@ func runDNSRecordsImport(ctx context.Context) error {
 		}
 	}
 
+	records, err := parseZone(domain.Name, string(data))
+	if err != nil {
+		return err
+	}
+	current, err := apiClient.GetDNSRecords(ctx, domain.Name)
+	if err != nil {
+		return err
+	}
+	from, err := zoneRecords(current)
+	if err != nil {
+		return err
+	}
+
+	if flag.GetBool(ctx, "dry-run") {
+		out := iostreams.FromContext(ctx)
+		fmt.Fprintf(out.Out, "Changes importing the zone file to %s:\n", domain.Name)
+		renderChanges(out.Out, out.ColorScheme(), diffZones(from, records))
+		return nil
+	}
+
 	warnings, changes, err := apiClient.ImportDNSRecords(ctx, domain.ID, string(data))
 	if err != nil {
 		return err
@@ -0,0 +1,229 @@
+package dnsrecords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newDNSRecordsSet() *cobra.Command {
+	const (
+		short = "Set DNS records"
+		long  = `Set the records of a type and name within a domain, replacing the existing
+ones, or removing them with --delete. Names are relative to the domain, with @
+for the domain itself.
+
+With --app, records point at an app instead of taking values: A and AAAA
+records to its public IPv4 and IPv6 addresses, CNAME records to its fly.dev
+hostname. With --cert-validation, the DNS validation record of the app's
+certificate for the name is set, to issue certificates before traffic points
+at the app.
+
+The changes are previewed before being applied.`
+		usage = "set <domain> <name> [type] [value...]"
+	)
+	cmd := command.New(usage, short, long, runDNSRecordsSet,
+		command.RequireSession,
+	)
+	cmd.Args = cobra.MinimumNArgs(2)
+	cmd.Example = `  fly dns-records set example.com www CNAME --app my-app
+  fly dns-records set example.com @ A --app my-app
+  fly dns-records set example.com www --cert-validation --app my-app
+  fly dns-records set example.com @ MX "10 mail.example.com."
+  fly dns-records set example.com @ TXT "v=spf1 -all" --ttl 3600
+  fly dns-records set example.com old CNAME --delete`
+	flag.Add(cmd,
+		flag.Yes(),
+		flag.Int{
+			Name:        "ttl",
+			Description: "TTL of the records, in seconds",
+			Default:     300,
+		},
+		flag.String{
+			Name:        "app",
+			Description: "App the records point at",
+		},
+		flag.Bool{
+			Name:        "cert-validation",
+			Description: "Set the DNS validation record of the certificate of --app for the name",
+		},
+		flag.Bool{
+			Name:        "delete",
+			Description: "Remove the records instead",
+		},
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Only preview the changes",
+		},
+	)
+	return cmd
+}
+
+func runDNSRecordsSet(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		args      = flag.Args(ctx)
+		domain    = args[0]
+		name      = qualify(args[1], domain)
+	)
+
+	rrtype, values, err := recordsToSet(ctx, name, args[2:])
+	if err != nil {
+		return err
+	}
+	// Validation records are named after the certificate
+	if flag.GetBool(ctx, "cert-validation") {
+		name, values = values[0], values[1:]
+	}
+	if rrtype == "CNAME" && len(values) > 1 {
+		return errors.New("a name has at most one CNAME record")
+	}
+
+	rrs := make([]dns.RR, 0, len(values))
+	for _, value := range values {
+		if rrtype == "TXT" && !strings.HasPrefix(value, `"`) {
+			value = strconv.Quote(value)
+		}
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, flag.GetInt(ctx, "ttl"), rrtype, value))
+		if err != nil {
+			return fmt.Errorf("invalid %s record %s: %w", rrtype, value, err)
+		}
+		rrs = append(rrs, rr)
+	}
+
+	d, err := apiClient.GetDomain(ctx, domain)
+	if err != nil {
+		return err
+	}
+	current, err := apiClient.GetDNSRecords(ctx, d.Name)
+	if err != nil {
+		return err
+	}
+	from, err := zoneRecords(current)
+	if err != nil {
+		return err
+	}
+	to := setRecords(from, name, dns.StringToType[rrtype], rrs)
+
+	changes := diffZones(from, to)
+	renderChanges(io.Out, io.ColorScheme(), changes)
+	if len(changes) == 0 || flag.GetBool(ctx, "dry-run") {
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirm(ctx, fmt.Sprintf("Apply %d changes to %s?", len(changes), d.Name)); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	warnings, _, err := apiClient.ImportDNSRecords(ctx, d.ID, formatZone(d.Name, to))
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(io.ErrOut, "Warning:", warning.Action, warning.Message)
+	}
+
+	fmt.Fprintf(io.Out, "Applied %d changes to %s\n", len(changes), d.Name)
+	return nil
+}
+
+// recordsToSet returns the type and values of the records to set from args
+// and flags. With --cert-validation, the first value is the name of the
+// validation record.
+func recordsToSet(ctx context.Context, name string, args []string) (rrtype string, values []string, err error) {
+	var (
+		apiClient = client.FromContext(ctx).API()
+		appName   = flag.GetString(ctx, "app")
+	)
+
+	if len(args) > 0 {
+		rrtype = strings.ToUpper(args[0])
+		values = args[1:]
+	}
+
+	switch {
+	case flag.GetBool(ctx, "cert-validation"):
+		if appName == "" {
+			return "", nil, errors.New("--cert-validation requires --app")
+		}
+		if rrtype != "" && rrtype != "CNAME" {
+			return "", nil, errors.New("validation records are CNAME records")
+		}
+		cert, _, err := apiClient.CheckAppCertificate(ctx, appName, strings.TrimSuffix(name, "."))
+		if err != nil {
+			return "", nil, fmt.Errorf("failed getting the certificate of %s for %s, add it with fly certs add: %w", appName, name, err)
+		}
+		if flag.GetBool(ctx, "delete") {
+			return "CNAME", []string{dns.Fqdn(cert.DNSValidationHostname)}, nil
+		}
+		return "CNAME", []string{dns.Fqdn(cert.DNSValidationHostname), dns.Fqdn(cert.DNSValidationTarget)}, nil
+	case rrtype == "":
+		return "", nil, errors.New("the type of the records is required")
+	case dns.StringToType[rrtype] == 0:
+		return "", nil, fmt.Errorf("unknown record type %s", rrtype)
+	case flag.GetBool(ctx, "delete"):
+		return rrtype, nil, nil
+	case appName != "":
+		if len(values) > 0 {
+			return "", nil, errors.New("records point at either --app or the given values")
+		}
+		values, err = appValues(ctx, appName, rrtype)
+		return rrtype, values, err
+	case len(values) == 0:
+		return "", nil, errors.New("no value given, pass --delete to remove the records")
+	}
+	return rrtype, values, nil
+}
+
+// appValues returns the values of records of rrtype pointing at appName.
+func appValues(ctx context.Context, appName, rrtype string) ([]string, error) {
+	if rrtype == "CNAME" {
+		return []string{appName + ".fly.dev."}, nil
+	}
+
+	addresses, err := client.FromContext(ctx).API().GetIPAddresses(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	values := ipValues(addresses, rrtype)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s has no public address for %s records, allocate one with fly ips allocate", appName, rrtype)
+	}
+	return values, nil
+}
+
+func ipValues(addresses []api.IPAddress, rrtype string) (values []string) {
+	for _, a := range addresses {
+		switch {
+		case rrtype == "A" && (a.Type == "v4" || a.Type == "shared_v4"):
+			values = append(values, a.Address)
+		case rrtype == "AAAA" && a.Type == "v6":
+			values = append(values, a.Address)
+		case rrtype != "A" && rrtype != "AAAA":
+			return nil
+		}
+	}
+	return values
+}
@@ -0,0 +1,167 @@
+package dnsrecords
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// zoneChange is a change of a record between two versions of a zone.
+type zoneChange struct {
+	Action string
+	Old    dns.RR
+	New    dns.RR
+}
+
+// qualify returns the fully qualified name of name within domain, where @ is
+// the apex.
+func qualify(name, domain string) string {
+	name, domain = strings.ToLower(strings.TrimSpace(name)), strings.ToLower(strings.TrimSuffix(domain, "."))
+	switch {
+	case name == "" || name == "@":
+		return dns.Fqdn(domain)
+	case strings.HasSuffix(name, "."):
+		return name
+	case name == domain || strings.HasSuffix(name, "."+domain):
+		return dns.Fqdn(name)
+	default:
+		return dns.Fqdn(name + "." + domain)
+	}
+}
+
+// parseZone parses the records of a zone file of domain. SOA records, which
+// Fly DNS manages, are left out.
+func parseZone(domain, contents string) ([]dns.RR, error) {
+	zp := dns.NewZoneParser(strings.NewReader(contents), dns.Fqdn(domain), "")
+
+	var records []dns.RR
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			continue
+		}
+		records = append(records, rr)
+	}
+	if err := zp.Err(); err != nil {
+		return nil, fmt.Errorf("invalid zone file: %w", err)
+	}
+	return records, nil
+}
+
+// zoneRecords returns the records of a domain as resource records, leaving
+// out the system ones Fly DNS manages.
+func zoneRecords(records []*api.DNSRecord) ([]dns.RR, error) {
+	var rrs []dns.RR
+	for _, r := range records {
+		if r.IsSystem || r.Type == "SOA" {
+			continue
+		}
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(r.FQDN), r.TTL, r.Type, r.RData))
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing record %s %s: %w", r.FQDN, r.Type, err)
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs, nil
+}
+
+// formatZone returns the zone file of domain with records.
+func formatZone(domain string, records []dns.RR) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ORIGIN %s\n", dns.Fqdn(domain))
+	for _, rr := range records {
+		b.WriteString(rr.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// rdata returns the data of rr, without its header.
+func rdata(rr dns.RR) string {
+	return strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
+
+func recordKey(rr dns.RR) string {
+	h := rr.Header()
+	return strings.ToLower(h.Name) + " " + dns.TypeToString[h.Rrtype] + " " + rdata(rr)
+}
+
+// diffZones returns the changes turning the records of from into the ones
+// of to, records changing only their TTL being updated.
+func diffZones(from, to []dns.RR) []zoneChange {
+	old := map[string]dns.RR{}
+	for _, rr := range from {
+		old[recordKey(rr)] = rr
+	}
+
+	var changes []zoneChange
+	seen := map[string]bool{}
+	for _, rr := range to {
+		key := recordKey(rr)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch o, ok := old[key]; {
+		case !ok:
+			changes = append(changes, zoneChange{Action: "CREATE", New: rr})
+		case o.Header().Ttl != rr.Header().Ttl:
+			changes = append(changes, zoneChange{Action: "UPDATE", Old: o, New: rr})
+		}
+	}
+	for _, rr := range from {
+		if key := recordKey(rr); !seen[key] {
+			seen[key] = true
+			changes = append(changes, zoneChange{Action: "DELETE", Old: rr})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changeName(changes[i]) < changeName(changes[j])
+	})
+	return changes
+}
+
+func changeName(c zoneChange) string {
+	if c.New != nil {
+		return c.New.Header().Name
+	}
+	return c.Old.Header().Name
+}
+
+// setRecords replaces the records of type rrtype named name in records with
+// rrs, removing them when rrs is empty.
+func setRecords(records []dns.RR, name string, rrtype uint16, rrs []dns.RR) []dns.RR {
+	var result []dns.RR
+	for _, rr := range records {
+		h := rr.Header()
+		if strings.EqualFold(h.Name, name) && h.Rrtype == rrtype {
+			continue
+		}
+		result = append(result, rr)
+	}
+	return append(result, rrs...)
+}
+
+func renderChanges(w io.Writer, colorize *iostreams.ColorScheme, changes []zoneChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes")
+		return
+	}
+	for _, c := range changes {
+		switch c.Action {
+		case "CREATE":
+			fmt.Fprintln(w, colorize.Green("+ "+c.New.String()))
+		case "DELETE":
+			fmt.Fprintln(w, colorize.Red("- "+c.Old.String()))
+		case "UPDATE":
+			fmt.Fprintln(w, colorize.Yellow("~ "+c.Old.String()+" => "+c.New.String()))
+		}
+	}
+}
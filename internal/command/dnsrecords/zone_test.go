@@ -0,0 +1,90 @@
+package dnsrecords
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestQualify(t *testing.T) {
+	assert.Equal(t, "example.com.", qualify("@", "example.com"))
+	assert.Equal(t, "example.com.", qualify("", "example.com."))
+	assert.Equal(t, "www.example.com.", qualify("www", "example.com"))
+	assert.Equal(t, "www.example.com.", qualify("WWW.example.com", "example.com"))
+	assert.Equal(t, "other.net.", qualify("other.net.", "example.com"))
+}
+
+func TestDiffZones(t *testing.T) {
+	from, err := zoneRecords([]*api.DNSRecord{
+		{FQDN: "example.com", Type: "SOA", TTL: 3600, RData: "ns1.fly-dns.com. hostmaster.fly.io. 1 7200 3600 1209600 3600"},
+		{FQDN: "example.com", Type: "NS", TTL: 3600, RData: "ns1.fly-dns.com.", IsSystem: true},
+		{FQDN: "example.com", Type: "A", TTL: 300, RData: "1.2.3.4"},
+		{FQDN: "www.example.com", Type: "CNAME", TTL: 300, RData: "old.fly.dev."},
+		{FQDN: "example.com", Type: "MX", TTL: 300, RData: "10 mail.example.com."},
+	})
+	require.NoError(t, err)
+	require.Len(t, from, 3)
+
+	to, err := parseZone("example.com", `
+$TTL 300
+@      IN A     1.2.3.4
+www    IN CNAME new.fly.dev.
+@ 3600 IN MX    10 mail.example.com.
+@      IN SOA   ns1.fly-dns.com. hostmaster.fly.io. 2 7200 3600 1209600 3600
+`)
+	require.NoError(t, err)
+	require.Len(t, to, 3)
+
+	changes := diffZones(from, to)
+	require.Len(t, changes, 3)
+	actions := map[string]int{}
+	for _, c := range changes {
+		actions[c.Action]++
+	}
+	assert.Equal(t, map[string]int{"CREATE": 1, "DELETE": 1, "UPDATE": 1}, actions)
+
+	assert.Empty(t, diffZones(to, to))
+
+	_, err = parseZone("example.com", "www IN CNAME")
+	assert.Error(t, err)
+}
+
+func TestSetRecords(t *testing.T) {
+	records, err := parseZone("example.com", `
+@   300 IN A    1.2.3.4
+@   300 IN AAAA ::1
+www 300 IN A    1.2.3.4
+`)
+	require.NoError(t, err)
+
+	rr, err := dns.NewRR("example.com. 300 IN A 5.6.7.8")
+	require.NoError(t, err)
+
+	updated := setRecords(records, "example.com.", dns.TypeA, []dns.RR{rr})
+	require.Len(t, updated, 3)
+	changes := diffZones(records, updated)
+	require.Len(t, changes, 2)
+
+	deleted := setRecords(records, "EXAMPLE.com.", dns.TypeAAAA, nil)
+	assert.Len(t, deleted, 2)
+
+	zone, err := parseZone("example.com", formatZone("example.com", updated))
+	require.NoError(t, err)
+	assert.Empty(t, diffZones(updated, zone))
+}
+
+func TestIPValues(t *testing.T) {
+	addresses := []api.IPAddress{
+		{Type: "v4", Address: "1.2.3.4"},
+		{Type: "shared_v4", Address: "66.241.1.1"},
+		{Type: "v6", Address: "2a09::1"},
+		{Type: "private_v6", Address: "fdaa::1"},
+	}
+	assert.Equal(t, []string{"1.2.3.4", "66.241.1.1"}, ipValues(addresses, "A"))
+	assert.Equal(t, []string{"2a09::1"}, ipValues(addresses, "AAAA"))
+	assert.Empty(t, ipValues(addresses, "TXT"))
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/command"
-	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/state"
 	"github.com/superfly/flyctl/iostreams"
@@ -183,8 +182,7 @@ func grepv(r io.Reader, excludes []string) *bytes.Buffer {
 }
 
 func fetchConfigYaml(ctx context.Context, z *zip.Writer) error {
-	path := filepath.Join(state.ConfigDirectory(ctx), config.FileName)
-	f, err := os.Open(path)
+	f, err := os.Open(state.ConfigFile(ctx))
 	if err != nil {
 		return err
 	}
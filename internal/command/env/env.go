@@ -0,0 +1,145 @@
+// Package env implements the env command chain.
+package env
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+var sharedFlags = flag.Set{
+	flag.App(),
+	flag.AppConfig(),
+	flag.Detach(),
+	flag.Bool{
+		Name:        "stage",
+		Description: "Only update the [env] section of fly.toml, skipping deployment",
+	},
+}
+
+// New initializes and returns a new env Command.
+func New() *cobra.Command {
+	const (
+		long = `Manage the environment variables of an application's machines.
+
+Environment variables are plain-text configuration stored in the [env]
+section of fly.toml and with every release, so their changes show up when
+comparing releases. Use secrets for sensitive values instead.
+
+Setting or unsetting variables updates the local fly.toml, when it belongs to
+the app, and the app's machines with the currently deployed image.`
+		short = "Manage application environment variables"
+	)
+
+	cmd := command.New("env", short, long, nil)
+
+	cmd.AddCommand(
+		newList(),
+		newSet(),
+		newUnset(),
+	)
+
+	return cmd
+}
+
+// updateEnvAndDeploy applies update to the env of the local fly.toml and of
+// the deployed app config, then updates the app's machines with the latter.
+// update reports whether it changed the env it was given.
+func updateEnvAndDeploy(ctx context.Context, update func(env map[string]string) bool) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		stage     = flag.GetBool(ctx, "stage")
+		detach    = flag.GetDetach(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("app %s uses an earlier version of the platform, set environment variables in fly.toml and deploy instead", appName)
+	}
+
+	if local := appconfig.ConfigFromContext(ctx); local != nil && local.AppName == appName && local.ConfigFilePath() != "" {
+		if local.Env == nil {
+			local.Env = map[string]string{}
+		}
+		if update(local.Env) {
+			if err := local.WriteToDisk(ctx, local.ConfigFilePath()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if stage {
+		fmt.Fprintln(io.Out, "Environment variables have been staged in fly.toml, deploy for them to take effect.")
+		return nil
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return fmt.Errorf("could not create flaps client: %w", err)
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	release, err := apiClient.GetAppCurrentReleaseMachines(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if release == nil || release.ImageRef == "" {
+		fmt.Fprintln(io.Out, "Environment variables are staged for the first deployment")
+		return nil
+	}
+
+	// Always start from the deployed config so unrelated changes of the
+	// local fly.toml aren't deployed along
+	cfg, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("error loading app config: %w", err)
+	}
+	if cfg.Env == nil {
+		cfg.Env = map[string]string{}
+	}
+	if !update(cfg.Env) {
+		fmt.Fprintln(io.Out, "Environment variables are already up to date")
+		return nil
+	}
+	ctx = appconfig.WithConfig(ctx, cfg)
+
+	return deployEnv(ctx, app, cfg, release, detach)
+}
+
+func deployEnv(ctx context.Context, app *api.AppCompact, cfg *appconfig.Config, release *api.Release, detach bool) error {
+	md, err := deploy.NewMachineDeployment(ctx, deploy.MachineDeploymentArgs{
+		AppCompact:         app,
+		DeploymentImage:    release.ImageRef,
+		PrimaryRegionFlag:  cfg.PrimaryRegion,
+		SkipSmokeChecks:    detach,
+		SkipSmokeTests:     detach,
+		SkipHealthChecks:   detach,
+		SkipReleaseCommand: true,
+	})
+	if err != nil {
+		sentry.CaptureExceptionWithAppInfo(err, "env", app)
+		return err
+	}
+
+	err = md.DeployMachinesApp(ctx)
+	if err != nil {
+		sentry.CaptureExceptionWithAppInfo(err, "env", app)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package env
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+func newSet() (cmd *cobra.Command) {
+	const (
+		long  = `Set one or more environment variables for an application`
+		short = long
+		usage = "set [flags] NAME=VALUE NAME=VALUE ..."
+	)
+
+	cmd = command.New(usage, short, long, runSet,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	flag.Add(cmd, sharedFlags)
+
+	return cmd
+}
+
+func runSet(ctx context.Context) error {
+	vars, err := cmdutil.ParseKVStringsToMap(flag.Args(ctx))
+	if err != nil {
+		return fmt.Errorf("could not parse environment variables: %w", err)
+	}
+
+	return updateEnvAndDeploy(ctx, func(env map[string]string) bool {
+		changed := false
+		for k, v := range vars {
+			if cur, ok := env[k]; !ok || cur != v {
+				env[k] = v
+				changed = true
+			}
+		}
+		return changed
+	})
+}
@@ -0,0 +1,44 @@
+package env
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+func newUnset() (cmd *cobra.Command) {
+	const (
+		long  = `Unset one or more environment variables for an application`
+		short = long
+		usage = "unset [flags] NAME NAME ..."
+	)
+
+	cmd = command.New(usage, short, long, runUnset,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	flag.Add(cmd, sharedFlags)
+
+	return cmd
+}
+
+func runUnset(ctx context.Context) error {
+	names := flag.Args(ctx)
+
+	return updateEnvAndDeploy(ctx, func(env map[string]string) bool {
+		changed := false
+		for _, name := range names {
+			if _, ok := env[name]; ok {
+				delete(env, name)
+				changed = true
+			}
+		}
+		return changed
+	})
+}
@@ -0,0 +1,209 @@
+package flycast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/apps"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newAttach() *cobra.Command {
+	const (
+		long = `Expose a port of the machines of an app on its Flycast addresses, adding a
+service forwarding it to --internal-port. Machines are updated one at a time,
+each waiting for the previous one to pass its health checks.
+
+Services are exposed on all the addresses of an app, so apps with public
+addresses expose the port publicly too: release them with fly ips release to
+keep an app private. Deploys replace the services of machines with the ones
+of fly.toml, so add the printed service to it too.`
+		short = "Expose a port on the Flycast addresses of an app"
+	)
+
+	cmd := command.New("attach", short, long, runAttach,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly flycast attach --port 80 --internal-port 8080 --handlers http`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.String{
+			Name:        "process-group",
+			Description: "Only expose the port on the machines of this process group",
+		},
+		flag.Int{
+			Name:        "port",
+			Description: "Port to expose on the Flycast addresses",
+		},
+		flag.Int{
+			Name:        "internal-port",
+			Description: "Port the machines listen on, --port by default",
+		},
+		flag.StringSlice{
+			Name:        "handlers",
+			Description: "Handlers of the port, such as http, none for raw TCP",
+		},
+	)
+
+	return cmd
+}
+
+func runAttach(ctx context.Context) error {
+	var (
+		io           = iostreams.FromContext(ctx)
+		appName      = appconfig.NameFromContext(ctx)
+		port         = flag.GetInt(ctx, "port")
+		internalPort = flag.GetInt(ctx, "internal-port")
+		handlers     = flag.GetStringSlice(ctx, "handlers")
+		group        = flag.GetString(ctx, "process-group")
+	)
+
+	if port <= 0 || port > 65535 {
+		return errors.New("--port must be a port number")
+	}
+	if internalPort == 0 {
+		internalPort = port
+	}
+
+	addresses, err := flycastAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		fmt.Fprintf(io.ErrOut, "Warning: %s has no Flycast address yet, allocate one with fly flycast allocate\n", appName)
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if ctx, err = apps.BuildContext(ctx, app); err != nil {
+		return err
+	}
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		machines = lo.Filter(machines, func(m *api.Machine, _ int) bool { return m.ProcessGroup() == group })
+	}
+
+	var pending []*api.Machine
+	for _, m := range machines {
+		if exposesPort(m.Config, port) {
+			fmt.Fprintf(io.Out, "Machine %s already exposes port %d\n", m.ID, port)
+			continue
+		}
+		pending = append(pending, m)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Update %d machines of %s to expose port %d, restarting them one at a time?", len(pending), appName, port)
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	for _, m := range pending {
+		if err := attachMachine(ctx, m, port, internalPort, handlers); err != nil {
+			return err
+		}
+	}
+
+	printServiceTOML(io.Out, port, internalPort, handlers)
+	return nil
+}
+
+func attachMachine(ctx context.Context, m *api.Machine, port, internalPort int, handlers []string) error {
+	m, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(m.Config)
+	config.Services = append(config.Services, flycastService(port, internalPort, handlers))
+
+	return mach.Update(ctx, m, &api.LaunchMachineInput{
+		ID:     m.ID,
+		Region: m.Region,
+		Config: config,
+	})
+}
+
+// exposesPort reports whether a service of config exposes port.
+func exposesPort(config *api.MachineConfig, port int) bool {
+	if config == nil {
+		return false
+	}
+	for _, s := range config.Services {
+		for _, p := range s.Ports {
+			if p.Port != nil && *p.Port == port {
+				return true
+			}
+			if p.StartPort != nil && p.EndPort != nil && *p.StartPort <= port && port <= *p.EndPort {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flycastService returns the service exposing port, forwarded to
+// internalPort.
+func flycastService(port, internalPort int, handlers []string) api.MachineService {
+	return api.MachineService{
+		Protocol:     "tcp",
+		InternalPort: internalPort,
+		Ports: []api.MachinePort{{
+			Port:     api.Pointer(port),
+			Handlers: handlers,
+		}},
+	}
+}
+
+func printServiceTOML(w io.Writer, port, internalPort int, handlers []string) {
+	quoted := lo.Map(handlers, func(h string, _ int) string { return fmt.Sprintf("%q", h) })
+
+	fmt.Fprintf(w, `
+Add the service to fly.toml, as deploys replace the services of machines:
+
+[[services]]
+  protocol = "tcp"
+  internal_port = %d
+
+  [[services.ports]]
+    port = %d
+    handlers = [%s]
+`, internalPort, port, strings.Join(quoted, ", "))
+}
@@ -0,0 +1,215 @@
+// Package flycast implements the flycast command chain.
+package flycast
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/format"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// addressType is the type of Flycast addresses, private IPv6 addresses the
+// proxy load balances.
+const addressType = "private_v6"
+
+// New initializes and returns a new flycast Command.
+func New() *cobra.Command {
+	const (
+		long = `Flycast addresses are private IPv6 addresses of apps, reachable from the
+private network of their organization only, through the Fly.io proxy: it load
+balances connections between the machines of the app, and starts and stops
+them as their services configure, as it does for public traffic.
+
+Apps reach the Flycast addresses of each other on <app>.flycast. Connections
+are routed to the services of the app exposing the port they're made to, which
+fly flycast attach adds.`
+		short = "Manage Flycast private load balancing"
+	)
+
+	cmd := command.New("flycast", short, long, nil)
+
+	cmd.AddCommand(
+		newAllocate(),
+		newList(),
+		newRemove(),
+		newAttach(),
+		newTest(),
+	)
+
+	return cmd
+}
+
+func newAllocate() *cobra.Command {
+	const (
+		long = `Allocate a Flycast address to an app. With --org, the address is allocated in
+the private network of another organization, exposing the app to its apps.`
+		short = "Allocate a Flycast address"
+	)
+
+	cmd := command.New("allocate", short, long, runAllocate,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.Org(),
+		flag.String{
+			Name:        "network",
+			Description: "Custom private network to allocate the address in",
+		},
+	)
+
+	return cmd
+}
+
+func newList() *cobra.Command {
+	const (
+		long  = `List the Flycast addresses of an app.`
+		short = "List Flycast addresses"
+	)
+
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"ls"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newRemove() *cobra.Command {
+	const (
+		long  = `Release a Flycast address of an app.`
+		short = "Remove a Flycast address"
+		usage = "remove <address>"
+	)
+
+	cmd := command.New(usage, short, long, runRemove,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Aliases = []string{"rm", "release"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runAllocate(ctx context.Context) error {
+	var (
+		out       = iostreams.FromContext(ctx).Out
+		appName   = appconfig.NameFromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	var org *api.Organization
+	if slug := flag.GetOrg(ctx); slug != "" {
+		var err error
+		if org, err = orgs.OrgFromSlug(ctx, slug); err != nil {
+			return err
+		}
+	}
+
+	address, err := apiClient.AllocateIPAddress(ctx, appName, addressType, "", org, flag.GetString(ctx, "network"))
+	if err != nil {
+		return fmt.Errorf("failed allocating a Flycast address: %w", err)
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, address)
+	}
+
+	fmt.Fprintf(out, "Allocated Flycast address %s to %s\n", address.Address, appName)
+	fmt.Fprintf(out, "Apps of its private network reach it on %s.flycast, once services expose ports with fly flycast attach\n", appName)
+	return nil
+}
+
+func runList(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	addresses, err := flycastAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, addresses)
+	}
+
+	rows := make([][]string, 0, len(addresses))
+	for _, a := range addresses {
+		rows = append(rows, []string{a.Address, appName + ".flycast", format.RelativeTime(a.CreatedAt)})
+	}
+
+	return render.Table(out, "", rows, "Address", "Hostname", "Created At")
+}
+
+func runRemove(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		appName = appconfig.NameFromContext(ctx)
+		address = flag.FirstArg(ctx)
+	)
+
+	if net.ParseIP(address) == nil {
+		return fmt.Errorf("invalid IP address %s", address)
+	}
+
+	addresses, err := flycastAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if !lo.ContainsBy(addresses, func(a api.IPAddress) bool { return net.ParseIP(a.Address).Equal(net.ParseIP(address)) }) {
+		return fmt.Errorf("%s isn't a Flycast address of %s", address, appName)
+	}
+
+	if err := client.FromContext(ctx).API().ReleaseIPAddress(ctx, appName, address); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Removed Flycast address %s from %s\n", address, appName)
+	return nil
+}
+
+// flycastAddresses returns the Flycast addresses of appName.
+func flycastAddresses(ctx context.Context, appName string) ([]api.IPAddress, error) {
+	addresses, err := client.FromContext(ctx).API().GetIPAddresses(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(addresses, func(a api.IPAddress, _ int) bool { return a.Type == addressType }), nil
+}
@@ -0,0 +1,57 @@
+package flycast
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestExposesPort(t *testing.T) {
+	config := &api.MachineConfig{Services: []api.MachineService{
+		flycastService(80, 8080, []string{"http"}),
+		{Protocol: "tcp", InternalPort: 9000, Ports: []api.MachinePort{{StartPort: api.Pointer(9000), EndPort: api.Pointer(9010)}}},
+	}}
+
+	assert.True(t, exposesPort(config, 80))
+	assert.True(t, exposesPort(config, 9005))
+	assert.False(t, exposesPort(config, 8080))
+	assert.False(t, exposesPort(nil, 80))
+}
+
+func TestPrintServiceTOML(t *testing.T) {
+	var out bytes.Buffer
+	printServiceTOML(&out, 80, 8080, []string{"http"})
+
+	assert.Contains(t, out.String(), "internal_port = 8080")
+	assert.Contains(t, out.String(), "port = 80")
+	assert.Contains(t, out.String(), `handlers = ["http"]`)
+}
+
+func TestProbeScript(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh isn't installed")
+	}
+
+	for _, http := range []bool{false, true} {
+		out, err := exec.Command(sh, "-n", "-c", probeScript("my-api.flycast", 80, http)).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func TestParseProbeOutput(t *testing.T) {
+	result := parseProbeOutput("resolve fdaa:0:1:0::3 \nconnect ok\nhttp 204\n")
+	assert.Equal(t, []string{"fdaa:0:1:0::3"}, result.Resolved)
+	assert.True(t, result.Connected)
+	assert.Equal(t, 204, result.HTTPStatus)
+
+	result = parseProbeOutput("resolve \nerror connection failed\n")
+	assert.Empty(t, result.Resolved)
+	assert.False(t, result.Connected)
+	assert.Equal(t, "connection failed", result.Error)
+}
@@ -0,0 +1,242 @@
+package flycast
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// probeTimeout is the time in seconds probes wait to connect.
+const probeTimeout = 10
+
+func newTest() *cobra.Command {
+	const (
+		long = `Test an app is reachable on its Flycast hostname from a machine of another
+app, --from, resolving <app>.flycast and connecting to --port there. With
+--http, requests / instead and reports the status of the response.
+
+The test runs in the machine with fly machine exec, so the image of the
+machine needs nc or bash to test TCP connections, and curl to test HTTP.`
+		short = "Test an app is reachable on Flycast from another app"
+	)
+
+	cmd := command.New("test", short, long, runTest,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly flycast test --app my-api --from my-frontend --port 80 --http`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "from",
+			Description: "App to test from, the first started machine of which runs the test",
+		},
+		flag.String{
+			Name:        "machine",
+			Description: "Machine of --from to test from",
+		},
+		flag.Int{
+			Name:        "port",
+			Description: "Port to connect to",
+			Default:     80,
+		},
+		flag.Bool{
+			Name:        "http",
+			Description: "Request / over HTTP rather than only connecting",
+		},
+	)
+
+	return cmd
+}
+
+// probeResult is the outcome of a Flycast reachability test.
+type probeResult struct {
+	From       string   `json:"from"`
+	Machine    string   `json:"machine"`
+	Target     string   `json:"target"`
+	Resolved   []string `json:"resolved"`
+	Connected  bool     `json:"connected"`
+	HTTPStatus int      `json:"http_status,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func runTest(ctx context.Context) error {
+	var (
+		out       = iostreams.FromContext(ctx).Out
+		appName   = appconfig.NameFromContext(ctx)
+		fromName  = flag.GetString(ctx, "from")
+		port      = flag.GetInt(ctx, "port")
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	if fromName == "" {
+		return errors.New("--from is required, the app to test from")
+	}
+
+	target, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	from, err := apiClient.GetAppCompact(ctx, fromName)
+	if err != nil {
+		return err
+	}
+
+	addresses, err := flycastAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("%s has no Flycast address, allocate one with fly flycast allocate", appName)
+	}
+	if from.Organization.Slug != target.Organization.Slug {
+		fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Warning: %s belongs to another organization than %s, it reaches it only if a Flycast address was allocated in its organization with --org\n", fromName, appName)
+	}
+
+	flapsClient, err := flaps.New(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	machine, err := probeMachine(ctx, flapsClient, flag.GetString(ctx, "machine"))
+	if err != nil {
+		return err
+	}
+
+	host := appName + ".flycast"
+	script := probeScript(host, port, flag.GetBool(ctx, "http"))
+	resp, err := flapsClient.Exec(ctx, machine.ID, &api.MachineExecRequest{
+		Cmd:     fmt.Sprintf(`sh -c "echo %s | base64 -d | sh"`, base64.StdEncoding.EncodeToString([]byte(script))),
+		Timeout: probeTimeout + 5,
+	})
+	if err != nil {
+		return fmt.Errorf("failed running the test in machine %s: %w", machine.ID, err)
+	}
+
+	result := parseProbeOutput(resp.StdOut)
+	result.From, result.Machine, result.Target = fromName, machine.ID, fmt.Sprintf("%s:%d", host, port)
+	if result.Error == "" && resp.ExitCode != 0 {
+		result.Error = strings.TrimSpace(resp.StdErr)
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(out, result); err != nil {
+			return err
+		}
+	} else {
+		renderProbe(ctx, result)
+	}
+
+	if !result.Connected {
+		return fmt.Errorf("%s isn't reachable from %s", result.Target, fromName)
+	}
+	return nil
+}
+
+func probeMachine(ctx context.Context, flapsClient *flaps.Client, id string) (*api.Machine, error) {
+	if id != "" {
+		return flapsClient.Get(ctx, id)
+	}
+
+	machines, err := flapsClient.List(ctx, api.MachineStateStarted)
+	if err != nil {
+		return nil, err
+	}
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool { return !m.IsFlyAppsReleaseCommand() })
+	if len(machines) == 0 {
+		return nil, errors.New("no started machine to test from, start one or pass --machine")
+	}
+	return machines[0], nil
+}
+
+// probeScript returns the shell script resolving host and connecting to
+// port, or requesting it over HTTP with http. It prints a line per result:
+// resolve <addresses>, then connect ok or http <status>, or error <message>.
+func probeScript(host string, port int, http bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "host=%s\nport=%d\ntimeout=%d\n", host, port, probeTimeout)
+	b.WriteString(`echo "resolve $(getent hosts "$host" 2>/dev/null | awk '{print $1}' | tr '\n' ' ')"
+`)
+	if http {
+		b.WriteString(`command -v curl >/dev/null 2>&1 || { echo "error curl isn't installed in the machine"; exit 1; }
+code=$(curl -s -o /dev/null -m "$timeout" -w '%{http_code}' "http://$host:$port/")
+if [ "$code" = "000" ] || [ -z "$code" ]; then echo "error no HTTP response"; exit 1; fi
+echo "connect ok"
+echo "http $code"
+`)
+	} else {
+		b.WriteString(`if command -v nc >/dev/null 2>&1; then
+  nc -z -w "$timeout" "$host" "$port" || { echo "error connection failed"; exit 1; }
+elif command -v bash >/dev/null 2>&1; then
+  timeout "$timeout" bash -c "</dev/tcp/$host/$port" 2>/dev/null || { echo "error connection failed"; exit 1; }
+else
+  echo "error neither nc nor bash is installed in the machine, test with --http"; exit 1
+fi
+echo "connect ok"
+`)
+	}
+	return b.String()
+}
+
+func parseProbeOutput(stdout string) probeResult {
+	var result probeResult
+	for _, line := range strings.Split(stdout, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch key {
+		case "resolve":
+			result.Resolved = strings.Fields(value)
+		case "connect":
+			result.Connected = value == "ok"
+		case "http":
+			result.HTTPStatus, _ = strconv.Atoi(value)
+		case "error":
+			result.Error = value
+		}
+	}
+	return result
+}
+
+func renderProbe(ctx context.Context, r probeResult) {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+	)
+
+	resolved := strings.Join(r.Resolved, ", ")
+	if resolved == "" {
+		resolved = "nothing"
+	}
+	fmt.Fprintf(io.Out, "From machine %s of %s:\n", r.Machine, r.From)
+	fmt.Fprintf(io.Out, "  %s resolved to %s\n", r.Target, resolved)
+
+	switch {
+	case !r.Connected:
+		fmt.Fprintf(io.Out, "  %s %s\n", colorize.Red("✗"), r.Error)
+	case r.HTTPStatus != 0:
+		fmt.Fprintf(io.Out, "  %s responded with %d\n", colorize.Green("✓"), r.HTTPStatus)
+	default:
+		fmt.Fprintf(io.Out, "  %s connected\n", colorize.Green("✓"))
+	}
+}
@@ -0,0 +1,218 @@
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	defaultModelPath       = "/models"
+	defaultDownloadImage   = "alpine:3.17"
+	defaultDownloadTimeout = time.Hour
+)
+
+func newAttachModel() *cobra.Command {
+	const (
+		short = "Download model weights to a volume"
+		long  = `Download model weights from a URL to a volume, using a temporary machine
+that mounts the volume, fetches the file and is destroyed once it's done.
+
+Mount the same volume on a GPU machine, e.g. with
+` + "`fly machine run --vm-gpu-kind a100-pcie-40gb --volume <volume>:/models`" + `,
+to load the model from disk instead of downloading it on every boot.`
+		usage = "attach-model <url>"
+	)
+
+	cmd := command.New(usage, short, long, runAttachModel,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "volume",
+			Shorthand:   "v",
+			Description: "Name or ID of the volume to download the model to",
+		},
+		flag.String{
+			Name:        "path",
+			Description: "Path the volume is mounted at on the temporary machine",
+			Default:     defaultModelPath,
+		},
+		flag.String{
+			Name:        "file-name",
+			Description: "Name of the file on the volume, defaults to the last element of the URL path",
+		},
+		flag.Duration{
+			Name:        "timeout",
+			Description: "How long to wait for the download to finish",
+			Default:     defaultDownloadTimeout,
+		},
+	)
+
+	return cmd
+}
+
+func runAttachModel(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		colorize  = io.ColorScheme()
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		modelURL  = flag.FirstArg(ctx)
+		mountPath = flag.GetString(ctx, "path")
+		volName   = flag.GetString(ctx, "volume")
+	)
+
+	if volName == "" {
+		return errors.New("--volume is required")
+	}
+
+	fileName, err := modelFileName(modelURL, flag.GetString(ctx, "file-name"))
+	if err != nil {
+		return err
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	volumes, err := apiClient.GetVolumes(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving volumes: %w", err)
+	}
+
+	var volume *api.Volume
+	for i, v := range volumes {
+		if v.ID == volName || v.Name == volName {
+			volume = &volumes[i]
+			break
+		}
+	}
+	switch {
+	case volume == nil:
+		return fmt.Errorf("volume %s not found in app %s", volName, appName)
+	case volume.IsAttached():
+		return fmt.Errorf("volume %s is attached to a machine; detach it before downloading a model to it", volume.ID)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	dest := path.Join(mountPath, fileName)
+	machineConfig := &api.MachineConfig{
+		Image: defaultDownloadImage,
+		Init: api.MachineInit{
+			Entrypoint: []string{"/bin/sh", "-c"},
+			Cmd:        []string{downloadScript(modelURL, dest)},
+		},
+		Guest: helpers.Clone(api.MachinePresets["shared-cpu-1x"]),
+		Mounts: []api.MachineMount{{
+			Volume: volume.ID,
+			Path:   mountPath,
+		}},
+		DNS: &api.DNSConfig{
+			SkipRegistration: true,
+		},
+		Restart: api.MachineRestart{
+			Policy: api.MachineRestartPolicyNo,
+		},
+	}
+
+	machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Region: volume.Region,
+		Config: machineConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching download machine: %w", err)
+	}
+	defer func() {
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: machine.ID, Kill: true}, machine.LeaseNonce); err != nil {
+			fmt.Fprintf(io.ErrOut, "Failed to destroy download machine %s: %v\n", machine.ID, err)
+		}
+	}()
+
+	fmt.Fprintf(io.Out, "Downloading %s to %s on volume %s using machine %s\n",
+		modelURL, dest, colorize.Bold(volume.ID), colorize.Bold(machine.ID))
+
+	if err := mach.WaitForStartOrStop(ctx, machine, "stop", flag.GetDuration(ctx, "timeout")); err != nil {
+		return fmt.Errorf("failed waiting for download to finish: %w", err)
+	}
+
+	machine, err = flapsClient.Get(ctx, machine.ID)
+	if err != nil {
+		return fmt.Errorf("failed retrieving download machine: %w", err)
+	}
+
+	for _, event := range machine.Events {
+		if event.Type != "exit" || event.Request == nil {
+			continue
+		}
+		exitCode, err := event.Request.GetExitCode()
+		if err != nil {
+			break
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("download failed with exit code %d, run `fly logs -i %s` for details", exitCode, machine.ID)
+		}
+		fmt.Fprintf(io.Out, "Model downloaded to %s\n", colorize.Green(dest))
+		return nil
+	}
+
+	return fmt.Errorf("could not determine whether the download on machine %s succeeded", machine.ID)
+}
+
+// modelFileName returns fileName if set, or the last element of the path of
+// modelURL otherwise.
+func modelFileName(modelURL, fileName string) (string, error) {
+	u, err := url.Parse(modelURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid model url %s, expected an http or https url", modelURL)
+	}
+
+	if fileName == "" {
+		fileName = path.Base(u.Path)
+	}
+	if fileName == "" || fileName == "/" || fileName == "." {
+		return "", errors.New("could not determine a file name from the url, set one with --file-name")
+	}
+
+	return fileName, nil
+}
+
+// downloadScript returns the shell script the download machine runs, writing
+// to a temporary file first so a failed download doesn't leave a partial
+// model behind.
+func downloadScript(modelURL, dest string) string {
+	return fmt.Sprintf("set -e; mkdir -p %s; wget -q -O %s %s; mv %s %s",
+		shellQuote(path.Dir(dest)), shellQuote(dest+".partial"), shellQuote(modelURL), shellQuote(dest+".partial"), shellQuote(dest))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
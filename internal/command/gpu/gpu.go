@@ -0,0 +1,31 @@
+// Package gpu implements the gpu command chain.
+package gpu
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// New initializes and returns a new gpu Command.
+func New() *cobra.Command {
+	const (
+		short = "Commands for working with GPU machines"
+		long  = `Commands for working with GPU machines: list the GPU kinds available in
+each region and load model weights onto volumes for GPU machines to mount.
+
+GPU machines are created with ` + "`fly machine run --vm-gpu-kind`" + `.`
+		usage = "gpu <command>"
+	)
+
+	cmd := command.New(usage, short, long, nil)
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.AddCommand(
+		newKinds(),
+		newAttachModel(),
+	)
+
+	return cmd
+}
@@ -0,0 +1,75 @@
+package gpu
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newKinds() *cobra.Command {
+	const (
+		short = "List GPU kinds and the regions they're available in"
+		long  = short + "\n"
+		usage = "kinds"
+	)
+
+	cmd := command.New(usage, short, long, runKinds)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"list", "ls"}
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "region",
+			Shorthand:   "r",
+			Description: "Only list GPU kinds available in this region",
+		},
+	)
+
+	return cmd
+}
+
+type gpuKind struct {
+	Name    string   `json:"name"`
+	Regions []string `json:"regions"`
+}
+
+func runKinds(ctx context.Context) error {
+	var (
+		out    = iostreams.FromContext(ctx).Out
+		region = flag.GetString(ctx, "region")
+	)
+
+	names := lo.Keys(api.MachineGPUKinds)
+	sort.Strings(names)
+
+	kinds := []gpuKind{}
+	for _, name := range names {
+		regions := api.MachineGPUKinds[name]
+		if region != "" && !lo.Contains(regions, region) {
+			continue
+		}
+		kinds = append(kinds, gpuKind{Name: name, Regions: regions})
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, kinds)
+	}
+
+	rows := lo.Map(kinds, func(k gpuKind, _ int) []string {
+		return []string{k.Name, strings.Join(k.Regions, ", ")}
+	})
+
+	return render.Table(out, "", rows, "Kind", "Regions")
+}
@@ -0,0 +1,222 @@
+// Package graphql implements the graphql command.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	genq "github.com/Khan/genqlient/graphql"
+	"github.com/spf13/cobra"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new graphql Command.
+func New() *cobra.Command {
+	const (
+		short = "Run a raw GraphQL query against the Fly.io API"
+		long  = `Run a GraphQL query or mutation against the Fly.io API, authenticated with
+your token, and print the response. Meant for reproducing issues and for API
+features flyctl doesn't wrap yet; the schema is not a stable public API and
+may change without notice.
+
+The query is given inline, or read from a file with --file (- for stdin).
+Variables are set with --field NAME=VALUE, where values are parsed as JSON
+when possible (numbers, booleans, null, objects) and taken as strings
+otherwise, and @path reads the value from a file. --raw-field always sets
+strings.
+
+Mutations change the state of your account and ask for confirmation unless
+--yes is passed.`
+		usage = "graphql [query]"
+	)
+
+	cmd := command.New(usage, short, long, run,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+	cmd.Example = `  fly graphql '{ viewer { email } }'
+  fly graphql -f query.graphql -F appName=my-app -F first=10`
+
+	flag.Add(cmd,
+		flag.Yes(),
+		flag.String{
+			Name:        "file",
+			Shorthand:   "f",
+			Description: "Read the query from this file, - for stdin",
+		},
+		flag.StringArray{
+			Name:        "field",
+			Shorthand:   "F",
+			Description: "Set a variable as NAME=VALUE, parsing the value as JSON when possible. Can be specified multiple times.",
+		},
+		flag.StringArray{
+			Name:        "raw-field",
+			Description: "Set a string variable as NAME=VALUE. Can be specified multiple times.",
+		},
+		flag.String{
+			Name:        "operation-name",
+			Description: "Name of the operation to run when the query defines several",
+		},
+	)
+
+	return cmd
+}
+
+func run(ctx context.Context) error {
+	var (
+		io     = iostreams.FromContext(ctx)
+		opName = flag.GetString(ctx, "operation-name")
+	)
+
+	query, err := readQuery(io, flag.FirstArg(ctx), flag.GetString(ctx, "file"))
+	if err != nil {
+		return err
+	}
+
+	variables, err := parseVariables(flag.GetStringArray(ctx, "field"), flag.GetStringArray(ctx, "raw-field"))
+	if err != nil {
+		return err
+	}
+
+	operation, err := operationType(query, opName)
+	if err != nil {
+		return err
+	}
+
+	if operation == ast.Mutation && !flag.GetYes(ctx) {
+		fmt.Fprintln(io.ErrOut, io.ColorScheme().Yellow("This is a mutation, it will change the state of your account."))
+		switch confirmed, err := prompt.Confirm(ctx, "Run it?"); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when running mutations non-interactively")
+		default:
+			return err
+		}
+	}
+
+	var (
+		data json.RawMessage
+		req  = &genq.Request{Query: query, OpName: opName}
+		resp = &genq.Response{Data: &data}
+	)
+	if len(variables) > 0 {
+		req.Variables = variables
+	}
+
+	// GraphQL errors are printed along with the data rather than returned
+	err = client.FromContext(ctx).API().GenqClient.MakeRequest(ctx, req, resp)
+	if err != nil && len(resp.Errors) == 0 {
+		return err
+	}
+
+	out, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(io.Out, string(out))
+
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("query returned %d error(s)", len(resp.Errors))
+	}
+	return nil
+}
+
+func readQuery(streams *iostreams.IOStreams, inline, file string) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch {
+	case inline != "" && file != "":
+		return "", errors.New("pass the query either inline or with --file, not both")
+	case inline != "":
+		return inline, nil
+	case file == "-":
+		b, err = io.ReadAll(streams.In)
+	case file != "":
+		b, err = os.ReadFile(file)
+	default:
+		return "", errors.New("no query given, pass it inline or with --file")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed reading query: %w", err)
+	}
+	return string(b), nil
+}
+
+// parseVariables returns the variables set by --field, parsed as JSON when
+// possible, and by --raw-field, taken as strings.
+func parseVariables(fields, rawFields []string) (map[string]any, error) {
+	variables := map[string]any{}
+
+	for _, f := range fields {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid field %q, expected NAME=VALUE", f)
+		}
+		if strings.HasPrefix(value, "@") {
+			b, err := os.ReadFile(value[1:])
+			if err != nil {
+				return nil, fmt.Errorf("failed reading value of %s: %w", name, err)
+			}
+			variables[name] = string(b)
+			continue
+		}
+		var parsed any
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			variables[name] = parsed
+		} else {
+			variables[name] = value
+		}
+	}
+
+	for _, f := range rawFields {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid field %q, expected NAME=VALUE", f)
+		}
+		variables[name] = value
+	}
+
+	return variables, nil
+}
+
+// operationType returns the type of the operation of query that would run:
+// the one named opName, or the only one.
+func operationType(query, opName string) (ast.Operation, error) {
+	doc, gqlErr := parser.ParseQuery(&ast.Source{Input: query})
+	if gqlErr != nil {
+		return "", fmt.Errorf("invalid query: %w", gqlErr)
+	}
+
+	switch {
+	case opName != "":
+		op := doc.Operations.ForName(opName)
+		if op == nil {
+			return "", fmt.Errorf("query has no operation named %s", opName)
+		}
+		return op.Operation, nil
+	case len(doc.Operations) == 1:
+		return doc.Operations[0].Operation, nil
+	case len(doc.Operations) == 0:
+		return "", errors.New("query has no operation")
+	default:
+		return "", errors.New("query defines several operations, select one with --operation-name")
+	}
+}
@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestParseVariables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	require.NoError(t, os.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----"), 0o644))
+
+	variables, err := parseVariables(
+		[]string{"appName=my-app", "first=10", "stable=true", "input={\"name\":\"x\"}", "cert=@" + path},
+		[]string{"version=10"},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"appName": "my-app",
+		"first":   float64(10),
+		"stable":  true,
+		"input":   map[string]any{"name": "x"},
+		"cert":    "-----BEGIN CERTIFICATE-----",
+		"version": "10",
+	}, variables)
+
+	_, err = parseVariables([]string{"appName"}, nil)
+	assert.Error(t, err)
+}
+
+func TestOperationType(t *testing.T) {
+	op, err := operationType(`{ viewer { email } }`, "")
+	require.NoError(t, err)
+	assert.Equal(t, ast.Query, op)
+
+	const doc = `
+query GetApp($name: String!) { app(name: $name) { id } }
+mutation DeleteApp($id: ID!) { deleteApp(appId: $id) { organization { id } } }
+`
+	_, err = operationType(doc, "")
+	assert.Error(t, err)
+
+	op, err = operationType(doc, "DeleteApp")
+	require.NoError(t, err)
+	assert.Equal(t, ast.Mutation, op)
+
+	_, err = operationType(doc, "Missing")
+	assert.Error(t, err)
+
+	_, err = operationType(`{ viewer {`, "")
+	assert.Error(t, err)
+}
@@ -3,11 +3,16 @@ package help
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/olekukonko/tablewriter"
 )
@@ -30,7 +35,23 @@ func New(root *cobra.Command) *cobra.Command {
 		Description: "show all commands, even the ones we secretly hate.",
 	})
 
-	cmd.AddCommand(list)
+	const exitCodesLong = `The exit codes of flyctl, by kind of failure.
+
+Scripts can branch on the exit code of a command rather than parse its error
+output. The codes never change meaning across flyctl releases.
+
+Commands exit with the validation code for invalid arguments and flags, and
+with the auth, not-found and validation codes when the API or the machines API
+reports those failures. Other failures exit with the error code.
+
+'fly machine start', 'stop' and 'restart' stop at the first machine they fail
+for. With --continue-on-error, they carry on with the remaining machines and
+exit with the partial-success code when they succeeded for others.
+`
+	exitCodes := command.New("exit-codes", "The exit codes of flyctl", exitCodesLong, HelpExitCodes)
+	flag.Add(exitCodes, flag.JSONOutput())
+
+	cmd.AddCommand(list, exitCodes)
 
 	return cmd
 }
@@ -132,6 +153,7 @@ More help:
 `)
 		listCommands([]string{"docs", "doctor"})
 		fmt.Printf("  help commands   A complete list of commands (there are a bunch more)\n")
+		fmt.Printf("  help exit-codes The exit codes of flyctl, for scripts\n")
 
 		return nil
 	}
@@ -164,3 +186,18 @@ Flags:
 		return nil
 	}
 }
+
+// the output of `flyctl help exit-codes`
+func HelpExitCodes(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, flyerr.ExitCodeDocs)
+	}
+
+	rows := make([][]string, 0, len(flyerr.ExitCodeDocs))
+	for _, doc := range flyerr.ExitCodeDocs {
+		rows = append(rows, []string{strconv.Itoa(doc.Code), doc.Name, doc.Description})
+	}
+	return render.Table(out, "", rows, "Code", "Name", "Description")
+}
@@ -23,6 +23,8 @@ func New() *cobra.Command {
 	cmd.AddCommand(
 		newShow(),
 		newUpdate(),
+		newSBOM(),
+		newScan(),
 	)
 
 	return cmd
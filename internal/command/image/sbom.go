@@ -0,0 +1,171 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/sbom"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newSBOM() *cobra.Command {
+	const (
+		short = "Generate a software bill of materials for the deployed image"
+		long  = `Generate a CycloneDX or SPDX software bill of materials (SBOM) for the
+image of the app's current release, for use with vulnerability and license
+tooling. Requires the syft CLI.
+
+With --diff, the packages of the current image are compared against the
+image of the previous release instead.`
+		usage = "sbom [APPNAME]"
+	)
+
+	cmd := command.New(usage, short, long, runSBOM,
+		command.RequireSession,
+		command.LoadAppNameIfPresentNoFlag,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "format",
+			Description: "SBOM format, either cyclonedx or spdx",
+			Default:     sbom.CycloneDX,
+		},
+		flag.String{
+			Name:        "output",
+			Shorthand:   "o",
+			Description: "Write the SBOM to this file instead of stdout",
+		},
+		flag.Bool{
+			Name:        "diff",
+			Description: "Show the packages added, removed and changed since the previous release",
+		},
+	)
+
+	return cmd
+}
+
+func runSBOM(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		format  = flag.GetString(ctx, "format")
+		appName = flag.FirstArg(ctx)
+	)
+
+	if appName == "" {
+		appName = appconfig.NameFromContext(ctx)
+		if appName == "" {
+			return errors.New("no app name was provided, and none is available from the environment or fly.toml")
+		}
+	}
+
+	images, err := releaseImages(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	if flag.GetBool(ctx, "diff") {
+		if len(images) < 2 {
+			return fmt.Errorf("app %s has no previous release with a different image to compare against", appName)
+		}
+		return runSBOMDiff(ctx, images[1], images[0], format)
+	}
+
+	fmt.Fprintf(io.ErrOut, "Generating %s SBOM for %s\n", format, images[0])
+
+	doc, err := sbom.Generate(ctx, images[0], format)
+	if err != nil {
+		return err
+	}
+
+	if output := flag.GetString(ctx, "output"); output != "" {
+		if err := os.WriteFile(output, doc, 0o644); err != nil {
+			return fmt.Errorf("failed writing sbom: %w", err)
+		}
+		fmt.Fprintf(io.ErrOut, "Wrote SBOM to %s\n", output)
+		return nil
+	}
+
+	_, err = io.Out.Write(doc)
+	return err
+}
+
+func runSBOMDiff(ctx context.Context, prevImage, curImage, format string) error {
+	io := iostreams.FromContext(ctx)
+
+	fmt.Fprintf(io.ErrOut, "Comparing packages of %s against %s\n", curImage, prevImage)
+
+	var packages [2][]sbom.Package
+	for i, image := range []string{prevImage, curImage} {
+		doc, err := sbom.Generate(ctx, image, format)
+		if err != nil {
+			return err
+		}
+		if packages[i], err = sbom.Packages(doc, format); err != nil {
+			return err
+		}
+	}
+
+	diff := sbom.Diff(packages[0], packages[1])
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, diff)
+	}
+
+	var rows [][]string
+	for _, p := range diff.Added {
+		rows = append(rows, []string{"added", p.Name, "", p.Version})
+	}
+	for _, p := range diff.Removed {
+		rows = append(rows, []string{"removed", p.Name, p.Version, ""})
+	}
+	for _, c := range diff.Changed {
+		rows = append(rows, []string{"changed", c.Name, c.From, c.To})
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintln(io.Out, "No package changes since the previous release")
+		return nil
+	}
+
+	return render.Table(io.Out, "", rows, "Change", "Package", "Previous", "Current")
+}
+
+// releaseImages returns the distinct images of the app's recent releases,
+// newest first.
+func releaseImages(ctx context.Context, appName string) ([]string, error) {
+	client := client.FromContext(ctx).API()
+
+	releases, err := client.GetAppReleasesMachines(ctx, appName, "", 25)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving releases of %s: %w", appName, err)
+	}
+
+	releases = lo.Filter(releases, func(r api.Release, _ int) bool {
+		return r.ImageRef != "" && r.Status == "complete"
+	})
+	images := lo.Uniq(lo.Map(releases, func(r api.Release, _ int) string {
+		return r.ImageRef
+	}))
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("app %s has no deployed image", appName)
+	}
+
+	return images, nil
+}
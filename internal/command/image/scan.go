@@ -0,0 +1,118 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/vulnscan"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newScan() *cobra.Command {
+	const (
+		short = "Scan the deployed image for known vulnerabilities"
+		long  = `Scan the image of the app's current release, or the image given with
+--image, for known vulnerabilities. Requires the grype or trivy CLI.
+
+With --fail-on, the command fails when vulnerabilities at least as severe as
+the given severity are found, e.g. to gate CI pipelines.`
+		usage = "scan [APPNAME]"
+	)
+
+	cmd := command.New(usage, short, long, runScan,
+		command.RequireSession,
+		command.LoadAppNameIfPresentNoFlag,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "image",
+			Shorthand:   "i",
+			Description: "Scan this image reference instead of the deployed image",
+		},
+		flag.String{
+			Name:        "scanner",
+			Description: "Scanner to use, either grype or trivy. Defaults to the first one installed",
+		},
+		flag.String{
+			Name:        "fail-on",
+			Description: "Fail when vulnerabilities of at least this severity are found: negligible, low, medium, high or critical",
+		},
+		flag.String{
+			Name:        "severity",
+			Description: "Only list vulnerabilities of at least this severity",
+			Default:     "low",
+		},
+	)
+
+	return cmd
+}
+
+func runScan(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		image   = flag.GetString(ctx, "image")
+		failOn  = flag.GetString(ctx, "fail-on")
+		appName = flag.FirstArg(ctx)
+	)
+
+	severity, _, err := vulnscan.ParseSeverity(flag.GetString(ctx, "severity"))
+	if err != nil {
+		return err
+	}
+	if failOn != "" {
+		if failOn, _, err = vulnscan.ParseSeverity(failOn); err != nil {
+			return err
+		}
+	}
+
+	if image == "" {
+		if appName == "" {
+			appName = appconfig.NameFromContext(ctx)
+			if appName == "" {
+				return errors.New("no app name was provided, and none is available from the environment or fly.toml")
+			}
+		}
+
+		images, err := releaseImages(ctx, appName)
+		if err != nil {
+			return err
+		}
+		image = images[0]
+	}
+
+	fmt.Fprintf(io.ErrOut, "Scanning %s for vulnerabilities\n", image)
+
+	report, err := vulnscan.Scan(ctx, image, flag.GetString(ctx, "scanner"))
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		err = render.JSON(io.Out, report)
+	} else {
+		err = report.Render(io.Out, severity)
+	}
+	if err != nil {
+		return err
+	}
+
+	if failOn != "" {
+		if vulns := report.AtOrAbove(failOn); len(vulns) > 0 {
+			return fmt.Errorf("found %d vulnerabilities of severity %s or higher", len(vulns), failOn)
+		}
+	}
+
+	return nil
+}
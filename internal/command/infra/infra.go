@@ -0,0 +1,299 @@
+// Package infra implements the infra command chain.
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new infra Command.
+func New() *cobra.Command {
+	const (
+		long = `Manage the infra machines flyctl runs for an organization: log shippers, cron
+runners, autoscalers, uptime probers, org secrets vaults and tunnel relays.
+
+Infra machines follow a release channel. On the stable channel they run the
+images matching this version of flyctl, on the edge channel the latest images.
+Org secrets vaults run the same pinned image on both channels. The channel and
+image of each machine are recorded in its metadata.`
+		short = "Manage the infra machines of an organization"
+	)
+
+	cmd := command.New("infra", short, long, nil)
+
+	cmd.AddCommand(
+		newList(),
+		newUpgrade(),
+	)
+
+	return cmd
+}
+
+func newList() *cobra.Command {
+	const (
+		long  = `List the infra machines of an organization, their channel and image.`
+		short = "List infra machines"
+	)
+
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+	)
+
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newUpgrade() *cobra.Command {
+	const (
+		long = `Update infra machines to the image of their release channel, or move them to
+--channel. Pick the machines of one app with --app, or every infra machine of
+the organization with --all.`
+		short = "Upgrade infra machines"
+	)
+
+	cmd := command.New("upgrade", short, long, runUpgrade,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly infra upgrade --all --org acme
+  fly infra upgrade --app acme-cron-runner --channel edge`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.Yes(),
+		flag.String{
+			Name:        "app",
+			Shorthand:   "a",
+			Description: "Upgrade the infra machines of this app",
+		},
+		flag.Bool{
+			Name:        "all",
+			Description: "Upgrade every infra machine of the organization",
+		},
+		flag.String{
+			Name:        "channel",
+			Description: fmt.Sprintf("Move machines to this release channel, one of %s", strings.Join(infra.Channels(), ", ")),
+		},
+	)
+
+	return cmd
+}
+
+// machine is an infra machine of an app.
+type machine struct {
+	App       string `json:"app"`
+	ID        string `json:"id"`
+	Component string `json:"component"`
+	Channel   string `json:"channel"`
+	Image     string `json:"image"`
+	UpToDate  bool   `json:"up_to_date"`
+
+	machine *api.Machine
+	flaps   *flaps.Client
+}
+
+// findMachines returns the infra machines of the apps of the organization,
+// or of appName when set.
+func findMachines(ctx context.Context, appName string) ([]*machine, error) {
+	io := iostreams.FromContext(ctx)
+	apiClient := client.FromContext(ctx).API()
+
+	var appNames []string
+	if appName != "" {
+		appNames = []string{appName}
+	} else {
+		org, err := orgs.OrgFromFlagOrSelect(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		apps, err := apiClient.GetAppsForOrganization(ctx, org.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed listing apps: %w", err)
+		}
+		for _, app := range apps {
+			if app.PlatformVersion == "machines" {
+				appNames = append(appNames, app.Name)
+			}
+		}
+		sort.Strings(appNames)
+	}
+
+	var found []*machine
+	for _, name := range appNames {
+		flapsClient, err := flaps.NewFromAppName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		machines, err := flapsClient.ListActive(ctx)
+		if err != nil {
+			fmt.Fprintf(io.ErrOut, "Failed listing machines of %s: %v\n", name, err)
+			continue
+		}
+
+		for _, m := range machines {
+			component := infra.ComponentOf(m)
+			if component == "" {
+				continue
+			}
+
+			channel := infra.ChannelOf(m)
+			image, _ := infra.Image(component, channel)
+			found = append(found, &machine{
+				App:       name,
+				ID:        m.ID,
+				Component: component,
+				Channel:   channel,
+				Image:     m.Config.Image,
+				UpToDate:  m.Config.Image == image && m.Config.Metadata[infra.MetadataKeyChannel] == channel,
+				machine:   m,
+				flaps:     flapsClient,
+			})
+		}
+	}
+
+	return found, nil
+}
+
+func runList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	machines, err := findMachines(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, machines)
+	}
+
+	rows := make([][]string, 0, len(machines))
+	for _, m := range machines {
+		status := "up to date"
+		if !m.UpToDate {
+			status = "upgrade available"
+		}
+		rows = append(rows, []string{m.App, m.ID, m.Component, m.Channel, m.Image, status})
+	}
+
+	return render.Table(out, "", rows, "App", "Machine", "Component", "Channel", "Image", "Status")
+}
+
+func runUpgrade(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = flag.GetString(ctx, "app")
+		channel = flag.GetString(ctx, "channel")
+	)
+
+	switch {
+	case appName == "" && !flag.GetBool(ctx, "all"):
+		return errors.New("pick the machines to upgrade with --app or --all")
+	case appName != "" && flag.GetBool(ctx, "all"):
+		return errors.New("--app and --all are mutually exclusive")
+	}
+	if channel != "" {
+		if _, err := infra.Image(infra.ComponentFlyctl, channel); err != nil {
+			return err
+		}
+	}
+
+	machines, err := findMachines(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	targets := map[*machine]string{}
+	var pending []*machine
+	for _, m := range machines {
+		target := channel
+		if target == "" {
+			target = m.Channel
+		}
+		// Edge images are always pulled again, as their tag doesn't change
+		if !m.UpToDate || target != m.Channel || target == infra.ChannelEdge {
+			targets[m] = target
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(io.Out, "Infra machines are up to date")
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Upgrade %d infra machines? They restart with their new image.", len(pending)); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	var failed int
+	for _, m := range pending {
+		target := targets[m]
+		if err := upgrade(ctx, m, target); err != nil {
+			fmt.Fprintf(io.ErrOut, "Failed upgrading %s of %s: %v\n", m.ID, m.App, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(io.Out, "Upgraded %s of %s to %s on the %s channel\n", m.ID, m.App, m.Image, target)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed upgrading %d of %d infra machines", failed, len(pending))
+	}
+	return nil
+}
+
+func upgrade(ctx context.Context, m *machine, channel string) error {
+	ctx = flaps.NewContext(ctx, m.flaps)
+
+	leased, releaseLeaseFunc, err := mach.AcquireLease(ctx, m.machine)
+	defer releaseLeaseFunc(ctx, leased)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(leased.Config)
+	infra.SetChannel(config, m.Component, channel)
+	m.Image = config.Image
+
+	return mach.Update(ctx, leased, &api.LaunchMachineInput{
+		Name:   leased.Name,
+		Region: leased.Region,
+		Config: config,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/command/orgs"
 	"github.com/superfly/flyctl/internal/flag"
@@ -86,6 +87,18 @@ func runAllocateIPAddressV4(ctx context.Context) error {
 			return err
 		}
 	}
+
+	if addrType == "v4" {
+		appName := appconfig.NameFromContext(ctx)
+		app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+		if err != nil {
+			return err
+		}
+		if err := budget.Check(ctx, app.Organization.Slug, appName, "Allocating a dedicated IPv4 address", func(prices budget.Prices) float64 { return prices.DedicatedIPv4 }); err != nil {
+			return err
+		}
+	}
+
 	return runAllocateIPAddress(ctx, addrType, nil, "")
 }
 
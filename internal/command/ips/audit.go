@@ -0,0 +1,412 @@
+package ips
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// probeTimeout bounds each IPv6 probe.
+const probeTimeout = 10 * time.Second
+
+// Severities of audit findings.
+const (
+	severityBreaks = "breaks"
+	severityWarn   = "warning"
+	severityOK     = "ok"
+)
+
+func newAudit() *cobra.Command {
+	const (
+		long = `Audits whether an app works for IPv6-only clients, and what would break if
+its IPv4 addresses were released with fly ips ipv6-only:
+
+  * the app has a public IPv6 address
+  * its hostnames, the fly.dev one and the ones of its certificates, have AAAA
+    records pointing to it
+  * its services answer over IPv6, probed from this machine
+  * its services don't need IPv4, as UDP services do
+  * the processes of its started machines listen on IPv6, which private
+    networking requires, and on the addresses its services forward to`
+		short = `Audit an app for IPv6-only readiness`
+	)
+
+	cmd := command.New("audit", short, long, runAudit,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.Bool{
+			Name:        "skip-probes",
+			Description: "Don't probe the services of the app over IPv6, as from machines without IPv6 connectivity",
+		},
+	)
+
+	return cmd
+}
+
+// finding is a result of an audit.
+type finding struct {
+	Severity string `json:"severity"`
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+}
+
+// auditReport is the IPv6 readiness of an app.
+type auditReport struct {
+	App      string    `json:"app"`
+	IPv4     []string  `json:"ipv4"`
+	IPv6     []string  `json:"ipv6"`
+	Findings []finding `json:"findings"`
+}
+
+// Breaks returns the findings which would break with IPv6 only.
+func (r *auditReport) Breaks() []finding {
+	return lo.Filter(r.Findings, func(f finding, _ int) bool { return f.Severity == severityBreaks })
+}
+
+func runAudit(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	report, err := audit(ctx, appName, !flag.GetBool(ctx, "skip-probes"))
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, report)
+	}
+
+	return renderAudit(out, report)
+}
+
+// audit audits appName for IPv6-only readiness, probing its services with
+// probe.
+func audit(ctx context.Context, appName string, probe bool) (*auditReport, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := apiClient.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := apiClient.GetAppCertificates(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &auditReport{App: appName}
+	for _, a := range addresses {
+		switch a.Type {
+		case "v4", "shared_v4":
+			report.IPv4 = append(report.IPv4, a.Address)
+		case "v6":
+			report.IPv6 = append(report.IPv6, a.Address)
+		}
+	}
+	if len(report.IPv6) == 0 {
+		report.Findings = append(report.Findings, finding{severityBreaks, "addresses", "no public IPv6 address, allocate one with fly ips allocate-v6"})
+	}
+
+	hosts := []string{app.Hostname}
+	for _, c := range certs {
+		hosts = append(hosts, c.Hostname)
+	}
+	for _, host := range lo.Uniq(hosts) {
+		if host == "" || strings.HasPrefix(host, "*.") {
+			continue
+		}
+		aaaa, err := net.DefaultResolver.LookupIP(ctx, "ip6", host)
+		if err != nil && !isNotFound(err) {
+			report.Findings = append(report.Findings, finding{severityWarn, host, fmt.Sprintf("failed resolving AAAA records: %v", err)})
+			continue
+		}
+		report.Findings = append(report.Findings, auditHost(host, ipStrings(aaaa), report.IPv6))
+	}
+
+	report.Findings = append(report.Findings, auditServices(machines)...)
+
+	for _, m := range machines {
+		if m.State != api.MachineStateStarted {
+			continue
+		}
+		processes, err := flapsClient.GetProcesses(ctx, m.ID)
+		if err != nil {
+			report.Findings = append(report.Findings, finding{severityWarn, "machine " + m.ID, fmt.Sprintf("failed listing its processes: %v", err)})
+			continue
+		}
+		report.Findings = append(report.Findings, auditListeners(m, processes)...)
+	}
+
+	if probe && len(report.IPv6) > 0 {
+		report.Findings = append(report.Findings, probeServices(ctx, app.Hostname, report.IPv6[0], machines)...)
+	}
+
+	return report, nil
+}
+
+// auditHost checks host has AAAA records, aaaa, pointing to one of the IPv6
+// addresses of the app, v6.
+func auditHost(host string, aaaa, v6 []string) finding {
+	if len(aaaa) == 0 {
+		return finding{severityBreaks, host, "no AAAA record, add one pointing to the IPv6 address of the app"}
+	}
+	for _, addr := range aaaa {
+		for _, own := range v6 {
+			if net.ParseIP(addr).Equal(net.ParseIP(own)) {
+				return finding{severityOK, host, "AAAA record points to " + addr}
+			}
+		}
+	}
+	return finding{severityBreaks, host, fmt.Sprintf("AAAA records point to %s, not to an IPv6 address of the app", strings.Join(aaaa, ", "))}
+}
+
+// auditServices checks the services of machines work over IPv6.
+func auditServices(machines []*api.Machine) []finding {
+	var findings []finding
+	seen := map[string]bool{}
+	for _, m := range machines {
+		if m.Config == nil {
+			continue
+		}
+		for _, s := range m.Config.Services {
+			subject := fmt.Sprintf("%s service on port %d", strings.ToLower(s.Protocol), s.InternalPort)
+			if seen[subject] {
+				continue
+			}
+			seen[subject] = true
+
+			if s.Protocol == "udp" {
+				findings = append(findings, finding{severityBreaks, subject, "UDP services are only reachable on a dedicated IPv4 address"})
+			}
+		}
+	}
+	return findings
+}
+
+// auditListeners checks the listening sockets of the processes of m: ones
+// only on IPv4 addresses aren't reachable over private networking, which is
+// IPv6, and ones on loopback addresses aren't reachable by the proxy.
+func auditListeners(m *api.Machine, processes api.MachinePsResponse) []finding {
+	internalPorts := map[int]bool{}
+	if m.Config != nil {
+		for _, s := range m.Config.Services {
+			internalPorts[s.InternalPort] = true
+		}
+	}
+
+	var (
+		findings []finding
+		dual     = map[string]bool{}
+	)
+	type listener struct {
+		process string
+		proto   string
+		host    string
+		port    int
+	}
+	var listeners []listener
+	for _, p := range processes {
+		for _, s := range p.ListenSockets {
+			host, portStr, err := net.SplitHostPort(s.Address)
+			if err != nil {
+				continue
+			}
+			port, _ := strconv.Atoi(portStr)
+			l := listener{process: p.Command, proto: s.Proto, host: host, port: port}
+			listeners = append(listeners, l)
+			if ip := net.ParseIP(host); ip != nil && ip.To4() == nil && !ip.IsLoopback() {
+				dual[fmt.Sprintf("%s/%d", s.Proto, port)] = true
+			}
+		}
+	}
+
+	for _, l := range listeners {
+		ip := net.ParseIP(l.host)
+		if ip == nil {
+			continue
+		}
+		subject := fmt.Sprintf("machine %s: %s on %s %s", m.ID, processName(l.process), l.proto, net.JoinHostPort(l.host, strconv.Itoa(l.port)))
+
+		switch {
+		case ip.IsLoopback() && internalPorts[l.port]:
+			findings = append(findings, finding{severityBreaks, subject, "listens on a loopback address, which the proxy can't reach, listen on [::] instead"})
+		case ip.IsLoopback():
+			continue
+		case ip.To4() != nil && !dual[fmt.Sprintf("%s/%d", l.proto, l.port)]:
+			findings = append(findings, finding{severityWarn, subject, "listens on IPv4 only, so it's unreachable over private networking, listen on [::] instead"})
+		}
+	}
+	return findings
+}
+
+// probeServices connects to the TCP services of machines on v6, and requests
+// host over IPv6 when they serve HTTPS.
+func probeServices(ctx context.Context, host, v6 string, machines []*api.Machine) []finding {
+	ports := map[int][]string{}
+	for _, m := range machines {
+		if m.Config == nil {
+			continue
+		}
+		for _, s := range m.Config.Services {
+			if s.Protocol != "tcp" {
+				continue
+			}
+			for _, p := range s.Ports {
+				if p.Port != nil {
+					ports[*p.Port] = p.Handlers
+				}
+			}
+		}
+	}
+
+	sorted := lo.Keys(ports)
+	sort.Ints(sorted)
+
+	dialer := &net.Dialer{Timeout: probeTimeout}
+	dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp6", net.JoinHostPort(v6, "443"))
+	}
+
+	var findings []finding
+	for _, port := range sorted {
+		subject := fmt.Sprintf("port %d over IPv6", port)
+		addr := net.JoinHostPort(v6, strconv.Itoa(port))
+
+		if port == 443 && lo.Contains(ports[port], "tls") && lo.Contains(ports[port], "http") {
+			status, err := probeHTTPS(ctx, host, dial)
+			switch {
+			case err != nil:
+				findings = append(findings, finding{severityWarn, subject, fmt.Sprintf("https://%s failed: %v", host, err)})
+			case status >= 500:
+				findings = append(findings, finding{severityWarn, subject, fmt.Sprintf("https://%s responded with %d", host, status)})
+			default:
+				findings = append(findings, finding{severityOK, subject, fmt.Sprintf("https://%s responded with %d", host, status)})
+			}
+			continue
+		}
+
+		conn, err := dialer.DialContext(ctx, "tcp6", addr)
+		if err != nil {
+			findings = append(findings, finding{severityWarn, subject, fmt.Sprintf("connecting to %s failed: %v", addr, err)})
+			continue
+		}
+		conn.Close()
+		findings = append(findings, finding{severityOK, subject, "accepted a connection on " + addr})
+	}
+	return findings
+}
+
+func probeHTTPS(ctx context.Context, host string, dial func(context.Context, string, string) (net.Conn, error)) (int, error) {
+	client := &http.Client{
+		Timeout: probeTimeout,
+		Transport: &http.Transport{
+			DialContext:     dial,
+			TLSClientConfig: &tls.Config{ServerName: host},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+host+"/", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func renderAudit(w io.Writer, r *auditReport) error {
+	fmt.Fprintf(w, "IPv4: %s\n", valueOrNone(r.IPv4))
+	fmt.Fprintf(w, "IPv6: %s\n\n", valueOrNone(r.IPv6))
+
+	rows := make([][]string, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		rows = append(rows, []string{f.Severity, f.Subject, f.Message})
+	}
+	if err := render.Table(w, "", rows, "Status", "Subject", "Finding"); err != nil {
+		return err
+	}
+
+	if breaks := r.Breaks(); len(breaks) > 0 {
+		fmt.Fprintf(w, "%d findings would break with IPv6 only\n", len(breaks))
+	} else {
+		fmt.Fprintf(w, "%s is ready for IPv6 only, release its IPv4 addresses with fly ips ipv6-only\n", r.App)
+	}
+	return nil
+}
+
+func processName(command string) string {
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields[0]
+	}
+	return command
+}
+
+func ipStrings(ips []net.IP) []string {
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+func valueOrNone(s []string) string {
+	if len(s) == 0 {
+		return "none"
+	}
+	return strings.Join(s, ", ")
+}
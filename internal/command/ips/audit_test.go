@@ -0,0 +1,50 @@
+package ips
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestAuditHost(t *testing.T) {
+	v6 := []string{"2a09:8280:1::a:bcd"}
+
+	assert.Equal(t, severityOK, auditHost("my-app.fly.dev", []string{"2a09:8280:1:0:0:0:a:bcd"}, v6).Severity)
+	assert.Equal(t, severityBreaks, auditHost("example.com", nil, v6).Severity)
+
+	f := auditHost("example.com", []string{"2001:db8::1"}, v6)
+	assert.Equal(t, severityBreaks, f.Severity)
+	assert.Contains(t, f.Message, "not to an IPv6 address of the app")
+}
+
+func TestAuditServices(t *testing.T) {
+	machines := []*api.Machine{
+		{Config: &api.MachineConfig{Services: []api.MachineService{{Protocol: "tcp", InternalPort: 8080}, {Protocol: "udp", InternalPort: 5000}}}},
+		{Config: &api.MachineConfig{Services: []api.MachineService{{Protocol: "udp", InternalPort: 5000}}}},
+	}
+
+	assert.Equal(t, []finding{{severityBreaks, "udp service on port 5000", "UDP services are only reachable on a dedicated IPv4 address"}}, auditServices(machines))
+}
+
+func TestAuditListeners(t *testing.T) {
+	m := &api.Machine{ID: "148e21", Config: &api.MachineConfig{Services: []api.MachineService{{Protocol: "tcp", InternalPort: 8080}}}}
+
+	findings := auditListeners(m, api.MachinePsResponse{
+		{Pid: 612, Command: "/app/server --port 8080", ListenSockets: []api.ListenSocket{
+			{Proto: "tcp", Address: "127.0.0.1:8080"},
+			{Proto: "tcp", Address: "0.0.0.0:9091"},
+			{Proto: "tcp", Address: "0.0.0.0:9000"},
+			{Proto: "tcp", Address: "[::]:9000"},
+			{Proto: "tcp", Address: "127.0.0.1:5432"},
+		}},
+	})
+
+	if assert.Len(t, findings, 2) {
+		assert.Equal(t, severityBreaks, findings[0].Severity)
+		assert.Equal(t, "machine 148e21: /app/server on tcp 127.0.0.1:8080", findings[0].Subject)
+		assert.Equal(t, severityWarn, findings[1].Severity)
+		assert.Contains(t, findings[1].Subject, "0.0.0.0:9091")
+	}
+}
@@ -20,6 +20,8 @@ func New() *cobra.Command {
 		newAllocatev6(),
 		newPrivate(),
 		newRelease(),
+		newAudit(),
+		newIPv6Only(),
 	)
 	return cmd
 }
@@ -0,0 +1,114 @@
+package ips
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newIPv6Only() *cobra.Command {
+	const (
+		long = `Runs an app on IPv6 only: allocates a public IPv6 address when it has none,
+audits it as fly ips audit does, and releases its dedicated and shared IPv4
+addresses when nothing would break. Clients without IPv6 connectivity can't
+reach the app anymore.`
+		short = `Release the IPv4 addresses of an app to run it on IPv6 only`
+	)
+
+	cmd := command.New("ipv6-only", short, long, runIPv6Only,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.Bool{
+			Name:        "force",
+			Description: "Release the IPv4 addresses even when the audit finds what would break",
+		},
+		flag.Bool{
+			Name:        "skip-probes",
+			Description: "Don't probe the services of the app over IPv6, as from machines without IPv6 connectivity",
+		},
+	)
+
+	return cmd
+}
+
+func runIPv6Only(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		appName   = appconfig.NameFromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	addresses, err := apiClient.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	hasV6 := false
+	for _, a := range addresses {
+		hasV6 = hasV6 || a.Type == "v6"
+	}
+	if !hasV6 {
+		ip, err := apiClient.AllocateIPAddress(ctx, appName, "v6", "", nil, "")
+		if err != nil {
+			return fmt.Errorf("failed allocating an IPv6 address: %w", err)
+		}
+		fmt.Fprintf(io.Out, "Allocated IPv6 address %s\n", ip.Address)
+	}
+
+	report, err := audit(ctx, appName, !flag.GetBool(ctx, "skip-probes"))
+	if err != nil {
+		return err
+	}
+	if err := renderAudit(io.Out, report); err != nil {
+		return err
+	}
+
+	if len(report.IPv4) == 0 {
+		fmt.Fprintf(io.Out, "%s has no IPv4 address, it already runs on IPv6 only\n", appName)
+		return nil
+	}
+
+	if breaks := report.Breaks(); len(breaks) > 0 && !flag.GetBool(ctx, "force") {
+		return fmt.Errorf("%d findings would break with IPv6 only, fix them or pass --force", len(breaks))
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Release %s from %s? Clients without IPv6 won't reach it anymore", valueOrNone(report.IPv4), appName)
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	for _, address := range report.IPv4 {
+		if err := apiClient.ReleaseIPAddress(ctx, appName, address); err != nil {
+			return fmt.Errorf("failed releasing %s: %w", address, err)
+		}
+		fmt.Fprintf(io.Out, "Released %s from %s\n", address, appName)
+	}
+
+	fmt.Fprintf(io.Out, "%s runs on IPv6 only\n", appName)
+	return nil
+}
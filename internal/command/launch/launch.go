@@ -169,7 +169,7 @@ func run(ctx context.Context) (err error) {
 		go imgsrc.EagerlyEnsureRemoteBuilder(ctx, client, org.Slug)
 	}
 
-	region, err := computeRegionToUse(ctx, appConfig, org.PaidPlan)
+	region, err := computeRegionToUse(ctx, appConfig, org)
 	if err != nil {
 		return err
 	}
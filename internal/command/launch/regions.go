@@ -29,7 +29,7 @@ func getRegionByCode(ctx context.Context, regionCode string) (*api.Region, error
 
 // computeRegionToUse looks at --region flag, existing fly.toml primary_region and as last
 // meassure asks the user from a list of valid platform regions which one to use
-func computeRegionToUse(ctx context.Context, appConfig *appconfig.Config, paidPlan bool) (*api.Region, error) {
+func computeRegionToUse(ctx context.Context, appConfig *appconfig.Config, org *api.Organization) (*api.Region, error) {
 	regionCode := flag.GetRegion(ctx)
 	if regionCode == "" {
 		regionCode = appConfig.PrimaryRegion
@@ -39,7 +39,7 @@ func computeRegionToUse(ctx context.Context, appConfig *appconfig.Config, paidPl
 		return getRegionByCode(ctx, regionCode)
 	}
 
-	return prompt.Region(ctx, !paidPlan,
-		prompt.RegionParams{Message: "Choose a region for deployment:"},
+	return prompt.Region(ctx, !org.PaidPlan,
+		prompt.RegionParams{Message: "Choose a region for deployment:", OrgSlug: org.Slug},
 	)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command/logs"
 	"github.com/superfly/flyctl/internal/command/postgres"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
@@ -203,6 +204,14 @@ func createDatabases(ctx context.Context, srcInfo *scanner.SourceInfo, appName s
 		options["redis"] = true
 	}
 
+	slug, err := logs.PromptShip(ctx, appName)
+	if err != nil && !prompt.IsNonInteractive(err) {
+		fmt.Fprintln(io.Out, colorize.Red(fmt.Sprintf("Error setting up log shipping: %s. Try again with 'fly logs ship --app %s'", err, appName)))
+	}
+	if slug != "" {
+		options["log_shipping"] = true
+	}
+
 	// Run any initialization commands required for Postgres if it was installed
 	if confirmPg && len(srcInfo.PostgresInitCommands) > 0 {
 		for _, cmd := range srcInfo.PostgresInitCommands {
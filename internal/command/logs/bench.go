@@ -0,0 +1,205 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+)
+
+const (
+	benchSink        = "bench"
+	benchSource      = "bench_events"
+	benchFormat      = "bench_fly_log"
+	benchMetrics     = "bench_metrics"
+	benchConfigPath  = "/tmp/flyctl-bench.toml"
+	benchMaxDuration = 50 * time.Second
+
+	// benchSustainedRatio is the share of the requested rate a sink has to
+	// ship for the log shipper to keep up with it.
+	benchSustainedRatio = 0.9
+)
+
+var sinkVarPattern = regexp.MustCompile(`\$\{(\w+)(\?|:-[^}]*)?\}`)
+
+// interpolateSinkVars returns a copy of sink with the variables it reads
+// replaced with their values in vars, or their defaults.
+func interpolateSinkVars(sink map[string]any, vars map[string]string) map[string]any {
+	out := make(map[string]any, len(sink))
+	for k, v := range sink {
+		switch v := v.(type) {
+		case string:
+			out[k] = sinkVarPattern.ReplaceAllStringFunc(v, func(ref string) string {
+				m := sinkVarPattern.FindStringSubmatch(ref)
+				if value, ok := vars[m[1]]; ok && value != "" {
+					return value
+				}
+				return strings.TrimPrefix(m[2], ":-")
+			})
+		case map[string]any:
+			out[k] = interpolateSinkVars(v, vars)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// benchVectorConfig returns the vector configuration pushing rate synthetic
+// logs per second to the sink of p, configured with vars and options, and
+// printing the metrics of the sink as JSON lines.
+func benchVectorConfig(p *provider, vars map[string]string, options sinkOptions, rate int) *vectorConfig {
+	input := benchFormat
+	cfg := &vectorConfig{
+		Sources: map[string]any{
+			benchSource: map[string]any{
+				"type":     "demo_logs",
+				"format":   "json",
+				"interval": 1 / float64(rate),
+			},
+			benchMetrics: map[string]any{
+				"type":                 "internal_metrics",
+				"scrape_interval_secs": 1,
+			},
+		},
+		Transforms: map[string]any{
+			// Synthetic logs take the shape of the ones of the log stream
+			benchFormat: remapTransform(benchSource, `. = {
+  "message": .message,
+  "timestamp": now(),
+  "fly": {"app": {"name": "flyctl-bench", "instance": "bench"}, "region": get_env_var("FLY_REGION") ?? "bench"},
+  "log": {"level": "info"}
+}`),
+			benchMetrics + "_sink": map[string]any{
+				"type":      "filter",
+				"inputs":    []string{benchMetrics},
+				"condition": fmt.Sprintf(`.tags.component_id == %q`, benchSink),
+			},
+		},
+		Sinks: map[string]any{
+			benchMetrics + "_console": map[string]any{
+				"type":     "console",
+				"inputs":   []string{benchMetrics + "_sink"},
+				"encoding": map[string]any{"codec": "json"},
+			},
+		},
+	}
+
+	if p.remap != "" {
+		input = benchFormat + "_" + p.Slug
+		cfg.Transforms[input] = remapTransform(benchFormat, p.remap)
+	}
+
+	sink := interpolateSinkVars(p.sink, vars)
+	options.apply(sink)
+	sink["inputs"] = []string{input}
+	cfg.Sinks[benchSink] = sink
+
+	return cfg
+}
+
+// benchResult is the throughput a sink sustained during a benchmark.
+type benchResult struct {
+	Provider     string  `json:"provider"`
+	Size         string  `json:"vm_size"`
+	Rate         int     `json:"requested_rate"`
+	Duration     float64 `json:"duration_seconds"`
+	Received     int64   `json:"received_events"`
+	Sent         int64   `json:"sent_events"`
+	SentBytes    int64   `json:"sent_bytes"`
+	Errors       int64   `json:"errors"`
+	EventsPerSec float64 `json:"events_per_second"`
+	BytesPerSec  float64 `json:"bytes_per_second"`
+	ErrorRate    float64 `json:"error_rate"`
+	Sustained    bool    `json:"sustained"`
+	Suggestion   string  `json:"suggested_vm_size,omitempty"`
+}
+
+// benchMetric is a metric of the internal_metrics source, as the console
+// sink prints it.
+type benchMetric struct {
+	Name    string            `json:"name"`
+	Tags    map[string]string `json:"tags"`
+	Counter *struct {
+		Value float64 `json:"value"`
+	} `json:"counter"`
+}
+
+// parseBenchMetrics returns the latest value of the counters in the JSON
+// lines of output, summed across their tags, by metric name.
+func parseBenchMetrics(output string) map[string]int64 {
+	latest := map[string]float64{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var m benchMetric
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil || m.Counter == nil {
+			continue
+		}
+		tags := make([]string, 0, len(m.Tags))
+		for k, v := range m.Tags {
+			tags = append(tags, k+"="+v)
+		}
+		sort.Strings(tags)
+		latest[m.Name+"{"+strings.Join(tags, ",")+"}"] = m.Counter.Value
+	}
+
+	totals := map[string]int64{}
+	for series, value := range latest {
+		name, _, _ := strings.Cut(series, "{")
+		totals[name] += int64(value)
+	}
+	return totals
+}
+
+// newBenchResult returns the result of benchmarking the sink of provider at
+// rate events per second for duration, on a machine of the given size, from
+// the metrics of the sink.
+func newBenchResult(provider, size string, rate int, duration time.Duration, metrics map[string]int64) *benchResult {
+	r := &benchResult{
+		Provider:  provider,
+		Size:      size,
+		Rate:      rate,
+		Duration:  duration.Seconds(),
+		Received:  metrics["component_received_events_total"],
+		Sent:      metrics["component_sent_events_total"],
+		SentBytes: metrics["component_sent_event_bytes_total"],
+		Errors:    metrics["component_errors_total"] + metrics["component_discarded_events_total"],
+	}
+
+	r.EventsPerSec = float64(r.Sent) / r.Duration
+	r.BytesPerSec = float64(r.SentBytes) / r.Duration
+	if attempted := r.Sent + r.Errors; attempted > 0 {
+		r.ErrorRate = float64(r.Errors) / float64(attempted)
+	}
+	r.Sustained = r.EventsPerSec >= benchSustainedRatio*float64(rate) && r.Errors == 0
+	if !r.Sustained && r.Errors == 0 {
+		r.Suggestion = largerVMSize(size)
+	}
+	return r
+}
+
+// largerVMSize returns the preset of the next size up from size, of the same
+// cpu kind, if any.
+func largerVMSize(size string) string {
+	current, ok := api.MachinePresets[size]
+	if !ok {
+		return ""
+	}
+
+	var next string
+	for name, preset := range api.MachinePresets {
+		if preset.CPUKind != current.CPUKind || preset.CPUs <= current.CPUs {
+			continue
+		}
+		if next == "" || preset.CPUs < api.MachinePresets[next].CPUs {
+			next = name
+		}
+	}
+	return next
+}
@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateSinkVars(t *testing.T) {
+	datadog, err := findProvider("datadog")
+	require.NoError(t, err)
+
+	sink := interpolateSinkVars(datadog.sink, map[string]string{"DATADOG_API_KEY": "key"})
+	assert.Equal(t, "key", sink["default_api_key"])
+	assert.Equal(t, "datadoghq.com", sink["site"])
+	assert.Equal(t, "${DATADOG_API_KEY?}", datadog.sink["default_api_key"])
+}
+
+func TestBenchVectorConfig(t *testing.T) {
+	otlp, err := findProvider("otlp")
+	require.NoError(t, err)
+
+	cfg := benchVectorConfig(otlp, map[string]string{"OTLP_ENDPOINT": "https://otel.example.com"}, sinkOptions{Compression: "gzip"}, 200)
+	_, err = cfg.Encode()
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.005, cfg.Sources[benchSource].(map[string]any)["interval"])
+	sink := cfg.Sinks[benchSink].(map[string]any)
+	assert.Equal(t, []string{"bench_fly_log_otlp"}, sink["inputs"])
+	assert.Equal(t, "https://otel.example.com/v1/logs", sink["uri"])
+	assert.Equal(t, "gzip", sink["compression"])
+}
+
+func TestBenchResult(t *testing.T) {
+	output := `{"name":"component_sent_events_total","tags":{"component_id":"bench"},"counter":{"value":1000.0}}
+{"name":"component_sent_events_total","tags":{"component_id":"bench"},"counter":{"value":2700.0}}
+{"name":"component_sent_event_bytes_total","tags":{"component_id":"bench"},"counter":{"value":270000.0}}
+{"name":"component_received_events_total","tags":{"component_id":"bench"},"counter":{"value":3000.0}}
+not a metric`
+
+	metrics := parseBenchMetrics(output)
+	assert.Equal(t, int64(2700), metrics["component_sent_events_total"])
+
+	r := newBenchResult("Datadog", "shared-cpu-1x", 100, 30*time.Second, metrics)
+	assert.InDelta(t, 90, r.EventsPerSec, 0.01)
+	assert.True(t, r.Sustained)
+
+	r = newBenchResult("Datadog", "shared-cpu-1x", 200, 30*time.Second, metrics)
+	assert.False(t, r.Sustained)
+	assert.Equal(t, "shared-cpu-2x", r.Suggestion)
+
+	errored := parseBenchMetrics(`{"name":"component_errors_total","tags":{"error_type":"request_failed"},"counter":{"value":3.0}}
+{"name":"component_errors_total","tags":{"error_type":"encoder_failed"},"counter":{"value":1.0}}`)
+	r = newBenchResult("Datadog", "shared-cpu-1x", 200, 30*time.Second, errored)
+	assert.Equal(t, int64(4), r.Errors)
+	assert.Equal(t, 1.0, r.ErrorRate)
+	assert.Empty(t, r.Suggestion)
+}
+
+func TestLargerVMSize(t *testing.T) {
+	assert.Equal(t, "shared-cpu-2x", largerVMSize("shared-cpu-1x"))
+	assert.Equal(t, "performance-16x", largerVMSize("performance-8x"))
+	assert.Empty(t, largerVMSize("performance-16x"))
+	assert.Empty(t, largerVMSize("unknown"))
+}
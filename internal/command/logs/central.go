@@ -0,0 +1,224 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	// aggregatorPort is the port the central shipper receives forwarded logs on.
+	aggregatorPort = 9000
+
+	// aggregatorMetadataKeyPrefix prefixes the central shipper machine
+	// metadata keys recording the Flycast address allocated in each source org.
+	aggregatorMetadataKeyPrefix = "fly_log_aggregator_"
+
+	// centralForwardSink is the sink source org shippers use to forward logs
+	// to the central shipper.
+	centralForwardSink = "vector"
+
+	// aggregationsPath is where the central shipper machine stores the apps
+	// it ships forwarded logs of.
+	aggregationsPath = "/etc/vector/aggregations.json"
+
+	// aggregatorConfigPath is where the central shipper machine stores the
+	// vector configuration generated from its aggregations.
+	aggregatorConfigPath = "/etc/vector/sinks/aggregator.toml"
+
+	aggregatorPrefix = "aggregated_"
+)
+
+// aggregation ships the logs of App, forwarded by the shipper of Org, to
+// Provider.
+type aggregation struct {
+	Org      string `json:"org"`
+	App      string `json:"app"`
+	Provider string `json:"provider"`
+}
+
+// aggregatedVar returns the name of the central shipper secret holding the
+// value of the provider variable name.
+func aggregatedVar(name string) string {
+	return "AGGREGATED_" + name
+}
+
+// setAggregation adds a to aggregations, replacing the one of the same app.
+func setAggregation(aggregations []aggregation, a aggregation) []aggregation {
+	out := lo.Reject(aggregations, func(o aggregation, _ int) bool { return o.Org == a.Org && o.App == a.App })
+	out = append(out, a)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Org != out[j].Org {
+			return out[i].Org < out[j].Org
+		}
+		return out[i].App < out[j].App
+	})
+	return out
+}
+
+func decodeAggregations(data []byte) ([]aggregation, error) {
+	var aggregations []aggregation
+	if err := json.Unmarshal(data, &aggregations); err != nil {
+		return nil, fmt.Errorf("failed decoding aggregations: %w", err)
+	}
+	return aggregations, nil
+}
+
+// aggregatorVectorConfig returns the vector configuration receiving logs
+// forwarded by the shippers of other orgs, and shipping those of each
+// aggregated app to its provider.
+func aggregatorVectorConfig(aggregations []aggregation) (*vectorConfig, error) {
+	const (
+		source = aggregatorPrefix + "logs"
+		router = aggregatorPrefix + "providers"
+	)
+
+	routes := map[string]any{}
+	cfg := &vectorConfig{
+		Sources: map[string]any{
+			source: map[string]any{
+				"type":    "vector",
+				"address": fmt.Sprintf("[::]:%d", aggregatorPort),
+			},
+		},
+		Transforms: map[string]any{
+			router: map[string]any{
+				"type":   "route",
+				"inputs": []string{source},
+				"route":  routes,
+			},
+		},
+		Sinks: map[string]any{},
+	}
+
+	byProvider := lo.GroupBy(aggregations, func(a aggregation) string { return a.Provider })
+	for _, slug := range lo.Keys(byProvider) {
+		p, err := findProvider(slug)
+		if err != nil {
+			return nil, err
+		}
+
+		apps := lo.Map(byProvider[slug], func(a aggregation, _ int) string { return fmt.Sprintf("%q", a.Org+"/"+a.App) })
+		sort.Strings(apps)
+		routes[slug] = fmt.Sprintf(`includes([%s], join!([to_string(.fly.org.name) ?? "", to_string(.fly.app.name) ?? ""], "/"))`, strings.Join(apps, ", "))
+
+		input := router + "." + slug
+		if p.remap != "" {
+			name := aggregatorPrefix + "format_" + slug
+			cfg.Transforms[name] = remapTransform(input, p.remap)
+			input = name
+		}
+
+		names := lo.Map(p.allVars(), func(v providerVar, _ int) string { return v.Name })
+		sink := renameSinkVars(p.sink, names, aggregatedVar)
+		sink["inputs"] = []string{input}
+		cfg.Sinks[aggregatorPrefix+slug] = sink
+	}
+
+	return cfg, nil
+}
+
+// ensureCentralAggregator configures the log shipper of the central org to
+// accept logs of appName forwarded from sourceOrg's shipper and to ship them
+// to p with vars, stored as secrets of the central shipper. It returns the
+// Flycast address, reachable from sourceOrg's private network, the source
+// shipper should forward to.
+func ensureCentralAggregator(ctx context.Context, centralSlug string, sourceOrg gql.AppDataOrganization, appName string, p *provider, vars map[string]string) (string, error) {
+	var (
+		apiClient = client.FromContext(ctx).API()
+		io        = iostreams.FromContext(ctx)
+	)
+
+	centralOrg, err := orgs.OrgFromSlug(ctx, centralSlug)
+	if err != nil {
+		return "", err
+	}
+	if centralOrg.ID == sourceOrg.Id {
+		return "", fmt.Errorf("--central-org must be a different organization than the one %s belongs to", appName)
+	}
+
+	shipperApp, err := resolveShipperApp(ctx, gql.AppDataOrganization{
+		Id:       centralOrg.ID,
+		Slug:     centralOrg.Slug,
+		RawSlug:  centralOrg.RawSlug,
+		PaidPlan: centralOrg.PaidPlan,
+	}, "")
+	if err != nil {
+		return "", err
+	}
+
+	flapsClient, machine, err := ensureShipperMachineInApp(ctx, shipperApp)
+	if err != nil {
+		return "", err
+	}
+
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return "", err
+	}
+	settings.Aggregations = setAggregation(settings.Aggregations, aggregation{Org: sourceOrg.Slug, App: appName, Provider: p.Slug})
+
+	if len(vars) > 0 {
+		secrets := make(map[string]string, len(vars))
+		for name, value := range vars {
+			secrets[aggregatedVar(name)] = value
+		}
+		if _, err := apiClient.SetSecrets(ctx, shipperApp.Name, secrets); err != nil {
+			return "", fmt.Errorf("failed storing the %s credentials on %s: %w", p.Name, shipperApp.Name, err)
+		}
+	}
+
+	key := aggregatorMetadataKeyPrefix + sourceOrg.Slug
+	address := machine.Config.Metadata[key]
+	if address == "" {
+		// Expose the central shipper on the source org's private network
+		ip, err := apiClient.AllocateIPAddress(ctx, shipperApp.Name, "private_v6", "", &api.Organization{ID: sourceOrg.Id}, "")
+		if err != nil {
+			return "", fmt.Errorf("failed allocating a Flycast address for %s in the %s organization: %w", shipperApp.Name, sourceOrg.Slug, err)
+		}
+		address = fmt.Sprintf("[%s]:%d", ip.Address, aggregatorPort)
+	}
+
+	fmt.Fprintf(io.Out, "Add %s route to central log shipper VM %s\n", appName, machine.ID)
+	err = updateShipperSettings(flaps.NewContext(ctx, flapsClient), shipperApp, machine, settings, func(config *api.MachineConfig) {
+		if config.Metadata == nil {
+			config.Metadata = map[string]string{}
+		}
+		config.Metadata[key] = address
+		if !hasAggregatorService(config) {
+			port := aggregatorPort
+			config.Services = append(config.Services, api.MachineService{
+				Protocol:     "tcp",
+				InternalPort: aggregatorPort,
+				Ports:        []api.MachinePort{{Port: &port}},
+			})
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(io.Out, "Central log shipper %s is reachable from %s at %s\n", shipperApp.Name, sourceOrg.Slug, address)
+
+	return address, nil
+}
+
+func hasAggregatorService(config *api.MachineConfig) bool {
+	for _, s := range config.Services {
+		if s.InternalPort == aggregatorPort {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestAggregatorVectorConfig(t *testing.T) {
+	aggregations := setAggregation(nil, aggregation{Org: "acme", App: "web", Provider: "datadog"})
+	aggregations = setAggregation(aggregations, aggregation{Org: "acme", App: "api", Provider: "loki"})
+	aggregations = setAggregation(aggregations, aggregation{Org: "acme", App: "web", Provider: "loki"})
+	require.Equal(t, []aggregation{
+		{Org: "acme", App: "api", Provider: "loki"},
+		{Org: "acme", App: "web", Provider: "loki"},
+	}, aggregations)
+
+	cfg, err := aggregatorVectorConfig(aggregations)
+	require.NoError(t, err)
+	data, err := cfg.Encode()
+	require.NoError(t, err)
+
+	var decoded map[string]map[string]map[string]any
+	_, err = toml.Decode(string(data), &decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, "vector", decoded["sources"]["aggregated_logs"]["type"])
+	assert.Equal(t, "[::]:9000", decoded["sources"]["aggregated_logs"]["address"])
+
+	route := decoded["transforms"]["aggregated_providers"]["route"].(map[string]any)
+	assert.Contains(t, route["loki"], `includes(["acme/api", "acme/web"]`)
+
+	loki := decoded["sinks"]["aggregated_loki"]
+	assert.Equal(t, []any{"aggregated_providers.loki"}, loki["inputs"])
+	assert.Equal(t, "${AGGREGATED_LOKI_URL?}", loki["endpoint"])
+	assert.Equal(t, "${AGGREGATED_LOKI_PASSWORD:-}", loki["auth"].(map[string]any)["password"])
+}
+
+func TestShipperSettingsAggregations(t *testing.T) {
+	s := &shipperSettings{Aggregations: []aggregation{{Org: "acme", App: "web", Provider: "loki"}}}
+	config := &api.MachineConfig{}
+	require.NoError(t, s.apply(config, "central"))
+
+	paths := make([]string, 0, len(config.Files))
+	for _, f := range config.Files {
+		paths = append(paths, f.GuestPath)
+	}
+	assert.ElementsMatch(t, []string{aggregationsPath, aggregatorConfigPath}, paths)
+
+	loaded, err := loadShipperSettings(&api.Machine{Config: config})
+	require.NoError(t, err)
+	assert.Equal(t, s.Aggregations, loaded.Aggregations)
+}
@@ -53,7 +53,7 @@ to all instances running in a specific region using the --region/-r flag.
 			Description: "Filter by instance ID",
 		},
 	)
-	cmd.AddCommand(newShip(), newUnship(), newDashboard())
+	cmd.AddCommand(newShip(), newUnship(), newDashboard(), newToken())
 	return
 }
 
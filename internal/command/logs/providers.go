@@ -0,0 +1,483 @@
+package logs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+)
+
+const validationTimeout = 10 * time.Second
+
+// providerVar describes a variable a log shipping provider consumes.
+type providerVar struct {
+	Name        string
+	Description string
+	Required    bool
+	Secret      bool
+}
+
+// provider describes a destination the log shipper can forward logs to.
+type provider struct {
+	Slug            string
+	Name            string
+	AutoProvisioned bool
+	Vars            []providerVar
+
+	// validate performs a pre-flight check of the credentials in vars against
+	// the provider, so that misconfigurations surface during setup rather than
+	// inside the shipper.
+	validate func(ctx context.Context, vars map[string]string) error
+
+	// sink holds the options of the vector sink shipping to the provider,
+	// reading its variables from the environment of vector.
+	sink map[string]any
+
+	// sinkVars are the variables sink reads on top of Vars, for providers
+	// whose credentials are otherwise provisioned automatically.
+	sinkVars []providerVar
+
+	// compressions and codecs are the compression algorithms and encoding
+	// codecs the sink of the provider supports.
+	compressions []string
+	codecs       []string
+
+	// partitioned is set for providers storing logs as objects under key
+	// prefixes, which --partition templates.
+	partitioned bool
+
+	// remap is a VRL program reshaping logs into the format the sink of the
+	// provider expects, if any.
+	remap string
+}
+
+var providers = []provider{
+	{
+		Slug:            "logtail",
+		Name:            "Logtail",
+		AutoProvisioned: true,
+		sink: map[string]any{
+			"type":     "http",
+			"uri":      "https://in.logtail.com/",
+			"encoding": map[string]any{"codec": "json"},
+			"auth":     map[string]any{"strategy": "bearer", "token": "${LOGTAIL_TOKEN?}"},
+		},
+		sinkVars: []providerVar{
+			{Name: "LOGTAIL_TOKEN", Description: "Logtail source token", Required: true, Secret: true},
+		},
+		compressions: []string{"none", "gzip", "zstd"},
+		codecs:       []string{"json", "text"},
+	},
+	{
+		Slug: "datadog",
+		Name: "Datadog",
+		Vars: []providerVar{
+			{Name: "DATADOG_API_KEY", Description: "Datadog API key", Required: true, Secret: true},
+			{Name: "DATADOG_SITE", Description: "Datadog site, e.g. datadoghq.eu (default: datadoghq.com)"},
+		},
+		validate: validateDatadog,
+		sink: map[string]any{
+			"type":            "datadog_logs",
+			"default_api_key": "${DATADOG_API_KEY?}",
+			"site":            "${DATADOG_SITE:-datadoghq.com}",
+			"compression":     "gzip",
+		},
+		compressions: []string{"none", "gzip", "zstd"},
+	},
+	{
+		Slug: "loki",
+		Name: "Grafana Loki",
+		Vars: []providerVar{
+			{Name: "LOKI_URL", Description: "Loki base URL", Required: true},
+			{Name: "LOKI_USERNAME", Description: "Loki basic auth username"},
+			{Name: "LOKI_PASSWORD", Description: "Loki basic auth password", Secret: true},
+		},
+		validate: validateLoki,
+		sink: map[string]any{
+			"type":     "loki",
+			"endpoint": "${LOKI_URL?}",
+			"encoding": map[string]any{"codec": "json"},
+			"auth": map[string]any{
+				"strategy": "basic",
+				"user":     "${LOKI_USERNAME:-}",
+				"password": "${LOKI_PASSWORD:-}",
+			},
+			"labels": map[string]any{
+				"app":    "{{ fly.app.name }}",
+				"region": "{{ fly.region }}",
+			},
+		},
+		compressions: []string{"none", "gzip", "snappy"},
+		codecs:       []string{"json", "text", "logfmt"},
+	},
+	{
+		Slug: "aws_s3",
+		Name: "AWS S3",
+		Vars: []providerVar{
+			{Name: "AWS_ACCESS_KEY_ID", Description: "AWS access key ID", Required: true},
+			{Name: "AWS_SECRET_ACCESS_KEY", Description: "AWS secret access key", Required: true, Secret: true},
+			{Name: "AWS_BUCKET", Description: "Bucket to write logs to", Required: true},
+			{Name: "AWS_REGION", Description: "Bucket region", Required: true},
+			{Name: "S3_ENDPOINT", Description: "Endpoint of an S3 compatible service (default: AWS)"},
+		},
+		validate: validateS3,
+		sink: map[string]any{
+			"type":        "aws_s3",
+			"bucket":      "${AWS_BUCKET?}",
+			"region":      "${AWS_REGION?}",
+			"key_prefix":  "{{ fly.app.name }}/%F/",
+			"compression": "gzip",
+			"encoding":    map[string]any{"codec": "json"},
+			"auth": map[string]any{
+				"access_key_id":     "${AWS_ACCESS_KEY_ID?}",
+				"secret_access_key": "${AWS_SECRET_ACCESS_KEY?}",
+			},
+		},
+		compressions: []string{"none", "gzip", "zstd"},
+		codecs:       []string{"json", "text"},
+		partitioned:  true,
+	},
+	{
+		Slug: "otlp",
+		Name: "OpenTelemetry",
+		Vars: []providerVar{
+			{Name: "OTLP_ENDPOINT", Description: "Base URL of the OpenTelemetry collector, e.g. https://otel.example.com:4318", Required: true},
+			{Name: "OTLP_PROTOCOL", Description: "OTLP protocol, grpc or http (default: http)"},
+			{Name: "OTLP_HEADERS", Description: "Comma separated name=value headers sent to the collector, e.g. for authentication", Secret: true},
+		},
+		validate: validateOTLP,
+		// vector has no OTLP sink, standalone configurations send logs as
+		// OTLP/JSON over HTTP one at a time, without OTLP_HEADERS
+		sink: map[string]any{
+			"type":     "http",
+			"uri":      "${OTLP_ENDPOINT?}/v1/logs",
+			"method":   "post",
+			"encoding": map[string]any{"codec": "json"},
+			"framing":  map[string]any{"method": "newline_delimited"},
+			"batch":    map[string]any{"max_events": 1},
+			"request": map[string]any{
+				"headers": map[string]any{"content-type": "application/json"},
+			},
+		},
+		compressions: []string{"none", "gzip"},
+		remap:        otlpRemap,
+	},
+}
+
+// otlpRemap reshapes a log into an OTLP/JSON logs export request.
+const otlpRemap = `ts = parse_timestamp(.timestamp, "%+") ?? now()
+. = {
+  "resourceLogs": [{
+    "resource": {"attributes": [
+      {"key": "service.name", "value": {"stringValue": to_string(.fly.app.name) ?? ""}},
+      {"key": "service.instance.id", "value": {"stringValue": to_string(.fly.app.instance) ?? ""}},
+      {"key": "cloud.region", "value": {"stringValue": to_string(.fly.region) ?? ""}}
+    ]},
+    "scopeLogs": [{"logRecords": [{
+      "timeUnixNano": to_string(to_unix_timestamp(ts, unit: "nanoseconds")),
+      "severityText": to_string(.log.level) ?? "",
+      "body": {"stringValue": to_string(.message) ?? ""}
+    }]}]
+  }]
+}
+`
+
+// ProviderSlugs returns the slugs of the providers logs can be shipped to.
+func ProviderSlugs() []string {
+	slugs := make([]string, 0, len(providers))
+	for _, p := range providers {
+		slugs = append(slugs, p.Slug)
+	}
+	return slugs
+}
+
+// allVars returns the variables of p along with the ones its sink reads.
+func (p *provider) allVars() []providerVar {
+	return append(append([]providerVar{}, p.Vars...), p.sinkVars...)
+}
+
+func findProvider(slug string) (*provider, error) {
+	for i := range providers {
+		if providers[i].Slug == slug {
+			return &providers[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown log provider %q, valid options are: %s", slug, strings.Join(ProviderSlugs(), ", "))
+}
+
+// resolveVars merges the NAME=VALUE pairs passed on the command line with
+// answers to prompts for any required variable which is still missing.
+func (p *provider) resolveVars(ctx context.Context, args []string) (map[string]string, error) {
+	vars, err := cmdutil.ParseKVStringsToMap(args)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(p.Vars))
+	for _, v := range p.Vars {
+		known[v.Name] = true
+	}
+	for name := range vars {
+		if !known[name] {
+			return nil, fmt.Errorf("%s does not accept the %s variable", p.Name, name)
+		}
+	}
+
+	for _, v := range p.Vars {
+		if !v.Required || vars[v.Name] != "" {
+			continue
+		}
+
+		var value string
+		msg := fmt.Sprintf("%s (%s):", v.Description, v.Name)
+		if v.Secret {
+			err = prompt.Password(ctx, &value, msg, true)
+		} else {
+			err = prompt.String(ctx, &value, msg, "", true)
+		}
+
+		switch {
+		case prompt.IsNonInteractive(err):
+			return nil, fmt.Errorf("%s is required by %s, set it with --var %s=<value>", v.Name, p.Name, v.Name)
+		case err != nil:
+			return nil, err
+		}
+
+		vars[v.Name] = value
+	}
+
+	return vars, nil
+}
+
+// loggerArgs returns the vars as sorted, shell quoted NAME=VALUE arguments
+// for the shipper's add-logger script.
+func loggerArgs(vars map[string]string) []string {
+	args := make([]string, 0, len(vars))
+	for name, value := range vars {
+		args = append(args, shellQuote(name+"="+value))
+	}
+	sort.Strings(args)
+
+	return args
+}
+
+// shellQuote quotes s as a single argument of the shell running the commands
+// of the shipper's machine.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func doValidationRequest(ctx context.Context, req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // skipcq: GO-S2307
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("credentials were rejected (%s)", resp.Status)
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("resource not found (%s)", resp.Status)
+	case resp.StatusCode > 299:
+		return fmt.Errorf("unexpected response (%s)", resp.Status)
+	}
+
+	return nil
+}
+
+func validateDatadog(ctx context.Context, vars map[string]string) error {
+	site := vars["DATADOG_SITE"]
+	if site == "" {
+		site = "datadoghq.com"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api."+site+"/api/v1/validate", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("DD-API-KEY", vars["DATADOG_API_KEY"])
+
+	return doValidationRequest(ctx, req)
+}
+
+func validateLoki(ctx context.Context, vars map[string]string) error {
+	u, err := url.Parse(vars["LOKI_URL"])
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("LOKI_URL must be an absolute URL")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(u.String(), "/")+"/ready", nil)
+	if err != nil {
+		return err
+	}
+	if user := vars["LOKI_USERNAME"]; user != "" {
+		req.SetBasicAuth(user, vars["LOKI_PASSWORD"])
+	}
+
+	return doValidationRequest(ctx, req)
+}
+
+func validateS3(ctx context.Context, vars map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s3BucketURL(vars), nil)
+	if err != nil {
+		return err
+	}
+	signS3Request(req, nil, vars["AWS_ACCESS_KEY_ID"], vars["AWS_SECRET_ACCESS_KEY"], vars["AWS_REGION"], time.Now().UTC())
+
+	return doValidationRequest(ctx, req)
+}
+
+func validateOTLP(ctx context.Context, vars map[string]string) error {
+	u, err := url.Parse(vars["OTLP_ENDPOINT"])
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("OTLP_ENDPOINT must be an absolute URL")
+	}
+	headers, err := parseOTLPHeaders(vars["OTLP_HEADERS"])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	switch vars["OTLP_PROTOCOL"] {
+	case "grpc":
+		port := u.Port()
+		if port == "" {
+			port = "4317"
+		}
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+		if err != nil {
+			return fmt.Errorf("failed reaching the collector: %w", err)
+		}
+		return conn.Close()
+	case "", "http":
+		// An export request without logs is valid, and checks the headers
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(u.String(), "/")+"/v1/logs",
+			strings.NewReader(`{"resourceLogs":[]}`))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		return doValidationRequest(ctx, req)
+	default:
+		return fmt.Errorf("OTLP_PROTOCOL must be grpc or http, not %q", vars["OTLP_PROTOCOL"])
+	}
+}
+
+// parseOTLPHeaders parses headers in the name=value,name=value format of
+// OTEL_EXPORTER_OTLP_HEADERS.
+func parseOTLPHeaders(headers string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, pair := range strings.Split(headers, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("OTLP_HEADERS must be comma separated name=value pairs, not %q", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of OTLP header %s: %w", name, err)
+		}
+		parsed[strings.TrimSpace(name)] = value
+	}
+	return parsed, nil
+}
+
+// s3BucketURL returns the URL of the bucket vars configure, on S3 or on the
+// S3 compatible service of S3_ENDPOINT.
+func s3BucketURL(vars map[string]string) string {
+	if endpoint := vars["S3_ENDPOINT"]; endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/" + vars["AWS_BUCKET"]
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", vars["AWS_BUCKET"], vars["AWS_REGION"])
+}
+
+// signS3Request signs a request whose body is payload with AWS Signature
+// Version 4.
+func signS3Request(req *http.Request, payload []byte, accessKey, secretKey, region string, now time.Time) {
+	const service = "s3"
+
+	payloadDigest := sha256.Sum256(payload)
+	payloadSHA256 := hex.EncodeToString(payloadDigest[:])
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadSHA256)
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadSHA256,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadSHA256,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(digest[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func providerFlag() flag.String {
+	return flag.String{
+		Name:        "provider",
+		Description: "The log provider to ship logs to",
+		Default:     "logtail",
+	}
+}
@@ -0,0 +1,75 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProvider(t *testing.T) {
+	p, err := findProvider("loki")
+	require.NoError(t, err)
+	assert.Equal(t, "Grafana Loki", p.Name)
+
+	_, err = findProvider("nope")
+	assert.ErrorContains(t, err, "valid options are: logtail, datadog, loki, aws_s3, otlp")
+}
+
+func TestResolveVarsRejectsUnknown(t *testing.T) {
+	p, err := findProvider("datadog")
+	require.NoError(t, err)
+
+	_, err = p.resolveVars(context.Background(), []string{"DATADOG_API_KEY=abc", "LOKI_URL=http://x"})
+	assert.ErrorContains(t, err, "does not accept the LOKI_URL variable")
+}
+
+func TestLoggerArgs(t *testing.T) {
+	args := loggerArgs(map[string]string{"LOKI_URL": "http://x", "LOKI_PASSWORD": "p"})
+	assert.Equal(t, []string{"'LOKI_PASSWORD=p'", "'LOKI_URL=http://x'"}, args)
+
+	args = loggerArgs(map[string]string{"LOKI_PASSWORD": "it's'; rm -rf /"})
+	assert.Equal(t, []string{`'LOKI_PASSWORD=it'\''s'\''; rm -rf /'`}, args)
+}
+
+func TestValidateLoki(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		switch {
+		case r.URL.Path != "/ready":
+			w.WriteHeader(http.StatusNotFound)
+		case user != "u" || pass != "p":
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	assert.NoError(t, validateLoki(ctx, map[string]string{"LOKI_URL": server.URL + "/", "LOKI_USERNAME": "u", "LOKI_PASSWORD": "p"}))
+	assert.ErrorContains(t, validateLoki(ctx, map[string]string{"LOKI_URL": server.URL, "LOKI_USERNAME": "u", "LOKI_PASSWORD": "x"}), "credentials were rejected")
+	assert.ErrorContains(t, validateLoki(ctx, map[string]string{"LOKI_URL": "not-a-url"}), "absolute URL")
+}
+
+func TestValidateOTLP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != http.MethodPost || r.URL.Path != "/v1/logs":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Header.Get("X-Api-Key") != "s3cr3t=":
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	assert.NoError(t, validateOTLP(ctx, map[string]string{"OTLP_ENDPOINT": server.URL, "OTLP_HEADERS": "x-api-key=s3cr3t%3D"}))
+	assert.NoError(t, validateOTLP(ctx, map[string]string{"OTLP_ENDPOINT": server.URL, "OTLP_PROTOCOL": "grpc"}))
+	assert.ErrorContains(t, validateOTLP(ctx, map[string]string{"OTLP_ENDPOINT": server.URL}), "credentials were rejected")
+	assert.ErrorContains(t, validateOTLP(ctx, map[string]string{"OTLP_ENDPOINT": server.URL, "OTLP_PROTOCOL": "thrift"}), "grpc or http")
+	assert.ErrorContains(t, validateOTLP(ctx, map[string]string{"OTLP_ENDPOINT": server.URL, "OTLP_HEADERS": "broken"}), "name=value pairs")
+}
@@ -0,0 +1,62 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestRedactPresets(t *testing.T) {
+	r := redaction{Presets: redactionPresetNames()}
+
+	for line, exp := range map[string]string{
+		redactionSamples[0]: "user [REDACTED EMAIL] signed in",
+		redactionSamples[1]: "charging card [REDACTED CARD] for order 1234",
+		redactionSamples[2]: "GET /api/me Authorization: Bearer [REDACTED]",
+	} {
+		redacted, err := r.Redact(line)
+		require.NoError(t, err)
+		assert.Equal(t, exp, redacted)
+	}
+}
+
+func TestRedactCustom(t *testing.T) {
+	r := redaction{Custom: []string{`session=[a-f0-9]+`}}
+	redacted, err := r.Redact("cookie session=deadbeef set")
+	require.NoError(t, err)
+	assert.Equal(t, "cookie [REDACTED] set", redacted)
+
+	_, err = redaction{Custom: []string{`it's`}}.vrl()
+	assert.ErrorContains(t, err, "single quotes")
+	_, err = redaction{Presets: []string{"phones"}}.vrl()
+	assert.ErrorContains(t, err, "unknown redaction preset")
+}
+
+func TestMergeRemoveRedaction(t *testing.T) {
+	r := mergeRedaction(redaction{Presets: []string{"emails"}}, []string{"bearer_tokens", "emails"}, []string{"x+"})
+	assert.Equal(t, []string{"bearer_tokens", "emails"}, r.Presets)
+	assert.Equal(t, []string{"x+"}, r.Custom)
+
+	r = removeRedaction(r, []string{"emails"}, []string{"x+"})
+	assert.Equal(t, redaction{Presets: []string{"bearer_tokens"}, Custom: []string{}}, r)
+}
+
+func TestShipperSettingsApply(t *testing.T) {
+	config := &api.MachineConfig{
+		Env:   map[string]string{"LOKI_REDACT_VRL": "/etc/vector/redact/loki.vrl", "ORG": "acme"},
+		Files: []*api.File{{GuestPath: redactionVRLPath("loki"), RawValue: api.Pointer("")}},
+	}
+	s := &shipperSettings{Redactions: map[string]redaction{"datadog": {Presets: []string{"emails"}}}}
+	require.NoError(t, s.apply(config, "acme"))
+
+	assert.Equal(t, map[string]string{"DATADOG_REDACT_VRL": "/etc/vector/redact/datadog.vrl", "ORG": "acme"}, config.Env)
+
+	machine := &api.Machine{Config: config}
+	loaded, err := loadShipperSettings(machine)
+	require.NoError(t, err)
+	assert.Equal(t, s.Redactions, loaded.Redactions)
+	assert.Empty(t, loaded.Routes)
+}
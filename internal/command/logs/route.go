@@ -0,0 +1,173 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// routesPath is where the log shipper machine stores its routing rules.
+	routesPath = "/etc/vector/routes.json"
+
+	// routesConfigPath is where the log shipper machine stores the vector
+	// configuration generated from its routing rules.
+	routesConfigPath = "/etc/vector/sinks/routes.toml"
+
+	// routesTokenVar is the shipper secret holding the token routed logs are
+	// read with.
+	routesTokenVar = "ROUTE_ACCESS_TOKEN"
+
+	routesPrefix = "routed_"
+)
+
+var routeNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// routeRule ships the logs of the apps running in Regions to Provider. Rules
+// without regions catch the logs no other rule matches.
+type routeRule struct {
+	Name     string   `json:"name"`
+	Provider string   `json:"provider"`
+	Regions  []string `json:"regions,omitempty"`
+
+	// Vars are the names of the provider variables the rule overrides. Their
+	// values are stored as shipper secrets, named by routeVar.
+	Vars []string `json:"vars,omitempty"`
+}
+
+// routeVar returns the name of the shipper secret holding the value of the
+// variable name for the rule.
+func (r routeRule) routeVar(name string) string {
+	return fmt.Sprintf("ROUTE_%s_%s", strings.ToUpper(r.Name), name)
+}
+
+func (r routeRule) isDefault() bool {
+	return len(r.Regions) == 0
+}
+
+func (r routeRule) validate() error {
+	if !routeNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("route name %q must only contain lowercase letters, digits and underscores", r.Name)
+	}
+	_, err := findProvider(r.Provider)
+	return err
+}
+
+func decodeRoutes(data []byte) ([]routeRule, error) {
+	var rules []routeRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed decoding routes: %w", err)
+	}
+	return rules, nil
+}
+
+// routedVectorConfig returns the vector configuration shipping the logs of
+// orgSlug to the providers of rules, according to the regions they run in,
+// redacted as redactions, keyed by provider slug, configure.
+func routedVectorConfig(orgSlug string, rules []routeRule, redactions map[string]redaction) (*vectorConfig, error) {
+	const (
+		source    = routesPrefix + vectorSourceName
+		transform = routesPrefix + vectorTransformName
+		router    = routesPrefix + "regions"
+	)
+
+	routes := map[string]any{}
+	cfg := &vectorConfig{
+		Sources: map[string]any{
+			source: natsSource(orgSlug, "logs.>", routesTokenVar),
+		},
+		Transforms: map[string]any{
+			transform: logJSONTransform(source),
+			router: map[string]any{
+				"type":   "route",
+				"inputs": []string{transform},
+				"route":  routes,
+			},
+		},
+		Sinks: map[string]any{},
+	}
+
+	for _, r := range rules {
+		p, err := findProvider(r.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		input := router + "._unmatched"
+		if !r.isDefault() {
+			regions := make([]string, len(r.Regions))
+			for i, region := range r.Regions {
+				regions[i] = fmt.Sprintf("%q", region)
+			}
+			routes[r.Name] = fmt.Sprintf("includes([%s], .fly.region)", strings.Join(regions, ", "))
+			input = router + "." + r.Name
+		}
+
+		if red := redactions[p.Slug]; !red.isEmpty() {
+			vrl, err := red.vrl()
+			if err != nil {
+				return nil, err
+			}
+			name := routesPrefix + "redact_" + r.Name
+			cfg.Transforms[name] = remapTransform(input, vrl)
+			input = name
+		}
+
+		if p.remap != "" {
+			name := routesPrefix + "format_" + r.Name
+			cfg.Transforms[name] = remapTransform(input, p.remap)
+			input = name
+		}
+
+		sink := overrideSinkVars(p.sink, r)
+		sink["inputs"] = []string{input}
+		cfg.Sinks[routesPrefix+r.Name] = sink
+	}
+
+	return cfg, nil
+}
+
+// overrideSinkVars returns a copy of sink reading the variables r overrides
+// from their route secrets.
+func overrideSinkVars(sink map[string]any, r routeRule) map[string]any {
+	return renameSinkVars(sink, r.Vars, r.routeVar)
+}
+
+// renameSinkVars returns a copy of sink reading the variables names from
+// the variables rename names them.
+func renameSinkVars(sink map[string]any, names []string, rename func(string) string) map[string]any {
+	replacements := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		replacements = append(replacements, "${"+name, "${"+rename(name))
+	}
+	return replaceSinkVars(sink, strings.NewReplacer(replacements...))
+}
+
+func replaceSinkVars(sink map[string]any, replacer *strings.Replacer) map[string]any {
+	out := make(map[string]any, len(sink))
+	for k, v := range sink {
+		switch v := v.(type) {
+		case string:
+			out[k] = replacer.Replace(v)
+		case map[string]any:
+			out[k] = replaceSinkVars(v, replacer)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// setRoute adds rule to rules, replacing the rule of the same name.
+func setRoute(rules []routeRule, rule routeRule) []routeRule {
+	out := []routeRule{rule}
+	for _, r := range rules {
+		if r.Name != rule.Name {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutedVectorConfig(t *testing.T) {
+	rules := []routeRule{
+		{Name: "eu", Provider: "datadog", Regions: []string{"ams", "fra"}, Vars: []string{"DATADOG_SITE"}},
+		{Name: "rest", Provider: "datadog"},
+	}
+
+	cfg, err := routedVectorConfig("acme", rules, map[string]redaction{
+		"datadog": {Presets: []string{"emails"}},
+	})
+	require.NoError(t, err)
+	data, err := cfg.Encode()
+	require.NoError(t, err)
+
+	var decoded map[string]map[string]map[string]any
+	_, err = toml.Decode(string(data), &decoded)
+	require.NoError(t, err)
+
+	router := decoded["transforms"]["routed_regions"]
+	assert.Equal(t, map[string]any{"eu": `includes(["ams", "fra"], .fly.region)`}, router["route"])
+
+	assert.Equal(t, []any{"routed_regions.eu"}, decoded["transforms"]["routed_redact_eu"]["inputs"])
+
+	eu := decoded["sinks"]["routed_eu"]
+	assert.Equal(t, []any{"routed_redact_eu"}, eu["inputs"])
+	assert.Equal(t, "${ROUTE_EU_DATADOG_SITE:-datadoghq.com}", eu["site"])
+	assert.Equal(t, "${DATADOG_API_KEY?}", eu["default_api_key"])
+
+	rest := decoded["sinks"]["routed_rest"]
+	assert.Equal(t, []any{"routed_redact_rest"}, rest["inputs"])
+	assert.Equal(t, "${DATADOG_SITE:-datadoghq.com}", rest["site"])
+}
+
+func TestSetRouteReplaces(t *testing.T) {
+	rules := setRoute(nil, routeRule{Name: "us", Provider: "loki"})
+	rules = setRoute(rules, routeRule{Name: "eu", Provider: "loki"})
+	rules = setRoute(rules, routeRule{Name: "us", Provider: "datadog"})
+
+	require.Len(t, rules, 2)
+	assert.Equal(t, "eu", rules[0].Name)
+	assert.Equal(t, "datadog", rules[1].Provider)
+}
+
+func TestRouteRuleValidate(t *testing.T) {
+	assert.NoError(t, routeRule{Name: "eu_1", Provider: "loki"}.validate())
+	assert.ErrorContains(t, routeRule{Name: "EU", Provider: "loki"}.validate(), "lowercase")
+	assert.ErrorContains(t, routeRule{Name: "eu", Provider: "nope"}.validate(), "unknown log provider")
+}
@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// partitionPresets are the key prefix templates --partition accepts by name.
+// Templates interpolate log fields between double braces and strftime
+// specifiers of the time logs are written at.
+var partitionPresets = map[string]string{
+	"app/date":      "{{ fly.app.name }}/%F/",
+	"app/date/hour": "{{ fly.app.name }}/%F/%H/",
+	"date/app":      "%F/{{ fly.app.name }}/",
+	"region/app":    "{{ fly.region }}/{{ fly.app.name }}/%F/",
+	"hive":          "app={{ fly.app.name }}/date=%F/",
+}
+
+func partitionPresetNames() []string {
+	names := make([]string, 0, len(partitionPresets))
+	for name := range partitionPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// partitionKeyPrefix returns the key prefix template of the partition preset
+// value names, or value itself when it is a custom template.
+func partitionKeyPrefix(value string) (string, error) {
+	if prefix, ok := partitionPresets[value]; ok {
+		return prefix, nil
+	}
+	if !strings.Contains(value, "{{") && !strings.Contains(value, "%") {
+		return "", fmt.Errorf("unknown partition %q, pass one of %s or a template such as %q",
+			value, strings.Join(partitionPresetNames(), ", "), partitionPresets["app/date"])
+	}
+	if strings.Contains(value, "'") {
+		return "", fmt.Errorf("partition %q must not contain single quotes", value)
+	}
+	if !strings.HasSuffix(value, "/") {
+		value += "/"
+	}
+	return value, nil
+}
+
+// s3LifecycleConfiguration returns the bucket lifecycle configuration
+// expiring log objects after days.
+func s3LifecycleConfiguration(days int) []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Rule>
+    <ID>fly-logs-expiration</ID>
+    <Filter><Prefix></Prefix></Filter>
+    <Status>Enabled</Status>
+    <Expiration><Days>%d</Days></Expiration>
+  </Rule>
+</LifecycleConfiguration>
+`, days))
+}
+
+// putS3Lifecycle replaces the lifecycle configuration of the bucket vars
+// configure with one expiring its objects after days.
+func putS3Lifecycle(ctx context.Context, vars map[string]string, days int) error {
+	body := s3LifecycleConfiguration(days)
+	digest := md5.Sum(body) // skipcq: GSC-G401
+
+	ctx, cancel := context.WithTimeout(ctx, validationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s3BucketURL(vars)+"?lifecycle=", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(digest[:]))
+	signS3Request(req, body, vars["AWS_ACCESS_KEY_ID"], vars["AWS_SECRET_ACCESS_KEY"], vars["AWS_REGION"], time.Now().UTC())
+
+	if err := doValidationRequest(ctx, req); err != nil {
+		return fmt.Errorf("failed setting the lifecycle of bucket %s: %w", vars["AWS_BUCKET"], err)
+	}
+	return nil
+}
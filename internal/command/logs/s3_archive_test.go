@@ -0,0 +1,41 @@
+package logs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutS3Lifecycle(t *testing.T) {
+	var (
+		path, query, body string
+		signed            bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, query, body = r.URL.Path, r.URL.RawQuery, string(data)
+		signed = strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/")
+		assert.NotEmpty(t, r.Header.Get("Content-MD5"))
+	}))
+	defer server.Close()
+
+	err := putS3Lifecycle(context.Background(), map[string]string{
+		"AWS_ACCESS_KEY_ID":     "key",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+		"AWS_BUCKET":            "logs",
+		"AWS_REGION":            "auto",
+		"S3_ENDPOINT":           server.URL,
+	}, 30)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/logs", path)
+	assert.Equal(t, "lifecycle=", query)
+	assert.Contains(t, body, "<Days>30</Days>")
+	assert.True(t, signed)
+}
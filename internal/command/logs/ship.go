@@ -12,31 +12,130 @@ import (
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/iostreams"
 )
 
 func newShip() (cmd *cobra.Command) {
 	const (
-		short = "Ship application logs to Logtail"
-		long  = short + "\n"
+		short = "Ship application logs to a third-party provider"
+		long  = short + `
+
+Logtail is provisioned automatically. Other providers require credentials,
+passed as --var NAME=VALUE pairs or prompted for, which are validated against
+the provider before the shipper is configured.
+
+With --central-org, the shipper of the app's organization forwards logs over
+Flycast to the shipper of the central organization, which ships them to the
+provider. This lets a single shipper serve many organizations.
+
+--compression, --encoding-codec and --batch-size tune how the shipper batches,
+compresses and encodes logs for the provider, trading ingest cost against
+latency.
+
+Object storage providers, such as aws_s3 along with S3 compatible services
+like R2 or Tigris set with S3_ENDPOINT, archive logs under the key prefix
+--partition picks, and expire them after --expire-after-days.
+`
 	)
 
 	cmd = command.New("ship", short, long, runSetup, command.RequireSession, command.RequireAppName)
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
+		providerFlag(),
+		shipperAppFlag(),
+		flag.String{
+			Name:        "central-org",
+			Description: "Forward logs to the log shipper of this organization, which ships them to the provider",
+		},
+		flag.StringArray{
+			Name:        "var",
+			Description: "Provider variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
+		},
+		flag.Bool{
+			Name:        "skip-validation",
+			Description: "Save provider credentials without validating them first",
+		},
+		shipperVMSizeFlag(),
+		flag.Int{
+			Name:        "expire-after-days",
+			Description: "Set a lifecycle policy on the bucket of object storage providers expiring logs after this many days, replacing its current one",
+		},
+		sinkOptionFlags(),
+	)
+
+	cmd.AddCommand(
+		newShipProviders(),
+		newShipExportConfig(),
+		newShipImport(),
+		newShipStatus(),
+		newShipUpgrade(),
+		newShipRoute(),
+		newShipRedact(),
+		newShipUsage(),
+		newShipBench(),
 	)
+
 	return cmd
 }
 
 func runSetup(ctx context.Context) (err error) {
+	return Ship(ctx, flag.GetString(ctx, "provider"), flag.GetStringArray(ctx, "var"))
+}
+
+// Ship ships the logs of the app in ctx to the provider with the given slug,
+// with vars as its NAME=VALUE variables. Missing required variables are
+// prompted for.
+func Ship(ctx context.Context, slug string, vars []string) (err error) {
 	client := client.FromContext(ctx).API().GenqClient
 	io := iostreams.FromContext(ctx)
 	appName := appconfig.NameFromContext(ctx)
-	var logtailToken string
+	var (
+		providerArgs []string
+		providerVars = map[string]string{}
+	)
 
+	p, err := findProvider(slug)
+	if err != nil {
+		return err
+	}
+
+	// Catch bad credentials before anything gets provisioned
+	if !p.AutoProvisioned {
+		vars, err := p.resolveVars(ctx, vars)
+		if err != nil {
+			return err
+		}
+
+		if p.validate != nil && !flag.GetBool(ctx, "skip-validation") {
+			fmt.Fprintf(io.Out, "Validating %s credentials\n", p.Name)
+			if err := p.validate(ctx, vars); err != nil {
+				return fmt.Errorf("failed validating %s credentials: %w (use --skip-validation to save them anyway)", p.Name, err)
+			}
+		}
+
+		providerVars = vars
+		providerArgs = loggerArgs(vars)
+
+		if days := flag.GetInt(ctx, "expire-after-days"); days > 0 {
+			if !p.partitioned {
+				return fmt.Errorf("%s doesn't store logs as objects, --expire-after-days only applies to object storage", p.Name)
+			}
+			if err := putS3Lifecycle(ctx, vars, days); err != nil {
+				return err
+			}
+			fmt.Fprintf(io.Out, "Objects of bucket %s expire after %d days\n", vars["AWS_BUCKET"], days)
+		} else if p.partitioned {
+			fmt.Fprintf(io.ErrOut, "Archived logs are kept forever, expire them with --expire-after-days\n")
+		}
+	}
+
+	options, err := sinkOptionsFromFlags(ctx, []*provider{p})
 	if err != nil {
 		return err
 	}
@@ -55,29 +154,37 @@ func runSetup(ctx context.Context) (err error) {
 	}
 
 	// Fetch or create the Logtail integration for the app
+	if p.AutoProvisioned {
+		addOnName := LoggerAddOnName(appName)
+		getAddOnResponse, err := gql.GetAddOn(ctx, client, addOnName)
 
-	addOnName := appName + "-log-shipper"
-	getAddOnResponse, err := gql.GetAddOn(ctx, client, addOnName)
-
-	if err != nil {
+		if err != nil {
 
-		input := gql.CreateAddOnInput{
-			OrganizationId: targetOrg.Id,
-			Name:           addOnName,
-			AppId:          targetApp.Id,
-			Type:           "logtail",
-		}
+			input := gql.CreateAddOnInput{
+				OrganizationId: targetOrg.Id,
+				Name:           addOnName,
+				AppId:          targetApp.Id,
+				Type:           gql.AddOnType(p.Slug),
+			}
 
-		createAddOnResponse, err := gql.CreateAddOn(ctx, client, input)
-		if err != nil {
-			return err
-		}
+			createAddOnResponse, err := gql.CreateAddOn(ctx, client, input)
+			if err != nil {
+				return err
+			}
 
-		logtailToken = createAddOnResponse.CreateAddOn.AddOn.Token
+			providerVars[p.envPrefix()+"_TOKEN"] = createAddOnResponse.CreateAddOn.AddOn.Token
+			providerArgs = []string{shellQuote(createAddOnResponse.CreateAddOn.AddOn.Token)}
 
-	} else {
-		logtailToken = getAddOnResponse.AddOn.Token
+		} else {
+			providerVars[p.envPrefix()+"_TOKEN"] = getAddOnResponse.AddOn.Token
+			providerArgs = []string{shellQuote(getAddOnResponse.AddOn.Token)}
+		}
+	}
+	providerArgs = append(providerArgs, loggerArgs(options[p.Slug].vars(p))...)
+	for name, value := range options[p.Slug].vars(p) {
+		providerVars[name] = value
 	}
+
 	// Fetch a macaroon token whose access is limited to reading this app's logs
 	tokenResponse, err := gql.CreateLimitedAccessToken(ctx, client, appName+"-logs", targetOrg.Id, "read_organization_apps", &gql.LimitedAccessTokenOptions{
 		"app_ids": []string{targetApp.Name},
@@ -86,14 +193,69 @@ func runSetup(ctx context.Context) (err error) {
 		return
 	}
 
-	flapsClient, machine, err := EnsureShipperMachine(ctx, targetOrg)
+	// In central mode the org's shipper forwards to the central aggregator,
+	// which in turn ships to the provider
+	sinkSlug := p.Slug
+	if centralSlug := flag.GetString(ctx, "central-org"); centralSlug != "" {
+		address, err := ensureCentralAggregator(ctx, centralSlug, targetOrg, targetApp.Name, p, providerVars)
+		if err != nil {
+			return err
+		}
+
+		sinkSlug = centralForwardSink
+		providerArgs = []string{shellQuote("VECTOR_ADDRESS=" + address)}
+	}
+
+	flapsClient, machine, err := EnsureShipperMachine(ctx, targetOrg, flag.GetString(ctx, "shipper-app"))
 	if err != nil {
 		return
 	}
 
-	cmd := []string{"/add-logger.sh", targetApp.Name, "logtail", "'" + tokenResponse.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader + "'", logtailToken}
+	cmd := []string{"/add-logger.sh", shellQuote(targetApp.Name), shellQuote(sinkSlug), shellQuote(tokenResponse.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader)}
+	cmd = append(cmd, providerArgs...)
 
 	fmt.Fprintf(io.Out, "Add logger source to log shipper VM %s\n", machine.ID)
+
+	return execShipperCommand(ctx, flapsClient, machine, cmd)
+}
+
+// PromptShip offers to ship the logs of appName to one of the providers,
+// prompting for its credentials, and returns the slug of the provider picked,
+// if any.
+func PromptShip(ctx context.Context, appName string) (string, error) {
+	confirm, err := prompt.Confirm(ctx, "Would you like to ship your app's logs to a log provider now?")
+	if !confirm || err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name
+	}
+	var index int
+	if err := prompt.Select(ctx, &index, "Select a log provider:", "", names...); err != nil {
+		return "", err
+	}
+	slug := providers[index].Slug
+
+	if err := Ship(appconfig.WithName(ctx, appName), slug, nil); err != nil {
+		return "", err
+	}
+	return slug, nil
+}
+
+func shipperVMSizeFlag() flag.String {
+	return flag.String{
+		Name:        "vm-size",
+		Description: "Size of the log shipper machine, e.g. shared-cpu-2x. See 'fly logs ship bench' to pick one.",
+	}
+}
+
+// execShipperCommand runs one of the log shipper's configuration scripts on
+// its machine.
+func execShipperCommand(ctx context.Context, flapsClient *flaps.Client, machine *api.Machine, cmd []string) error {
+	io := iostreams.FromContext(ctx)
+
 	request := &api.MachineExecRequest{
 		Cmd: strings.Join(cmd, " "),
 	}
@@ -101,45 +263,30 @@ func runSetup(ctx context.Context) (err error) {
 	flapsClient.Wait(ctx, machine, "started", time.Second*5)
 	response, err := flapsClient.Exec(ctx, machine.ID, request)
 	if err != nil {
-		fmt.Fprintf(io.ErrOut, response.StdErr)
 		return err
 	}
-	return
-}
+	if response.ExitCode != 0 {
+		fmt.Fprint(io.ErrOut, response.StdErr)
+		return fmt.Errorf("%s exited with code %d on log shipper VM %s", cmd[0], response.ExitCode, machine.ID)
+	}
 
-func EnsureShipperMachine(ctx context.Context, targetOrg gql.AppDataOrganization) (flapsClient *flaps.Client, machine *api.Machine, err error) {
-	client := client.FromContext(ctx).API().GenqClient
-	io := iostreams.FromContext(ctx)
+	return nil
+}
 
-	appsResult, err := gql.GetAppsByRole(ctx, client, "log-shipper", targetOrg.Id)
+func EnsureShipperMachine(ctx context.Context, targetOrg gql.AppDataOrganization, shipperAppName string) (flapsClient *flaps.Client, machine *api.Machine, err error) {
+	shipperApp, err := resolveShipperApp(ctx, targetOrg, shipperAppName)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var shipperApp gql.AppData
-
-	if len(appsResult.Apps.Nodes) > 0 {
-		shipperApp = appsResult.Apps.Nodes[0].AppData
-	} else {
-		input := gql.DefaultCreateAppInput()
-		input.Machines = true
-		input.OrganizationId = targetOrg.Id
-		input.AppRoleId = "log-shipper"
-		input.Name = targetOrg.RawSlug + "-log-shipper"
-
-		createdAppResult, err := gql.CreateApp(ctx, client, input)
-		if err != nil {
-			return nil, nil, err
-		}
+	return ensureShipperMachineInApp(ctx, shipperApp)
+}
 
-		shipperApp = createdAppResult.CreateApp.App.AppData
-		fmt.Fprintf(io.ErrOut, "Provisioning a log shipper VM in the app named %s\n", shipperApp.Name)
-	}
-	if err != nil {
-		return nil, nil, err
-	}
+func ensureShipperMachineInApp(ctx context.Context, shipperApp *gql.AppData) (flapsClient *flaps.Client, machine *api.Machine, err error) {
+	client := client.FromContext(ctx).API().GenqClient
+	io := iostreams.FromContext(ctx)
 
-	flapsClient, err = flaps.New(ctx, gql.ToAppCompact(shipperApp))
+	flapsClient, err = flaps.New(ctx, gql.ToAppCompact(*shipperApp))
 
 	if err != nil {
 		return
@@ -150,8 +297,13 @@ func EnsureShipperMachine(ctx context.Context, targetOrg gql.AppDataOrganization
 		return nil, nil, err
 	}
 
+	size := flag.GetString(ctx, "vm-size")
+
 	if len(machines) > 0 {
 		machine = machines[0]
+		if size != "" && machine.Config != nil && machine.Config.Guest.ToSize() != size {
+			fmt.Fprintf(io.ErrOut, "Log shipper %s already runs, resize it with 'fly logs ship upgrade --vm-size %s'\n", shipperApp.Name, size)
+		}
 	} else {
 
 		machineConf := &api.MachineConfig{
@@ -160,14 +312,24 @@ func EnsureShipperMachine(ctx context.Context, targetOrg gql.AppDataOrganization
 				CPUs:     1,
 				MemoryMB: 256,
 			},
-			Image: "flyio/log-shipper:auto-a14aa63",
 		}
+		if size != "" {
+			if err := machineConf.Guest.SetSize(size); err != nil {
+				return nil, nil, err
+			}
+		}
+		infra.Configure(machineConf, infra.ComponentLogShipper, nil)
 
 		launchInput := api.LaunchMachineInput{
 			Name:   "log-shipper",
 			Config: machineConf,
 		}
 
+		costDelta := func(prices budget.Prices) float64 { return prices.Machine(machineConf.Guest) }
+		if err := budget.Check(ctx, shipperApp.Organization.Slug, shipperApp.Name, "Launching a log shipper machine", costDelta); err != nil {
+			return nil, nil, err
+		}
+
 		regionResponse, err := gql.GetNearestRegion(ctx, client)
 		if err != nil {
 			return nil, nil, err
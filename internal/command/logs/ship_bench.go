@@ -0,0 +1,183 @@
+package logs
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipBench() (cmd *cobra.Command) {
+	const (
+		short = "Measure the throughput of a provider from the log shipper"
+		long  = short + `
+
+Pushes --rate synthetic log events per second for --duration through the sink
+of the provider, configured with its variables and sink options, from the log
+shipper machine of the organization. Reports the throughput the sink sustained
+and its error rate, and when it fell behind, the larger --vm-size to give the
+log shipper with 'fly logs ship upgrade'.
+
+The synthetic events reach the provider, under the flyctl-bench app name. The
+log shipper keeps shipping logs while the benchmark runs.
+`
+	)
+
+	cmd = command.New("bench", short, long, runShipBench, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly logs ship bench --org acme --provider datadog --var DATADOG_API_KEY=... --rate 2000
+  fly logs ship bench --org acme --provider loki --var LOKI_URL=https://loki.example.com --compression snappy`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+		flag.Yes(),
+		shipperAppFlag(),
+		flag.String{
+			Name:        "provider",
+			Description: "The log provider to benchmark",
+		},
+		flag.StringArray{
+			Name:        "var",
+			Description: "Provider variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
+		},
+		flag.Int{
+			Name:        "rate",
+			Description: "Synthetic events to push per second",
+			Default:     500,
+		},
+		flag.Duration{
+			Name:        "duration",
+			Description: "How long to push events for, at most 50s",
+			Default:     30 * time.Second,
+		},
+		sinkOptionFlags(),
+	)
+
+	return cmd
+}
+
+func runShipBench(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		rate     = flag.GetInt(ctx, "rate")
+		duration = flag.GetDuration(ctx, "duration")
+	)
+
+	switch {
+	case flag.GetString(ctx, "provider") == "":
+		return fmt.Errorf("pick the provider to benchmark with --provider")
+	case rate < 1:
+		return fmt.Errorf("--rate must be at least 1 event per second")
+	case duration < time.Second || duration > benchMaxDuration:
+		return fmt.Errorf("--duration must be between 1s and %s", benchMaxDuration)
+	}
+
+	p, err := findProvider(flag.GetString(ctx, "provider"))
+	if err != nil {
+		return err
+	}
+	if p.AutoProvisioned {
+		return fmt.Errorf("%s is provisioned when logs are first shipped to it, and can't be benchmarked before", p.Name)
+	}
+	vars, err := p.resolveVars(ctx, flag.GetStringArray(ctx, "var"))
+	if err != nil {
+		return err
+	}
+	options, err := sinkOptionsFromFlags(ctx, []*provider{p})
+	if err != nil {
+		return err
+	}
+
+	app, flapsClient, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !flag.GetYes(ctx) {
+		events := int64(rate) * int64(duration.Seconds())
+		switch confirmed, err := prompt.Confirmf(ctx, "Send %s synthetic log events to %s?", humanize.Comma(events), p.Name); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	data, err := benchVectorConfig(p, vars, options[p.Slug], rate).Encode()
+	if err != nil {
+		return fmt.Errorf("failed encoding the vector configuration of the benchmark: %w", err)
+	}
+
+	seconds := int(math.Ceil(duration.Seconds()))
+	request := &api.MachineExecRequest{
+		Cmd: fmt.Sprintf(`sh -c "echo %s | base64 -d > %s && timeout %d vector --quiet --config-toml %s; rm -f %s"`,
+			base64.StdEncoding.EncodeToString(data), benchConfigPath, seconds, benchConfigPath, benchConfigPath),
+		Timeout: seconds + 15,
+	}
+
+	fmt.Fprintf(io.ErrOut, "Pushing %d events per second to %s from log shipper %s for %s\n", rate, p.Name, app.Name, duration)
+
+	flapsClient.Wait(ctx, machine, "started", time.Second*5)
+	response, err := flapsClient.Exec(ctx, machine.ID, request)
+	if err != nil {
+		return err
+	}
+
+	metrics := parseBenchMetrics(response.StdOut)
+	if len(metrics) == 0 {
+		fmt.Fprint(io.ErrOut, response.StdErr)
+		return fmt.Errorf("the benchmark reported no metrics, check the variables of %s", p.Name)
+	}
+
+	result := newBenchResult(p.Name, machine.Config.Guest.ToSize(), rate, time.Duration(seconds)*time.Second, metrics)
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, result)
+	}
+
+	cols := []string{"Provider", "VM Size", "Requested", "Sustained", "Throughput", "Sent", "Errors", "Error Rate"}
+	row := []string{
+		result.Provider,
+		result.Size,
+		fmt.Sprintf("%d events/s", result.Rate),
+		fmt.Sprintf("%.0f events/s", result.EventsPerSec),
+		humanize.Bytes(uint64(result.BytesPerSec)) + "/s",
+		humanize.Comma(result.Sent),
+		humanize.Comma(result.Errors),
+		fmt.Sprintf("%.2f%%", 100*result.ErrorRate),
+	}
+	if err := render.VerticalTable(io.Out, "Sink Benchmark", [][]string{row}, cols...); err != nil {
+		return err
+	}
+
+	switch {
+	case result.Sustained:
+		fmt.Fprintf(io.Out, "Log shipper %s keeps up with %d events per second to %s\n", app.Name, rate, p.Name)
+	case result.Errors > 0:
+		fmt.Fprintf(io.Out, "%s rejected events, check its variables and sink options before shipping to it\n", p.Name)
+	case result.Suggestion != "":
+		fmt.Fprintf(io.Out, "Log shipper %s fell behind, resize it with 'fly logs ship upgrade --org %s --vm-size %s'\n",
+			app.Name, app.Organization.Slug, result.Suggestion)
+	default:
+		fmt.Fprintf(io.Out, "Log shipper %s fell behind, try a larger --batch-size or spread logs across providers with 'fly logs ship route'\n", app.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipExportConfig() (cmd *cobra.Command) {
+	const (
+		short = "Export a standalone vector configuration shipping the app's logs"
+		long  = short + `
+
+Writes a vector.toml shipping the logs of the app to the given providers, and a
+Dockerfile running it, for running the log shipper yourself instead of having
+flyctl manage it. Provider credentials are read from the environment of vector,
+so the configuration holds no secrets. Without --output-dir the configuration
+is printed instead.
+`
+	)
+
+	cmd = command.New("export-config", short, long, runShipExportConfig, command.RequireSession, command.RequireAppName)
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly logs ship export-config --provider datadog --output-dir my-shipper
+  cd my-shipper && fly launch --no-deploy`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.StringSlice{
+			Name:        "provider",
+			Description: "The log providers to ship logs to. Can be specified multiple times.",
+			Default:     []string{"logtail"},
+		},
+		flag.String{
+			Name:        "output-dir",
+			Description: "Directory to write vector.toml and the Dockerfile to",
+		},
+		sinkOptionFlags(),
+	)
+
+	return cmd
+}
+
+func runShipExportConfig(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		client  = client.FromContext(ctx).API().GenqClient
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	var sinkProviders []*provider
+	for _, slug := range flag.GetStringSlice(ctx, "provider") {
+		p, err := findProvider(slug)
+		if err != nil {
+			return err
+		}
+		sinkProviders = append(sinkProviders, p)
+	}
+
+	options, err := sinkOptionsFromFlags(ctx, sinkProviders)
+	if err != nil {
+		return err
+	}
+
+	appResponse, err := gql.GetApp(ctx, client, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	orgSlug := appResponse.App.Organization.RawSlug
+
+	data, err := newVectorConfig(orgSlug, fmt.Sprintf("logs.%s.>", appName), sinkProviders, options).Encode()
+	if err != nil {
+		return fmt.Errorf("failed encoding the vector configuration: %w", err)
+	}
+
+	dir := flag.GetString(ctx, "output-dir")
+	if dir == "" {
+		_, err = io.Out.Write(data)
+		printShipExportGuidance(ctx, appName, sinkProviders)
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"vector.toml": data,
+		"Dockerfile":  []byte(vectorDockerfile()),
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, contents, 0o644); err != nil {
+			return fmt.Errorf("failed writing %s: %w", path, err)
+		}
+		fmt.Fprintf(io.ErrOut, "Wrote %s\n", path)
+	}
+
+	printShipExportGuidance(ctx, appName, sinkProviders)
+
+	return nil
+}
+
+// printShipExportGuidance explains how to provide vector with the NATS
+// credentials and the provider variables the exported configuration reads.
+func printShipExportGuidance(ctx context.Context, appName string, providers []*provider) {
+	var (
+		io = iostreams.FromContext(ctx)
+		cs = io.ColorScheme()
+	)
+
+	fmt.Fprintf(io.ErrOut, "\n%s\n", cs.Bold("Running the shipper"))
+	fmt.Fprintln(io.ErrOut, "Deploy the shipper as an app in the same organization, so it can reach the NATS log stream")
+	fmt.Fprintln(io.ErrOut, "over the private network. It reads these environment variables, which should be set as secrets:")
+	fmt.Fprintln(io.ErrOut)
+
+	for _, v := range vectorEnv(providers) {
+		fmt.Fprintf(io.ErrOut, "  %-24s %s%s\n", v.Name, v.Description, lo.Ternary(v.Required, "", " (optional)"))
+	}
+
+	fmt.Fprintf(io.ErrOut, "\nCreate the token with 'fly logs token create -a %s', then set it with\n", appName)
+	fmt.Fprintf(io.ErrOut, "'fly secrets set ACCESS_TOKEN=<token> %s'.\n", strings.Join(providerSecretArgs(providers), " "))
+	fmt.Fprintf(io.ErrOut, "Stop the managed shipper with 'fly logs unship -a %s' once yours ships logs.\n", appName)
+}
+
+// providerSecretArgs returns NAME=<value> placeholders for the variables the
+// sinks of providers require.
+func providerSecretArgs(providers []*provider) (args []string) {
+	for _, v := range vectorEnv(providers) {
+		if v.Required && v.Name != "ACCESS_TOKEN" {
+			args = append(args, v.Name+"=<value>")
+		}
+	}
+	return
+}
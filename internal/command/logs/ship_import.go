@@ -0,0 +1,254 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// Metadata keys recording the adoption of a self-managed log shipper.
+const (
+	metadataKeyShipperAdopted   = "fly_log_shipper_adopted"
+	metadataKeyShipperProviders = "fly_log_shipper_providers"
+)
+
+// shipperNameFragments identify the names self-managed log shipper apps
+// commonly go by.
+var shipperNameFragments = []string{"log-shipper", "logshipper", "log-ship", "vector"}
+
+func newShipImport() (cmd *cobra.Command) {
+	const (
+		short = "Adopt a self-managed log shipper"
+		long  = short + `
+
+Finds a log shipper app of the organization created by hand, e.g. from
+'fly logs ship export-config', and hands it to flyctl: its machine moves to the
+managed log shipper image, and the providers its secrets configure are
+recorded. Later 'fly logs ship' runs use it instead of creating another
+shipper, and 'fly logs ship status' and 'fly logs ship upgrade' manage it.
+`
+	)
+
+	cmd = command.New("import", short, long, runShipImport, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly logs ship import --org acme
+  fly logs ship import --org acme --shipper-app acme-vector`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.Yes(),
+		shipperAppFlag(),
+	)
+
+	return cmd
+}
+
+func runShipImport(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	managed, err := gql.GetAppsByRole(ctx, apiClient.GenqClient, ShipperAppRole, org.ID)
+	if err != nil {
+		return err
+	}
+	if len(managed.Apps.Nodes) > 0 {
+		fmt.Fprintf(io.Out, "The log shipper of %s, %s, is already managed by flyctl\n", org.Slug, managed.Apps.Nodes[0].Name)
+		return nil
+	}
+
+	appName := flag.GetString(ctx, "shipper-app")
+	if appName == "" {
+		candidates, err := shipperAppCandidates(ctx, org.ID)
+		if err != nil {
+			return err
+		}
+
+		switch len(candidates) {
+		case 0:
+			return fmt.Errorf("found no log shipper app in %s, pick one with --shipper-app", org.Slug)
+		case 1:
+			appName = candidates[0]
+		default:
+			return fmt.Errorf("found several log shipper apps in %s (%s), pick one with --shipper-app",
+				org.Slug, strings.Join(candidates, ", "))
+		}
+	}
+
+	appResponse, err := gql.GetApp(ctx, apiClient.GenqClient, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving shipper app %s: %w", appName, err)
+	}
+	if appResponse.App.Organization.Id != org.ID {
+		return fmt.Errorf("shipper app %s does not belong to the %s organization", appName, org.Slug)
+	}
+
+	secrets, err := apiClient.GetAppSecrets(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed listing the secrets of %s: %w", appName, err)
+	}
+	providers := detectShipperProviders(lo.Map(secrets, func(s api.Secret, _ int) string { return s.Name }))
+
+	flapsClient, err := flaps.New(ctx, gql.ToAppCompact(appResponse.App.AppData))
+	if err != nil {
+		return err
+	}
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed listing the machines of %s: %w", appName, err)
+	}
+	if len(machines) == 0 {
+		return fmt.Errorf("%s runs no machine to adopt", appName)
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Adopt %s as the log shipper of %s? Its machine %s restarts on the managed log shipper image.",
+			appName, org.Slug, machines[0].ID)
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := adoptShipperMachine(flaps.NewContext(ctx, flapsClient), machines[0], providers); err != nil {
+		return fmt.Errorf("failed adopting machine %s of %s: %w", machines[0].ID, appName, err)
+	}
+
+	fmt.Fprintf(io.Out, "Adopted %s as the log shipper of %s\n", appName, org.Slug)
+	if len(providers) > 0 {
+		fmt.Fprintf(io.Out, "It ships logs to %s\n", strings.Join(providers, ", "))
+	}
+
+	return nil
+}
+
+// shipperAppCandidates returns the names of the apps of the organization
+// looking like log shippers.
+func shipperAppCandidates(ctx context.Context, orgID string) ([]string, error) {
+	apps, err := client.FromContext(ctx).API().GetAppsForOrganization(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing apps: %w", err)
+	}
+
+	var names []string
+	for _, app := range apps {
+		if isShipperAppName(app.Name) {
+			names = append(names, app.Name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func isShipperAppName(name string) bool {
+	for _, fragment := range shipperNameFragments {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectShipperProviders returns the slugs of the providers whose required
+// variables are all among secretNames.
+func detectShipperProviders(secretNames []string) []string {
+	var slugs []string
+	for _, p := range providers {
+		required := lo.Filter(p.allVars(), func(v providerVar, _ int) bool {
+			return v.Required
+		})
+		if len(required) == 0 {
+			continue
+		}
+		if lo.EveryBy(required, func(v providerVar) bool { return lo.Contains(secretNames, v.Name) }) {
+			slugs = append(slugs, p.Slug)
+		}
+	}
+	return slugs
+}
+
+// adoptShipperMachine moves m to the managed log shipper image and records
+// its adoption, along with the providers it ships to, in its metadata.
+func adoptShipperMachine(ctx context.Context, m *api.Machine, providers []string) error {
+	leased, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, leased)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(leased.Config)
+	infra.Configure(config, infra.ComponentLogShipper, leased)
+	config.Metadata[metadataKeyShipperAdopted] = "true"
+	config.Metadata[metadataKeyShipperProviders] = strings.Join(providers, ",")
+
+	return mach.Update(ctx, leased, &api.LaunchMachineInput{
+		Name:   leased.Name,
+		Region: leased.Region,
+		Config: config,
+	})
+}
+
+// isAdoptedShipper reports whether m is the machine of an adopted log
+// shipper.
+func isAdoptedShipper(m *api.Machine) bool {
+	return m.Config != nil && m.Config.Metadata[metadataKeyShipperAdopted] == "true"
+}
+
+// findAdoptedShipperApp returns the log shipper app of targetOrg adopted with
+// 'fly logs ship import', or errNoShipperApp.
+func findAdoptedShipperApp(ctx context.Context, targetOrg gql.AppDataOrganization) (*gql.AppData, error) {
+	client := client.FromContext(ctx).API().GenqClient
+
+	candidates, err := shipperAppCandidates(ctx, targetOrg.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range candidates {
+		flapsClient, err := flaps.NewFromAppName(ctx, name)
+		if err != nil {
+			continue
+		}
+		machines, err := flapsClient.ListActive(ctx)
+		if err != nil || !lo.SomeBy(machines, isAdoptedShipper) {
+			continue
+		}
+
+		appResponse, err := gql.GetApp(ctx, client, name)
+		if err != nil {
+			return nil, err
+		}
+		return &appResponse.App.AppData, nil
+	}
+
+	return nil, errNoShipperApp
+}
@@ -0,0 +1,19 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsShipperAppName(t *testing.T) {
+	assert.True(t, isShipperAppName("acme-log-shipper"))
+	assert.True(t, isShipperAppName("acme-vector"))
+	assert.False(t, isShipperAppName("acme-web"))
+}
+
+func TestDetectShipperProviders(t *testing.T) {
+	secrets := []string{"ACCESS_TOKEN", "DATADOG_API_KEY", "AWS_BUCKET", "LOGTAIL_TOKEN"}
+	assert.Equal(t, []string{"logtail", "datadog"}, detectShipperProviders(secrets))
+	assert.Empty(t, detectShipperProviders([]string{"ACCESS_TOKEN"}))
+}
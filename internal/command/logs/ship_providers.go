@@ -0,0 +1,85 @@
+package logs
+
+import (
+	"context"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipProviders() (cmd *cobra.Command) {
+	const (
+		short = "List the providers logs can be shipped to"
+		long  = short + "\n"
+		usage = "providers"
+	)
+
+	cmd = command.New(usage, short, long, runShipProviders)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd, flag.JSONOutput())
+
+	return cmd
+}
+
+type providerVarInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+}
+
+type providerInfo struct {
+	Slug            string            `json:"slug"`
+	Name            string            `json:"name"`
+	AutoProvisioned bool              `json:"auto_provisioned"`
+	Vars            []providerVarInfo `json:"vars"`
+}
+
+func runShipProviders(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	if config.FromContext(ctx).JSONOutput {
+		infos := lo.Map(providers, func(p provider, _ int) providerInfo {
+			return providerInfo{
+				Slug:            p.Slug,
+				Name:            p.Name,
+				AutoProvisioned: p.AutoProvisioned,
+				Vars: lo.Map(p.Vars, func(v providerVar, _ int) providerVarInfo {
+					return providerVarInfo(v)
+				}),
+			}
+		})
+
+		return render.JSON(out, infos)
+	}
+
+	var rows [][]string
+	for _, p := range providers {
+		var required, optional []string
+		for _, v := range p.Vars {
+			if v.Required {
+				required = append(required, v.Name)
+			} else {
+				optional = append(optional, v.Name)
+			}
+		}
+
+		rows = append(rows, []string{
+			p.Slug,
+			p.Name,
+			strings.Join(required, ", "),
+			strings.Join(optional, ", "),
+			lo.Ternary(p.AutoProvisioned, "yes", "no"),
+		})
+	}
+
+	return render.Table(out, "", rows, "Slug", "Name", "Required Vars", "Optional Vars", "Auto Provisioned")
+}
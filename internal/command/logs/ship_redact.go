@@ -0,0 +1,267 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipRedact() (cmd *cobra.Command) {
+	const (
+		short = "Redact personal data from shipped logs"
+		long  = short + `
+
+The log shipper of the organization redacts the data the presets and custom
+regular expressions enabled for a provider cover from log messages, before
+they leave Fly.io. The presets are emails, credit_cards and bearer_tokens.
+`
+	)
+
+	cmd = command.New("redact", short, long, runShipRedactList, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly logs ship redact enable datadog --org acme --preset emails --preset bearer_tokens
+  fly logs ship redact enable loki --org acme --regex 'session=[a-f0-9]+'
+  fly logs ship redact test --preset credit_cards "paid with 4242-4242-4242-4242"`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+		shipperAppFlag(),
+	)
+
+	cmd.AddCommand(
+		newShipRedactEnable(),
+		newShipRedactDisable(),
+		newShipRedactTest(),
+	)
+
+	return cmd
+}
+
+func redactionFlags() flag.Set {
+	return flag.Set{
+		flag.StringSlice{
+			Name:        "preset",
+			Description: "Redaction presets: " + strings.Join(redactionPresetNames(), ", "),
+		},
+		flag.StringArray{
+			Name:        "regex",
+			Description: "Regular expressions whose matches are redacted. Can be specified multiple times.",
+		},
+	}
+}
+
+func newShipRedactEnable() (cmd *cobra.Command) {
+	const (
+		short = "Enable redactions for a provider"
+		long  = short + "\n"
+		usage = "enable <provider>"
+	)
+
+	cmd = command.New(usage, short, long, runShipRedactEnable, command.RequireSession)
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+		shipperAppFlag(),
+		redactionFlags(),
+	)
+
+	return cmd
+}
+
+func newShipRedactDisable() (cmd *cobra.Command) {
+	const (
+		short = "Disable redactions for a provider"
+		long  = short + `
+
+Disables the given presets and regular expressions, or every redaction of the
+provider when given none.
+`
+		usage = "disable <provider>"
+	)
+
+	cmd = command.New(usage, short, long, runShipRedactDisable, command.RequireSession)
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+		shipperAppFlag(),
+		redactionFlags(),
+	)
+
+	return cmd
+}
+
+func newShipRedactTest() (cmd *cobra.Command) {
+	const (
+		short = "Show log lines before and after redaction"
+		long  = short + `
+
+Redacts the given lines, or sample lines when given none, with the presets and
+regular expressions passed as flags, or every preset when given none. Nothing
+is sent to Fly.io.
+`
+		usage = "test [line...]"
+	)
+
+	cmd = command.New(usage, short, long, runShipRedactTest)
+
+	flag.Add(cmd, redactionFlags())
+
+	return cmd
+}
+
+// redactionFromFlags returns the redaction the flags of redactionFlags set.
+func redactionFromFlags(ctx context.Context) (redaction, error) {
+	r := redaction{
+		Presets: flag.GetStringSlice(ctx, "preset"),
+		Custom:  flag.GetStringArray(ctx, "regex"),
+	}
+	_, err := r.rules()
+	return r, err
+}
+
+func runShipRedactList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	_, _, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, settings.Redactions)
+	}
+
+	if len(settings.Redactions) == 0 {
+		fmt.Fprintln(out, "No redactions, logs are shipped as they are")
+		return nil
+	}
+
+	slugs := make([]string, 0, len(settings.Redactions))
+	for slug := range settings.Redactions {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	rows := make([][]string, 0, len(slugs))
+	for _, slug := range slugs {
+		r := settings.Redactions[slug]
+		rows = append(rows, []string{slug, strings.Join(r.Presets, ", "), strings.Join(r.Custom, ", ")})
+	}
+
+	return render.Table(out, "", rows, "Provider", "Presets", "Custom")
+}
+
+func runShipRedactEnable(ctx context.Context) error {
+	return updateRedaction(ctx, func(current, given redaction) (redaction, error) {
+		if given.isEmpty() {
+			return current, fmt.Errorf("pick what to redact with --preset or --regex")
+		}
+		return mergeRedaction(current, given.Presets, given.Custom), nil
+	})
+}
+
+func runShipRedactDisable(ctx context.Context) error {
+	return updateRedaction(ctx, func(current, given redaction) (redaction, error) {
+		if given.isEmpty() {
+			return redaction{}, nil
+		}
+		return removeRedaction(current, given.Presets, given.Custom), nil
+	})
+}
+
+// updateRedaction replaces the redaction of the provider the first argument
+// names on the log shipper with the one update derives from the current one
+// and the one the flags set.
+func updateRedaction(ctx context.Context, update func(current, given redaction) (redaction, error)) error {
+	io := iostreams.FromContext(ctx)
+
+	p, err := findProvider(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+	given, err := redactionFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	app, flapsClient, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+
+	updated, err := update(settings.Redactions[p.Slug], given)
+	if err != nil {
+		return err
+	}
+	if updated.isEmpty() {
+		delete(settings.Redactions, p.Slug)
+	} else {
+		settings.Redactions[p.Slug] = updated
+	}
+
+	if err := saveShipperSettings(flaps.NewContext(ctx, flapsClient), app, machine, settings); err != nil {
+		return err
+	}
+
+	if updated.isEmpty() {
+		fmt.Fprintf(io.Out, "Logs shipped to %s are no longer redacted\n", p.Name)
+	} else {
+		fmt.Fprintf(io.Out, "Logs shipped to %s are redacted with %s\n", p.Name,
+			strings.Join(append(append([]string{}, updated.Presets...), updated.Custom...), ", "))
+	}
+
+	return nil
+}
+
+func runShipRedactTest(ctx context.Context) error {
+	var (
+		io = iostreams.FromContext(ctx)
+		cs = io.ColorScheme()
+	)
+
+	r, err := redactionFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	if r.isEmpty() {
+		r.Presets = redactionPresetNames()
+	}
+
+	lines := flag.Args(ctx)
+	if len(lines) == 0 {
+		lines = redactionSamples
+	}
+
+	for _, line := range lines {
+		redacted, err := r.Redact(line)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(io.Out, "%s %s\n", cs.Red("-"), line)
+		fmt.Fprintf(io.Out, "%s %s\n\n", cs.Green("+"), redacted)
+	}
+
+	return nil
+}
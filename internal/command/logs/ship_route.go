@@ -0,0 +1,262 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipRoute() (cmd *cobra.Command) {
+	const (
+		short = "Route logs to providers by region"
+		long  = short + `
+
+Routing rules ship the logs of the apps running in a set of regions to a
+provider, with its own variables, e.g. to keep the logs of EU regions on the
+EU Datadog site for data residency. Rules without regions catch the logs no
+other rule matches. The log shipper of the organization runs the rules.
+
+Providers logs are routed to shouldn't also be set up with 'fly logs ship',
+as logs would reach them twice.
+`
+	)
+
+	cmd = command.New("route", short, long, runShipRouteList, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly logs ship route add eu --org acme --provider datadog --regions ams,cdg,fra --var DATADOG_SITE=datadoghq.eu
+  fly logs ship route add rest --org acme --provider datadog --default`
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+		shipperAppFlag(),
+	)
+
+	cmd.AddCommand(newShipRouteAdd(), newShipRouteRemove())
+
+	return cmd
+}
+
+func newShipRouteAdd() (cmd *cobra.Command) {
+	const (
+		short = "Add or replace a routing rule"
+		long  = short + `
+
+Variables passed with --var override the ones of the provider for this rule
+only. They're stored as secrets of the log shipper app.
+`
+		usage = "add <name>"
+	)
+
+	cmd = command.New(usage, short, long, runShipRouteAdd, command.RequireSession)
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+		shipperAppFlag(),
+		flag.String{
+			Name:        "provider",
+			Description: "The log provider to route logs to",
+		},
+		flag.StringSlice{
+			Name:        "regions",
+			Description: "Route the logs of the apps running in these regions",
+		},
+		flag.Bool{
+			Name:        "default",
+			Description: "Route the logs no other rule matches",
+		},
+		flag.StringArray{
+			Name:        "var",
+			Description: "Provider variables of the rule in the form of NAME=VALUE pairs. Can be specified multiple times.",
+		},
+	)
+
+	return cmd
+}
+
+func newShipRouteRemove() (cmd *cobra.Command) {
+	const (
+		short = "Remove a routing rule"
+		long  = short + "\n"
+		usage = "remove <name>"
+	)
+
+	cmd = command.New(usage, short, long, runShipRouteRemove, command.RequireSession)
+	cmd.Aliases = []string{"rm"}
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+		shipperAppFlag(),
+	)
+
+	return cmd
+}
+
+func runShipRouteList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	_, _, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+	rules := settings.Routes
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, rules)
+	}
+
+	if len(rules) == 0 {
+		fmt.Fprintln(out, "No routing rules, logs are shipped regardless of their region")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(rules))
+	for _, r := range rules {
+		rows = append(rows, []string{
+			r.Name,
+			r.Provider,
+			lo.Ternary(r.isDefault(), "(unmatched)", strings.Join(r.Regions, ", ")),
+			strings.Join(r.Vars, ", "),
+		})
+	}
+
+	return render.Table(out, "", rows, "Name", "Provider", "Regions", "Vars")
+}
+
+func runShipRouteAdd(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	rule := routeRule{
+		Name:     flag.FirstArg(ctx),
+		Provider: flag.GetString(ctx, "provider"),
+		Regions:  flag.GetStringSlice(ctx, "regions"),
+	}
+	switch {
+	case rule.Provider == "":
+		return fmt.Errorf("pick the provider to route logs to with --provider")
+	case len(rule.Regions) == 0 && !flag.GetBool(ctx, "default"):
+		return fmt.Errorf("pick the regions to route with --regions, or route unmatched logs with --default")
+	case len(rule.Regions) > 0 && flag.GetBool(ctx, "default"):
+		return fmt.Errorf("--regions and --default are mutually exclusive")
+	}
+	if err := rule.validate(); err != nil {
+		return err
+	}
+
+	p, _ := findProvider(rule.Provider)
+	vars, err := cmdutil.ParseKVStringsToMap(flag.GetStringArray(ctx, "var"))
+	if err != nil {
+		return err
+	}
+	secrets := map[string]string{}
+	for name, value := range vars {
+		if !lo.ContainsBy(p.allVars(), func(v providerVar) bool { return v.Name == name }) {
+			return fmt.Errorf("%s does not accept the %s variable", p.Name, name)
+		}
+		rule.Vars = append(rule.Vars, name)
+		secrets[rule.routeVar(name)] = value
+	}
+	sort.Strings(rule.Vars)
+
+	app, flapsClient, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+	settings.Routes = setRoute(settings.Routes, rule)
+
+	existing, err := apiClient.GetAppSecrets(ctx, app.Name)
+	if err != nil {
+		return fmt.Errorf("failed listing the secrets of %s: %w", app.Name, err)
+	}
+	if !lo.ContainsBy(existing, func(s api.Secret) bool { return s.Name == routesTokenVar }) {
+		token, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient, app.Name+"-routes", app.Organization.Id,
+			"read_organization_apps", &gql.LimitedAccessTokenOptions{}, "")
+		if err != nil {
+			return fmt.Errorf("failed creating the token routed logs are read with: %w", err)
+		}
+		secrets[routesTokenVar] = token.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader
+	}
+
+	if len(secrets) > 0 {
+		if _, err := apiClient.SetSecrets(ctx, app.Name, secrets); err != nil {
+			return fmt.Errorf("failed storing the variables of route %s: %w", rule.Name, err)
+		}
+	}
+
+	if err := saveShipperSettings(flaps.NewContext(ctx, flapsClient), app, machine, settings); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Logs %s are routed to %s by rule %s\n",
+		lo.Ternary(rule.isDefault(), "no other rule matches", "of "+strings.Join(rule.Regions, ", ")), p.Name, rule.Name)
+
+	return nil
+}
+
+func runShipRouteRemove(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		name      = flag.FirstArg(ctx)
+	)
+
+	app, flapsClient, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+	rule, found := lo.Find(settings.Routes, func(r routeRule) bool { return r.Name == name })
+	if !found {
+		return fmt.Errorf("log shipper %s has no route named %s", app.Name, name)
+	}
+	settings.Routes = lo.Reject(settings.Routes, func(r routeRule, _ int) bool { return r.Name == name })
+
+	if len(rule.Vars) > 0 {
+		keys := lo.Map(rule.Vars, func(v string, _ int) string { return rule.routeVar(v) })
+		if _, err := apiClient.UnsetSecrets(ctx, app.Name, keys); err != nil {
+			return fmt.Errorf("failed removing the variables of route %s: %w", name, err)
+		}
+	}
+
+	if err := saveShipperSettings(flaps.NewContext(ctx, flapsClient), app, machine, settings); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Removed route %s\n", name)
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipStatus() (cmd *cobra.Command) {
+	const (
+		short = "Show the log shipper of an organization"
+		long  = short + "\n"
+	)
+
+	cmd = command.New("status", short, long, runShipStatus, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+		shipperAppFlag(),
+	)
+
+	return cmd
+}
+
+func newShipUpgrade() (cmd *cobra.Command) {
+	const (
+		short = "Upgrade the log shipper of an organization"
+		long  = short + `
+
+Updates the log shipper machine to the image of its release channel, like
+'fly infra upgrade' does for every infra machine, and resizes it to --vm-size.
+`
+	)
+
+	cmd = command.New("upgrade", short, long, runShipUpgrade, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		shipperAppFlag(),
+		shipperVMSizeFlag(),
+	)
+
+	return cmd
+}
+
+// shipperStatus describes the log shipper of an organization.
+type shipperStatus struct {
+	App       string   `json:"app"`
+	Machine   string   `json:"machine"`
+	State     string   `json:"state"`
+	Region    string   `json:"region"`
+	Size      string   `json:"size"`
+	Channel   string   `json:"channel"`
+	Image     string   `json:"image"`
+	UpToDate  bool     `json:"up_to_date"`
+	Adopted   bool     `json:"adopted"`
+	Providers []string `json:"providers,omitempty"`
+}
+
+// findShipperMachine returns the machine of the log shipper of the
+// organization the --org flag picks.
+func findShipperMachine(ctx context.Context) (*gql.AppData, *flaps.Client, *api.Machine, error) {
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	app, err := findShipperApp(ctx, gql.AppDataOrganization{
+		Id:       org.ID,
+		Slug:     org.Slug,
+		RawSlug:  org.RawSlug,
+		PaidPlan: org.PaidPlan,
+	}, flag.GetString(ctx, "shipper-app"))
+	switch {
+	case errors.Is(err, errNoShipperApp):
+		return nil, nil, nil, fmt.Errorf("%s has no log shipper, set one up with 'fly logs ship' or adopt yours with 'fly logs ship import'", org.Slug)
+	case err != nil:
+		return nil, nil, nil, err
+	}
+
+	flapsClient, err := flaps.New(ctx, gql.ToAppCompact(*app))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed listing the machines of %s: %w", app.Name, err)
+	}
+	if len(machines) == 0 {
+		return nil, nil, nil, fmt.Errorf("log shipper %s runs no machine", app.Name)
+	}
+
+	return app, flapsClient, machines[0], nil
+}
+
+func runShipStatus(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	app, _, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+
+	channel := infra.ChannelOf(machine)
+	image, _ := infra.Image(infra.ComponentLogShipper, channel)
+	status := shipperStatus{
+		App:      app.Name,
+		Machine:  machine.ID,
+		State:    machine.State,
+		Region:   machine.Region,
+		Size:     machine.Config.Guest.ToSize(),
+		Channel:  channel,
+		Image:    machine.Config.Image,
+		UpToDate: machine.Config.Image == image,
+		Adopted:  isAdoptedShipper(machine),
+	}
+	if providers := machine.Config.Metadata[metadataKeyShipperProviders]; providers != "" {
+		status.Providers = strings.Split(providers, ",")
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, status)
+	}
+
+	cols := []string{"App", "Machine", "State", "Region", "Size", "Managed", "Channel", "Image", "Status", "Providers"}
+	row := []string{
+		status.App,
+		status.Machine,
+		status.State,
+		status.Region,
+		status.Size,
+		lo.Ternary(status.Adopted, "adopted", "flyctl"),
+		status.Channel,
+		status.Image,
+		lo.Ternary(status.UpToDate, "up to date", "upgrade available"),
+		strings.Join(status.Providers, ", "),
+	}
+
+	return render.VerticalTable(out, "Log Shipper", [][]string{row}, cols...)
+}
+
+func runShipUpgrade(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	app, flapsClient, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	channel := infra.ChannelOf(machine)
+	image, _ := infra.Image(infra.ComponentLogShipper, channel)
+	size := flag.GetString(ctx, "vm-size")
+	resize := size != "" && machine.Config.Guest.ToSize() != size
+	// Edge images are always pulled again, as their tag doesn't change
+	if machine.Config.Image == image && channel != infra.ChannelEdge && !resize {
+		fmt.Fprintf(io.Out, "Log shipper %s is up to date\n", app.Name)
+		return nil
+	}
+
+	leased, releaseLeaseFunc, err := mach.AcquireLease(ctx, machine)
+	defer releaseLeaseFunc(ctx, leased)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(leased.Config)
+	infra.SetChannel(config, infra.ComponentLogShipper, channel)
+	if resize {
+		if config.Guest == nil {
+			config.Guest = &api.MachineGuest{}
+		}
+		if err := config.Guest.SetSize(size); err != nil {
+			return err
+		}
+	}
+
+	if err := mach.Update(ctx, leased, &api.LaunchMachineInput{
+		Name:   leased.Name,
+		Region: leased.Region,
+		Config: config,
+	}); err != nil {
+		return fmt.Errorf("failed upgrading log shipper %s: %w", app.Name, err)
+	}
+
+	fmt.Fprintf(io.Out, "Upgraded log shipper %s to %s on a %s machine\n", app.Name, config.Image, config.Guest.ToSize())
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newShipUsage() (cmd *cobra.Command) {
+	const (
+		short = "Show the volume of logs shipped per app and per provider"
+		long  = short + `
+
+Reports the events and bytes the log shipper of the organization shipped each
+day, per app it reads the logs of and per provider it ships them to, e.g. to
+find the app dominating a provider's bill. Counting starts once usage metrics
+are enabled with 'fly logs ship usage enable'.
+`
+	)
+
+	cmd = command.New("usage", short, long, runShipUsage, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+		shipperAppFlag(),
+		flag.Int{
+			Name:        "days",
+			Description: "Number of days to report",
+			Default:     7,
+		},
+	)
+
+	cmd.AddCommand(newShipUsageToggle(true), newShipUsageToggle(false))
+
+	return cmd
+}
+
+func newShipUsageToggle(enable bool) (cmd *cobra.Command) {
+	usage, short := "disable", "Stop counting the logs the log shipper ships"
+	if enable {
+		usage, short = "enable", "Count the logs the log shipper ships"
+	}
+	long := short + "\n"
+
+	cmd = command.New(usage, short, long, func(ctx context.Context) error {
+		return runShipUsageToggle(ctx, enable)
+	}, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		shipperAppFlag(),
+	)
+
+	return cmd
+}
+
+func runShipUsageToggle(ctx context.Context, enable bool) error {
+	io := iostreams.FromContext(ctx)
+
+	app, flapsClient, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+
+	if settings.Usage == enable {
+		fmt.Fprintf(io.Out, "Usage metrics of log shipper %s are already %s\n", app.Name, lo.Ternary(enable, "enabled", "disabled"))
+		return nil
+	}
+	settings.Usage = enable
+
+	if err := saveShipperSettings(flaps.NewContext(ctx, flapsClient), app, machine, settings); err != nil {
+		return err
+	}
+
+	if enable {
+		fmt.Fprintf(io.Out, "Log shipper %s counts the logs it ships, see them with 'fly logs ship usage'\n", app.Name)
+	} else {
+		fmt.Fprintf(io.Out, "Log shipper %s no longer counts the logs it ships\n", app.Name)
+	}
+
+	return nil
+}
+
+// shipperUsage is the volume of logs a log shipper shipped.
+type shipperUsage struct {
+	Apps      []usageRow `json:"apps"`
+	Providers []usageRow `json:"providers"`
+}
+
+func runShipUsage(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	days := flag.GetInt(ctx, "days")
+	if days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+
+	app, _, machine, err := findShipperMachine(ctx)
+	if err != nil {
+		return err
+	}
+	settings, err := loadShipperSettings(machine)
+	if err != nil {
+		return err
+	}
+	if !settings.Usage {
+		return fmt.Errorf("log shipper %s doesn't count the logs it ships, enable it with 'fly logs ship usage enable'", app.Name)
+	}
+
+	orgSlug := app.Organization.Slug
+	var usage shipperUsage
+	if usage.Apps, err = queryUsage(ctx, orgSlug, days, true); err != nil {
+		return err
+	}
+	if usage.Providers, err = queryUsage(ctx, orgSlug, days, false); err != nil {
+		return err
+	}
+	for i := range usage.Providers {
+		usage.Providers[i].Name = sinkProviderName(usage.Providers[i].Name, settings.Routes)
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, usage)
+	}
+
+	if err := renderUsage(out, "Apps", "App", usage.Apps); err != nil {
+		return err
+	}
+	return renderUsage(out, "Providers", "Provider", usage.Providers)
+}
+
+func renderUsage(out io.Writer, title, nameCol string, rows []usageRow) error {
+	table := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		table = append(table, []string{r.Day, r.Name, humanize.Comma(r.Events), humanize.Bytes(uint64(r.Bytes))})
+	}
+	return render.Table(out, title, table, "Day", nameCol, "Events", "Bytes")
+}
+
+// queryUsage returns the daily usage of the last days of the log shipper of
+// orgSlug, per app when byApp is set or per sink otherwise, from the metrics
+// Fly.io scrapes.
+func queryUsage(ctx context.Context, orgSlug string, days int, byApp bool) ([]usageRow, error) {
+	label := "component_id"
+	if byApp {
+		label = usageAppLabel
+	}
+
+	eventsQuery, bytesQuery := usageQueries(byApp)
+	events, err := queryDaily(ctx, orgSlug, days, eventsQuery, label)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := queryDaily(ctx, orgSlug, days, bytesQuery, label)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeUsage(events, bytes), nil
+}
+
+// queryDaily runs query over the last days, one point per day, against the
+// Prometheus API of orgSlug.
+func queryDaily(ctx context.Context, orgSlug string, days int, query, label string) (map[[2]string]int64, error) {
+	cfg := config.FromContext(ctx)
+
+	// Points at midnight cover the day before
+	end := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	start := end.Add(-time.Duration(days-1) * 24 * time.Hour)
+
+	params := url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {"1d"},
+	}
+	endpoint := fmt.Sprintf("%s/prometheus/%s/api/v1/query_range?%s", cfg.APIBaseURL, url.PathEscape(orgSlug), params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", api.AuthorizationHeader(cfg.AccessToken))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying the metrics of %s: %w", orgSlug, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusUnprocessableEntity {
+		return nil, fmt.Errorf("failed querying the metrics of %s: %s", orgSlug, resp.Status)
+	}
+
+	return decodePromMatrix(data, label)
+}
@@ -0,0 +1,152 @@
+package logs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// ShipperAppRole is the role of the log shipper app of an organization.
+const ShipperAppRole = "log-shipper"
+
+const (
+	shipperAppSuffix = "-log-shipper"
+
+	// maxAppNameLength matches the length limit of a DNS label, as app names
+	// end up as hostnames.
+	maxAppNameLength = 63
+
+	shipperAppNameAttempts = 3
+)
+
+// LoggerAddOnName returns the name of the add-on provisioned to ship the logs
+// of appName to auto-provisioned providers.
+func LoggerAddOnName(appName string) string {
+	return appName + shipperAppSuffix
+}
+
+// LoggerAppName returns the name of the log shipper app for the organization
+// with the given slug.
+func LoggerAppName(orgSlug string) string {
+	return loggerAppName(orgSlug, 0)
+}
+
+// loggerAppName derives a shipper app name for orgSlug. The first attempt
+// uses the plain slug whenever it fits; long slugs and subsequent attempts are
+// truncated and suffixed with a short hash so names are both deterministic and
+// within the length limit.
+func loggerAppName(orgSlug string, attempt int) string {
+	if name := orgSlug + shipperAppSuffix; attempt == 0 && len(name) <= maxAppNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", orgSlug, attempt)))
+	hash := hex.EncodeToString(sum[:])[:6]
+
+	slug := orgSlug
+	if maxSlug := maxAppNameLength - len(shipperAppSuffix) - len(hash) - 1; len(slug) > maxSlug {
+		slug = strings.TrimRight(slug[:maxSlug], "-")
+	}
+
+	return slug + "-" + hash + shipperAppSuffix
+}
+
+func isNameTakenError(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "already been taken")
+}
+
+// errNoShipperApp is returned by findShipperApp when the organization has no
+// log shipper yet.
+var errNoShipperApp = errors.New("no log shipper app")
+
+// findShipperApp returns the app hosting the log shipper for targetOrg. When
+// appName is set, that existing app is used; otherwise the org's shipper is
+// looked up by role, or among adopted shippers.
+func findShipperApp(ctx context.Context, targetOrg gql.AppDataOrganization, appName string) (*gql.AppData, error) {
+	client := client.FromContext(ctx).API().GenqClient
+
+	if appName != "" {
+		appResult, err := gql.GetApp(ctx, client, appName)
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving shipper app %s: %w", appName, err)
+		}
+		if appResult.App.Organization.Id != targetOrg.Id {
+			return nil, fmt.Errorf("shipper app %s does not belong to the %s organization", appName, targetOrg.Slug)
+		}
+		return &appResult.App.AppData, nil
+	}
+
+	appsResult, err := gql.GetAppsByRole(ctx, client, ShipperAppRole, targetOrg.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(appsResult.Apps.Nodes) > 0 {
+		return &appsResult.Apps.Nodes[0].AppData, nil
+	}
+
+	// Self-managed shippers adopted with 'fly logs ship import' have no role
+	return findAdoptedShipperApp(ctx, targetOrg)
+}
+
+// resolveShipperApp returns the app hosting the log shipper for targetOrg,
+// like findShipperApp, creating it when the org has none.
+func resolveShipperApp(ctx context.Context, targetOrg gql.AppDataOrganization, appName string) (*gql.AppData, error) {
+	var (
+		client = client.FromContext(ctx).API().GenqClient
+		io     = iostreams.FromContext(ctx)
+	)
+
+	switch app, err := findShipperApp(ctx, targetOrg, appName); {
+	case err == nil:
+		return app, nil
+	case !errors.Is(err, errNoShipperApp):
+		return nil, err
+	}
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = targetOrg.Id
+	input.AppRoleId = ShipperAppRole
+
+	for attempt := 0; attempt < shipperAppNameAttempts; attempt++ {
+		input.Name = loggerAppName(targetOrg.RawSlug, attempt)
+
+		createdAppResult, err := gql.CreateApp(ctx, client, input)
+		switch {
+		case isNameTakenError(err):
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		fmt.Fprintf(io.ErrOut, "Provisioning a log shipper VM in the app named %s\n", input.Name)
+
+		// Other apps depend on the shipper, guard it against accidental removal
+		if err := config.SetProtected(state.SharedConfigFile(ctx), config.ProtectedApp, input.Name, true); err != nil {
+			terminal.Warnf("failed protecting %s from deletion: %v\n", input.Name, err)
+		}
+
+		return &createdAppResult.CreateApp.App.AppData, nil
+	}
+
+	return nil, fmt.Errorf("could not find an available name for the log shipper app, use --shipper-app to pick an existing app")
+}
+
+func shipperAppFlag() flag.String {
+	return flag.String{
+		Name:        "shipper-app",
+		Description: "Name of an existing app in the organization to run the log shipper in",
+	}
+}
@@ -0,0 +1,30 @@
+package logs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerAppName(t *testing.T) {
+	assert.Equal(t, "personal-log-shipper", LoggerAppName("personal"))
+
+	long := strings.Repeat("a", 60)
+	name := LoggerAppName(long)
+	assert.LessOrEqual(t, len(name), maxAppNameLength)
+	assert.True(t, strings.HasSuffix(name, shipperAppSuffix))
+	assert.Equal(t, name, LoggerAppName(long), "names must be deterministic")
+
+	retry := loggerAppName("personal", 1)
+	assert.NotEqual(t, "personal-log-shipper", retry)
+	assert.NotEqual(t, retry, loggerAppName("personal", 2))
+	assert.True(t, strings.HasPrefix(retry, "personal-"))
+}
+
+func TestIsNameTakenError(t *testing.T) {
+	assert.True(t, isNameTakenError(errors.New("Validation failed: Name has already been taken")))
+	assert.False(t, isNameTakenError(errors.New("unauthorized")))
+	assert.False(t, isNameTakenError(nil))
+}
@@ -0,0 +1,217 @@
+package logs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/gql"
+	mach "github.com/superfly/flyctl/internal/machine"
+)
+
+// redactionsPath is where the log shipper machine stores the redactions of
+// the providers it ships to.
+const redactionsPath = "/etc/vector/redactions.json"
+
+// shipperSettings are the routing rules, redactions, usage reporting and
+// aggregations stored on the log shipper machine, from which flyctl generates
+// the vector configuration running them.
+type shipperSettings struct {
+	Routes []routeRule
+
+	// Aggregations are the apps of other orgs a central shipper ships the
+	// forwarded logs of.
+	Aggregations []aggregation
+
+	// Redactions are keyed by provider slug.
+	Redactions map[string]redaction
+
+	// Usage enables the metrics 'fly logs ship usage' reports from.
+	Usage bool
+}
+
+// loadShipperSettings returns the settings stored on the log shipper
+// machine m.
+func loadShipperSettings(m *api.Machine) (*shipperSettings, error) {
+	s := &shipperSettings{Redactions: map[string]redaction{}}
+	if m.Config == nil {
+		return s, nil
+	}
+
+	for _, f := range m.Config.Files {
+		if f.GuestPath == usageConfigPath {
+			s.Usage = true
+		}
+		if f.RawValue == nil || (f.GuestPath != routesPath && f.GuestPath != redactionsPath && f.GuestPath != aggregationsPath) {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(*f.RawValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding %s: %w", f.GuestPath, err)
+		}
+
+		switch f.GuestPath {
+		case routesPath:
+			if s.Routes, err = decodeRoutes(data); err != nil {
+				return nil, err
+			}
+		case redactionsPath:
+			if err := json.Unmarshal(data, &s.Redactions); err != nil {
+				return nil, fmt.Errorf("failed decoding redactions: %w", err)
+			}
+		case aggregationsPath:
+			if s.Aggregations, err = decodeAggregations(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// isShipperSettingsFile reports whether the file at path is generated from
+// shipper settings.
+func isShipperSettingsFile(path string) bool {
+	return path == routesPath || path == routesConfigPath || path == redactionsPath || path == usageConfigPath ||
+		path == aggregationsPath || path == aggregatorConfigPath || strings.HasPrefix(path, "/etc/vector/redact/")
+}
+
+// apply writes the files and environment variables running s to config,
+// replacing the ones of previous settings.
+func (s *shipperSettings) apply(config *api.MachineConfig, orgSlug string) error {
+	config.Files = lo.Reject(config.Files, func(f *api.File, _ int) bool {
+		return isShipperSettingsFile(f.GuestPath)
+	})
+	for name := range config.Env {
+		if strings.HasSuffix(name, "_REDACT_VRL") {
+			delete(config.Env, name)
+		}
+	}
+
+	addFile := func(path string, data []byte) {
+		config.Files = append(config.Files, &api.File{
+			GuestPath: path,
+			RawValue:  api.Pointer(base64.StdEncoding.EncodeToString(data)),
+		})
+	}
+
+	if len(s.Routes) > 0 {
+		data, err := json.Marshal(s.Routes)
+		if err != nil {
+			return err
+		}
+		addFile(routesPath, data)
+
+		vectorCfg, err := routedVectorConfig(orgSlug, s.Routes, s.Redactions)
+		if err != nil {
+			return err
+		}
+		toml, err := vectorCfg.Encode()
+		if err != nil {
+			return fmt.Errorf("failed encoding the vector configuration of the routes: %w", err)
+		}
+		addFile(routesConfigPath, toml)
+	}
+
+	if len(s.Aggregations) > 0 {
+		data, err := json.Marshal(s.Aggregations)
+		if err != nil {
+			return err
+		}
+		addFile(aggregationsPath, data)
+
+		vectorCfg, err := aggregatorVectorConfig(s.Aggregations)
+		if err != nil {
+			return err
+		}
+		toml, err := vectorCfg.Encode()
+		if err != nil {
+			return fmt.Errorf("failed encoding the vector configuration of the aggregations: %w", err)
+		}
+		addFile(aggregatorConfigPath, toml)
+	}
+
+	redactions := lo.OmitBy(s.Redactions, func(_ string, r redaction) bool { return r.isEmpty() })
+	if len(redactions) > 0 {
+		data, err := json.Marshal(redactions)
+		if err != nil {
+			return err
+		}
+		addFile(redactionsPath, data)
+
+		slugs := lo.Keys(redactions)
+		sort.Strings(slugs)
+		for _, slug := range slugs {
+			p, err := findProvider(slug)
+			if err != nil {
+				return err
+			}
+			vrl, err := redactions[slug].vrl()
+			if err != nil {
+				return err
+			}
+
+			// The sinks of the shipper remap logs with the program the
+			// variable points at before shipping them
+			addFile(redactionVRLPath(slug), []byte(vrl))
+			if config.Env == nil {
+				config.Env = map[string]string{}
+			}
+			config.Env[p.envPrefix()+"_REDACT_VRL"] = redactionVRLPath(slug)
+		}
+	}
+
+	if config.Metrics != nil && config.Metrics.Port == usageMetricsPort {
+		config.Metrics = nil
+	}
+	if s.Usage {
+		toml, err := usageVectorConfig(len(s.Routes) > 0).Encode()
+		if err != nil {
+			return fmt.Errorf("failed encoding the vector configuration of the usage metrics: %w", err)
+		}
+		addFile(usageConfigPath, toml)
+		config.Metrics = &api.MachineMetrics{Port: usageMetricsPort, Path: "/metrics"}
+	}
+
+	return nil
+}
+
+// saveShipperSettings stores s on the log shipper machine m of app, along
+// with the vector configuration running them, and restarts it.
+func saveShipperSettings(ctx context.Context, app *gql.AppData, m *api.Machine, s *shipperSettings) error {
+	return updateShipperSettings(ctx, app, m, s, nil)
+}
+
+// updateShipperSettings saves s like saveShipperSettings, along with the
+// changes edit makes to the config of m.
+func updateShipperSettings(ctx context.Context, app *gql.AppData, m *api.Machine, s *shipperSettings, edit func(*api.MachineConfig)) error {
+	leased, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, leased)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(leased.Config)
+	if err := s.apply(config, app.Organization.RawSlug); err != nil {
+		return err
+	}
+	if edit != nil {
+		edit(config)
+	}
+
+	if err := mach.Update(ctx, leased, &api.LaunchMachineInput{
+		Name:   leased.Name,
+		Region: leased.Region,
+		Config: config,
+	}); err != nil {
+		return fmt.Errorf("failed updating the settings of log shipper %s: %w", app.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+// sinkOptions tune how a sink batches, compresses and encodes the logs it
+// ships, trading provider ingest cost against latency, and for object
+// storage, under which keys.
+type sinkOptions struct {
+	Compression string
+	Codec       string
+	BatchSize   int
+
+	// KeyPrefix is the template of the keys of the objects the sink writes.
+	KeyPrefix string
+}
+
+// sinkOptionFlags returns the flags setting sinkOptions. Values may be
+// prefixed with a provider slug, as in datadog=zstd, to only apply to the
+// sink of that provider.
+func sinkOptionFlags() flag.Set {
+	return flag.Set{
+		flag.StringArray{
+			Name:        "compression",
+			Description: "Compression of the logs sent to the provider, e.g. gzip or zstd. Prefix with PROVIDER= to target one provider.",
+		},
+		flag.StringArray{
+			Name:        "encoding-codec",
+			Description: "Encoding of the logs sent to the provider, e.g. json or text. Prefix with PROVIDER= to target one provider.",
+		},
+		flag.StringArray{
+			Name:        "batch-size",
+			Description: "Maximum size in bytes of the batches sent to the provider. Prefix with PROVIDER= to target one provider.",
+		},
+		flag.StringArray{
+			Name:        "partition",
+			Description: "Key prefix of the objects logs are archived as: " + strings.Join(partitionPresetNames(), ", ") + ", or a custom template. Prefix with PROVIDER= to target one provider.",
+		},
+	}
+}
+
+// sinkOptionsFromFlags returns the sinkOptions the flags of sinkOptionFlags
+// set for each of providers, by provider slug.
+func sinkOptionsFromFlags(ctx context.Context, providers []*provider) (map[string]sinkOptions, error) {
+	options := make(map[string]sinkOptions, len(providers))
+
+	for _, name := range []string{"compression", "encoding-codec", "batch-size", "partition"} {
+		for _, value := range flag.GetStringArray(ctx, name) {
+			// Only provider slugs target a sink, as custom partitions may hold = signs
+			slug, targetedValue, targeted := strings.Cut(value, "=")
+			if _, err := findProvider(slug); targeted && err == nil {
+				value = targetedValue
+			} else {
+				slug = ""
+			}
+
+			matched := false
+			for _, p := range providers {
+				if slug != "" && slug != p.Slug {
+					continue
+				}
+				matched = true
+
+				o := options[p.Slug]
+				if err := o.set(p, name, value); err != nil {
+					return nil, err
+				}
+				options[p.Slug] = o
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("--%s targets %s, which logs aren't shipped to", name, slug)
+			}
+		}
+	}
+
+	return options, nil
+}
+
+func (o *sinkOptions) set(p *provider, name, value string) error {
+	switch name {
+	case "compression":
+		if !slices.Contains(p.compressions, value) {
+			return fmt.Errorf("%s doesn't support %s compression, valid options are: %s", p.Name, value, strings.Join(p.compressions, ", "))
+		}
+		o.Compression = value
+	case "encoding-codec":
+		if len(p.codecs) == 0 {
+			return fmt.Errorf("%s doesn't support picking an encoding codec", p.Name)
+		}
+		if !slices.Contains(p.codecs, value) {
+			return fmt.Errorf("%s doesn't support the %s encoding codec, valid options are: %s", p.Name, value, strings.Join(p.codecs, ", "))
+		}
+		o.Codec = value
+	case "batch-size":
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("--batch-size must be a positive number of bytes, not %q", value)
+		}
+		o.BatchSize = size
+	case "partition":
+		if !p.partitioned {
+			return fmt.Errorf("%s doesn't store logs as objects, --partition only applies to object storage", p.Name)
+		}
+		prefix, err := partitionKeyPrefix(value)
+		if err != nil {
+			return err
+		}
+		o.KeyPrefix = prefix
+	}
+	return nil
+}
+
+// envPrefix returns the prefix of the variables configuring the sink of p on
+// the managed log shipper.
+func (p *provider) envPrefix() string {
+	return strings.ToUpper(p.Slug)
+}
+
+// vars returns o as variables of the managed log shipper configuring the sink
+// of p, like PAPERTRAIL_ENCODING_CODEC does for Papertrail.
+func (o sinkOptions) vars(p *provider) map[string]string {
+	vars := map[string]string{}
+	if o.Compression != "" {
+		vars[p.envPrefix()+"_COMPRESSION"] = o.Compression
+	}
+	if o.Codec != "" {
+		vars[p.envPrefix()+"_ENCODING_CODEC"] = o.Codec
+	}
+	if o.BatchSize > 0 {
+		vars[p.envPrefix()+"_BATCH_MAX_BYTES"] = strconv.Itoa(o.BatchSize)
+	}
+	if o.KeyPrefix != "" {
+		vars[p.envPrefix()+"_KEY_PREFIX"] = o.KeyPrefix
+	}
+	return vars
+}
+
+// apply sets o on the options of a vector sink.
+func (o sinkOptions) apply(sink map[string]any) {
+	if o.Compression != "" {
+		sink["compression"] = o.Compression
+	}
+	if o.Codec != "" {
+		encoding := map[string]any{}
+		if current, ok := sink["encoding"].(map[string]any); ok {
+			for k, v := range current {
+				encoding[k] = v
+			}
+		}
+		encoding["codec"] = o.Codec
+		sink["encoding"] = encoding
+	}
+	if o.BatchSize > 0 {
+		batch := map[string]any{}
+		if current, ok := sink["batch"].(map[string]any); ok {
+			for k, v := range current {
+				batch[k] = v
+			}
+		}
+		batch["max_bytes"] = o.BatchSize
+		sink["batch"] = batch
+	}
+	if o.KeyPrefix != "" {
+		sink["key_prefix"] = o.KeyPrefix
+	}
+}
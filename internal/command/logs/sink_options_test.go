@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinkOptionsSet(t *testing.T) {
+	loki, err := findProvider("loki")
+	require.NoError(t, err)
+	datadog, err := findProvider("datadog")
+	require.NoError(t, err)
+
+	var o sinkOptions
+	assert.NoError(t, o.set(loki, "compression", "snappy"))
+	assert.ErrorContains(t, o.set(loki, "compression", "zstd"), "valid options are: none, gzip, snappy")
+	assert.ErrorContains(t, o.set(datadog, "encoding-codec", "json"), "doesn't support picking an encoding codec")
+	assert.ErrorContains(t, o.set(loki, "batch-size", "-1"), "positive number of bytes")
+
+	assert.NoError(t, o.set(loki, "encoding-codec", "logfmt"))
+	assert.NoError(t, o.set(loki, "batch-size", "2048"))
+	assert.Equal(t, map[string]string{
+		"LOKI_COMPRESSION":     "snappy",
+		"LOKI_ENCODING_CODEC":  "logfmt",
+		"LOKI_BATCH_MAX_BYTES": "2048",
+	}, o.vars(loki))
+}
+
+func TestSinkOptionsApplyKeepsEncoding(t *testing.T) {
+	sink := map[string]any{"encoding": map[string]any{"codec": "json", "timestamp_format": "rfc3339"}}
+	sinkOptions{Codec: "text"}.apply(sink)
+
+	assert.Equal(t, map[string]any{"codec": "text", "timestamp_format": "rfc3339"}, sink["encoding"])
+}
+
+func TestSinkOptionsPartition(t *testing.T) {
+	s3, err := findProvider("aws_s3")
+	require.NoError(t, err)
+	loki, err := findProvider("loki")
+	require.NoError(t, err)
+
+	var o sinkOptions
+	assert.ErrorContains(t, o.set(loki, "partition", "app/date"), "only applies to object storage")
+	assert.ErrorContains(t, o.set(s3, "partition", "yearly"), "unknown partition")
+
+	require.NoError(t, o.set(s3, "partition", "hive"))
+	assert.Equal(t, "app={{ fly.app.name }}/date=%F/", o.KeyPrefix)
+
+	require.NoError(t, o.set(s3, "partition", "logs/{{ fly.region }}/%Y"))
+	assert.Equal(t, map[string]string{"AWS_S3_KEY_PREFIX": "logs/{{ fly.region }}/%Y/"}, o.vars(s3))
+
+	sink := map[string]any{}
+	o.apply(sink)
+	assert.Equal(t, "logs/{{ fly.region }}/%Y/", sink["key_prefix"])
+}
@@ -0,0 +1,154 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// logsTokenProfile is the limited access token profile granting read-only
+// access to the logs of an app.
+const logsTokenProfile = "logs"
+
+const (
+	// defaultLogsTokenExpiry is how long logs tokens are valid by default.
+	defaultLogsTokenExpiry = 7 * 24 * time.Hour
+	// maxLogsTokenExpiry is the longest expiry allowed without --long-lived.
+	maxLogsTokenExpiry = 90 * 24 * time.Hour
+)
+
+func newToken() *cobra.Command {
+	const (
+		long  = `Manage tokens limited to reading the logs of an app.`
+		short = "Manage logs tokens"
+	)
+
+	cmd := command.New("token", short, long, nil)
+
+	cmd.AddCommand(newTokenCreate())
+
+	return cmd
+}
+
+func newTokenCreate() *cobra.Command {
+	const (
+		long = `Create an API token limited to reading the logs of a single app, to hand to
+third parties or dashboards tailing them. The token can't read anything else,
+nor change the app. Tokens are valid for 7 days by default, and for at most
+90 days unless --long-lived is passed.
+
+List and revoke logs tokens like other tokens, with TOKENS LIST and
+TOKENS REVOKE.`
+		short = "Create a logs token"
+	)
+
+	cmd := command.New("create", short, long, runTokenCreate,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly logs token create -a my-app --expiry 720h
+  FLY_API_TOKEN=<token> fly logs -a my-app`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "name",
+			Shorthand:   "n",
+			Description: "Token name",
+			Default:     "flyctl logs token",
+		},
+		flag.Duration{
+			Name:        "expiry",
+			Shorthand:   "x",
+			Description: "The duration that the token will be valid",
+			Default:     defaultLogsTokenExpiry,
+		},
+		flag.Bool{
+			Name:        "long-lived",
+			Description: "Allow an expiry longer than 90 days",
+		},
+	)
+
+	return cmd
+}
+
+func runTokenCreate(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	expiry := flag.GetDuration(ctx, "expiry")
+	if err := checkLogsTokenExpiry(expiry, flag.GetBool(ctx, "long-lived")); err != nil {
+		return err
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	resp, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient,
+		flag.GetString(ctx, "name"),
+		app.Organization.ID,
+		logsTokenProfile,
+		logsTokenOptions(app.ID),
+		expiry.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed creating logs token: %w", err)
+	}
+
+	token := resp.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader
+
+	if config.FromContext(ctx).JSONOutput {
+		output := map[string]string{
+			"token":      token,
+			"app":        app.Name,
+			"expires_at": time.Now().Add(expiry).UTC().Format(time.RFC3339),
+		}
+		return render.JSON(io.Out, output)
+	}
+
+	fmt.Fprintln(io.Out, token)
+	fmt.Fprintf(io.ErrOut, "\nTail the logs of %s with FLY_API_TOKEN=<token> fly logs -a %s, or poll\n", app.Name, app.Name)
+	fmt.Fprintf(io.ErrOut, "%s/api/v1/apps/%s/logs with the token as Authorization header\n",
+		config.FromContext(ctx).APIBaseURL, app.Name)
+
+	return nil
+}
+
+// checkLogsTokenExpiry fails for expiries that aren't positive, and for those
+// longer than maxLogsTokenExpiry unless longLived.
+func checkLogsTokenExpiry(expiry time.Duration, longLived bool) error {
+	switch {
+	case expiry <= 0:
+		return errors.New("--expiry must be positive")
+	case expiry > maxLogsTokenExpiry && !longLived:
+		return fmt.Errorf("--expiry %s is longer than %d days, pass --long-lived to create a token valid that long", expiry, int(maxLogsTokenExpiry.Hours()/24))
+	default:
+		return nil
+	}
+}
+
+// logsTokenOptions scopes a logs token to the app appID.
+func logsTokenOptions(appID string) *gql.LimitedAccessTokenOptions {
+	return &gql.LimitedAccessTokenOptions{"app_id": appID}
+}
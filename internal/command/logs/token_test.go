@@ -0,0 +1,24 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/gql"
+)
+
+func TestCheckLogsTokenExpiry(t *testing.T) {
+	assert.NoError(t, checkLogsTokenExpiry(defaultLogsTokenExpiry, false))
+	assert.NoError(t, checkLogsTokenExpiry(maxLogsTokenExpiry, false))
+	assert.ErrorContains(t, checkLogsTokenExpiry(0, false), "must be positive")
+	assert.ErrorContains(t, checkLogsTokenExpiry(-time.Hour, true), "must be positive")
+	assert.ErrorContains(t, checkLogsTokenExpiry(maxLogsTokenExpiry+time.Hour, false), "pass --long-lived")
+	assert.NoError(t, checkLogsTokenExpiry(365*24*time.Hour, true))
+}
+
+func TestLogsTokenOptions(t *testing.T) {
+	assert.Equal(t, &gql.LimitedAccessTokenOptions{"app_id": "app123"}, logsTokenOptions("app123"))
+	assert.Equal(t, "logs", logsTokenProfile)
+}
@@ -19,7 +19,7 @@ import (
 
 func newUnship() (cmd *cobra.Command) {
 	const (
-		short = "Stop shipping application logs to Logtail"
+		short = "Stop shipping application logs to a third-party provider"
 		long  = short + "\n"
 	)
 
@@ -27,6 +27,8 @@ func newUnship() (cmd *cobra.Command) {
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
+		providerFlag(),
+		shipperAppFlag(),
 	)
 	return cmd
 }
@@ -49,19 +51,26 @@ func runUnship(ctx context.Context) (err error) {
 	targetApp := appNameResponse.App.AppData
 	targetOrg := targetApp.Organization
 
-	_, err = gql.DeleteAddOn(ctx, client, appName+"-log-shipper")
-
+	p, err := findProvider(flag.GetString(ctx, "provider"))
 	if err != nil {
-		return
+		return err
+	}
+
+	if p.AutoProvisioned {
+		_, err = gql.DeleteAddOn(ctx, client, LoggerAddOnName(appName))
+
+		if err != nil {
+			return
+		}
 	}
 
-	flapsClient, machine, err := EnsureShipperMachine(ctx, targetOrg)
+	flapsClient, machine, err := EnsureShipperMachine(ctx, targetOrg, flag.GetString(ctx, "shipper-app"))
 
 	if err != nil {
 		return
 	}
 
-	cmd := []string{"/remove-logger.sh", targetApp.Name, "logtail"}
+	cmd := []string{"/remove-logger.sh", targetApp.Name, p.Slug}
 
 	request := &api.MachineExecRequest{
 		Cmd: strings.Join(cmd, " "),
@@ -73,6 +82,6 @@ func runUnship(ctx context.Context) (err error) {
 		fmt.Fprintf(io.ErrOut, response.StdErr)
 		return err
 	}
-	fmt.Fprintf(out, "Logs for %s are no longer being shipped, but older logs are still preserved in Logtail.\n", appName)
+	fmt.Fprintf(out, "Logs for %s are no longer being shipped, but older logs are still preserved in %s.\n", appName, p.Name)
 	return
 }
@@ -0,0 +1,186 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// usageConfigPath is where the log shipper machine stores the vector
+	// configuration counting the logs it ships.
+	usageConfigPath = "/etc/vector/sinks/usage.toml"
+
+	// usageMetricsPort is the port the log shipper exports its metrics on, for
+	// Fly.io to scrape them.
+	usageMetricsPort = 9598
+
+	usagePrefix    = "usage_"
+	usageNamespace = "fly_log_shipper"
+
+	// usageAppLabel is the label of the counters holding the name of the app
+	// logs come from. Fly.io sets the app label to the log shipper's own name.
+	usageAppLabel = "shipped_app"
+)
+
+// usageVectorConfig returns the vector configuration exporting the metrics
+// of the log shipper along with counters of the events and bytes it reads per
+// app, from the managed pipeline and the routed one when routed is set.
+func usageVectorConfig(routed bool) *vectorConfig {
+	const (
+		internal = usagePrefix + "internal_metrics"
+		sized    = usagePrefix + "sized"
+		counters = usagePrefix + "counters"
+	)
+
+	// log_json is the transform parsing logs in the log shipper image
+	inputs := []string{"log_json"}
+	if routed {
+		inputs = append(inputs, routesPrefix+vectorTransformName)
+	}
+
+	tags := map[string]any{usageAppLabel: "{{ fly.app.name }}"}
+
+	return &vectorConfig{
+		Sources: map[string]any{
+			internal: map[string]any{"type": "internal_metrics"},
+		},
+		Transforms: map[string]any{
+			sized: map[string]any{
+				"type":   "remap",
+				"inputs": inputs,
+				"source": ".usage_bytes = strlen(encode_json(.))",
+			},
+			counters: map[string]any{
+				"type":   "log_to_metric",
+				"inputs": []string{sized},
+				"metrics": []map[string]any{
+					{"type": "counter", "field": "usage_bytes", "name": "events_total", "namespace": usageNamespace, "tags": tags},
+					{"type": "counter", "field": "usage_bytes", "name": "bytes_total", "namespace": usageNamespace, "tags": tags, "increment_by_value": true},
+				},
+			},
+		},
+		Sinks: map[string]any{
+			usagePrefix + "metrics": map[string]any{
+				"type":    "prometheus_exporter",
+				"inputs":  []string{internal, counters},
+				"address": fmt.Sprintf("0.0.0.0:%d", usageMetricsPort),
+			},
+		},
+	}
+}
+
+// usageRow is the volume a log shipper shipped for an app, or to a sink, on
+// a day.
+type usageRow struct {
+	Day    string `json:"day"`
+	Name   string `json:"name"`
+	Events int64  `json:"events"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// usageQueries returns the PromQL queries of the daily events and bytes
+// shipped, per app when byApp is set or per sink otherwise.
+func usageQueries(byApp bool) (events, bytes string) {
+	if byApp {
+		return fmt.Sprintf("sum by (%s) (increase(%s_events_total[1d]))", usageAppLabel, usageNamespace),
+			fmt.Sprintf("sum by (%s) (increase(%s_bytes_total[1d]))", usageAppLabel, usageNamespace)
+	}
+
+	sinks := fmt.Sprintf(`component_kind="sink",component_id!="%smetrics"`, usagePrefix)
+	return fmt.Sprintf("sum by (component_id) (increase(vector_component_sent_events_total{%s}[1d]))", sinks),
+		fmt.Sprintf("sum by (component_id) (increase(vector_component_sent_event_bytes_total{%s}[1d]))", sinks)
+}
+
+// promMatrix is the response of the Prometheus range query API.
+type promMatrix struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// decodePromMatrix returns the daily values of the range query response
+// data, keyed by day and the value of label.
+func decodePromMatrix(data []byte, label string) (map[[2]string]int64, error) {
+	var m promMatrix
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed decoding metrics: %w", err)
+	}
+	if m.Status != "success" {
+		return nil, fmt.Errorf("failed querying metrics: %s", m.Error)
+	}
+
+	values := map[[2]string]int64{}
+	for _, series := range m.Data.Result {
+		name := series.Metric[label]
+		if name == "" {
+			continue
+		}
+		for _, v := range series.Values {
+			ts, ok := v[0].(float64)
+			if !ok {
+				continue
+			}
+			s, _ := v[1].(string)
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				continue
+			}
+			day := time.Unix(int64(ts), 0).UTC().Add(-24 * time.Hour).Format(time.DateOnly)
+			values[[2]string{day, name}] += int64(f)
+		}
+	}
+	return values, nil
+}
+
+// mergeUsage returns the rows of the daily events and bytes, ordered by day
+// and then by decreasing bytes.
+func mergeUsage(events, bytes map[[2]string]int64) []usageRow {
+	keys := map[[2]string]bool{}
+	for k := range events {
+		keys[k] = true
+	}
+	for k := range bytes {
+		keys[k] = true
+	}
+
+	rows := make([]usageRow, 0, len(keys))
+	for k := range keys {
+		rows = append(rows, usageRow{Day: k[0], Name: k[1], Events: events[k], Bytes: bytes[k]})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Day != rows[j].Day {
+			return rows[i].Day < rows[j].Day
+		}
+		if rows[i].Bytes != rows[j].Bytes {
+			return rows[i].Bytes > rows[j].Bytes
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// sinkProviderName returns the name of the provider the log shipper sink of
+// the given name ships to, looking routed sinks up in rules.
+func sinkProviderName(sink string, rules []routeRule) string {
+	slug := sink
+	if name, ok := strings.CutPrefix(sink, routesPrefix); ok {
+		for _, r := range rules {
+			if r.Name == name {
+				slug = r.Provider
+			}
+		}
+	}
+	if p, err := findProvider(slug); err == nil {
+		return p.Name
+	}
+	return sink
+}
@@ -0,0 +1,65 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestUsageSettings(t *testing.T) {
+	config := &api.MachineConfig{}
+	require.NoError(t, (&shipperSettings{Usage: true}).apply(config, "acme"))
+	assert.Equal(t, &api.MachineMetrics{Port: usageMetricsPort, Path: "/metrics"}, config.Metrics)
+
+	settings, err := loadShipperSettings(&api.Machine{Config: config})
+	require.NoError(t, err)
+	assert.True(t, settings.Usage)
+
+	settings.Usage = false
+	require.NoError(t, settings.apply(config, "acme"))
+	assert.Nil(t, config.Metrics)
+	assert.Empty(t, config.Files)
+}
+
+func TestUsageVectorConfigInputs(t *testing.T) {
+	sized := usageVectorConfig(false).Transforms["usage_sized"].(map[string]any)
+	assert.Equal(t, []string{"log_json"}, sized["inputs"])
+
+	sized = usageVectorConfig(true).Transforms["usage_sized"].(map[string]any)
+	assert.Equal(t, []string{"log_json", "routed_fly_log_json"}, sized["inputs"])
+}
+
+func TestDecodePromMatrix(t *testing.T) {
+	data := []byte(`{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{"shipped_app":"web"},"values":[[1696204800,"2048"],[1696291200,"4096.5"]]},
+		{"metric":{"shipped_app":"worker"},"values":[[1696291200,"8192"]]}
+	]}}`)
+
+	values, err := decodePromMatrix(data, usageAppLabel)
+	require.NoError(t, err)
+	assert.Equal(t, map[[2]string]int64{
+		{"2023-10-01", "web"}:    2048,
+		{"2023-10-02", "web"}:    4096,
+		{"2023-10-02", "worker"}: 8192,
+	}, values)
+
+	rows := mergeUsage(map[[2]string]int64{{"2023-10-02", "web"}: 10}, values)
+	assert.Equal(t, []usageRow{
+		{Day: "2023-10-01", Name: "web", Bytes: 2048},
+		{Day: "2023-10-02", Name: "worker", Bytes: 8192},
+		{Day: "2023-10-02", Name: "web", Events: 10, Bytes: 4096},
+	}, rows)
+
+	_, err = decodePromMatrix([]byte(`{"status":"error","error":"parse error"}`), usageAppLabel)
+	assert.ErrorContains(t, err, "parse error")
+}
+
+func TestSinkProviderName(t *testing.T) {
+	rules := []routeRule{{Name: "eu", Provider: "datadog"}}
+	assert.Equal(t, "Datadog", sinkProviderName("routed_eu", rules))
+	assert.Equal(t, "Datadog", sinkProviderName("datadog", nil))
+	assert.Equal(t, "custom_sink", sinkProviderName("custom_sink", nil))
+}
@@ -0,0 +1,151 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// vectorImage is the vector image self-managed shippers run.
+	vectorImage = "timberio/vector:0.31.0-debian"
+
+	vectorSourceName    = "fly_logs"
+	vectorTransformName = "fly_log_json"
+)
+
+// vectorConfig is a standalone vector configuration shipping the logs of an
+// organization, read from its NATS log stream, to a set of providers.
+type vectorConfig struct {
+	Sources    map[string]any `toml:"sources"`
+	Transforms map[string]any `toml:"transforms"`
+	Sinks      map[string]any `toml:"sinks"`
+}
+
+// newVectorConfig returns the vector configuration shipping the logs matching
+// the NATS subject of orgSlug's log stream to providers, tuning their sinks
+// with the options keyed by their slug.
+func newVectorConfig(orgSlug, subject string, providers []*provider, options map[string]sinkOptions) *vectorConfig {
+	cfg := &vectorConfig{
+		Sources: map[string]any{
+			vectorSourceName: natsSource(orgSlug, subject, "ACCESS_TOKEN"),
+		},
+		Transforms: map[string]any{
+			vectorTransformName: logJSONTransform(vectorSourceName),
+		},
+		Sinks: map[string]any{},
+	}
+
+	for _, p := range providers {
+		input := vectorTransformName
+		if p.remap != "" {
+			input = p.Slug + "_format"
+			cfg.Transforms[input] = remapTransform(vectorTransformName, p.remap)
+		}
+
+		sink := map[string]any{"inputs": []string{input}}
+		for k, v := range p.sink {
+			sink[k] = v
+		}
+		options[p.Slug].apply(sink)
+		cfg.Sinks[p.Slug] = sink
+	}
+
+	return cfg
+}
+
+// natsSource returns a vector source reading the logs matching subject from
+// the NATS log stream of orgSlug, with the token in the tokenVar variable.
+func natsSource(orgSlug, subject, tokenVar string) map[string]any {
+	return map[string]any{
+		"type":            "nats",
+		"url":             "nats://[fdaa::3]:4223",
+		"subject":         fmt.Sprintf("${SUBJECT:-%s}", subject),
+		"queue":           "${QUEUE:-}",
+		"connection_name": "Fly logs stream",
+		"auth": map[string]any{
+			"strategy": "user_password",
+			"user_password": map[string]any{
+				"user":     fmt.Sprintf("${ORG:-%s}", orgSlug),
+				"password": fmt.Sprintf("${%s?}", tokenVar),
+			},
+		},
+	}
+}
+
+// logJSONTransform returns a vector transform parsing the JSON log lines of
+// the input component.
+func logJSONTransform(input string) map[string]any {
+	return remapTransform(input, ". = parse_json!(.message)")
+}
+
+// remapTransform returns a vector transform running the VRL program source
+// on the events of the input component.
+func remapTransform(input, source string) map[string]any {
+	return map[string]any{
+		"type":   "remap",
+		"inputs": []string{input},
+		"source": source,
+	}
+}
+
+// Encode returns the TOML encoding of cfg.
+func (cfg *vectorConfig) Encode() ([]byte, error) {
+	var b bytes.Buffer
+	if err := toml.NewEncoder(&b).Encode(cfg); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// vectorEnv returns the environment variables vector requires to ship logs to
+// providers, sorted by name.
+func vectorEnv(providers []*provider) []providerVar {
+	env := []providerVar{
+		{Name: "ACCESS_TOKEN", Description: "Token reading the logs of the shipped apps, e.g. from 'fly logs token create'", Required: true, Secret: true},
+	}
+
+	for _, p := range providers {
+		for _, v := range p.allVars() {
+			if sinkReads(p.sink, v.Name) {
+				env = append(env, v)
+			}
+		}
+	}
+
+	sort.SliceStable(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+
+	return env
+}
+
+// sinkReads reports whether any of the options of sink interpolates the
+// environment variable name.
+func sinkReads(sink map[string]any, name string) bool {
+	for _, v := range sink {
+		switch v := v.(type) {
+		case string:
+			if strings.Contains(v, "${"+name+"?}") || strings.Contains(v, "${"+name+":-") {
+				return true
+			}
+		case map[string]any:
+			if sinkReads(v, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// vectorDockerfile returns a Dockerfile building an image running vector with
+// the configuration in vector.toml.
+func vectorDockerfile() string {
+	return fmt.Sprintf(`FROM %s
+
+COPY vector.toml /etc/vector/vector.toml
+
+CMD ["--config", "/etc/vector/vector.toml"]
+`, vectorImage)
+}
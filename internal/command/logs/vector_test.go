@@ -0,0 +1,60 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectorConfig(t *testing.T) {
+	datadog, err := findProvider("datadog")
+	require.NoError(t, err)
+	s3, err := findProvider("aws_s3")
+	require.NoError(t, err)
+
+	data, err := newVectorConfig("acme", "logs.my-app.>", []*provider{datadog, s3}, map[string]sinkOptions{
+		"aws_s3": {Compression: "zstd", BatchSize: 1024},
+	}).Encode()
+	require.NoError(t, err)
+
+	var decoded map[string]map[string]map[string]any
+	_, err = toml.Decode(string(data), &decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, "nats", decoded["sources"][vectorSourceName]["type"])
+	assert.Equal(t, "${SUBJECT:-logs.my-app.>}", decoded["sources"][vectorSourceName]["subject"])
+	assert.Equal(t, "datadog_logs", decoded["sinks"]["datadog"]["type"])
+	assert.Equal(t, []any{vectorTransformName}, decoded["sinks"]["aws_s3"]["inputs"])
+	assert.Equal(t, "zstd", decoded["sinks"]["aws_s3"]["compression"])
+	assert.Equal(t, "gzip", decoded["sinks"]["datadog"]["compression"])
+}
+
+func TestVectorEnv(t *testing.T) {
+	logtail, err := findProvider("logtail")
+	require.NoError(t, err)
+	s3, err := findProvider("aws_s3")
+	require.NoError(t, err)
+
+	names := lo.Map(vectorEnv([]*provider{logtail, s3}), func(v providerVar, _ int) string { return v.Name })
+	assert.Equal(t, []string{"ACCESS_TOKEN", "AWS_ACCESS_KEY_ID", "AWS_BUCKET", "AWS_REGION", "AWS_SECRET_ACCESS_KEY", "LOGTAIL_TOKEN"}, names)
+}
+
+func TestVectorConfigRemapsOTLP(t *testing.T) {
+	otlp, err := findProvider("otlp")
+	require.NoError(t, err)
+
+	cfg := newVectorConfig("acme", "logs.my-app.>", []*provider{otlp}, map[string]sinkOptions{
+		"otlp": {BatchSize: 4096},
+	})
+
+	format := cfg.Transforms["otlp_format"].(map[string]any)
+	assert.Equal(t, []string{vectorTransformName}, format["inputs"])
+	assert.Equal(t, otlpRemap, format["source"])
+
+	sink := cfg.Sinks["otlp"].(map[string]any)
+	assert.Equal(t, []string{"otlp_format"}, sink["inputs"])
+	assert.Equal(t, map[string]any{"max_events": 1, "max_bytes": 4096}, sink["batch"])
+}
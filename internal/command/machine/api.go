@@ -0,0 +1,189 @@
+package machine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newAPI() *cobra.Command {
+	const (
+		short = "Send a raw request to the Machines API"
+		long  = `Send a raw request to the Machines API, authenticated with your token, and
+print the response. Use it for Machines API features flyctl doesn't wrap yet.
+
+Paths starting with /v1/ are sent as is, with {app} replaced by the app name.
+Other paths are relative to the app's machines, e.g. / lists them and
+/<machine-id>/events lists the events of a machine.
+
+The request body is given with --data, either inline, as @file to read it from
+a file, or as @- to read it from stdin.`
+		usage = "api <method> <path>"
+	)
+
+	cmd := command.New(usage, short, long, runAPI,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(2)
+	cmd.Example = `  fly machines api GET /
+  fly machines api POST /v1/apps/{app}/machines -d @payload.json
+  fly machines api GET /v1/apps/{app}/volumes`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "data",
+			Shorthand:   "d",
+			Description: "Request body, inline, @file or @- for stdin",
+		},
+		flag.StringArray{
+			Name:        "header",
+			Shorthand:   "H",
+			Description: "Additional request header as 'Name: value'. Can be specified multiple times.",
+		},
+		flag.Bool{
+			Name:        "include",
+			Shorthand:   "i",
+			Description: "Print the response status and headers",
+		},
+		flag.Bool{
+			Name:        "raw",
+			Description: "Print the response body as is instead of pretty-printing JSON",
+		},
+	)
+
+	return cmd
+}
+
+func runAPI(ctx context.Context) error {
+	var (
+		streams = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		args    = flag.Args(ctx)
+		method  = strings.ToUpper(args[0])
+		path    = args[1]
+	)
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	body, err := apiRequestBody(streams, flag.GetString(ctx, "data"))
+	if err != nil {
+		return err
+	}
+
+	headers := http.Header{}
+	for _, h := range flag.GetStringArray(ctx, "header") {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q, expected 'Name: value'", h)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	flapsClient, err := flaps.NewFromAppName(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	resp, err := flapsClient.RawRequest(ctx, method, path, body, headers)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if flag.GetBool(ctx, "include") {
+		fmt.Fprintf(streams.Out, "%s %s\n", resp.Proto, resp.Status)
+		names := make([]string, 0, len(resp.Header))
+		for name := range resp.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(streams.Out, "%s: %s\n", name, strings.Join(resp.Header[name], ", "))
+		}
+		fmt.Fprintln(streams.Out)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed reading response: %w", err)
+	}
+
+	if err := writeAPIResponse(streams.Out, respBody, flag.GetBool(ctx, "raw")); err != nil {
+		return err
+	}
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("request failed with status %s", resp.Status)
+	}
+	return nil
+}
+
+// apiRequestBody returns the body given by --data: inline, @file or @- for
+// stdin.
+func apiRequestBody(streams *iostreams.IOStreams, data string) (io.Reader, error) {
+	switch {
+	case data == "":
+		return nil, nil
+	case data == "@-":
+		b, err := io.ReadAll(streams.In)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading request body from stdin: %w", err)
+		}
+		return bytes.NewReader(b), nil
+	case strings.HasPrefix(data, "@"):
+		b, err := os.ReadFile(data[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed reading request body: %w", err)
+		}
+		return bytes.NewReader(b), nil
+	default:
+		return strings.NewReader(data), nil
+	}
+}
+
+// writeAPIResponse writes body to w, indented if it's JSON and raw is false.
+func writeAPIResponse(w io.Writer, body []byte, raw bool) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	if !raw && json.Valid(body) {
+		var out bytes.Buffer
+		if err := json.Indent(&out, body, "", "  "); err != nil {
+			return errors.New("failed formatting response")
+		}
+		out.WriteByte('\n')
+		_, err := out.WriteTo(w)
+		return err
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if body[len(body)-1] != '\n' {
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	return nil
+}
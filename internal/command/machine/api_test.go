@@ -0,0 +1,59 @@
+package machine
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestWriteAPIResponse(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeAPIResponse(&out, []byte(`{"id":"abc","state":"started"}`), false))
+	assert.Equal(t, "{\n  \"id\": \"abc\",\n  \"state\": \"started\"\n}\n", out.String())
+
+	out.Reset()
+	require.NoError(t, writeAPIResponse(&out, []byte(`{"id":"abc"}`), true))
+	assert.Equal(t, "{\"id\":\"abc\"}\n", out.String())
+
+	out.Reset()
+	require.NoError(t, writeAPIResponse(&out, []byte("not found\n"), false))
+	assert.Equal(t, "not found\n", out.String())
+
+	out.Reset()
+	require.NoError(t, writeAPIResponse(&out, nil, false))
+	assert.Empty(t, out.String())
+}
+
+func TestAPIRequestBody(t *testing.T) {
+	streams, stdin, _, _ := iostreams.Test()
+	stdin.WriteString(`{"from":"stdin"}`)
+
+	path := filepath.Join(t.TempDir(), "payload.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"from":"file"}`), 0o644))
+
+	for data, expected := range map[string]string{
+		`{"from":"inline"}`: `{"from":"inline"}`,
+		"@" + path:          `{"from":"file"}`,
+		"@-":                `{"from":"stdin"}`,
+	} {
+		body, err := apiRequestBody(streams, data)
+		require.NoError(t, err)
+		b, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(b))
+	}
+
+	body, err := apiRequestBody(streams, "")
+	require.NoError(t, err)
+	assert.Nil(t, body)
+
+	_, err = apiRequestBody(streams, "@does-not-exist.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,426 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newAutostop() *cobra.Command {
+	const (
+		short = "Manage how idle machines are stopped"
+		long  = `Manage how the proxy stops the idle machines of an app and starts them again
+on requests. Besides auto_stop_machines, auto_start_machines and
+min_machines_running of services, which keeps machines running in the primary
+region only, the [autostop] section of fly.toml keeps machines running in
+other regions and excludes process groups from being stopped:
+
+  [autostop]
+    exclude_processes = ["worker"]
+
+    [autostop.min_machines_per_region]
+      ams = 1
+
+The proxy decides on its own how long machines stay idle before stopping
+them, which isn't configurable.`
+		usage = "autostop <command>"
+	)
+
+	cmd := command.New(usage, short, long, nil)
+
+	cmd.AddCommand(
+		newAutostopConfigure(),
+		newAutostopShow(),
+	)
+
+	return cmd
+}
+
+func newAutostopConfigure() *cobra.Command {
+	const (
+		short = "Configure how idle machines are stopped"
+		long  = short + `
+
+Only changes the given settings, in the local fly.toml when it belongs to the
+app and on the app's machines, without restarting the ones kept running.
+Machines kept running in a region are picked among the started ones first.
+Pass --stage to only change fly.toml, for the next deploy.`
+		usage = "configure"
+	)
+
+	cmd := command.New(usage, short, long, runAutostopConfigure,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly machine autostop configure --auto-stop --min-per-region ams=1 --min-per-region ord=2
+  fly machine autostop configure --exclude-process worker --exclude-process cron
+  fly machine autostop configure --min-per-region ams=0`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "auto-stop",
+			Description: "Stop idle machines",
+		},
+		flag.Bool{
+			Name:        "auto-start",
+			Description: "Start stopped machines on requests",
+		},
+		flag.Int{
+			Name:        "min-machines-running",
+			Description: "Machines kept running in the primary region",
+		},
+		flag.StringArray{
+			Name:        "min-per-region",
+			Description: "Machines of each process group kept running in a region, as region=count. A count of 0 removes the region",
+		},
+		flag.StringArray{
+			Name:        "exclude-process",
+			Description: "Process group whose machines are never stopped, replacing the excluded groups. Pass an empty value to exclude none",
+		},
+		flag.Bool{
+			Name:        "stage",
+			Description: "Only change fly.toml, without updating machines",
+		},
+	)
+
+	return cmd
+}
+
+func newAutostopShow() *cobra.Command {
+	const (
+		short = "Show the autostop policy in effect"
+		long  = short + `
+
+Shows, by process group, whether the app's machines are stopped when idle and
+started on requests, and how many are kept running in each region.`
+		usage = "show"
+	)
+
+	cmd := command.New(usage, short, long, runAutostopShow,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+// autostopChanges are the autostop settings to change, left nil when
+// unchanged.
+type autostopChanges struct {
+	AutoStop           *bool
+	AutoStart          *bool
+	MinMachinesRunning *int
+	MinPerRegion       map[string]int
+	ExcludeProcesses   *[]string
+}
+
+func autostopChangesFromFlags(ctx context.Context) (*autostopChanges, error) {
+	var changes autostopChanges
+
+	if flag.IsSpecified(ctx, "auto-stop") {
+		changes.AutoStop = api.Pointer(flag.GetBool(ctx, "auto-stop"))
+	}
+	if flag.IsSpecified(ctx, "auto-start") {
+		changes.AutoStart = api.Pointer(flag.GetBool(ctx, "auto-start"))
+	}
+	if flag.IsSpecified(ctx, "min-machines-running") {
+		min := flag.GetInt(ctx, "min-machines-running")
+		if min < 0 {
+			return nil, flyerr.Validation(errors.New("--min-machines-running can't be negative"))
+		}
+		changes.MinMachinesRunning = &min
+	}
+	if flag.IsSpecified(ctx, "min-per-region") {
+		kvs, err := cmdutil.ParseKVStringsToMap(flag.GetStringArray(ctx, "min-per-region"))
+		if err != nil {
+			return nil, flyerr.Validation(fmt.Errorf("invalid --min-per-region: %w", err))
+		}
+		changes.MinPerRegion = map[string]int{}
+		for region, v := range kvs {
+			min, err := strconv.Atoi(v)
+			if err != nil || min < 0 {
+				return nil, flyerr.Validation(fmt.Errorf("invalid --min-per-region %s=%s, the count must be a positive number", region, v))
+			}
+			changes.MinPerRegion[region] = min
+		}
+	}
+	if flag.IsSpecified(ctx, "exclude-process") {
+		groups := lo.Compact(flag.GetStringArray(ctx, "exclude-process"))
+		changes.ExcludeProcesses = &groups
+	}
+
+	if reflect.DeepEqual(changes, autostopChanges{}) {
+		return nil, flyerr.Validation(errors.New("nothing to configure, see --help for the settings"))
+	}
+	return &changes, nil
+}
+
+// applyToConfig applies the changes to the services and [autostop] section
+// of cfg.
+func (c *autostopChanges) applyToConfig(cfg *appconfig.Config) {
+	apply := func(stop, start **bool, min **int) {
+		if c.AutoStop != nil {
+			*stop = api.Pointer(*c.AutoStop)
+		}
+		if c.AutoStart != nil {
+			*start = api.Pointer(*c.AutoStart)
+		}
+		if c.MinMachinesRunning != nil {
+			*min = api.Pointer(*c.MinMachinesRunning)
+		}
+	}
+	if s := cfg.HTTPService; s != nil {
+		apply(&s.AutoStopMachines, &s.AutoStartMachines, &s.MinMachinesRunning)
+	}
+	for i := range cfg.Services {
+		s := &cfg.Services[i]
+		apply(&s.AutoStopMachines, &s.AutoStartMachines, &s.MinMachinesRunning)
+	}
+
+	if c.MinPerRegion == nil && c.ExcludeProcesses == nil {
+		return
+	}
+	if cfg.Autostop == nil {
+		cfg.Autostop = &appconfig.Autostop{}
+	}
+	if c.ExcludeProcesses != nil {
+		cfg.Autostop.ExcludeProcesses = *c.ExcludeProcesses
+	}
+	for region, min := range c.MinPerRegion {
+		if min == 0 {
+			delete(cfg.Autostop.MinMachinesPerRegion, region)
+			continue
+		}
+		if cfg.Autostop.MinMachinesPerRegion == nil {
+			cfg.Autostop.MinMachinesPerRegion = map[string]int{}
+		}
+		cfg.Autostop.MinMachinesPerRegion[region] = min
+	}
+	if cfg.Autostop.IsEmpty() {
+		cfg.Autostop = nil
+	}
+}
+
+// autostopMachineConfig returns the config of m with the autostop settings
+// of the services of its process group in cfg, or nil when they're already
+// in effect. Machines excluded or pinned by the [autostop] section of cfg
+// never stop.
+func autostopMachineConfig(cfg *appconfig.Config, c *autostopChanges, m *api.Machine, pinned bool) (*api.MachineConfig, error) {
+	if m.Config == nil || len(m.Config.Services) == 0 {
+		return nil, nil
+	}
+
+	group := m.ProcessGroup()
+	flattened, err := cfg.Flatten(group)
+	if err != nil {
+		return nil, err
+	}
+	services := flattened.AllServices()
+
+	mConfig := helpers.Clone(m.Config)
+	for i := range mConfig.Services {
+		svc := &mConfig.Services[i]
+		// Machine services without a counterpart in fly.toml only take the
+		// given changes
+		if s, ok := lo.Find(services, func(s appconfig.Service) bool { return s.InternalPort == svc.InternalPort }); ok {
+			svc.Autostop, svc.Autostart, svc.MinMachinesRunning = s.AutoStopMachines, s.AutoStartMachines, s.MinMachinesRunning
+			continue
+		}
+		if c.AutoStop != nil {
+			svc.Autostop = api.Pointer(*c.AutoStop)
+		}
+		if c.AutoStart != nil {
+			svc.Autostart = api.Pointer(*c.AutoStart)
+		}
+		if c.MinMachinesRunning != nil {
+			svc.MinMachinesRunning = api.Pointer(*c.MinMachinesRunning)
+		}
+	}
+	if pinned || cfg.Autostop.Excludes(group) {
+		appconfig.DisableAutostop(mConfig)
+	}
+
+	if reflect.DeepEqual(mConfig.Services, m.Config.Services) {
+		return nil, nil
+	}
+	return mConfig, nil
+}
+
+func runAutostopConfigure(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	changes, err := autostopChangesFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	if local := appconfig.ConfigFromContext(ctx); local != nil && local.AppName == appName && local.ConfigFilePath() != "" {
+		changes.applyToConfig(local)
+		if err := local.WriteToDisk(ctx, local.ConfigFilePath()); err != nil {
+			return err
+		}
+		fmt.Fprintf(io.Out, "Updated the autostop settings of %s\n", local.ConfigFilePath())
+	}
+
+	if flag.GetBool(ctx, "stage") {
+		fmt.Fprintln(io.Out, "Autostop settings have been staged in fly.toml, deploy for them to take effect.")
+		return nil
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	// Start from the deployed config so unrelated changes of the local
+	// fly.toml aren't applied along
+	cfg, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("error loading app config: %w", err)
+	}
+	changes.applyToConfig(cfg)
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	pinned := cfg.AutostopPinned(machines)
+
+	var (
+		updates []*api.Machine
+		inputs  = map[string]*api.LaunchMachineInput{}
+	)
+	for _, m := range machines {
+		mConfig, err := autostopMachineConfig(cfg, changes, m, pinned[m.ID])
+		if err != nil {
+			return err
+		}
+		if mConfig == nil {
+			continue
+		}
+		updates = append(updates, m)
+		inputs[m.ID] = &api.LaunchMachineInput{
+			Name:   m.Name,
+			Region: m.Region,
+			Config: mConfig,
+			// Stopped machines are left stopped, unless kept running
+			SkipLaunch:       m.State != api.MachineStateStarted && !pinned[m.ID],
+			SkipHealthChecks: true,
+		}
+	}
+	if len(updates) == 0 {
+		fmt.Fprintln(io.Out, "Autostop settings are already in effect")
+		return nil
+	}
+
+	updates, releaseFunc, err := mach.AcquireLeases(ctx, updates)
+	defer releaseFunc(ctx, updates)
+	if err != nil {
+		return err
+	}
+
+	return flyerr.ForEach(updates, false, func(m *api.Machine) error {
+		return mach.Update(ctx, m, inputs[m.ID])
+	})
+}
+
+func runAutostopShow(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	policies := appconfig.EffectiveAutostop(machines)
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, policies)
+	}
+	if len(policies) == 0 {
+		fmt.Fprintf(io.Out, "No machines of %s have services, so none are stopped when idle\n", appName)
+		return nil
+	}
+	return RenderAutostopPolicies(io.Out, policies)
+}
+
+// RenderAutostopPolicies writes a table of policies to w.
+func RenderAutostopPolicies(w io.Writer, policies []appconfig.AutostopPolicy) error {
+	rows := make([][]string, 0, len(policies))
+	for _, p := range policies {
+		rows = append(rows, []string{
+			p.ProcessGroup,
+			p.Autostop,
+			p.Autostart,
+			strconv.Itoa(p.MinMachinesRunning),
+			formatKeptRunning(p.KeptRunning),
+		})
+	}
+	return render.Table(w, "Autostop", rows, "Process", "Auto Stop", "Auto Start", "Min Running", "Kept Running")
+}
+
+func formatKeptRunning(byRegion map[string]int) string {
+	if len(byRegion) == 0 {
+		return "-"
+	}
+	regions := lo.Keys(byRegion)
+	sort.Strings(regions)
+	parts := make([]string, 0, len(regions))
+	for _, region := range regions {
+		parts = append(parts, fmt.Sprintf("%s=%d", region, byRegion[region]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,57 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestAutostopConfigure(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.HTTPService = &appconfig.HTTPService{InternalPort: 8080, AutoStopMachines: api.Pointer(false)}
+
+	changes := &autostopChanges{
+		AutoStop:     api.Pointer(true),
+		MinPerRegion: map[string]int{"ams": 1, "ord": 2},
+	}
+	changes.applyToConfig(cfg)
+	assert.Equal(t, api.Pointer(true), cfg.HTTPService.AutoStopMachines)
+	assert.Nil(t, cfg.HTTPService.AutoStartMachines)
+	assert.Equal(t, &appconfig.Autostop{MinMachinesPerRegion: map[string]int{"ams": 1, "ord": 2}}, cfg.Autostop)
+
+	m := &api.Machine{
+		ID:     "m1",
+		Region: "ams",
+		Config: &api.MachineConfig{
+			Services: []api.MachineService{
+				{InternalPort: 8080, Autostop: api.Pointer(false)},
+				{InternalPort: 9090},
+			},
+		},
+	}
+
+	mConfig, err := autostopMachineConfig(cfg, changes, m, false)
+	require.NoError(t, err)
+	assert.Equal(t, api.Pointer(true), mConfig.Services[0].Autostop)
+	assert.Equal(t, api.Pointer(true), mConfig.Services[1].Autostop)
+	assert.Nil(t, m.Config.Services[1].Autostop)
+
+	mConfig, err = autostopMachineConfig(cfg, changes, m, true)
+	require.NoError(t, err)
+	assert.Equal(t, api.Pointer(false), mConfig.Services[1].Autostop, "pinned machines never stop")
+
+	m.Config = mConfig
+	mConfig, err = autostopMachineConfig(cfg, changes, m, true)
+	require.NoError(t, err)
+	assert.Nil(t, mConfig, "the machine is already up to date")
+
+	changes = &autostopChanges{MinPerRegion: map[string]int{"ams": 0, "ord": 0}}
+	changes.applyToConfig(cfg)
+	assert.Nil(t, cfg.Autostop)
+
+	assert.Equal(t, "ams=1, ord=2", formatKeptRunning(map[string]int{"ord": 2, "ams": 1}))
+	assert.Equal(t, "-", formatKeptRunning(nil))
+}
@@ -0,0 +1,110 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newAwait() *cobra.Command {
+	const (
+		short = "Wait until a machine reaches a state"
+		long  = `Wait until a machine reaches a state: started, stopped, destroyed, or
+checks-passing, which waits for it to start and for all its health checks to
+pass. Exits with an error when the timeout elapses first.`
+
+		usage = "await <id>"
+	)
+
+	cmd := command.New(usage, short, long, runMachineAwait,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.RangeArgs(0, 1)
+	cmd.Example = `  fly machine start 148ed599c14189 && fly machine await 148ed599c14189 --state checks-passing
+  fly machine await 148ed599c14189 --state stopped --timeout 2m --json`
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		selectFlag,
+		flag.String{
+			Name:        "state",
+			Description: fmt.Sprintf("State to wait for, one of %s", strings.Join(mach.AwaitStates(), ", ")),
+			Default:     mach.StateStarted,
+		},
+		flag.Duration{
+			Name:        "timeout",
+			Description: "How long to wait for",
+			Default:     5 * time.Minute,
+		},
+	)
+
+	return cmd
+}
+
+// awaitResult is the outcome of awaiting a machine.
+type awaitResult struct {
+	ID            string `json:"id"`
+	State         string `json:"state"`
+	Awaited       string `json:"awaited"`
+	Reached       bool   `json:"reached"`
+	ChecksPassing bool   `json:"checks_passing"`
+	Elapsed       string `json:"elapsed"`
+	Error         string `json:"error,omitempty"`
+}
+
+func runMachineAwait(ctx context.Context) error {
+	var (
+		io    = iostreams.FromContext(ctx)
+		state = flag.GetString(ctx, "state")
+	)
+
+	machineID := flag.FirstArg(ctx)
+	haveMachineID := len(flag.Args(ctx)) > 0
+	machine, ctx, err := selectOneMachine(ctx, nil, machineID, haveMachineID)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	machine, err = mach.Await(ctx, machine, state, flag.GetDuration(ctx, "timeout"))
+
+	result := awaitResult{
+		ID:            machine.ID,
+		State:         machine.State,
+		Awaited:       state,
+		Reached:       err == nil,
+		ChecksPassing: machine.AllHealthChecks().AllPassing(),
+		Elapsed:       time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if rerr := render.JSON(io.Out, result); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(io.Out, "Machine %s reached %s after %s\n", result.ID, state, result.Elapsed)
+	return nil
+}
@@ -0,0 +1,250 @@
+package machine
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+)
+
+const (
+	// captureTimeout bounds the time spent capturing and uploading the state
+	// of a process.
+	captureTimeout = 5 * time.Minute
+
+	// capturePresignExpiry is how long the presigned URL captures are uploaded
+	// to stays valid.
+	capturePresignExpiry = 15 * time.Minute
+)
+
+// captureTarget is where the capture of a machine is stored: a directory of
+// one of its volumes, or an S3 compatible bucket.
+type captureTarget struct {
+	Dir string
+
+	Bucket string
+	Prefix string
+}
+
+// parseCaptureTarget parses --capture-to, defaulting to the cores directory
+// of the first volume of m.
+func parseCaptureTarget(value string, m *api.Machine) (captureTarget, error) {
+	var mounts []api.MachineMount
+	if m.Config != nil {
+		mounts = m.Config.Mounts
+	}
+
+	if value == "" {
+		if len(mounts) == 0 {
+			return captureTarget{}, errors.New("machine has no volume to store the capture on, pass --capture-to s3://<bucket>/<prefix>")
+		}
+		return captureTarget{Dir: path.Join(mounts[0].Path, "cores")}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(value, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return captureTarget{}, fmt.Errorf("invalid --capture-to %s, expected s3://<bucket>/<prefix>", value)
+		}
+		return captureTarget{Bucket: bucket, Prefix: strings.Trim(prefix, "/")}, nil
+	}
+
+	dir := path.Clean(value)
+	if !path.IsAbs(dir) || strings.Contains(dir, "'") {
+		return captureTarget{}, fmt.Errorf("invalid --capture-to %s, expected a directory of a volume or s3://<bucket>/<prefix>", value)
+	}
+	for _, mount := range mounts {
+		if dir == mount.Path || strings.HasPrefix(dir, strings.TrimSuffix(mount.Path, "/")+"/") {
+			return captureTarget{Dir: dir}, nil
+		}
+	}
+	return captureTarget{}, fmt.Errorf("%s isn't on a volume of the machine, which would lose the capture when it's killed", dir)
+}
+
+// mainProcess returns the PID of the process of the app of a machine, the
+// first one started which isn't init or one of the Fly.io agents.
+func mainProcess(processes api.MachinePsResponse) (int32, error) {
+	candidates := make([]api.ProcessStat, 0, len(processes))
+	for _, p := range processes {
+		if p.Pid == 1 || strings.HasPrefix(p.Command, "/.fly/") {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+	if len(candidates) == 0 {
+		return 0, errors.New("machine runs no process to capture")
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Pid < candidates[j].Pid })
+	return candidates[0].Pid, nil
+}
+
+// captureScript returns the shell script capturing the state of pid into an
+// archive named name, and storing it to dir or uploading it to uploadURL.
+// Core dumps require gdb's gcore in the image, and uploads curl.
+func captureScript(pid int32, name, dir, uploadURL string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "pid=%d\n", pid)
+	fmt.Fprintf(&b, "name=%s\n", name)
+	b.WriteString(`work=$(mktemp -d /tmp/fly-capture.XXXXXX) || exit 1
+trap 'rm -rf "$work"' EXIT
+[ -d "/proc/$pid" ] || { echo "process $pid is gone" >&2; exit 1; }
+for f in cmdline status stat wchan limits io sched maps; do
+  cat "/proc/$pid/$f" > "$work/$f" 2>/dev/null
+done
+ls -l "/proc/$pid/fd" > "$work/fds" 2>/dev/null
+for t in /proc/$pid/task/*; do
+  echo "== thread ${t##*/} $(cat "$t/comm" 2>/dev/null) $(cat "$t/wchan" 2>/dev/null)"
+  cat "$t/stack" 2>/dev/null
+done > "$work/threads"
+if command -v gcore >/dev/null 2>&1; then
+  gcore -o "$work/core" "$pid" >/dev/null 2>&1 && echo "core dump captured" || echo "gcore failed, capturing the process state only" >&2
+else
+  echo "gcore isn't installed, capturing the process state only" >&2
+fi
+tar -czf "/tmp/$name.tar.gz" -C "$work" . || exit 1
+`)
+
+	if uploadURL != "" {
+		fmt.Fprintf(&b, `curl -sSf -X PUT -T "/tmp/$name.tar.gz" '%s' || { rm -f "/tmp/$name.tar.gz"; exit 1; }
+rm -f "/tmp/$name.tar.gz"
+`, uploadURL)
+	} else {
+		fmt.Fprintf(&b, `mkdir -p '%s' && mv "/tmp/$name.tar.gz" '%s/' || exit 1
+`, dir, dir)
+	}
+	return b.String()
+}
+
+// captureProcess captures the state and core of pid, or of the main process of
+// m when 0, to target. It returns where the capture was stored, and the
+// warnings of the capture, such as the core dump being left out.
+func captureProcess(ctx context.Context, flapsClient *flaps.Client, m *api.Machine, target captureTarget, pid int32) (location, warnings string, err error) {
+	if pid == 0 {
+		processes, err := flapsClient.GetProcesses(ctx, m.ID)
+		if err != nil {
+			return "", "", err
+		}
+		if pid, err = mainProcess(processes); err != nil {
+			return "", "", err
+		}
+	}
+
+	name := fmt.Sprintf("%s-%d-%s", m.ID, pid, time.Now().UTC().Format("20060102T150405Z"))
+
+	var uploadURL string
+	if target.Bucket != "" {
+		key := path.Join(target.Prefix, name+".tar.gz")
+		object, err := s3ObjectURL(target.Bucket, key)
+		if err != nil {
+			return "", "", err
+		}
+		uploadURL, err = presignS3(object, "PUT", os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), s3Region(), time.Now().UTC(), capturePresignExpiry)
+		if err != nil {
+			return "", "", err
+		}
+		location = fmt.Sprintf("s3://%s/%s", target.Bucket, key)
+	} else {
+		location = path.Join(target.Dir, name+".tar.gz")
+	}
+
+	script := captureScript(pid, name, target.Dir, uploadURL)
+	out, err := flapsClient.Exec(ctx, m.ID, &api.MachineExecRequest{
+		Cmd:     fmt.Sprintf(`sh -c "echo %s | base64 -d | sh"`, base64.StdEncoding.EncodeToString([]byte(script))),
+		Timeout: int(captureTimeout.Seconds()),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if out.ExitCode != 0 {
+		return "", "", fmt.Errorf("capture exited with code %d: %s", out.ExitCode, strings.TrimSpace(out.StdErr))
+	}
+	return location, strings.TrimSpace(out.StdErr), nil
+}
+
+// s3Region returns the region of the S3 credentials of the environment.
+func s3Region() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+// s3ObjectURL returns the URL of key in bucket, on AWS_ENDPOINT_URL_S3 for
+// S3 compatible storage such as Tigris.
+func s3ObjectURL(bucket, key string) (*url.URL, error) {
+	escaped := make([]string, 0)
+	for _, segment := range strings.Split(key, "/") {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	escapedKey := strings.Join(escaped, "/")
+
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_S3"); endpoint != "" {
+		return url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + escapedKey)
+	}
+	return url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, s3Region(), escapedKey))
+}
+
+// presignS3 returns object presigned for method with AWS Signature Version 4
+// query parameters, so that machines can upload to it without credentials.
+func presignS3(object *url.URL, method, accessKey, secretKey, region string, now time.Time, expires time.Duration) (string, error) {
+	if accessKey == "" || secretKey == "" {
+		return "", errors.New("uploading to S3 requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY in the environment")
+	}
+
+	const service = "s3"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {accessKey + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {strconv.Itoa(int(expires.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		object.EscapedPath(),
+		canonicalQuery,
+		"host:" + object.Host,
+		"",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(digest[:])}, "\n")
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = sign(key, part)
+	}
+
+	presigned := *object
+	presigned.RawQuery = canonicalQuery + "&X-Amz-Signature=" + hex.EncodeToString(sign(key, stringToSign))
+	return presigned.String(), nil
+}
+
+func sign(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
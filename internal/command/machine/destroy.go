@@ -10,7 +10,9 @@ import (
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/protect"
 	"github.com/superfly/flyctl/iostreams"
 )
 
@@ -40,6 +42,7 @@ This command requires a machine to be in a stopped state unless the force flag i
 			Shorthand:   "f",
 			Description: "force kill machine regardless of current state",
 		},
+		protect.ForceFlag(),
 	)
 
 	cmd.Args = cobra.RangeArgs(0, 1)
@@ -61,6 +64,10 @@ func runMachineDestroy(ctx context.Context) (err error) {
 	}
 	appName := appconfig.NameFromContext(ctx)
 
+	if err := protect.CheckDestroy(ctx, config.ProtectedMachine, current.ID); err != nil {
+		return err
+	}
+
 	// This is used for the deletion hook below.
 	client := client.FromContext(ctx).API()
 	app, err := client.GetAppCompact(ctx, appName)
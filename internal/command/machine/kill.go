@@ -14,7 +14,18 @@ import (
 func newKill() *cobra.Command {
 	const (
 		short = "Kill (SIGKILL) a Fly machine"
-		long  = short + "\n"
+		long  = short + `
+
+With --capture-core, first captures the state of the main process of the
+machine to debug hung processes: its status, threads and their kernel stacks,
+open files and memory maps, and with gdb's gcore in the image its core dump.
+The capture is archived into the cores directory of the first volume of the
+machine, or to --capture-to, a directory of one of its volumes or an S3
+bucket. Uploads to buckets use the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+AWS_REGION and AWS_ENDPOINT_URL_S3 of the environment to presign the upload,
+which the machine performs with curl. The machine isn't killed when the
+capture fails.
+`
 
 		usage = "kill <id>"
 	)
@@ -31,6 +42,18 @@ func newKill() *cobra.Command {
 		flag.App(),
 		flag.AppConfig(),
 		selectFlag,
+		flag.Bool{
+			Name:        "capture-core",
+			Description: "Capture the state and core dump of the main process before killing the machine",
+		},
+		flag.String{
+			Name:        "capture-to",
+			Description: "Directory of a volume of the machine, or s3://<bucket>/<prefix>, to store the capture in",
+		},
+		flag.Int{
+			Name:        "capture-pid",
+			Description: "PID of the process to capture, the main process of the machine by default",
+		},
 	)
 
 	return cmd
@@ -52,6 +75,23 @@ func runMachineKill(ctx context.Context) (err error) {
 	}
 	fmt.Fprintf(io.Out, "machine %s was found and is currently in a %s state, attempting to kill...\n", current.ID, current.State)
 
+	if flag.GetBool(ctx, "capture-core") {
+		target, err := parseCaptureTarget(flag.GetString(ctx, "capture-to"), current)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(io.Out, "capturing the process state of machine %s...\n", current.ID)
+		location, warnings, err := captureProcess(ctx, flapsClient, current, target, int32(flag.GetInt(ctx, "capture-pid")))
+		if err != nil {
+			return fmt.Errorf("could not capture machine %s, it wasn't killed, run again without --capture-core to kill it anyway: %w", current.ID, err)
+		}
+		if warnings != "" {
+			fmt.Fprintln(io.ErrOut, warnings)
+		}
+		fmt.Fprintf(io.Out, "capture stored to %s\n", location)
+	}
+
 	err = flapsClient.Kill(ctx, current.ID)
 	if err != nil {
 		if err := rewriteMachineNotFoundErrors(ctx, err, current.ID); err != nil {
@@ -0,0 +1,238 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newLabel() *cobra.Command {
+	const (
+		short = "Manage the labels of machines"
+		long  = `Manage the labels of machines, like their team, tier or role. Labels are
+stored as the metadata of machines, without restarting them, and persist
+across deploys. Set labels for every machine of an app with the [metadata]
+section of fly.toml.
+
+Commands acting on many machines, like machine list, start, stop and
+restart, only act on those with some labels with --selector key=value.`
+		usage = "label <command>"
+	)
+
+	cmd := command.New(usage, short, long, nil)
+
+	cmd.AddCommand(
+		newLabelSet(),
+		newLabelUnset(),
+		newLabelList(),
+	)
+
+	return cmd
+}
+
+func newLabelSet() *cobra.Command {
+	const (
+		short = "Set labels of a machine"
+		long  = short + "\n"
+		usage = "set <id> <key=value> [<key=value>...]"
+	)
+
+	cmd := command.New(usage, short, long, runLabelSet,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(2)
+	cmd.Example = `  fly machine label set 3d8d9015b32089 team=core tier=web`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runLabelSet(ctx context.Context) error {
+	var (
+		io   = iostreams.FromContext(ctx)
+		args = flag.Args(ctx)
+	)
+
+	labels, err := cmdutil.ParseKVStringsToMap(args[1:])
+	if err != nil {
+		return fmt.Errorf("invalid labels: %w", err)
+	}
+	for key := range labels {
+		if err := mach.ValidateLabel(key); err != nil {
+			return err
+		}
+	}
+
+	machineID := args[0]
+	ctx, err = buildContextFromAppNameOrMachineID(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	flapsClient := flaps.FromContext(ctx)
+	for _, key := range sortedKeys(labels) {
+		if err := flapsClient.SetMetadata(ctx, machineID, key, labels[key]); err != nil {
+			if rewritten := rewriteMachineNotFoundErrors(ctx, err, machineID); rewritten != nil {
+				return rewritten
+			}
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Set labels %s of machine %s\n", mach.FormatLabels(labels), machineID)
+	return nil
+}
+
+func newLabelUnset() *cobra.Command {
+	const (
+		short = "Remove labels of a machine"
+		long  = short + "\n"
+		usage = "unset <id> <key> [<key>...]"
+	)
+
+	cmd := command.New(usage, short, long, runLabelUnset,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(2)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runLabelUnset(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		args      = flag.Args(ctx)
+		machineID = args[0]
+		keys      = args[1:]
+	)
+
+	for _, key := range keys {
+		if err := mach.ValidateLabel(key); err != nil {
+			return err
+		}
+	}
+
+	ctx, err := buildContextFromAppNameOrMachineID(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	flapsClient := flaps.FromContext(ctx)
+	for _, key := range keys {
+		if err := flapsClient.DeleteMetadata(ctx, machineID, key); err != nil {
+			if rewritten := rewriteMachineNotFoundErrors(ctx, err, machineID); rewritten != nil {
+				return rewritten
+			}
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Removed %d labels of machine %s\n", len(keys), machineID)
+	return nil
+}
+
+func newLabelList() *cobra.Command {
+	const (
+		short = "List the labels of machines"
+		long  = `List the labels of a machine, or of all the machines of the app.`
+		usage = "list [id]"
+	)
+
+	cmd := command.New(usage, short, long, runLabelList,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		selectorFlag,
+	)
+
+	return cmd
+}
+
+func runLabelList(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		machineID = flag.FirstArg(ctx)
+		machines  []*api.Machine
+		err       error
+	)
+
+	if machineID != "" {
+		var m *api.Machine
+		if m, ctx, err = selectOneMachine(ctx, nil, machineID, true); err != nil {
+			return err
+		}
+		machines = []*api.Machine{m}
+	} else {
+		if appconfig.NameFromContext(ctx) == "" {
+			return fmt.Errorf("a machine ID or an app name must be specified")
+		}
+		if ctx, err = buildContextFromAppNameOrMachineID(ctx); err != nil {
+			return err
+		}
+		if haveSelector(ctx) {
+			machines, err = machinesMatchingSelector(ctx)
+		} else {
+			machines, err = flaps.FromContext(ctx).List(ctx, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		out := map[string]map[string]string{}
+		for _, m := range machines {
+			out[m.ID] = mach.Labels(m)
+		}
+		return render.JSON(io.Out, out)
+	}
+
+	rows := make([][]string, 0, len(machines))
+	for _, m := range machines {
+		rows = append(rows, []string{m.ID, m.Name, mach.FormatLabels(mach.Labels(m))})
+	}
+	return render.Table(io.Out, "", rows, "ID", "Name", "Labels")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flaps"
@@ -11,7 +12,10 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/format"
+	mach "github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/schema"
 	"github.com/superfly/flyctl/iostreams"
 )
 
@@ -36,11 +40,14 @@ func newList() *cobra.Command {
 		flag.App(),
 		flag.AppConfig(),
 		flag.JSONOutput(),
+		flag.JSONSchemaVersion(),
 		flag.Bool{
 			Name:        "quiet",
 			Shorthand:   "q",
 			Description: "Only list machine ids",
 		},
+		selectorFlag,
+		flag.NoMachinesCache(),
 	)
 
 	return cmd
@@ -52,17 +59,38 @@ func runMachineList(ctx context.Context) (err error) {
 		io      = iostreams.FromContext(ctx)
 		silence = flag.GetBool(ctx, "quiet")
 		cfg     = config.FromContext(ctx)
+
+		schemaVersion = flag.GetJSONSchemaVersion(ctx)
 	)
 
+	if err := schema.CheckVersion("machines", schemaVersion); err != nil {
+		return err
+	}
+
 	flapsClient, err := flaps.NewFromAppName(ctx, appName)
 	if err != nil {
 		return fmt.Errorf("list of machines could not be retrieved: %w", err)
 	}
 
-	machines, err := flapsClient.List(ctx, "")
+	machines, cachedAt, err := flapsClient.ListCached(ctx, flag.GetBool(ctx, flag.NoMachinesCache().Name))
 	if err != nil {
 		return fmt.Errorf("machines could not be retrieved")
 	}
+	if !cachedAt.IsZero() && !silence && !cfg.JSONOutput && schemaVersion == 0 {
+		fmt.Fprintf(io.ErrOut, "Showing machines cached %s, run with --no-cache for their current state\n", format.RelativeTime(cachedAt))
+	}
+
+	if haveSelector(ctx) {
+		selector, err := mach.ParseSelector(flag.GetStringArray(ctx, selectorFlag.Name))
+		if err != nil {
+			return err
+		}
+		machines = lo.Filter(machines, func(m *api.Machine, _ int) bool { return mach.MatchesSelector(m, selector) })
+	}
+
+	if schemaVersion > 0 {
+		return schema.Render(io.Out, "machines", schemaVersion, schema.NewMachinesV1(machines))
+	}
 
 	if len(machines) == 0 {
 		if !silence {
@@ -128,11 +156,12 @@ func runMachineList(ctx context.Context) (err error) {
 				appPlatform,
 				machineProcessGroup,
 				size,
+				mach.FormatLabels(mach.Labels(machine)),
 			})
 
 		}
 
-		_ = render.Table(io.Out, appName, rows, "ID", "Name", "State", "Region", "Image", "IP Address", "Volume", "Created", "Last Updated", "App Platform", "Process Group", "Size")
+		_ = render.Table(io.Out, appName, rows, "ID", "Name", "State", "Region", "Image", "IP Address", "Volume", "Created", "Last Updated", "App Platform", "Process Group", "Size", "Labels")
 	}
 	return nil
 }
@@ -32,6 +32,12 @@ func New() *cobra.Command {
 		newRestart(),
 		newLeases(),
 		newMachineExec(),
+		newSizes(),
+		newAPI(),
+		newAwait(),
+		newLabel(),
+		newTop(),
+		newAutostop(),
 	)
 
 	return cmd
@@ -10,6 +10,7 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
 	mach "github.com/superfly/flyctl/internal/machine"
 )
 
@@ -33,16 +34,20 @@ func newRestart() *cobra.Command {
 		flag.App(),
 		flag.AppConfig(),
 		selectFlag,
+		selectorFlag,
 		flag.String{
-			Name:        "signal",
+			Name:        "stop-signal",
 			Shorthand:   "s",
 			Description: "Signal to stop the machine with (default: SIGINT)",
+			Aliases:     []string{"signal"},
 		},
 
 		flag.Int{
 			Name:        "time",
 			Description: "Seconds to wait before killing the machine",
 		},
+		flag.StopGracePeriod(),
+		flag.PreStopCommand(),
 		flag.Bool{
 			Name:        "force",
 			Description: "Force stop the machine(s)",
@@ -52,6 +57,7 @@ func newRestart() *cobra.Command {
 			Description: "Restarts app without waiting for health checks. ( Machines only )",
 			Default:     false,
 		},
+		flag.ContinueOnError(),
 	)
 
 	return cmd
@@ -60,15 +66,23 @@ func newRestart() *cobra.Command {
 func runMachineRestart(ctx context.Context) error {
 	var (
 		args    = flag.Args(ctx)
-		timeout = flag.GetInt(ctx, "time")
+		timeout = time.Duration(flag.GetInt(ctx, "time")) * time.Second
 	)
 
+	if flag.IsSpecified(ctx, "stop-grace-period") {
+		timeout = flag.GetDuration(ctx, "stop-grace-period")
+	}
+
+	drain := mach.DrainOptions{
+		PreStopCommand: flag.GetString(ctx, "pre-stop-command"),
+	}
+
 	// Resolve flags
 	input := &api.RestartMachineInput{
-		Timeout:          time.Duration(timeout) * time.Second,
+		Timeout:          timeout,
 		ForceStop:        flag.GetBool(ctx, "force"),
 		SkipHealthChecks: flag.GetBool(ctx, "skip-health-checks"),
-		Signal:           strings.ToUpper(flag.GetString(ctx, "signal")),
+		Signal:           strings.ToUpper(flag.GetString(ctx, "stop-signal")),
 	}
 
 	machines, ctx, err := selectManyMachines(ctx, args)
@@ -84,11 +98,13 @@ func runMachineRestart(ctx context.Context) error {
 	}
 
 	// Restart each machine
-	for _, machine := range machines {
+	return flyerr.ForEach(machines, flag.GetContinueOnError(ctx), func(machine *api.Machine) error {
+		if err := mach.RunPreStopHook(ctx, machine, drain); err != nil {
+			return err
+		}
 		if err := mach.Restart(ctx, machine, input, machine.LeaseNonce); err != nil {
 			return fmt.Errorf("failed to restart machine %s: %w", machine.ID, err)
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
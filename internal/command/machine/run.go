@@ -31,9 +31,11 @@ import (
 	"github.com/superfly/flyctl/internal/env"
 	"github.com/superfly/flyctl/internal/flag"
 	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/policy"
 	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/state"
 	"github.com/superfly/flyctl/internal/watch"
+	"github.com/superfly/flyctl/terminal"
 )
 
 var sharedFlags = flag.Set{
@@ -50,7 +52,7 @@ var sharedFlags = flag.Set{
 	flag.String{
 		Name:        "vm-size",
 		Shorthand:   "s",
-		Description: "Preset guest cpu and memory for a machine, defaults to shared-cpu-1x",
+		Description: "Preset guest cpu and memory for a machine, defaults to shared-cpu-1x. See `fly machine sizes` for valid sizes",
 		Aliases:     []string{"size"},
 	},
 	flag.Int{
@@ -63,6 +65,10 @@ var sharedFlags = flag.Set{
 		Description: "Memory (in megabytes) to attribute to the machine",
 		Aliases:     []string{"memory"},
 	},
+	flag.String{
+		Name:        "vm-gpu-kind",
+		Description: "GPU kind to attach to the machine, see `fly gpu kinds` for the kinds available in each region",
+	},
 	flag.StringArray{
 		Name:        "env",
 		Shorthand:   "e",
@@ -80,12 +86,10 @@ var sharedFlags = flag.Set{
 	flag.Bool{
 		Name:        "build-remote-only",
 		Description: "Perform builds remotely without using the local docker daemon",
-		Hidden:      true,
 	},
 	flag.Bool{
 		Name:        "build-local-only",
 		Description: "Only perform builds locally using the local docker daemon",
-		Hidden:      true,
 	},
 	flag.Bool{
 		Name:        "build-nixpacks",
@@ -98,7 +102,6 @@ var sharedFlags = flag.Set{
 	flag.StringArray{
 		Name:        "build-arg",
 		Description: "Set of build time variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
-		Hidden:      true,
 	},
 	flag.String{
 		Name:        "image-label",
@@ -170,9 +173,14 @@ var s = spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 func newRun() *cobra.Command {
 	const (
 		short = "Run a machine"
-		long  = short + "\n"
+		long  = short + `
 
-		usage = "run <image> [command]"
+The machine runs an existing image, or one built from a local path (e.g. ".")
+or --dockerfile, either with the local Docker daemon or the remote builder,
+and pushed to the Fly registry before launching.
+`
+
+		usage = "run <image|path> [command]"
 	)
 
 	cmd := command.New(usage, short, long, runMachineRun,
@@ -209,7 +217,7 @@ func newRun() *cobra.Command {
 		sharedFlags,
 	)
 
-	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Args = cobra.ArbitraryArgs
 
 	return cmd
 }
@@ -282,6 +290,10 @@ func runMachineRun(ctx context.Context) error {
 	}
 
 	imageOrPath := flag.FirstArg(ctx)
+	if imageOrPath == "" && flag.GetString(ctx, "dockerfile") != "" && len(flag.Args(ctx)) == 0 {
+		// Build the working directory with the given Dockerfile
+		imageOrPath = "."
+	}
 	if imageOrPath == "" {
 		return fmt.Errorf("image argument can't be an empty string")
 	}
@@ -309,6 +321,10 @@ func runMachineRun(ctx context.Context) error {
 	input.SkipLaunch = len(machineConf.Standbys) > 0
 	input.Config = machineConf
 
+	if err := policy.Check(ctx, app.Organization.Slug, policy.Plan{AppName: app.Name, Machines: []*api.LaunchMachineInput{&input}}); err != nil {
+		return err
+	}
+
 	machine, err := flapsClient.Launch(ctx, input)
 	if err != nil {
 		return fmt.Errorf("could not launch machine: %w", err)
@@ -418,10 +434,15 @@ func determineImage(ctx context.Context, appName string, imageOrPath string) (im
 	resolver := imgsrc.NewResolver(daemonType, client, appName, io)
 
 	// build if relative or absolute path
-	if strings.HasPrefix(imageOrPath, ".") || strings.HasPrefix(imageOrPath, "/") {
+	if isLocalBuildPath(imageOrPath) {
+		workingDir, err := buildContextDir(ctx, imageOrPath)
+		if err != nil {
+			return nil, err
+		}
+
 		opts := imgsrc.ImageOptions{
 			AppName:    appName,
-			WorkingDir: path.Join(state.WorkingDirectory(ctx)),
+			WorkingDir: workingDir,
 			Publish:    !flag.GetBuildOnly(ctx),
 			ImageLabel: flag.GetString(ctx, "image-label"),
 			Target:     flag.GetString(ctx, "build-target"),
@@ -430,6 +451,11 @@ func determineImage(ctx context.Context, appName string, imageOrPath string) (im
 
 		dockerfilePath := cfg.Dockerfile()
 
+		// a Dockerfile passed as the path to build is used over the one set in config
+		if info, err := os.Stat(imageOrPath); err == nil && !info.IsDir() {
+			dockerfilePath = imageOrPath
+		}
+
 		// dockerfile passed through flags takes precedence over the one set in config
 		if flag.GetString(ctx, "dockerfile") != "" {
 			dockerfilePath = flag.GetString(ctx, "dockerfile")
@@ -481,6 +507,28 @@ func determineImage(ctx context.Context, appName string, imageOrPath string) (im
 	return img, nil
 }
 
+func isLocalBuildPath(imageOrPath string) bool {
+	return strings.HasPrefix(imageOrPath, ".") || strings.HasPrefix(imageOrPath, "/")
+}
+
+// buildContextDir returns the directory to build an image from for the path
+// passed to run, which may point at a directory or at a Dockerfile.
+func buildContextDir(ctx context.Context, imageOrPath string) (string, error) {
+	dir := imageOrPath
+	if !filepath.IsAbs(dir) {
+		dir = path.Join(state.WorkingDirectory(ctx), dir)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed reading build path %s: %w", imageOrPath, err)
+	}
+	if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	return dir, nil
+}
+
 func determineMounts(ctx context.Context, mounts []api.MachineMount, region string) ([]api.MachineMount, error) {
 	unattachedVolumes := make(map[string][]api.Volume)
 
@@ -745,6 +793,19 @@ func determineMachineConfig(ctx context.Context, input *determineMachineConfigIn
 		return nil, fmt.Errorf("memory cannot be zero")
 	}
 
+	if gpuKind := flag.GetString(ctx, "vm-gpu-kind"); gpuKind != "" {
+		machineConf.Guest.GPUKind = gpuKind
+		if err := mach.ValidateGPURegion(gpuKind, input.region); err != nil {
+			return nil, err
+		}
+	}
+
+	if flag.IsSpecified(ctx, "vm-size") || flag.IsSpecified(ctx, "vm-cpus") || flag.IsSpecified(ctx, "vm-memory") || flag.IsSpecified(ctx, "vm-gpu-kind") {
+		if err := machineConf.Guest.Validate(); err != nil {
+			return nil, fmt.Errorf("%w, see `fly machine sizes` for valid sizes", err)
+		}
+	}
+
 	if len(flag.GetStringArray(ctx, "kernel-arg")) != 0 {
 		machineConf.Guest.KernelArgs = flag.GetStringArray(ctx, "kernel-arg")
 	}
@@ -777,7 +838,9 @@ func determineMachineConfig(ctx context.Context, input *determineMachineConfigIn
 		}
 	} else {
 		// Called from `run`. Command is specified by arguments.
-		machineConf.Init.Cmd = flag.Args(ctx)[1:]
+		if args := flag.Args(ctx); len(args) > 1 {
+			machineConf.Init.Cmd = args[1:]
+		}
 	}
 
 	if flag.IsSpecified(ctx, "skip-dns-registration") {
@@ -850,6 +913,10 @@ func determineMachineConfig(ctx context.Context, input *determineMachineConfigIn
 			return machineConf, err
 		}
 		machineConf.Image = img.Tag
+
+		if machineConf.Guest.GPUKind != "" && !isLocalBuildPath(input.imageOrPath) && !mach.LooksLikeCUDAImage(input.imageOrPath) {
+			terminal.Warnf("%s doesn't look like a CUDA base image; GPU machines need the CUDA runtime, e.g. from nvidia/cuda images\n", input.imageOrPath)
+		}
 	}
 
 	// Service updates
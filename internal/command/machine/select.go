@@ -7,11 +7,14 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/samber/lo"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	mach "github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/internal/prompt"
 )
 
@@ -20,6 +23,32 @@ var selectFlag = flag.Bool{
 	Description: "Select from a list of machines",
 }
 
+var selectorFlag = flag.StringArray{
+	Name:        "selector",
+	Shorthand:   "l",
+	Description: "Only the machines with these labels, as key=value pairs, e.g. --selector team=core,tier=web",
+}
+
+// machinesMatchingSelector returns the machines of the app matching the
+// labels of --selector.
+func machinesMatchingSelector(ctx context.Context) ([]*api.Machine, error) {
+	selector, err := mach.ParseSelector(flag.GetStringArray(ctx, selectorFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := flaps.FromContext(ctx).List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not get a list of machines: %w", err)
+	}
+
+	matching := lo.Filter(machines, func(m *api.Machine, _ int) bool { return mach.MatchesSelector(m, selector) })
+	if len(matching) == 0 {
+		return nil, fmt.Errorf("no machines of %s match %s", appconfig.NameFromContext(ctx), mach.FormatLabels(selector))
+	}
+	return matching, nil
+}
+
 func selectOneMachine(ctx context.Context, app *api.AppCompact, machineID string, haveMachineID bool) (*api.Machine, context.Context, error) {
 	if err := checkSelectCmdline(ctx, haveMachineID); err != nil {
 		return nil, nil, err
@@ -70,6 +99,11 @@ func selectManyMachines(ctx context.Context, machineIDs []string) ([]*api.Machin
 		if err != nil {
 			return nil, nil, err
 		}
+	} else if haveSelector(ctx) {
+		machines, err = machinesMatchingSelector(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
 	} else {
 		flapsClient := flaps.FromContext(ctx)
 		for _, machineID := range machineIDs {
@@ -106,6 +140,14 @@ func selectManyMachineIDs(ctx context.Context, machineIDs []string) ([]string, c
 		for _, machine := range machines {
 			machineIDs = append(machineIDs, machine.ID)
 		}
+	} else if haveSelector(ctx) {
+		machines, err := machinesMatchingSelector(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, machine := range machines {
+			machineIDs = append(machineIDs, machine.ID)
+		}
 	}
 	return machineIDs, ctx, nil
 }
@@ -229,22 +271,34 @@ func getMachineRole(machine *api.Machine) string {
 func rewriteMachineNotFoundErrors(ctx context.Context, err error, machineID string) error {
 	if strings.Contains(err.Error(), "machine not found") {
 		appName := appconfig.NameFromContext(ctx)
-		return fmt.Errorf("machine %s was not found in app '%s'", machineID, appName)
+		return flyerr.NotFound(fmt.Errorf("machine %s was not found in app '%s'", machineID, appName))
 	} else {
 		return nil
 	}
 }
 
+// haveSelector reports whether the command has a --selector flag set.
+func haveSelector(ctx context.Context) bool {
+	return len(flag.GetStringArray(ctx, selectorFlag.Name)) > 0
+}
+
 func checkSelectCmdline(ctx context.Context, haveMachineIDs bool) error {
 	haveSelectFlag := flag.GetBool(ctx, "select")
+	haveSelectorFlag := haveSelector(ctx)
 	appName := appconfig.NameFromContext(ctx)
 	switch {
+	case haveSelectFlag && haveSelectorFlag:
+		return errors.New("--select can't be used with --selector")
 	case haveSelectFlag && haveMachineIDs:
 		return errors.New("machine IDs can't be used with --select")
-	case !haveSelectFlag && !haveMachineIDs:
+	case haveSelectorFlag && haveMachineIDs:
+		return errors.New("machine IDs can't be used with --selector")
+	case !haveSelectFlag && !haveSelectorFlag && !haveMachineIDs:
 		return errors.New("a machine ID must be provided unless --select is used")
 	case haveSelectFlag && appName == "":
 		return errors.New("an app name must be specified to use --select")
+	case haveSelectorFlag && appName == "":
+		return errors.New("an app name must be specified to use --selector")
 	default:
 		return nil
 	}
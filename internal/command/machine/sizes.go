@@ -0,0 +1,187 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newSizes() *cobra.Command {
+	const (
+		short = "List valid machine sizes and their pricing"
+		long  = `List every valid guest configuration for machines: the presets accepted by
+--vm-size, the cpu counts and memory range of each cpu kind, and the GPU kinds
+a machine can be attached to, along with the regions they're available in and
+their hourly pricing.`
+		usage = "sizes"
+	)
+
+	cmd := command.New(usage, short, long, runSizes,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "region",
+			Shorthand:   "r",
+			Description: "Only list sizes available in this region",
+		},
+	)
+
+	return cmd
+}
+
+type machineSize struct {
+	Name         string   `json:"name"`
+	CPUKind      string   `json:"cpu_kind"`
+	CPUs         int      `json:"cpus"`
+	MemoryMB     int      `json:"memory_mb"`
+	MinMemoryMB  int      `json:"min_memory_mb"`
+	MaxMemoryMB  int      `json:"max_memory_mb"`
+	PricePerHour *float64 `json:"price_per_hour,omitempty"`
+	Regions      []string `json:"regions,omitempty"`
+	isGPU        bool
+}
+
+func runSizes(ctx context.Context) error {
+	var (
+		out    = iostreams.FromContext(ctx).Out
+		client = client.FromContext(ctx).API()
+		region = flag.GetString(ctx, "region")
+	)
+
+	vmSizes, err := client.PlatformVMSizes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed retrieving machine sizes: %w", err)
+	}
+
+	regions, _, err := client.PlatformRegions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed retrieving regions: %w", err)
+	}
+
+	sizes := machineSizes(vmSizes, regions)
+
+	if region != "" {
+		if !lo.ContainsBy(regions, func(r api.Region) bool { return r.Code == region }) {
+			return fmt.Errorf("unknown region %s, run `fly platform regions` to list regions", region)
+		}
+		sizes = lo.Filter(sizes, func(s machineSize, _ int) bool {
+			return lo.Contains(s.Regions, region)
+		})
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, sizes)
+	}
+
+	var cpuRows, gpuRows [][]string
+	for _, s := range sizes {
+		if s.isGPU {
+			gpuRows = append(gpuRows, []string{
+				s.Name,
+				strings.Join(s.Regions, ", "),
+			})
+			continue
+		}
+		cpuRows = append(cpuRows, []string{
+			s.Name,
+			s.CPUKind,
+			fmt.Sprint(s.CPUs),
+			memory(s.MemoryMB),
+			fmt.Sprintf("%s - %s", memory(s.MinMemoryMB), memory(s.MaxMemoryMB)),
+			price(s.PricePerHour),
+		})
+	}
+
+	if err := render.Table(out, "Sizes", cpuRows, "Name", "CPU Kind", "CPUs", "Memory", "Memory Range", "Price/Hour"); err != nil {
+		return err
+	}
+
+	return render.Table(out, "GPU Kinds", gpuRows, "Name", "Regions")
+}
+
+// machineSizes returns the machine sizes the platform reports followed by the
+// GPU kinds, each with the regions of the platform it's available in. Sizes
+// flyctl has no preset for are left out, since --vm-size doesn't accept them.
+func machineSizes(vmSizes []api.VMSize, regions []api.Region) []machineSize {
+	codes := lo.Map(regions, func(r api.Region, _ int) string { return r.Code })
+	sort.Strings(codes)
+
+	var sizes []machineSize
+	for _, s := range vmSizes {
+		guest, ok := api.MachinePresets[s.Name]
+		if !ok {
+			continue
+		}
+		minMemory, maxMemory := api.MemoryRange(guest.CPUKind, guest.CPUs)
+		if s.MaxMemoryMB > 0 {
+			maxMemory = s.MaxMemoryMB
+		}
+		price := float64(s.PriceSecond) * 3600
+		sizes = append(sizes, machineSize{
+			Name:         s.Name,
+			CPUKind:      guest.CPUKind,
+			CPUs:         guest.CPUs,
+			MemoryMB:     s.MemoryMB,
+			MinMemoryMB:  minMemory,
+			MaxMemoryMB:  maxMemory,
+			PricePerHour: &price,
+			Regions:      codes,
+		})
+	}
+
+	sort.Slice(sizes, func(i, j int) bool {
+		a, b := sizes[i], sizes[j]
+		if a.CPUKind != b.CPUKind {
+			return a.CPUKind == "shared"
+		}
+		return a.CPUs < b.CPUs
+	})
+
+	kinds := lo.Keys(api.MachineGPUKinds)
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		// Leave out the regions the platform no longer reports
+		available := lo.Intersect(codes, api.MachineGPUKinds[kind])
+		if len(available) == 0 {
+			continue
+		}
+		sizes = append(sizes, machineSize{
+			Name:    kind,
+			Regions: available,
+			isGPU:   true,
+		})
+	}
+
+	return sizes
+}
+
+func memory(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d MB", size)
+	}
+	return fmt.Sprintf("%d GB", size/1024)
+}
+
+func price(p *float64) string {
+	if p == nil {
+		return "-"
+	}
+	return fmt.Sprintf("$%.4f", *p)
+}
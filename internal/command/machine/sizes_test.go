@@ -0,0 +1,41 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestMachineSizes(t *testing.T) {
+	vmSizes := []api.VMSize{
+		{Name: "performance-1x", MemoryMB: 2048, MaxMemoryMB: 8192, PriceSecond: 0.00001},
+		{Name: "shared-cpu-1x", MemoryMB: 256, PriceSecond: 0.000001},
+		{Name: "dedicated-cpu-1x", MemoryMB: 2048},
+	}
+	regions := []api.Region{{Code: "ord"}, {Code: "ams"}}
+
+	sizes := machineSizes(vmSizes, regions)
+
+	names := make([]string, 0, len(sizes))
+	for _, s := range sizes {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"shared-cpu-1x", "performance-1x", "a10", "a100-pcie-40gb", "a100-sxm4-80gb", "l40s"}, names)
+
+	shared := sizes[0]
+	assert.Equal(t, []string{"ams", "ord"}, shared.Regions)
+	assert.Equal(t, 2048, shared.MaxMemoryMB)
+	require.NotNil(t, shared.PricePerHour)
+	assert.InDelta(t, 0.0036, *shared.PricePerHour, 0.0001)
+
+	assert.Equal(t, 8192, sizes[1].MaxMemoryMB)
+
+	for _, s := range sizes[2:] {
+		assert.True(t, s.isGPU)
+		assert.Subset(t, []string{"ams", "ord"}, s.Regions)
+	}
+	assert.Equal(t, []string{"ams"}, sizes[4].Regions)
+}
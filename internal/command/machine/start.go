@@ -8,6 +8,7 @@ import (
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/iostreams"
 )
 
@@ -31,6 +32,8 @@ func newStart() *cobra.Command {
 		flag.App(),
 		flag.AppConfig(),
 		selectFlag,
+		selectorFlag,
+		flag.ContinueOnError(),
 	)
 
 	return cmd
@@ -47,13 +50,13 @@ func runMachineStart(ctx context.Context) (err error) {
 		return err
 	}
 
-	for _, machineID := range machineIDs {
-		if err = Start(ctx, machineID); err != nil {
-			return
+	return flyerr.ForEach(machineIDs, flag.GetContinueOnError(ctx), func(machineID string) error {
+		if err := Start(ctx, machineID); err != nil {
+			return err
 		}
 		fmt.Fprintf(io.Out, "%s has been started\n", machineID)
-	}
-	return
+		return nil
+	})
 }
 
 func Start(ctx context.Context, machineID string) (err error) {
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path"
+	"strings"
+	"time"
+
 	"github.com/alecthomas/chroma/quick"
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/iostreams"
-	"time"
 )
 
 func newStatus() *cobra.Command {
@@ -97,6 +103,10 @@ func runMachineStatus(ctx context.Context) (err error) {
 		return
 	}
 
+	if err = renderSidecars(ctx, machine); err != nil {
+		return
+	}
+
 	eventLogs := [][]string{}
 
 	for _, event := range machine.Events {
@@ -133,3 +143,43 @@ func runMachineStatus(ctx context.Context) (err error) {
 
 	return
 }
+
+// renderSidecars lists the sidecars the machine was deployed with, along with
+// whether their process is currently running.
+func renderSidecars(ctx context.Context, machine *api.Machine) error {
+	sidecars, err := appconfig.SidecarsFromMachine(machine)
+	if err != nil || len(sidecars) == 0 {
+		return err
+	}
+
+	var processes api.MachinePsResponse
+	if machine.State == api.MachineStateStarted {
+		// Best effort, the status is shown as unknown when ps isn't available
+		processes, _ = flaps.FromContext(ctx).GetProcesses(ctx, machine.ID)
+	}
+
+	rows := [][]string{}
+	for _, sc := range sidecars {
+		status := "stopped"
+		switch {
+		case machine.State != api.MachineStateStarted:
+		case processes == nil:
+			status = "unknown"
+		case sidecarRunning(sc, processes):
+			status = "running"
+		}
+		rows = append(rows, []string{sc.Name, sc.Command, sc.RestartPolicy(), status})
+	}
+
+	return render.Table(iostreams.FromContext(ctx).Out, "Sidecars", rows, "Name", "Command", "Restart", "Status")
+}
+
+func sidecarRunning(sc appconfig.Sidecar, processes api.MachinePsResponse) bool {
+	executable := path.Base(sc.Executable())
+	for _, p := range processes {
+		if fields := strings.Fields(p.Command); len(fields) > 0 && path.Base(fields[0]) == executable {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/iostreams"
 )
 
@@ -34,6 +35,7 @@ func newStop() *cobra.Command {
 		flag.App(),
 		flag.AppConfig(),
 		selectFlag,
+		selectorFlag,
 		flag.String{
 			Name:        "signal",
 			Shorthand:   "s",
@@ -43,6 +45,7 @@ func newStop() *cobra.Command {
 			Name:        "timeout",
 			Description: "Seconds to wait before sending SIGKILL to the machine",
 		},
+		flag.ContinueOnError(),
 	)
 
 	return cmd
@@ -61,15 +64,15 @@ func runMachineStop(ctx context.Context) (err error) {
 		return err
 	}
 
-	for _, machineID := range machineIDs {
+	return flyerr.ForEach(machineIDs, flag.GetContinueOnError(ctx), func(machineID string) error {
 		fmt.Fprintf(io.Out, "Sending kill signal to machine %s...\n", machineID)
 
-		if err = Stop(ctx, machineID, signal, timeout); err != nil {
-			return
+		if err := Stop(ctx, machineID, signal, timeout); err != nil {
+			return err
 		}
 		fmt.Fprintf(io.Out, "%s has been successfully stopped\n", machineID)
-	}
-	return
+		return nil
+	})
 }
 
 func Stop(ctx context.Context, machineID string, signal string, timeout int) (err error) {
@@ -0,0 +1,275 @@
+package machine
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/azazeal/pause"
+	"github.com/dustin/go-humanize"
+	"github.com/inancgumus/screen"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/prometheus"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+var topSortKeys = []string{"cpu", "memory", "net-in", "net-out", "name", "region"}
+
+func newTop() *cobra.Command {
+	const (
+		short = "Show the live resource usage of the machines of an app"
+		long  = short + `
+
+Shows the CPU, memory and network usage of each machine of the app, from the
+metrics Fly.io scrapes, refreshing in place every --interval until
+interrupted. Metrics lag up to a minute behind the machines.
+
+Prints the usage once when not running interactively, with --once or --json.
+`
+
+		usage = "top"
+	)
+
+	cmd := command.New(usage, short, long, runMachineTop,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "sort",
+			Description: "Sort machines by " + strings.Join(topSortKeys, ", "),
+			Default:     "cpu",
+		},
+		flag.String{
+			Name:        "process-group",
+			Description: "Only show the machines of this process group",
+		},
+		flag.String{
+			Name:        "region",
+			Shorthand:   "r",
+			Description: "Only show the machines in this region",
+		},
+		flag.Duration{
+			Name:        "interval",
+			Description: "How often to refresh, at least 2s",
+			Default:     5 * time.Second,
+		},
+		flag.Bool{
+			Name:        "once",
+			Description: "Print the usage once instead of refreshing it",
+		},
+	)
+
+	return cmd
+}
+
+// machineUsage is the resource usage of a machine.
+type machineUsage struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	ProcessGroup string  `json:"process_group"`
+	Region       string  `json:"region"`
+	State        string  `json:"state"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	MemoryUsed   float64 `json:"memory_used_bytes"`
+	MemoryTotal  float64 `json:"memory_total_bytes"`
+	NetIn        float64 `json:"net_in_bytes_per_second"`
+	NetOut       float64 `json:"net_out_bytes_per_second"`
+}
+
+func runMachineTop(ctx context.Context) error {
+	var (
+		streams  = iostreams.FromContext(ctx)
+		appName  = appconfig.NameFromContext(ctx)
+		sortKey  = flag.GetString(ctx, "sort")
+		interval = flag.GetDuration(ctx, "interval")
+		once     = flag.GetBool(ctx, "once") || config.FromContext(ctx).JSONOutput || !streams.IsInteractive()
+	)
+
+	if !slices.Contains(topSortKeys, sortKey) {
+		return flyerr.Validation(fmt.Errorf("can't sort by %q, valid options are: %s", sortKey, strings.Join(topSortKeys, ", ")))
+	}
+	if interval < 2*time.Second {
+		return flyerr.Validation(errors.New("--interval must be at least 2s"))
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	if once {
+		return topOnce(ctx, streams.Out, app, flapsClient)
+	}
+
+	cs := streams.ColorScheme()
+	var buf bytes.Buffer
+	for {
+		buf.Reset()
+		if err := topOnce(ctx, &buf, app, flapsClient); err != nil {
+			// Interrupted with Ctrl-C
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return err
+		}
+
+		screen.Clear()
+		screen.MoveTopLeft()
+		fmt.Fprintf(streams.Out, "%s at: %s, by %s\n\n", cs.Bold(appName), cs.Bold(time.Now().UTC().Format("15:04:05")), sortKey)
+		io.Copy(streams.Out, &buf)
+
+		pause.For(ctx, interval)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil
+		}
+	}
+}
+
+// topOnce writes the usage of the machines of app to w.
+func topOnce(ctx context.Context, w io.Writer, app *api.AppCompact, flapsClient *flaps.Client) error {
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("could not get a list of machines: %w", err)
+	}
+	machines = filterTopMachines(machines, flag.GetString(ctx, "process-group"), flag.GetString(ctx, "region"))
+
+	values := map[string]map[string]float64{}
+	for name, query := range topQueries(app.Name) {
+		samples, err := prometheus.Query(ctx, app.Organization.Slug, query)
+		if err != nil {
+			return err
+		}
+		values[name] = map[string]float64{}
+		for _, s := range samples {
+			values[name][s.Labels["instance"]] = s.Value
+		}
+	}
+
+	usage := newMachineUsage(machines, values)
+	sortMachineUsage(usage, flag.GetString(ctx, "sort"))
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(w, usage)
+	}
+
+	rows := make([][]string, 0, len(usage))
+	for _, u := range usage {
+		memory := "-"
+		if u.MemoryTotal > 0 {
+			memory = fmt.Sprintf("%s / %s (%.0f%%)", humanize.Bytes(uint64(u.MemoryUsed)), humanize.Bytes(uint64(u.MemoryTotal)), 100*u.MemoryUsed/u.MemoryTotal)
+		}
+		rows = append(rows, []string{
+			u.ID,
+			u.Name,
+			u.ProcessGroup,
+			u.Region,
+			u.State,
+			fmt.Sprintf("%.1f%%", u.CPUPercent),
+			memory,
+			humanize.Bytes(uint64(u.NetIn)) + "/s",
+			humanize.Bytes(uint64(u.NetOut)) + "/s",
+		})
+	}
+	return render.Table(w, "", rows, "ID", "Name", "Process Group", "Region", "State", "CPU", "Memory", "Net In", "Net Out")
+}
+
+// topQueries returns the instant queries of the usage of the machines of
+// appName, by name. Fly.io labels the series of machines with their ID as
+// the instance.
+func topQueries(appName string) map[string]string {
+	sel := fmt.Sprintf(`app=%q`, appName)
+	return map[string]string{
+		// fly_instance_cpu counts centiseconds per CPU, so its rate out of
+		// idle, over the number of CPUs, is a percentage
+		"cpu":          fmt.Sprintf(`sum by (instance) (rate(fly_instance_cpu{%s,mode!="idle"}[1m])) / count by (instance) (fly_instance_cpu{%s,mode="idle"})`, sel, sel),
+		"memory_used":  fmt.Sprintf(`sum by (instance) (fly_instance_memory_mem_total{%s} - fly_instance_memory_mem_available{%s})`, sel, sel),
+		"memory_total": fmt.Sprintf(`sum by (instance) (fly_instance_memory_mem_total{%s})`, sel),
+		"net_in":       fmt.Sprintf(`sum by (instance) (rate(fly_instance_net_recv_bytes{%s,device="eth0"}[1m]))`, sel),
+		"net_out":      fmt.Sprintf(`sum by (instance) (rate(fly_instance_net_sent_bytes{%s,device="eth0"}[1m]))`, sel),
+	}
+}
+
+// filterTopMachines returns the machines of machines in group and region,
+// when set.
+func filterTopMachines(machines []*api.Machine, group, region string) []*api.Machine {
+	out := make([]*api.Machine, 0, len(machines))
+	for _, m := range machines {
+		if (group == "" || m.ProcessGroup() == group) && (region == "" || m.Region == region) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// newMachineUsage returns the usage of machines from values, the results of
+// topQueries by machine ID.
+func newMachineUsage(machines []*api.Machine, values map[string]map[string]float64) []machineUsage {
+	usage := make([]machineUsage, 0, len(machines))
+	for _, m := range machines {
+		usage = append(usage, machineUsage{
+			ID:           m.ID,
+			Name:         m.Name,
+			ProcessGroup: m.ProcessGroup(),
+			Region:       m.Region,
+			State:        m.State,
+			CPUPercent:   values["cpu"][m.ID],
+			MemoryUsed:   values["memory_used"][m.ID],
+			MemoryTotal:  values["memory_total"][m.ID],
+			NetIn:        values["net_in"][m.ID],
+			NetOut:       values["net_out"][m.ID],
+		})
+	}
+	return usage
+}
+
+// sortMachineUsage sorts usage by key, the largest usage first, or by name
+// or region in alphabetical order. Ties keep the order of machine IDs.
+func sortMachineUsage(usage []machineUsage, key string) {
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].ID < usage[j].ID })
+	sort.SliceStable(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		switch key {
+		case "memory":
+			return a.MemoryUsed > b.MemoryUsed
+		case "net-in":
+			return a.NetIn > b.NetIn
+		case "net-out":
+			return a.NetOut > b.NetOut
+		case "name":
+			return a.Name < b.Name
+		case "region":
+			return a.Region < b.Region
+		default:
+			return a.CPUPercent > b.CPUPercent
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func topMachine(id, group, region string) *api.Machine {
+	return &api.Machine{
+		ID:     id,
+		Name:   "name-" + id,
+		Region: region,
+		State:  api.MachineStateStarted,
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: group},
+		},
+	}
+}
+
+func TestMachineTop(t *testing.T) {
+	machines := []*api.Machine{
+		topMachine("m3", "app", "ord"),
+		topMachine("m1", "worker", "ams"),
+		topMachine("m2", "app", "ams"),
+	}
+
+	assert.Len(t, filterTopMachines(machines, "app", ""), 2)
+	assert.Len(t, filterTopMachines(machines, "app", "ams"), 1)
+	assert.Len(t, filterTopMachines(machines, "", ""), 3)
+
+	usage := newMachineUsage(machines, map[string]map[string]float64{
+		"cpu":          {"m1": 50, "m2": 50, "m3": 75},
+		"memory_used":  {"m1": 100, "m2": 300, "m3": 200},
+		"memory_total": {"m1": 1024},
+		"net_in":       {"m2": 10},
+	})
+	assert.Equal(t, machineUsage{
+		ID: "m1", Name: "name-m1", ProcessGroup: "worker", Region: "ams", State: api.MachineStateStarted,
+		CPUPercent: 50, MemoryUsed: 100, MemoryTotal: 1024,
+	}, usage[1])
+
+	ids := func() (out []string) {
+		for _, u := range usage {
+			out = append(out, u.ID)
+		}
+		return
+	}
+
+	sortMachineUsage(usage, "cpu")
+	assert.Equal(t, []string{"m3", "m1", "m2"}, ids())
+
+	sortMachineUsage(usage, "memory")
+	assert.Equal(t, []string{"m2", "m3", "m1"}, ids())
+
+	sortMachineUsage(usage, "region")
+	assert.Equal(t, []string{"m1", "m2", "m3"}, ids())
+
+	sortMachineUsage(usage, "net-in")
+	assert.Equal(t, []string{"m2", "m1", "m3"}, ids())
+
+	assert.Contains(t, topQueries("web")["cpu"], `fly_instance_cpu{app="web",mode!="idle"}`)
+}
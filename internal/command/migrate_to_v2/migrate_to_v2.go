@@ -22,6 +22,7 @@ import (
 	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/cache"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/command/apps"
 	"github.com/superfly/flyctl/internal/command/deploy"
@@ -843,6 +844,9 @@ func (m *v2PlatformMigrator) updateAppPlatformVersion(ctx context.Context, platf
 	if err != nil {
 		return err
 	}
+	if lookups := cache.LookupsFromContext(ctx); lookups != nil {
+		lookups.ForgetApp(m.appConfig.AppName)
+	}
 	m.recovery.platformVersion = platform
 	return nil
 }
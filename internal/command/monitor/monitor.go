@@ -17,7 +17,9 @@ import (
 func New() (cmd *cobra.Command) {
 	const (
 		short = `Monitor currently running application deployments`
-		long  = short + `. Use Control-C to stop output.`
+		long  = short + `. Use Control-C to stop output.
+
+Its subcommands monitor the uptime of apps from several regions.`
 	)
 
 	cmd = command.New("monitor", short, long, run,
@@ -31,6 +33,13 @@ func New() (cmd *cobra.Command) {
 		flag.AppConfig(),
 	)
 
+	cmd.AddCommand(
+		newCreate(),
+		newStatus(),
+		newDestroy(),
+		newProbe(),
+	)
+
 	return
 }
 
@@ -0,0 +1,322 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/notify"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// maxResults is how many results of each endpoint probers keep to compute
+// uptime, a day's worth at the default interval.
+const maxResults = 1440
+
+func newProbe() *cobra.Command {
+	const (
+		short = "Run an uptime prober"
+		long  = `Run an uptime prober, in a prober machine.`
+	)
+
+	cmd := command.New("probe", short, long, runProbe)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "config",
+			Description: "Path of the prober configuration",
+			Default:     proberConfigPath,
+		},
+	)
+
+	return cmd
+}
+
+// endpointStatus is the status of an endpoint seen from a region.
+type endpointStatus struct {
+	URL         string        `json:"url"`
+	Region      string        `json:"region"`
+	Up          bool          `json:"up"`
+	Checks      int           `json:"checks"`
+	Failures    int           `json:"failures"`
+	LastCheck   time.Time     `json:"last_check"`
+	LastLatency time.Duration `json:"last_latency"`
+	LastError   string        `json:"last_error,omitempty"`
+	DownSince   *time.Time    `json:"down_since,omitempty"`
+}
+
+// Uptime returns the percentage of successful checks.
+func (s endpointStatus) Uptime() float64 {
+	if s.Checks == 0 {
+		return 100
+	}
+	return 100 * float64(s.Checks-s.Failures) / float64(s.Checks)
+}
+
+// alert is posted to webhooks when an endpoint goes down or recovers.
+type alert struct {
+	App    string    `json:"app"`
+	URL    string    `json:"url"`
+	Region string    `json:"region"`
+	State  string    `json:"state"`
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+func (a alert) text() string {
+	if a.State == "down" {
+		return fmt.Sprintf(":red_circle: %s is down from %s: %s", a.URL, a.Region, a.Error)
+	}
+	return fmt.Sprintf(":large_green_circle: %s is back up from %s", a.URL, a.Region)
+}
+
+func runProbe(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	data, err := os.ReadFile(flag.GetString(ctx, "config"))
+	if err != nil {
+		return err
+	}
+
+	var cfg proberConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed decoding prober configuration: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	p := newProber(cfg, os.Getenv("FLY_REGION"), out)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", proberPort))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(out, "Probing %d endpoints of %s every %s\n", len(cfg.URLs), cfg.App, cfg.Interval)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.probeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// endpoint tracks the recent results of an endpoint.
+type endpoint struct {
+	status              endpointStatus
+	results             []bool
+	consecutiveFailures int
+}
+
+// prober probes endpoints, tracks their state and alerts on changes.
+type prober struct {
+	cfg    proberConfig
+	region string
+	out    io.Writer
+
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+}
+
+func newProber(cfg proberConfig, region string, out io.Writer) *prober {
+	p := &prober{
+		cfg:    cfg,
+		region: region,
+		out:    out,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+			// Redirects are a response like any other
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now: time.Now,
+	}
+	for _, u := range cfg.URLs {
+		p.endpoints = append(p.endpoints, &endpoint{status: endpointStatus{URL: u, Region: region, Up: true}})
+	}
+	return p
+}
+
+func (p *prober) probeAll(ctx context.Context) {
+	for _, e := range p.endpoints {
+		start := p.now()
+		err := p.probe(ctx, e.status.URL)
+		p.record(ctx, e, start, p.now().Sub(start), err)
+	}
+}
+
+func (p *prober) probe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "fly-uptime-prober")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode != p.cfg.ExpectStatus {
+		return fmt.Errorf("expected status %d, got %s", p.cfg.ExpectStatus, resp.Status)
+	}
+	return nil
+}
+
+// record updates the state of e with a result, alerting when the endpoint
+// goes down or recovers.
+func (p *prober) record(ctx context.Context, e *endpoint, at time.Time, latency time.Duration, err error) {
+	p.mu.Lock()
+
+	e.results = append(e.results, err == nil)
+	if len(e.results) > maxResults {
+		e.results = e.results[len(e.results)-maxResults:]
+	}
+
+	s := &e.status
+	s.Checks = len(e.results)
+	s.Failures = 0
+	for _, ok := range e.results {
+		if !ok {
+			s.Failures++
+		}
+	}
+	s.LastCheck = at.UTC()
+	s.LastLatency = latency
+
+	var changed *alert
+	if err == nil {
+		e.consecutiveFailures = 0
+		s.LastError = ""
+		if !s.Up {
+			s.Up = true
+			s.DownSince = nil
+			changed = &alert{State: "up"}
+		}
+	} else {
+		e.consecutiveFailures++
+		s.LastError = err.Error()
+		if s.Up && e.consecutiveFailures >= p.cfg.FailureThreshold {
+			s.Up = false
+			since := at.UTC()
+			s.DownSince = &since
+			changed = &alert{State: "down", Error: s.LastError}
+		}
+	}
+
+	p.mu.Unlock()
+
+	if changed == nil {
+		return
+	}
+
+	changed.App = p.cfg.App
+	changed.URL = s.URL
+	changed.Region = p.region
+	changed.At = at.UTC()
+
+	fmt.Fprintln(p.out, changed.text())
+	p.sendAlert(ctx, *changed)
+}
+
+func (p *prober) sendAlert(ctx context.Context, a alert) {
+	if p.cfg.AlertWebhook != "" {
+		if err := p.post(ctx, p.cfg.AlertWebhook, a); err != nil {
+			fmt.Fprintf(p.out, "Failed posting alert to webhook: %v\n", err)
+		}
+	}
+	if p.cfg.AlertSlack != "" {
+		// Discord accepts Slack formatted payloads on its /slack webhooks
+		payload := map[string]string{"text": a.text()}
+		if err := p.post(ctx, p.cfg.AlertSlack, payload); err != nil {
+			fmt.Fprintf(p.out, "Failed posting alert to Slack: %v\n", err)
+		}
+	}
+	for _, c := range p.cfg.AlertChannels {
+		msg := notify.Message{
+			Event:    notify.EventAlert,
+			App:      a.App,
+			Title:    fmt.Sprintf("%s is down from %s", a.URL, a.Region),
+			Text:     a.Error,
+			URL:      a.URL,
+			Resolved: a.State != "down",
+		}
+		if msg.Resolved {
+			msg.Title = fmt.Sprintf("%s is back up from %s", a.URL, a.Region)
+		}
+		if err := notify.Post(ctx, c, msg); err != nil {
+			fmt.Fprintf(p.out, "Failed posting alert to %s: %v\n", c.Name, err)
+		}
+	}
+}
+
+func (p *prober) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with %s", url, resp.Status)
+	}
+	return nil
+}
+
+// ServeHTTP serves the status of the endpoints on GET /status.
+func (p *prober) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Path != "/status" {
+		http.NotFound(w, r)
+		return
+	}
+
+	p.mu.Lock()
+	statuses := make([]endpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		statuses = append(statuses, e.status)
+	}
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
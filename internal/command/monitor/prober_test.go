@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProberAlerts(t *testing.T) {
+	var healthy atomic.Bool
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer app.Close()
+
+	alerts := make(chan alert, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		alerts <- a
+	}))
+	defer webhook.Close()
+
+	p := newProber(proberConfig{
+		App:              "my-app",
+		URLs:             []string{app.URL},
+		Interval:         time.Minute,
+		Timeout:          time.Second,
+		ExpectStatus:     http.StatusOK,
+		FailureThreshold: 2,
+		AlertWebhook:     webhook.URL,
+	}, "ams", io.Discard)
+
+	healthy.Store(true)
+	p.probeAll(context.Background())
+	healthy.Store(false)
+	p.probeAll(context.Background())
+	assert.Empty(t, alerts)
+
+	p.probeAll(context.Background())
+	down := <-alerts
+	assert.Equal(t, "down", down.State)
+	assert.Equal(t, "ams", down.Region)
+	assert.Equal(t, "expected status 200, got 502 Bad Gateway", down.Error)
+
+	healthy.Store(true)
+	p.probeAll(context.Background())
+	assert.Equal(t, "up", (<-alerts).State)
+
+	server := httptest.NewServer(p)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/status")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var statuses []endpointStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&statuses))
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Up)
+	assert.Equal(t, 4, statuses[0].Checks)
+	assert.Equal(t, 2, statuses[0].Failures)
+	assert.Equal(t, 50.0, statuses[0].Uptime())
+}
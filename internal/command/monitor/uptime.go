@@ -0,0 +1,571 @@
+package monitor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/notify"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	proberAppSuffix   = "-uptime"
+	proberConfigPath  = "/etc/fly-uptime/config.json"
+	proberMachineName = "prober"
+	proberPort        = 8080
+)
+
+func newCreate() *cobra.Command {
+	const (
+		long = `Monitor the uptime of an app from the outside. Prober machines launched in
+each of --regions, in a separate app of the organization, request the app's
+endpoints every --interval and expect --expect-status. Endpoints are --url
+addresses, or --path on the app's hostname, the root path by default.
+
+An endpoint is down after --failures consecutive failed requests. Alerts are
+posted to --alert-webhook as JSON, and to a Slack or Discord compatible
+incoming webhook with --alert-slack, when an endpoint goes down and when it
+recovers, as well as to the notification channels of the app subscribed to
+alerts, see fly notifications.
+
+Running the command again replaces the configuration and the regions probed
+from. See results with fly monitor status.`
+		short = "Monitor the uptime of an app from several regions"
+	)
+
+	cmd := command.New("create", short, long, runCreate,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly monitor create --regions ams,iad,syd
+  fly monitor create --regions iad,lhr --path /health --alert-slack https://hooks.slack.com/services/...`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.StringSlice{
+			Name:        "regions",
+			Description: "Comma separated regions to probe from",
+		},
+		flag.StringArray{
+			Name:        "url",
+			Description: "URL to probe, can be repeated",
+		},
+		flag.StringArray{
+			Name:        "path",
+			Description: "Path of the app's hostname to probe, can be repeated",
+		},
+		flag.Duration{
+			Name:        "interval",
+			Description: "How often to probe each endpoint",
+			Default:     time.Minute,
+		},
+		flag.Duration{
+			Name:        "timeout",
+			Description: "How long requests may take",
+			Default:     10 * time.Second,
+		},
+		flag.Int{
+			Name:        "expect-status",
+			Description: "HTTP status endpoints must respond with",
+			Default:     http.StatusOK,
+		},
+		flag.Int{
+			Name:        "failures",
+			Description: "Number of consecutive failures before an endpoint is down",
+			Default:     2,
+		},
+		flag.String{
+			Name:        "alert-webhook",
+			Description: "URL to post JSON alerts to",
+		},
+		flag.String{
+			Name:        "alert-slack",
+			Description: "Slack or Discord compatible incoming webhook URL to post alerts to",
+		},
+	)
+
+	return cmd
+}
+
+func newStatus() *cobra.Command {
+	const (
+		long  = `Show the uptime of the endpoints of an app, as seen from each region probing them.`
+		short = "Show uptime monitoring results"
+	)
+
+	cmd := command.New("status", short, long, runStatus,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newDestroy() *cobra.Command {
+	const (
+		long  = `Stop monitoring the uptime of an app, destroying its prober app.`
+		short = "Stop uptime monitoring"
+	)
+
+	cmd := command.New("destroy", short, long, runDestroy,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func proberAppName(appName string) string {
+	return appName + proberAppSuffix
+}
+
+func runCreate(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	regions := flag.GetStringSlice(ctx, "regions")
+	if len(regions) == 0 {
+		return errors.New("--regions is required")
+	}
+	slices.Sort(regions)
+	regions = slices.Compact(regions)
+
+	cfg := proberConfig{
+		App:              appName,
+		URLs:             endpointURLs(app, flag.GetStringArray(ctx, "url"), flag.GetStringArray(ctx, "path")),
+		Interval:         flag.GetDuration(ctx, "interval"),
+		Timeout:          flag.GetDuration(ctx, "timeout"),
+		ExpectStatus:     flag.GetInt(ctx, "expect-status"),
+		FailureThreshold: flag.GetInt(ctx, "failures"),
+		AlertWebhook:     flag.GetString(ctx, "alert-webhook"),
+		AlertSlack:       flag.GetString(ctx, "alert-slack"),
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	channels, err := notify.Load(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.AlertChannels = notify.Matching(channels, app.Organization.Slug, appName, notify.EventAlert)
+
+	proberApp, err := ensureProberApp(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	if err := launchProbers(ctx, proberApp, regions, cfg); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Probing %s from %s every %s\n", strings.Join(cfg.URLs, ", "), strings.Join(regions, ", "), cfg.Interval)
+	fmt.Fprintf(io.Out, "See results with fly monitor status -a %s\n", appName)
+	return nil
+}
+
+// endpointURLs returns the URLs to probe, defaulting to the root of the app's
+// hostname.
+func endpointURLs(app *api.AppCompact, urls, paths []string) []string {
+	hostname := app.Hostname
+	if hostname == "" {
+		hostname = app.Name + ".fly.dev"
+	}
+
+	endpoints := slices.Clone(urls)
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		endpoints = append(endpoints, "https://"+hostname+path)
+	}
+	if len(endpoints) == 0 {
+		endpoints = []string{"https://" + hostname + "/"}
+	}
+	return endpoints
+}
+
+// ensureProberApp returns the prober app of app, creating it if needed.
+func ensureProberApp(ctx context.Context, app *api.AppCompact) (*api.AppCompact, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		name      = proberAppName(app.Name)
+	)
+
+	proberApp, err := apiClient.GetAppCompact(ctx, name)
+	switch {
+	case err == nil:
+		return proberApp, nil
+	case !api.IsNotFoundError(err):
+		return nil, err
+	}
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = app.Organization.ID
+	input.Name = name
+
+	if _, err := gql.CreateApp(ctx, apiClient.GenqClient, input); err != nil {
+		return nil, fmt.Errorf("failed creating prober app %s: %w", name, err)
+	}
+	fmt.Fprintf(io.ErrOut, "Created prober app %s\n", name)
+
+	return apiClient.GetAppCompact(ctx, name)
+}
+
+// launchProbers runs a prober machine with cfg in each of regions, and
+// destroys the probers of other regions.
+func launchProbers(ctx context.Context, proberApp *api.AppCompact, regions []string, cfg proberConfig) error {
+	io := iostreams.FromContext(ctx)
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	config := &api.MachineConfig{
+		Init: api.MachineInit{
+			Entrypoint: []string{"flyctl"},
+			Cmd:        []string{"monitor", "probe", "--config", proberConfigPath},
+		},
+		Guest: &api.MachineGuest{
+			CPUKind:  "shared",
+			CPUs:     1,
+			MemoryMB: 256,
+		},
+		Files: []*api.File{{GuestPath: proberConfigPath, RawValue: &encoded}},
+	}
+
+	flapsClient, err := flaps.New(ctx, proberApp)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, m := range machines {
+		if m.Name != proberMachineName {
+			continue
+		}
+
+		if !slices.Contains(regions, m.Region) || existing[m.Region] {
+			if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: m.ID, Kill: true}, ""); err != nil {
+				return fmt.Errorf("failed destroying prober machine %s: %w", m.ID, err)
+			}
+			fmt.Fprintf(io.ErrOut, "Destroyed prober machine %s in %s\n", m.ID, m.Region)
+			continue
+		}
+		existing[m.Region] = true
+
+		if err := updateProber(ctx, m, config); err != nil {
+			return err
+		}
+	}
+
+	var missing []string
+	for _, region := range regions {
+		if !existing[region] {
+			missing = append(missing, region)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	infra.Configure(config, infra.ComponentFlyctl, nil)
+
+	costDelta := func(prices budget.Prices) float64 { return float64(len(missing)) * prices.Machine(config.Guest) }
+	if err := budget.Check(ctx, proberApp.Organization.Slug, proberApp.Name, "Launching uptime prober machines", costDelta); err != nil {
+		return err
+	}
+
+	for _, region := range missing {
+		machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+			Name:   proberMachineName,
+			Region: region,
+			Config: config,
+		})
+		if err != nil {
+			return fmt.Errorf("failed launching prober machine in %s: %w", region, err)
+		}
+		fmt.Fprintf(io.ErrOut, "Launched prober machine %s in %s\n", machine.ID, machine.Region)
+	}
+
+	return nil
+}
+
+func updateProber(ctx context.Context, m *api.Machine, config *api.MachineConfig) error {
+	machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	infra.Configure(config, infra.ComponentFlyctl, m)
+	return mach.Update(ctx, machine, &api.LaunchMachineInput{
+		Name:   proberMachineName,
+		Region: machine.Region,
+		Config: config,
+	})
+}
+
+func runStatus(ctx context.Context) error {
+	var (
+		out       = iostreams.FromContext(ctx).Out
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	proberApp, err := apiClient.GetAppCompact(ctx, proberAppName(appName))
+	if err != nil {
+		if api.IsNotFoundError(err) {
+			return fmt.Errorf("%s isn't monitored, start with fly monitor create", appName)
+		}
+		return err
+	}
+
+	flapsClient, err := flaps.New(ctx, proberApp)
+	if err != nil {
+		return err
+	}
+
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := privateClient(ctx, proberApp.Organization.Slug)
+	if err != nil {
+		return err
+	}
+
+	var statuses []endpointStatus
+	for _, m := range machines {
+		if m.Name != proberMachineName {
+			continue
+		}
+
+		regionStatuses, err := fetchStatus(ctx, httpClient, m)
+		if err != nil {
+			fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Failed fetching results from %s: %v\n", m.Region, err)
+			continue
+		}
+		statuses = append(statuses, regionStatuses...)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].URL != statuses[j].URL {
+			return statuses[i].URL < statuses[j].URL
+		}
+		return statuses[i].Region < statuses[j].Region
+	})
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, statuses)
+	}
+
+	rows := make([][]string, 0, len(statuses))
+	for _, s := range statuses {
+		state := "up"
+		if !s.Up {
+			state = "DOWN"
+		}
+		lastCheck := "-"
+		if !s.LastCheck.IsZero() {
+			lastCheck = s.LastCheck.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			s.URL,
+			s.Region,
+			state,
+			fmt.Sprintf("%.2f%%", s.Uptime()),
+			s.LastLatency.Round(time.Millisecond).String(),
+			lastCheck,
+			s.LastError,
+		})
+	}
+
+	return render.Table(out, "", rows, "URL", "Region", "State", "Uptime", "Latency", "Last Check", "Last Error")
+}
+
+// privateClient returns an HTTP client reaching the private network of the
+// organization.
+func privateClient(ctx context.Context, orgSlug string) (*http.Client, error) {
+	agentclient, err := agent.Establish(ctx, client.FromContext(ctx).API())
+	if err != nil {
+		return nil, err
+	}
+
+	dialer, err := agentclient.ConnectToTunnel(ctx, orgSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}, nil
+}
+
+// fetchStatus asks a prober machine for the status of its endpoints.
+func fetchStatus(ctx context.Context, httpClient *http.Client, m *api.Machine) ([]endpointStatus, error) {
+	endpoint := fmt.Sprintf("http://%s/status", net.JoinHostPort(m.PrivateIP, strconv.Itoa(proberPort)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prober responded with %s", resp.Status)
+	}
+
+	var statuses []endpointStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+func runDestroy(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		name      = proberAppName(appName)
+	)
+
+	if _, err := apiClient.GetAppCompact(ctx, name); err != nil {
+		if api.IsNotFoundError(err) {
+			return fmt.Errorf("%s isn't monitored", appName)
+		}
+		return err
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Destroy the prober app %s?", name); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := apiClient.DeleteApp(ctx, name); err != nil {
+		return fmt.Errorf("failed destroying prober app %s: %w", name, err)
+	}
+
+	fmt.Fprintf(io.Out, "Destroyed prober app %s, %s is no longer monitored\n", name, appName)
+	return nil
+}
+
+// proberConfig configures the probers of an app.
+type proberConfig struct {
+	App              string        `json:"app"`
+	URLs             []string      `json:"urls"`
+	Interval         time.Duration `json:"interval"`
+	Timeout          time.Duration `json:"timeout"`
+	ExpectStatus     int           `json:"expect_status"`
+	FailureThreshold int           `json:"failure_threshold"`
+	AlertWebhook     string        `json:"alert_webhook,omitempty"`
+	AlertSlack       string        `json:"alert_slack,omitempty"`
+	// AlertChannels are the notification channels of the app alerted too,
+	// see fly notifications.
+	AlertChannels []notify.Channel `json:"alert_channels,omitempty"`
+}
+
+func (cfg proberConfig) validate() error {
+	for _, u := range append(slices.Clone(cfg.URLs), cfg.AlertWebhook, cfg.AlertSlack) {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s is not an absolute http or https url", u)
+		}
+	}
+
+	switch {
+	case len(cfg.URLs) == 0:
+		return errors.New("no endpoints to probe")
+	case cfg.Interval < 10*time.Second:
+		return errors.New("--interval must be at least 10 seconds")
+	case cfg.Timeout <= 0 || cfg.Timeout > cfg.Interval:
+		return errors.New("--timeout must be positive and at most --interval")
+	case cfg.ExpectStatus < 100 || cfg.ExpectStatus > 599:
+		return errors.New("--expect-status must be an HTTP status")
+	case cfg.FailureThreshold < 1:
+		return errors.New("--failures must be at least 1")
+	}
+	return nil
+}
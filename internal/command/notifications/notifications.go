@@ -0,0 +1,291 @@
+// Package notifications implements the notifications command chain.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/notify"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new notifications Command.
+func New() *cobra.Command {
+	const (
+		long = `Post deploy results, automatic rollbacks and uptime alerts to Slack or
+Discord channels, through their incoming webhooks. Channels are notified of
+the events of an app, or of all the apps of an organization.
+
+Deploy results and rollbacks are posted by fly deploy. Uptime alerts are
+posted by the probers of fly monitor create, which pick up the channels of the
+app when they're created, so run it again after adding channels.
+
+Channels are stored in flyctl's configuration directory, so add them on every
+machine deploying the apps, such as CI runners.`
+		short = "Notify Slack and Discord channels of deploys and alerts"
+	)
+
+	cmd := command.New("notifications", short, long, nil)
+
+	cmd.AddCommand(
+		newAdd(),
+		newList(),
+		newRemove(),
+		newTest(),
+	)
+
+	return cmd
+}
+
+func newAdd() *cobra.Command {
+	const (
+		long = `Notify a Slack or Discord channel of the events of an app, or with --org of
+all the apps of an organization. Adding a channel of the same name replaces it.`
+		short = "Add a Slack or Discord channel"
+		usage = "add <slack|discord>"
+	)
+
+	cmd := command.New(usage, short, long, runAdd,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.ValidArgs = notify.Kinds
+	cmd.Example = `  fly notifications add slack --webhook-url https://hooks.slack.com/services/...
+  fly notifications add discord --org my-org --webhook-url https://discord.com/api/webhooks/... --event deploy.failed`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Org(),
+		flag.String{
+			Name:        "webhook-url",
+			Description: "Incoming webhook URL of the channel",
+		},
+		flag.String{
+			Name:        "name",
+			Description: "Name of the channel, its kind and app or organization by default",
+		},
+		flag.StringSlice{
+			Name:        "event",
+			Description: fmt.Sprintf("Events to post, of %s. All of them by default", strings.Join(notify.Events, ", ")),
+		},
+	)
+
+	return cmd
+}
+
+func newList() *cobra.Command {
+	const (
+		long  = `List the Slack and Discord channels notified of events.`
+		short = "List notification channels"
+	)
+
+	cmd := command.New("list", short, long, runList)
+
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newRemove() *cobra.Command {
+	const (
+		long  = `Stop notifying a channel.`
+		short = "Remove a notification channel"
+		usage = "remove <name>"
+	)
+
+	cmd := command.New(usage, short, long, runRemove)
+
+	cmd.Aliases = []string{"rm"}
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func newTest() *cobra.Command {
+	const (
+		long  = `Post a test message to a channel, or to all channels without a name.`
+		short = "Post a test message to notification channels"
+		usage = "test [name]"
+	)
+
+	cmd := command.New(usage, short, long, runTest)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	return cmd
+}
+
+func runAdd(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		orgSlug = flag.GetOrg(ctx)
+	)
+
+	c := notify.Channel{
+		Name:       flag.GetString(ctx, "name"),
+		Kind:       flag.FirstArg(ctx),
+		WebhookURL: flag.GetString(ctx, "webhook-url"),
+		Events:     flag.GetStringSlice(ctx, "event"),
+	}
+
+	if orgSlug != "" {
+		org, err := orgs.OrgFromSlug(ctx, orgSlug)
+		if err != nil {
+			return err
+		}
+		c.Org = org.Slug
+	} else {
+		c.App = appconfig.NameFromContext(ctx)
+		if c.App == "" {
+			return errors.New("specify the app with --app, or the organization with --org")
+		}
+	}
+
+	if c.Name == "" {
+		c.Name = c.Kind + "-" + c.App + c.Org
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	channels, err := notify.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	channels = lo.Reject(channels, func(existing notify.Channel, _ int) bool { return existing.Name == c.Name })
+	channels = append(channels, c)
+
+	if err := notify.Save(ctx, channels); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Notifying %s channel %s of the events of %s\n", c.Kind, c.Name, c.Scope())
+	fmt.Fprintf(out, "Post a test message with fly notifications test %s\n", c.Name)
+	return nil
+}
+
+func runList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	channels, err := notify.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, channels)
+	}
+
+	if len(channels) == 0 {
+		fmt.Fprintln(out, "No notification channels, add one with fly notifications add")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(channels))
+	for _, c := range channels {
+		events := strings.Join(c.Events, ", ")
+		if events == "" {
+			events = "all"
+		}
+		rows = append(rows, []string{c.Name, c.Kind, c.Scope(), events, redactURL(c.WebhookURL)})
+	}
+
+	return render.Table(out, "", rows, "Name", "Kind", "Scope", "Events", "Webhook URL")
+}
+
+// redactURL hides the path of incoming webhook URLs, which holds their
+// credentials.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "<redacted>"
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/..."
+}
+
+func runRemove(ctx context.Context) error {
+	var (
+		out  = iostreams.FromContext(ctx).Out
+		name = flag.FirstArg(ctx)
+	)
+
+	channels, err := notify.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	kept := lo.Reject(channels, func(c notify.Channel, _ int) bool { return c.Name == name })
+	if len(kept) == len(channels) {
+		return fmt.Errorf("no notification channel named %s", name)
+	}
+
+	if err := notify.Save(ctx, kept); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Channel %s removed\n", name)
+	return nil
+}
+
+func runTest(ctx context.Context) error {
+	var (
+		out  = iostreams.FromContext(ctx).Out
+		name = flag.FirstArg(ctx)
+	)
+
+	channels, err := notify.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		channels = lo.Filter(channels, func(c notify.Channel, _ int) bool { return c.Name == name })
+		if len(channels) == 0 {
+			return fmt.Errorf("no notification channel named %s", name)
+		}
+	}
+	if len(channels) == 0 {
+		return errors.New("no notification channels, add one with fly notifications add")
+	}
+
+	var failed int
+	for _, c := range channels {
+		err := notify.Post(ctx, c, notify.Message{
+			Event: notify.EventTest,
+			App:   c.App,
+			Title: "Test notification from flyctl",
+			Text:  fmt.Sprintf("Channel %s is notified of the events of %s.", c.Name, c.Scope()),
+		})
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "Failed posting to %s: %v\n", c.Name, err)
+			continue
+		}
+		fmt.Fprintf(out, "Posted a test message to %s\n", c.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed posting to %d of %d channels", failed, len(channels))
+	}
+	return nil
+}
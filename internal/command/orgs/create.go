@@ -2,25 +2,37 @@ package orgs
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/budget"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
 )
 
 func newCreate() *cobra.Command {
 	const (
 		long = `Create a new organization. Other users can be invited to join the
 organization later.
+
+The slug of the organization is derived from its name, and must be available.
+With --default-region, the region is preselected when creating resources in
+the organization.
+
+Resources can't be created in the organization until it has a payment method.
 `
 		short = "Create an organization"
 		usage = "create [name]"
@@ -35,6 +47,10 @@ organization later.
 			Description: "Configure this org to use apps v2 by default for new apps",
 			Default:     false,
 		},
+		flag.String{
+			Name:        "default-region",
+			Description: "Region preselected when creating resources in the organization",
+		},
 	)
 	cmd.Args = cobra.MaximumNArgs(1)
 
@@ -50,6 +66,21 @@ func runCreate(ctx context.Context) error {
 
 	client := client.FromContext(ctx).API()
 
+	slug := slugFromName(name)
+	if err := validateSlug(slug); err != nil {
+		return fmt.Errorf("invalid organization name %q: %w", name, err)
+	}
+	if _, err := gql.GetOrganization(ctx, client.GenqClient, slug); err == nil {
+		return fmt.Errorf("the slug %s of organization %q is taken, choose another name", slug, name)
+	}
+
+	region := flag.GetString(ctx, "default-region")
+	if region != "" {
+		if err := validateRegion(ctx, region); err != nil {
+			return err
+		}
+	}
+
 	var org *api.Organization
 	if flag.GetBool(ctx, "apps-v2-default-on") {
 		org, err = client.CreateOrganizationWithAppsV2DefaultOn(ctx, name)
@@ -60,15 +91,73 @@ func runCreate(ctx context.Context) error {
 		return fmt.Errorf("failed creating organization: %w", err)
 	}
 
-	if io := iostreams.FromContext(ctx); config.FromContext(ctx).JSONOutput {
+	io := iostreams.FromContext(ctx)
+
+	if region != "" {
+		if err := config.SetOrgRegion(state.ConfigFile(ctx), org.Slug, region); err != nil {
+			return fmt.Errorf("failed saving the default region of %s: %w", org.Slug, err)
+		}
+	}
+
+	if config.FromContext(ctx).JSONOutput {
 		_ = render.JSON(io.Out, org)
-	} else {
-		printOrg(io.Out, org, true)
+		return nil
+	}
+
+	printOrg(io.Out, org, true)
+	if region != "" {
+		fmt.Fprintf(io.Out, "\nDefault region: %s\n", region)
+	}
+	if err := budget.CheckBilling(ctx, org.Slug); err != nil {
+		fmt.Fprintf(io.ErrOut, "\n%s %v\n", io.ColorScheme().Yellow("WARN"), err)
 	}
 
 	return nil
 }
 
+var (
+	slugInvalidChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+	slugDashes       = regexp.MustCompile(`-{2,}`)
+)
+
+// slugFromName derives the slug of an organization from its name, like the
+// platform does.
+func slugFromName(name string) string {
+	slug := slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	slug = slugDashes.ReplaceAllString(slug, "-")
+	return strings.Trim(slug, "-")
+}
+
+// maxSlugLength matches the length limit of a DNS label, as slugs end up in
+// hostnames.
+const maxSlugLength = 63
+
+func validateSlug(slug string) error {
+	switch {
+	case slug == "":
+		return errors.New("it must contain letters or digits")
+	case len(slug) > maxSlugLength:
+		return fmt.Errorf("its slug %s is longer than %d characters", slug, maxSlugLength)
+	case slug == "personal":
+		return errors.New("personal is reserved for personal organizations")
+	default:
+		return nil
+	}
+}
+
+func validateRegion(ctx context.Context, code string) error {
+	regions, _, err := client.FromContext(ctx).API().PlatformRegions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range regions {
+		if r.Code == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown region %s, run fly platform regions to see valid regions", code)
+}
+
 func nameFromFirstArgOrPrompt(ctx context.Context) (name string, err error) {
 	if name = flag.FirstArg(ctx); name != "" {
 		return
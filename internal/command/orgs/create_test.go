@@ -0,0 +1,22 @@
+package orgs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugFromName(t *testing.T) {
+	assert.Equal(t, "acme-corp", slugFromName("Acme Corp"))
+	assert.Equal(t, "acme-corp", slugFromName("  Acme -- Corp!  "))
+	assert.Equal(t, "team_42", slugFromName("Team_42"))
+	assert.Equal(t, "", slugFromName("!!!"))
+}
+
+func TestValidateSlug(t *testing.T) {
+	assert.NoError(t, validateSlug("acme-corp"))
+	assert.Error(t, validateSlug(""))
+	assert.Error(t, validateSlug("personal"))
+	assert.Error(t, validateSlug(strings.Repeat("a", maxSlugLength+1)))
+}
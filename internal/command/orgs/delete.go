@@ -9,8 +9,10 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/state"
 	"github.com/superfly/flyctl/iostreams"
 )
 
@@ -64,5 +66,15 @@ func runDelete(ctx context.Context) error {
 		return fmt.Errorf("failed deleting organization %s", err)
 	}
 
+	// Forget the local settings of the organization
+	path := state.ConfigFile(ctx)
+	if err := config.SetOrgRegion(path, org.Slug, ""); err != nil {
+		return fmt.Errorf("failed removing the default region of %s from %s: %w", org.Slug, path, err)
+	}
+	if err := config.SetBudget(path, config.BudgetOrg, org.Slug, 0); err != nil {
+		return fmt.Errorf("failed removing the budget of %s from %s: %w", org.Slug, path, err)
+	}
+
+	fmt.Fprintf(io.Out, "Deleted organization %s\n", org.Slug)
 	return nil
 }
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
@@ -62,6 +64,12 @@ func runShow(ctx context.Context) (err error) {
 	fmt.Fprintf(&buf, "%-10s: %-20s\n", "Name", org.Name)
 	fmt.Fprintf(&buf, "%-10s: %-20s\n", "Slug", org.Slug)
 	fmt.Fprintf(&buf, "%-10s: %-20s\n", "Type", org.Type)
+	if region := config.FromContext(ctx).OrgRegion(org.Slug); region != "" {
+		fmt.Fprintf(&buf, "%-10s: %-20s\n", "Region", region)
+	}
+	if billing, err := gql.GetOrganizationBilling(ctx, client.GenqClient, org.Slug); err == nil {
+		fmt.Fprintf(&buf, "%-10s: %-20s\n", "Billing", strings.ToLower(strings.ReplaceAll(string(billing.Organization.BillingStatus), "_", " ")))
+	}
 	fmt.Fprintln(&buf)
 
 	fmt.Fprintln(&buf, colorize.Bold("Summary"))
@@ -0,0 +1,566 @@
+// Package pipelines implements the pipelines command chain.
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/format"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// metadataKeyPipeline is the metadata key of the machines of the apps of a
+// pipeline holding its definition and state, as JSON.
+const metadataKeyPipeline = "fly_pipeline"
+
+// historySize is how many promotions pipelines remember.
+const historySize = 20
+
+// New initializes and returns a new pipelines Command.
+func New() *cobra.Command {
+	const (
+		long = `Pipelines are ordered stages, such as staging then production, each an app.
+Images move through them by promotion: the image the machines of a stage run
+is deployed to the next stage, once it has the approvals the stage requires,
+then verified by the smoke tests of the stage.
+
+Pipelines are stored in the metadata of the machines of their apps, so the
+commands take any app of the pipeline.`
+		short = "Promote images between staging and production apps"
+	)
+
+	cmd := command.New("pipelines", short, long, nil)
+	cmd.Aliases = []string{"pipeline"}
+
+	cmd.AddCommand(
+		newCreate(),
+		newShow(),
+		newApprove(),
+		newPromote(),
+		newDestroy(),
+	)
+
+	return cmd
+}
+
+// Pipeline is a pipeline of apps images are promoted through.
+type Pipeline struct {
+	Name      string      `json:"name"`
+	Stages    []Stage     `json:"stages"`
+	Approvals []Approval  `json:"approvals,omitempty"`
+	History   []Promotion `json:"history,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Stage is an app of a pipeline.
+type Stage struct {
+	App               string   `json:"app"`
+	RequiredApprovals int      `json:"required_approvals,omitempty"`
+	SmokeURLs         []string `json:"smoke_urls,omitempty"`
+	AutoRollback      bool     `json:"auto_rollback,omitempty"`
+}
+
+// Approval approves the promotion of an image to the stage of an app.
+type Approval struct {
+	App   string    `json:"app"`
+	Image string    `json:"image"`
+	By    string    `json:"by"`
+	At    time.Time `json:"at"`
+}
+
+// Promotion is the promotion of an image between two stages.
+type Promotion struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Image  string    `json:"image"`
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// stage returns the index of the stage of app in p, or -1.
+func (p *Pipeline) stage(app string) int {
+	return lo.IndexOf(lo.Map(p.Stages, func(s Stage, _ int) string { return s.App }), app)
+}
+
+// apps returns the apps of the stages of p.
+func (p *Pipeline) apps() []string {
+	return lo.Map(p.Stages, func(s Stage, _ int) string { return s.App })
+}
+
+// approvers returns who approved promoting image to the stage of app.
+func (p *Pipeline) approvers(app, image string) []string {
+	return lo.Uniq(lo.FilterMap(p.Approvals, func(a Approval, _ int) (string, bool) {
+		return a.By, a.App == app && a.Image == image
+	}))
+}
+
+// approve records the approval of image for the stage of app by by.
+func (p *Pipeline) approve(app, image, by string, at time.Time) {
+	if lo.Contains(p.approvers(app, image), by) {
+		return
+	}
+	// Approvals of other images of the stage are outdated
+	p.Approvals = lo.Reject(p.Approvals, func(a Approval, _ int) bool { return a.App == app && a.Image != image })
+	p.Approvals = append(p.Approvals, Approval{App: app, Image: image, By: by, At: at})
+}
+
+// record records a promotion, consuming the approvals of its image.
+func (p *Pipeline) record(promotion Promotion) {
+	if promotion.Status == "succeeded" {
+		p.Approvals = lo.Reject(p.Approvals, func(a Approval, _ int) bool { return a.App == promotion.To })
+	}
+	p.History = append([]Promotion{promotion}, p.History...)
+	if len(p.History) > historySize {
+		p.History = p.History[:historySize]
+	}
+}
+
+func newCreate() *cobra.Command {
+	const (
+		long = `Create a pipeline of the apps of --stage, in the order given. Stages after
+the first one can require approvals of promotions with --approvals, and verify
+them with smoke tests of --smoke-url, besides the smoke tests of their
+fly.toml.
+
+The apps must have machines, which store the pipeline. Recreating a pipeline
+keeps its history.`
+		short = "Create or update a pipeline"
+		usage = "create <name>"
+	)
+
+	cmd := command.New(usage, short, long, runCreate,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly pipelines create web --stage web-staging --stage web-production \
+    --approvals web-production=1 --smoke-url web-production=/health --auto-rollback web-production`
+
+	flag.Add(cmd,
+		flag.StringArray{
+			Name:        "stage",
+			Description: "App of a stage, in order. Can be specified multiple times",
+		},
+		flag.StringArray{
+			Name:        "approvals",
+			Description: "Approvals promotions to a stage require, as app=count",
+		},
+		flag.StringArray{
+			Name:        "smoke-url",
+			Description: "URL, or path on the app's hostname, verifying promotions to a stage, as app=url. Can be specified multiple times",
+		},
+		flag.StringArray{
+			Name:        "auto-rollback",
+			Description: "App whose machines roll back when the smoke tests of a promotion fail",
+		},
+	)
+
+	return cmd
+}
+
+func newShow() *cobra.Command {
+	const (
+		long  = `Show the stages of the pipeline of an app, the image each runs, the approvals of pending promotions and the latest promotions.`
+		short = "Show the state of a pipeline"
+	)
+
+	cmd := command.New("show", short, long, runShow,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"status"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newDestroy() *cobra.Command {
+	const (
+		long  = `Destroy the pipeline of an app, removing it from the machines of all its apps. The apps are left as they are.`
+		short = "Destroy a pipeline"
+	)
+
+	cmd := command.New("destroy", short, long, runDestroy,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+// stagesFromFlags returns the stages of the apps of --stage with the
+// settings of the other flags.
+func stagesFromFlags(apps, approvals, smokeURLs, autoRollback []string) ([]Stage, error) {
+	if len(apps) < 2 {
+		return nil, flyerr.Validation(errors.New("a pipeline needs at least two stages, pass --stage for each app"))
+	}
+	if len(lo.Uniq(apps)) != len(apps) {
+		return nil, flyerr.Validation(errors.New("an app can only be a stage once"))
+	}
+
+	stages := lo.Map(apps, func(app string, _ int) Stage { return Stage{App: app} })
+	stageOf := func(app, flagName string) (*Stage, error) {
+		i := lo.IndexOf(apps, app)
+		if i < 0 {
+			return nil, flyerr.Validation(fmt.Errorf("--%s app %s isn't a stage", flagName, app))
+		}
+		return &stages[i], nil
+	}
+
+	counts, err := cmdutil.ParseKVStringsToMap(approvals)
+	if err != nil {
+		return nil, flyerr.Validation(fmt.Errorf("invalid --approvals: %w", err))
+	}
+	for app, v := range counts {
+		stage, err := stageOf(app, "approvals")
+		if err != nil {
+			return nil, err
+		}
+		if stage.RequiredApprovals, err = strconv.Atoi(v); err != nil || stage.RequiredApprovals < 0 {
+			return nil, flyerr.Validation(fmt.Errorf("invalid --approvals %s=%s, the count must be a positive number", app, v))
+		}
+	}
+
+	for _, kv := range smokeURLs {
+		app, url, ok := strings.Cut(kv, "=")
+		if !ok || url == "" {
+			return nil, flyerr.Validation(fmt.Errorf("invalid --smoke-url %s, expected app=url", kv))
+		}
+		if err := validateSmokeURL(url); err != nil {
+			return nil, flyerr.Validation(fmt.Errorf("invalid --smoke-url %s: %w", kv, err))
+		}
+		stage, err := stageOf(app, "smoke-url")
+		if err != nil {
+			return nil, err
+		}
+		stage.SmokeURLs = append(stage.SmokeURLs, url)
+	}
+
+	for _, app := range autoRollback {
+		stage, err := stageOf(app, "auto-rollback")
+		if err != nil {
+			return nil, err
+		}
+		stage.AutoRollback = true
+	}
+
+	if stages[0].RequiredApprovals > 0 || len(stages[0].SmokeURLs) > 0 {
+		return nil, flyerr.Validation(fmt.Errorf("%s is the first stage, which images are deployed to rather than promoted", stages[0].App))
+	}
+	return stages, nil
+}
+
+func runCreate(ctx context.Context) error {
+	var (
+		io   = iostreams.FromContext(ctx)
+		name = flag.FirstArg(ctx)
+	)
+
+	stages, err := stagesFromFlags(
+		flag.GetStringArray(ctx, "stage"),
+		flag.GetStringArray(ctx, "approvals"),
+		flag.GetStringArray(ctx, "smoke-url"),
+		flag.GetStringArray(ctx, "auto-rollback"),
+	)
+	if err != nil {
+		return err
+	}
+
+	pipeline := &Pipeline{Name: name, Stages: stages}
+	var dropped []string
+	for _, stage := range stages {
+		existing, err := load(ctx, stage.App)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			continue
+		}
+		if existing.Name != name {
+			return fmt.Errorf("%s is already a stage of the %s pipeline, destroy it first", stage.App, existing.Name)
+		}
+		pipeline.History = existing.History
+		dropped, _ = lo.Difference(existing.apps(), pipeline.apps())
+	}
+
+	if err := save(ctx, pipeline); err != nil {
+		return err
+	}
+	for _, app := range dropped {
+		if err := forget(ctx, app); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Pipeline %s: %s\n", name, strings.Join(pipeline.apps(), " → "))
+	return nil
+}
+
+// stageState is the state of a stage of a pipeline.
+type stageState struct {
+	Stage
+	Image     string   `json:"image"`
+	Version   string   `json:"version"`
+	Pending   string   `json:"pending,omitempty"`
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+func runShow(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	pipeline, err := mustLoad(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	states := make([]stageState, len(pipeline.Stages))
+	for i, stage := range pipeline.Stages {
+		states[i].Stage = stage
+		m, err := currentRelease(ctx, stage.App)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			states[i].Image, states[i].Version = m.FullImageRef(), m.Config.Metadata[api.MachineConfigMetadataKeyFlyReleaseVersion]
+		}
+	}
+	for i := 1; i < len(states); i++ {
+		if image := states[i-1].Image; image != "" && image != states[i].Image {
+			states[i].Pending = image
+			states[i].Approvers = pipeline.approvers(states[i].App, image)
+		}
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, map[string]any{
+			"name":    pipeline.Name,
+			"stages":  states,
+			"history": pipeline.History,
+		})
+	}
+
+	rows := make([][]string, 0, len(states))
+	for _, s := range states {
+		pending := "-"
+		if s.Pending != "" {
+			pending = imageTag(s.Pending)
+			if s.RequiredApprovals > 0 {
+				pending += fmt.Sprintf(" (%d/%d approvals)", len(s.Approvers), s.RequiredApprovals)
+			}
+		}
+		version := "-"
+		if s.Version != "" {
+			version = "v" + s.Version
+		}
+		rows = append(rows, []string{s.App, version, imageTag(s.Image), pending})
+	}
+	if err := render.Table(out, "Pipeline "+pipeline.Name, rows, "Stage", "Release", "Image", "Pending Promotion"); err != nil {
+		return err
+	}
+
+	if len(pipeline.History) == 0 {
+		return nil
+	}
+	rows = rows[:0]
+	for _, p := range pipeline.History {
+		status := p.Status
+		if p.Error != "" {
+			status += ": " + p.Error
+		}
+		rows = append(rows, []string{p.From + " → " + p.To, imageTag(p.Image), p.By, format.RelativeTime(p.At), status})
+	}
+	return render.Table(out, "Promotions", rows, "Stages", "Image", "By", "When", "Status")
+}
+
+func runDestroy(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	pipeline, err := mustLoad(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range pipeline.apps() {
+		if err := forget(ctx, app); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Destroyed pipeline %s\n", pipeline.Name)
+	return nil
+}
+
+// imageTag shortens image to its repository and tag.
+func imageTag(image string) string {
+	if image == "" {
+		return "-"
+	}
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.Index(image, "/"); i >= 0 {
+		image = image[i+1:]
+	}
+	return image
+}
+
+// appMachines returns the flaps client and machines of app, leaving out the
+// ones the platform runs for it.
+func appMachines(ctx context.Context, app string) (*flaps.Client, []*api.Machine, error) {
+	appCompact, err := client.FromContext(ctx).API().GetAppCompact(ctx, app)
+	if err != nil {
+		return nil, nil, err
+	}
+	flapsClient, err := flaps.New(ctx, appCompact)
+	if err != nil {
+		return nil, nil, err
+	}
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return flapsClient, lo.Filter(machines, func(m *api.Machine, _ int) bool { return m.Config != nil }), nil
+}
+
+// currentRelease returns the machine of app running its latest release, or
+// nil when it has none.
+func currentRelease(ctx context.Context, app string) (*api.Machine, error) {
+	_, machines, err := appMachines(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return latestRelease(machines), nil
+}
+
+func latestRelease(machines []*api.Machine) *api.Machine {
+	var (
+		latest  *api.Machine
+		version = -1
+	)
+	for _, m := range machines {
+		v, err := strconv.Atoi(m.Config.Metadata[api.MachineConfigMetadataKeyFlyReleaseVersion])
+		if err != nil {
+			v = 0
+		}
+		if v > version {
+			latest, version = m, v
+		}
+	}
+	return latest
+}
+
+// load returns the pipeline app is a stage of, or nil.
+func load(ctx context.Context, app string) (*Pipeline, error) {
+	_, machines, err := appMachines(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	return decode(machines)
+}
+
+func mustLoad(ctx context.Context, app string) (*Pipeline, error) {
+	pipeline, err := load(ctx, app)
+	if err == nil && pipeline == nil {
+		err = fmt.Errorf("%s isn't a stage of a pipeline, create one with fly pipelines create", app)
+	}
+	return pipeline, err
+}
+
+// decode returns the latest pipeline stored on machines, or nil.
+func decode(machines []*api.Machine) (*Pipeline, error) {
+	var latest *Pipeline
+	for _, m := range machines {
+		data := m.Config.Metadata[metadataKeyPipeline]
+		if data == "" {
+			continue
+		}
+		var p Pipeline
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			return nil, fmt.Errorf("invalid pipeline on machine %s: %w", m.ID, err)
+		}
+		if latest == nil || p.UpdatedAt.After(latest.UpdatedAt) {
+			latest = &p
+		}
+	}
+	return latest, nil
+}
+
+// save stores pipeline on the machines of its apps.
+func save(ctx context.Context, pipeline *Pipeline) error {
+	pipeline.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(pipeline)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range pipeline.apps() {
+		flapsClient, machines, err := appMachines(ctx, app)
+		if err != nil {
+			return err
+		}
+		if len(machines) == 0 {
+			return fmt.Errorf("%s has no machines to store the pipeline on, deploy it first", app)
+		}
+		for _, m := range machines {
+			if err := flapsClient.SetMetadata(ctx, m.ID, metadataKeyPipeline, string(data)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forget removes the pipeline stored on the machines of app.
+func forget(ctx context.Context, app string) error {
+	flapsClient, machines, err := appMachines(ctx, app)
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if _, ok := m.Config.Metadata[metadataKeyPipeline]; !ok {
+			continue
+		}
+		if err := flapsClient.DeleteMetadata(ctx, m.ID, metadataKeyPipeline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
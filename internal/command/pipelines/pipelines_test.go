@@ -0,0 +1,123 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestStagesFromFlags(t *testing.T) {
+	stages, err := stagesFromFlags(
+		[]string{"web-staging", "web-production"},
+		[]string{"web-production=2"},
+		[]string{"web-production=/health", "web-production=https://example.com/"},
+		[]string{"web-production"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []Stage{
+		{App: "web-staging"},
+		{App: "web-production", RequiredApprovals: 2, SmokeURLs: []string{"/health", "https://example.com/"}, AutoRollback: true},
+	}, stages)
+
+	for _, tc := range []struct {
+		apps, approvals, urls, rollback []string
+	}{
+		{apps: []string{"web"}},
+		{apps: []string{"web", "web"}},
+		{apps: []string{"a", "b"}, approvals: []string{"c=1"}},
+		{apps: []string{"a", "b"}, approvals: []string{"b=-1"}},
+		{apps: []string{"a", "b"}, approvals: []string{"a=1"}},
+		{apps: []string{"a", "b"}, urls: []string{"b=health"}},
+		{apps: []string{"a", "b"}, urls: []string{"b"}},
+		{apps: []string{"a", "b"}, rollback: []string{"c"}},
+	} {
+		_, err := stagesFromFlags(tc.apps, tc.approvals, tc.urls, tc.rollback)
+		assert.Error(t, err, tc)
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	p := &Pipeline{Stages: []Stage{{App: "staging"}, {App: "production", RequiredApprovals: 2}}}
+	now := time.Now()
+
+	p.approve("production", "img:1", "alice@example.com", now)
+	p.approve("production", "img:1", "alice@example.com", now)
+	p.approve("production", "img:1", "bob@example.com", now)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, p.approvers("production", "img:1"))
+	assert.Empty(t, p.approvers("production", "img:2"))
+
+	// Approving another image drops the approvals of the previous one
+	p.approve("production", "img:2", "alice@example.com", now)
+	assert.Empty(t, p.approvers("production", "img:1"))
+	assert.Len(t, p.approvers("production", "img:2"), 1)
+
+	p.record(Promotion{To: "production", Image: "img:2", Status: "failed"})
+	assert.Len(t, p.approvers("production", "img:2"), 1, "failed promotions keep approvals")
+	p.record(Promotion{To: "production", Image: "img:2", Status: "succeeded"})
+	assert.Empty(t, p.Approvals)
+	assert.Len(t, p.History, 2)
+	assert.Equal(t, "succeeded", p.History[0].Status)
+
+	for i := 0; i < 2*historySize; i++ {
+		p.record(Promotion{To: "production"})
+	}
+	assert.Len(t, p.History, historySize)
+
+	assert.Equal(t, 1, p.stage("production"))
+	assert.Equal(t, -1, p.stage("other"))
+}
+
+func TestDecode(t *testing.T) {
+	encode := func(p Pipeline) string {
+		data, err := json.Marshal(p)
+		require.NoError(t, err)
+		return string(data)
+	}
+	now := time.Now()
+	machines := []*api.Machine{
+		{ID: "m1", Config: &api.MachineConfig{Metadata: map[string]string{metadataKeyPipeline: encode(Pipeline{Name: "old", UpdatedAt: now.Add(-time.Hour)})}}},
+		{ID: "m2", Config: &api.MachineConfig{Metadata: map[string]string{metadataKeyPipeline: encode(Pipeline{Name: "new", UpdatedAt: now})}}},
+		{ID: "m3", Config: &api.MachineConfig{}},
+	}
+
+	p, err := decode(machines)
+	require.NoError(t, err)
+	assert.Equal(t, "new", p.Name)
+
+	p, err = decode(machines[2:])
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	machines[2].Config.Metadata = map[string]string{metadataKeyPipeline: "{"}
+	_, err = decode(machines)
+	assert.Error(t, err)
+}
+
+func TestLatestRelease(t *testing.T) {
+	machine := func(id, version string) *api.Machine {
+		return &api.Machine{ID: id, Config: &api.MachineConfig{Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyReleaseVersion: version,
+		}}}
+	}
+
+	assert.Nil(t, latestRelease(nil))
+	assert.Equal(t, "m2", latestRelease([]*api.Machine{machine("m1", "3"), machine("m2", "12"), machine("m3", "")}).ID)
+}
+
+func TestImageTag(t *testing.T) {
+	assert.Equal(t, "web-staging:deployment-01H", imageTag("registry.fly.io/web-staging:deployment-01H@sha256:abc"))
+	assert.Equal(t, "-", imageTag(""))
+}
+
+func TestSmokeTests(t *testing.T) {
+	tests := smokeTests([]string{"/health", "https://example.com/"})
+	require.Len(t, tests, 2)
+	assert.Equal(t, "/health", tests[0].Path)
+	assert.Equal(t, "https://example.com/", tests[1].URL)
+	assert.Equal(t, smokeRetries, tests[1].Retries)
+}
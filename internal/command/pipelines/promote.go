@@ -0,0 +1,258 @@
+package pipelines
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// smokeRetries is how many times the smoke URLs of stages are retried.
+const smokeRetries = 3
+
+func newApprove() *cobra.Command {
+	const (
+		long = `Approve promoting the image of the previous stage to the stage of an app.
+Approvals are per image: they're consumed by the promotion of the image, and
+dropped when the previous stage runs another image.`
+		short = "Approve a pending promotion"
+	)
+
+	cmd := command.New("approve", short, long, runApprove,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func newPromote() *cobra.Command {
+	const (
+		long = `Promote the image the previous stage runs to the stage of an app, deploying
+it with the config the app is deployed with, as fly deploy --image does. The
+promotion needs the approvals the stage requires, and is verified by the smoke
+tests of the stage and of its fly.toml.`
+		short = "Promote an image to the next stage"
+	)
+
+	cmd := command.New("promote", short, long, runPromote,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly pipelines promote --app web-production`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+// pendingPromotion returns the pipeline of app, with its stage and the image
+// of the previous stage to promote to it.
+func pendingPromotion(ctx context.Context, app string) (pipeline *Pipeline, stage int, image string, err error) {
+	if pipeline, err = mustLoad(ctx, app); err != nil {
+		return nil, 0, "", err
+	}
+
+	stage = pipeline.stage(app)
+	if stage <= 0 {
+		return nil, 0, "", fmt.Errorf("%s is the first stage of the %s pipeline, deploy to it with fly deploy", app, pipeline.Name)
+	}
+	from := pipeline.Stages[stage-1].App
+
+	source, err := currentRelease(ctx, from)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if source == nil {
+		return nil, 0, "", fmt.Errorf("%s has no machines, so no image to promote", from)
+	}
+	image = source.FullImageRef()
+
+	target, err := currentRelease(ctx, app)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if target != nil && target.FullImageRef() == image {
+		return pipeline, stage, "", nil
+	}
+	return pipeline, stage, image, nil
+}
+
+func runApprove(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	pipeline, stage, image, err := pendingPromotion(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if image == "" {
+		fmt.Fprintf(io.Out, "%s already runs the image of %s, nothing to approve\n", appName, pipeline.Stages[stage-1].App)
+		return nil
+	}
+
+	user, err := client.FromContext(ctx).API().GetCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	pipeline.approve(appName, image, user.Email, time.Now().UTC())
+	if err := save(ctx, pipeline); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Approved promoting %s to %s (%d/%d approvals)\n",
+		imageTag(image), appName, len(pipeline.approvers(appName, image)), pipeline.Stages[stage].RequiredApprovals)
+	return nil
+}
+
+func runPromote(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		appName   = appconfig.NameFromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	pipeline, i, image, err := pendingPromotion(ctx, appName)
+	if err != nil {
+		return err
+	}
+	stage, from := pipeline.Stages[i], pipeline.Stages[i-1].App
+	if image == "" {
+		fmt.Fprintf(io.Out, "%s already runs the image of %s, nothing to promote\n", appName, from)
+		return nil
+	}
+
+	if approvers := pipeline.approvers(appName, image); len(approvers) < stage.RequiredApprovals {
+		return fmt.Errorf("promoting %s to %s requires %d approvals, it has %d, approve it with fly pipelines approve --app %s",
+			imageTag(image), appName, stage.RequiredApprovals, len(approvers), appName)
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Promote %s from %s to %s?", imageTag(image), from, appName)
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	user, err := apiClient.GetCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Promoting %s from %s to %s\n", imageTag(image), from, appName)
+	promotion := Promotion{From: from, To: appName, Image: image, By: user.Email, At: time.Now().UTC(), Status: "succeeded"}
+
+	deployErr := promote(ctx, stage, image)
+	if deployErr != nil {
+		promotion.Status, promotion.Error = "failed", deployErr.Error()
+	}
+
+	// The deploy may have replaced machines, storing the pipeline on the new
+	// ones too
+	pipeline.record(promotion)
+	if err := save(ctx, pipeline); err != nil {
+		if deployErr != nil {
+			return deployErr
+		}
+		return fmt.Errorf("promoted %s to %s, but failed recording it: %w", imageTag(image), appName, err)
+	}
+	if deployErr != nil {
+		return deployErr
+	}
+
+	fmt.Fprintf(io.Out, "Promoted %s to %s\n", imageTag(image), appName)
+	return nil
+}
+
+// promote deploys image to the app of stage with its deployed config.
+func promote(ctx context.Context, stage Stage, image string) error {
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, stage.App)
+	if err != nil {
+		return err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	// Promotions move images only, so the local fly.toml isn't deployed
+	// along
+	cfg, err := appconfig.FromRemoteApp(ctx, stage.App)
+	if err != nil {
+		return fmt.Errorf("error loading the config of %s: %w", stage.App, err)
+	}
+	ctx = appconfig.WithConfig(ctx, cfg)
+
+	md, err := deploy.NewMachineDeployment(ctx, deploy.MachineDeploymentArgs{
+		AppCompact:      app,
+		DeploymentImage: image,
+		SmokeURLs:       smokeTests(stage.SmokeURLs),
+		AutoRollback:    stage.AutoRollback || (cfg.Experimental != nil && cfg.Experimental.AutoRollback),
+	})
+	if err != nil {
+		return err
+	}
+	return md.DeployMachinesApp(ctx)
+}
+
+// smokeTests returns the smoke tests requesting urls, either absolute or
+// paths on the app's hostname.
+func smokeTests(urls []string) []appconfig.SmokeTest {
+	var tests []appconfig.SmokeTest
+	for _, u := range urls {
+		t := appconfig.SmokeTest{Name: u, Status: http.StatusOK, Retries: smokeRetries}
+		if strings.HasPrefix(u, "/") {
+			t.Path = u
+		} else {
+			t.URL = u
+		}
+		tests = append(tests, t)
+	}
+	return tests
+}
+
+// validateSmokeURL checks u is an absolute http or https URL, or a path.
+func validateSmokeURL(u string) error {
+	if strings.HasPrefix(u, "/") || strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return nil
+	}
+	return errors.New("it must be an absolute http or https url or a path starting with '/'")
+}
@@ -0,0 +1,285 @@
+// Package policy implements the policy command chain.
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/policy"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new policy Command.
+func New() *cobra.Command {
+	const (
+		long = `Manage the local policies of organizations: rules on the regions, sizes
+and metadata of machines, and on log shipping, that this flyctl checks before
+deploy, machine run and scale create or resize machines.
+
+A policy is a TOML file such as:
+
+  allowed_regions = ["ams", "fra"]
+  max_cpus = 4
+  max_memory_mb = 8192
+  allow_performance_cpus = false
+  required_metadata = ["team"]
+  require_log_shipping = true
+  enforce = true
+
+Violations fail commands when enforce is true, and are printed as warnings
+otherwise.
+
+Policies are a local safeguard, not an access control: they are stored in
+flyctl's configuration directory and only checked by this flyctl. The platform
+does not enforce them, so the API, the dashboard and flyctl installs without
+the policy bypass them. Commit the policy file next to your apps and set it on
+every machine that manages the organization.`
+		short = "Manage local organization policies"
+	)
+
+	cmd := command.New("policy", short, long, nil)
+
+	cmd.AddCommand(
+		newSet(),
+		newShow(),
+		newRemove(),
+		newTest(),
+	)
+
+	return cmd
+}
+
+func newSet() *cobra.Command {
+	const (
+		long = `Set the local policy of an organization from a TOML file. The policy only
+applies to commands run by this flyctl.`
+		short = "Set the policy of an organization"
+		usage = "set <file>"
+	)
+
+	cmd := command.New(usage, short, long, runSet,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+	)
+
+	return cmd
+}
+
+func newShow() *cobra.Command {
+	const (
+		long  = `Show the policy of an organization.`
+		short = "Show the policy of an organization"
+	)
+
+	cmd := command.New("show", short, long, runShow,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newRemove() *cobra.Command {
+	const (
+		long  = `Remove the policy of an organization.`
+		short = "Remove the policy of an organization"
+	)
+
+	cmd := command.New("remove", short, long, runRemove,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(cmd,
+		flag.Org(),
+	)
+
+	return cmd
+}
+
+func newTest() *cobra.Command {
+	const (
+		long = `Check what deploying the app's configuration would run against the policy
+of its organization, without deploying. The app's machines are checked with
+their updated configuration, along with the machines a deploy would launch.
+
+Pass --policy to test a policy file before setting it.`
+		short = "Test a planned deploy against the policy"
+	)
+
+	cmd := command.New("test", short, long, runTest,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "policy",
+			Description: "Path of a policy file to test instead of the organization's policy",
+		},
+	)
+
+	return cmd
+}
+
+func runSet(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	p, err := policy.LoadFile(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Save(ctx, org.Slug, p); err != nil {
+		return fmt.Errorf("failed saving policy: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "Policy of %s set in %s, it only applies to commands run by this flyctl\n", org.Slug, policy.Path(ctx, org.Slug))
+	return nil
+}
+
+func runShow(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	p, err := policy.Load(ctx, org.Slug)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, p)
+	}
+
+	if p == nil {
+		fmt.Fprintf(out, "%s has no policy\n", org.Slug)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(p); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+func runRemove(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Remove(ctx, org.Slug); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Policy of %s removed\n", org.Slug)
+	return nil
+}
+
+func runTest(ctx context.Context) error {
+	var (
+		out       = iostreams.FromContext(ctx).Out
+		appName   = appconfig.NameFromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	appCompact, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	orgSlug := appCompact.Organization.Slug
+
+	var p *policy.Policy
+	if path := flag.GetString(ctx, "policy"); path != "" {
+		p, err = policy.LoadFile(path)
+	} else {
+		p, err = policy.Load(ctx, orgSlug)
+	}
+	switch {
+	case err != nil:
+		return err
+	case p == nil:
+		return fmt.Errorf("%s has no policy, set one with `fly policy set` or pass --policy", orgSlug)
+	}
+
+	appConfig := appconfig.ConfigFromContext(ctx)
+	if appConfig == nil {
+		if appConfig, err = appconfig.FromRemoteApp(ctx, appName); err != nil {
+			return err
+		}
+	}
+
+	plan, err := deploy.PolicyPlan(ctx, appCompact, appConfig, nil)
+	if err != nil {
+		return err
+	}
+
+	violations, err := policy.Test(ctx, p, orgSlug, plan)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(out, violations); err != nil {
+			return err
+		}
+	} else if len(violations) == 0 {
+		fmt.Fprintf(out, "Deploying %s complies with the policy of %s (%d machines checked)\n", appName, orgSlug, len(plan.Machines))
+	} else {
+		rows := make([][]string, 0, len(violations))
+		for _, v := range violations {
+			rows = append(rows, []string{v.Rule, v.Machine, v.Message})
+		}
+		if err := render.Table(out, "Policy violations", rows, "Rule", "Machine", "Violation"); err != nil {
+			return err
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("deploying %s would break %d rule(s) of the policy", appName, len(violations))
+	}
+	return nil
+}
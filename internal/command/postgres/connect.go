@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/mattn/go-colorable"
@@ -22,7 +23,13 @@ import (
 func newConnect() *cobra.Command {
 	const (
 		short = "Connect to the Postgres console"
-		long  = short + "\n"
+		long  = short + `
+
+With --psql, psql runs locally through a proxy to the leader, and with --url
+the command prints a connection URL for local tools and keeps the proxy open
+until --ttl expires or it is interrupted. Unless --password is set, both
+connect with a temporary user that is removed when the command exits.
+`
 
 		usage = "connect"
 	)
@@ -52,6 +59,23 @@ func newConnect() *cobra.Command {
 			Shorthand:   "p",
 			Description: "The postgres user password",
 		},
+		flag.Bool{
+			Name:        "psql",
+			Description: "Run psql locally instead of in the leader's console",
+		},
+		flag.Bool{
+			Name:        "url",
+			Description: "Print a temporary connection URL for local tools",
+		},
+		flag.Bool{
+			Name:        "direct",
+			Description: "Connect to the leader's Postgres port directly, bypassing the proxy that routes connections to the leader",
+		},
+		flag.Duration{
+			Name:        "ttl",
+			Description: "How long the connection URL printed by --url stays valid",
+			Default:     time.Hour,
+		},
 	)
 
 	return cmd
@@ -113,6 +137,11 @@ func runMachineConnect(ctx context.Context, app *api.AppCompact) error {
 	if err != nil {
 		return err
 	}
+
+	if flag.GetBool(ctx, "psql") || flag.GetBool(ctx, "url") {
+		return runLocalConnect(ctx, app, leader.PrivateIP)
+	}
+
 	return ssh.SSHConnect(&ssh.SSHParams{
 		Ctx:      ctx,
 		Org:      app.Organization,
@@ -142,6 +171,10 @@ func runNomadConnect(ctx context.Context, app *api.AppCompact) error {
 		return err
 	}
 
+	if flag.GetBool(ctx, "psql") || flag.GetBool(ctx, "url") {
+		return fmt.Errorf("--psql and --url require a machines cluster")
+	}
+
 	agentclient, err := agent.Establish(ctx, client)
 	if err != nil {
 		return fmt.Errorf("failed to establish agent: %w", err)
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flypg"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/proxy"
+)
+
+const (
+	proxyPort    = 5432
+	postgresPort = 5433
+)
+
+// runLocalConnect connects local tools to the cluster through a proxy to the
+// leader, either running psql or printing a connection URL.
+func runLocalConnect(ctx context.Context, app *api.AppCompact, leaderIP string) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		dialer   = agent.DialerFromContext(ctx)
+		runPsql  = flag.GetBool(ctx, "psql")
+		database = flag.GetString(ctx, "database")
+		user     = flag.GetString(ctx, "user")
+		password = flag.GetString(ctx, "password")
+	)
+
+	if runPsql && flag.GetBool(ctx, "url") {
+		return errors.New("set either --psql or --url, not both")
+	}
+
+	psqlPath := ""
+	if runPsql {
+		var err error
+		if psqlPath, err = exec.LookPath("psql"); err != nil {
+			return errors.New("psql isn't installed, install it or use --url with another client")
+		}
+	}
+
+	remotePort := proxyPort
+	if flag.GetBool(ctx, "direct") {
+		remotePort = postgresPort
+	}
+
+	if password == "" {
+		pgclient := flypg.NewFromInstance(leaderIP, dialer)
+
+		var err error
+		if user, password, err = createTemporaryUser(ctx, pgclient); err != nil {
+			return err
+		}
+		defer func() {
+			// the command's context is cancelled on interrupt, which must not
+			// leave the user behind
+			if err := pgclient.DeleteUser(context.Background(), user); err != nil {
+				fmt.Fprintf(io.ErrOut, "failed removing temporary user %s: %v\n", user, err)
+			}
+		}()
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	// psql handles interrupts itself, so the proxy outlives the context of
+	// the command until psql exits
+	proxyCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := &proxy.Server{
+		Addr:     net.JoinHostPort(leaderIP, strconv.Itoa(remotePort)),
+		Listener: listener,
+		Dial:     dialer.DialContext,
+	}
+	go server.ProxyServer(proxyCtx)
+
+	connURL := connectionURL(user, password, listener.Addr().(*net.TCPAddr).Port, database)
+
+	if runPsql {
+		cmd := exec.Command(psqlPath, connURL)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = io.Out
+		cmd.Stderr = io.ErrOut
+		return cmd.Run()
+	}
+
+	ttl := flag.GetDuration(ctx, "ttl")
+	fmt.Fprintln(io.Out, connURL)
+	fmt.Fprintf(io.ErrOut, "Connection URL of %s valid until %s, press Ctrl-C to revoke it sooner\n",
+		app.Name, time.Now().Add(ttl).Format(time.Kitchen))
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(ttl):
+	}
+	return nil
+}
+
+// createTemporaryUser creates a superuser with a random name and password.
+func createTemporaryUser(ctx context.Context, pgclient *flypg.Client) (user, password string, err error) {
+	suffix, err := helpers.RandString(8)
+	if err != nil {
+		return "", "", err
+	}
+	user = "flyctl_" + strings.ToLower(suffix)
+
+	if password, err = helpers.RandString(24); err != nil {
+		return "", "", err
+	}
+
+	if err := pgclient.CreateUser(ctx, user, password, true); err != nil {
+		return "", "", fmt.Errorf("failed creating temporary user: %w", err)
+	}
+	return user, password, nil
+}
+
+func connectionURL(user, password string, port int, database string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     net.JoinHostPort("localhost", strconv.Itoa(port)),
+		Path:     "/" + database,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
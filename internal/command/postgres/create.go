@@ -240,6 +240,7 @@ func run(ctx context.Context) (err error) {
 	var region *api.Region
 	region, err = prompt.Region(ctx, !org.PaidPlan, prompt.RegionParams{
 		Message: "",
+		OrgSlug: org.Slug,
 	})
 	if err != nil {
 		return
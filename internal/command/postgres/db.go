@@ -15,6 +15,7 @@ import (
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/iostreams"
 )
@@ -29,6 +30,8 @@ func newDb() *cobra.Command {
 
 	cmd.AddCommand(
 		newListDbs(),
+		newCreateDb(),
+		newDropDb(),
 	)
 
 	flag.Add(cmd, flag.JSONOutput())
@@ -59,6 +62,58 @@ func newListDbs() *cobra.Command {
 	return cmd
 }
 
+func newCreateDb() *cobra.Command {
+	const (
+		short = "Create a database"
+		long  = short + `. Give users access to it with
+fly postgres users create or grant.
+`
+
+		usage = "create <name>"
+	)
+
+	cmd := command.New(usage, short, long, runCreateDb,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func newDropDb() *cobra.Command {
+	const (
+		short = "Drop a database and all its data"
+		long  = short + "\n"
+
+		usage = "drop <name>"
+	)
+
+	cmd := command.New(usage, short, long, runDropDb,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
 func runListDbs(ctx context.Context) error {
 	var (
 		client  = client.FromContext(ctx).API()
@@ -189,3 +244,73 @@ func listDBs(ctx context.Context, leaderIP string) error {
 
 	return render.Table(io.Out, "", rows, "Name", "Users")
 }
+
+func runCreateDb(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	name, err := pgIdentifier(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx, app, _, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+	pgclient := flypg.NewFromInstance(leader.PrivateIP, agent.DialerFromContext(ctx))
+
+	if exists, err := pgclient.DatabaseExists(ctx, name); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("database %s already exists on %s", name, app.Name)
+	}
+
+	if err := pgclient.CreateDatabase(ctx, name); err != nil {
+		return fmt.Errorf("failed creating database %s: %w", name, err)
+	}
+
+	fmt.Fprintf(io.Out, "Created database %s\n", name)
+	return nil
+}
+
+func runDropDb(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	name, err := pgIdentifier(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx, app, _, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+	pgclient := flypg.NewFromInstance(leader.PrivateIP, agent.DialerFromContext(ctx))
+
+	if exists, err := pgclient.DatabaseExists(ctx, name); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("database %s doesn't exist on %s", name, app.Name)
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Drop database %s from %s? All its data will be lost", name, app.Name)
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := pgclient.DeleteDatabase(ctx, name); err != nil {
+		return fmt.Errorf("failed dropping database %s: %w", name, err)
+	}
+
+	fmt.Fprintf(io.Out, "Dropped database %s\n", name)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/avast/retry-go/v4"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/mattn/go-colorable"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/api"
@@ -28,7 +29,9 @@ import (
 func newFailover() *cobra.Command {
 	const (
 		short = "Failover to a new primary"
-		long  = short + "\n"
+		long  = short + `. Flex clusters fail over to a healthy replica in the
+primary region, or to the one given with --to.
+`
 		usage = "failover"
 	)
 
@@ -41,6 +44,10 @@ func newFailover() *cobra.Command {
 		cmd,
 		flag.App(),
 		flag.AppConfig(),
+		flag.String{
+			Name:        "to",
+			Description: "The ID of the replica to promote, flex clusters only",
+		},
 	)
 
 	return cmd
@@ -95,8 +102,13 @@ func runFailover(ctx context.Context) (err error) {
 		return err
 	}
 
+	to := flag.GetString(ctx, "to")
+	if to != "" && !IsFlex(leader) {
+		return fmt.Errorf("--to is only supported by flex clusters")
+	}
+
 	if IsFlex(leader) {
-		if failoverErr := flexFailover(ctx, machines, app); failoverErr != nil {
+		if failoverErr := flexFailover(ctx, machines, app, to); failoverErr != nil {
 			if err := handleFlexFailoverFail(ctx, machines); err != nil {
 				fmt.Fprintf(io.ErrOut, "Failed to handle failover failure, please manually configure PG cluster primary")
 			}
@@ -142,7 +154,7 @@ func runFailover(ctx context.Context) (err error) {
 	return
 }
 
-func flexFailover(ctx context.Context, machines []*api.Machine, app *api.AppCompact) error {
+func flexFailover(ctx context.Context, machines []*api.Machine, app *api.AppCompact, to string) error {
 	if len(machines) < 3 {
 		return fmt.Errorf("Not enough machines to meet quorum requirements")
 	}
@@ -192,6 +204,13 @@ func flexFailover(ctx context.Context, machines []*api.Machine, app *api.AppComp
 		return fmt.Errorf("Could not find primary region for app")
 	}
 
+	if to != "" {
+		candidates = lo.Filter(candidates, func(m *api.Machine, _ int) bool { return m.ID == to })
+		if len(candidates) == 0 {
+			return fmt.Errorf("machine %s can't be promoted, it must be a replica in the primary region %s", to, primaryRegion)
+		}
+	}
+
 	newLeader, err := pickNewLeader(ctx, app, candidates)
 	if err != nil {
 		return err
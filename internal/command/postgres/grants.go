@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+var identifierRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// pgIdentifier normalizes the name of a database or user the way attach
+// does, checking it's a plain identifier.
+func pgIdentifier(name string) (string, error) {
+	id := strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	if !identifierRegexp.MatchString(id) {
+		return "", fmt.Errorf("%q isn't a valid name, use letters, digits and underscores", name)
+	}
+	return id, nil
+}
+
+// grantTemplates are the permissions users can be given on a database,
+// covering the objects of its public schema.
+var grantTemplates = map[string]struct {
+	description string
+	statements  func(database, user string) []string
+}{
+	"readonly": {
+		description: "read the tables, for analysts and reporting",
+		statements: func(database, user string) []string {
+			return []string{
+				fmt.Sprintf(`GRANT CONNECT ON DATABASE "%s" TO "%s"`, database, user),
+				fmt.Sprintf(`GRANT USAGE ON SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`GRANT SELECT ON ALL TABLES IN SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`GRANT SELECT ON ALL SEQUENCES IN SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO "%s"`, user),
+			}
+		},
+	},
+	"readwrite": {
+		description: "read and write the rows of tables, for apps",
+		statements: func(database, user string) []string {
+			return []string{
+				fmt.Sprintf(`GRANT CONNECT ON DATABASE "%s" TO "%s"`, database, user),
+				fmt.Sprintf(`GRANT USAGE ON SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO "%s"`, user),
+				fmt.Sprintf(`ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO "%s"`, user),
+			}
+		},
+	},
+	"migration": {
+		description: "create and change the schema, for running migrations",
+		statements: func(database, user string) []string {
+			return []string{
+				fmt.Sprintf(`GRANT ALL PRIVILEGES ON DATABASE "%s" TO "%s"`, database, user),
+				fmt.Sprintf(`GRANT ALL ON SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`GRANT ALL ON ALL TABLES IN SCHEMA public TO "%s"`, user),
+				fmt.Sprintf(`GRANT ALL ON ALL SEQUENCES IN SCHEMA public TO "%s"`, user),
+			}
+		},
+	},
+}
+
+// grantTemplateNames returns the names of grantTemplates, sorted.
+func grantTemplateNames() []string {
+	names := lo.Keys(grantTemplates)
+	sort.Strings(names)
+	return names
+}
+
+// grantTemplatesHelp describes the templates for the help of commands.
+func grantTemplatesHelp() string {
+	var b strings.Builder
+	for _, name := range grantTemplateNames() {
+		fmt.Fprintf(&b, "  %-10s %s\n", name, grantTemplates[name].description)
+	}
+	return b.String()
+}
+
+// grantStatements returns the statements granting template on database to
+// user.
+func grantStatements(template, database, user string) ([]string, error) {
+	t, ok := grantTemplates[template]
+	if !ok {
+		return nil, fmt.Errorf("unknown permission template %q, use one of %s", template, strings.Join(grantTemplateNames(), ", "))
+	}
+	return t.statements(database, user), nil
+}
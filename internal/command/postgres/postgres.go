@@ -36,6 +36,7 @@ func New() *cobra.Command {
 		newRestart(),
 		newUsers(),
 		newFailover(),
+		newReplicas(),
 		newNomadToMachines(),
 		newAddFlycast(),
 		newImport(),
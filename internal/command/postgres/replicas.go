@@ -0,0 +1,436 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/flypg"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/apps"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/watch"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// flexQuorum is how many members the primary region of a flex cluster needs
+// to fail over.
+const flexQuorum = 3
+
+func newReplicas() *cobra.Command {
+	const (
+		short = "Manage the replicas of a cluster"
+		long  = short + "\n"
+	)
+
+	cmd := command.New("replicas", short, long, nil)
+
+	cmd.AddCommand(
+		newListReplicas(),
+		newAddReplicas(),
+		newRemoveReplicas(),
+	)
+
+	return cmd
+}
+
+func newListReplicas() *cobra.Command {
+	const (
+		short = "List the members of a cluster with their replication lag"
+		long  = short + `. The lag is read from pg_stat_replication on the
+leader, for flex clusters only.
+`
+		usage = "list"
+	)
+
+	cmd := command.New(usage, short, long, runListReplicas,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Aliases = []string{"ls"}
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newAddReplicas() *cobra.Command {
+	const (
+		short = "Add replicas to a cluster"
+		long  = short + `. Replicas clone the config of the leader, with a new
+empty volume in the region they're added to. Replicas in the primary region are
+failover candidates, replicas in other regions are read replicas.
+`
+		usage = "add"
+	)
+
+	cmd := command.New(usage, short, long, runAddReplicas,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly postgres replicas add --region lhr --app my-db`
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Region(),
+		flag.Int{
+			Name:        "count",
+			Description: "The number of replicas to add",
+			Default:     1,
+		},
+		flag.Int{
+			Name:        "volume-size",
+			Description: "The size in GB of the volumes of the replicas, the size of the leader's volume by default",
+		},
+	)
+
+	return cmd
+}
+
+func newRemoveReplicas() *cobra.Command {
+	const (
+		short = "Remove replicas from a cluster"
+		long  = short + `. The replicas are unregistered from the cluster,
+destroyed, and their volumes deleted unless --keep-volume is set.
+`
+		usage = "remove <machine-id> [<machine-id>...]"
+	)
+
+	cmd := command.New(usage, short, long, runRemoveReplicas,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.Bool{
+			Name:        "keep-volume",
+			Description: "Keep the volumes of the removed replicas",
+		},
+	)
+
+	return cmd
+}
+
+// loadCluster returns the app of a machines Postgres cluster with its active
+// machines and leader, and the context to manage its machines with.
+func loadCluster(ctx context.Context) (context.Context, *api.AppCompact, []*api.Machine, *api.Machine, error) {
+	var (
+		client  = client.FromContext(ctx).API()
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("get app: %w", err)
+	}
+
+	if !app.IsPostgresApp() {
+		return nil, nil, nil, nil, fmt.Errorf("app %s is not a Postgres app", app.Name)
+	}
+
+	if app.PlatformVersion != "machines" {
+		return nil, nil, nil, nil, fmt.Errorf("replicas are only supported for machines apps")
+	}
+
+	ctx, err = apps.BuildContext(ctx, app)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("machines could not be retrieved %w", err)
+	}
+
+	leader, err := pickLeader(ctx, machines)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return ctx, app, machines, leader, nil
+}
+
+type replicaStatus struct {
+	ID          string   `json:"id"`
+	Region      string   `json:"region"`
+	Role        string   `json:"role"`
+	State       string   `json:"state"`
+	Replication string   `json:"replication,omitempty"`
+	LagSeconds  *float64 `json:"lag_seconds,omitempty"`
+	BehindBytes *int64   `json:"behind_bytes,omitempty"`
+}
+
+// replicaStatuses matches the members of a cluster with the lag of the
+// standbys streaming from leader. Lag is nil for clusters it isn't known of.
+func replicaStatuses(machines []*api.Machine, leader *api.Machine, lags []flypg.ReplicaLag) []replicaStatus {
+	byAddress := lo.KeyBy(lags, func(l flypg.ReplicaLag) string { return l.Address })
+
+	statuses := make([]replicaStatus, 0, len(machines))
+	for _, m := range machines {
+		status := replicaStatus{ID: m.ID, Region: m.Region, Role: machineRole(m), State: m.State}
+
+		switch lag, ok := byAddress[m.PrivateIP]; {
+		case m.ID == leader.ID || lags == nil:
+		case ok:
+			status.Replication = lag.State
+			status.LagSeconds = &lag.LagSeconds
+			status.BehindBytes = &lag.BehindBytes
+		default:
+			status.Replication = "not streaming"
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func runListReplicas(ctx context.Context) error {
+	var (
+		io  = iostreams.FromContext(ctx)
+		cfg = config.FromContext(ctx)
+	)
+
+	ctx, app, machines, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lags []flypg.ReplicaLag
+	if IsFlex(leader) {
+		cmd, err := flypg.NewCommand(ctx, app)
+		if err != nil {
+			return err
+		}
+		if lags, err = cmd.ReplicationLag(ctx, leader.PrivateIP); err != nil {
+			return fmt.Errorf("failed reading the replication lag from %s: %w", leader.ID, err)
+		}
+		if lags == nil {
+			lags = []flypg.ReplicaLag{}
+		}
+	}
+
+	statuses := replicaStatuses(machines, leader, lags)
+	if cfg.JSONOutput {
+		return render.JSON(io.Out, statuses)
+	}
+
+	rows := make([][]string, 0, len(statuses))
+	for _, s := range statuses {
+		lag, behind := "", ""
+		if s.LagSeconds != nil {
+			lag = fmt.Sprintf("%.2fs", *s.LagSeconds)
+			behind = humanize.Bytes(uint64(*s.BehindBytes))
+		}
+		rows = append(rows, []string{s.ID, s.Region, s.Role, s.State, s.Replication, lag, behind})
+	}
+
+	if lags == nil {
+		fmt.Fprintln(io.ErrOut, "Replication lag is only reported for flex clusters")
+	}
+	return render.Table(io.Out, "", rows, "ID", "Region", "Role", "State", "Replication", "Lag", "Behind")
+}
+
+func runAddReplicas(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		client   = client.FromContext(ctx).API()
+		region   = flag.GetRegion(ctx)
+		count    = flag.GetInt(ctx, "count")
+	)
+
+	if region == "" {
+		return fmt.Errorf("--region is required")
+	}
+	if count < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+
+	ctx, app, _, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+	flapsClient := flaps.FromContext(ctx)
+
+	if primaryRegion := leader.Config.Env["PRIMARY_REGION"]; region == primaryRegion {
+		fmt.Fprintf(io.Out, "Replicas in %s, the primary region, are failover candidates\n", region)
+	} else {
+		fmt.Fprintf(io.Out, "Replicas in %s are read replicas, the primary region is %s\n", region, primaryRegion)
+	}
+
+	launched := make([]*api.Machine, 0, count)
+	for i := 0; i < count; i++ {
+		mConfig := mach.CloneConfig(leader.Config)
+		// Replicas clone the leader rather than restoring its snapshots
+		delete(mConfig.Env, "FLY_RESTORED_FROM")
+
+		mConfig.Mounts = nil
+		for _, mnt := range leader.Config.Mounts {
+			size := flag.GetInt(ctx, "volume-size")
+			if size == 0 {
+				vol, err := client.GetVolume(ctx, mnt.Volume)
+				if err != nil {
+					return fmt.Errorf("failed to get the volume of the leader: %w", err)
+				}
+				size = vol.SizeGb
+			}
+
+			vol, err := client.CreateVolume(ctx, api.CreateVolumeInput{
+				AppID:             app.ID,
+				Name:              mnt.Name,
+				Region:            region,
+				SizeGb:            size,
+				Encrypted:         mnt.Encrypted,
+				RequireUniqueZone: true,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create the volume of the replica: %w", err)
+			}
+			mConfig.Mounts = append(mConfig.Mounts, api.MachineMount{Volume: vol.ID, Path: mnt.Path})
+		}
+
+		m, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+			Region: region,
+			Config: mConfig,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to launch the replica: %w", err)
+		}
+		fmt.Fprintf(io.Out, "  Replica %s created in %s\n", colorize.Bold(m.ID), region)
+
+		if _, err := mach.Await(ctx, m, mach.StateStarted, 5*time.Minute); err != nil {
+			return err
+		}
+		launched = append(launched, m)
+	}
+
+	// wait for health checks to pass
+	if err := watch.MachinesChecks(ctx, launched); err != nil {
+		return fmt.Errorf("failed to wait for health checks to pass: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "Added %d replicas in %s\n", len(launched), region)
+	return nil
+}
+
+// quorumWarning warns when removing machines leaves fewer than flexQuorum
+// members in the primary region of a flex cluster, so it can't fail over.
+func quorumWarning(machines []*api.Machine, remove []*api.Machine, primaryRegion string) string {
+	remaining := lo.CountBy(lo.Without(machines, remove...), func(m *api.Machine) bool {
+		return m.Region == primaryRegion
+	})
+	if remaining >= flexQuorum {
+		return ""
+	}
+	return fmt.Sprintf("%s will have %d members, fewer than the %d failovers need", primaryRegion, remaining, flexQuorum)
+}
+
+func runRemoveReplicas(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		client   = client.FromContext(ctx).API()
+	)
+
+	ctx, app, machines, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+	flapsClient := flaps.FromContext(ctx)
+
+	var remove []*api.Machine
+	for _, id := range flag.Args(ctx) {
+		m, ok := lo.Find(machines, func(m *api.Machine) bool { return m.ID == id })
+		switch {
+		case !ok:
+			return fmt.Errorf("machine %s is not an active member of %s", id, app.Name)
+		case m.ID == leader.ID:
+			return fmt.Errorf("machine %s is the leader, fail over with fly postgres failover before removing it", id)
+		}
+		remove = append(remove, m)
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Remove %d replicas from %s?", len(remove), app.Name)
+		if IsFlex(leader) {
+			if warning := quorumWarning(machines, remove, leader.Config.Env["PRIMARY_REGION"]); warning != "" {
+				msg = fmt.Sprintf("%s: %s", warning, msg)
+			}
+		}
+
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	var cmd *flypg.Command
+	if IsFlex(leader) {
+		if cmd, err = flypg.NewCommand(ctx, app); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range remove {
+		fmt.Fprintf(io.Out, "Removing replica %s in %s\n", colorize.Bold(m.ID), m.Region)
+
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: m.ID, Kill: true}, ""); err != nil {
+			return fmt.Errorf("could not destroy machine %s: %w", m.ID, err)
+		}
+
+		if cmd != nil {
+			if err := cmd.UnregisterMember(ctx, leader.PrivateIP, m.PrivateIP); err != nil {
+				fmt.Fprintf(io.ErrOut, "failed to unregister postgres member %s: %v\n", m.ID, err)
+			}
+		}
+
+		if flag.GetBool(ctx, "keep-volume") {
+			continue
+		}
+		if _, err := mach.Await(ctx, m, mach.StateDestroyed, 2*time.Minute); err != nil {
+			return err
+		}
+		for _, mnt := range m.Config.Mounts {
+			if _, err := client.DeleteVolume(ctx, mnt.Volume, ""); err != nil {
+				return fmt.Errorf("failed to delete volume %s of machine %s: %w", mnt.Volume, m.ID, err)
+			}
+			fmt.Fprintf(io.Out, "  Deleted volume %s\n", mnt.Volume)
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Removed %d replicas\n", len(remove))
+	return nil
+}
@@ -3,18 +3,21 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flypg"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/command/apps"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/iostreams"
 )
@@ -31,6 +34,9 @@ func newUsers() *cobra.Command {
 
 	cmd.AddCommand(
 		newListUsers(),
+		newCreateUser(),
+		newDropUser(),
+		newGrantUser(),
 	)
 
 	flag.Add(cmd, flag.JSONOutput())
@@ -61,6 +67,111 @@ func newListUsers() *cobra.Command {
 	return cmd
 }
 
+func newCreateUser() *cobra.Command {
+	const (
+		short = "Create a user"
+		usage = "create <name>"
+	)
+	long := short + `, with a generated password unless --password is set.
+Non superusers get the permissions of a template on --database:
+
+` + grantTemplatesHelp()
+
+	cmd := command.New(usage, short, long, runCreateUser,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly postgres users create analyst --database my_app --template readonly`
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "password",
+			Description: "The password of the user",
+		},
+		flag.Bool{
+			Name:        "superuser",
+			Description: "Create a superuser",
+		},
+		flag.String{
+			Name:        "database",
+			Description: "The database to grant the permissions of --template on",
+		},
+		flag.String{
+			Name:        "template",
+			Description: "The permissions to grant on --database: " + strings.Join(grantTemplateNames(), ", "),
+		},
+	)
+
+	return cmd
+}
+
+func newDropUser() *cobra.Command {
+	const (
+		short = "Drop a user"
+		long  = short + "\n"
+
+		usage = "drop <name>"
+	)
+
+	cmd := command.New(usage, short, long, runDropUser,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func newGrantUser() *cobra.Command {
+	const (
+		short = "Grant the permissions of a template to a user"
+		usage = "grant <name>"
+	)
+	long := short + ` on a database. Grants cover the tables
+of the public schema when they're run, run them again once tables are created
+by other users:
+
+` + grantTemplatesHelp()
+
+	cmd := command.New(usage, short, long, runGrantUser,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly postgres users grant migrator --database my_app --template migration`
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "database",
+			Description: "The database to grant the permissions on",
+		},
+		flag.String{
+			Name:        "template",
+			Description: "The permissions to grant: " + strings.Join(grantTemplateNames(), ", "),
+		},
+	)
+
+	return cmd
+}
+
 func runListUsers(ctx context.Context) error {
 	var (
 		client  = client.FromContext(ctx).API()
@@ -197,3 +308,168 @@ func renderUsers(ctx context.Context, leaderIP string) error {
 
 	return render.Table(io.Out, "", rows, "Name", "Superuser", "Databases")
 }
+
+// grantTemplate grants the permissions of template on database to user, on
+// flex clusters.
+func grantTemplate(ctx context.Context, app *api.AppCompact, leader *api.Machine, template, database, user string) error {
+	if database == "" || template == "" {
+		return fmt.Errorf("--database and --template must be set together")
+	}
+	if !IsFlex(leader) {
+		return fmt.Errorf("permission templates are only supported by flex clusters")
+	}
+
+	database, err := pgIdentifier(database)
+	if err != nil {
+		return err
+	}
+	statements, err := grantStatements(template, database, user)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := flypg.NewCommand(ctx, app)
+	if err != nil {
+		return err
+	}
+	if err := cmd.RunSQL(ctx, leader.PrivateIP, database, statements); err != nil {
+		return fmt.Errorf("failed granting %s permissions on %s to %s: %w", template, database, user, err)
+	}
+	return nil
+}
+
+func runCreateUser(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		database  = flag.GetString(ctx, "database")
+		template  = flag.GetString(ctx, "template")
+		password  = flag.GetString(ctx, "password")
+		superuser = flag.GetBool(ctx, "superuser")
+	)
+
+	user, err := pgIdentifier(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+	if superuser && (database != "" || template != "") {
+		return fmt.Errorf("superusers have every permission, --database and --template can't be set with --superuser")
+	}
+	if (database == "") != (template == "") {
+		return fmt.Errorf("--database and --template must be set together")
+	}
+	if template != "" {
+		if _, err := grantStatements(template, "", ""); err != nil {
+			return err
+		}
+	}
+
+	ctx, app, _, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+	pgclient := flypg.NewFromInstance(leader.PrivateIP, agent.DialerFromContext(ctx))
+
+	if exists, err := pgclient.UserExists(ctx, user); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("user %s already exists", user)
+	}
+
+	generated := password == ""
+	if generated {
+		if password, err = helpers.RandString(24); err != nil {
+			return err
+		}
+	}
+
+	if err := pgclient.CreateUser(ctx, user, password, superuser); err != nil {
+		return fmt.Errorf("failed creating user %s: %w", user, err)
+	}
+	fmt.Fprintf(io.Out, "Created user %s\n", user)
+
+	if database != "" || template != "" {
+		if err := grantTemplate(ctx, app, leader, template, database, user); err != nil {
+			return err
+		}
+		fmt.Fprintf(io.Out, "Granted %s permissions on %s\n", template, database)
+	}
+
+	if generated {
+		fmt.Fprintf(io.Out, "Password: %s\n", password)
+		fmt.Fprintln(io.ErrOut, "Save the password, it won't be shown again")
+	}
+	return nil
+}
+
+func runDropUser(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	user, err := pgIdentifier(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx, app, _, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+	pgclient := flypg.NewFromInstance(leader.PrivateIP, agent.DialerFromContext(ctx))
+
+	if exists, err := pgclient.UserExists(ctx, user); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("user %s doesn't exist on %s", user, app.Name)
+	}
+
+	if !flag.GetYes(ctx) {
+		msg := fmt.Sprintf("Drop user %s from %s? Apps connecting as %s will lose access", user, app.Name, user)
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := pgclient.DeleteUser(ctx, user); err != nil {
+		return fmt.Errorf("failed dropping user %s: %w", user, err)
+	}
+
+	fmt.Fprintf(io.Out, "Dropped user %s\n", user)
+	return nil
+}
+
+func runGrantUser(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		database = flag.GetString(ctx, "database")
+		template = flag.GetString(ctx, "template")
+	)
+
+	user, err := pgIdentifier(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx, app, _, leader, err := loadCluster(ctx)
+	if err != nil {
+		return err
+	}
+
+	if exists, err := flypg.NewFromInstance(leader.PrivateIP, agent.DialerFromContext(ctx)).UserExists(ctx, user); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("user %s doesn't exist on %s", user, app.Name)
+	}
+
+	if err := grantTemplate(ctx, app, leader, template, database, user); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Granted %s permissions on %s to %s\n", template, database, user)
+	return nil
+}
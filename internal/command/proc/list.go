@@ -0,0 +1,148 @@
+package proc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/format"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newList() *cobra.Command {
+	const (
+		long = `List the process groups of the app, with their command, how many machines
+each has per region, and the services routed to them.`
+		short = "List process groups"
+	)
+
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.NoMachinesCache(),
+	)
+
+	return cmd
+}
+
+// group describes a process group.
+type group struct {
+	Name     string              `json:"name"`
+	Command  string              `json:"command,omitempty"`
+	Machines int                 `json:"machines"`
+	Started  int                 `json:"started"`
+	Regions  map[string]int      `json:"regions"`
+	Services []appconfig.Service `json:"services"`
+}
+
+func runList(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	appConfig, err := loadAppConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	flapsClient, err := flaps.NewFromAppName(ctx, appName)
+	if err != nil {
+		return err
+	}
+	machines, cachedAt, err := flapsClient.ListCached(ctx, flag.GetBool(ctx, flag.NoMachinesCache().Name))
+	if err != nil {
+		return fmt.Errorf("failed listing the machines of %s: %w", appName, err)
+	}
+	if !cachedAt.IsZero() && !config.FromContext(ctx).JSONOutput {
+		fmt.Fprintf(io.ErrOut, "Showing machines cached %s, run with --no-cache for their current state\n", format.RelativeTime(cachedAt))
+	}
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return m.IsFlyAppsPlatform() && m.IsActive() && !m.IsFlyAppsReleaseCommand() && !m.IsFlyAppsConsole()
+	})
+
+	groups, err := describeGroups(appConfig, machines)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, groups)
+	}
+
+	rows := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, []string{
+			g.Name,
+			g.Command,
+			fmt.Sprintf("%d (%d started)", g.Machines, g.Started),
+			formatRegions(g.Regions),
+			formatServices(g.Services),
+		})
+	}
+	return render.Table(io.Out, appName, rows, "Group", "Command", "Machines", "Regions", "Services")
+}
+
+// describeGroups describes the process groups of appConfig, and those of
+// machines no longer in it.
+func describeGroups(appConfig *appconfig.Config, machines []*api.Machine) ([]group, error) {
+	byGroup := lo.GroupBy(machines, func(m *api.Machine) string { return m.ProcessGroup() })
+
+	names := lo.Uniq(append(appConfig.ProcessNames(), lo.Keys(byGroup)...))
+	slices.Sort(names)
+
+	groups := make([]group, 0, len(names))
+	for _, name := range names {
+		g := group{
+			Name:     name,
+			Command:  appConfig.Processes[name],
+			Machines: len(byGroup[name]),
+			Started: lo.CountBy(byGroup[name], func(m *api.Machine) bool {
+				return m.State == api.MachineStateStarted
+			}),
+			Regions: lo.CountValues(lo.Map(byGroup[name], func(m *api.Machine, _ int) string { return m.Region })),
+		}
+
+		if slices.Contains(appConfig.ProcessNames(), name) {
+			flattened, err := appConfig.Flatten(name)
+			if err != nil {
+				return nil, err
+			}
+			g.Services = flattened.AllServices()
+		} else {
+			g.Command = "(no longer in fly.toml)"
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// formatRegions formats machine counts per region, e.g. ord:2 sea:1.
+func formatRegions(regions map[string]int) string {
+	names := lo.Keys(regions)
+	slices.Sort(names)
+	return strings.Join(lo.Map(names, func(r string, _ int) string {
+		return r + ":" + strconv.Itoa(regions[r])
+	}), " ")
+}
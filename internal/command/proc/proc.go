@@ -0,0 +1,84 @@
+// Package proc implements the proc command chain, managing the process groups
+// of apps.
+package proc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// New initializes and returns a new proc Command.
+func New() *cobra.Command {
+	const (
+		long = `Manage the process groups of apps, the [processes] of fly.toml, without
+filtering their machines by hand.`
+		short = "Manage process groups"
+	)
+
+	cmd := command.New("proc", short, long, nil)
+	cmd.Aliases = []string{"process", "processes"}
+
+	cmd.AddCommand(
+		newList(),
+		newScale(),
+		newRestart(),
+	)
+
+	return cmd
+}
+
+// checkGroup returns an error when appConfig has no process group named
+// group.
+func checkGroup(appConfig *appconfig.Config, group string) error {
+	if !slices.Contains(appConfig.ProcessNames(), group) {
+		return fmt.Errorf("process group '%s' not found, the groups of %s are %s", group, appConfig.AppName, appConfig.FormatProcessNames())
+	}
+	return nil
+}
+
+// formatServices describes services as their internal ports and the public
+// ports routed to them, e.g. 8080/tcp: 80 [http], 443 [http,tls].
+func formatServices(services []appconfig.Service) string {
+	var parts []string
+	for _, s := range services {
+		var ports []string
+		for _, p := range s.Ports {
+			ports = append(ports, formatPort(p))
+		}
+		parts = append(parts, fmt.Sprintf("%d/%s: %s", s.InternalPort, s.Protocol, strings.Join(ports, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatPort(p api.MachinePort) string {
+	var port string
+	switch {
+	case p.Port != nil:
+		port = strconv.Itoa(*p.Port)
+	case p.StartPort != nil && p.EndPort != nil:
+		port = fmt.Sprintf("%d-%d", *p.StartPort, *p.EndPort)
+	}
+	if len(p.Handlers) > 0 {
+		port += " [" + strings.Join(p.Handlers, ",") + "]"
+	}
+	return port
+}
+
+// loadAppConfig returns the config of the app, from the current release.
+func loadAppConfig(ctx context.Context) (*appconfig.Config, error) {
+	appName := appconfig.NameFromContext(ctx)
+	appConfig, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving the config of %s: %w", appName, err)
+	}
+	return appConfig, nil
+}
@@ -0,0 +1,51 @@
+package proc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestDescribeGroups(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.AppName = "my-app"
+	require.NoError(t, cfg.SetMachinesPlatform())
+	cfg.Processes = map[string]string{"web": "bin/web", "worker": "bin/worker"}
+	cfg.HTTPService = &appconfig.HTTPService{InternalPort: 8080, Processes: []string{"web"}}
+
+	machine := func(group, region string, state string) *api.Machine {
+		return &api.Machine{
+			Region: region,
+			State:  state,
+			Config: &api.MachineConfig{Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: group}},
+		}
+	}
+	machines := []*api.Machine{
+		machine("web", "ord", api.MachineStateStarted),
+		machine("web", "ord", api.MachineStateStopped),
+		machine("web", "sea", api.MachineStateStarted),
+		machine("old", "ord", api.MachineStateStarted),
+	}
+
+	groups, err := describeGroups(cfg, machines)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+
+	assert.Equal(t, "old", groups[0].Name)
+	assert.Equal(t, "(no longer in fly.toml)", groups[0].Command)
+
+	web := groups[1]
+	assert.Equal(t, "bin/web", web.Command)
+	assert.Equal(t, 3, web.Machines)
+	assert.Equal(t, 2, web.Started)
+	assert.Equal(t, "ord:2 sea:1", formatRegions(web.Regions))
+	assert.Equal(t, "8080/tcp: 80 [http], 443 [http,tls]", formatServices(web.Services))
+
+	worker := groups[2]
+	assert.Equal(t, 0, worker.Machines)
+	assert.Empty(t, worker.Services)
+}
@@ -0,0 +1,109 @@
+package proc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/apps"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newRestart() *cobra.Command {
+	const (
+		long = `Restart the machines of a single process group of the app, in batches of
+--max-unavailable. Every batch must be started and healthy before the next
+one is restarted.`
+		short = "Restart the machines of a process group"
+		usage = "restart <group>"
+	)
+
+	cmd := command.New(usage, short, long, runRestart,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "force-stop",
+			Description: "Performs a force stop against the machines of the group",
+		},
+		flag.Bool{
+			Name:        "skip-health-checks",
+			Description: "Restarts machines without waiting for health checks",
+		},
+		flag.Int{
+			Name:        "max-unavailable",
+			Description: "Number of machines restarted at once",
+			Default:     1,
+		},
+	)
+
+	return cmd
+}
+
+func runRestart(ctx context.Context) error {
+	var (
+		io             = iostreams.FromContext(ctx)
+		appName        = appconfig.NameFromContext(ctx)
+		group          = flag.FirstArg(ctx)
+		maxUnavailable = flag.GetInt(ctx, "max-unavailable")
+	)
+
+	if maxUnavailable < 1 {
+		return errors.New("--max-unavailable must be at least 1")
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != "machines" {
+		return fmt.Errorf("process groups can only be restarted for apps on the machines platform, restart %s with fly apps restart instead", appName)
+	}
+
+	if ctx, err = apps.BuildContext(ctx, app); err != nil {
+		return err
+	}
+
+	machines, _, err := flaps.FromContext(ctx).ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return m.ProcessGroup() == group
+	})
+	if len(machines) == 0 {
+		return fmt.Errorf("no machines of %s found in process group %s", appName, group)
+	}
+
+	machines, releaseFunc, err := machine.AcquireLeases(ctx, machines)
+	defer releaseFunc(ctx, machines)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Restarting %d machines of process group %s\n", len(machines), group)
+
+	return machine.RollingRestartMachines(ctx, machines, machine.RollingRestartOptions{
+		Input: &api.RestartMachineInput{
+			ForceStop:        flag.GetBool(ctx, "force-stop"),
+			SkipHealthChecks: flag.GetBool(ctx, "skip-health-checks"),
+		},
+		MaxUnavailable: maxUnavailable,
+	})
+}
@@ -0,0 +1,67 @@
+package proc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/scale"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/protect"
+)
+
+func newScale() *cobra.Command {
+	const (
+		long = `Scale a single process group of the app to the given number of machines,
+leaving the machines of other groups alone.`
+		short = "Scale a process group"
+		usage = "scale <group> <count>"
+	)
+
+	cmd := command.New(usage, short, long, runScale,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(2)
+	cmd.Example = `  fly proc scale worker 3
+  fly proc scale web 4 --region ord,sea --max-per-region 2`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.Int{Name: "max-per-region", Description: "Max number of machines of the group per region", Default: -1},
+		flag.String{Name: "region", Description: "Comma separated list of regions to act on. Defaults to all regions where the group has at least one machine"},
+		protect.ForceFlag(),
+	)
+
+	return cmd
+}
+
+func runScale(ctx context.Context) error {
+	var (
+		appName = appconfig.NameFromContext(ctx)
+		args    = flag.Args(ctx)
+		group   = args[0]
+	)
+
+	count, err := strconv.Atoi(args[1])
+	if err != nil || count < 0 {
+		return fmt.Errorf("invalid machine count '%s', it must be a non negative integer", args[1])
+	}
+
+	appConfig, err := loadAppConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkGroup(appConfig, group); err != nil {
+		return err
+	}
+
+	return scale.ScaleGroup(ctx, appName, group, count, flag.GetInt(ctx, "max-per-region"), flag.GetRegion(ctx), flag.GetYes(ctx))
+}
@@ -0,0 +1,197 @@
+// Package queue implements the queue command chain.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/scale"
+	"github.com/superfly/flyctl/internal/command/secrets"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/offlinequeue"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new queue Command.
+func New() *cobra.Command {
+	const (
+		long = `Manage the mutations queued while the Fly.io API was unreachable.
+
+With fly settings offline-queue enable, or FLY_OFFLINE_QUEUE=1, secrets set and
+scale count are queued locally when the API can't be reached, to be applied
+in order with fly queue flush once back online.`
+		short = "Manage mutations queued while offline"
+	)
+
+	cmd := command.New("queue", short, long, nil)
+
+	cmd.AddCommand(
+		newList(),
+		newFlush(),
+		newDrop(),
+	)
+
+	return cmd
+}
+
+func newList() *cobra.Command {
+	const (
+		long  = `List the queued mutations, oldest first.`
+		short = "List queued mutations"
+	)
+
+	cmd := command.New("list", short, long, runList)
+
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd, flag.JSONOutput())
+
+	return cmd
+}
+
+func newFlush() *cobra.Command {
+	const (
+		long = `Apply the queued mutations in order, removing them from the queue once
+applied. Flushing stops at the first mutation that fails, keeping it and the
+following ones queued.
+
+A mutation conflicts when what it changes was changed remotely since it was
+queued, e.g. a secret was set by someone else. Apply it anyway with --force,
+or drop it with fly queue drop.`
+		short = "Apply queued mutations"
+	)
+
+	cmd := command.New("flush", short, long, runFlush,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Bool{
+			Name:        "force",
+			Description: "Apply mutations even when they conflict with remote changes",
+		},
+	)
+
+	return cmd
+}
+
+func newDrop() *cobra.Command {
+	const (
+		long  = `Remove a mutation from the queue without applying it.`
+		short = "Drop a queued mutation"
+	)
+
+	cmd := command.New("drop <id>", short, long, runDrop)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	queue, err := offlinequeue.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		// Keep the parameters of mutations out of the output
+		for i := range queue {
+			queue[i].Params = nil
+		}
+		return render.JSON(out, queue)
+	}
+
+	if len(queue) == 0 {
+		fmt.Fprintln(out, "No queued mutations")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(queue))
+	for _, m := range queue {
+		rows = append(rows, []string{m.ID, m.Summary, m.QueuedAt.Format(time.RFC3339)})
+	}
+
+	return render.Table(out, "", rows, "ID", "Mutation", "Queued At")
+}
+
+func runFlush(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+	force := flag.GetBool(ctx, "force")
+
+	queue, err := offlinequeue.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if len(queue) == 0 {
+		fmt.Fprintln(io.Out, "No queued mutations")
+		return nil
+	}
+
+	for i, m := range queue {
+		fmt.Fprintf(io.Out, "Applying %s: %s\n", m.ID, m.Summary)
+
+		if err := replay(ctx, m, force); err != nil {
+			left := len(queue) - i
+			switch {
+			case errors.Is(err, offlinequeue.ErrConflict):
+				return fmt.Errorf("%s conflicts, %d mutations left queued: %w\nApply it anyway with --force, or drop it with fly queue drop %s", m.ID, left, err, m.ID)
+			case offlinequeue.IsOffline(err):
+				return fmt.Errorf("the Fly.io API is still unreachable, %d mutations left queued: %w", left, err)
+			default:
+				return fmt.Errorf("failed applying %s, %d mutations left queued: %w", m.ID, left, err)
+			}
+		}
+
+		if err := offlinequeue.Remove(ctx, m.ID); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Applied %d queued mutations\n", len(queue))
+	return nil
+}
+
+func replay(ctx context.Context, m offlinequeue.Mutation, force bool) error {
+	switch m.Kind {
+	case offlinequeue.KindSecretsSet:
+		return secrets.ReplaySet(ctx, m, force)
+	case offlinequeue.KindScaleCount:
+		return scale.ReplayCount(ctx, m, force)
+	default:
+		return fmt.Errorf("unknown kind of mutation %s, it was queued by another version of flyctl", m.Kind)
+	}
+}
+
+func runDrop(ctx context.Context) error {
+	id := flag.FirstArg(ctx)
+
+	queue, err := offlinequeue.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range queue {
+		if m.ID == id {
+			if err := offlinequeue.Remove(ctx, id); err != nil {
+				return err
+			}
+			fmt.Fprintf(iostreams.FromContext(ctx).Out, "Dropped %s: %s\n", m.ID, m.Summary)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no queued mutation %s, see fly queue list", id)
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 
@@ -23,11 +24,13 @@ func newConnect() (cmd *cobra.Command) {
 		long = `Connect to a Redis database using redis-cli`
 
 		short = long
-		usage = "connect"
+		usage = "connect [name]"
 	)
 
 	cmd = command.New(usage, short, long, runConnect, command.RequireSession)
 
+	cmd.Args = cobra.MaximumNArgs(1)
+
 	flag.Add(cmd,
 		flag.Org(),
 		flag.Region(),
@@ -36,48 +39,70 @@ func newConnect() (cmd *cobra.Command) {
 	return cmd
 }
 
-func runConnect(ctx context.Context) (err error) {
-	var (
-		client = client.FromContext(ctx).API()
-		io     = iostreams.FromContext(ctx)
-	)
+// selectDatabase returns the database named by the first argument, or the
+// one the user selects when none is named.
+func selectDatabase(ctx context.Context) (*gql.GetAddOnAddOn, error) {
+	client := client.FromContext(ctx).API()
+
+	name := flag.FirstArg(ctx)
+	if name == "" {
+		result, err := gql.ListAddOns(ctx, client.GenqClient, "redis")
+		if err != nil {
+			return nil, err
+		}
+
+		databases := result.AddOns.Nodes
+		if len(databases) == 0 {
+			return nil, fmt.Errorf("no Redis databases found, create one with `fly redis create`")
+		}
+
+		var options []string
+		for _, database := range databases {
+			options = append(options, fmt.Sprintf("%s (%s) %s", database.Name, database.PrimaryRegion, database.Organization.Slug))
+		}
+
+		var index int
+		if err := prompt.Select(ctx, &index, "Select a database", "", options...); err != nil {
+			if prompt.IsNonInteractive(err) {
+				return nil, prompt.NonInteractiveError("name argument must be specified when not running interactively")
+			}
+			return nil, err
+		}
+		name = databases[index].Name
+	}
 
+	response, err := gql.GetAddOn(ctx, client.GenqClient, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var index int
-	var options []string
+	return &response.AddOn, nil
+}
 
-	result, err := gql.ListAddOns(ctx, client.GenqClient, "redis")
+// databaseDialer returns a dialer into the private network of the database.
+func databaseDialer(ctx context.Context, database *gql.GetAddOnAddOn) (agent.Dialer, error) {
+	agentclient, err := agent.Establish(ctx, client.FromContext(ctx).API())
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	databases := result.AddOns.Nodes
-
-	for _, database := range databases {
-		options = append(options, fmt.Sprintf("%s (%s) %s", database.Name, database.PrimaryRegion, database.Organization.Slug))
-	}
+	return agentclient.ConnectToTunnel(ctx, database.Organization.Slug)
+}
 
-	err = prompt.Select(ctx, &index, "Select a database to connect to", "", options...)
-	if err != nil {
-		return
-	}
+func runConnect(ctx context.Context) (err error) {
+	io := iostreams.FromContext(ctx)
 
-	response, err := gql.GetAddOn(ctx, client.GenqClient, databases[index].Name)
+	redisCliPath, err := exec.LookPath("redis-cli")
 	if err != nil {
-		return err
+		return errors.New("could not find redis-cli in your $PATH, install it and try again")
 	}
 
-	database := response.AddOn
-
-	agentclient, err := agent.Establish(ctx, client)
+	database, err := selectDatabase(ctx)
 	if err != nil {
 		return err
 	}
 
-	dialer, err := agentclient.ConnectToTunnel(ctx, database.Organization.Slug)
+	dialer, err := databaseDialer(ctx, database)
 	if err != nil {
 		return err
 	}
@@ -91,12 +116,6 @@ func runConnect(ctx context.Context) (err error) {
 		RemoteHost:       database.PrivateIp,
 	}
 
-	redisCliPath, err := exec.LookPath("redis-cli")
-	if err != nil {
-		fmt.Fprintf(io.Out, "Could not find redis-cli in your $PATH. Install it or point your redis-cli at: %s", "someurl")
-		return
-	}
-
 	err = proxy.Start(ctx, params)
 	if err != nil {
 		return err
@@ -108,8 +127,5 @@ func runConnect(ctx context.Context) (err error) {
 	cmd.Stderr = io.ErrOut
 	cmd.Stdin = io.In
 
-	cmd.Start()
-	cmd.Wait()
-
-	return
+	return cmd.Run()
 }
@@ -86,6 +86,7 @@ func runCreate(ctx context.Context) (err error) {
 	primaryRegion, err := prompt.Region(ctx, !org.PaidPlan, prompt.RegionParams{
 		Message:             "Choose a primary region (can't be changed later)",
 		ExcludedRegionCodes: excludedRegions,
+		OrgSlug:             org.Slug,
 	})
 
 	if err != nil {
@@ -0,0 +1,229 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newStats() (cmd *cobra.Command) {
+	const (
+		long = `Show memory usage, hit rate and keyspace sizes of a Redis database, as
+reported by the database itself`
+
+		short = "Show statistics of a Redis database"
+		usage = "stats [name]"
+	)
+
+	cmd = command.New(usage, short, long, runStats, command.RequireSession)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+type keyspaceStats struct {
+	Database string `json:"database"`
+	Keys     int64  `json:"keys"`
+	Expires  int64  `json:"expires"`
+}
+
+type databaseStats struct {
+	Name             string          `json:"name"`
+	UsedMemory       int64           `json:"used_memory"`
+	MaxMemory        int64           `json:"max_memory"`
+	Hits             int64           `json:"hits"`
+	Misses           int64           `json:"misses"`
+	HitRate          float64         `json:"hit_rate"`
+	ConnectedClients int64           `json:"connected_clients"`
+	Commands         int64           `json:"commands_processed"`
+	Keyspace         []keyspaceStats `json:"keyspace"`
+}
+
+func runStats(ctx context.Context) (err error) {
+	out := iostreams.FromContext(ctx).Out
+
+	database, err := selectDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	dialer, err := databaseDialer(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(database.PrivateIp, "6379"))
+	if err != nil {
+		return fmt.Errorf("failed connecting to %s: %w", database.Name, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	info, err := fetchInfo(conn, database.Password)
+	if err != nil {
+		return fmt.Errorf("failed fetching statistics of %s: %w", database.Name, err)
+	}
+
+	stats := parseInfo(info)
+	stats.Name = database.Name
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, stats)
+	}
+
+	maxMemory := "unlimited"
+	if stats.MaxMemory > 0 {
+		maxMemory = humanize.IBytes(uint64(stats.MaxMemory))
+	}
+
+	obj := [][]string{
+		{
+			stats.Name,
+			humanize.IBytes(uint64(stats.UsedMemory)),
+			maxMemory,
+			fmt.Sprintf("%.1f%% (%d hits, %d misses)", stats.HitRate*100, stats.Hits, stats.Misses),
+			strconv.FormatInt(stats.ConnectedClients, 10),
+			strconv.FormatInt(stats.Commands, 10),
+		},
+	}
+
+	if err = render.VerticalTable(out, "Redis", obj, "Name", "Used Memory", "Max Memory", "Hit Rate", "Clients", "Commands"); err != nil {
+		return
+	}
+
+	rows := make([][]string, 0, len(stats.Keyspace))
+	for _, ks := range stats.Keyspace {
+		rows = append(rows, []string{ks.Database, strconv.FormatInt(ks.Keys, 10), strconv.FormatInt(ks.Expires, 10)})
+	}
+
+	return render.Table(out, "Keyspace", rows, "Database", "Keys", "Expiring")
+}
+
+// fetchInfo authenticates and returns the reply of the INFO command.
+func fetchInfo(conn io.ReadWriter, password string) (string, error) {
+	r := bufio.NewReader(conn)
+
+	if password != "" {
+		if _, err := readReply(conn, r, "AUTH", password); err != nil {
+			return "", err
+		}
+	}
+
+	return readReply(conn, r, "INFO")
+}
+
+// readReply sends a command and reads its status or bulk string reply.
+func readReply(w io.Writer, r *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return "", err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+
+	switch {
+	case strings.HasPrefix(line, "+"):
+		return line[1:], nil
+	case strings.HasPrefix(line, "-"):
+		return "", errors.New(line[1:])
+	case strings.HasPrefix(line, "$"):
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("unexpected reply %q", line)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line)
+	}
+}
+
+// parseInfo extracts statistics from the reply of the INFO command.
+func parseInfo(info string) databaseStats {
+	var stats databaseStats
+
+	for _, line := range strings.Split(info, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+
+		n, _ := strconv.ParseInt(value, 10, 64)
+		switch key {
+		case "used_memory":
+			stats.UsedMemory = n
+		case "maxmemory":
+			stats.MaxMemory = n
+		case "keyspace_hits":
+			stats.Hits = n
+		case "keyspace_misses":
+			stats.Misses = n
+		case "connected_clients":
+			stats.ConnectedClients = n
+		case "total_commands_processed":
+			stats.Commands = n
+		default:
+			if !strings.HasPrefix(key, "db") {
+				continue
+			}
+			ks := keyspaceStats{Database: key}
+			for _, field := range strings.Split(value, ",") {
+				name, v, _ := strings.Cut(field, "=")
+				n, _ := strconv.ParseInt(v, 10, 64)
+				switch name {
+				case "keys":
+					ks.Keys = n
+				case "expires":
+					ks.Expires = n
+				}
+			}
+			stats.Keyspace = append(stats.Keyspace, ks)
+		}
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	sort.Slice(stats.Keyspace, func(i, j int) bool { return stats.Keyspace[i].Database < stats.Keyspace[j].Database })
+
+	return stats
+}
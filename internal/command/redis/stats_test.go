@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:2048\r\nmaxmemory:0\r\n# Stats\r\nkeyspace_hits:3\r\nkeyspace_misses:1\r\n# Keyspace\r\ndb1:keys=2,expires=0,avg_ttl=0\r\ndb0:keys=10,expires=4,avg_ttl=100\r\n"
+
+	stats := parseInfo(info)
+	assert.Equal(t, int64(2048), stats.UsedMemory)
+	assert.Equal(t, 0.75, stats.HitRate)
+	assert.Equal(t, []keyspaceStats{
+		{Database: "db0", Keys: 10, Expires: 4},
+		{Database: "db1", Keys: 2},
+	}, stats.Keyspace)
+}
+
+func TestReadReply(t *testing.T) {
+	var sent bytes.Buffer
+	r := bufio.NewReader(strings.NewReader("+OK\r\n$5\r\nhello\r\n-ERR invalid password\r\n"))
+
+	reply, err := readReply(&sent, r, "AUTH", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+	assert.Equal(t, "*2\r\n$4\r\nAUTH\r\n$6\r\nsecret\r\n", sent.String())
+
+	reply, err = readReply(&sent, r, "INFO")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", reply)
+
+	_, err = readReply(&sent, r, "INFO")
+	assert.EqualError(t, err, "ERR invalid password")
+}
@@ -10,26 +10,35 @@ import (
 	"github.com/superfly/flyctl/internal/command/apps"
 	"github.com/superfly/flyctl/internal/command/auth"
 	"github.com/superfly/flyctl/internal/command/autoscale"
+	"github.com/superfly/flyctl/internal/command/budget"
+	"github.com/superfly/flyctl/internal/command/cache"
 	"github.com/superfly/flyctl/internal/command/certificates"
 	"github.com/superfly/flyctl/internal/command/checks"
 	"github.com/superfly/flyctl/internal/command/config"
 	"github.com/superfly/flyctl/internal/command/console"
 	"github.com/superfly/flyctl/internal/command/consul"
 	"github.com/superfly/flyctl/internal/command/create"
+	"github.com/superfly/flyctl/internal/command/cron"
 	"github.com/superfly/flyctl/internal/command/curl"
 	"github.com/superfly/flyctl/internal/command/dashboard"
 	"github.com/superfly/flyctl/internal/command/deploy"
 	"github.com/superfly/flyctl/internal/command/destroy"
+	"github.com/superfly/flyctl/internal/command/dev"
 	"github.com/superfly/flyctl/internal/command/dig"
 	"github.com/superfly/flyctl/internal/command/dnsrecords"
 	"github.com/superfly/flyctl/internal/command/docs"
 	"github.com/superfly/flyctl/internal/command/doctor"
 	"github.com/superfly/flyctl/internal/command/domains"
+	"github.com/superfly/flyctl/internal/command/env"
 	"github.com/superfly/flyctl/internal/command/extensions"
+	"github.com/superfly/flyctl/internal/command/flycast"
+	"github.com/superfly/flyctl/internal/command/gpu"
+	"github.com/superfly/flyctl/internal/command/graphql"
 	"github.com/superfly/flyctl/internal/command/help"
 	"github.com/superfly/flyctl/internal/command/history"
 	"github.com/superfly/flyctl/internal/command/image"
 	"github.com/superfly/flyctl/internal/command/info"
+	"github.com/superfly/flyctl/internal/command/infra"
 	"github.com/superfly/flyctl/internal/command/ips"
 	"github.com/superfly/flyctl/internal/command/jobs"
 	"github.com/superfly/flyctl/internal/command/launch"
@@ -39,31 +48,42 @@ import (
 	"github.com/superfly/flyctl/internal/command/monitor"
 	"github.com/superfly/flyctl/internal/command/move"
 	"github.com/superfly/flyctl/internal/command/mysql"
+	"github.com/superfly/flyctl/internal/command/notifications"
 	"github.com/superfly/flyctl/internal/command/open"
 	"github.com/superfly/flyctl/internal/command/orgs"
 	"github.com/superfly/flyctl/internal/command/ping"
+	"github.com/superfly/flyctl/internal/command/pipelines"
 	"github.com/superfly/flyctl/internal/command/platform"
+	"github.com/superfly/flyctl/internal/command/policy"
 	"github.com/superfly/flyctl/internal/command/postgres"
+	"github.com/superfly/flyctl/internal/command/proc"
 	"github.com/superfly/flyctl/internal/command/proxy"
+	"github.com/superfly/flyctl/internal/command/queue"
 	"github.com/superfly/flyctl/internal/command/redis"
 	"github.com/superfly/flyctl/internal/command/regions"
 	"github.com/superfly/flyctl/internal/command/releases"
 	"github.com/superfly/flyctl/internal/command/restart"
 	"github.com/superfly/flyctl/internal/command/resume"
 	"github.com/superfly/flyctl/internal/command/scale"
+	"github.com/superfly/flyctl/internal/command/schema"
 	"github.com/superfly/flyctl/internal/command/secrets"
 	"github.com/superfly/flyctl/internal/command/services"
 	"github.com/superfly/flyctl/internal/command/settings"
+	"github.com/superfly/flyctl/internal/command/setup"
 	"github.com/superfly/flyctl/internal/command/ssh"
 	"github.com/superfly/flyctl/internal/command/status"
 	"github.com/superfly/flyctl/internal/command/suspend"
 	"github.com/superfly/flyctl/internal/command/tokens"
+	"github.com/superfly/flyctl/internal/command/trace"
 	"github.com/superfly/flyctl/internal/command/turboku"
 	"github.com/superfly/flyctl/internal/command/version"
 	"github.com/superfly/flyctl/internal/command/vm"
 	"github.com/superfly/flyctl/internal/command/volumes"
+	"github.com/superfly/flyctl/internal/command/webhooks"
 	"github.com/superfly/flyctl/internal/command/wireguard"
+	"github.com/superfly/flyctl/internal/command/workers"
 	"github.com/superfly/flyctl/internal/flag/flagnames"
+	"github.com/superfly/flyctl/internal/flyerr"
 )
 
 // New initializes and returns a reference to a new root command.
@@ -94,6 +114,7 @@ To read more, use the docs command to view Fly's help on the web.
 	fs := root.PersistentFlags()
 	_ = fs.StringP(flagnames.AccessToken, "t", "", "Fly API Access Token")
 	_ = fs.BoolP(flagnames.Verbose, "", false, "Verbose output")
+	_ = fs.String(flagnames.Context, "", "Auth context to use, overriding the current one (FLY_CONTEXT)")
 
 	flyctl.InitConfig()
 
@@ -119,33 +140,46 @@ To read more, use the docs command to view Fly's help on the web.
 		docs.New(),
 		releases.New(),
 		deploy.New(),
+		dev.New(),
 		history.New(),
 		status.New(),
 		logs.New(),
+		trace.New(),
 		doctor.New(),
 		dig.New(),
 		volumes.New(),
 		agent.New(),
 		image.New(),
 		ping.New(),
+		policy.New(),
+		budget.New(),
+		cron.New(),
 		proxy.New(),
 		machine.New(),
+		gpu.New(),
+		graphql.New(),
 		monitor.New(),
+		notifications.New(),
 		postgres.New(),
 		ips.New(),
+		flycast.New(),
 		secrets.New(),
+		env.New(),
 		ssh.New(),
 		ssh.NewSFTP(),
 		redis.New(),
 		vm.New(),
 		checks.New(),
 		launch.New(),
+		setup.New(),
 		info.New(),
 		jobs.New(),
 		turboku.New(),
 		services.New(),
 		config.New(),
 		scale.New(),
+		proc.New(),
+		pipelines.New(),
 		migrate_to_v2.New(),
 		tokens.New(),
 		extensions.New(),
@@ -156,9 +190,15 @@ To read more, use the docs command to view Fly's help on the web.
 		dashboard.New(),
 		wireguard.New(),
 		autoscale.New(),
+		workers.New(),
+		webhooks.New(),
 		domains.New(),
 		console.New(),
 		settings.New(),
+		cache.New(),
+		schema.New(),
+		queue.New(),
+		infra.New(),
 		mysql.New(),
 	)
 
@@ -166,7 +206,26 @@ To read more, use the docs command to view Fly's help on the web.
 	// 	newCommands = append(newCommands, services.New())
 	// }
 
+	// invalid flags and arguments exit with flyerr.ExitCodeValidation
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return flyerr.Validation(err)
+	})
+	markArgsErrors(root)
+
 	root.SetHelpCommand(help.New(root))
 	root.RunE = help.NewRootHelp().RunE
 	return root
 }
+
+// markArgsErrors marks the errors of the argument validators of cmd and its
+// subcommands as validation errors.
+func markArgsErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			return flyerr.Validation(validate(cmd, args))
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markArgsErrors(sub)
+	}
+}
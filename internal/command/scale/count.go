@@ -2,9 +2,11 @@ package scale
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
@@ -13,6 +15,8 @@ import (
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/offlinequeue"
+	"github.com/superfly/flyctl/internal/protect"
 	"github.com/superfly/flyctl/iostreams"
 	"golang.org/x/exp/slices"
 )
@@ -36,12 +40,68 @@ For pricing, see https://fly.io/docs/about/pricing/`
 		flag.Int{Name: "max-per-region", Description: "Max number of VMs per region", Default: -1},
 		flag.String{Name: "region", Description: "Comma separated list of regions to act on. Defaults to all regions where there is at least one machine running for the app"},
 		flag.String{Name: "process-group", Description: "The process group to scale"},
+		protect.ForceFlag(),
 	)
 	return cmd
 }
 
 func runScaleCount(ctx context.Context) error {
 	appName := appconfig.NameFromContext(ctx)
+
+	opts := countOptions{
+		Args:         flag.Args(ctx),
+		ProcessGroup: flag.GetString(ctx, "process-group"),
+		MaxPerRegion: flag.GetInt(ctx, "max-per-region"),
+		Region:       flag.GetRegion(ctx),
+	}
+
+	err := scaleCount(ctx, appName, opts, flag.GetYes(ctx), time.Time{})
+	if offlinequeue.ShouldQueue(ctx, err) {
+		summary := fmt.Sprintf("scale count %s -a %s", strings.Join(opts.Args, " "), appName)
+		return offlinequeue.Enqueue(ctx, offlinequeue.KindScaleCount, appName, summary, opts)
+	}
+	return err
+}
+
+// countOptions are the arguments and flags of fly scale count.
+type countOptions struct {
+	Args         []string `json:"args"`
+	ProcessGroup string   `json:"process_group,omitempty"`
+	MaxPerRegion int      `json:"max_per_region"`
+	Region       string   `json:"region,omitempty"`
+}
+
+// ScaleGroup scales the process group of appName to count machines, like fly
+// scale count --process-group does.
+func ScaleGroup(ctx context.Context, appName, group string, count, maxPerRegion int, region string, yes bool) error {
+	opts := countOptions{
+		Args:         []string{strconv.Itoa(count)},
+		ProcessGroup: group,
+		MaxPerRegion: maxPerRegion,
+		Region:       region,
+	}
+	return scaleCount(ctx, appName, opts, yes, time.Time{})
+}
+
+// ReplayCount applies a queued scale count. Unless force is set, it fails
+// with a conflict when machines of the scaled groups changed since it was
+// queued.
+func ReplayCount(ctx context.Context, m offlinequeue.Mutation, force bool) error {
+	var opts countOptions
+	if err := json.Unmarshal(m.Params, &opts); err != nil {
+		return err
+	}
+
+	var since time.Time
+	if !force {
+		since = m.QueuedAt
+	}
+	return scaleCount(ctx, m.App, opts, false, since)
+}
+
+// scaleCount scales the groups of appName. When changedSince is set, it fails
+// with a conflict if machines of the groups were created or updated since.
+func scaleCount(ctx context.Context, appName string, opts countOptions, yes bool, changedSince time.Time) error {
 	flapsClient, err := flaps.NewFromAppName(ctx, appName)
 	if err != nil {
 		return err
@@ -53,10 +113,8 @@ func runScaleCount(ctx context.Context) error {
 		return err
 	}
 
-	args := flag.Args(ctx)
-
 	processNames := appConfig.ProcessNames()
-	groupName := flag.GetString(ctx, "process-group")
+	groupName := opts.ProcessGroup
 
 	if groupName == "" {
 		groupName = api.MachineProcessGroupApp
@@ -69,21 +127,43 @@ func runScaleCount(ctx context.Context) error {
 		return fmt.Errorf("process group '%s' not found", groupName)
 	}
 
-	groups, err := parseGroupCounts(args, groupName)
+	groups, err := parseGroupCounts(opts.Args, groupName)
 	if err != nil {
 		return err
 	}
 
-	maxPerRegion := flag.GetInt(ctx, "max-per-region")
-
 	isV2, err := command.IsMachinesPlatform(ctx, appName)
 	if err != nil {
 		return err
 	}
 	if isV2 {
-		return runMachinesScaleCount(ctx, appName, appConfig, groups, maxPerRegion)
+		if !changedSince.IsZero() {
+			if err := checkCountConflict(ctx, appName, groups, changedSince); err != nil {
+				return err
+			}
+		}
+		return runMachinesScaleCount(ctx, appName, appConfig, groups, opts.MaxPerRegion, opts.Region, yes)
 	}
-	return runNomadScaleCount(ctx, appName, groups, maxPerRegion)
+	return runNomadScaleCount(ctx, appName, groups, opts.MaxPerRegion)
+}
+
+// checkCountConflict fails with a conflict when machines of groups were
+// created or updated since t.
+func checkCountConflict(ctx context.Context, appName string, groups map[string]int, t time.Time) error {
+	machines, _, err := flaps.FromContext(ctx).ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range machines {
+		if _, ok := groups[m.ProcessGroup()]; !ok {
+			continue
+		}
+		if updatedAt, err := time.Parse(time.RFC3339, m.UpdatedAt); err == nil && updatedAt.After(t) {
+			return offlinequeue.Conflict("machine %s of %s's %s group changed at %s", m.ID, appName, m.ProcessGroup(), m.UpdatedAt)
+		}
+	}
+	return nil
 }
 
 func parseGroupCounts(args []string, defaultGroupName string) (map[string]int, error) {
@@ -11,15 +11,18 @@ import (
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
-	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/config"
 	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/policy"
 	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/protect"
 	"github.com/superfly/flyctl/iostreams"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
-func runMachinesScaleCount(ctx context.Context, appName string, appConfig *appconfig.Config, expectedGroupCounts map[string]int, maxPerRegion int) error {
+func runMachinesScaleCount(ctx context.Context, appName string, appConfig *appconfig.Config, expectedGroupCounts map[string]int, maxPerRegion int, region string, yes bool) error {
 	io := iostreams.FromContext(ctx)
 	flapsClient := flaps.FromContext(ctx)
 	ctx = appconfig.WithConfig(ctx, appConfig)
@@ -44,8 +47,8 @@ func runMachinesScaleCount(ctx context.Context, appName string, appConfig *appco
 	}
 
 	var regions []string
-	if v := flag.GetRegion(ctx); v != "" {
-		regions = strings.Split(v, ",")
+	if region != "" {
+		regions = strings.Split(region, ",")
 	}
 	if len(regions) == 0 {
 		regions = lo.Uniq(lo.Map(machines, func(m *api.Machine, _ int) string { return m.Region }))
@@ -93,7 +96,39 @@ func runMachinesScaleCount(ctx context.Context, appName string, appConfig *appco
 		)
 	}
 
-	if !flag.GetYes(ctx) {
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	plan := policy.Plan{AppName: appName}
+	for _, action := range actions {
+		if action.Delta > 0 {
+			plan.Machines = append(plan.Machines, &api.LaunchMachineInput{Region: action.Region, Config: action.MachineConfig})
+		}
+	}
+	if err := policy.Check(ctx, app.Organization.Slug, plan); err != nil {
+		return err
+	}
+
+	costDelta := func(prices budget.Prices) (delta float64) {
+		for _, action := range actions {
+			delta += float64(action.Delta) * prices.Machine(action.MachineConfig.Guest)
+		}
+		return
+	}
+	if err := budget.Check(ctx, app.Organization.Slug, appName, "Scaling "+appName, costDelta); err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		for i := 0; i > action.Delta; i-- {
+			if err := protect.CheckDestroy(ctx, config.ProtectedMachine, action.Machines[-i].ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !yes {
 		switch confirmed, err := prompt.Confirmf(ctx, "Scale app %s?", appName); {
 		case err == nil:
 			if !confirmed {
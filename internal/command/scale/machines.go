@@ -6,9 +6,13 @@ import (
 
 	"github.com/samber/lo"
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
 	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/policy"
 )
 
 func v2ScaleVM(ctx context.Context, appName, group, sizeName string, memoryMB int) (*api.VMSize, error) {
@@ -48,7 +52,12 @@ func v2ScaleVM(ctx context.Context, appName, group, sizeName string, memoryMB in
 		return nil, err
 	}
 
+	var (
+		plan      = policy.Plan{AppName: appName}
+		oldGuests []*api.MachineGuest
+	)
 	for _, machine := range machines {
+		oldGuests = append(oldGuests, helpers.Clone(machine.Config.Guest))
 		if sizeName != "" {
 			machine.Config.Guest.SetSize(sizeName)
 		}
@@ -56,12 +65,33 @@ func v2ScaleVM(ctx context.Context, appName, group, sizeName string, memoryMB in
 			machine.Config.Guest.MemoryMB = memoryMB
 		}
 
-		input := &api.LaunchMachineInput{
+		plan.Machines = append(plan.Machines, &api.LaunchMachineInput{
+			ID:     machine.ID,
 			Name:   machine.Name,
 			Region: machine.Region,
 			Config: machine.Config,
+		})
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Check(ctx, app.Organization.Slug, plan); err != nil {
+		return nil, err
+	}
+	costDelta := func(prices budget.Prices) (delta float64) {
+		for i, machine := range machines {
+			delta += prices.Machine(machine.Config.Guest) - prices.Machine(oldGuests[i])
 		}
-		if err := mach.Update(ctx, machine, input); err != nil {
+		return
+	}
+	if err := budget.Check(ctx, app.Organization.Slug, appName, "Resizing the machines of "+appName, costDelta); err != nil {
+		return nil, err
+	}
+
+	for i, machine := range machines {
+		if err := mach.Update(ctx, machine, plan.Machines[i]); err != nil {
 			return nil, err
 		}
 	}
@@ -0,0 +1,369 @@
+package scale
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prometheus"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	// recommendCPUTarget is the share of its CPUs a machine should use at its
+	// 95th percentile hour.
+	recommendCPUTarget = 0.7
+
+	// recommendMemoryHeadroom is the memory a machine should have over its
+	// peak usage.
+	recommendMemoryHeadroom = 1.25
+)
+
+func newScaleRecommend() *cobra.Command {
+	const (
+		short = "Recommend VM sizes from the resource usage of machines"
+		long  = short + `
+
+Analyzes the CPU and memory usage of the machines of each process group over
+the last --days, and recommends the VM size fitting it: enough CPUs for the
+busiest hours to stay under 70% utilization, and 25% more memory than the
+peak usage. Recommendations keep the CPU kind of the machines, and come with
+the change of their estimated monthly cost.
+
+With --apply, scales the process groups to the recommended sizes, as
+'fly scale vm' does.
+`
+	)
+	cmd := command.New("recommend", short, long, runScaleRecommend,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.Yes(),
+		flag.Int{
+			Name:        "days",
+			Description: "Number of days of metrics to analyze",
+			Default:     7,
+		},
+		flag.String{Name: "group", Description: "Only recommend a VM size for this process group"},
+		flag.Bool{Name: "apply", Description: "Scale the process groups to the recommended VM sizes"},
+	)
+	return cmd
+}
+
+// recommendation is the VM size recommended for the machines of a process
+// group.
+type recommendation struct {
+	Group           string  `json:"process_group"`
+	Machines        int     `json:"machines"`
+	CurrentSize     string  `json:"current_size"`
+	CurrentMemoryMB int     `json:"current_memory_mb"`
+	CPUPercentP95   float64 `json:"cpu_p95_percent"`
+	MemoryPeakMB    float64 `json:"memory_peak_mb"`
+	Size            string  `json:"recommended_size,omitempty"`
+	MemoryMB        int     `json:"recommended_memory_mb,omitempty"`
+	MonthlyCost     float64 `json:"monthly_cost_change"`
+	Note            string  `json:"note,omitempty"`
+}
+
+// groupUsage is the resource usage of the machines of a process group.
+type groupUsage struct {
+	cpu          []float64
+	memoryPeakMB float64
+}
+
+func runScaleRecommend(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		days    = flag.GetInt(ctx, "days")
+		apply   = flag.GetBool(ctx, "apply")
+	)
+
+	if days < 1 || days > 30 {
+		return flyerr.Validation(fmt.Errorf("--days must be between 1 and 30"))
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	groups := map[string][]*api.Machine{}
+	for _, m := range machines {
+		if group := flag.GetString(ctx, "group"); group == "" || m.ProcessGroup() == group {
+			groups[m.ProcessGroup()] = append(groups[m.ProcessGroup()], m)
+		}
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("%s has no active machines to recommend VM sizes for", appName)
+	}
+
+	usage, err := queryGroupUsage(ctx, app, machines, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	prices, err := budget.FetchPrices(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	recommendations := make([]recommendation, 0, len(names))
+	for _, name := range names {
+		recommendations = append(recommendations, recommend(name, groups[name], usage[name], prices))
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(io.Out, recommendations); err != nil {
+			return err
+		}
+	} else if err := renderRecommendations(io, recommendations); err != nil {
+		return err
+	}
+
+	if !apply {
+		return nil
+	}
+
+	var changes []recommendation
+	for _, r := range recommendations {
+		if r.Size != "" {
+			changes = append(changes, r)
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(io.ErrOut, "No process group needs resizing")
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Resize the machines of %d process groups of %s?", len(changes), appName); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	return flyerr.ForEach(changes, false, func(r recommendation) error {
+		return scaleVertically(ctx, r.Group, r.Size, r.MemoryMB)
+	})
+}
+
+// queryGroupUsage returns the hourly CPU usage of the machines of app, in
+// percent of their CPUs, and their peak memory usage over the last period,
+// by process group.
+func queryGroupUsage(ctx context.Context, app *api.AppCompact, machines []*api.Machine, period time.Duration) (map[string]*groupUsage, error) {
+	var (
+		sel = fmt.Sprintf(`app=%q`, app.Name)
+		end = time.Now()
+
+		// fly_instance_cpu counts centiseconds per CPU, so its rate out of
+		// idle, over the number of CPUs, is a percentage
+		cpuQuery = fmt.Sprintf(`sum by (instance) (rate(fly_instance_cpu{%s,mode!="idle"}[1h])) / count by (instance) (fly_instance_cpu{%s,mode="idle"})`, sel, sel)
+		memQuery = fmt.Sprintf(`max by (instance) (max_over_time((fly_instance_memory_mem_total{%s} - fly_instance_memory_mem_available{%s})[1h:1m]))`, sel, sel)
+	)
+
+	cpu, err := prometheus.QueryRange(ctx, app.Organization.Slug, cpuQuery, end.Add(-period), end, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := prometheus.QueryRange(ctx, app.Organization.Slug, memQuery, end.Add(-period), end, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGroupUsage(machines, cpu, memory), nil
+}
+
+// newGroupUsage returns the usage of machines by process group from the
+// series of their CPU usage and memory usage, in bytes.
+func newGroupUsage(machines []*api.Machine, cpu, memory []prometheus.Series) map[string]*groupUsage {
+	groupOf := map[string]string{}
+	for _, m := range machines {
+		groupOf[m.ID] = m.ProcessGroup()
+	}
+
+	usage := map[string]*groupUsage{}
+	get := func(s prometheus.Series) *groupUsage {
+		group, ok := groupOf[s.Labels["instance"]]
+		if !ok {
+			return nil
+		}
+		if usage[group] == nil {
+			usage[group] = &groupUsage{}
+		}
+		return usage[group]
+	}
+
+	for _, s := range cpu {
+		if u := get(s); u != nil {
+			for _, p := range s.Points {
+				u.cpu = append(u.cpu, p.Value)
+			}
+		}
+	}
+	for _, s := range memory {
+		if u := get(s); u != nil {
+			for _, p := range s.Points {
+				u.memoryPeakMB = math.Max(u.memoryPeakMB, p.Value/(1024*1024))
+			}
+		}
+	}
+	return usage
+}
+
+// recommend returns the recommendation for the machines of group, from their
+// usage.
+func recommend(group string, machines []*api.Machine, usage *groupUsage, prices budget.Prices) recommendation {
+	var current *api.MachineGuest
+	if machines[0].Config != nil {
+		current = machines[0].Config.Guest
+	}
+	r := recommendation{
+		Group:           group,
+		Machines:        len(machines),
+		CurrentSize:     current.ToSize(),
+		CurrentMemoryMB: current.MemoryMB,
+	}
+
+	switch {
+	case current == nil:
+		r.Note = "unknown size"
+		return r
+	case usage == nil || len(usage.cpu) == 0 || usage.memoryPeakMB == 0:
+		r.Note = "not enough metrics"
+		return r
+	}
+
+	r.CPUPercentP95 = percentile(usage.cpu, 95)
+	r.MemoryPeakMB = usage.memoryPeakMB
+
+	guest := recommendGuest(current, r.CPUPercentP95, r.MemoryPeakMB)
+	if guest.CPUs == current.CPUs && guest.MemoryMB == current.MemoryMB {
+		r.Note = "size fits"
+		return r
+	}
+	r.Size = guest.ToSize()
+	r.MemoryMB = guest.MemoryMB
+
+	for _, m := range machines {
+		r.MonthlyCost += prices.Machine(guest)
+		if m.Config != nil {
+			r.MonthlyCost -= prices.Machine(m.Config.Guest)
+		}
+	}
+	return r
+}
+
+// recommendGuest returns the smallest guest of the CPU kind of current which
+// keeps cpuP95, its 95th percentile CPU usage in percent, under
+// recommendCPUTarget, with recommendMemoryHeadroom over memPeakMB.
+func recommendGuest(current *api.MachineGuest, cpuP95, memPeakMB float64) *api.MachineGuest {
+	minMemoryPerCPU, maxMemoryPerCPU := api.MIN_MEMORY_MB_PER_CPU, api.MAX_MEMORY_MB_PER_CPU
+	if current.CPUKind == "shared" {
+		minMemoryPerCPU, maxMemoryPerCPU = api.MIN_MEMORY_MB_PER_SHARED_CPU, api.MAX_MEMORY_MB_PER_SHARED_CPU
+	}
+
+	var sizes []int
+	for _, preset := range api.MachinePresets {
+		if preset.CPUKind == current.CPUKind {
+			sizes = append(sizes, preset.CPUs)
+		}
+	}
+	sort.Ints(sizes)
+
+	neededCPUs := cpuP95 / 100 * float64(current.CPUs) / recommendCPUTarget
+	neededMemory := int(math.Ceil(memPeakMB*recommendMemoryHeadroom/256)) * 256
+
+	for i, cpus := range sizes {
+		largest := i == len(sizes)-1
+		if float64(cpus) < neededCPUs && !largest {
+			continue
+		}
+		memory := neededMemory
+		if lower := cpus * minMemoryPerCPU; memory < lower {
+			memory = lower
+		}
+		if upper := cpus * maxMemoryPerCPU; memory > upper {
+			if !largest {
+				continue
+			}
+			memory = upper
+		}
+		return &api.MachineGuest{CPUKind: current.CPUKind, CPUs: cpus, MemoryMB: memory}
+	}
+	return current
+}
+
+// percentile returns the p-th percentile of values, by the nearest rank.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func renderRecommendations(io *iostreams.IOStreams, recommendations []recommendation) error {
+	rows := make([][]string, 0, len(recommendations))
+	for _, r := range recommendations {
+		recommended, cost := r.Note, "-"
+		if r.Size != "" {
+			recommended = fmt.Sprintf("%s, %d MB", r.Size, r.MemoryMB)
+			cost = fmt.Sprintf("%+.2f USD/month", r.MonthlyCost)
+		}
+		rows = append(rows, []string{
+			r.Group,
+			fmt.Sprint(r.Machines),
+			fmt.Sprintf("%s, %d MB", r.CurrentSize, r.CurrentMemoryMB),
+			fmt.Sprintf("%.1f%%", r.CPUPercentP95),
+			fmt.Sprintf("%.0f MB", r.MemoryPeakMB),
+			recommended,
+			cost,
+		})
+	}
+	return render.Table(io.Out, "", rows, "Process Group", "Machines", "Current", "CPU p95", "Memory Peak", "Recommended", "Cost Change")
+}
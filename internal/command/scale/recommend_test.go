@@ -0,0 +1,83 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/prometheus"
+)
+
+func TestRecommendGuest(t *testing.T) {
+	shared := &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 2048}
+
+	// Idle with little memory shrinks to the smallest size
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256}, recommendGuest(shared, 5, 100))
+
+	// Busy CPUs grow, 90% of 2 CPUs needs 3 at 70%
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 4, MemoryMB: 1024}, recommendGuest(shared, 90, 700))
+
+	// Memory beyond what a CPU supports takes more CPUs
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 3072}, recommendGuest(shared, 10, 2400))
+
+	// The largest size caps recommendations
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 8, MemoryMB: 16384}, recommendGuest(shared, 400, 64000))
+
+	performance := &api.MachineGuest{CPUKind: "performance", CPUs: 4, MemoryMB: 8192}
+	assert.Equal(t, &api.MachineGuest{CPUKind: "performance", CPUs: 1, MemoryMB: 2048}, recommendGuest(performance, 10, 1000))
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}
+	assert.Equal(t, 10.0, percentile(values, 95))
+	assert.Equal(t, 5.0, percentile(values, 50))
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 10.0, values[0])
+}
+
+func TestRecommend(t *testing.T) {
+	machine := func(id, group string, guest *api.MachineGuest) *api.Machine {
+		return &api.Machine{ID: id, Config: &api.MachineConfig{
+			Guest:    guest,
+			Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: group},
+		}}
+	}
+	machines := []*api.Machine{
+		machine("m1", "app", &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 1024}),
+		machine("m2", "app", &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 1024}),
+		machine("m3", "worker", &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256}),
+	}
+
+	at := time.Unix(1700000000, 0)
+	series := func(instance string, values ...float64) prometheus.Series {
+		s := prometheus.Series{Labels: map[string]string{"instance": instance}}
+		for i, v := range values {
+			s.Points = append(s.Points, prometheus.Point{Time: at.Add(time.Duration(i) * time.Hour), Value: v})
+		}
+		return s
+	}
+	usage := newGroupUsage(machines,
+		[]prometheus.Series{series("m1", 5, 10), series("m2", 20), series("m3", 30), series("other", 100)},
+		[]prometheus.Series{series("m1", 100<<20, 200<<20), series("m2", 150<<20), series("m3", 200<<20)},
+	)
+	assert.ElementsMatch(t, []float64{5, 10, 20}, usage["app"].cpu)
+	assert.Equal(t, 200.0, usage["app"].memoryPeakMB)
+
+	prices := budget.Prices{Machines: map[string]float64{"shared-cpu-1x": 2, "shared-cpu-2x": 4}, AdditionalMemoryPerGB: 5}
+
+	r := recommend("app", machines[:2], usage["app"], prices)
+	assert.Equal(t, "shared-cpu-1x", r.Size)
+	assert.Equal(t, 256, r.MemoryMB)
+	assert.Equal(t, 20.0, r.CPUPercentP95)
+	// 2 shared-cpu-2x with 512 MB extra at $5/GB down to 2 shared-cpu-1x
+	assert.InDelta(t, -2*(4+2.5-2), r.MonthlyCost, 0.001)
+
+	r = recommend("worker", machines[2:], usage["worker"], prices)
+	assert.Equal(t, "size fits", r.Note)
+	assert.Empty(t, r.Size)
+
+	r = recommend("worker", machines[2:], nil, prices)
+	assert.Equal(t, "not enough metrics", r.Note)
+}
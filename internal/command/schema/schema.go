@@ -0,0 +1,113 @@
+// Package schema implements the schema command chain.
+package schema
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/schema"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new schema Command.
+func New() *cobra.Command {
+	const (
+		short = "List the versioned JSON outputs of flyctl"
+		long  = short + `
+
+Commands print their JSON output in the shape of a schema version when given
+--json-schema-version, so scripts and tools parsing it keep working across
+flyctl upgrades. Output without --json-schema-version may change in any
+release.
+
+Within a schema version, fields are only ever added: they are never removed,
+renamed or retyped, and the meaning of their values doesn't change. Breaking
+changes ship as a new schema version, and flyctl keeps printing the versions
+it supported for at least six months after the release replacing them.
+`
+	)
+
+	cmd := command.New("schema", short, long, runList)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd, flag.JSONOutput())
+
+	cmd.AddCommand(newPrint())
+
+	return cmd
+}
+
+func newPrint() *cobra.Command {
+	const (
+		short = "Print the JSON Schema of a versioned output"
+		long  = short + `
+
+Prints the latest schema version of the output unless --json-schema-version
+picks another one.
+`
+		usage = "print <output>"
+	)
+
+	cmd := command.New(usage, short, long, runPrint)
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.ValidArgs = schema.Names()
+	cmd.Example = `  fly schema print status
+  fly schema print machines --json-schema-version 1`
+
+	flag.Add(cmd, flag.JSONSchemaVersion())
+
+	return cmd
+}
+
+func runList(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	if config.FromContext(ctx).JSONOutput {
+		type output struct {
+			Name     string `json:"name"`
+			Command  string `json:"command"`
+			Versions []int  `json:"versions"`
+			Latest   int    `json:"latest"`
+		}
+		return render.JSON(out, lo.Map(schema.Outputs, func(o schema.Output, _ int) output {
+			return output{Name: o.Name, Command: o.Command, Versions: o.SupportedVersions(), Latest: o.Latest()}
+		}))
+	}
+
+	rows := make([][]string, 0, len(schema.Outputs))
+	for _, o := range schema.Outputs {
+		versions := lo.Map(o.SupportedVersions(), func(v int, _ int) string { return strconv.Itoa(v) })
+		rows = append(rows, []string{o.Name, o.Command, strings.Join(versions, ", "), strconv.Itoa(o.Latest())})
+	}
+
+	return render.Table(out, "", rows, "Output", "Command", "Versions", "Latest")
+}
+
+func runPrint(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	o, err := schema.Find(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	version := flag.GetJSONSchemaVersion(ctx)
+	if version == 0 {
+		version = o.Latest()
+	}
+
+	doc, err := o.Document(version)
+	if err != nil {
+		return err
+	}
+
+	return render.JSON(out, doc)
+}
@@ -0,0 +1,109 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/secretscan"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newLint() (cmd *cobra.Command) {
+	const (
+		long = `Look for values that look like secrets in the build context, and in the
+layers of an image with --image, so they don't get baked into images.
+
+Well-known kinds of credentials such as AWS keys, GitHub tokens and private
+keys are reported, along with values assigned to the names of the app's Fly
+secrets, e.g. in .env files. Files excluded by .dockerignore aren't scanned.`
+		short = "Check the build context and images for leaked secrets"
+		usage = "lint [WORKING_DIRECTORY]"
+	)
+
+	cmd = command.New(usage, short, long, runLint,
+		command.RequireSession,
+		command.ChangeWorkingDirectoryToFirstArgIfPresent,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.Dockerfile(),
+		flag.Ignorefile(),
+		flag.String{
+			Name:        "image",
+			Shorthand:   "i",
+			Description: "Also scan the layers of this image",
+		},
+	)
+
+	return cmd
+}
+
+func runLint(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		client  = client.FromContext(ctx).API()
+		appName = appconfig.NameFromContext(ctx)
+		image   = flag.GetString(ctx, "image")
+		names   []string
+	)
+
+	if appName != "" {
+		secrets, err := client.GetAppSecrets(ctx, appName)
+		if err != nil {
+			return fmt.Errorf("failed retrieving secrets of %s: %w", appName, err)
+		}
+		for _, s := range secrets {
+			names = append(names, s.Name)
+		}
+	}
+
+	scanner := secretscan.New(names)
+
+	findings, err := scanner.ScanBuildContext(state.WorkingDirectory(ctx), flag.GetString(ctx, "dockerfile"), flag.GetString(ctx, "ignorefile"))
+	if err != nil {
+		return err
+	}
+
+	if image != "" {
+		imageFindings, err := scanner.ScanImage(ctx, image)
+		if err != nil {
+			return err
+		}
+		for i := range imageFindings {
+			imageFindings[i].Path = image + ":/" + imageFindings[i].Path
+		}
+		findings = append(findings, imageFindings...)
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(io.Out, findings); err != nil {
+			return err
+		}
+	} else if len(findings) > 0 {
+		if err := secretscan.Render(io.Out, findings); err != nil {
+			return err
+		}
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("found %d values that look like secrets", len(findings))
+	}
+
+	fmt.Fprintln(io.ErrOut, "No leaked secrets found")
+	return nil
+}
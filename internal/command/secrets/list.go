@@ -18,12 +18,14 @@ func newList() (cmd *cobra.Command) {
 	const (
 		long = `List the secrets available to the application. It shows each secret's
 name, a digest of its value and the time the secret was last set. The
-actual value of the secret is only available to the application.`
+actual value of the secret is only available to the application.
+
+With --org, list the secrets of an organization and the apps linked to them.`
 		short = `List application secret names, digests and creation times`
 		usage = "list [flags]"
 	)
 
-	cmd = command.New(usage, short, long, runList, command.RequireSession, command.RequireAppName)
+	cmd = command.New(usage, short, long, runList, command.RequireSession, command.LoadAppNameIfPresent)
 
 	cmd.Aliases = []string{"ls"}
 
@@ -31,12 +33,20 @@ actual value of the secret is only available to the application.`
 		flag.App(),
 		flag.AppConfig(),
 		flag.JSONOutput(),
+		orgFlag,
 	)
 
 	return cmd
 }
 
 func runList(ctx context.Context) (err error) {
+	if org := flag.GetOrg(ctx); org != "" {
+		return listOrgSecrets(ctx, org, config.FromContext(ctx).JSONOutput)
+	}
+	if err := requireAppOrOrg(ctx); err != nil {
+		return err
+	}
+
 	client := client.FromContext(ctx).API()
 	appName := appconfig.NameFromContext(ctx)
 	out := iostreams.FromContext(ctx).Out
@@ -0,0 +1,537 @@
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/format"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// OrgSecretsAppRole is the role of the app holding the secrets of an
+// organization.
+const OrgSecretsAppRole = "org-secrets"
+
+const (
+	orgSecretsAppSuffix   = "-org-secrets"
+	orgSecretsAppAttempts = 3
+	maxAppNameLength      = 63
+
+	vaultMachineName = "vault"
+
+	// vaultLinksMetadataKey holds the apps linked to each org secret, as a
+	// JSON object of secret names to app names.
+	vaultLinksMetadataKey = "fly_org_secret_links"
+)
+
+var secretNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var orgFlag = flag.String{
+	Name:        flag.Org().Name,
+	Description: "Manage the secrets of this organization instead of an app's",
+}
+
+func newLink() *cobra.Command {
+	const (
+		long = `Link an app to secrets of its organization, set with fly secrets set --org.
+The app gets the current values of the secrets, and the new ones whenever
+they're set again.`
+		short = "Link an app to organization secrets"
+		usage = "link [flags] NAME NAME ..."
+	)
+
+	cmd := command.New(usage, short, long, runLink, command.RequireSession, command.RequireAppName)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	flag.Add(cmd,
+		sharedFlags,
+	)
+
+	return cmd
+}
+
+func newUnlink() *cobra.Command {
+	const (
+		long = `Stop updating the secrets of an app when the organization secrets they
+were linked to change. The app keeps its current values, remove them with fly
+secrets unset.`
+		short = "Unlink an app from organization secrets"
+		usage = "unlink [flags] NAME NAME ..."
+	)
+
+	cmd := command.New(usage, short, long, runUnlink, command.RequireSession, command.RequireAppName)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+// orgSecretsAppName derives a name for the org secrets app of orgSlug,
+// suffixed with a hash after the first attempt.
+func orgSecretsAppName(orgSlug string, attempt int) string {
+	if name := orgSlug + orgSecretsAppSuffix; attempt == 0 && len(name) <= maxAppNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", orgSlug, attempt)))
+	hash := hex.EncodeToString(sum[:])[:6]
+
+	slug := orgSlug
+	if maxSlug := maxAppNameLength - len(orgSecretsAppSuffix) - len(hash) - 1; len(slug) > maxSlug {
+		slug = strings.TrimRight(slug[:maxSlug], "-")
+	}
+
+	return slug + "-" + hash + orgSecretsAppSuffix
+}
+
+// vault is the app holding the secrets of an organization, and its machine.
+type vault struct {
+	app     *api.AppCompact
+	flaps   *flaps.Client
+	machine *api.Machine
+}
+
+// findVault returns the vault of org, creating it when create is set.
+func findVault(ctx context.Context, orgSlug string, create bool) (*vault, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	org, err := apiClient.GetOrganizationBySlug(ctx, orgSlug)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving organization %s: %w", orgSlug, err)
+	}
+
+	appsResult, err := gql.GetAppsByRole(ctx, apiClient.GenqClient, OrgSecretsAppRole, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var appName string
+	switch {
+	case len(appsResult.Apps.Nodes) > 0:
+		appName = appsResult.Apps.Nodes[0].Name
+	case !create:
+		return nil, fmt.Errorf("%s has no organization secrets, set some with fly secrets set --org %s", org.Slug, org.Slug)
+	default:
+		if appName, err = createVaultApp(ctx, org); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(io.ErrOut, "Created app %s to hold the secrets of %s\n", appName, org.Slug)
+
+		// Linked apps depend on the vault, guard it against accidental removal
+		if err := config.SetProtected(state.SharedConfigFile(ctx), config.ProtectedApp, appName, true); err != nil {
+			terminal.Warnf("failed protecting %s from deletion: %v\n", appName, err)
+		}
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &vault{app: app, flaps: flapsClient}
+
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range machines {
+		if m.Name == vaultMachineName {
+			v.machine = m
+			return v, nil
+		}
+	}
+
+	if !create {
+		return nil, fmt.Errorf("the vault machine of %s is missing, set a secret with fly secrets set --org %s to launch it again", org.Slug, org.Slug)
+	}
+	return v, v.launch(ctx)
+}
+
+func createVaultApp(ctx context.Context, org *api.Organization) (string, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = org.ID
+	input.AppRoleId = OrgSecretsAppRole
+
+	for attempt := 0; attempt < orgSecretsAppAttempts; attempt++ {
+		input.Name = orgSecretsAppName(org.RawSlug, attempt)
+
+		_, err := gql.CreateApp(ctx, apiClient.GenqClient, input)
+		switch {
+		case err != nil && strings.Contains(strings.ToLower(err.Error()), "already been taken"):
+			continue
+		case err != nil:
+			return "", fmt.Errorf("failed creating org secrets app: %w", err)
+		}
+		return input.Name, nil
+	}
+
+	return "", errors.New("could not find an available name for the org secrets app")
+}
+
+func (v *vault) config(links map[string][]string) (*api.MachineConfig, error) {
+	data, err := json.Marshal(links)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &api.MachineConfig{
+		Init: api.MachineInit{
+			// Idle, the secrets are read by fly secrets link through exec
+			Entrypoint: []string{"tail", "-f", "/dev/null"},
+		},
+		Guest: &api.MachineGuest{
+			CPUKind:  "shared",
+			CPUs:     1,
+			MemoryMB: 256,
+		},
+		Metadata: map[string]string{vaultLinksMetadataKey: string(data)},
+	}
+	infra.Configure(config, infra.ComponentVault, v.machine)
+	return config, nil
+}
+
+func (v *vault) launch(ctx context.Context) error {
+	config, err := v.config(nil)
+	if err != nil {
+		return err
+	}
+
+	costDelta := func(prices budget.Prices) float64 { return prices.Machine(config.Guest) }
+	if err := budget.Check(ctx, v.app.Organization.Slug, v.app.Name, "Launching the org secrets vault machine", costDelta); err != nil {
+		return err
+	}
+
+	machine, err := v.flaps.Launch(ctx, api.LaunchMachineInput{
+		Name:   vaultMachineName,
+		Config: config,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching the vault machine: %w", err)
+	}
+	v.machine = machine
+	return nil
+}
+
+// links returns the apps linked to each secret.
+func (v *vault) links() map[string][]string {
+	links := map[string][]string{}
+	if v.machine.Config != nil {
+		if data := v.machine.Config.Metadata[vaultLinksMetadataKey]; data != "" {
+			// Unreadable links are dropped on the next update
+			_ = json.Unmarshal([]byte(data), &links)
+		}
+	}
+	return links
+}
+
+// update replaces the links of the vault, and restarts its machine to load
+// the current secrets.
+func (v *vault) update(ctx context.Context, links map[string][]string) error {
+	for name, apps := range links {
+		if len(apps) == 0 {
+			delete(links, name)
+		}
+	}
+
+	config, err := v.config(links)
+	if err != nil {
+		return err
+	}
+
+	ctx = flaps.NewContext(ctx, v.flaps)
+
+	machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, v.machine)
+	defer releaseLeaseFunc(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	return mach.Update(ctx, machine, &api.LaunchMachineInput{
+		Name:   vaultMachineName,
+		Region: machine.Region,
+		Config: config,
+	})
+}
+
+// read returns the values of secrets, from the environment of the vault
+// machine.
+func (v *vault) read(ctx context.Context, names []string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		if !secretNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("%q isn't a valid secret name", name)
+		}
+
+		resp, err := v.flaps.Exec(ctx, v.machine.ID, &api.MachineExecRequest{
+			Cmd:     "printenv " + name,
+			Timeout: 30,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed reading org secret %s: %w", name, err)
+		}
+		if resp.ExitCode != 0 {
+			return nil, fmt.Errorf("org secret %s isn't set", name)
+		}
+		values[name] = printenvValue(resp.StdOut)
+	}
+	return values, nil
+}
+
+// printenvValue returns the value printenv printed, without the newline it
+// ends values with.
+func printenvValue(out string) string {
+	return strings.TrimSuffix(out, "\n")
+}
+
+func setOrgSecrets(ctx context.Context, orgSlug string, secrets map[string]string) error {
+	io := iostreams.FromContext(ctx)
+	apiClient := client.FromContext(ctx).API()
+
+	v, err := findVault(ctx, orgSlug, true)
+	if err != nil {
+		return err
+	}
+
+	if _, err := apiClient.SetSecrets(ctx, v.app.Name, secrets); err != nil {
+		return err
+	}
+
+	links := v.links()
+	if err := v.update(ctx, links); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.Out, "Set %s in %s\n", strings.Join(sortedKeys(secrets), ", "), orgSlug)
+
+	return propagate(ctx, links, maps.Keys(secrets), func(app *api.AppCompact, names []string) error {
+		subset := make(map[string]string, len(names))
+		for _, name := range names {
+			subset[name] = secrets[name]
+		}
+		return SetSecretsAndDeploy(ctx, app, subset, flag.GetBool(ctx, "stage"), flag.GetBool(ctx, "detach"))
+	})
+}
+
+func unsetOrgSecrets(ctx context.Context, orgSlug string, names []string) error {
+	io := iostreams.FromContext(ctx)
+	apiClient := client.FromContext(ctx).API()
+
+	v, err := findVault(ctx, orgSlug, false)
+	if err != nil {
+		return err
+	}
+
+	if _, err := apiClient.UnsetSecrets(ctx, v.app.Name, names); err != nil {
+		return err
+	}
+
+	links := v.links()
+	remaining := maps.Clone(links)
+	for _, name := range names {
+		delete(remaining, name)
+	}
+	if err := v.update(ctx, remaining); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.Out, "Unset %s in %s\n", strings.Join(names, ", "), orgSlug)
+
+	return propagate(ctx, links, names, func(app *api.AppCompact, names []string) error {
+		return UnsetSecretsAndDeploy(ctx, app, names, flag.GetBool(ctx, "stage"), flag.GetBool(ctx, "detach"))
+	})
+}
+
+// propagate applies a change of secrets to the apps linked to them, carrying
+// on past failures so that one broken app doesn't hold back the others.
+func propagate(ctx context.Context, links map[string][]string, names []string, apply func(*api.AppCompact, []string) error) error {
+	io := iostreams.FromContext(ctx)
+	apiClient := client.FromContext(ctx).API()
+
+	byApp := map[string][]string{}
+	for _, name := range names {
+		for _, appName := range links[name] {
+			byApp[appName] = append(byApp[appName], name)
+		}
+	}
+
+	var errs []error
+	for _, appName := range sortedKeys(byApp) {
+		names := byApp[appName]
+		slices.Sort(names)
+		fmt.Fprintf(io.Out, "Updating %s of %s\n", strings.Join(names, ", "), appName)
+
+		app, err := apiClient.GetAppCompact(ctx, appName)
+		if err == nil {
+			err = apply(app, names)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed updating %s: %w", appName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func listOrgSecrets(ctx context.Context, orgSlug string, jsonOutput bool) error {
+	out := iostreams.FromContext(ctx).Out
+	apiClient := client.FromContext(ctx).API()
+
+	v, err := findVault(ctx, orgSlug, false)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := apiClient.GetAppSecrets(ctx, v.app.Name)
+	if err != nil {
+		return err
+	}
+	links := v.links()
+
+	if jsonOutput {
+		type orgSecret struct {
+			api.Secret
+			Apps []string `json:"apps"`
+		}
+		list := make([]orgSecret, 0, len(secrets))
+		for _, secret := range secrets {
+			list = append(list, orgSecret{Secret: secret, Apps: links[secret.Name]})
+		}
+		return render.JSON(out, list)
+	}
+
+	var rows [][]string
+	for _, secret := range secrets {
+		rows = append(rows, []string{
+			secret.Name,
+			secret.Digest,
+			format.RelativeTime(secret.CreatedAt),
+			strings.Join(links[secret.Name], ", "),
+		})
+	}
+
+	return render.Table(out, "", rows, "Name", "Digest", "Created At", "Linked Apps")
+}
+
+func runLink(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		names     = flag.Args(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	v, err := findVault(ctx, app.Organization.Slug, false)
+	if err != nil {
+		return err
+	}
+
+	values, err := v.read(ctx, names)
+	if err != nil {
+		return err
+	}
+
+	links := v.links()
+	for _, name := range names {
+		if !slices.Contains(links[name], appName) {
+			links[name] = append(links[name], appName)
+			slices.Sort(links[name])
+		}
+	}
+	if err := v.update(ctx, links); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.Out, "Linked %s to %s of %s\n", appName, strings.Join(names, ", "), app.Organization.Slug)
+
+	return SetSecretsAndDeploy(ctx, app, values, flag.GetBool(ctx, "stage"), flag.GetBool(ctx, "detach"))
+}
+
+func runUnlink(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		names     = flag.Args(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	v, err := findVault(ctx, app.Organization.Slug, false)
+	if err != nil {
+		return err
+	}
+
+	links := v.links()
+	for _, name := range names {
+		if !slices.Contains(links[name], appName) {
+			return fmt.Errorf("%s isn't linked to %s", appName, name)
+		}
+		links[name] = lo.Without(links[name], appName)
+	}
+	if err := v.update(ctx, links); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Unlinked %s from %s, it keeps their current values\n", appName, strings.Join(names, ", "))
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// requireAppOrOrg fails when neither an app nor an organization was given.
+func requireAppOrOrg(ctx context.Context) error {
+	if flag.GetOrg(ctx) == "" && appconfig.NameFromContext(ctx) == "" {
+		return command.ErrRequireAppName
+	}
+	return nil
+}
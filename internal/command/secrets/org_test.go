@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgSecretsAppName(t *testing.T) {
+	assert.Equal(t, "acme-org-secrets", orgSecretsAppName("acme", 0))
+
+	retry := orgSecretsAppName("acme", 1)
+	assert.NotEqual(t, "acme-org-secrets", retry)
+	assert.True(t, strings.HasSuffix(retry, orgSecretsAppSuffix))
+
+	long := orgSecretsAppName(strings.Repeat("a", 60), 0)
+	assert.LessOrEqual(t, len(long), maxAppNameLength)
+	assert.True(t, strings.HasSuffix(long, orgSecretsAppSuffix))
+}
+
+func TestPrintenvValue(t *testing.T) {
+	assert.Equal(t, "s3cr3t", printenvValue("s3cr3t\n"))
+	assert.Equal(t, "line one\nline two\n", printenvValue("line one\nline two\n\n"))
+	assert.Equal(t, "", printenvValue("\n"))
+}
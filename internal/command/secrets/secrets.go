@@ -45,6 +45,9 @@ func New() *cobra.Command {
 		newSet(),
 		newUnset(),
 		newImport(),
+		newLint(),
+		newLink(),
+		newUnlink(),
 	)
 
 	return secrets
@@ -2,8 +2,11 @@ package secrets
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/api"
@@ -13,19 +16,26 @@ import (
 	"github.com/superfly/flyctl/internal/cmdutil"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/offlinequeue"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 func newSet() (cmd *cobra.Command) {
 	const (
-		long  = `Set one or more encrypted secrets for an application`
-		short = long
+		long = `Set one or more encrypted secrets for an application.
+
+With --org, set secrets of an organization instead. Apps opt into them with
+fly secrets link, and are updated whenever they're set again.`
+		short = `Set one or more encrypted secrets for an application`
 		usage = "set [flags] NAME=VALUE NAME=VALUE ..."
 	)
 
-	cmd = command.New(usage, short, long, runSet, command.RequireSession, command.RequireAppName)
+	cmd = command.New(usage, short, long, runSet, command.RequireSession, command.LoadAppNameIfPresent)
 
 	flag.Add(cmd,
 		sharedFlags,
+		orgFlag,
 	)
 
 	cmd.Args = cobra.MinimumNArgs(1)
@@ -36,12 +46,6 @@ func newSet() (cmd *cobra.Command) {
 func runSet(ctx context.Context) (err error) {
 	client := client.FromContext(ctx).API()
 	appName := appconfig.NameFromContext(ctx)
-	app, err := client.GetAppCompact(ctx, appName)
-	if err != nil {
-		return err
-	}
-
-	command.PromptToMigrate(ctx, app)
 
 	secrets, err := cmdutil.ParseKVStringsToMap(flag.Args(ctx))
 	if err != nil {
@@ -65,7 +69,92 @@ func runSet(ctx context.Context) (err error) {
 		return errors.New("requires at least one SECRET=VALUE pair")
 	}
 
-	return SetSecretsAndDeploy(ctx, app, secrets, flag.GetBool(ctx, "stage"), flag.GetBool(ctx, "detach"))
+	if org := flag.GetOrg(ctx); org != "" {
+		return setOrgSecrets(ctx, org, secrets)
+	}
+	if err := requireAppOrOrg(ctx); err != nil {
+		return err
+	}
+
+	stage, detach := flag.GetBool(ctx, "stage"), flag.GetBool(ctx, "detach")
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		if offlinequeue.ShouldQueue(ctx, err) {
+			set := newQueuedSet(secrets, stage, detach)
+			// The values stay in the keyring, out of the queue file
+			return offlinequeue.EnqueueWithSecrets(ctx, offlinequeue.KindSecretsSet, appName, set.summary(appName), set, secrets)
+		}
+		return err
+	}
+
+	command.PromptToMigrate(ctx, app)
+
+	return SetSecretsAndDeploy(ctx, app, secrets, stage, detach)
+}
+
+// queuedSet is a secrets set queued while the API was unreachable. The values
+// of its secrets are kept in the keyring.
+type queuedSet struct {
+	Names  []string `json:"names"`
+	Stage  bool     `json:"stage,omitempty"`
+	Detach bool     `json:"detach,omitempty"`
+}
+
+func newQueuedSet(secrets map[string]string, stage, detach bool) queuedSet {
+	names := maps.Keys(secrets)
+	slices.Sort(names)
+	return queuedSet{Names: names, Stage: stage, Detach: detach}
+}
+
+func (set queuedSet) summary(appName string) string {
+	return fmt.Sprintf("secrets set %s -a %s", strings.Join(set.Names, " "), appName)
+}
+
+// queueClockSkew is how far the clock secrets were queued by may be behind
+// the API's, whose timestamps tell when secrets were set.
+const queueClockSkew = 5 * time.Minute
+
+// setSince reports whether secret was set since queuedAt, erring on the side
+// of conflicts when clocks disagree.
+func setSince(secret api.Secret, queuedAt time.Time) bool {
+	return secret.CreatedAt.After(queuedAt.Add(-queueClockSkew))
+}
+
+// ReplaySet applies a queued secrets set. Unless force is set, it fails with
+// a conflict when one of its secrets was set since it was queued.
+func ReplaySet(ctx context.Context, m offlinequeue.Mutation, force bool) error {
+	var set queuedSet
+	if err := json.Unmarshal(m.Params, &set); err != nil {
+		return err
+	}
+	secrets, err := offlinequeue.Secrets(m)
+	if err != nil {
+		return err
+	}
+	if len(secrets) != len(set.Names) {
+		return fmt.Errorf("the keyring lost the values of %s, drop it with fly queue drop %s and set the secrets again", m.Summary, m.ID)
+	}
+
+	client := client.FromContext(ctx).API()
+	app, err := client.GetAppCompact(ctx, m.App)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		current, err := client.GetAppSecrets(ctx, m.App)
+		if err != nil {
+			return err
+		}
+		for _, secret := range current {
+			if _, ok := secrets[secret.Name]; ok && setSince(secret, m.QueuedAt) {
+				return offlinequeue.Conflict("secret %s of %s was set at %s", secret.Name, m.App, secret.CreatedAt.Format(time.RFC3339))
+			}
+		}
+	}
+
+	return SetSecretsAndDeploy(ctx, app, secrets, set.Stage, set.Detach)
 }
 
 func SetSecretsAndDeploy(ctx context.Context, app *api.AppCompact, secrets map[string]string, stage bool, detach bool) error {
@@ -0,0 +1,23 @@
+package secrets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestSetSince(t *testing.T) {
+	queuedAt := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, setSince(api.Secret{CreatedAt: queuedAt.Add(time.Hour)}, queuedAt))
+	assert.True(t, setSince(api.Secret{CreatedAt: queuedAt.Add(-time.Minute)}, queuedAt), "the queueing clock may be ahead")
+	assert.False(t, setSince(api.Secret{CreatedAt: queuedAt.Add(-time.Hour)}, queuedAt))
+}
+
+func TestNewQueuedSet(t *testing.T) {
+	set := newQueuedSet(map[string]string{"B": "secret", "A": "secret"}, true, false)
+	assert.Equal(t, queuedSet{Names: []string{"A", "B"}, Stage: true}, set)
+	assert.Equal(t, "secrets set A B -a app", set.summary("app"))
+}
@@ -13,15 +13,19 @@ import (
 
 func newUnset() (cmd *cobra.Command) {
 	const (
-		long  = `Unset one or more encrypted secrets for an application`
-		short = long
+		long = `Unset one or more encrypted secrets for an application.
+
+With --org, unset secrets of an organization instead, and of the apps linked
+to them.`
+		short = `Unset one or more encrypted secrets for an application`
 		usage = "unset [flags] NAME NAME ..."
 	)
 
-	cmd = command.New(usage, short, long, runUnset, command.RequireSession, command.RequireAppName)
+	cmd = command.New(usage, short, long, runUnset, command.RequireSession, command.LoadAppNameIfPresent)
 
 	flag.Add(cmd,
 		sharedFlags,
+		orgFlag,
 	)
 
 	cmd.Args = cobra.MinimumNArgs(1)
@@ -30,6 +34,13 @@ func newUnset() (cmd *cobra.Command) {
 }
 
 func runUnset(ctx context.Context) (err error) {
+	if org := flag.GetOrg(ctx); org != "" {
+		return unsetOrgSecrets(ctx, org, flag.Args(ctx))
+	}
+	if err := requireAppOrOrg(ctx); err != nil {
+		return err
+	}
+
 	client := client.FromContext(ctx).API()
 	appName := appconfig.NameFromContext(ctx)
 	app, err := client.GetAppCompact(ctx, appName)
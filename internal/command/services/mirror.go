@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const trafficProxyModeMirror = "mirror"
+
+func newMirror() *cobra.Command {
+	const (
+		long = `Mirror a share of production HTTP traffic to candidate machines running a
+new image. The candidates' responses are discarded, so risky changes can be
+validated against real traffic before a canary or blue-green deploy.
+
+Mirrored requests carry a Fly-Mirror: 1 header. Candidates share the app's
+secrets and backing services, so requests with side effects are repeated.`
+		short = "Mirror production traffic to candidate machines"
+	)
+
+	cmd := command.New("mirror", short, long, nil)
+
+	cmd.AddCommand(
+		newMirrorStart(),
+		newMirrorStatus(),
+		newMirrorStop(),
+	)
+
+	return cmd
+}
+
+func newMirrorStart() *cobra.Command {
+	const (
+		long = `Launch candidate machines running --image and traffic proxies, one in each
+region of the app's machines, that send the app's HTTP traffic to its machines
+and mirror --percent of it to the candidates. Deploys are refused until
+mirroring stops.`
+		short = "Start mirroring traffic to candidate machines"
+	)
+
+	cmd := command.New("start", short, long, runMirrorStart,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "image",
+			Description: "Image the candidate machines run",
+		},
+		flag.Int{
+			Name:        "percent",
+			Description: "Percentage of requests mirrored to the candidates",
+			Default:     10,
+		},
+		flag.Int{
+			Name:        "count",
+			Description: "Number of candidate machines",
+			Default:     1,
+		},
+	)
+
+	return cmd
+}
+
+func newMirrorStatus() *cobra.Command {
+	const (
+		long  = `Compare the requests, error rates and latency of the app's machines and of the candidates since mirroring started.`
+		short = "Show mirroring stats"
+	)
+
+	cmd := command.New("status", short, long, runMirrorStatus,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newMirrorStop() *cobra.Command {
+	const (
+		long  = `Route traffic straight to the app's machines again and destroy the traffic proxy and candidate machines.`
+		short = "Stop mirroring traffic"
+	)
+
+	cmd := command.New("stop", short, long, runMirrorStop,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runMirrorStart(ctx context.Context) (err error) {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		image    = flag.GetString(ctx, "image")
+		percent  = flag.GetInt(ctx, "percent")
+		count    = flag.GetInt(ctx, "count")
+	)
+
+	switch {
+	case image == "":
+		return errors.New("--image is required")
+	case percent < 1 || percent > 100:
+		return errors.New("--percent must be between 1 and 100")
+	case count < 1:
+		return errors.New("--count must be at least 1")
+	}
+
+	ctx, flapsClient, err := trafficProxyContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if proxies, err := findTrafficProxies(ctx); err != nil {
+		return err
+	} else if len(proxies) > 0 {
+		return fmt.Errorf("a traffic proxy is already running in %s mode", trafficProxyMode(proxies))
+	}
+
+	machines, err := routedMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	candidates := make([]*api.Machine, 0, count)
+	defer func() {
+		if err != nil {
+			destroyMachines(ctx, candidates)
+		}
+	}()
+
+	for i := 0; i < count; i++ {
+		candidateConfig := mach.CloneConfig(machines[0].Config)
+		candidateConfig.Image = image
+		candidateConfig.Services = nil
+		candidateConfig.Mounts = nil
+		candidateConfig.Standbys = nil
+		candidateConfig.Metadata[api.MachineConfigMetadataKeyFlyProcessGroup] = api.MachineProcessGroupFlyAppMirrorCandidate
+		delete(candidateConfig.Metadata, api.MachineConfigMetadataKeyFlyReleaseId)
+		delete(candidateConfig.Metadata, api.MachineConfigMetadataKeyFlyReleaseVersion)
+
+		candidate, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+			Region: machines[0].Region,
+			Config: candidateConfig,
+		})
+		if err != nil {
+			return fmt.Errorf("failed launching candidate machine: %w", err)
+		}
+		candidates = append(candidates, candidate)
+		fmt.Fprintf(io.Out, "Launched candidate machine %s running %s\n", colorize.Bold(candidate.ID), image)
+
+		if err := mach.WaitForStartOrStop(ctx, candidate, "start", 5*time.Minute); err != nil {
+			return fmt.Errorf("candidate machine %s failed to start: %w", candidate.ID, err)
+		}
+	}
+
+	// The app's internal port is used for candidates, which run the same config
+	port := httpInternalPort(machines[0])
+	proxyConfig := trafficProxyConfig{
+		Groups: []upstreamGroup{{
+			Name:    "primary",
+			Servers: lo.Map(machines, func(m *api.Machine, _ int) string { return upstreamServer(m) }),
+			Percent: 100,
+		}},
+		Mirror: &upstreamGroup{
+			Name: mirrorGroup,
+			Servers: lo.Map(candidates, func(m *api.Machine, _ int) string {
+				return fmt.Sprintf("[%s]:%d", m.PrivateIP, port)
+			}),
+			Percent: percent,
+		},
+	}
+
+	proxies, err := launchTrafficProxies(ctx, trafficProxyModeMirror, machines, proxyConfig, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Mirroring %d%% of traffic to %d candidate machine(s) through proxies in %s\n", percent, count, strings.Join(proxiedRegions(proxies), ", "))
+	fmt.Fprintln(io.Out, "Run `fly services mirror status` to compare them and `fly services mirror stop` when done")
+	return nil
+}
+
+func runMirrorStatus(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	ctx, _, err := trafficProxyContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	proxies, err := findTrafficProxies(ctx)
+	if err != nil {
+		return err
+	}
+	if trafficProxyMode(proxies) != trafficProxyModeMirror {
+		return errors.New("traffic is not being mirrored")
+	}
+
+	stats, err := fetchTrafficStats(ctx, proxies)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, stats)
+	}
+
+	return renderTrafficStats(out, stats)
+}
+
+func runMirrorStop(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	ctx, flapsClient, err := trafficProxyContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	proxies, err := findTrafficProxies(ctx)
+	if err != nil {
+		return err
+	}
+	if trafficProxyMode(proxies) != trafficProxyModeMirror {
+		return errors.New("traffic is not being mirrored")
+	}
+
+	if err := stopTrafficProxies(ctx, proxies); err != nil {
+		return err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed listing machines: %w", err)
+	}
+	for _, m := range machines {
+		if m.Config == nil || !m.IsActive() || !m.IsFlyAppsMirrorCandidate() {
+			continue
+		}
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: m.ID, Kill: true}, ""); err != nil {
+			return fmt.Errorf("failed destroying candidate machine %s: %w", m.ID, err)
+		}
+	}
+
+	fmt.Fprintln(io.Out, "Stopped mirroring traffic")
+	return nil
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/apps"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newPorts() *cobra.Command {
+	const (
+		long = `Add or remove ports of the services of the app's machines in place, updating
+the machines one at a time without a deploy, for quick fixes.
+
+Deploys replace the services of machines with the ones of fly.toml, so the
+change is reverted by the next deploy unless fly.toml is changed to match:
+pass --write-config to do so, or answer the prompt.`
+		short = "Change the ports of running machines"
+	)
+
+	cmd := command.New("ports", short, long, nil)
+
+	cmd.AddCommand(
+		newPortsAdd(),
+		newPortsRemove(),
+	)
+
+	return cmd
+}
+
+func portsFlags() flag.Set {
+	return flag.Set{
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.String{
+			Name:        "process-group",
+			Description: "Only change the machines of this process group",
+		},
+		flag.Bool{
+			Name:        "write-config",
+			Description: "Apply the change to the local fly.toml too, without prompting",
+		},
+	}
+}
+
+func newPortsAdd() *cobra.Command {
+	const (
+		long = `Expose a port on the machines of the app, forwarded to --internal-port. The
+port is added to the service of the machines forwarding to --internal-port,
+or to a new TCP service when they have none.`
+		short = "Expose a port on running machines"
+		usage = "add <port>"
+	)
+
+	cmd := command.New(usage, short, long, runPortsAdd,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly services ports add 8443 --internal-port 8080 --handlers tls,http
+  fly services ports add 5432 --process-group db --write-config`
+
+	flag.Add(cmd,
+		portsFlags(),
+		flag.Int{
+			Name:        "internal-port",
+			Description: "Port the machines listen on, the exposed port by default",
+		},
+		flag.StringSlice{
+			Name:        "handlers",
+			Description: "Handlers of the port, such as tls,http. None for raw TCP",
+		},
+	)
+
+	return cmd
+}
+
+func newPortsRemove() *cobra.Command {
+	const (
+		long = `Stop exposing a port on the machines of the app. Services left without ports
+are removed. Ports exposed as part of a port range can't be removed alone.`
+		short = "Stop exposing a port on running machines"
+		usage = "remove <port>"
+	)
+
+	cmd := command.New(usage, short, long, runPortsRemove,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(cmd, portsFlags())
+
+	return cmd
+}
+
+// portChange is a port to add to or remove from services.
+type portChange struct {
+	Remove       bool
+	Port         int
+	InternalPort int
+	Handlers     []string
+}
+
+func (c portChange) String() string {
+	if c.Remove {
+		return fmt.Sprintf("stop exposing port %d", c.Port)
+	}
+	return fmt.Sprintf("expose port %d on internal port %d", c.Port, c.InternalPort)
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, flyerr.Validation(fmt.Errorf("invalid port %s", s))
+	}
+	return port, nil
+}
+
+func runPortsAdd(ctx context.Context) error {
+	port, err := parsePort(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	change := portChange{
+		Port:         port,
+		InternalPort: flag.GetInt(ctx, "internal-port"),
+		Handlers:     lo.Compact(flag.GetStringSlice(ctx, "handlers")),
+	}
+	if change.InternalPort == 0 {
+		change.InternalPort = port
+	}
+	return changePorts(ctx, change)
+}
+
+func runPortsRemove(ctx context.Context) error {
+	port, err := parsePort(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+	return changePorts(ctx, portChange{Remove: true, Port: port})
+}
+
+func changePorts(ctx context.Context, change portChange) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		group   = flag.GetString(ctx, "process-group")
+	)
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if ctx, err = apps.BuildContext(ctx, app); err != nil {
+		return err
+	}
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		machines = lo.Filter(machines, func(m *api.Machine, _ int) bool { return m.ProcessGroup() == group })
+	}
+
+	var pending []*api.Machine
+	for _, m := range machines {
+		config := mach.CloneConfig(m.Config)
+		changed, err := change.applyToMachine(config)
+		if err != nil {
+			return fmt.Errorf("machine %s: %w", m.ID, err)
+		}
+		if !changed {
+			fmt.Fprintf(io.Out, "Machine %s has nothing to change\n", m.ID)
+			continue
+		}
+		pending = append(pending, m)
+	}
+
+	if len(pending) > 0 {
+		msg := fmt.Sprintf("Update %d machines of %s to %s, restarting them one at a time?", len(pending), appName, change)
+		if confirmed, err := confirm(ctx, msg); err != nil || !confirmed {
+			return err
+		}
+
+		for _, m := range pending {
+			if err := updateMachinePorts(ctx, m, change); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(io.Out, "Updated %d machines to %s\n", len(pending), change)
+	}
+
+	return writeBackPorts(ctx, change, group)
+}
+
+func confirm(ctx context.Context, msg string) (bool, error) {
+	if flag.GetYes(ctx) {
+		return true, nil
+	}
+	switch confirmed, err := prompt.Confirm(ctx, msg); {
+	case err == nil:
+		return confirmed, nil
+	case prompt.IsNonInteractive(err):
+		return false, prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+	default:
+		return false, err
+	}
+}
+
+func updateMachinePorts(ctx context.Context, m *api.Machine, change portChange) error {
+	m, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	// The machine may have changed since it was listed
+	config := mach.CloneConfig(m.Config)
+	if changed, err := change.applyToMachine(config); err != nil || !changed {
+		return err
+	}
+
+	return mach.Update(ctx, m, &api.LaunchMachineInput{
+		ID:     m.ID,
+		Region: m.Region,
+		Config: config,
+	})
+}
+
+// writeBackPorts applies change to the local fly.toml when it belongs to the
+// app and --write-config is passed or the user agrees, and warns it's out of
+// sync otherwise.
+func writeBackPorts(ctx context.Context, change portChange, group string) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		local   = appconfig.ConfigFromContext(ctx)
+	)
+
+	const warning = "Warning: fly.toml doesn't match the machines anymore, the next deploy reverts the change unless it's updated\n"
+
+	if local == nil || local.AppName != appName || local.ConfigFilePath() == "" {
+		fmt.Fprint(io.ErrOut, warning)
+		return nil
+	}
+
+	write := flag.GetBool(ctx, "write-config")
+	if !write {
+		confirmed, err := prompt.Confirm(ctx, fmt.Sprintf("Apply the change to %s too?", local.ConfigFilePath()))
+		switch {
+		case prompt.IsNonInteractive(err):
+			fmt.Fprint(io.ErrOut, warning)
+			return nil
+		case err != nil:
+			return err
+		}
+		write = confirmed
+	}
+	if !write {
+		fmt.Fprint(io.ErrOut, warning)
+		return nil
+	}
+
+	changed, err := change.applyToConfig(local, group)
+	if err != nil {
+		fmt.Fprintf(io.ErrOut, "Warning: %s, edit %s by hand to match the machines\n", err, local.ConfigFilePath())
+		return nil
+	}
+	if !changed {
+		fmt.Fprintf(io.Out, "%s already matches\n", local.ConfigFilePath())
+		return nil
+	}
+	if err := local.WriteToDisk(ctx, local.ConfigFilePath()); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.Out, "Updated the services of %s\n", local.ConfigFilePath())
+	return nil
+}
+
+// applyToMachine applies the change to the services of config, reporting
+// whether anything changed.
+func (c portChange) applyToMachine(config *api.MachineConfig) (bool, error) {
+	if c.Remove {
+		services, removed, err := removePort(config.Services, c.Port,
+			func(api.MachineService) bool { return true },
+			func(s api.MachineService) []api.MachinePort { return s.Ports },
+			func(s *api.MachineService, ports []api.MachinePort) { s.Ports = ports },
+		)
+		config.Services = services
+		return removed, err
+	}
+
+	if portExposed(lo.FlatMap(config.Services, func(s api.MachineService, _ int) []api.MachinePort { return s.Ports }), c.Port) {
+		return false, nil
+	}
+	port := api.MachinePort{Port: api.Pointer(c.Port), Handlers: c.Handlers}
+	for i := range config.Services {
+		if s := &config.Services[i]; s.InternalPort == c.InternalPort && s.Protocol == "tcp" {
+			s.Ports = append(s.Ports, port)
+			return true, nil
+		}
+	}
+	config.Services = append(config.Services, api.MachineService{
+		Protocol:     "tcp",
+		InternalPort: c.InternalPort,
+		Ports:        []api.MachinePort{port},
+	})
+	return true, nil
+}
+
+// applyToConfig applies the change to the [[services]] of cfg, of the
+// process group only when group isn't empty, reporting whether anything
+// changed. Ports of [http_service] can't be changed this way.
+func (c portChange) applyToConfig(cfg *appconfig.Config, group string) (bool, error) {
+	inGroup := func(s appconfig.Service) bool {
+		return group == "" || len(s.Processes) == 0 || lo.Contains(s.Processes, group)
+	}
+
+	if c.Remove {
+		if cfg.HTTPService != nil && (c.Port == 80 || c.Port == 443) {
+			return false, fmt.Errorf("port %d is exposed by [http_service]", c.Port)
+		}
+		services, removed, err := removePort(cfg.Services, c.Port, inGroup,
+			func(s appconfig.Service) []api.MachinePort { return s.Ports },
+			func(s *appconfig.Service, ports []api.MachinePort) { s.Ports = ports },
+		)
+		if err != nil || !removed {
+			return false, err
+		}
+		cfg.Services = services
+		return true, nil
+	}
+
+	services := lo.Filter(cfg.AllServices(), func(s appconfig.Service, _ int) bool { return inGroup(s) })
+	if portExposed(lo.FlatMap(services, func(s appconfig.Service, _ int) []api.MachinePort { return s.Ports }), c.Port) {
+		return false, nil
+	}
+	port := api.MachinePort{Port: api.Pointer(c.Port), Handlers: c.Handlers}
+	for i := range cfg.Services {
+		if s := &cfg.Services[i]; s.InternalPort == c.InternalPort && s.Protocol == "tcp" && inGroup(*s) {
+			s.Ports = append(s.Ports, port)
+			return true, nil
+		}
+	}
+	service := appconfig.Service{
+		Protocol:     "tcp",
+		InternalPort: c.InternalPort,
+		Ports:        []api.MachinePort{port},
+	}
+	if group != "" {
+		service.Processes = []string{group}
+	}
+	cfg.Services = append(cfg.Services, service)
+	return true, nil
+}
+
+// portExposed reports whether ports expose port, alone or in a range.
+func portExposed(ports []api.MachinePort, port int) bool {
+	for _, p := range ports {
+		if p.Port != nil && *p.Port == port {
+			return true
+		}
+		if p.StartPort != nil && p.EndPort != nil && *p.StartPort <= port && port <= *p.EndPort {
+			return true
+		}
+	}
+	return false
+}
+
+// removePort removes port from the services matching match, dropping the
+// ones left without ports. It fails when port is only exposed as part of a
+// range.
+func removePort[S any](services []S, port int, match func(S) bool, get func(S) []api.MachinePort, set func(*S, []api.MachinePort)) ([]S, bool, error) {
+	var (
+		result  []S
+		removed bool
+	)
+	for _, s := range services {
+		if !match(s) {
+			result = append(result, s)
+			continue
+		}
+		ports := get(s)
+		kept := lo.Reject(ports, func(p api.MachinePort, _ int) bool { return p.Port != nil && *p.Port == port })
+		if len(kept) == len(ports) {
+			if portExposed(ports, port) {
+				return services, false, fmt.Errorf("port %d is part of a port range", port)
+			}
+			result = append(result, s)
+			continue
+		}
+		removed = true
+		if len(kept) > 0 {
+			set(&s, kept)
+			result = append(result, s)
+		}
+	}
+	return result, removed, nil
+}
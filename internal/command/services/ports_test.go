@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestPortChangeApplyToMachine(t *testing.T) {
+	config := &api.MachineConfig{Services: []api.MachineService{{
+		Protocol:     "tcp",
+		InternalPort: 8080,
+		Ports:        []api.MachinePort{{Port: api.Pointer(80), Handlers: []string{"http"}}},
+	}}}
+
+	changed, err := portChange{Port: 8443, InternalPort: 8080, Handlers: []string{"tls", "http"}}.applyToMachine(config)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, config.Services, 1)
+	assert.Len(t, config.Services[0].Ports, 2)
+
+	changed, err = portChange{Port: 5432, InternalPort: 5432}.applyToMachine(config)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, config.Services, 2)
+	assert.Equal(t, 5432, config.Services[1].InternalPort)
+
+	changed, err = portChange{Port: 5432, InternalPort: 5433}.applyToMachine(config)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = portChange{Remove: true, Port: 5432}.applyToMachine(config)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, config.Services, 1)
+
+	changed, err = portChange{Remove: true, Port: 5432}.applyToMachine(config)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	config.Services[0].Ports = append(config.Services[0].Ports, api.MachinePort{StartPort: api.Pointer(9000), EndPort: api.Pointer(9100)})
+	_, err = portChange{Remove: true, Port: 9050}.applyToMachine(config)
+	assert.Error(t, err)
+}
+
+func TestPortChangeApplyToConfig(t *testing.T) {
+	cfg := &appconfig.Config{
+		HTTPService: &appconfig.HTTPService{InternalPort: 8080},
+		Services: []appconfig.Service{{
+			Protocol:     "tcp",
+			InternalPort: 5432,
+			Processes:    []string{"db"},
+			Ports:        []api.MachinePort{{Port: api.Pointer(5432)}},
+		}},
+	}
+
+	changed, err := portChange{Port: 443, InternalPort: 8080}.applyToConfig(cfg, "")
+	require.NoError(t, err)
+	assert.False(t, changed, "http_service exposes 443")
+
+	changed, err = portChange{Port: 5433, InternalPort: 5432}.applyToConfig(cfg, "db")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, cfg.Services, 1)
+	assert.Len(t, cfg.Services[0].Ports, 2)
+
+	changed, err = portChange{Port: 5433, InternalPort: 5432}.applyToConfig(cfg, "app")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, cfg.Services, 2)
+	assert.Equal(t, []string{"app"}, cfg.Services[1].Processes)
+
+	changed, err = portChange{Remove: true, Port: 5433}.applyToConfig(cfg, "app")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, cfg.Services, 1)
+	assert.Len(t, cfg.Services[0].Ports, 2)
+
+	_, err = portChange{Remove: true, Port: 80}.applyToConfig(cfg, "")
+	assert.Error(t, err)
+}
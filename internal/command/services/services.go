@@ -16,6 +16,9 @@ func New() *cobra.Command {
 
 	services.AddCommand(
 		newList(),
+		newMirror(),
+		newWeights(),
+		newPorts(),
 	)
 
 	return services
@@ -0,0 +1,572 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// The traffic proxy is a set of nginx machines, one in each region the app's
+// machines run in, that take over the app's HTTP services and forward
+// requests to the app's machines over the private network, which lets flyctl
+// mirror or split traffic without platform support. The app's machines are
+// cordoned while the proxy runs so requests only reach them through it. The
+// proxy forwards to the private IPs the machines had when it was configured,
+// so deploys are refused while it runs.
+const (
+	trafficProxyImage = "nginx:1.25-alpine"
+	trafficProxyPort  = 8080
+
+	trafficProxyMetadataMode     = "fly_traffic_proxy_mode"
+	trafficProxyMetadataCordoned = "fly_traffic_proxy_cordoned"
+
+	trafficProxyConfigPath = "/etc/nginx/conf.d/default.conf"
+	trafficProxyStatsPath  = "/fly-traffic-stats.sh"
+
+	// mirrorGroup names the upstream of mirrored requests in stats.
+	mirrorGroup = "mirror"
+)
+
+// trafficStatsScript aggregates the proxy's access logs by upstream group:
+// requests, 5xx responses and average latency in seconds.
+const trafficStatsScript = `cat /var/log/nginx/traffic.log /var/log/nginx/mirror.log 2>/dev/null |
+  awk '{ n[$1]++; if ($2 >= 500) e[$1]++; t[$1] += $3 }
+       END { for (g in n) printf "%s %d %d %.4f\n", g, n[g], e[g], t[g] / n[g] }'
+`
+
+// upstreamGroup is a set of machines receiving a share of the traffic.
+type upstreamGroup struct {
+	Name    string
+	Servers []string
+	Percent int
+}
+
+// trafficProxyConfig describes how the proxy routes requests.
+type trafficProxyConfig struct {
+	Groups []upstreamGroup
+	Mirror *upstreamGroup
+}
+
+var nginxNameRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func upstreamName(group string) string {
+	return "g_" + nginxNameRe.ReplaceAllString(group, "_")
+}
+
+// nginxConfig renders the nginx server config of the traffic proxy.
+func (c trafficProxyConfig) nginxConfig() string {
+	var b strings.Builder
+
+	groups := append([]upstreamGroup{}, c.Groups...)
+	if c.Mirror != nil {
+		groups = append(groups, *c.Mirror)
+	}
+	for _, g := range groups {
+		fmt.Fprintf(&b, "upstream %s {\n", upstreamName(g.Name))
+		for _, s := range g.Servers {
+			fmt.Fprintf(&b, "    server %s;\n", s)
+		}
+		b.WriteString("    keepalive 16;\n}\n\n")
+	}
+
+	b.WriteString("split_clients \"${remote_addr}${request_id}\" $fly_backend {\n")
+	for i, g := range c.Groups {
+		if i == len(c.Groups)-1 {
+			fmt.Fprintf(&b, "    * %s;\n", g.Name)
+		} else {
+			fmt.Fprintf(&b, "    %d%% %s;\n", g.Percent, g.Name)
+		}
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("map $fly_backend $fly_upstream {\n")
+	for _, g := range c.Groups {
+		fmt.Fprintf(&b, "    %s %s;\n", g.Name, upstreamName(g.Name))
+	}
+	b.WriteString("}\n\n")
+
+	if c.Mirror != nil {
+		fmt.Fprintf(&b, "split_clients \"${remote_addr}${request_id}\" $fly_mirror {\n    %d%% 1;\n    * 0;\n}\n\n", c.Mirror.Percent)
+	}
+
+	b.WriteString("log_format fly_traffic '$fly_backend $status $request_time';\n")
+	fmt.Fprintf(&b, "log_format fly_mirror '%s $status $request_time';\n\n", mirrorGroup)
+
+	fmt.Fprintf(&b, "server {\n    listen %d default_server;\n    listen [::]:%d default_server;\n\n", trafficProxyPort, trafficProxyPort)
+
+	b.WriteString("    location / {\n")
+	if c.Mirror != nil {
+		b.WriteString("        mirror /__fly_mirror;\n        mirror_request_body on;\n")
+	}
+	b.WriteString(`        proxy_pass http://$fly_upstream;
+        proxy_http_version 1.1;
+        proxy_set_header Connection "";
+        proxy_set_header Host $host;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        access_log /var/log/nginx/traffic.log fly_traffic;
+    }
+`)
+
+	if c.Mirror != nil {
+		fmt.Fprintf(&b, `
+    location = /__fly_mirror {
+        internal;
+        if ($fly_mirror = 0) {
+            return 204;
+        }
+        proxy_pass http://%s$request_uri;
+        proxy_http_version 1.1;
+        proxy_set_header Connection "";
+        proxy_set_header Host $host;
+        proxy_set_header Fly-Mirror 1;
+        proxy_connect_timeout 2s;
+        proxy_read_timeout 10s;
+        log_subrequest on;
+        access_log /var/log/nginx/mirror.log fly_mirror if=$fly_mirror;
+    }
+`, upstreamName(c.Mirror.Name))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// trafficStat is the traffic an upstream group has served through the proxy.
+type trafficStat struct {
+	Group      string  `json:"group"`
+	Requests   int     `json:"requests"`
+	Errors     int     `json:"errors"`
+	AvgLatency float64 `json:"avg_latency_seconds"`
+}
+
+func (s trafficStat) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// parseTrafficStats parses the output of trafficStatsScript.
+func parseTrafficStats(out string) ([]trafficStat, error) {
+	var stats []trafficStat
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected traffic stats line %q", line)
+		}
+		requests, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected traffic stats line %q", line)
+		}
+		errs, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected traffic stats line %q", line)
+		}
+		latency, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected traffic stats line %q", line)
+		}
+		stats = append(stats, trafficStat{Group: fields[0], Requests: requests, Errors: errs, AvgLatency: latency})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Group < stats[j].Group
+	})
+	return stats, nil
+}
+
+func renderTrafficStats(w io.Writer, stats []trafficStat) error {
+	rows := lo.Map(stats, func(s trafficStat, _ int) []string {
+		return []string{
+			s.Group,
+			strconv.Itoa(s.Requests),
+			strconv.Itoa(s.Errors),
+			fmt.Sprintf("%.2f%%", s.ErrorRate()*100),
+			fmt.Sprintf("%.0fms", s.AvgLatency*1000),
+		}
+	})
+	return render.Table(w, "", rows, "Group", "Requests", "5xx", "Error Rate", "Avg Latency")
+}
+
+func trafficProxyContext(ctx context.Context) (context.Context, *flaps.Client, error) {
+	appName := appconfig.NameFromContext(ctx)
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return nil, nil, errors.New("traffic mirroring and splitting are only supported for machines apps")
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flaps.NewContext(ctx, flapsClient), flapsClient, nil
+}
+
+// findTrafficProxies returns the app's traffic proxy machines.
+func findTrafficProxies(ctx context.Context) ([]*api.Machine, error) {
+	machines, err := flaps.FromContext(ctx).List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed listing machines: %w", err)
+	}
+
+	return lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return m.Config != nil && m.IsActive() && m.IsFlyAppsTrafficProxy()
+	}), nil
+}
+
+// trafficProxyMode returns the mode the proxies run in, or an empty string
+// when there are none.
+func trafficProxyMode(proxies []*api.Machine) string {
+	if len(proxies) == 0 {
+		return ""
+	}
+	return proxies[0].Config.Metadata[trafficProxyMetadataMode]
+}
+
+// cordonedMachines returns the IDs of the machines the proxies cordoned.
+func cordonedMachines(proxies []*api.Machine) []string {
+	var ids []string
+	for _, proxy := range proxies {
+		ids = append(ids, strings.Split(proxy.Config.Metadata[trafficProxyMetadataCordoned], ",")...)
+	}
+	return lo.Compact(lo.Uniq(ids))
+}
+
+// proxiedRegions returns the regions machines run in, sorted.
+func proxiedRegions(machines []*api.Machine) []string {
+	regions := lo.Uniq(lo.Map(machines, func(m *api.Machine, _ int) string { return m.Region }))
+	sort.Strings(regions)
+	return regions
+}
+
+func isHTTPService(s api.MachineService) bool {
+	return lo.SomeBy(s.Ports, func(p api.MachinePort) bool {
+		return lo.Contains(p.Handlers, "http")
+	})
+}
+
+// httpInternalPort returns the internal port of the machine's first HTTP
+// service, or 0 if it has none.
+func httpInternalPort(m *api.Machine) int {
+	s, _ := lo.Find(m.Config.Services, isHTTPService)
+	return s.InternalPort
+}
+
+// routedMachines returns the app's machines serving HTTP.
+func routedMachines(ctx context.Context) ([]*api.Machine, error) {
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing machines: %w", err)
+	}
+
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return httpInternalPort(m) != 0
+	})
+	if len(machines) == 0 {
+		return nil, errors.New("app has no machines with HTTP services")
+	}
+	return machines, nil
+}
+
+// upstreamServer returns the private network address the proxy reaches m at.
+func upstreamServer(m *api.Machine) string {
+	return fmt.Sprintf("[%s]:%d", m.PrivateIP, httpInternalPort(m))
+}
+
+func trafficProxyFiles(config trafficProxyConfig) []*api.File {
+	return []*api.File{
+		{
+			GuestPath: trafficProxyConfigPath,
+			RawValue:  api.Pointer(base64.StdEncoding.EncodeToString([]byte(config.nginxConfig()))),
+		},
+		{
+			GuestPath: trafficProxyStatsPath,
+			RawValue:  api.Pointer(base64.StdEncoding.EncodeToString([]byte(trafficStatsScript))),
+		},
+	}
+}
+
+// trafficProxyServices returns the HTTP services of machines, pointed at the
+// proxy.
+func trafficProxyServices(machines []*api.Machine) []api.MachineService {
+	var services []api.MachineService
+	for _, m := range machines {
+		for _, s := range m.Config.Services {
+			if !isHTTPService(s) {
+				continue
+			}
+			s := *helpers.Clone(&s)
+			s.InternalPort = trafficProxyPort
+			s.Checks = nil
+			s.Autostop = api.Pointer(false)
+			s.Autostart = api.Pointer(true)
+			s.MinMachinesRunning = nil
+			services = append(services, s)
+		}
+	}
+	return lo.UniqBy(services, func(s api.MachineService) string {
+		return fmt.Sprintf("%s/%v", s.Protocol, lo.Map(s.Ports, func(p api.MachinePort, _ int) int {
+			return lo.FromPtr(p.Port)
+		}))
+	})
+}
+
+func trafficProxyMachineConfig(machines []*api.Machine, config trafficProxyConfig, metadata map[string]string) *api.MachineConfig {
+	return &api.MachineConfig{
+		Image: trafficProxyImage,
+		Metadata: lo.Assign(metadata, map[string]string{
+			api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
+			api.MachineConfigMetadataKeyFlyProcessGroup:    api.MachineProcessGroupFlyAppTrafficProxy,
+		}),
+		Services: trafficProxyServices(machines),
+		Guest:    helpers.Clone(api.MachinePresets["shared-cpu-1x"]),
+		Files:    trafficProxyFiles(config),
+		Restart: api.MachineRestart{
+			Policy: api.MachineRestartPolicyAlways,
+		},
+	}
+}
+
+// launchProxyMachines launches a proxy in each of regions and waits for them
+// to start. When one fails, those launched are destroyed.
+func launchProxyMachines(ctx context.Context, regions []string, config *api.MachineConfig) (proxies []*api.Machine, err error) {
+	var (
+		io          = iostreams.FromContext(ctx)
+		colorize    = io.ColorScheme()
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	defer func() {
+		if err != nil {
+			destroyMachines(ctx, proxies)
+		}
+	}()
+
+	for _, region := range regions {
+		proxy, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+			Region: region,
+			Config: mach.CloneConfig(config),
+		})
+		if err != nil {
+			return proxies, fmt.Errorf("failed launching traffic proxy in %s: %w", region, err)
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	for _, proxy := range proxies {
+		fmt.Fprintf(io.Out, "Waiting for traffic proxy %s in %s to start\n", colorize.Bold(proxy.ID), proxy.Region)
+		if err := mach.WaitForStartOrStop(ctx, proxy, "start", 2*time.Minute); err != nil {
+			return proxies, fmt.Errorf("traffic proxy %s failed to start: %w", proxy.ID, err)
+		}
+	}
+
+	return proxies, nil
+}
+
+// destroyMachines destroys the machines of a command that failed, warning
+// about those left behind.
+func destroyMachines(ctx context.Context, machines []*api.Machine) {
+	var (
+		io          = iostreams.FromContext(ctx)
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	for _, m := range machines {
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: m.ID, Kill: true}, ""); err != nil {
+			fmt.Fprintf(io.ErrOut, "Warning: failed destroying machine %s, remove it with fly machine destroy --force %s: %v\n", m.ID, m.ID, err)
+		}
+	}
+}
+
+// uncordonMachines puts machines cordoned by a command that failed back in
+// service, warning about those left out.
+func uncordonMachines(ctx context.Context, ids []string) {
+	var (
+		io          = iostreams.FromContext(ctx)
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	for _, id := range ids {
+		if err := flapsClient.UnCordon(ctx, id); err != nil {
+			fmt.Fprintf(io.ErrOut, "Warning: failed putting machine %s back in service: %v\n", id, err)
+		}
+	}
+}
+
+// launchTrafficProxies launches proxies routing requests per config in front
+// of machines, one in each of their regions, then cordons the machines.
+// metadata is recorded on the proxy machines. When it fails, the proxies are
+// destroyed and the machines put back in service.
+func launchTrafficProxies(ctx context.Context, mode string, machines []*api.Machine, config trafficProxyConfig, metadata map[string]string) ([]*api.Machine, error) {
+	flapsClient := flaps.FromContext(ctx)
+
+	ids := lo.Map(machines, func(m *api.Machine, _ int) string { return m.ID })
+
+	machineConfig := trafficProxyMachineConfig(machines, config, lo.Assign(metadata, map[string]string{
+		trafficProxyMetadataMode:     mode,
+		trafficProxyMetadataCordoned: strings.Join(ids, ","),
+	}))
+
+	proxies, err := launchProxyMachines(ctx, proxiedRegions(machines), machineConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var cordoned []string
+	for _, id := range ids {
+		if err := flapsClient.Cordon(ctx, id); err != nil {
+			uncordonMachines(ctx, cordoned)
+			destroyMachines(ctx, proxies)
+			return nil, fmt.Errorf("failed routing machine %s through the traffic proxy: %w", id, err)
+		}
+		cordoned = append(cordoned, id)
+	}
+
+	return proxies, nil
+}
+
+// updateTrafficProxies replaces the routing config of running proxies in
+// front of machines, along with the given metadata. Machines launched since
+// the proxies started are cordoned too, and proxies are launched in their
+// regions when none runs there.
+func updateTrafficProxies(ctx context.Context, proxies []*api.Machine, machines []*api.Machine, config trafficProxyConfig, metadata map[string]string) error {
+	flapsClient := flaps.FromContext(ctx)
+
+	cordoned := cordonedMachines(proxies)
+	uncordoned := lo.Filter(machines, func(m *api.Machine, _ int) bool { return !lo.Contains(cordoned, m.ID) })
+	cordoned = append(cordoned, lo.Map(uncordoned, func(m *api.Machine, _ int) string { return m.ID })...)
+
+	metadata = lo.Assign(metadata, map[string]string{
+		trafficProxyMetadataMode:     trafficProxyMode(proxies),
+		trafficProxyMetadataCordoned: strings.Join(cordoned, ","),
+	})
+
+	missing, _ := lo.Difference(proxiedRegions(machines), proxiedRegions(proxies))
+	if len(missing) > 0 {
+		if _, err := launchProxyMachines(ctx, missing, trafficProxyMachineConfig(machines, config, metadata)); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range uncordoned {
+		if err := flapsClient.Cordon(ctx, m.ID); err != nil {
+			return fmt.Errorf("failed routing machine %s through the traffic proxy: %w", m.ID, err)
+		}
+	}
+
+	for _, proxy := range proxies {
+		if err := updateTrafficProxy(ctx, proxy, config, metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updateTrafficProxy(ctx context.Context, proxy *api.Machine, config trafficProxyConfig, metadata map[string]string) error {
+	proxy, releaseLeaseFunc, err := mach.AcquireLease(ctx, proxy)
+	defer releaseLeaseFunc(ctx, proxy)
+	if err != nil {
+		return err
+	}
+
+	machineConfig := mach.CloneConfig(proxy.Config)
+	machineConfig.Files = trafficProxyFiles(config)
+	machineConfig.Metadata = lo.Assign(machineConfig.Metadata, metadata)
+
+	return mach.Update(ctx, proxy, &api.LaunchMachineInput{
+		ID:     proxy.ID,
+		Region: proxy.Region,
+		Config: machineConfig,
+	})
+}
+
+// stopTrafficProxies puts the machines cordoned by the proxies back in
+// service and destroys them.
+func stopTrafficProxies(ctx context.Context, proxies []*api.Machine) error {
+	flapsClient := flaps.FromContext(ctx)
+
+	for _, id := range cordonedMachines(proxies) {
+		if err := flapsClient.UnCordon(ctx, id); err != nil {
+			return fmt.Errorf("failed putting machine %s back in service: %w", id, err)
+		}
+	}
+
+	for _, proxy := range proxies {
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: proxy.ID, Kill: true}, ""); err != nil {
+			return fmt.Errorf("failed destroying traffic proxy %s: %w", proxy.ID, err)
+		}
+	}
+	return nil
+}
+
+// fetchTrafficStats returns the traffic served through the proxies so far.
+func fetchTrafficStats(ctx context.Context, proxies []*api.Machine) ([]trafficStat, error) {
+	var stats []trafficStat
+	for _, proxy := range proxies {
+		out, err := flaps.FromContext(ctx).Exec(ctx, proxy.ID, &api.MachineExecRequest{
+			Cmd:     "sh " + trafficProxyStatsPath,
+			Timeout: 30,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving traffic stats from %s: %w", proxy.ID, err)
+		}
+		if out.ExitCode != 0 {
+			return nil, fmt.Errorf("failed retrieving traffic stats from %s: %s", proxy.ID, out.StdErr)
+		}
+		proxyStats, err := parseTrafficStats(out.StdOut)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, proxyStats...)
+	}
+	return mergeTrafficStats(stats), nil
+}
+
+// mergeTrafficStats sums the stats of each group across proxies.
+func mergeTrafficStats(stats []trafficStat) []trafficStat {
+	var merged []trafficStat
+	for group, groupStats := range lo.GroupBy(stats, func(s trafficStat) string { return s.Group }) {
+		total := trafficStat{Group: group}
+		var latency float64
+		for _, s := range groupStats {
+			total.Requests += s.Requests
+			total.Errors += s.Errors
+			latency += s.AvgLatency * float64(s.Requests)
+		}
+		if total.Requests > 0 {
+			total.AvgLatency = latency / float64(total.Requests)
+		}
+		merged = append(merged, total)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Group < merged[j].Group
+	})
+	return merged
+}
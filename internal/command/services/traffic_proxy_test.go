@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestTrafficProxyConfig_Mirror(t *testing.T) {
+	config := trafficProxyConfig{
+		Groups: []upstreamGroup{{Name: "primary", Servers: []string{"[fdaa::1]:8080", "[fdaa::2]:8080"}, Percent: 100}},
+		Mirror: &upstreamGroup{Name: mirrorGroup, Servers: []string{"[fdaa::3]:8080"}, Percent: 10},
+	}
+
+	conf := config.nginxConfig()
+
+	assert.Contains(t, conf, "upstream g_primary {\n    server [fdaa::1]:8080;\n    server [fdaa::2]:8080;\n")
+	assert.Contains(t, conf, "upstream g_mirror {\n    server [fdaa::3]:8080;\n")
+	assert.Contains(t, conf, "$fly_backend {\n    * primary;\n}")
+	assert.Contains(t, conf, "$fly_mirror {\n    10% 1;\n    * 0;\n}")
+	assert.Contains(t, conf, "mirror /__fly_mirror;")
+	assert.Contains(t, conf, "proxy_pass http://g_mirror$request_uri;")
+}
+
+func TestTrafficProxyConfig_Split(t *testing.T) {
+	config := trafficProxyConfig{
+		Groups: []upstreamGroup{
+			{Name: "green", Servers: []string{"[fdaa::1]:8080"}, Percent: 10},
+			{Name: "blue-1", Servers: []string{"[fdaa::2]:8080"}, Percent: 90},
+		},
+	}
+
+	conf := config.nginxConfig()
+
+	assert.Contains(t, conf, "$fly_backend {\n    10% green;\n    * blue-1;\n}")
+	assert.Contains(t, conf, "    blue-1 g_blue_1;\n")
+	assert.NotContains(t, conf, "/__fly_mirror")
+}
+
+func TestMergeTrafficStats(t *testing.T) {
+	stats := mergeTrafficStats([]trafficStat{
+		{Group: "primary", Requests: 100, Errors: 1, AvgLatency: 0.01},
+		{Group: "mirror", Requests: 10, Errors: 0, AvgLatency: 0.05},
+		{Group: "primary", Requests: 300, Errors: 3, AvgLatency: 0.03},
+	})
+
+	assert.Equal(t, []trafficStat{
+		{Group: "mirror", Requests: 10, AvgLatency: 0.05},
+		{Group: "primary", Requests: 400, Errors: 4, AvgLatency: 0.025},
+	}, stats)
+}
+
+func TestTrafficProxyMachines(t *testing.T) {
+	newProxy := func(region, cordoned string) *api.Machine {
+		return &api.Machine{Region: region, Config: &api.MachineConfig{Metadata: map[string]string{
+			trafficProxyMetadataMode:     trafficProxyModeWeights,
+			trafficProxyMetadataCordoned: cordoned,
+		}}}
+	}
+	proxies := []*api.Machine{newProxy("ord", "m1,m2"), newProxy("ams", "m1,m2,m3")}
+
+	assert.Equal(t, []string{"ams", "ord"}, proxiedRegions(proxies))
+	assert.Equal(t, []string{"m1", "m2", "m3"}, cordonedMachines(proxies))
+	assert.Equal(t, trafficProxyModeWeights, trafficProxyMode(proxies))
+	assert.Equal(t, "", trafficProxyMode(nil))
+}
+
+func TestParseTrafficStats(t *testing.T) {
+	stats, err := parseTrafficStats("primary 200 2 0.0150\nmirror 20 1 0.0300\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, []trafficStat{
+		{Group: "mirror", Requests: 20, Errors: 1, AvgLatency: 0.03},
+		{Group: "primary", Requests: 200, Errors: 2, AvgLatency: 0.015},
+	}, stats)
+	assert.Equal(t, 0.05, stats[0].ErrorRate())
+
+	stats, err = parseTrafficStats("")
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+
+	_, err = parseTrafficStats("primary two 0 0")
+	assert.Error(t, err)
+}
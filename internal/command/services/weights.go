@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	trafficProxyModeWeights = "weights"
+
+	trafficProxyMetadataWeights = "fly_traffic_proxy_weights"
+)
+
+func newWeights() *cobra.Command {
+	const (
+		long = `Split the app's HTTP traffic between process groups or releases by weight,
+for gradual cutovers such as moving traffic from the machines of one release
+to those of the next after a blue-green deploy.`
+		short = "Split traffic between process groups or releases"
+	)
+
+	cmd := command.New("weights", short, long, nil)
+
+	cmd.AddCommand(
+		newWeightsSet(),
+		newWeightsShow(),
+		newWeightsReset(),
+	)
+
+	return cmd
+}
+
+func newWeightsSet() *cobra.Command {
+	const (
+		long = `Set the share of traffic each group of machines receives, in percent.
+A group is either a process group name, or a release version such as v12 to
+select the machines running that release. Weights must add up to 100, and
+machines outside of the weighted groups receive no traffic.
+
+Traffic is split by proxy machines in front of the app's machines, one in each
+of their regions, launched the first time weights are set. Deploys are refused
+until weights are reset.`
+		short = "Set traffic weights"
+		usage = "set GROUP=WEIGHT GROUP=WEIGHT ..."
+	)
+
+	cmd := command.New(usage, short, long, runWeightsSet,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Example = `  fly services weights set v12=90 v13=10
+  fly services weights set app=50 canary=50`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func newWeightsShow() *cobra.Command {
+	const (
+		long  = `Show the traffic weights of the app along with the requests, error rates and latency of each group.`
+		short = "Show traffic weights"
+	)
+
+	cmd := command.New("show", short, long, runWeightsShow,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"status"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newWeightsReset() *cobra.Command {
+	const (
+		long  = `Route traffic straight to all of the app's machines again and destroy the traffic proxy.`
+		short = "Remove traffic weights"
+	)
+
+	cmd := command.New("reset", short, long, runWeightsReset,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+// groupWeight is the share of traffic a group of machines receives.
+type groupWeight struct {
+	Group  string `json:"group"`
+	Weight int    `json:"weight"`
+}
+
+// parseWeights parses GROUP=WEIGHT arguments, keeping their order.
+func parseWeights(args []string) ([]groupWeight, error) {
+	var (
+		weights []groupWeight
+		total   int
+	)
+	for _, arg := range args {
+		for _, pair := range strings.Split(arg, ",") {
+			group, value, ok := strings.Cut(pair, "=")
+			if !ok || group == "" {
+				return nil, fmt.Errorf("invalid weight %q, expected GROUP=WEIGHT", pair)
+			}
+			weight, err := strconv.Atoi(value)
+			if err != nil || weight < 0 || weight > 100 {
+				return nil, fmt.Errorf("invalid weight %q, expected a percentage between 0 and 100", pair)
+			}
+			if lo.ContainsBy(weights, func(w groupWeight) bool { return w.Group == group }) {
+				return nil, fmt.Errorf("group %s is weighted more than once", group)
+			}
+			weights = append(weights, groupWeight{Group: group, Weight: weight})
+			total += weight
+		}
+	}
+
+	if total != 100 {
+		return nil, fmt.Errorf("weights add up to %d, they must add up to 100", total)
+	}
+	return weights, nil
+}
+
+func formatWeights(weights []groupWeight) string {
+	return strings.Join(lo.Map(weights, func(w groupWeight, _ int) string {
+		return fmt.Sprintf("%s=%d", w.Group, w.Weight)
+	}), ",")
+}
+
+// groupMachines returns the machines a group selects: those running release
+// vN, or those of the process group of that name.
+func groupMachines(machines []*api.Machine, group string) []*api.Machine {
+	if version, err := strconv.Atoi(strings.TrimPrefix(group, "v")); err == nil && strings.HasPrefix(group, "v") {
+		return lo.Filter(machines, func(m *api.Machine, _ int) bool {
+			return m.Config.Metadata[api.MachineConfigMetadataKeyFlyReleaseVersion] == strconv.Itoa(version)
+		})
+	}
+	return lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return m.ProcessGroup() == group
+	})
+}
+
+// weightedProxyConfig returns the proxy config splitting traffic between the
+// groups of machines by weight.
+func weightedProxyConfig(machines []*api.Machine, weights []groupWeight) (trafficProxyConfig, error) {
+	var config trafficProxyConfig
+	for _, w := range weights {
+		selected := groupMachines(machines, w.Group)
+		if len(selected) == 0 {
+			return config, fmt.Errorf("no machines serving HTTP match group %s", w.Group)
+		}
+		if w.Weight == 0 {
+			continue
+		}
+		config.Groups = append(config.Groups, upstreamGroup{
+			Name:    w.Group,
+			Servers: lo.Map(selected, func(m *api.Machine, _ int) string { return upstreamServer(m) }),
+			Percent: w.Weight,
+		})
+	}
+	return config, nil
+}
+
+func runWeightsSet(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	weights, err := parseWeights(flag.Args(ctx))
+	if err != nil {
+		return err
+	}
+
+	ctx, _, err = trafficProxyContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	proxies, err := findTrafficProxies(ctx)
+	if err != nil {
+		return err
+	}
+	if mode := trafficProxyMode(proxies); mode != "" && mode != trafficProxyModeWeights {
+		return fmt.Errorf("a traffic proxy is already running in %s mode", mode)
+	}
+
+	machines, err := routedMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	proxyConfig, err := weightedProxyConfig(machines, weights)
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]string{trafficProxyMetadataWeights: formatWeights(weights)}
+	if len(proxies) == 0 {
+		if _, err := launchTrafficProxies(ctx, trafficProxyModeWeights, machines, proxyConfig, metadata); err != nil {
+			return err
+		}
+	} else if err := updateTrafficProxies(ctx, proxies, machines, proxyConfig, metadata); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Traffic weights set to %s\n", formatWeights(weights))
+	return nil
+}
+
+func runWeightsShow(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	ctx, _, err := trafficProxyContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	proxies, err := findTrafficProxies(ctx)
+	if err != nil {
+		return err
+	}
+	if trafficProxyMode(proxies) != trafficProxyModeWeights {
+		fmt.Fprintln(out, "No traffic weights are set, traffic is balanced across all machines")
+		return nil
+	}
+
+	weights, err := parseWeights([]string{proxies[0].Config.Metadata[trafficProxyMetadataWeights]})
+	if err != nil {
+		return fmt.Errorf("traffic proxy %s has invalid weights: %w", proxies[0].ID, err)
+	}
+
+	stats, err := fetchTrafficStats(ctx, proxies)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, struct {
+			Weights []groupWeight `json:"weights"`
+			Stats   []trafficStat `json:"stats"`
+		}{weights, stats})
+	}
+
+	rows := lo.Map(weights, func(w groupWeight, _ int) []string {
+		s, _ := lo.Find(stats, func(s trafficStat) bool { return s.Group == w.Group })
+		return []string{
+			w.Group,
+			fmt.Sprintf("%d%%", w.Weight),
+			strconv.Itoa(s.Requests),
+			fmt.Sprintf("%.2f%%", s.ErrorRate()*100),
+			fmt.Sprintf("%.0fms", s.AvgLatency*1000),
+		}
+	})
+
+	return render.Table(out, fmt.Sprintf("Traffic weights (proxies in %s)", strings.Join(proxiedRegions(proxies), ", ")), rows, "Group", "Weight", "Requests", "Error Rate", "Avg Latency")
+}
+
+func runWeightsReset(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	ctx, _, err := trafficProxyContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	proxies, err := findTrafficProxies(ctx)
+	if err != nil {
+		return err
+	}
+	if trafficProxyMode(proxies) != trafficProxyModeWeights {
+		return errors.New("no traffic weights are set")
+	}
+
+	if err := stopTrafficProxies(ctx, proxies); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(io.Out, "Traffic weights removed, traffic is balanced across all machines again")
+	return nil
+}
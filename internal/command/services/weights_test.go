@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestParseWeights(t *testing.T) {
+	weights, err := parseWeights([]string{"green=10", "blue=90"})
+	require.NoError(t, err)
+	assert.Equal(t, []groupWeight{{"green", 10}, {"blue", 90}}, weights)
+	assert.Equal(t, "green=10,blue=90", formatWeights(weights))
+
+	weights, err = parseWeights([]string{"green=10,blue=90"})
+	require.NoError(t, err)
+	assert.Len(t, weights, 2)
+
+	for _, args := range [][]string{
+		{"green=10", "blue=80"},
+		{"green=10", "green=90"},
+		{"green"},
+		{"green=-10", "blue=110"},
+		{"=100"},
+	} {
+		_, err := parseWeights(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestGroupMachines(t *testing.T) {
+	newMachine := func(id, group, version string) *api.Machine {
+		return &api.Machine{ID: id, Config: &api.MachineConfig{Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyProcessGroup:   group,
+			api.MachineConfigMetadataKeyFlyReleaseVersion: version,
+		}}}
+	}
+	machines := []*api.Machine{
+		newMachine("m1", "app", "12"),
+		newMachine("m2", "app", "13"),
+		newMachine("m3", "canary", "13"),
+	}
+	ids := func(ms []*api.Machine) []string {
+		return lo.Map(ms, func(m *api.Machine, _ int) string { return m.ID })
+	}
+
+	assert.Equal(t, []string{"m1", "m2"}, ids(groupMachines(machines, "app")))
+	assert.Equal(t, []string{"m2", "m3"}, ids(groupMachines(machines, "v13")))
+	assert.Empty(t, groupMachines(machines, "v14"))
+	assert.Empty(t, groupMachines(machines, "worker"))
+}
@@ -0,0 +1,63 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newOfflineQueue() *cobra.Command {
+	const long = `Control whether secrets set and scale count are queued locally when the
+Fly.io API is unreachable, to be applied later with fly queue flush.`
+
+	queueRoot := command.New("offline-queue", "Control queueing of mutations while offline", long, runOfflineQueueStatus)
+
+	enable := command.New("enable", "Enable the offline queue", "", func(ctx context.Context) error {
+		return setOfflineQueue(ctx, true)
+	})
+	disable := command.New("disable", "Disable the offline queue", "", func(ctx context.Context) error {
+		return setOfflineQueue(ctx, false)
+	})
+
+	queueRoot.AddCommand(enable)
+	queueRoot.AddCommand(disable)
+
+	return queueRoot
+}
+
+func printOfflineQueueEnabled(ctx context.Context, enabled bool) {
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.Out, "Offline queue: %s\n", lo.Ternary(enabled, "enabled", "disabled"))
+}
+
+func runOfflineQueueStatus(ctx context.Context) error {
+	var (
+		cfg = config.FromContext(ctx)
+		io  = iostreams.FromContext(ctx)
+	)
+
+	printOfflineQueueEnabled(ctx, cfg.OfflineQueue)
+
+	fmt.Fprintf(io.Out, "\nThis can be controlled with 'fly settings offline-queue <enable/disable>'\n")
+
+	return nil
+}
+
+func setOfflineQueue(ctx context.Context, enabled bool) error {
+	path := state.ConfigFile(ctx)
+
+	if err := config.SetOfflineQueue(path, enabled); err != nil {
+		return fmt.Errorf("failed persisting %s in %s: %w\n",
+			config.OfflineQueueFileKey, path, err)
+	}
+
+	printOfflineQueueEnabled(ctx, enabled)
+
+	return nil
+}
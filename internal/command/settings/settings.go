@@ -10,6 +10,7 @@ func New() *cobra.Command {
 
 	cmd.AddCommand(
 		newAnalytics(),
+		newOfflineQueue(),
 	)
 
 	return cmd
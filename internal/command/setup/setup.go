@@ -0,0 +1,329 @@
+// Package setup implements the setup command, a wizard reconfiguring existing
+// apps.
+package setup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/launch"
+	"github.com/superfly/flyctl/internal/command/logs"
+	"github.com/superfly/flyctl/internal/command/scale"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new setup Command.
+func New() *cobra.Command {
+	const (
+		long = `Walk through the setup of an existing app again, like fly launch does for
+new apps: its regions, the number of machines of each process group, its
+Postgres and Redis attachments, and log shipping.
+
+The current values are shown as defaults, and only the items which changed are
+applied, after confirmation.`
+		short = "Reconfigure an existing app"
+	)
+
+	cmd := command.New("setup", short, long, run,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+// state is the part of the setup of an app the wizard walks through.
+type state struct {
+	Regions     []string
+	Counts      map[string]int
+	Postgres    bool
+	Redis       bool
+	LogProvider string
+}
+
+// change is an item of the setup to apply.
+type change struct {
+	description string
+	apply       func(ctx context.Context) error
+}
+
+func run(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	if !io.IsInteractive() {
+		return prompt.NonInteractiveError("fly setup must be run interactively")
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("only apps on the machines platform can be set up again")
+	}
+
+	appConfig, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	current, err := currentState(ctx, app, appConfig)
+	if err != nil {
+		return err
+	}
+
+	desired, err := askState(ctx, app, appConfig, current)
+	if err != nil {
+		return err
+	}
+
+	org := &api.Organization{
+		ID:       app.Organization.ID,
+		Name:     app.Organization.Name,
+		Slug:     app.Organization.Slug,
+		RawSlug:  app.Organization.RawSlug,
+		PaidPlan: app.Organization.PaidPlan,
+	}
+	changes := planChanges(appName, org, appConfig.PrimaryRegion, current, desired)
+	if len(changes) == 0 {
+		fmt.Fprintf(io.Out, "Nothing changed, %s is set up already\n", appName)
+		return nil
+	}
+
+	fmt.Fprintf(io.Out, "\nChanges to %s:\n", appName)
+	for _, c := range changes {
+		fmt.Fprintf(io.Out, "  - %s\n", c.description)
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirm(ctx, "Apply these changes?"); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	for _, c := range changes {
+		fmt.Fprintf(io.Out, "\n%s\n", io.ColorScheme().Bold(c.description))
+		if err := c.apply(ctx); err != nil {
+			return fmt.Errorf("failed to %s: %w", lowerFirst(c.description), err)
+		}
+	}
+	return nil
+}
+
+// currentState returns how app is set up.
+func currentState(ctx context.Context, app *api.AppCompact, appConfig *appconfig.Config) (*state, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &state{Counts: map[string]int{}}
+	s.Regions = lo.Uniq(lo.Map(machines, func(m *api.Machine, _ int) string { return m.Region }))
+	slices.Sort(s.Regions)
+	if len(s.Regions) == 0 && appConfig.PrimaryRegion != "" {
+		s.Regions = []string{appConfig.PrimaryRegion}
+	}
+	for _, group := range appConfig.ProcessNames() {
+		s.Counts[group] = 0
+	}
+	for _, m := range machines {
+		s.Counts[m.ProcessGroup()]++
+	}
+
+	secrets, err := apiClient.GetAppSecrets(ctx, app.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		switch secret.Name {
+		case "DATABASE_URL":
+			s.Postgres = true
+		case "REDIS_URL":
+			s.Redis = true
+		}
+	}
+
+	// Only the add-on of auto-provisioned providers tells where logs are
+	// shipped.
+	if _, err := gql.GetAddOn(ctx, apiClient.GenqClient, logs.LoggerAddOnName(app.Name)); err == nil {
+		s.LogProvider = "logtail"
+	}
+
+	return s, nil
+}
+
+// askState walks through the setup of app, with current as defaults.
+func askState(ctx context.Context, app *api.AppCompact, appConfig *appconfig.Config, current *state) (*state, error) {
+	io := iostreams.FromContext(ctx)
+	desired := &state{
+		Counts:      map[string]int{},
+		Postgres:    current.Postgres,
+		Redis:       current.Redis,
+		LogProvider: current.LogProvider,
+	}
+
+	regions, err := prompt.MultiRegion(ctx, "Regions to run machines in:", !app.Organization.PaidPlan, current.Regions, nil)
+	if err != nil {
+		return nil, err
+	}
+	desired.Regions = lo.Map(*regions, func(r api.Region, _ int) string { return r.Code })
+	slices.Sort(desired.Regions)
+	if len(desired.Regions) == 0 {
+		desired.Regions = current.Regions
+	}
+
+	for _, group := range appConfig.ProcessNames() {
+		count := current.Counts[group]
+		if err := prompt.Int(ctx, &count, fmt.Sprintf("Machines of process group %s:", group), count, true); err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("the number of machines of %s can't be negative", group)
+		}
+		desired.Counts[group] = count
+	}
+
+	if current.Postgres {
+		fmt.Fprintln(io.Out, "Postgres: attached")
+	} else if desired.Postgres, err = prompt.Confirm(ctx, "Create and attach a Postgres cluster?"); err != nil {
+		return nil, err
+	}
+
+	if current.Redis {
+		fmt.Fprintln(io.Out, "Redis: attached")
+	} else if desired.Redis, err = prompt.Confirm(ctx, "Create and attach an Upstash Redis database?"); err != nil {
+		return nil, err
+	}
+
+	if current.LogProvider != "" {
+		fmt.Fprintf(io.Out, "Log shipping: %s\n", current.LogProvider)
+	} else {
+		ship, err := prompt.Confirm(ctx, "Ship logs to a third-party provider?")
+		if err != nil {
+			return nil, err
+		}
+		if ship {
+			slugs := logs.ProviderSlugs()
+			var index int
+			if err := prompt.Select(ctx, &index, "Provider:", slugs[0], slugs...); err != nil {
+				return nil, err
+			}
+			desired.LogProvider = slugs[index]
+		}
+	}
+
+	return desired, nil
+}
+
+// planChanges returns the changes applying desired to the app, set up as
+// current.
+func planChanges(appName string, org *api.Organization, primaryRegion string, current, desired *state) []change {
+	var changes []change
+
+	removedRegions := lo.Without(current.Regions, desired.Regions...)
+	regionsChanged := !slices.Equal(current.Regions, desired.Regions)
+
+	groups := lo.Keys(desired.Counts)
+	slices.Sort(groups)
+	for _, group := range groups {
+		group, count := group, desired.Counts[group]
+		if count == current.Counts[group] && !regionsChanged {
+			continue
+		}
+		if count == 0 && current.Counts[group] == 0 {
+			continue
+		}
+
+		changes = append(changes, change{
+			description: fmt.Sprintf("Scale process group %s to %d machines in %s", group, count, strings.Join(desired.Regions, ", ")),
+			apply: func(ctx context.Context) error {
+				if len(removedRegions) > 0 && current.Counts[group] > 0 {
+					if err := scale.ScaleGroup(ctx, appName, group, 0, -1, strings.Join(removedRegions, ","), true); err != nil {
+						return err
+					}
+				}
+				return scale.ScaleGroup(ctx, appName, group, count, -1, strings.Join(desired.Regions, ","), true)
+			},
+		})
+	}
+
+	region := &api.Region{Code: primaryRegion}
+	if region.Code == "" && len(desired.Regions) > 0 {
+		region.Code = desired.Regions[0]
+	}
+
+	if desired.Postgres && !current.Postgres {
+		changes = append(changes, change{
+			description: "Create and attach the Postgres cluster " + appName + "-db",
+			apply: func(ctx context.Context) error {
+				return launch.LaunchPostgres(ctx, appName, org, region)
+			},
+		})
+	}
+
+	if desired.Redis && !current.Redis {
+		changes = append(changes, change{
+			description: "Create and attach the Upstash Redis database " + appName + "-redis",
+			apply: func(ctx context.Context) error {
+				return launch.LaunchRedis(ctx, appName, org, region)
+			},
+		})
+	}
+
+	if desired.LogProvider != current.LogProvider && desired.LogProvider != "" {
+		slug := desired.LogProvider
+		changes = append(changes, change{
+			description: "Ship logs to " + slug,
+			apply: func(ctx context.Context) error {
+				return logs.Ship(ctx, slug, nil)
+			},
+		})
+	}
+
+	return changes
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
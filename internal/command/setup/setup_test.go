@@ -0,0 +1,54 @@
+package setup
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestPlanChanges(t *testing.T) {
+	current := &state{
+		Regions:     []string{"ord"},
+		Counts:      map[string]int{"app": 2, "worker": 1},
+		Postgres:    true,
+		LogProvider: "logtail",
+	}
+	org := &api.Organization{Slug: "personal"}
+
+	unchanged := &state{
+		Regions:     []string{"ord"},
+		Counts:      map[string]int{"app": 2, "worker": 1},
+		Postgres:    true,
+		LogProvider: "logtail",
+	}
+	assert.Empty(t, planChanges("my-app", org, "ord", current, unchanged))
+
+	desired := &state{
+		Regions:     []string{"ord"},
+		Counts:      map[string]int{"app": 3, "worker": 1},
+		Postgres:    true,
+		Redis:       true,
+		LogProvider: "logtail",
+	}
+	descriptions := lo.Map(planChanges("my-app", org, "ord", current, desired), func(c change, _ int) string { return c.description })
+	assert.Equal(t, []string{
+		"Scale process group app to 3 machines in ord",
+		"Create and attach the Upstash Redis database my-app-redis",
+	}, descriptions)
+
+	// Moving regions rescales every group.
+	desired = &state{
+		Regions:     []string{"ams", "ord"},
+		Counts:      map[string]int{"app": 2, "worker": 0},
+		Postgres:    true,
+		LogProvider: "logtail",
+	}
+	descriptions = lo.Map(planChanges("my-app", org, "ord", current, desired), func(c change, _ int) string { return c.description })
+	assert.Equal(t, []string{
+		"Scale process group app to 2 machines in ams, ord",
+		"Scale process group worker to 0 machines in ams, ord",
+	}, descriptions)
+}
@@ -56,6 +56,7 @@ type ConnectParams struct {
 	Username       string
 	Dialer         agent.Dialer
 	DisableSpinner bool
+	ForwardAgent   bool
 }
 
 func Connect(p *ConnectParams, addr string) (*ssh.Client, error) {
@@ -78,6 +79,8 @@ func Connect(p *ConnectParams, addr string) (*ssh.Client, error) {
 
 		Certificate: cert.Certificate,
 		PrivateKey:  string(pemkey),
+
+		ForwardAgent: p.ForwardAgent,
 	}
 
 	var endSpin context.CancelFunc
@@ -107,6 +107,12 @@ func newConsole() *cobra.Command {
 	cmd.Args = cobra.MaximumNArgs(1)
 
 	stdArgsSSH(cmd)
+	flag.Add(cmd,
+		flag.Bool{
+			Name:        "agent-forward",
+			Description: "Forward your SSH agent to the machine, letting the session use its keys. Off by default, as anyone with root on the machine can use the forwarded agent",
+		},
+	)
 
 	return cmd
 }
@@ -170,6 +176,7 @@ func runConsole(ctx context.Context) error {
 		Dialer:         dialer,
 		Username:       flag.GetString(ctx, "user"),
 		DisableSpinner: quiet(ctx),
+		ForwardAgent:   flag.GetBool(ctx, "agent-forward"),
 	}
 	sshc, err := Connect(params, addr)
 	if err != nil {
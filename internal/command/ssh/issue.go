@@ -12,6 +12,7 @@ import (
 	"net/mail"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/ejcx/sshcert"
@@ -31,7 +32,15 @@ func newIssue() *cobra.Command {
 	const (
 		long = `Issue a new SSH credential. With -agent, populate credential
 into SSH agent. With -hour, set the number of hours (1-72) for credential
-validity.`
+validity.
+
+With --ttl, set a validity from 1 minute to 72 hours instead. Credentials are
+issued for whole hours, so with --agent the agent also forgets the credential
+once its TTL elapses. With --app, the credential only grants access to the
+machines of that app, rather than to every app of the organization.
+
+Whether fly ssh console forwards your SSH agent to machines is controlled
+with its --agent-forward flag.`
 		short = `Issue a new SSH credential`
 		usage = "issue [org] [path]"
 	)
@@ -53,6 +62,15 @@ validity.`
 			Default:     24,
 			Description: "Expiration, in hours (<72)",
 		},
+		flag.Duration{
+			Name:        "ttl",
+			Description: "Expiration, from 1m to 72h, overriding --hours",
+		},
+		flag.String{
+			Name:        "app",
+			Shorthand:   "a",
+			Description: "Restrict the credential to the machines of this app",
+		},
 
 		flag.Bool{
 			Name:        "agent",
@@ -80,7 +98,7 @@ func runSSHIssue(ctx context.Context) (err error) {
 	client := client.FromContext(ctx).API()
 	out := iostreams.FromContext(ctx).Out
 
-	org, err := orgs.OrgFromFirstArgOrSelect(ctx)
+	org, apps, err := issueOrgAndApps(ctx)
 	if err != nil {
 		return err
 	}
@@ -131,9 +149,9 @@ func runSSHIssue(ctx context.Context) (err error) {
 		principals = append(principals, name)
 	}
 
-	hours := flag.GetInt(ctx, "hours")
-	if hours < 1 || hours > 72 {
-		return fmt.Errorf("Invalid expiration time (1-72 hours)\n")
+	hours, ttl, err := issueValidity(flag.GetInt(ctx, "hours"), flag.GetDuration(ctx, "ttl"))
+	if err != nil {
+		return err
 	}
 
 	pub, priv, err := ed25519.GenerateKey(nil)
@@ -141,18 +159,18 @@ func runSSHIssue(ctx context.Context) (err error) {
 		return err
 	}
 
-	icert, err := client.IssueSSHCertificate(ctx, org, principals, nil, &hours, pub)
+	icert, err := client.IssueSSHCertificate(ctx, org, principals, apps, &hours, pub)
 	if err != nil {
 		return err
 	}
 
 	doAgent := flag.GetBool(ctx, "agent")
 	if doAgent {
-		if err = populateAgent(icert, priv); err != nil {
+		if err = populateAgent(icert, priv, ttl); err != nil {
 			return err
 		}
 
-		fmt.Printf("Populated agent with cert:\n%s\n", icert.Certificate)
+		fmt.Printf("Populated agent with cert, for %s:\n%s\n", ttl, icert.Certificate)
 		return nil
 	}
 
@@ -287,7 +305,42 @@ func MarshalED25519PrivateKey(key ed25519.PrivateKey, comment string) []byte {
 	})
 }
 
-func populateAgent(icert *api.IssuedCertificate, priv ed25519.PrivateKey) error {
+// issueOrgAndApps returns the organization to issue a credential for and the
+// apps it's restricted to with --app, whose organization it defaults to.
+func issueOrgAndApps(ctx context.Context) (api.OrganizationImpl, []api.App, error) {
+	appName := flag.GetString(ctx, "app")
+	if appName == "" {
+		org, err := orgs.OrgFromFirstArgOrSelect(ctx)
+		return org, nil, err
+	}
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if slug := flag.FirstArg(ctx); slug != "" && slug != app.Organization.Slug {
+		return nil, nil, fmt.Errorf("app %s belongs to organization %s, not %s", appName, app.Organization.Slug, slug)
+	}
+	return app.Organization, []api.App{{ID: app.ID, Name: app.Name}}, nil
+}
+
+// issueValidity returns the whole hours to issue a credential for and its
+// exact TTL, from --hours unless --ttl is set.
+func issueValidity(hours int, ttl time.Duration) (int, time.Duration, error) {
+	if ttl == 0 {
+		if hours < 1 || hours > 72 {
+			return 0, 0, fmt.Errorf("Invalid expiration time (1-72 hours)\n")
+		}
+		return hours, time.Duration(hours) * time.Hour, nil
+	}
+
+	if ttl < time.Minute || ttl > 72*time.Hour {
+		return 0, 0, fmt.Errorf("Invalid --ttl %s (1m-72h)\n", ttl)
+	}
+	return int((ttl + time.Hour - 1) / time.Hour), ttl, nil
+}
+
+func populateAgent(icert *api.IssuedCertificate, priv ed25519.PrivateKey, ttl time.Duration) error {
 	acon, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
 	if err != nil {
 		return fmt.Errorf("can't connect to SSH agent: %w", err)
@@ -301,8 +354,9 @@ func populateAgent(icert *api.IssuedCertificate, priv ed25519.PrivateKey) error
 	}
 
 	if err = ssha.Add(agent.AddedKey{
-		PrivateKey:  priv,
-		Certificate: cert.(*ssh.Certificate),
+		PrivateKey:   priv,
+		Certificate:  cert.(*ssh.Certificate),
+		LifetimeSecs: uint32(ttl.Seconds()),
 	}); err != nil {
 		return fmt.Errorf("ssh-agent failure: %w", err)
 	}
@@ -0,0 +1,31 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueValidity(t *testing.T) {
+	hours, ttl, err := issueValidity(24, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 24, hours)
+	assert.Equal(t, 24*time.Hour, ttl)
+
+	hours, ttl, err = issueValidity(24, 90*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, hours)
+	assert.Equal(t, 90*time.Minute, ttl)
+
+	hours, _, err = issueValidity(24, 15*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hours)
+
+	_, _, err = issueValidity(73, 0)
+	assert.Error(t, err)
+	_, _, err = issueValidity(24, 30*time.Second)
+	assert.Error(t, err)
+	_, _, err = issueValidity(24, 73*time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentLookups bounds the number of API requests status makes at once.
+const maxConcurrentLookups = 8
+
+func machineImage(m *api.Machine) string {
+	return fmt.Sprintf("%s:%s", m.ImageRef.Repository, m.ImageRef.Tag)
+}
+
+// fetchLatestImages looks up the latest version of every distinct image run
+// by machines concurrently. Images of unknown repositories are left out.
+func fetchLatestImages(ctx context.Context, machines []*api.Machine) (map[string]*api.ImageVersion, error) {
+	client := client.FromContext(ctx).API()
+
+	images := map[string]struct{}{}
+	for _, m := range machines {
+		images[machineImage(m)] = struct{}{}
+	}
+
+	var (
+		mu     sync.Mutex
+		latest = make(map[string]*api.ImageVersion, len(images))
+	)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxConcurrentLookups)
+
+	for image := range images {
+		image := image
+		eg.Go(func() error {
+			latestImage, err := client.GetLatestImageDetails(ctx, image)
+			if err != nil {
+				if strings.Contains(err.Error(), "Unknown repository") {
+					return nil
+				}
+				return fmt.Errorf("unable to fetch latest image details for %s: %w", image, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			latest[image] = latestImage
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// fetchCurrentReleaseVersion returns the version of the app's current
+// release, or 0 when it has none.
+func fetchCurrentReleaseVersion(ctx context.Context, appName string) (int, error) {
+	client := client.FromContext(ctx).API()
+
+	versionQuery := `
+		query ($appName: String!) {
+			app(name:$appName) {
+				currentRelease:currentReleaseUnprocessed {
+					version
+				}
+			}
+		}
+	`
+	req := client.NewRequest(versionQuery)
+	req.Var("appName", appName)
+	resp, err := client.RunWithContext(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("could not get current release for app '%s': %w", appName, err)
+	}
+	if resp.App.CurrentRelease == nil {
+		return 0, nil
+	}
+	return resp.App.CurrentRelease.Version, nil
+}
@@ -9,13 +9,17 @@ import (
 	"strings"
 
 	"github.com/superfly/flyctl/api"
-	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	machcmd "github.com/superfly/flyctl/internal/command/machine"
 	"github.com/superfly/flyctl/internal/command/postgres"
 	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/schema"
 	"github.com/superfly/flyctl/iostreams"
 	"golang.org/x/exp/slices"
+	"golang.org/x/sync/errgroup"
 )
 
 func getFromMetadata(m *api.Machine, key string) string {
@@ -73,21 +77,43 @@ func getImage(machines []*api.Machine) (string, error) {
 }
 
 func RenderMachineStatus(ctx context.Context, app *api.AppCompact, out io.Writer) error {
+	_, err := renderMachineStatus(ctx, app, out)
+	return err
+}
+
+// renderMachineStatus renders the status of the machines of app to out, and
+// returns their state.
+func renderMachineStatus(ctx context.Context, app *api.AppCompact, out io.Writer) ([]rowState, error) {
 	var (
 		io         = iostreams.FromContext(ctx)
 		colorize   = io.ColorScheme()
-		client     = client.FromContext(ctx).API()
-		jsonOutput = config.FromContext(ctx).JSONOutput
+		jsonOutput = config.FromContext(ctx).JSONOutput || flag.GetJSONSchemaVersion(ctx) > 0
 	)
 
 	flapsClient, err := flaps.New(ctx, app)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	machines, err := flapsClient.ListActive(ctx)
-	if err != nil {
-		return err
+	// The release version is only needed for JSON output, fetch it
+	// alongside the machines
+	var (
+		machines []*api.Machine
+		version  int
+	)
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		machines, err = flapsClient.ListActive(egCtx)
+		return
+	})
+	if jsonOutput {
+		eg.Go(func() (err error) {
+			version, err = fetchCurrentReleaseVersion(egCtx, app.Name)
+			return
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
 
 	sort.Slice(machines, func(i, j int) bool {
@@ -95,51 +121,19 @@ func RenderMachineStatus(ctx context.Context, app *api.AppCompact, out io.Writer
 	})
 
 	if jsonOutput {
-		return renderMachineJSONStatus(ctx, app, machines)
-	}
-
-	if app.IsPostgresApp() {
-		return renderPGStatus(ctx, app, machines, out)
+		return nil, renderMachineJSONStatus(ctx, app, machines, version)
 	}
 
-	// Tracks latest eligible version
-	var latest *api.ImageVersion
-
-	var updatable []*api.Machine
-
+	rows := make([]rowState, 0, len(machines))
 	for _, machine := range machines {
-		image := fmt.Sprintf("%s:%s", machine.ImageRef.Repository, machine.ImageRef.Tag)
-
-		latestImage, err := client.GetLatestImageDetails(ctx, image)
-		if err != nil {
-			if strings.Contains(err.Error(), "Unknown repository") {
-				continue
-			}
-			return fmt.Errorf("unable to fetch latest image details for %s: %w", image, err)
-		}
-
-		if latest == nil {
-			latest = latestImage
-		}
-
-		// Exclude machines that are already running the latest version
-		if machine.ImageRef.Digest == latest.Digest {
-			continue
-		}
-		updatable = append(updatable, machine)
+		rows = append(rows, machineRowState(machine))
 	}
 
-	if len(updatable) > 0 {
-		msgs := []string{"Updates available:\n\n"}
-
-		for _, machine := range updatable {
-			latestStr := fmt.Sprintf("%s:%s (%s)", latest.Repository, latest.Tag, latest.Version)
-			msg := fmt.Sprintf("Machine %q %s -> %s\n", machine.ID, machine.ImageRefWithVersion(), latestStr)
-			msgs = append(msgs, msg)
-		}
+	// Look up image updates while the machines are rendered
+	waitLatestImages := lookupLatestImages(ctx, machines)
 
-		fmt.Fprintln(out, colorize.Yellow(strings.Join(msgs, "")))
-		fmt.Fprintln(out, colorize.Yellow("Run `flyctl image update` to migrate to the latest image version."))
+	if app.IsPostgresApp() {
+		return rows, renderPGStatus(ctx, app, machines, waitLatestImages, out)
 	}
 
 	managed, unmanaged := []*api.Machine{}, []*api.Machine{}
@@ -154,22 +148,22 @@ func RenderMachineStatus(ctx context.Context, app *api.AppCompact, out io.Writer
 
 	image, err := getImage(managed)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	obj := [][]string{{app.Name, app.Organization.Slug, app.Hostname, image, app.PlatformVersion}}
 	if err := render.VerticalTable(out, "App", obj, "Name", "Owner", "Hostname", "Image", "Platform"); err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(managed) > 0 {
 		hasStandbys := false
-		rows := [][]string{}
+		table := [][]string{}
 		for _, machine := range managed {
 			if len(machine.Config.Standbys) > 0 {
 				hasStandbys = true
 			}
-			rows = append(rows, []string{
+			table = append(table, []string{
 				getProcessgroup(machine),
 				machine.ID,
 				getReleaseVersion(machine),
@@ -180,18 +174,24 @@ func RenderMachineStatus(ctx context.Context, app *api.AppCompact, out io.Writer
 			})
 		}
 
-		sort.Slice(rows, func(i, j int) bool {
-			return slices.Compare(rows[i], rows[j]) < 0
+		sort.Slice(table, func(i, j int) bool {
+			return slices.Compare(table[i], table[j]) < 0
 		})
 
-		err := render.Table(out, "Machines", rows, "Process", "ID", "Version", "Region", "State", "Checks", "Last Updated")
+		err := render.Table(out, "Machines", table, "Process", "ID", "Version", "Region", "State", "Checks", "Last Updated")
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if hasStandbys {
 			fmt.Fprintf(out, "  † Standby machine (it will take over only in case of host hardware failure)\n")
 		}
+
+		if policies := appconfig.EffectiveAutostop(managed); len(policies) > 0 {
+			if err := machcmd.RenderAutostopPolicies(out, policies); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if len(unmanaged) > 0 {
@@ -199,34 +199,25 @@ func RenderMachineStatus(ctx context.Context, app *api.AppCompact, out io.Writer
 		fmt.Fprint(out, msg)
 	}
 
-	return nil
-}
-
-func renderMachineJSONStatus(ctx context.Context, app *api.AppCompact, machines []*api.Machine) error {
-	var (
-		out    = iostreams.FromContext(ctx).Out
-		client = client.FromContext(ctx).API()
-	)
-
-	versionQuery := `
-		query ($appName: String!) {
-			app(name:$appName) {
-				currentRelease:currentReleaseUnprocessed {
-					version
-				}
-			}
-		}
-	`
-	req := client.NewRequest(versionQuery)
-	req.Var("appName", app.Name)
-	resp, err := client.RunWithContext(ctx, req)
+	latestImages, err := waitLatestImages()
 	if err != nil {
-		return fmt.Errorf("could not get current release for app '%s': %w", app.Name, err)
+		return nil, err
 	}
-	version := 0
-	if resp.App.CurrentRelease != nil {
-		version = resp.App.CurrentRelease.Version
+	return rows, renderImageUpdates(out, colorize, machines, latestImages, false)
+}
+
+// machineRowState returns the state of machine, as shown by status.
+func machineRowState(machine *api.Machine) rowState {
+	return rowState{
+		ID:      machine.ID,
+		State:   machine.State,
+		Checks:  render.MachineHealthChecksSummary(machine),
+		Version: getReleaseVersion(machine) + " " + machine.ImageRefWithVersion(),
 	}
+}
+
+func renderMachineJSONStatus(ctx context.Context, app *api.AppCompact, machines []*api.Machine, version int) error {
+	out := iostreams.FromContext(ctx).Out
 
 	machinesToShow := []*api.Machine{}
 	if app.IsPostgresApp() {
@@ -239,6 +230,10 @@ func renderMachineJSONStatus(ctx context.Context, app *api.AppCompact, machines
 		}
 	}
 
+	if schemaVersion := flag.GetJSONSchemaVersion(ctx); schemaVersion > 0 {
+		return schema.Render(out, "status", schemaVersion, schema.NewStatusV1(app, machinesToShow, version))
+	}
+
 	status := map[string]any{
 		"ID":              app.ID,
 		"Name":            app.Name,
@@ -254,11 +249,10 @@ func renderMachineJSONStatus(ctx context.Context, app *api.AppCompact, machines
 	return render.JSON(out, status)
 }
 
-func renderPGStatus(ctx context.Context, app *api.AppCompact, machines []*api.Machine, out io.Writer) (err error) {
+func renderPGStatus(ctx context.Context, app *api.AppCompact, machines []*api.Machine, waitLatestImages func() (map[string]*api.ImageVersion, error), out io.Writer) (err error) {
 	var (
 		io       = iostreams.FromContext(ctx)
 		colorize = io.ColorScheme()
-		client   = client.FromContext(ctx).API()
 	)
 
 	if len(machines) > 0 {
@@ -273,50 +267,6 @@ func renderPGStatus(ctx context.Context, app *api.AppCompact, machines []*api.Ma
 		return
 	}
 
-	// Tracks latest eligible version
-	var latest *api.ImageVersion
-	var updatable []*api.Machine
-
-	for _, machine := range machines {
-		image := fmt.Sprintf("%s:%s", machine.ImageRef.Repository, machine.ImageRef.Tag)
-
-		latestImage, err := client.GetLatestImageDetails(ctx, image)
-
-		if err != nil && strings.Contains(err.Error(), "Unknown repository") {
-			continue
-		}
-		if err != nil {
-			return fmt.Errorf("unable to fetch latest image details for %s: %w", image, err)
-		}
-
-		if latest == nil {
-			latest = latestImage
-		}
-
-		if latest.Tag != latestImage.Tag {
-			return fmt.Errorf("major version mismatch detected")
-		}
-
-		// Exclude machines that are already running the latest version
-		if machine.ImageRef.Digest == latest.Digest {
-			continue
-		}
-		updatable = append(updatable, machine)
-	}
-
-	if len(updatable) > 0 {
-		msgs := []string{"Updates available:\n\n"}
-
-		for _, machine := range updatable {
-			latestStr := fmt.Sprintf("%s:%s (%s)", latest.Repository, latest.Tag, latest.Version)
-			msg := fmt.Sprintf("Machine %q %s -> %s\n", machine.ID, machine.ImageRefWithVersion(), latestStr)
-			msgs = append(msgs, msg)
-		}
-
-		fmt.Fprintln(out, colorize.Yellow(strings.Join(msgs, "")))
-		fmt.Fprintln(out, colorize.Yellow("Run `flyctl image update` to migrate to the latest image version."))
-	}
-
 	rows := [][]string{}
 
 	for _, machine := range machines {
@@ -343,7 +293,15 @@ func renderPGStatus(ctx context.Context, app *api.AppCompact, machines []*api.Ma
 		})
 	}
 
-	return render.Table(out, "", rows, "ID", "State", "Role", "Region", "Checks", "Image", "Created", "Updated")
+	if err := render.Table(out, "", rows, "ID", "State", "Role", "Region", "Checks", "Image", "Created", "Updated"); err != nil {
+		return err
+	}
+
+	latestImages, err := waitLatestImages()
+	if err != nil {
+		return err
+	}
+	return renderImageUpdates(out, colorize, machines, latestImages, true)
 }
 
 func isQuorumMet(machines []*api.Machine) (bool, string) {
@@ -378,3 +336,68 @@ func isQuorumMet(machines []*api.Machine) (bool, string) {
 
 	return true, ""
 }
+
+// lookupLatestImages looks up the latest images of machines in the
+// background. The returned func waits for the lookups to complete.
+func lookupLatestImages(ctx context.Context, machines []*api.Machine) func() (map[string]*api.ImageVersion, error) {
+	var (
+		eg     errgroup.Group
+		latest map[string]*api.ImageVersion
+	)
+	eg.Go(func() (err error) {
+		latest, err = fetchLatestImages(ctx, machines)
+		return
+	})
+
+	return func() (map[string]*api.ImageVersion, error) {
+		if err := eg.Wait(); err != nil {
+			return nil, err
+		}
+		return latest, nil
+	}
+}
+
+// renderImageUpdates prints the machines that don't run the latest version of
+// their image. With sameTag, machines running images of different tags, such
+// as postgres major versions, are an error.
+func renderImageUpdates(out io.Writer, colorize *iostreams.ColorScheme, machines []*api.Machine, latestImages map[string]*api.ImageVersion, sameTag bool) error {
+	// Tracks latest eligible version
+	var latest *api.ImageVersion
+	var updatable []*api.Machine
+
+	for _, machine := range machines {
+		latestImage, ok := latestImages[machineImage(machine)]
+		if !ok {
+			continue
+		}
+
+		if latest == nil {
+			latest = latestImage
+		}
+
+		if sameTag && latest.Tag != latestImage.Tag {
+			return fmt.Errorf("major version mismatch detected")
+		}
+
+		// Exclude machines that are already running the latest version
+		if machine.ImageRef.Digest == latest.Digest {
+			continue
+		}
+		updatable = append(updatable, machine)
+	}
+
+	if len(updatable) == 0 {
+		return nil
+	}
+
+	msgs := []string{"Updates available:\n\n"}
+	for _, machine := range updatable {
+		latestStr := fmt.Sprintf("%s:%s (%s)", latest.Repository, latest.Tag, latest.Version)
+		msg := fmt.Sprintf("Machine %q %s -> %s\n", machine.ID, machine.ImageRefWithVersion(), latestStr)
+		msgs = append(msgs, msg)
+	}
+
+	fmt.Fprintln(out, colorize.Yellow(strings.Join(msgs, "")))
+	fmt.Fprintln(out, colorize.Yellow("Run `flyctl image update` to migrate to the latest image version."))
+	return nil
+}
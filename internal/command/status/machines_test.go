@@ -1,10 +1,12 @@
 package status
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/iostreams"
 )
 
 func TestGetImage(t *testing.T) {
@@ -81,3 +83,28 @@ func TestGetImage(t *testing.T) {
 	}
 
 }
+
+func TestRenderImageUpdates(t *testing.T) {
+	colorize := &iostreams.ColorScheme{}
+	machines := []*api.Machine{
+		{ID: "m1", ImageRef: api.MachineImageRef{Repository: "flyio/postgres", Tag: "15", Digest: "sha256:new"}},
+		{ID: "m2", ImageRef: api.MachineImageRef{Repository: "flyio/postgres", Tag: "15", Digest: "sha256:old"}},
+	}
+	latest := map[string]*api.ImageVersion{
+		"flyio/postgres:15": {Repository: "flyio/postgres", Tag: "15", Version: "v2", Digest: "sha256:new"},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, renderImageUpdates(&out, colorize, machines, latest, true))
+	require.Contains(t, out.String(), `Machine "m2"`)
+	require.NotContains(t, out.String(), `Machine "m1"`)
+
+	out.Reset()
+	require.NoError(t, renderImageUpdates(&out, colorize, machines[:1], latest, true))
+	require.Empty(t, out.String())
+
+	machines = append(machines, &api.Machine{ID: "m3", ImageRef: api.MachineImageRef{Repository: "flyio/postgres", Tag: "14"}})
+	latest["flyio/postgres:14"] = &api.ImageVersion{Repository: "flyio/postgres", Tag: "14"}
+	require.EqualError(t, renderImageUpdates(&out, colorize, machines, latest, true), "major version mismatch detected")
+	require.NoError(t, renderImageUpdates(&out, colorize, machines, latest, false))
+}
@@ -24,7 +24,9 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/format"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/schema"
 )
 
 func New() (cmd *cobra.Command) {
@@ -47,6 +49,7 @@ currently allocated.
 		flag.App(),
 		flag.AppConfig(),
 		flag.JSONOutput(),
+		flag.JSONSchemaVersion(),
 		flag.Bool{
 			Name:        "all",
 			Description: "Show completed instances",
@@ -57,7 +60,7 @@ currently allocated.
 		},
 		flag.Bool{
 			Name:        "watch",
-			Description: "Refresh details",
+			Description: "Refresh details, highlighting what changed since the previous refresh",
 		},
 		flag.Int{
 			Name:        "rate",
@@ -75,9 +78,13 @@ currently allocated.
 
 func run(ctx context.Context) error {
 	watch := flag.GetBool(ctx, "watch")
-	if watch && config.FromContext(ctx).JSONOutput {
+	schemaVersion := flag.GetJSONSchemaVersion(ctx)
+	if watch && (config.FromContext(ctx).JSONOutput || schemaVersion > 0) {
 		return errors.New("--watch and --json are not supported together")
 	}
+	if err := schema.CheckVersion("status", schemaVersion); err != nil {
+		return err
+	}
 
 	if !watch {
 		return runOnce(ctx)
@@ -87,10 +94,13 @@ func run(ctx context.Context) error {
 }
 
 func runOnce(ctx context.Context) error {
-	return once(ctx, iostreams.FromContext(ctx).Out)
+	_, err := once(ctx, iostreams.FromContext(ctx).Out)
+	return err
 }
 
-func once(ctx context.Context, out io.Writer) (err error) {
+// once renders the status of the app to out, and returns the state of its
+// machines or instances for --watch to highlight changes of.
+func once(ctx context.Context, out io.Writer) (rows []rowState, err error) {
 	var (
 		appName    = appconfig.NameFromContext(ctx)
 		all        = flag.GetBool(ctx, "all")
@@ -100,14 +110,15 @@ func once(ctx context.Context, out io.Writer) (err error) {
 
 	app, err := client.GetAppCompact(ctx, appName)
 	if err != nil {
-		return fmt.Errorf("failed to get app: %w", err)
+		return nil, fmt.Errorf("failed to get app: %w", err)
 	}
 
 	platformVersion := app.PlatformVersion
 
 	if platformVersion == "machines" {
-		err = RenderMachineStatus(ctx, app, out)
-		return
+		return renderMachineStatus(ctx, app, out)
+	} else if flag.GetJSONSchemaVersion(ctx) > 0 {
+		return nil, fmt.Errorf("--json-schema-version only covers apps running on machines, %s runs on %s", appName, platformVersion)
 	} else {
 		command.PromptToMigrate(ctx, app)
 	}
@@ -121,7 +132,7 @@ func once(ctx context.Context, out io.Writer) (err error) {
 	var backupRegions []api.Region
 	if status.Deployed && !jsonOutput {
 		if _, backupRegions, err = client.ListAppRegions(ctx, appName); err != nil {
-			return fmt.Errorf("failed retrieving backup regions for %s: %w", appName, err)
+			return nil, fmt.Errorf("failed retrieving backup regions for %s: %w", appName, err)
 		}
 	}
 
@@ -160,7 +171,18 @@ func once(ctx context.Context, out io.Writer) (err error) {
 		}
 	}
 
-	err = render.AllocationStatuses(out, "Instances", backupRegions, status.Allocations...)
+	if err = render.AllocationStatuses(out, "Instances", backupRegions, status.Allocations...); err != nil {
+		return
+	}
+
+	for _, alloc := range status.Allocations {
+		rows = append(rows, rowState{
+			ID:      alloc.IDShort,
+			State:   format.AllocStatus(alloc),
+			Checks:  format.HealthChecksSummary(alloc),
+			Version: strconv.Itoa(alloc.Version),
+		})
+	}
 
 	return
 }
@@ -204,15 +226,24 @@ func runWatch(ctx context.Context) (err error) {
 
 	appName := appconfig.NameFromContext(ctx)
 
-	var buf bytes.Buffer
+	var (
+		buf  bytes.Buffer
+		prev map[string]rowState
+	)
 
 	for err == nil {
 		buf.Reset()
 
-		if err = once(ctx, &buf); err != nil {
+		var rows []rowState
+		if rows, err = once(ctx, &buf); err != nil {
 			break
 		}
 
+		// Highlight what changed since the previous refresh, e.g. machines
+		// starting or stopping, check transitions or a new release
+		body := highlightChanges(prev, rows, buf.String(), colorize.Yellow)
+		prev = indexRows(rows)
+
 		header := fmt.Sprintf("%s %s %s\n\n", colorize.Bold(appName), "at:", colorize.Bold(time.Now().UTC().Format("15:04:05")))
 
 		screen.Clear()
@@ -220,7 +251,7 @@ func runWatch(ctx context.Context) (err error) {
 
 		io.Copy(streams.Out, io.MultiReader(
 			strings.NewReader(header),
-			&buf,
+			strings.NewReader(body),
 		))
 
 		pause.For(ctx, time.Duration(sleep)*time.Second)
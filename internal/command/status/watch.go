@@ -0,0 +1,56 @@
+package status
+
+import (
+	"strings"
+)
+
+// rowState identifies a row of the status output, of a machine or an
+// instance, along with the fields whose changes are highlighted.
+type rowState struct {
+	ID      string
+	State   string
+	Checks  string
+	Version string
+}
+
+// indexRows returns rows keyed by ID.
+func indexRows(rows []rowState) map[string]rowState {
+	index := make(map[string]rowState, len(rows))
+	for _, row := range rows {
+		index[row.ID] = row
+	}
+	return index
+}
+
+// highlightChanges returns out with highlight applied to the lines of the rows
+// which are new since, or whose state, checks or version differ from, the
+// previous refresh. Rows are matched by ID rather than by rendered text, since
+// a change in the width of a column re-pads every row of its table. Nothing is
+// highlighted on the first refresh, when prev is nil.
+func highlightChanges(prev map[string]rowState, rows []rowState, out string, highlight func(string) string) string {
+	if prev == nil {
+		return out
+	}
+
+	var changed []string
+	for _, row := range rows {
+		if before, ok := prev[row.ID]; !ok || before != row {
+			changed = append(changed, row.ID)
+		}
+	}
+	if len(changed) == 0 {
+		return out
+	}
+
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		for _, id := range changed {
+			if strings.Contains(line, id) {
+				lines[i] = highlight(line)
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
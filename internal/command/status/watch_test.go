@@ -0,0 +1,33 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightChanges(t *testing.T) {
+	mark := func(s string) string { return "*" + s }
+
+	prev := []rowState{
+		{ID: "m1", State: "started", Checks: "1 passing", Version: "1"},
+		{ID: "m2", State: "started", Checks: "1 passing", Version: "1"},
+	}
+	out := "App\nm1 started  1\nm2 started  1\n"
+	assert.Equal(t, out, highlightChanges(nil, prev, out, mark))
+
+	// Re-padded rows of unchanged machines aren't highlighted
+	cur := []rowState{
+		{ID: "m1", State: "started", Checks: "1 passing", Version: "1"},
+		{ID: "m2", State: "replacing", Checks: "1 passing", Version: "1"},
+		{ID: "m3", State: "started", Checks: "1 passing", Version: "1"},
+	}
+	out = "App\nm1 started    1\nm2 replacing  1\n\nm3 started    1\n"
+	assert.Equal(t, "App\nm1 started    1\n*m2 replacing  1\n\n*m3 started    1\n", highlightChanges(indexRows(prev), cur, out, mark))
+
+	// Neither are rows whose relative times moved on
+	assert.Equal(t, out, highlightChanges(indexRows(cur), cur, out, mark))
+
+	// New rows are highlighted after a refresh without any
+	assert.Equal(t, "*m1 started\n", highlightChanges(indexRows(nil), prev[:1], "m1 started\n", mark))
+}
@@ -3,8 +3,11 @@ package tokens
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/gql"
@@ -64,7 +67,10 @@ func newOrg() *cobra.Command {
 func newDeploy() *cobra.Command {
 	const (
 		short = "Create deploy tokens"
-		long  = "Create an API token limited to managing a single app and its resources. Also available as TOKENS DEPLOY. Tokens are valid for 20 years by default. We recommend using a shorter expiry if practical."
+		long  = `Create an API token limited to managing a single app and its resources. Also available as TOKENS DEPLOY. Tokens are valid for 20 years by default. We recommend using a shorter expiry if practical.
+
+With --ci, also print how to store the token as the FLY_API_TOKEN secret of a CI
+provider, and how to use it in a pipeline.`
 		usage = "deploy"
 	)
 
@@ -89,6 +95,10 @@ func newDeploy() *cobra.Command {
 			Description: "The duration that the token will be valid",
 			Default:     time.Hour * 24 * 365 * 20,
 		},
+		flag.String{
+			Name:        "ci",
+			Description: fmt.Sprintf("Print a snippet storing the token in this CI provider, one of %s", strings.Join(ciProviders, ", ")),
+		},
 	)
 
 	return cmd
@@ -188,6 +198,11 @@ func runDeploy(ctx context.Context) (err error) {
 
 	appName := appconfig.NameFromContext(ctx)
 
+	ci := flag.GetString(ctx, "ci")
+	if ci != "" && !slices.Contains(ciProviders, ci) {
+		return fmt.Errorf("unknown CI provider %s, must be one of %s", ci, strings.Join(ciProviders, ", "))
+	}
+
 	app, err := apiClient.GetAppCompact(ctx, appName)
 	if err != nil {
 		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
@@ -204,7 +219,16 @@ func runDeploy(ctx context.Context) (err error) {
 
 	io := iostreams.FromContext(ctx)
 	if config.FromContext(ctx).JSONOutput {
-		render.JSON(io.Out, map[string]string{"token": token})
+		output := map[string]string{"token": token, "app": app.Name}
+		if expiryDuration := flag.GetDuration(ctx, "expiry"); expiryDuration != 0 {
+			output["expires_at"] = time.Now().Add(expiryDuration).UTC().Format(time.RFC3339)
+		}
+		if ci != "" {
+			output["ci_snippet"] = ciSnippet(ci, app.Name, token)
+		}
+		render.JSON(io.Out, output)
+	} else if ci != "" {
+		fmt.Fprint(io.Out, ciSnippet(ci, app.Name, token))
 	} else {
 		fmt.Fprintln(io.Out, token)
 	}
@@ -212,6 +236,47 @@ func runDeploy(ctx context.Context) (err error) {
 	return nil
 }
 
+var ciProviders = []string{"github", "gitlab", "circleci", "env"}
+
+// ciSnippet returns how to store a deploy token of appName as the secret of
+// a CI provider, and how to use it.
+func ciSnippet(provider, appName, token string) string {
+	quoted := "'" + strings.ReplaceAll(token, "'", `'\''`) + "'"
+
+	switch provider {
+	case "github":
+		return fmt.Sprintf(`# Store the token as a secret of the repository
+gh secret set FLY_API_TOKEN --body %s
+
+# And deploy from a workflow
+- uses: superfly/flyctl-actions/setup-flyctl@master
+- run: flyctl deploy --remote-only -a %s
+  env:
+    FLY_API_TOKEN: ${{ secrets.FLY_API_TOKEN }}
+`, quoted, appName)
+	case "gitlab":
+		return fmt.Sprintf(`# Store the token as a masked variable of the project
+glab variable set FLY_API_TOKEN --masked --value %s
+
+# And deploy from .gitlab-ci.yml
+deploy:
+  image: flyio/flyctl:latest
+  script:
+    - flyctl deploy --remote-only -a %s
+`, quoted, appName)
+	case "circleci":
+		return fmt.Sprintf(`# Store the token as the FLY_API_TOKEN environment variable in
+# Project Settings > Environment Variables, or a context:
+circleci context store-secret github/<org> <context> FLY_API_TOKEN <<< %s
+
+# And deploy from .circleci/config.yml
+- run: flyctl deploy --remote-only -a %s
+`, quoted, appName)
+	default:
+		return fmt.Sprintf("export FLY_API_TOKEN=%s\n", quoted)
+	}
+}
+
 func runLiteFSCloud(ctx context.Context) (err error) {
 	var token string
 	apiClient := client.FromContext(ctx).API()
@@ -0,0 +1,429 @@
+// Package trace implements the trace command.
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// maxLogPages bounds the pages of logs searched for a request.
+const maxLogPages = 20
+
+// New initializes and returns a new trace Command.
+func New() *cobra.Command {
+	const (
+		short = "Trace a request from its Fly-Request-Id"
+		long  = short + `
+
+Assembles what Fly.io knows of a request from the ID in its Fly-Request-Id
+response header: the edge region which received it and when, the log lines
+of the proxy routing it, the machine it was routed to and its events, and the
+log lines of the app mentioning the ID or logged by the machine around the
+request, each with its time since the edge received the request.
+
+Logs are kept by the log API for a short while only, so trace failing
+requests soon after they happen, or ship logs for longer retention with
+fly logs ship.
+`
+		usage = "trace <request-id>"
+	)
+
+	cmd := command.New(usage, short, long, run,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Example = `  fly trace 01H2ZKQ3X5V7MCS0Q9Y6G1T4RA-iad`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.Duration{
+			Name:        "window",
+			Description: "How long around the request to include the log lines and events of its machine",
+			Default:     5 * time.Second,
+		},
+	)
+
+	return cmd
+}
+
+// requestID is a parsed Fly-Request-Id, a ULID suffixed with the region of
+// the edge which received the request.
+type requestID struct {
+	ID         string
+	EdgeRegion string
+	// ReceivedAt is the time of the ULID, zero when it isn't one.
+	ReceivedAt time.Time
+}
+
+func parseRequestID(s string) (requestID, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return requestID{}, errors.New("request ID is empty")
+	}
+
+	id := requestID{ID: s}
+	prefix := s
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		prefix, id.EdgeRegion = s[:i], s[i+1:]
+	}
+	if parsed, err := ulid.ParseStrict(prefix); err == nil {
+		id.ReceivedAt = ulid.Time(parsed.Time()).UTC()
+	}
+	return id, nil
+}
+
+// Trace is what's known of a request.
+type Trace struct {
+	RequestID     string     `json:"request_id"`
+	EdgeRegion    string     `json:"edge_region,omitempty"`
+	ReceivedAt    *time.Time `json:"received_at,omitempty"`
+	Machine       string     `json:"machine,omitempty"`
+	MachineRegion string     `json:"machine_region,omitempty"`
+	Status        int        `json:"status,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	Lines         []Line     `json:"lines"`
+	Hints         []string   `json:"hints,omitempty"`
+}
+
+// Line is a log line or machine event of a trace.
+type Line struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	Instance string    `json:"instance,omitempty"`
+	Region   string    `json:"region,omitempty"`
+	Message  string    `json:"message"`
+	// Matched is set on lines mentioning the request ID.
+	Matched bool `json:"matched"`
+}
+
+func run(ctx context.Context) error {
+	var (
+		out       = iostreams.FromContext(ctx).Out
+		appName   = appconfig.NameFromContext(ctx)
+		window    = flag.GetDuration(ctx, "window")
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	id, err := parseRequestID(flag.FirstArg(ctx))
+	if err != nil {
+		return flyerr.Validation(err)
+	}
+
+	entries, err := fetchLogs(ctx, apiClient, appName)
+	if err != nil {
+		return fmt.Errorf("failed fetching the logs of %s: %w", appName, err)
+	}
+
+	t := buildTrace(id, entries, window)
+
+	if t.Machine != "" {
+		app, err := apiClient.GetAppCompact(ctx, appName)
+		if err != nil {
+			return fmt.Errorf("failed to get app: %w", err)
+		}
+		flapsClient, err := flaps.New(ctx, app)
+		if err != nil {
+			return err
+		}
+		// the machine may have been destroyed since
+		if m, err := flapsClient.Get(ctx, t.Machine); err == nil {
+			addMachineEvents(t, m, window)
+		}
+	}
+	t.Hints = hints(t)
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, t)
+	}
+
+	return renderTrace(out, t)
+}
+
+// fetchLogs returns the log entries of appName the log API still has.
+func fetchLogs(ctx context.Context, apiClient *api.Client, appName string) ([]api.LogEntry, error) {
+	var (
+		all   []api.LogEntry
+		token string
+	)
+	for page := 0; page < maxLogPages; page++ {
+		entries, next, err := apiClient.GetAppLogs(ctx, appName, token, "", "")
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+		if len(entries) == 0 || next == "" || next == token {
+			break
+		}
+		token = next
+	}
+	return all, nil
+}
+
+// buildTrace assembles the trace of id from entries: the lines mentioning
+// it, and the lines of the machine it was routed to within window of it.
+func buildTrace(id requestID, entries []api.LogEntry, window time.Duration) *Trace {
+	t := &Trace{RequestID: id.ID, EdgeRegion: id.EdgeRegion}
+	if !id.ReceivedAt.IsZero() {
+		t.ReceivedAt = &id.ReceivedAt
+	}
+
+	var matched []api.LogEntry
+	for _, e := range entries {
+		if !mentions(e, id.ID) {
+			continue
+		}
+		matched = append(matched, e)
+
+		if t.Machine == "" && e.Meta.Instance != "" {
+			t.Machine, t.MachineRegion = e.Meta.Instance, e.Meta.Region
+		}
+		if code := e.Meta.HTTP.Response.StatusCode; code != 0 {
+			t.Status = code
+		}
+		if msg := e.Meta.Error.Message; msg != "" {
+			t.Error = msg
+		}
+	}
+
+	// proxy entries name the machine they route to, app entries the one
+	// logging them
+	if t.Machine == "" {
+		for _, e := range matched {
+			if e.Instance != "" {
+				t.Machine, t.MachineRegion = e.Instance, e.Region
+				break
+			}
+		}
+	}
+
+	from, to := correlationWindow(id, matched, window)
+	for _, e := range entries {
+		isMatched := mentions(e, id.ID)
+		ts, err := time.Parse(time.RFC3339Nano, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		ts = ts.UTC()
+
+		if !isMatched {
+			if t.Machine == "" || e.Instance != t.Machine || ts.Before(from) || ts.After(to) {
+				continue
+			}
+		}
+
+		t.Lines = append(t.Lines, Line{
+			Time:     ts,
+			Source:   source(e),
+			Instance: e.Instance,
+			Region:   e.Region,
+			Message:  e.Message,
+			Matched:  isMatched,
+		})
+	}
+	sortLines(t.Lines)
+
+	return t
+}
+
+// correlationWindow returns the times the lines of the machine of a request
+// are included within.
+func correlationWindow(id requestID, matched []api.LogEntry, window time.Duration) (from, to time.Time) {
+	for _, e := range matched {
+		ts, err := time.Parse(time.RFC3339Nano, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		if from.IsZero() || ts.Before(from) {
+			from = ts
+		}
+		if ts.After(to) {
+			to = ts
+		}
+	}
+	if !id.ReceivedAt.IsZero() && (from.IsZero() || id.ReceivedAt.Before(from)) {
+		from = id.ReceivedAt
+	}
+	if to.IsZero() {
+		to = from
+	}
+	return from.Add(-window), to.Add(window)
+}
+
+// mentions reports whether e is about the request id.
+func mentions(e api.LogEntry, id string) bool {
+	return e.Meta.HTTP.Request.ID == id || strings.Contains(e.Message, id)
+}
+
+func source(e api.LogEntry) string {
+	if e.Meta.Event.Provider != "" {
+		return e.Meta.Event.Provider
+	}
+	return "app"
+}
+
+// addMachineEvents adds the events of m within window of the lines of t.
+func addMachineEvents(t *Trace, m *api.Machine, window time.Duration) {
+	var from, to time.Time
+	switch {
+	case len(t.Lines) > 0:
+		from, to = t.Lines[0].Time, t.Lines[len(t.Lines)-1].Time
+	case t.ReceivedAt != nil:
+		from, to = *t.ReceivedAt, *t.ReceivedAt
+	default:
+		return
+	}
+	from, to = from.Add(-window), to.Add(window)
+
+	for _, e := range m.Events {
+		ts := time.UnixMilli(e.Timestamp).UTC()
+		if ts.Before(from) || ts.After(to) {
+			continue
+		}
+
+		msg := fmt.Sprintf("machine %s (%s)", e.Type, e.Status)
+		if e.Request != nil && e.Request.ExitEvent != nil {
+			msg += fmt.Sprintf(", exit code %d", e.Request.ExitEvent.ExitCode)
+			if e.Request.ExitEvent.OOMKilled {
+				msg += ", out of memory"
+			}
+		}
+
+		t.Lines = append(t.Lines, Line{
+			Time:     ts,
+			Source:   "event",
+			Instance: m.ID,
+			Region:   m.Region,
+			Message:  msg,
+		})
+	}
+	sortLines(t.Lines)
+}
+
+func sortLines(lines []Line) {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+}
+
+// hints explains the likely causes of failed requests.
+func hints(t *Trace) []string {
+	var hints []string
+
+	if t.Machine == "" {
+		if len(t.Lines) == 0 {
+			hints = append(hints, "No log lines mention the request, it may be older than the logs the log API keeps, or belong to another app")
+		} else {
+			hints = append(hints, "The request wasn't routed to a machine")
+		}
+	}
+
+	for _, l := range t.Lines {
+		switch msg := strings.ToLower(l.Message); {
+		case l.Source == "event" && strings.Contains(msg, "out of memory"):
+			hints = append(hints, fmt.Sprintf("Machine %s ran out of memory during the request, consider more memory with fly scale memory", l.Instance))
+		case l.Source == "event" && strings.Contains(msg, "machine exit"):
+			hints = append(hints, fmt.Sprintf("Machine %s exited during the request", l.Instance))
+		case strings.Contains(msg, "could not find a good candidate"), strings.Contains(msg, "no known healthy"):
+			hints = append(hints, "The proxy found no machine to route the request to, check their health checks and concurrency limits")
+		case strings.Contains(msg, "connection refused"), strings.Contains(msg, "connection closed"):
+			hints = append(hints, "The machine didn't accept the connection, check the app listens on the internal_port of its service, on 0.0.0.0")
+		}
+	}
+
+	return dedupe(hints)
+}
+
+func dedupe(s []string) []string {
+	seen := map[string]bool{}
+	out := s[:0]
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func renderTrace(w io.Writer, t *Trace) error {
+	routing := "edge " + valueOr(t.EdgeRegion, "unknown")
+	if t.Machine != "" {
+		routing += fmt.Sprintf(" -> machine %s in %s", t.Machine, valueOr(t.MachineRegion, "unknown"))
+	}
+
+	received := "unknown"
+	if t.ReceivedAt != nil {
+		received = t.ReceivedAt.Format(time.RFC3339Nano)
+	}
+
+	status := "unknown"
+	if t.Status != 0 {
+		status = fmt.Sprint(t.Status)
+	}
+	if t.Error != "" {
+		status += ", " + t.Error
+	}
+
+	summary := []string{t.RequestID, received, routing, status}
+	if err := render.VerticalTable(w, "Request", [][]string{summary}, "ID", "Received", "Routing", "Status"); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(t.Lines))
+	for _, l := range t.Lines {
+		marker := ""
+		if l.Matched {
+			marker = "*"
+		}
+		rows = append(rows, []string{offset(t, l.Time), marker, l.Source, l.Instance, l.Region, l.Message})
+	}
+	if err := render.Table(w, "Timeline (* mentions the request)", rows, "Time", "", "Source", "Instance", "Region", "Message"); err != nil {
+		return err
+	}
+
+	for _, h := range t.Hints {
+		fmt.Fprintln(w, h)
+	}
+	return nil
+}
+
+// offset returns the time of ts since the edge received the request of t,
+// or ts itself when that's unknown.
+func offset(t *Trace, ts time.Time) string {
+	if t.ReceivedAt == nil {
+		return ts.Format("15:04:05.000")
+	}
+	d := ts.Sub(*t.ReceivedAt).Round(time.Millisecond)
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func logEntry(ts time.Time, instance, provider, requestID, message string) api.LogEntry {
+	var e api.LogEntry
+	e.Timestamp = ts.Format(time.RFC3339Nano)
+	e.Instance = instance
+	e.Region = "ord"
+	e.Message = message
+	e.Meta.Event.Provider = provider
+	e.Meta.HTTP.Request.ID = requestID
+	return e
+}
+
+func TestParseRequestID(t *testing.T) {
+	received := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	raw := ulid.MustNew(ulid.Timestamp(received), nil).String() + "-iad"
+
+	id, err := parseRequestID(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "iad", id.EdgeRegion)
+	assert.Equal(t, received, id.ReceivedAt)
+
+	id, err = parseRequestID("not-a-ulid")
+	require.NoError(t, err)
+	assert.Equal(t, "ulid", id.EdgeRegion)
+	assert.True(t, id.ReceivedAt.IsZero())
+
+	_, err = parseRequestID(" ")
+	assert.Error(t, err)
+}
+
+func TestBuildTrace(t *testing.T) {
+	received := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	id := requestID{ID: "01H2ZKQ3X5V7MCS0Q9Y6G1T4RA-iad", EdgeRegion: "iad", ReceivedAt: received}
+
+	failed := logEntry(received.Add(40*time.Millisecond), "", "proxy", id.ID, "could not complete HTTP request to instance: connection closed before message completed")
+	failed.Meta.Instance = "148e21"
+	failed.Meta.Region = "ord"
+	failed.Meta.HTTP.Response.StatusCode = 502
+
+	entries := []api.LogEntry{
+		logEntry(received.Add(-time.Minute), "148e21", "app", "", "too early"),
+		logEntry(received.Add(30*time.Millisecond), "148e21", "app", "", "panic: nil map"),
+		failed,
+		logEntry(received.Add(35*time.Millisecond), "999aaa", "app", "", "other machine"),
+		logEntry(received.Add(10*time.Millisecond), "148e21", "app", "", "GET / request_id="+id.ID),
+	}
+
+	tr := buildTrace(id, entries, time.Second)
+	assert.Equal(t, "148e21", tr.Machine)
+	assert.Equal(t, "ord", tr.MachineRegion)
+	assert.Equal(t, 502, tr.Status)
+
+	require.Len(t, tr.Lines, 3)
+	assert.Equal(t, "+10ms", offset(tr, tr.Lines[0].Time))
+	assert.True(t, tr.Lines[0].Matched)
+	assert.Equal(t, "panic: nil map", tr.Lines[1].Message)
+	assert.False(t, tr.Lines[1].Matched)
+	assert.Equal(t, "proxy", tr.Lines[2].Source)
+
+	m := &api.Machine{ID: "148e21", Region: "ord", Events: []*api.MachineEvent{
+		{Type: "exit", Status: "stopped", Timestamp: received.Add(50 * time.Millisecond).UnixMilli(), Request: &api.MachineRequest{
+			ExitEvent: &api.MachineExitEvent{ExitCode: 137, OOMKilled: true},
+		}},
+		{Type: "start", Status: "started", Timestamp: received.Add(-time.Hour).UnixMilli()},
+	}}
+	addMachineEvents(tr, m, time.Second)
+	require.Len(t, tr.Lines, 4)
+	assert.Equal(t, "machine exit (stopped), exit code 137, out of memory", tr.Lines[3].Message)
+
+	assert.Equal(t, []string{
+		"The machine didn't accept the connection, check the app listens on the internal_port of its service, on 0.0.0.0",
+		"Machine 148e21 ran out of memory during the request, consider more memory with fly scale memory",
+	}, hints(tr))
+}
+
+func TestBuildTraceWithoutLogs(t *testing.T) {
+	tr := buildTrace(requestID{ID: "abc-iad", EdgeRegion: "iad"}, nil, time.Second)
+	assert.Empty(t, tr.Machine)
+	assert.Len(t, hints(tr), 1)
+}
@@ -26,7 +26,12 @@ func newUpgrade() *cobra.Command {
 		short = "Checks for available updates and automatically upgrades"
 
 		long = `Checks for an update and if one is available, runs the appropriate
-command to upgrade the application.`
+command to upgrade the application.
+
+How flyctl upgrades depends on how it was installed: installs from the install
+script, Homebrew and Scoop upgrade in place, while for Chocolatey, Snap, apt,
+rpm and Nix installs, which need privileges or are managed by their package
+manager, the command to upgrade is printed instead.`
 	)
 
 	cmd := command.New("upgrade", short, long, runUpgrade)
@@ -58,13 +63,22 @@ func runUpgrade(ctx context.Context) error {
 		return nil
 	}
 
-	homebrew := update.IsUnderHomebrew()
+	inst := update.DetectInstallation()
+	if release.Prerelease && !inst.SupportsPrereleases() {
+		fmt.Fprintf(io.ErrOut, "Prereleases aren't available through %s, only its latest release\n", inst.Method)
+	}
+
+	if !inst.SelfUpgrades() {
+		fmt.Fprintf(io.Out, "flyctl v%s is available. flyctl was installed with %s, upgrade it with:\n\n  %s\n",
+			latest.String(), inst.Method, inst.UpgradeCommand(false))
+		return nil
+	}
 
-	if err = update.UpgradeInPlace(ctx, io, release.Prerelease); err != nil {
+	if err = update.UpgradeInPlace(ctx, io, inst, release.Prerelease && inst.SupportsPrereleases()); err != nil {
 		return err
 	}
 
-	err = printVersionUpgrade(ctx, buildinfo.Version(), homebrew)
+	err = printVersionUpgrade(ctx, buildinfo.Version(), inst.Method == update.InstallHomebrew)
 	if err != nil {
 		terminal.Debugf("Error printing version upgrade: %v", err)
 	}
@@ -81,9 +95,9 @@ func printVersionUpgrade(ctx context.Context, oldVersion semver.Version, homebre
 	)
 
 	if homebrew {
-		currentVer, err = getNewVersionFlyInstaller(ctx)
-	} else {
 		currentVer, err = getNewVersionHomebrew(ctx)
+	} else {
+		currentVer, err = getNewVersionFlyInstaller(ctx)
 	}
 	if err != nil {
 		if strings.Contains(err.Error(), "failed to parse version") {
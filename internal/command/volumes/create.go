@@ -11,6 +11,7 @@ import (
 
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
@@ -108,11 +109,17 @@ func runCreate(ctx context.Context) error {
 		snapshotID = api.StringPointer(flag.GetString(ctx, "snapshot-id"))
 	}
 
+	size := flag.GetInt(ctx, "size")
+	what := fmt.Sprintf("Creating a %dGB volume", size)
+	if err := budget.Check(ctx, app.Organization.Slug, appName, what, func(prices budget.Prices) float64 { return prices.Volume(size) }); err != nil {
+		return err
+	}
+
 	input := api.CreateVolumeInput{
 		AppID:             app.ID,
 		Name:              volumeName,
 		Region:            region.Code,
-		SizeGb:            flag.GetInt(ctx, "size"),
+		SizeGb:            size,
 		Encrypted:         !flag.GetBool(ctx, "no-encryption"),
 		RequireUniqueZone: flag.GetBool(ctx, "require-unique-zone"),
 		SnapshotID:        snapshotID,
@@ -11,8 +11,10 @@ import (
 
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/protect"
 )
 
 func newDestroy() *cobra.Command {
@@ -31,6 +33,7 @@ number to operate. This can be found through the volumes list command`
 
 	flag.Add(cmd,
 		flag.Yes(),
+		protect.ForceFlag(),
 	)
 
 	return cmd
@@ -43,6 +46,10 @@ func runDestroy(ctx context.Context) error {
 		volID  = flag.FirstArg(ctx)
 	)
 
+	if err := protect.CheckDestroy(ctx, config.ProtectedVolume, volID); err != nil {
+		return err
+	}
+
 	if confirm, err := confirmVolumeDelete(ctx, volID); err != nil {
 		return err
 	} else if !confirm {
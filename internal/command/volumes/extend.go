@@ -22,11 +22,14 @@ func newExtend() *cobra.Command {
 	const (
 		long = `Extends a target volume to the size specified. Volumes with attached nomad allocations
 		will be restarted automatically. Machines will require a manual restart to increase the size
-		of the FS.`
+		of the FS.
+
+		With --all, extends every volume of the app to the size specified, optionally only those
+		whose names start with --name-prefix or in --region, after confirming which ones.`
 
 		short = "Extend a target volume"
 
-		usage = "extend <id>"
+		usage = "extend [id]"
 	)
 
 	cmd := command.New(usage, short, long, runExtend,
@@ -34,7 +37,9 @@ func newExtend() *cobra.Command {
 		command.RequireAppName,
 	)
 
-	cmd.Args = cobra.ExactArgs(1)
+	cmd.Args = cobra.MaximumNArgs(1)
+	cmd.Example = `  fly volumes extend vol_123 --size 10
+  fly volumes extend --all --name-prefix data --region ord --size 20`
 
 	flag.Add(cmd,
 		flag.App(),
@@ -48,6 +53,19 @@ func newExtend() *cobra.Command {
 			Name:        "auto-confirm",
 			Description: "Will automatically confirm changes without an interactive prompt.",
 		},
+		flag.Bool{
+			Name:        "all",
+			Description: "Extend all the volumes of the app matching --name-prefix and --region",
+		},
+		flag.String{
+			Name:        "name-prefix",
+			Description: "With --all, only extend volumes whose names start with this prefix",
+		},
+		flag.String{
+			Name:        "region",
+			Shorthand:   "r",
+			Description: "With --all, only extend volumes of this region",
+		},
 	)
 
 	flag.Add(cmd, flag.JSONOutput())
@@ -64,6 +82,15 @@ func runExtend(ctx context.Context) error {
 		volID    = flag.FirstArg(ctx)
 	)
 
+	switch all := flag.GetBool(ctx, "all"); {
+	case all && volID != "":
+		return fmt.Errorf("a volume ID can't be specified with --all")
+	case !all && volID == "":
+		return fmt.Errorf("a volume ID, or --all, must be specified")
+	case !all && (flag.GetString(ctx, "name-prefix") != "" || flag.GetString(ctx, "region") != ""):
+		return fmt.Errorf("--name-prefix and --region can only be used with --all")
+	}
+
 	app, err := client.GetApp(ctx, appName)
 	if err != nil {
 		return err
@@ -74,6 +101,10 @@ func runExtend(ctx context.Context) error {
 		return fmt.Errorf("Volume size must be specified")
 	}
 
+	if flag.GetBool(ctx, "all") {
+		return runExtendAll(ctx, app, sizeGB)
+	}
+
 	if app.PlatformVersion == "nomad" {
 		if !flag.GetBool(ctx, "auto-confirm") {
 			switch confirmed, err := prompt.Confirm(ctx, "Extending this volume will result in a VM restart. Continue?"); {
@@ -0,0 +1,158 @@
+package volumes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// extendResult is the outcome of extending a volume with --all.
+type extendResult struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Region   string `json:"region"`
+	FromSize int    `json:"from_size_gb"`
+	ToSize   int    `json:"to_size_gb"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Statuses of extendResult.
+const (
+	extendStatusExtended = "extended"
+	extendStatusSkipped  = "skipped"
+	extendStatusFailed   = "failed"
+)
+
+// volumesToExtend returns the volumes of volumes matching the --name-prefix
+// and --region filters.
+func volumesToExtend(ctx context.Context, volumes []api.Volume) []api.Volume {
+	var (
+		prefix = flag.GetString(ctx, "name-prefix")
+		region = flag.GetString(ctx, "region")
+		out    []api.Volume
+	)
+	for _, volume := range volumes {
+		if strings.HasPrefix(volume.Name, prefix) && (region == "" || volume.Region == region) {
+			out = append(out, volume)
+		}
+	}
+	return out
+}
+
+func runExtendAll(ctx context.Context, app *api.App, sizeGB int) error {
+	var (
+		cfg      = config.FromContext(ctx)
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		client   = client.FromContext(ctx).API()
+	)
+
+	all, err := client.GetVolumes(ctx, app.Name)
+	if err != nil {
+		return fmt.Errorf("failed retrieving volumes: %w", err)
+	}
+
+	volumes := volumesToExtend(ctx, all)
+	if len(volumes) == 0 {
+		return fmt.Errorf("no volumes of %s match the filters", app.Name)
+	}
+
+	var pending int
+	for _, volume := range volumes {
+		if volume.SizeGb < sizeGB {
+			pending++
+		}
+	}
+
+	if !flag.GetBool(ctx, "auto-confirm") && pending > 0 {
+		msg := fmt.Sprintf("Extend %d volumes of %s to %dGB?", pending, app.Name, sizeGB)
+		if app.PlatformVersion == "nomad" {
+			msg = fmt.Sprintf("Extend %d volumes of %s to %dGB? Their VMs will be restarted.", pending, app.Name, sizeGB)
+		}
+		switch confirmed, err := prompt.Confirm(ctx, msg); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("auto-confirm flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	results := make([]extendResult, 0, len(volumes))
+	var failed int
+	for _, volume := range volumes {
+		result := extendResult{
+			ID:       volume.ID,
+			Name:     volume.Name,
+			Region:   volume.Region,
+			FromSize: volume.SizeGb,
+			ToSize:   volume.SizeGb,
+		}
+
+		if volume.SizeGb >= sizeGB {
+			result.Status = extendStatusSkipped
+			result.Detail = fmt.Sprintf("already %dGB", volume.SizeGb)
+			results = append(results, result)
+			continue
+		}
+
+		if !cfg.JSONOutput {
+			fmt.Fprintf(io.ErrOut, "Extending %s (%s) to %dGB\n", volume.ID, volume.Name, sizeGB)
+		}
+
+		extended, err := client.ExtendVolume(ctx, api.ExtendVolumeInput{VolumeID: volume.ID, SizeGb: sizeGB})
+		if err != nil {
+			failed++
+			result.Status = extendStatusFailed
+			result.Detail = err.Error()
+		} else {
+			result.Status = extendStatusExtended
+			result.ToSize = extended.SizeGb
+		}
+		results = append(results, result)
+	}
+
+	if cfg.JSONOutput {
+		if err := render.JSON(io.Out, results); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, 0, len(results))
+		for _, r := range results {
+			rows = append(rows, []string{
+				r.ID,
+				r.Name,
+				r.Region,
+				strconv.Itoa(r.FromSize) + "GB",
+				strconv.Itoa(r.ToSize) + "GB",
+				r.Status,
+				r.Detail,
+			})
+		}
+		if err := render.Table(io.Out, "", rows, "ID", "Name", "Region", "From", "To", "Status", "Detail"); err != nil {
+			return err
+		}
+
+		if app.PlatformVersion == "machines" && pending > failed {
+			fmt.Fprintln(io.Out, colorize.Yellow("You will need to stop and start the machines of extended volumes to increase the size of their FS"))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to extend %d of %d volumes", failed, pending)
+	}
+	return nil
+}
@@ -3,11 +3,16 @@ package volumes
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/superfly/flyctl/client"
@@ -20,7 +25,12 @@ import (
 
 func newList() *cobra.Command {
 	const (
-		long = "List all the volumes associated with this application."
+		long = `List all the volumes associated with this application.
+
+With --with-machines, also shows the machine each volume is attached to, its
+state and region, and how much of each volume is used. Volumes attached to
+no machine are marked as orphaned. --suggest-prune only lists the orphaned
+volumes older than --min-age, with the commands to destroy them.`
 
 		short = "List the volumes for app"
 	)
@@ -35,15 +45,48 @@ func newList() *cobra.Command {
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
+		flag.String{
+			Name:        "region",
+			Shorthand:   "r",
+			Description: "Only list the volumes of this region",
+		},
+		flag.Bool{
+			Name:        "all-regions",
+			Description: "Summarize the volumes of each region after listing them",
+		},
+		flag.Bool{
+			Name:        "with-machines",
+			Description: "Show the machines volumes are attached to and how much of each volume is used",
+		},
+		flag.Bool{
+			Name:        "suggest-prune",
+			Description: "Only list orphaned volumes older than --min-age, with the commands to destroy them",
+		},
+		flag.Duration{
+			Name:        "min-age",
+			Description: "How old orphaned volumes must be to be suggested for pruning",
+			Default:     7 * 24 * time.Hour,
+		},
 	)
 
 	flag.Add(cmd, flag.JSONOutput())
 	return cmd
 }
 
+// volumeListing is a volume with the machine it's attached to and its usage.
+type volumeListing struct {
+	api.Volume
+	Orphaned      bool   `json:"orphaned"`
+	MachineState  string `json:"machine_state,omitempty"`
+	MachineRegion string `json:"machine_region,omitempty"`
+	UsedBytes     *int64 `json:"used_bytes,omitempty"`
+	TotalBytes    *int64 `json:"total_bytes,omitempty"`
+}
+
 func runList(ctx context.Context) error {
 	cfg := config.FromContext(ctx)
 	client := client.FromContext(ctx).API()
+	io := iostreams.FromContext(ctx)
 
 	appName := appconfig.NameFromContext(ctx)
 
@@ -52,30 +95,148 @@ func runList(ctx context.Context) error {
 		return fmt.Errorf("failed retrieving volumes: %w", err)
 	}
 
-	out := iostreams.FromContext(ctx).Out
+	if region := flag.GetString(ctx, "region"); region != "" {
+		filtered := volumes[:0]
+		for _, volume := range volumes {
+			if volume.Region == region {
+				filtered = append(filtered, volume)
+			}
+		}
+		volumes = filtered
+	}
 
-	if cfg.JSONOutput {
-		return render.JSON(out, volumes)
+	out := io.Out
+
+	withMachines := flag.GetBool(ctx, "with-machines")
+	suggestPrune := flag.GetBool(ctx, "suggest-prune")
+
+	if !withMachines && !suggestPrune {
+		if cfg.JSONOutput {
+			return render.JSON(out, volumes)
+		}
+		if err := renderVolumes(out, volumes); err != nil {
+			return err
+		}
+		return renderRegionSummary(ctx, out, volumes)
 	}
 
-	rows := make([][]string, 0, len(volumes))
+	listings := make([]*volumeListing, 0, len(volumes))
 	for _, volume := range volumes {
-		var attachedVMID string
+		listings = append(listings, &volumeListing{Volume: volume, Orphaned: !volume.IsAttached()})
+	}
+
+	if withMachines {
+		if err := describeAttachments(ctx, appName, listings); err != nil && !cfg.JSONOutput {
+			fmt.Fprintf(io.ErrOut, "Showing volumes without their machines and usage: %s\n", err)
+		}
+	}
+
+	if suggestPrune {
+		minAge := flag.GetDuration(ctx, "min-age")
+		prunable := listings[:0]
+		for _, l := range listings {
+			if l.Orphaned && time.Since(l.CreatedAt) >= minAge {
+				prunable = append(prunable, l)
+			}
+		}
+		listings = prunable
+	}
+
+	if cfg.JSONOutput {
+		return render.JSON(out, listings)
+	}
+
+	if suggestPrune && len(listings) == 0 {
+		fmt.Fprintf(out, "No orphaned volumes older than %s\n", humanize.RelTime(time.Now().Add(-flag.GetDuration(ctx, "min-age")), time.Now(), "", ""))
+		return nil
+	}
+
+	if err := renderVolumeListings(out, listings, withMachines); err != nil {
+		return err
+	}
+
+	if suggestPrune {
+		fmt.Fprintln(out, "These volumes are attached to no machine. Once sure their data isn't needed, destroy them with:")
+		for _, l := range listings {
+			fmt.Fprintf(out, "  fly volumes destroy %s\n", l.ID)
+		}
+		return nil
+	}
+
+	volumes = volumes[:0]
+	for _, l := range listings {
+		volumes = append(volumes, l.Volume)
+	}
+	return renderRegionSummary(ctx, out, volumes)
+}
+
+// describeAttachments fills listings with the state and region of the
+// machines they're attached to, and their usage.
+func describeAttachments(ctx context.Context, appName string, listings []*volumeListing) error {
+	flapsClient, err := flaps.NewFromAppName(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	machinesByID := make(map[string]*api.Machine, len(machines))
+	for _, m := range machines {
+		machinesByID[m.ID] = m
+	}
+
+	usage, err := flapsClient.GetVolumes(ctx)
+	if err != nil {
+		return err
+	}
+	usageByID := make(map[string]api.MachinesVolume, len(usage))
+	for _, v := range usage {
+		usageByID[v.ID] = v
+	}
 
-		if volume.App.PlatformVersion == "machines" {
-			if volume.AttachedMachine != nil {
-				attachedVMID = volume.AttachedMachine.ID
+	for _, l := range listings {
+		if l.AttachedMachine != nil {
+			if m, ok := machinesByID[l.AttachedMachine.ID]; ok {
+				l.MachineState = m.State
+				l.MachineRegion = m.Region
 			}
-		} else {
-			if volume.AttachedAllocation != nil {
-				attachedVMID = volume.AttachedAllocation.IDShort
+		}
 
-				if volume.AttachedAllocation.TaskName != "app" {
-					attachedVMID = fmt.Sprintf("%s (%s)", volume.AttachedAllocation.IDShort, volume.AttachedAllocation.TaskName)
-				}
+		if v, ok := usageByID[l.ID]; ok {
+			if used, ok := v.UsedBytes(); ok {
+				total := v.Blocks * v.BlockSize
+				l.UsedBytes = &used
+				l.TotalBytes = &total
 			}
 		}
+	}
+	return nil
+}
 
+// attachedVMID returns the ID of the machine or allocation volume is
+// attached to.
+func attachedVMID(volume api.Volume) string {
+	if volume.App.PlatformVersion == "machines" {
+		if volume.AttachedMachine != nil {
+			return volume.AttachedMachine.ID
+		}
+		return ""
+	}
+
+	if volume.AttachedAllocation != nil {
+		if volume.AttachedAllocation.TaskName != "app" {
+			return fmt.Sprintf("%s (%s)", volume.AttachedAllocation.IDShort, volume.AttachedAllocation.TaskName)
+		}
+		return volume.AttachedAllocation.IDShort
+	}
+	return ""
+}
+
+func renderVolumes(out io.Writer, volumes []api.Volume) error {
+	rows := make([][]string, 0, len(volumes))
+	for _, volume := range volumes {
 		rows = append(rows, []string{
 			volume.ID,
 			volume.State,
@@ -84,10 +245,89 @@ func runList(ctx context.Context) error {
 			volume.Region,
 			volume.Host.ID,
 			fmt.Sprint(volume.Encrypted),
-			attachedVMID,
+			attachedVMID(volume),
 			humanize.Time(volume.CreatedAt),
 		})
 	}
 
 	return render.Table(out, "", rows, "ID", "State", "Name", "Size", "Region", "Zone", "Encrypted", "Attached VM", "Created At")
 }
+
+func renderVolumeListings(out io.Writer, listings []*volumeListing, withMachines bool) error {
+	rows := make([][]string, 0, len(listings))
+	for _, l := range listings {
+		attached := attachedVMID(l.Volume)
+		if l.Orphaned {
+			attached = "orphaned"
+		}
+
+		row := []string{
+			l.ID,
+			l.State,
+			l.Name,
+			strconv.Itoa(l.SizeGb) + "GB",
+			l.Region,
+			attached,
+		}
+		if withMachines {
+			used := "-"
+			if l.UsedBytes != nil && *l.TotalBytes > 0 {
+				used = fmt.Sprintf("%s (%d%%)", humanize.Bytes(uint64(*l.UsedBytes)), *l.UsedBytes*100 / *l.TotalBytes)
+			}
+			row = append(row, l.MachineState, l.MachineRegion, used)
+		}
+		rows = append(rows, append(row, humanize.Time(l.CreatedAt)))
+	}
+
+	cols := []string{"ID", "State", "Name", "Size", "Region", "Attached VM"}
+	if withMachines {
+		cols = append(cols, "Machine State", "Machine Region", "Used")
+	}
+	return render.Table(out, "", rows, append(cols, "Created At")...)
+}
+
+// renderRegionSummary renders how many volumes each region has, and how many
+// of them are orphaned, with --all-regions.
+func renderRegionSummary(ctx context.Context, out io.Writer, volumes []api.Volume) error {
+	if !flag.GetBool(ctx, "all-regions") {
+		return nil
+	}
+
+	type summary struct {
+		count, attached, sizeGb int
+	}
+	regions := map[string]*summary{}
+	for _, volume := range volumes {
+		s, ok := regions[volume.Region]
+		if !ok {
+			s = &summary{}
+			regions[volume.Region] = s
+		}
+		s.count++
+		s.sizeGb += volume.SizeGb
+		if volume.IsAttached() {
+			s.attached++
+		}
+	}
+
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		s := regions[name]
+		rows = append(rows, []string{
+			name,
+			strconv.Itoa(s.count),
+			strconv.Itoa(s.attached),
+			strconv.Itoa(s.count - s.attached),
+			strconv.Itoa(s.sizeGb) + "GB",
+		})
+	}
+
+	fmt.Fprintln(out)
+	return render.Table(out, "Regions", rows, "Region", "Volumes", "Attached", "Orphaned", "Size")
+}
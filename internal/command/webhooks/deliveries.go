@@ -0,0 +1,184 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newDeliveries() *cobra.Command {
+	const (
+		long = `List the recent deliveries of the webhooks of an app, newest first. The
+webhooks app remembers the last 500 deliveries since it last started.`
+		short = "List recent webhook deliveries"
+	)
+
+	cmd := command.New("deliveries", short, long, runDeliveries,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "webhook",
+			Description: "Only list the deliveries of this webhook",
+		},
+	)
+
+	return cmd
+}
+
+func newRedeliver() *cobra.Command {
+	const (
+		long  = `Post the event of a delivery to its webhook again, as a new delivery.`
+		short = "Redeliver a webhook delivery"
+	)
+
+	cmd := command.New("redeliver <delivery-id>", short, long, runRedeliver,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func runDeliveries(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	query := url.Values{}
+	if webhook := flag.GetString(ctx, "webhook"); webhook != "" {
+		query.Set("webhook", webhook)
+	}
+
+	var deliveries []Delivery
+	if err := requestDispatcher(ctx, http.MethodGet, "/deliveries", query, &deliveries); err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, deliveries)
+	}
+
+	rows := make([][]string, 0, len(deliveries))
+	for _, d := range deliveries {
+		rows = append(rows, deliveryRow(d))
+	}
+
+	return render.Table(out, "", rows, deliveryColumns...)
+}
+
+func runRedeliver(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	var delivery Delivery
+	if err := requestDispatcher(ctx, http.MethodPost, "/deliveries/"+url.PathEscape(flag.FirstArg(ctx))+"/redeliver", nil, &delivery); err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, delivery)
+	}
+
+	return render.Table(out, "", [][]string{deliveryRow(delivery)}, deliveryColumns...)
+}
+
+var deliveryColumns = []string{"ID", "Webhook", "Event", "Attempts", "Status", "Delivered"}
+
+func deliveryRow(d Delivery) []string {
+	status := strconv.Itoa(d.StatusCode)
+	if d.Error != "" {
+		status = d.Error
+	}
+	return []string{
+		d.ID,
+		d.Webhook,
+		d.Event.Type,
+		strconv.Itoa(d.Attempts),
+		status,
+		d.DeliveredAt.Format(time.RFC3339),
+	}
+}
+
+// requestDispatcher requests path of the dispatcher machine of the app over
+// the private network of its organization, decoding the response into v.
+func requestDispatcher(ctx context.Context, method, path string, query url.Values, v any) error {
+	appName := appconfig.NameFromContext(ctx)
+
+	d, err := findDispatcher(ctx, appName)
+	switch {
+	case err != nil:
+		return err
+	case d == nil || d.machine == nil:
+		return fmt.Errorf("%s has no webhooks", appName)
+	}
+
+	agentclient, err := agent.Establish(ctx, client.FromContext(ctx).API())
+	if err != nil {
+		return err
+	}
+
+	dialer, err := agentclient.ConnectToTunnel(ctx, d.app.Organization.Slug)
+	if err != nil {
+		return err
+	}
+
+	httpClient := &http.Client{
+		Timeout:   time.Minute,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+
+	endpoint := fmt.Sprintf("http://%s%s", net.JoinHostPort(d.machine.PrivateIP, strconv.Itoa(dispatcherPort)), path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed reaching the webhooks app: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && method == http.MethodPost:
+		return errors.New("no such delivery, or its webhook was removed")
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("webhooks app responded with %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,491 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	EventDeployStarted  = "deploy.started"
+	EventDeployFinished = "deploy.finished"
+	EventMachineCrashed = "machine.crashed"
+	EventCertExpiring   = "cert.expiring"
+)
+
+var eventTypes = []string{EventDeployStarted, EventDeployFinished, EventMachineCrashed, EventCertExpiring}
+
+const (
+	// defaultInterval is how often the dispatcher polls the app for events.
+	defaultInterval = 30 * time.Second
+	// certExpiryWindow is how long before they expire certificates are
+	// reported expiring.
+	certExpiryWindow = 14 * 24 * time.Hour
+	// maxDeliveries is the number of deliveries the dispatcher remembers.
+	maxDeliveries = 500
+	// maxAttempts is how many times events are posted before giving up.
+	maxAttempts = 3
+)
+
+// finishedReleaseStatuses are the statuses of releases done deploying.
+var finishedReleaseStatuses = []string{"complete", "failed", "interrupted"}
+
+// Event is an event of an app, as posted to webhooks.
+type Event struct {
+	ID   string         `json:"id"`
+	Type string         `json:"type"`
+	App  string         `json:"app"`
+	At   time.Time      `json:"at"`
+	Data map[string]any `json:"data"`
+}
+
+// Delivery is an attempt to post an event to a webhook, as recorded by the
+// dispatcher.
+type Delivery struct {
+	ID          string    `json:"id"`
+	Webhook     string    `json:"webhook"`
+	Event       Event     `json:"event"`
+	Attempts    int       `json:"attempts"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// Succeeded reports whether the webhook accepted the event.
+func (d Delivery) Succeeded() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+func newDispatch() *cobra.Command {
+	const (
+		short = "Dispatch the webhooks of an app"
+		long  = `Dispatch the webhooks of an app, in the webhooks app's machine.`
+	)
+
+	cmd := command.New("dispatch", short, long, runDispatch)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "config",
+			Description: "Path of the webhooks configuration",
+			Default:     dispatcherConfigPath,
+		},
+	)
+
+	return cmd
+}
+
+func runDispatch(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	data, err := os.ReadFile(flag.GetString(ctx, "config"))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return err
+	}
+	if err := loadSecrets(&cfg); err != nil {
+		return err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+
+	flapsClient, err := flaps.NewFromAppName(ctx, cfg.App)
+	if err != nil {
+		return err
+	}
+
+	src := &appSource{app: cfg.App, apiClient: client.FromContext(ctx).API(), flapsClient: flapsClient}
+	w := newWatcher(cfg.App)
+	s := newSender(out, cfg.Webhooks)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", dispatcherPort))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(out, "Dispatching %d webhooks of %s\n", len(cfg.Webhooks), cfg.App)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := w.poll(ctx, src, time.Now())
+		if err != nil {
+			fmt.Fprintf(out, "Failed polling %s: %v\n", cfg.App, err)
+		}
+		for _, event := range events {
+			s.dispatch(ctx, event)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// source is where the dispatcher polls the state of the app from.
+type source interface {
+	releases(ctx context.Context) ([]api.Release, error)
+	machines(ctx context.Context) ([]*api.Machine, error)
+	certificates(ctx context.Context) ([]api.AppCertificate, error)
+}
+
+type appSource struct {
+	app         string
+	apiClient   *api.Client
+	flapsClient *flaps.Client
+}
+
+func (s *appSource) releases(ctx context.Context) ([]api.Release, error) {
+	return s.apiClient.GetAppReleasesMachines(ctx, s.app, "", 10)
+}
+
+func (s *appSource) machines(ctx context.Context) ([]*api.Machine, error) {
+	return s.flapsClient.List(ctx, "")
+}
+
+func (s *appSource) certificates(ctx context.Context) ([]api.AppCertificate, error) {
+	return s.apiClient.GetAppIssuedCertificates(ctx, s.app)
+}
+
+// watcher turns changes in the state of an app into events. Its first poll
+// only records the state of releases and machines, to not report past events.
+type watcher struct {
+	app    string
+	primed bool
+
+	// releases are the statuses of releases, by version.
+	releases map[int]string
+	// lastMachineEvents are the timestamps of the latest events of
+	// machines.
+	lastMachineEvents map[string]int64
+	// expiring are the certificates reported expiring, by hostname and
+	// expiry.
+	expiring map[string]bool
+}
+
+func newWatcher(app string) *watcher {
+	return &watcher{
+		app:               app,
+		releases:          map[int]string{},
+		lastMachineEvents: map[string]int64{},
+		expiring:          map[string]bool{},
+	}
+}
+
+// poll returns the events of the app since the previous poll.
+func (w *watcher) poll(ctx context.Context, src source, now time.Time) ([]Event, error) {
+	releases, err := src.releases(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing releases: %w", err)
+	}
+	machines, err := src.machines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing machines: %w", err)
+	}
+	certs, err := src.certificates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing certificates: %w", err)
+	}
+
+	events := w.releaseEvents(releases, now)
+	events = append(events, w.machineEvents(machines)...)
+	events = append(events, w.certEvents(certs, now)...)
+	w.primed = true
+
+	for i := range events {
+		id, err := helpers.RandString(12)
+		if err != nil {
+			return nil, err
+		}
+		events[i].ID = "evt_" + strings.ToLower(id)
+		events[i].App = w.app
+	}
+	return events, nil
+}
+
+func (w *watcher) releaseEvents(releases []api.Release, now time.Time) (events []Event) {
+	// oldest first
+	slices.SortFunc(releases, func(a, b api.Release) bool { return a.Version < b.Version })
+
+	for _, r := range releases {
+		previous, seen := w.releases[r.Version]
+		w.releases[r.Version] = r.Status
+		if !w.primed || previous == r.Status {
+			continue
+		}
+
+		data := map[string]any{
+			"version":     r.Version,
+			"status":      r.Status,
+			"description": r.Description,
+			"image":       r.ImageRef,
+			"user":        r.User.Email,
+		}
+		if !seen {
+			events = append(events, Event{Type: EventDeployStarted, At: r.CreatedAt, Data: data})
+		}
+		if slices.Contains(finishedReleaseStatuses, r.Status) {
+			events = append(events, Event{Type: EventDeployFinished, At: now, Data: data})
+		}
+	}
+	return events
+}
+
+func (w *watcher) machineEvents(machines []*api.Machine) (events []Event) {
+	for _, m := range machines {
+		last := w.lastMachineEvents[m.ID]
+		latest := last
+		for _, e := range m.Events {
+			if e.Timestamp > latest {
+				latest = e.Timestamp
+			}
+			if !w.primed || e.Timestamp <= last || e.Type != "exit" || e.Request == nil {
+				continue
+			}
+
+			exit := e.Request.ExitEvent
+			if e.Request.MonitorEvent != nil && e.Request.MonitorEvent.ExitEvent != nil {
+				exit = e.Request.MonitorEvent.ExitEvent
+			}
+			if exit == nil || exit.RequestedStop || (exit.ExitCode == 0 && !exit.OOMKilled) {
+				continue
+			}
+
+			events = append(events, Event{
+				Type: EventMachineCrashed,
+				At:   time.UnixMilli(e.Timestamp).UTC(),
+				Data: map[string]any{
+					"machine":       m.ID,
+					"region":        m.Region,
+					"process_group": m.ProcessGroup(),
+					"exit_code":     exit.ExitCode,
+					"oom_killed":    exit.OOMKilled,
+					"restarting":    exit.Restarting,
+				},
+			})
+		}
+		w.lastMachineEvents[m.ID] = latest
+	}
+	return events
+}
+
+func (w *watcher) certEvents(certs []api.AppCertificate, now time.Time) (events []Event) {
+	for _, cert := range certs {
+		for _, issued := range cert.Issued.Nodes {
+			key := cert.Hostname + "@" + issued.ExpiresAt.String()
+			if issued.ExpiresAt.IsZero() || issued.ExpiresAt.Sub(now) > certExpiryWindow || w.expiring[key] {
+				continue
+			}
+			w.expiring[key] = true
+
+			events = append(events, Event{
+				Type: EventCertExpiring,
+				At:   now,
+				Data: map[string]any{
+					"hostname":   cert.Hostname,
+					"type":       issued.Type,
+					"expires_at": issued.ExpiresAt,
+				},
+			})
+		}
+	}
+	return events
+}
+
+// sender posts events to the webhooks subscribing to them, and serves its
+// recent deliveries.
+type sender struct {
+	out        io.Writer
+	webhooks   []Webhook
+	httpClient *http.Client
+	retryDelay time.Duration
+	now        func() time.Time
+
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+func newSender(out io.Writer, webhooks []Webhook) *sender {
+	return &sender{
+		out:        out,
+		webhooks:   webhooks,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retryDelay: 5 * time.Second,
+		now:        time.Now,
+	}
+}
+
+// dispatch posts event to the webhooks subscribing to it.
+func (s *sender) dispatch(ctx context.Context, event Event) {
+	for _, hook := range s.webhooks {
+		if hook.Subscribes(event.Type) {
+			s.deliver(ctx, hook, event)
+		}
+	}
+}
+
+// deliver posts event to hook, retrying failed attempts, and records the
+// delivery.
+func (s *sender) deliver(ctx context.Context, hook Webhook, event Event) Delivery {
+	id, _ := helpers.RandString(12)
+	delivery := Delivery{ID: "dlv_" + strings.ToLower(id), Webhook: hook.ID, Event: event}
+
+	for delivery.Attempts < maxAttempts {
+		delivery.Attempts++
+		delivery.StatusCode, delivery.Error = 0, ""
+
+		status, err := s.post(ctx, hook, delivery)
+		delivery.StatusCode = status
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if delivery.Succeeded() || delivery.Attempts == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			delivery.Error = ctx.Err().Error()
+		case <-time.After(time.Duration(delivery.Attempts) * s.retryDelay):
+			continue
+		}
+		break
+	}
+	delivery.DeliveredAt = s.now().UTC()
+
+	result := "Delivered"
+	if !delivery.Succeeded() {
+		result = "Failed delivering"
+	}
+	fmt.Fprintf(s.out, "%s %s %s to %s after %d attempts\n", result, event.Type, delivery.ID, hook.URL, delivery.Attempts)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+	if len(s.deliveries) > maxDeliveries {
+		s.deliveries = s.deliveries[len(s.deliveries)-maxDeliveries:]
+	}
+	return delivery
+}
+
+func (s *sender) post(ctx context.Context, hook Webhook, delivery Delivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "fly-webhooks")
+	req.Header.Set("Fly-Event", delivery.Event.Type)
+	req.Header.Set("Fly-Delivery", delivery.ID)
+	req.Header.Set("Fly-Signature", Sign(hook.Secret, s.now(), body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the Fly-Signature header of a delivery of body at t:
+// t=<unix time>,v1=<hex encoded HMAC-SHA256 of the time, a dot and body>.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves the recent deliveries, newest first, on GET /deliveries,
+// and redelivers them on POST /deliveries/<id>/redeliver.
+func (s *sender) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/deliveries":
+		webhook := r.URL.Query().Get("webhook")
+
+		s.mu.Lock()
+		deliveries := make([]Delivery, 0, len(s.deliveries))
+		for i := len(s.deliveries) - 1; i >= 0; i-- {
+			if webhook == "" || s.deliveries[i].Webhook == webhook {
+				deliveries = append(deliveries, s.deliveries[i])
+			}
+		}
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deliveries)
+
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/deliveries/") && strings.HasSuffix(r.URL.Path, "/redeliver"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/deliveries/"), "/redeliver")
+
+		s.mu.Lock()
+		i := slices.IndexFunc(s.deliveries, func(d Delivery) bool { return d.ID == id })
+		var previous Delivery
+		if i >= 0 {
+			previous = s.deliveries[i]
+		}
+		s.mu.Unlock()
+
+		hookIndex := slices.IndexFunc(s.webhooks, func(hook Webhook) bool { return hook.ID == previous.Webhook })
+		if i < 0 || hookIndex < 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		delivery := s.deliver(r.Context(), s.webhooks[hookIndex], previous.Event)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(delivery)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
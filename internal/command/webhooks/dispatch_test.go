@@ -0,0 +1,176 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+type fakeSource struct {
+	releaseList []api.Release
+	machineList []*api.Machine
+	certList    []api.AppCertificate
+}
+
+func (s *fakeSource) releases(context.Context) ([]api.Release, error)  { return s.releaseList, nil }
+func (s *fakeSource) machines(context.Context) ([]*api.Machine, error) { return s.machineList, nil }
+func (s *fakeSource) certificates(context.Context) ([]api.AppCertificate, error) {
+	return s.certList, nil
+}
+
+func exitEvent(ts int64, code int, requestedStop bool) *api.MachineEvent {
+	return &api.MachineEvent{
+		Type:      "exit",
+		Timestamp: ts,
+		Request: &api.MachineRequest{
+			ExitEvent: &api.MachineExitEvent{ExitCode: code, RequestedStop: requestedStop},
+		},
+	}
+}
+
+func eventTypesOf(events []Event) []string {
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestWatcherPoll(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	src := &fakeSource{
+		releaseList: []api.Release{{Version: 1, Status: "complete"}},
+		machineList: []*api.Machine{{ID: "m1", Events: []*api.MachineEvent{exitEvent(1000, 1, false)}}},
+	}
+	w := newWatcher("my-app")
+
+	events, err := w.poll(ctx, src, now)
+	require.NoError(t, err)
+	assert.Empty(t, events, "the first poll doesn't report past events")
+
+	src.releaseList = []api.Release{{Version: 2, Status: "running"}, {Version: 1, Status: "complete"}}
+	src.machineList[0].Events = []*api.MachineEvent{
+		exitEvent(3000, 0, true),
+		exitEvent(2000, 137, false),
+		exitEvent(1000, 1, false),
+	}
+	events, err = w.poll(ctx, src, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{EventDeployStarted, EventMachineCrashed}, eventTypesOf(events))
+	assert.Equal(t, "my-app", events[0].App)
+	assert.Equal(t, 137, events[1].Data["exit_code"])
+
+	src.releaseList = []api.Release{{Version: 3, Status: "failed"}, {Version: 2, Status: "complete"}}
+	src.certList = []api.AppCertificate{{Hostname: "example.com"}}
+	src.certList[0].Issued.Nodes = append(src.certList[0].Issued.Nodes, struct {
+		ExpiresAt time.Time
+		Type      string
+	}{ExpiresAt: now.Add(7 * 24 * time.Hour), Type: "rsa"})
+	events, err = w.poll(ctx, src, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{EventDeployFinished, EventDeployStarted, EventDeployFinished, EventCertExpiring}, eventTypesOf(events))
+	assert.Equal(t, 2, events[0].Data["version"])
+	assert.Equal(t, 3, events[1].Data["version"])
+
+	events, err = w.poll(ctx, src, now)
+	require.NoError(t, err)
+	assert.Empty(t, events, "events are reported once")
+}
+
+func TestSenderDeliverAndRedeliver(t *testing.T) {
+	var (
+		calls     int
+		signature string
+		body      []byte
+	)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		signature = r.Header.Get("Fly-Signature")
+		body, _ = io.ReadAll(r.Body)
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer target.Close()
+
+	now := time.Unix(1685620800, 0)
+	hook := Webhook{ID: "wh_1", URL: target.URL, Events: []string{EventMachineCrashed}, Secret: "shh"}
+	s := newSender(io.Discard, []Webhook{hook})
+	s.retryDelay = time.Millisecond
+	s.now = func() time.Time { return now }
+
+	s.dispatch(context.Background(), Event{ID: "evt_1", Type: EventDeployStarted})
+	assert.Zero(t, calls, "webhooks only get the events they subscribe to")
+
+	s.dispatch(context.Background(), Event{ID: "evt_2", Type: EventMachineCrashed})
+	require.Len(t, s.deliveries, 1)
+	delivery := s.deliveries[0]
+	assert.True(t, delivery.Succeeded())
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, Sign("shh", now, body), signature)
+	assert.Regexp(t, `^t=1685620800,v1=[0-9a-f]{64}$`, signature)
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/deliveries/"+delivery.ID+"/redeliver", "", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var redelivery Delivery
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&redelivery))
+	assert.NotEqual(t, delivery.ID, redelivery.ID)
+	assert.Equal(t, "evt_2", redelivery.Event.ID)
+	assert.Equal(t, 3, calls)
+
+	resp, err = http.Get(server.URL + "/deliveries?webhook=wh_1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var deliveries []Delivery
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&deliveries))
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, redelivery.ID, deliveries[0].ID, "newest first")
+
+	resp, err = http.Post(server.URL+"/deliveries/dlv_unknown/redeliver", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebhookValidate(t *testing.T) {
+	assert.NoError(t, Webhook{URL: "https://example.com/hook", Events: eventTypes}.validate())
+	assert.ErrorContains(t, Webhook{}.validate(), "--url is required")
+	assert.ErrorContains(t, Webhook{URL: "example.com"}.validate(), "absolute")
+	assert.ErrorContains(t, Webhook{URL: "https://example.com", Events: []string{"app.exploded"}}.validate(), "unknown event app.exploded")
+}
+
+func TestConfigLeavesOutSecrets(t *testing.T) {
+	cfg := dispatcherConfig{
+		App:      "my-app",
+		Webhooks: []Webhook{{ID: "wh_abc123", URL: "https://example.com", Secret: "whsec_shh"}},
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "whsec_shh")
+
+	decoded, err := decodeConfig(data)
+	require.NoError(t, err)
+	assert.Error(t, loadSecrets(&decoded))
+
+	t.Setenv("WEBHOOK_SECRET_ABC123", "whsec_shh")
+	require.NoError(t, loadSecrets(&decoded))
+	assert.Equal(t, "whsec_shh", decoded.Webhooks[0].Secret)
+}
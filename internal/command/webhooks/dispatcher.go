@@ -0,0 +1,255 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	dispatcherAppSuffix   = "-webhooks"
+	dispatcherMachineName = "webhooks"
+	dispatcherConfigPath  = "/etc/fly-webhooks/config.json"
+	dispatcherPort        = 8080
+
+	// secretEnvPrefix prefixes the secrets of the webhooks app holding the
+	// signing secrets of webhooks, which the config only names by ID.
+	secretEnvPrefix = "WEBHOOK_SECRET_"
+)
+
+// dispatcherConfig configures the webhooks of an app.
+type dispatcherConfig struct {
+	App      string        `json:"app"`
+	Webhooks []Webhook     `json:"webhooks"`
+	Interval time.Duration `json:"interval,omitempty"`
+}
+
+func dispatcherAppName(appName string) string {
+	return appName + dispatcherAppSuffix
+}
+
+// secretEnvName returns the name of the secret of the webhooks app holding
+// the signing secret of the webhook with the given ID.
+func secretEnvName(id string) string {
+	return secretEnvPrefix + strings.ToUpper(strings.TrimPrefix(id, "wh_"))
+}
+
+// loadSecrets sets the signing secrets of the webhooks of cfg from the
+// environment of the dispatcher machine.
+func loadSecrets(cfg *dispatcherConfig) error {
+	for i := range cfg.Webhooks {
+		hook := &cfg.Webhooks[i]
+		if hook.Secret = os.Getenv(secretEnvName(hook.ID)); hook.Secret == "" {
+			return fmt.Errorf("the signing secret of webhook %s isn't set", hook.ID)
+		}
+	}
+	return nil
+}
+
+// dispatcher is the webhooks app of an app and its machine.
+type dispatcher struct {
+	app         *api.AppCompact
+	flapsClient *flaps.Client
+	machine     *api.Machine
+}
+
+// findDispatcher returns the dispatcher of the app, or nil if it has none.
+func findDispatcher(ctx context.Context, appName string) (*dispatcher, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	app, err := apiClient.GetAppCompact(ctx, dispatcherAppName(appName))
+	switch {
+	case api.IsNotFoundError(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dispatcher{app: app, flapsClient: flapsClient}
+	for _, m := range machines {
+		if m.Name == dispatcherMachineName {
+			d.machine = m
+		}
+	}
+	return d, nil
+}
+
+// ensureDispatcher returns the dispatcher of app, creating its app with a
+// read-only token of app to watch it with.
+func ensureDispatcher(ctx context.Context, app *api.AppCompact) (*dispatcher, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	d, err := findDispatcher(ctx, app.Name)
+	if err != nil || d != nil {
+		return d, err
+	}
+
+	name := dispatcherAppName(app.Name)
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = app.Organization.ID
+	input.Name = name
+
+	if _, err := gql.CreateApp(ctx, apiClient.GenqClient, input); err != nil {
+		return nil, fmt.Errorf("failed creating webhooks app %s: %w", name, err)
+	}
+	fmt.Fprintf(io.ErrOut, "Created webhooks app %s\n", name)
+
+	token, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient, name, app.Organization.ID, "read_organization_apps", &gql.LimitedAccessTokenOptions{
+		"app_ids": []string{app.Name},
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the token of the webhooks app: %w", err)
+	}
+
+	if _, err := apiClient.SetSecrets(ctx, name, map[string]string{
+		"FLY_API_TOKEN": token.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader,
+	}); err != nil {
+		return nil, fmt.Errorf("failed setting the token of the webhooks app: %w", err)
+	}
+
+	return findDispatcher(ctx, app.Name)
+}
+
+// config returns the configuration of the dispatcher machine.
+func (d *dispatcher) config() (dispatcherConfig, error) {
+	if d == nil || d.machine == nil || d.machine.Config == nil {
+		return dispatcherConfig{}, nil
+	}
+
+	for _, f := range d.machine.Config.Files {
+		if f.GuestPath != dispatcherConfigPath || f.RawValue == nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(*f.RawValue)
+		if err != nil {
+			return dispatcherConfig{}, fmt.Errorf("failed decoding webhooks: %w", err)
+		}
+		return decodeConfig(data)
+	}
+	return dispatcherConfig{}, nil
+}
+
+func decodeConfig(data []byte) (dispatcherConfig, error) {
+	var cfg dispatcherConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed decoding webhooks: %w", err)
+	}
+	return cfg, nil
+}
+
+// setSecret stores the signing secret of hook as a secret of the webhooks app,
+// for the dispatcher machine to pick up when next updated or launched.
+func (d *dispatcher) setSecret(ctx context.Context, hook Webhook) error {
+	apiClient := client.FromContext(ctx).API()
+
+	if _, err := apiClient.SetSecrets(ctx, d.app.Name, map[string]string{
+		secretEnvName(hook.ID): hook.Secret,
+	}); err != nil {
+		return fmt.Errorf("failed storing the secret of webhook %s: %w", hook.ID, err)
+	}
+	return nil
+}
+
+// unsetSecret drops the signing secret of the webhook with the given ID from
+// the secrets of the webhooks app.
+func (d *dispatcher) unsetSecret(ctx context.Context, id string) error {
+	apiClient := client.FromContext(ctx).API()
+
+	if _, err := apiClient.UnsetSecrets(ctx, d.app.Name, []string{secretEnvName(id)}); err != nil {
+		return fmt.Errorf("failed dropping the secret of webhook %s: %w", id, err)
+	}
+	return nil
+}
+
+// setConfig launches or updates the dispatcher machine to run cfg. Signing
+// secrets are left out of cfg, since they are secrets of the webhooks app.
+func (d *dispatcher) setConfig(ctx context.Context, cfg dispatcherConfig) error {
+	io := iostreams.FromContext(ctx)
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	config := &api.MachineConfig{
+		Init: api.MachineInit{
+			Entrypoint: []string{"flyctl"},
+			Cmd:        []string{"webhooks", "dispatch", "--config", dispatcherConfigPath},
+		},
+		Guest: &api.MachineGuest{
+			CPUKind:  "shared",
+			CPUs:     1,
+			MemoryMB: 256,
+		},
+		Files: []*api.File{{GuestPath: dispatcherConfigPath, RawValue: &encoded}},
+	}
+	infra.Configure(config, infra.ComponentFlyctl, d.machine)
+
+	ctx = flaps.NewContext(ctx, d.flapsClient)
+
+	if d.machine != nil {
+		machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, d.machine)
+		defer releaseLeaseFunc(ctx, machine)
+		if err != nil {
+			return err
+		}
+
+		return mach.Update(ctx, machine, &api.LaunchMachineInput{
+			Name:   dispatcherMachineName,
+			Region: machine.Region,
+			Config: config,
+		})
+	}
+
+	costDelta := func(prices budget.Prices) float64 { return prices.Machine(config.Guest) }
+	if err := budget.Check(ctx, d.app.Organization.Slug, d.app.Name, "Launching a webhooks machine", costDelta); err != nil {
+		return err
+	}
+
+	region, err := gql.GetNearestRegion(ctx, client.FromContext(ctx).API().GenqClient)
+	if err != nil {
+		return err
+	}
+
+	machine, err := d.flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Name:   dispatcherMachineName,
+		Region: region.NearestRegion.Code,
+		Config: config,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching webhooks machine: %w", err)
+	}
+	fmt.Fprintf(io.ErrOut, "Launched webhooks machine %s in %s\n", machine.ID, machine.Region)
+
+	d.machine = machine
+	return nil
+}
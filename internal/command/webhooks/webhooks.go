@@ -0,0 +1,314 @@
+// Package webhooks implements the webhooks command chain.
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// New initializes and returns a new webhooks Command.
+func New() *cobra.Command {
+	const (
+		long = `Post the events of an app to URLs. A webhooks app, created in the
+organization of the app along with its first webhook, with a read-only token
+of the app, watches the app for events and posts them as signed JSON.
+
+Events are deploy.started and deploy.finished when releases start and finish,
+machine.crashed when a machine exits without being asked to stop, and
+cert.expiring when a certificate of the app expires within two weeks.`
+		short = "Manage the webhooks of an app"
+	)
+
+	cmd := command.New("webhooks", short, long, nil)
+
+	cmd.AddCommand(
+		newCreate(),
+		newList(),
+		newRemove(),
+		newDeliveries(),
+		newRedeliver(),
+		newDispatch(),
+	)
+
+	return cmd
+}
+
+func newCreate() *cobra.Command {
+	const (
+		long = `Post events of the app to a URL. Deliveries are signed with the webhook's
+secret, generated unless --secret is given: the Fly-Signature header is
+t=<unix time>,v1=<signature>, where the signature is the hex encoded
+HMAC-SHA256 of the time, a dot and the body. The secret is stored as a secret
+of the webhooks app.`
+		short = "Post events of an app to a URL"
+	)
+
+	cmd := command.New("create", short, long, runCreate,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly webhooks create --url https://example.com/hooks/fly
+  fly webhooks create --url https://example.com/hooks/fly --event deploy.finished --event machine.crashed`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "url",
+			Description: "URL to post events to",
+		},
+		flag.StringSlice{
+			Name:        "event",
+			Description: fmt.Sprintf("Events to post, of %s. All of them by default", strings.Join(eventTypes, ", ")),
+		},
+		flag.String{
+			Name:        "secret",
+			Description: "Secret signing deliveries, generated by default",
+		},
+	)
+
+	return cmd
+}
+
+func newList() *cobra.Command {
+	const (
+		long  = `List the webhooks of an app.`
+		short = "List webhooks"
+	)
+
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+
+	return cmd
+}
+
+func newRemove() *cobra.Command {
+	const (
+		long = `Remove a webhook of an app. The webhooks app is destroyed along with the
+last webhook.`
+		short = "Remove a webhook"
+	)
+
+	cmd := command.New("remove <id>", short, long, runRemove,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Aliases = []string{"rm"}
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runCreate(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	hook := Webhook{
+		URL:       flag.GetString(ctx, "url"),
+		Events:    flag.GetStringSlice(ctx, "event"),
+		Secret:    flag.GetString(ctx, "secret"),
+		CreatedAt: time.Now().UTC(),
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = slices.Clone(eventTypes)
+	}
+	if err := hook.validate(); err != nil {
+		return err
+	}
+
+	id, err := helpers.RandString(8)
+	if err != nil {
+		return err
+	}
+	hook.ID = "wh_" + strings.ToLower(id)
+
+	generated := hook.Secret == ""
+	if generated {
+		secret, err := helpers.RandString(32)
+		if err != nil {
+			return err
+		}
+		hook.Secret = "whsec_" + secret
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	d, err := ensureDispatcher(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := d.config()
+	if err != nil {
+		return err
+	}
+	cfg.App = appName
+	cfg.Webhooks = append(cfg.Webhooks, hook)
+
+	if err := d.setSecret(ctx, hook); err != nil {
+		return err
+	}
+	if err := d.setConfig(ctx, cfg); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Webhook %s posts %s to %s\n", hook.ID, strings.Join(hook.Events, ", "), hook.URL)
+	if generated {
+		fmt.Fprintf(io.Out, "Deliveries are signed with the secret %s, which isn't shown again\n", hook.Secret)
+	}
+	return nil
+}
+
+func runList(ctx context.Context) error {
+	var (
+		out     = iostreams.FromContext(ctx).Out
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	d, err := findDispatcher(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := d.config()
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if cfg.Webhooks == nil {
+			cfg.Webhooks = []Webhook{}
+		}
+		return render.JSON(out, cfg.Webhooks)
+	}
+
+	if len(cfg.Webhooks) == 0 {
+		fmt.Fprintf(out, "%s has no webhooks\n", appName)
+		return nil
+	}
+
+	rows := make([][]string, 0, len(cfg.Webhooks))
+	for _, hook := range cfg.Webhooks {
+		rows = append(rows, []string{hook.ID, hook.URL, strings.Join(hook.Events, ", "), hook.CreatedAt.Format(time.RFC3339)})
+	}
+
+	return render.Table(out, "", rows, "ID", "URL", "Events", "Created")
+}
+
+func runRemove(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		id        = flag.FirstArg(ctx)
+	)
+
+	d, err := findDispatcher(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := d.config()
+	if err != nil {
+		return err
+	}
+
+	kept := make([]Webhook, 0, len(cfg.Webhooks))
+	for _, hook := range cfg.Webhooks {
+		if hook.ID != id {
+			kept = append(kept, hook)
+		}
+	}
+	if len(kept) == len(cfg.Webhooks) {
+		return fmt.Errorf("%s has no webhook %s", appName, id)
+	}
+
+	if len(kept) == 0 {
+		if err := apiClient.DeleteApp(ctx, d.app.Name); err != nil {
+			return fmt.Errorf("failed destroying webhooks app %s: %w", d.app.Name, err)
+		}
+		fmt.Fprintf(io.Out, "Webhook %s removed, and the webhooks app %s destroyed\n", id, d.app.Name)
+		return nil
+	}
+
+	cfg.Webhooks = kept
+	if err := d.setConfig(ctx, cfg); err != nil {
+		return err
+	}
+	if err := d.unsetSecret(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Webhook %s removed\n", id)
+	return nil
+}
+
+// Webhook posts events of an app to a URL.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether hook posts events of type eventType.
+func (hook Webhook) Subscribes(eventType string) bool {
+	return slices.Contains(hook.Events, eventType)
+}
+
+func (hook Webhook) validate() error {
+	if hook.URL == "" {
+		return errors.New("--url is required")
+	}
+	if parsed, err := url.Parse(hook.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s is not an absolute http or https url", hook.URL)
+	}
+	for _, event := range hook.Events {
+		if !slices.Contains(eventTypes, event) {
+			return fmt.Errorf("unknown event %s, expected one of %s", event, strings.Join(eventTypes, ", "))
+		}
+	}
+	return nil
+}
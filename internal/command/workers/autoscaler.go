@@ -0,0 +1,191 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newAutoscaler() *cobra.Command {
+	const (
+		short = "Run the autoscaler of a worker process group"
+		long  = `Run the autoscaler of a worker process group, in the autoscaler app's machine.`
+	)
+
+	cmd := command.New("autoscaler", short, long, runAutoscaler)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "config",
+			Description: "Path of the autoscaler configuration",
+			Default:     autoscalerConfigPath,
+		},
+	)
+
+	return cmd
+}
+
+func runAutoscaler(ctx context.Context) error {
+	out := iostreams.FromContext(ctx).Out
+
+	data, err := os.ReadFile(flag.GetString(ctx, "config"))
+	if err != nil {
+		return err
+	}
+
+	var cfg autoscalerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed decoding autoscaler configuration: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	flapsClient, err := flaps.NewFromAppName(ctx, cfg.App)
+	if err != nil {
+		return err
+	}
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	fmt.Fprintf(out, "Scaling %s machines of %s between %d and %d\n", cfg.ProcessGroup, cfg.App, cfg.Min, cfg.Max)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := autoscale(ctx, out, flapsClient, httpClient, cfg); err != nil {
+			fmt.Fprintf(out, "Failed scaling: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// autoscale starts or stops machines of the group to match the queue depth.
+func autoscale(ctx context.Context, out io.Writer, flapsClient *flaps.Client, httpClient *http.Client, cfg autoscalerConfig) error {
+	depth, err := fetchQueueDepth(ctx, httpClient, cfg.MetricURL, cfg.JSONField)
+	if err != nil {
+		return err
+	}
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	var started, stopped []*api.Machine
+	for _, m := range groupMachines(machines, cfg.ProcessGroup) {
+		switch m.State {
+		case api.MachineStateStarted:
+			started = append(started, m)
+		case api.MachineStateStopped:
+			stopped = append(stopped, m)
+		}
+	}
+
+	desired := desiredMachines(depth, cfg.JobsPerMachine, cfg.Min, cfg.Max)
+
+	switch {
+	case desired > len(started):
+		n := desired - len(started)
+		if n > len(stopped) {
+			n = len(stopped)
+		}
+		fmt.Fprintf(out, "Queue depth %d, starting %d machines\n", depth, n)
+		for _, m := range stopped[:n] {
+			if _, err := flapsClient.Start(ctx, m.ID, ""); err != nil {
+				return fmt.Errorf("failed starting machine %s: %w", m.ID, err)
+			}
+		}
+	case desired < len(started):
+		// Scale down a machine at a time, to let queues drain gracefully
+		m := started[len(started)-1]
+		fmt.Fprintf(out, "Queue depth %d, stopping machine %s\n", depth, m.ID)
+		if err := flapsClient.Stop(ctx, api.StopMachineInput{ID: m.ID}, ""); err != nil {
+			return fmt.Errorf("failed stopping machine %s: %w", m.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// desiredMachines returns the number of machines to handle depth jobs.
+func desiredMachines(depth, jobsPerMachine, min, max int) int {
+	desired := (depth + jobsPerMachine - 1) / jobsPerMachine
+	if desired < min {
+		desired = min
+	}
+	if desired > max {
+		desired = max
+	}
+	return desired
+}
+
+// fetchQueueDepth returns the queue depth served at url, as a plain number
+// or as the field of a JSON object.
+func fetchQueueDepth(ctx context.Context, httpClient *http.Client, url, field string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed fetching queue depth: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed fetching queue depth: %s responded with %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+
+	return parseQueueDepth(body, field)
+}
+
+func parseQueueDepth(body []byte, field string) (int, error) {
+	if field == "" {
+		depth, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+		if err != nil {
+			return 0, fmt.Errorf("queue depth %q is not a number", strings.TrimSpace(string(body)))
+		}
+		return int(depth), nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return 0, fmt.Errorf("failed decoding queue depth: %w", err)
+	}
+
+	depth, ok := obj[field].(float64)
+	if !ok {
+		return 0, fmt.Errorf("queue depth has no numeric %s field", field)
+	}
+	return int(depth), nil
+}
@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDesiredMachines(t *testing.T) {
+	assert.Equal(t, 0, desiredMachines(0, 10, 0, 5))
+	assert.Equal(t, 1, desiredMachines(0, 10, 1, 5))
+	assert.Equal(t, 1, desiredMachines(1, 10, 0, 5))
+	assert.Equal(t, 2, desiredMachines(11, 10, 0, 5))
+	assert.Equal(t, 5, desiredMachines(1000, 10, 0, 5))
+}
+
+func TestParseQueueDepth(t *testing.T) {
+	depth, err := parseQueueDepth([]byte("42\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, 42, depth)
+
+	depth, err = parseQueueDepth([]byte(`{"queued": 7, "busy": 2}`), "queued")
+	require.NoError(t, err)
+	assert.Equal(t, 7, depth)
+
+	_, err = parseQueueDepth([]byte(`{"busy": 2}`), "queued")
+	assert.Error(t, err)
+
+	_, err = parseQueueDepth([]byte("many"), "")
+	assert.Error(t, err)
+}
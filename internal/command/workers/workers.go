@@ -0,0 +1,373 @@
+// Package workers implements the workers command chain.
+package workers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/budget"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/infra"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	autoscalerAppSuffix   = "-queue-autoscaler"
+	autoscalerMachineName = "queue-autoscaler"
+	autoscalerConfigPath  = "/etc/fly-autoscaler/config.json"
+)
+
+// New initializes and returns a new workers Command.
+func New() *cobra.Command {
+	const (
+		long  = `Manage the worker process groups of apps.`
+		short = "Manage worker process groups"
+	)
+
+	cmd := command.New("workers", short, long, nil)
+
+	cmd.AddCommand(
+		newScaleByQueue(),
+		newAutoscaler(),
+	)
+
+	return cmd
+}
+
+func newScaleByQueue() *cobra.Command {
+	const (
+		long = `Scale the started machines of a worker process group with the depth of a
+queue. An autoscaler app launched in the organization polls --metric-url, whose
+response is the number of queued jobs, either as a plain number or as the
+--json-field of a JSON object, and keeps enough machines of the group started
+for each to handle --jobs-per-machine jobs, within --min and --max.
+
+The autoscaler starts and stops the group's existing machines, so first scale
+the group to --max machines with fly scale count. The metric URL may be a
+private address of the organization, such as my-queue.internal:9090.
+
+Running the command again replaces the configuration, and --disable destroys
+the autoscaler.`
+		short = "Scale a worker process group with queue depth"
+	)
+
+	cmd := command.New("scale-by-queue", short, long, runScaleByQueue,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Example = `  fly workers scale-by-queue --process-group worker --metric-url http://my-app.internal:9394/queue --max 5
+  fly workers scale-by-queue --disable`
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.String{
+			Name:        "process-group",
+			Description: "The worker process group to scale",
+			Default:     "worker",
+		},
+		flag.String{
+			Name:        "metric-url",
+			Description: "URL returning the number of queued jobs",
+		},
+		flag.String{
+			Name:        "json-field",
+			Description: "Field of the JSON object returned by --metric-url holding the number of queued jobs",
+		},
+		flag.Int{
+			Name:        "jobs-per-machine",
+			Description: "Number of queued jobs each started machine handles",
+			Default:     10,
+		},
+		flag.Int{
+			Name:        "min",
+			Description: "Minimum number of started machines",
+		},
+		flag.Int{
+			Name:        "max",
+			Description: "Maximum number of started machines",
+		},
+		flag.Duration{
+			Name:        "interval",
+			Description: "How often to poll the metric",
+			Default:     30 * time.Second,
+		},
+		flag.Bool{
+			Name:        "disable",
+			Description: "Destroy the autoscaler of the app",
+		},
+	)
+
+	return cmd
+}
+
+func autoscalerAppName(appName string) string {
+	return appName + autoscalerAppSuffix
+}
+
+func runScaleByQueue(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	if flag.GetBool(ctx, "disable") {
+		return disableAutoscaler(ctx, app)
+	}
+
+	cfg := autoscalerConfig{
+		App:            appName,
+		ProcessGroup:   flag.GetString(ctx, "process-group"),
+		MetricURL:      flag.GetString(ctx, "metric-url"),
+		JSONField:      flag.GetString(ctx, "json-field"),
+		JobsPerMachine: flag.GetInt(ctx, "jobs-per-machine"),
+		Min:            flag.GetInt(ctx, "min"),
+		Max:            flag.GetInt(ctx, "max"),
+		Interval:       flag.GetDuration(ctx, "interval"),
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+	if n := len(groupMachines(machines, cfg.ProcessGroup)); n < cfg.Max {
+		return fmt.Errorf("the %s group has %d machines, scale it to --max with `fly scale count %s=%d`", cfg.ProcessGroup, n, cfg.ProcessGroup, cfg.Max)
+	}
+
+	autoscalerApp, err := ensureAutoscalerApp(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	if err := launchAutoscaler(ctx, autoscalerApp, cfg); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Scaling %s machines of %s between %d and %d with the depth of %s\n", cfg.ProcessGroup, appName, cfg.Min, cfg.Max, cfg.MetricURL)
+	return nil
+}
+
+// ensureAutoscalerApp returns the autoscaler app of app, creating it with a
+// deploy token of the app to scale it with.
+func ensureAutoscalerApp(ctx context.Context, app *api.AppCompact) (*api.AppCompact, error) {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		name      = autoscalerAppName(app.Name)
+	)
+
+	autoscalerApp, err := apiClient.GetAppCompact(ctx, name)
+	switch {
+	case err == nil:
+		return autoscalerApp, nil
+	case !api.IsNotFoundError(err):
+		return nil, err
+	}
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = app.Organization.ID
+	input.Name = name
+
+	if _, err := gql.CreateApp(ctx, apiClient.GenqClient, input); err != nil {
+		return nil, fmt.Errorf("failed creating autoscaler app %s: %w", name, err)
+	}
+	fmt.Fprintf(io.ErrOut, "Created autoscaler app %s\n", name)
+
+	token, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient, name, app.Organization.ID, "deploy", &gql.LimitedAccessTokenOptions{
+		"app_id": app.ID,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the token of the autoscaler: %w", err)
+	}
+
+	if _, err := apiClient.SetSecrets(ctx, name, map[string]string{
+		"FLY_API_TOKEN": token.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader,
+	}); err != nil {
+		return nil, fmt.Errorf("failed setting the token of the autoscaler: %w", err)
+	}
+
+	return apiClient.GetAppCompact(ctx, name)
+}
+
+// launchAutoscaler launches or updates the autoscaler machine to run cfg.
+func launchAutoscaler(ctx context.Context, autoscalerApp *api.AppCompact, cfg autoscalerConfig) error {
+	io := iostreams.FromContext(ctx)
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	config := &api.MachineConfig{
+		Init: api.MachineInit{
+			Entrypoint: []string{"flyctl"},
+			Cmd:        []string{"workers", "autoscaler", "--config", autoscalerConfigPath},
+		},
+		Guest: &api.MachineGuest{
+			CPUKind:  "shared",
+			CPUs:     1,
+			MemoryMB: 256,
+		},
+		Files: []*api.File{{GuestPath: autoscalerConfigPath, RawValue: &encoded}},
+	}
+
+	flapsClient, err := flaps.New(ctx, autoscalerApp)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if m.Name != autoscalerMachineName {
+			continue
+		}
+
+		machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+		defer releaseLeaseFunc(ctx, machine)
+		if err != nil {
+			return err
+		}
+
+		infra.Configure(config, infra.ComponentFlyctl, m)
+		return mach.Update(ctx, machine, &api.LaunchMachineInput{
+			Name:   autoscalerMachineName,
+			Region: machine.Region,
+			Config: config,
+		})
+	}
+
+	infra.Configure(config, infra.ComponentFlyctl, nil)
+
+	costDelta := func(prices budget.Prices) float64 { return prices.Machine(config.Guest) }
+	if err := budget.Check(ctx, autoscalerApp.Organization.Slug, autoscalerApp.Name, "Launching an autoscaler machine", costDelta); err != nil {
+		return err
+	}
+
+	region, err := gql.GetNearestRegion(ctx, client.FromContext(ctx).API().GenqClient)
+	if err != nil {
+		return err
+	}
+
+	machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		Name:   autoscalerMachineName,
+		Region: region.NearestRegion.Code,
+		Config: config,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching autoscaler machine: %w", err)
+	}
+	fmt.Fprintf(io.ErrOut, "Launched autoscaler machine %s in %s\n", machine.ID, machine.Region)
+	return nil
+}
+
+func disableAutoscaler(ctx context.Context, app *api.AppCompact) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		name      = autoscalerAppName(app.Name)
+	)
+
+	if _, err := apiClient.GetAppCompact(ctx, name); err != nil {
+		if api.IsNotFoundError(err) {
+			return fmt.Errorf("%s has no autoscaler", app.Name)
+		}
+		return err
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Destroy the autoscaler app %s?", name); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("--yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := apiClient.DeleteApp(ctx, name); err != nil {
+		return fmt.Errorf("failed destroying autoscaler app %s: %w", name, err)
+	}
+
+	fmt.Fprintf(io.Out, "Destroyed autoscaler app %s, the started machines of %s stay as they are\n", name, app.Name)
+	return nil
+}
+
+func groupMachines(machines []*api.Machine, group string) []*api.Machine {
+	var matching []*api.Machine
+	for _, m := range machines {
+		if m.ProcessGroup() == group {
+			matching = append(matching, m)
+		}
+	}
+	return matching
+}
+
+// autoscalerConfig configures the autoscaler of a worker process group.
+type autoscalerConfig struct {
+	App            string        `json:"app"`
+	ProcessGroup   string        `json:"process_group"`
+	MetricURL      string        `json:"metric_url"`
+	JSONField      string        `json:"json_field,omitempty"`
+	JobsPerMachine int           `json:"jobs_per_machine"`
+	Min            int           `json:"min"`
+	Max            int           `json:"max"`
+	Interval       time.Duration `json:"interval"`
+}
+
+func (cfg autoscalerConfig) validate() error {
+	switch {
+	case cfg.MetricURL == "":
+		return errors.New("--metric-url is required")
+	case cfg.ProcessGroup == "":
+		return errors.New("--process-group is required")
+	case cfg.Max <= 0:
+		return errors.New("--max must be a positive number of machines")
+	case cfg.Min < 0 || cfg.Min > cfg.Max:
+		return errors.New("--min must be between 0 and --max")
+	case cfg.JobsPerMachine <= 0:
+		return errors.New("--jobs-per-machine must be positive")
+	case cfg.Interval < time.Second:
+		return errors.New("--interval must be at least a second")
+	}
+	return nil
+}
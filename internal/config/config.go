@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/pflag"
 
@@ -24,6 +26,11 @@ const (
 	MetricsTokenFileKey   = "metrics_token"
 	SendMetricsFileKey    = "send_metrics"
 	WireGuardStateFileKey = "wire_guard_state"
+	ProtectedFileKey      = "protected_resources"
+	BudgetsFileKey        = "budgets"
+	OfflineQueueFileKey   = "offline_queue"
+	OrgRegionsFileKey     = "org_regions"
+	SSOSessionFileKey     = "sso_session"
 	APITokenEnvKey        = envKeyPrefix + "API_TOKEN"
 	orgEnvKey             = envKeyPrefix + "ORG"
 	registryHostEnvKey    = envKeyPrefix + "REGISTRY_HOST"
@@ -33,6 +40,7 @@ const (
 	jsonOutputEnvKey      = envKeyPrefix + "JSON"
 	logGQLEnvKey          = envKeyPrefix + "LOG_GQL_ERRORS"
 	localOnlyEnvKey       = envKeyPrefix + "LOCAL_ONLY"
+	offlineQueueEnvKey    = envKeyPrefix + "OFFLINE_QUEUE"
 
 	defaultAPIBaseURL     = "https://api.fly.io"
 	defaultFlapsBaseURL   = "https://api.machines.dev"
@@ -84,6 +92,47 @@ type Config struct {
 
 	// MetricsToken denotes the user's metrics token.
 	MetricsToken string
+
+	// Protected denotes the names of the resources protected from deletion,
+	// keyed by resource kind. They're shared by all auth contexts.
+	Protected map[string][]string
+
+	// Budgets denotes the monthly budgets in USD of organizations and apps,
+	// keyed by kind and name.
+	Budgets map[string]map[string]float64
+
+	// OfflineQueue denotes whether the user wants mutations queued locally
+	// when the API is unreachable.
+	OfflineQueue bool
+
+	// OrgRegions denotes the default regions of organizations, keyed by slug.
+	OrgRegions map[string]string
+
+	// SSOSession denotes the session the access token was issued for, when
+	// the user logged in through the SSO of an organization.
+	SSOSession *SSOSession
+
+	// Context denotes the name of the auth context the configuration was
+	// loaded from.
+	Context string
+
+	// CredentialStore denotes where the access token of the configuration
+	// file is stored.
+	CredentialStore string
+}
+
+// SSOSession is the session of a login through the SSO of an organization.
+// Its access tokens are short-lived, and renewed with its refresh token.
+type SSOSession struct {
+	Organization string    `yaml:"organization"`
+	RefreshToken string    `yaml:"refresh_token"`
+	ExpiresAt    time.Time `yaml:"expires_at"`
+}
+
+// NeedsRefresh reports whether the access token of the session expires
+// within the next minute.
+func (s *SSOSession) NeedsRefresh(now time.Time) bool {
+	return s != nil && s.RefreshToken != "" && !s.ExpiresAt.IsZero() && now.Add(time.Minute).After(s.ExpiresAt)
 }
 
 // New returns a new instance of Config populated with default values.
@@ -104,8 +153,11 @@ func (cfg *Config) ApplyEnv() {
 	cfg.mu.Lock()
 	defer cfg.mu.Unlock()
 
-	cfg.AccessToken = env.FirstOrDefault(cfg.AccessToken,
-		AccessTokenEnvKey, APITokenEnvKey)
+	if token := env.First(AccessTokenEnvKey, APITokenEnvKey); token != "" {
+		cfg.AccessToken = token
+		// The SSO session is for the token of the configuration file
+		cfg.SSOSession = nil
+	}
 
 	// trim whitespace since it causes http errors when passsed to Docker auth
 	cfg.AccessToken = strings.TrimSpace(cfg.AccessToken)
@@ -114,6 +166,7 @@ func (cfg *Config) ApplyEnv() {
 	cfg.JSONOutput = env.IsTruthy(jsonOutputEnvKey) || cfg.JSONOutput
 	cfg.LogGQLErrors = env.IsTruthy(logGQLEnvKey) || cfg.LogGQLErrors
 	cfg.LocalOnly = env.IsTruthy(localOnlyEnvKey) || cfg.LocalOnly
+	cfg.OfflineQueue = env.IsTruthy(offlineQueueEnvKey) || cfg.OfflineQueue
 
 	cfg.Organization = env.FirstOrDefault(cfg.Organization,
 		orgEnvKey, organizationEnvKey)
@@ -131,16 +184,54 @@ func (cfg *Config) ApplyFile(path string) (err error) {
 	defer cfg.mu.Unlock()
 
 	var w struct {
-		AccessToken  string `yaml:"access_token"`
-		MetricsToken string `yaml:"metrics_token"`
-		SendMetrics  bool   `yaml:"send_metrics"`
+		AccessToken  string                        `yaml:"access_token"`
+		MetricsToken string                        `yaml:"metrics_token"`
+		SendMetrics  bool                          `yaml:"send_metrics"`
+		Budgets      map[string]map[string]float64 `yaml:"budgets"`
+		OfflineQueue bool                          `yaml:"offline_queue"`
+		OrgRegions   map[string]string             `yaml:"org_regions"`
+		SSOSession   *SSOSession                   `yaml:"sso_session"`
+		Organization string                        `yaml:"organization"`
+		Store        string                        `yaml:"credential_store"`
 	}
 	w.SendMetrics = true
 
 	if err = unmarshal(path, &w); err == nil {
+		// Apply the rest of the file even when the token can't be read, for
+		// commands such as auth login to fix it
+		if w.AccessToken, err = keyringToken(path, w.Store, w.AccessToken); err != nil {
+			err = fmt.Errorf("failed reading the access token of %s from the keyring: %w", path, err)
+		} else if w.SSOSession, err = keyringSession(path, w.Store, w.SSOSession); err != nil {
+			err = fmt.Errorf("failed reading the SSO session of %s from the keyring: %w", path, err)
+		}
+
 		cfg.AccessToken = w.AccessToken
 		cfg.MetricsToken = w.MetricsToken
 		cfg.SendMetrics = w.SendMetrics
+		cfg.Budgets = w.Budgets
+		cfg.OfflineQueue = w.OfflineQueue
+		cfg.OrgRegions = w.OrgRegions
+		cfg.SSOSession = w.SSOSession
+		cfg.Organization = w.Organization
+		cfg.CredentialStore = w.Store
+	}
+
+	return
+}
+
+// ApplySharedFile sets the properties of cfg shared by all auth contexts to
+// the values the configuration file of the config directory, found at path,
+// contains.
+func (cfg *Config) ApplySharedFile(path string) (err error) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	var w struct {
+		Protected map[string][]string `yaml:"protected_resources"`
+	}
+
+	if err = unmarshal(path, &w); err == nil {
+		cfg.Protected = w.Protected
 	}
 
 	return
@@ -152,6 +243,10 @@ func (cfg *Config) ApplyFlags(fs *pflag.FlagSet) {
 	cfg.mu.Lock()
 	defer cfg.mu.Unlock()
 
+	if fs.Changed(flagnames.AccessToken) {
+		cfg.SSOSession = nil
+	}
+
 	applyStringFlags(fs, map[string]*string{
 		flagnames.AccessToken: &cfg.AccessToken,
 		flagnames.Org:         &cfg.Organization,
@@ -165,6 +260,48 @@ func (cfg *Config) ApplyFlags(fs *pflag.FlagSet) {
 	})
 }
 
+// HasProtected reports whether any resource of the given kind is protected
+// from deletion.
+func (cfg *Config) HasProtected(kind string) bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+
+	return len(cfg.Protected[kind]) > 0
+}
+
+// IsProtected reports whether the resource of the given kind and name is
+// protected from deletion.
+func (cfg *Config) IsProtected(kind, name string) bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+
+	for _, n := range cfg.Protected[kind] {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// OrgRegion returns the default region of the organization orgSlug, or an
+// empty string when it has none.
+func (cfg *Config) OrgRegion(orgSlug string) string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+
+	return cfg.OrgRegions[orgSlug]
+}
+
+// Budget returns the monthly budget in USD of the organization or app of the
+// given kind and name, and whether it has one.
+func (cfg *Config) Budget(kind, name string) (float64, bool) {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+
+	budget, ok := cfg.Budgets[kind][name]
+	return budget, ok
+}
+
 func (cfg *Config) MetricsBaseURLIsProduction() bool {
 	return cfg.MetricsBaseURL == defaultMetricsBaseURL
 }
@@ -196,3 +333,13 @@ func applyBoolFlags(fs *pflag.FlagSet, flags map[string]*bool) {
 		}
 	}
 }
+
+// RenewSSOSession replaces the access token and SSO session of cfg after the
+// session was refreshed.
+func (cfg *Config) RenewSSOSession(token string, session *SSOSession) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+
+	cfg.AccessToken = token
+	cfg.SSOSession = session
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSOSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	expiresAt := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, SetSSOSession(path, "short-lived", &SSOSession{
+		Organization: "acme",
+		RefreshToken: "refresh",
+		ExpiresAt:    expiresAt,
+	}))
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Equal(t, "short-lived", cfg.AccessToken)
+	require.NotNil(t, cfg.SSOSession)
+	assert.Equal(t, "acme", cfg.SSOSession.Organization)
+	assert.True(t, cfg.SSOSession.ExpiresAt.Equal(expiresAt))
+
+	assert.False(t, cfg.SSOSession.NeedsRefresh(expiresAt.Add(-time.Hour)))
+	assert.True(t, cfg.SSOSession.NeedsRefresh(expiresAt.Add(-30*time.Second)))
+
+	t.Setenv(AccessTokenEnvKey, "from-env")
+	cfg.ApplyEnv()
+	assert.Equal(t, "from-env", cfg.AccessToken)
+	assert.Nil(t, cfg.SSOSession)
+
+	require.NoError(t, Clear(path))
+	cfg = New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Nil(t, cfg.SSOSession)
+}
+
+func TestOrgRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	require.NoError(t, SetOrgRegion(path, "acme", "ams"))
+	require.NoError(t, SetOrgRegion(path, "personal", "ord"))
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Equal(t, "ams", cfg.OrgRegion("acme"))
+	assert.Equal(t, "ord", cfg.OrgRegion("personal"))
+	assert.Empty(t, cfg.OrgRegion("other"))
+
+	require.NoError(t, SetOrgRegion(path, "acme", ""))
+	cfg = New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Empty(t, cfg.OrgRegion("acme"))
+	assert.Equal(t, "ord", cfg.OrgRegion("personal"))
+}
+
+func TestProtectedSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	shared := ContextFile(dir, DefaultContext)
+	require.NoError(t, SetProtected(shared, ProtectedMachine, "m1", true))
+
+	path := ContextFile(dir, "work")
+	require.NoError(t, CreateContext(dir, "work"))
+	require.NoError(t, SetProtected(path, ProtectedMachine, "m2", true))
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(path))
+	require.NoError(t, cfg.ApplySharedFile(shared))
+	assert.True(t, cfg.HasProtected(ProtectedMachine))
+	assert.True(t, cfg.IsProtected(ProtectedMachine, "m1"))
+	assert.False(t, cfg.IsProtected(ProtectedMachine, "m2"))
+	assert.False(t, cfg.HasProtected(ProtectedVolume))
+}
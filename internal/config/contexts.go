@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// ContextEnvKey denotes the environment variable selecting the auth
+	// context.
+	ContextEnvKey = envKeyPrefix + "CONTEXT"
+
+	// CurrentContextFileKey denotes the key of the configuration file holding
+	// the name of the current auth context.
+	CurrentContextFileKey = "current_context"
+
+	// OrganizationFileKey denotes the key of the configuration file holding the
+	// default organization.
+	OrganizationFileKey = "organization"
+
+	// DefaultContext is the name of the auth context using the configuration
+	// file of the config directory.
+	DefaultContext = "default"
+
+	contextsDirName = "contexts"
+	contextFileExt  = ".yml"
+)
+
+var contextNameRE = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateContextName returns an error when name can't name an auth context.
+func ValidateContextName(name string) error {
+	if !contextNameRE.MatchString(name) {
+		return fmt.Errorf("invalid context name %q, use letters, digits, dots, dashes and underscores", name)
+	}
+	return nil
+}
+
+// ContextFile returns the path to the configuration file of the auth context
+// with the given name, in the config directory dir.
+func ContextFile(dir, name string) string {
+	if name == "" || name == DefaultContext {
+		return filepath.Join(dir, FileName)
+	}
+	return filepath.Join(dir, contextsDirName, name+contextFileExt)
+}
+
+// Contexts returns the names of the auth contexts of the config directory dir,
+// sorted, starting with the default one.
+func Contexts(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, contextsDirName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), contextFileExt)
+		if !ok || e.IsDir() || ValidateContextName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return append([]string{DefaultContext}, names...), nil
+}
+
+// CurrentContext returns the name of the current auth context recorded at the
+// configuration file found at path.
+func CurrentContext(path string) (string, error) {
+	var w struct {
+		CurrentContext string `yaml:"current_context"`
+	}
+
+	switch err := unmarshal(path, &w); {
+	case err == nil, os.IsNotExist(err):
+		break
+	default:
+		return "", err
+	}
+
+	if w.CurrentContext == "" {
+		return DefaultContext, nil
+	}
+	return w.CurrentContext, nil
+}
+
+// SetCurrentContext sets the name of the current auth context at the
+// configuration file found at path.
+func SetCurrentContext(path, name string) error {
+	if name == DefaultContext {
+		name = ""
+	}
+	return set(path, map[string]interface{}{
+		CurrentContextFileKey: name,
+	})
+}
+
+// SetOrganization sets the default organization at the configuration file
+// found at path.
+func SetOrganization(path, slug string) error {
+	return set(path, map[string]interface{}{
+		OrganizationFileKey: slug,
+	})
+}
+
+// CreateContext creates the configuration file of the auth context with the
+// given name in the config directory dir, unless it exists.
+func CreateContext(dir, name string) error {
+	path := ContextFile(dir, name)
+
+	switch _, err := os.Stat(path); {
+	case err == nil:
+		return nil
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return marshal(path, map[string]interface{}{})
+}
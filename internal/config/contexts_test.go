@@ -0,0 +1,55 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContexts(t *testing.T) {
+	dir := t.TempDir()
+	main := ContextFile(dir, DefaultContext)
+	assert.Equal(t, filepath.Join(dir, FileName), main)
+	assert.Equal(t, filepath.Join(dir, "contexts", "work.yml"), ContextFile(dir, "work"))
+
+	current, err := CurrentContext(main)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultContext, current)
+
+	require.NoError(t, CreateContext(dir, "work"))
+	require.NoError(t, SetAccessToken(ContextFile(dir, "work"), "work-token"))
+	require.NoError(t, SetOrganization(ContextFile(dir, "work"), "acme"))
+	require.NoError(t, CreateContext(dir, "client-x"))
+	// Creating an existing context keeps its configuration
+	require.NoError(t, CreateContext(dir, "work"))
+
+	names, err := Contexts(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultContext, "client-x", "work"}, names)
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(ContextFile(dir, "work")))
+	assert.Equal(t, "work-token", cfg.AccessToken)
+	assert.Equal(t, "acme", cfg.Organization)
+
+	require.NoError(t, SetCurrentContext(main, "work"))
+	current, err = CurrentContext(main)
+	require.NoError(t, err)
+	assert.Equal(t, "work", current)
+
+	require.NoError(t, SetCurrentContext(main, DefaultContext))
+	current, err = CurrentContext(main)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultContext, current)
+}
+
+func TestValidateContextName(t *testing.T) {
+	for _, name := range []string{"work", "client-X", "a.b_c"} {
+		assert.NoError(t, ValidateContextName(name), name)
+	}
+	for _, name := range []string{"", "../etc", "a/b", "-x"} {
+		assert.Error(t, ValidateContextName(name), name)
+	}
+}
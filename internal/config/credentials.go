@@ -0,0 +1,315 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/superfly/flyctl/internal/keyring"
+)
+
+const (
+	// CredentialStoreFileKey denotes the key of the configuration file
+	// recording where its access token is stored.
+	CredentialStoreFileKey = "credential_store"
+
+	// CredentialStoreKeyring stores access tokens, and the refresh tokens of
+	// SSO sessions, in the keyring of the operating system.
+	CredentialStoreKeyring = "keyring"
+
+	// CredentialStoreFile stores access tokens, and the refresh tokens of SSO
+	// sessions, in plain text in the configuration file.
+	CredentialStoreFile = "file"
+
+	keyringService = "fly.io"
+
+	// refreshTokenAccountSuffix suffixes the path of a configuration file to
+	// name the keyring entry of the refresh token of its SSO session.
+	refreshTokenAccountSuffix = "#sso_refresh_token"
+)
+
+// ErrKeyringUnavailable is returned when an access token is stored in the
+// keyring of the operating system, but flyctl can't reach it.
+var ErrKeyringUnavailable = errors.New("the keyring of the operating system is unavailable")
+
+// KeyringFallbackError is returned when an access token couldn't be stored in
+// the keyring, and was stored in plain text in the configuration file instead.
+// The file is updated regardless, so callers warn about it rather than fail.
+type KeyringFallbackError struct {
+	Path string
+	Err  error
+}
+
+func (e *KeyringFallbackError) Error() string {
+	return fmt.Sprintf("couldn't store the credentials of %s in the keyring, they're stored in plain text in the file instead: %v", e.Path, e.Err)
+}
+
+func (e *KeyringFallbackError) Unwrap() error {
+	return e.Err
+}
+
+// systemKeyring is the keyring access tokens are stored in. It's nil when the
+// operating system has none.
+var systemKeyring = keyring.System()
+
+// HasKeyring reports whether access tokens may be stored in the keyring of
+// the operating system.
+func HasKeyring() bool {
+	return systemKeyring != nil
+}
+
+// readCredentials returns the access token and SSO session stored at the
+// configuration file found at path, or the keyring, and where they're stored.
+func readCredentials(path string) (token string, session *SSOSession, store string, err error) {
+	var w struct {
+		AccessToken     string      `yaml:"access_token"`
+		SSOSession      *SSOSession `yaml:"sso_session"`
+		CredentialStore string      `yaml:"credential_store"`
+	}
+
+	switch err = unmarshal(path, &w); {
+	case err == nil:
+		break
+	case os.IsNotExist(err):
+		return "", nil, "", nil
+	default:
+		return "", nil, "", err
+	}
+
+	if token, err = keyringToken(path, w.CredentialStore, w.AccessToken); err != nil {
+		return "", nil, w.CredentialStore, err
+	}
+	session, err = keyringSession(path, w.CredentialStore, w.SSOSession)
+	return token, session, w.CredentialStore, err
+}
+
+// keyringToken returns the access token of the configuration file found at
+// path from the keyring when store says it's kept there, or token otherwise.
+// It fails with ErrKeyringUnavailable when the keyring can't be reached.
+func keyringToken(path, store, token string) (string, error) {
+	if store != CredentialStoreKeyring {
+		return token, nil
+	}
+	return keyringSecret(path)
+}
+
+// keyringSession returns session with the refresh token the keyring holds for
+// the configuration file found at path, when store says it's kept there. A
+// refresh token still in plain text in the file is returned as is.
+func keyringSession(path, store string, session *SSOSession) (*SSOSession, error) {
+	if store != CredentialStoreKeyring || session == nil || session.RefreshToken != "" {
+		return session, nil
+	}
+
+	token, err := keyringSecret(refreshTokenAccount(path))
+	if err != nil {
+		return nil, err
+	}
+
+	stored := *session
+	stored.RefreshToken = token
+	return &stored, nil
+}
+
+// keyringSecret returns the secret the keyring holds for account, or an empty
+// string when it holds none. It fails with ErrKeyringUnavailable when the
+// keyring can't be reached.
+func keyringSecret(account string) (string, error) {
+	if systemKeyring == nil {
+		return "", ErrKeyringUnavailable
+	}
+
+	switch secret, err := systemKeyring.Get(keyringService, account); {
+	case errors.Is(err, keyring.ErrNotFound):
+		return "", nil
+	case err != nil:
+		return "", err
+	default:
+		return secret, nil
+	}
+}
+
+// deleteKeyringSecrets removes the access and refresh tokens of the
+// configuration file found at path from the keyring.
+func deleteKeyringSecrets(path string) error {
+	for _, account := range []string{path, refreshTokenAccount(path)} {
+		if err := systemKeyring.Delete(keyringService, account); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func refreshTokenAccount(path string) string {
+	return path + refreshTokenAccountSuffix
+}
+
+// setWithAccessToken sets vals and the access token at the configuration file
+// found at path.
+func setWithAccessToken(path, token string, vals map[string]interface{}) error {
+	// The token is replaced, so one the keyring can't return doesn't matter
+	_, _, store, err := readCredentials(path)
+	if err != nil && !errors.Is(err, ErrKeyringUnavailable) {
+		return err
+	}
+	return storeAccessToken(path, token, store, vals)
+}
+
+// storeAccessToken sets vals and the access token at the configuration file
+// found at path. The token, and the refresh token of the SSO session vals may
+// set, go to the keyring unless store opts out of it. When the keyring fails,
+// the file records it opted out and keeps them, and a KeyringFallbackError is
+// returned.
+func storeAccessToken(path, token, store string, vals map[string]interface{}) error {
+	vals[AccessTokenFileKey] = token
+	switch {
+	case store == CredentialStoreFile:
+		return set(path, vals)
+	case systemKeyring == nil && store != CredentialStoreKeyring:
+		return set(path, vals)
+	case systemKeyring == nil:
+		return fallBackToFile(path, token, vals, ErrKeyringUnavailable)
+	}
+
+	if token == "" {
+		if err := deleteKeyringSecrets(path); err != nil {
+			return fmt.Errorf("failed removing the credentials of %s from the keyring: %w", path, err)
+		}
+	} else if err := systemKeyring.Set(keyringService, path, token); err != nil {
+		return fallBackToFile(path, token, vals, err)
+	} else if err := storeRefreshToken(path, vals); err != nil {
+		return fallBackToFile(path, token, vals, err)
+	}
+
+	vals[AccessTokenFileKey] = ""
+	vals[CredentialStoreFileKey] = CredentialStoreKeyring
+	return set(path, vals)
+}
+
+// storeRefreshToken moves the refresh token of the SSO session vals sets to
+// the keyring, leaving it out of vals. A session set to nil removes it from
+// the keyring, and vals without a session leave it be.
+func storeRefreshToken(path string, vals map[string]interface{}) error {
+	v, ok := vals[SSOSessionFileKey]
+	if !ok {
+		return nil
+	}
+
+	account := refreshTokenAccount(path)
+
+	session, _ := v.(*SSOSession)
+	if session == nil || session.RefreshToken == "" {
+		if err := systemKeyring.Delete(keyringService, account); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return err
+		}
+		return nil
+	}
+
+	if err := systemKeyring.Set(keyringService, account, session.RefreshToken); err != nil {
+		return err
+	}
+
+	stored := *session
+	stored.RefreshToken = ""
+	vals[SSOSessionFileKey] = &stored
+	return nil
+}
+
+// fallBackToFile sets vals and the access token at the configuration file
+// found at path, recording the token is stored there since the keyring failed
+// with err.
+func fallBackToFile(path, token string, vals map[string]interface{}, err error) error {
+	vals[CredentialStoreFileKey] = CredentialStoreFile
+	if err := set(path, vals); err != nil {
+		return err
+	}
+	if token == "" {
+		return nil
+	}
+	return &KeyringFallbackError{Path: path, Err: err}
+}
+
+// SetCredentialStore sets where the access token of the configuration file
+// found at path, and the refresh token of its SSO session, are stored, one of
+// CredentialStoreKeyring and CredentialStoreFile, and moves the current ones
+// there.
+func SetCredentialStore(path, store string) error {
+	token, session, current, err := readCredentials(path)
+	switch {
+	case errors.Is(err, ErrKeyringUnavailable) && store == CredentialStoreFile:
+		// The tokens can't be moved out of the keyring, log in again
+		token, session = "", nil
+	case err != nil:
+		return err
+	case current == store:
+		return nil
+	}
+
+	if store == CredentialStoreFile {
+		if current == CredentialStoreKeyring && systemKeyring != nil {
+			if err := deleteKeyringSecrets(path); err != nil {
+				return err
+			}
+		}
+		return set(path, map[string]interface{}{
+			AccessTokenFileKey:     token,
+			SSOSessionFileKey:      session,
+			CredentialStoreFileKey: CredentialStoreFile,
+		})
+	}
+
+	return storeAccessToken(path, token, "", map[string]interface{}{
+		SSOSessionFileKey:      session,
+		CredentialStoreFileKey: "",
+	})
+}
+
+// MigrateAccessToken moves the plain text access token of the configuration
+// file found at path, and the refresh token of its SSO session, to the
+// keyring, unless the file opted out of it. It reports whether they were
+// moved.
+func MigrateAccessToken(path string) (bool, error) {
+	if systemKeyring == nil {
+		return false, nil
+	}
+
+	token, session, store, err := readCredentials(path)
+	if err != nil || token == "" {
+		return false, err
+	}
+
+	switch plain, err := hasPlainRefreshToken(path); {
+	case err != nil:
+		return false, err
+	case store == "":
+		break
+	case store == CredentialStoreKeyring && plain:
+		// Written before refresh tokens were stored in the keyring too
+		break
+	default:
+		return false, nil
+	}
+
+	vals := map[string]interface{}{}
+	if session != nil {
+		vals[SSOSessionFileKey] = session
+	}
+	if err := storeAccessToken(path, token, store, vals); err != nil {
+		return false, err
+	}
+
+	_, _, store, err = readCredentials(path)
+	return store == CredentialStoreKeyring, err
+}
+
+// hasPlainRefreshToken reports whether the configuration file found at path
+// holds the refresh token of its SSO session in plain text.
+func hasPlainRefreshToken(path string) (bool, error) {
+	var w struct {
+		SSOSession *SSOSession `yaml:"sso_session"`
+	}
+	if err := unmarshal(path, &w); err != nil {
+		return false, err
+	}
+	return w.SSOSession != nil && w.SSOSession.RefreshToken != "", nil
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/keyring"
+)
+
+func TestMain(m *testing.M) {
+	// Keep tests away from the keyring of the user running them
+	systemKeyring = nil
+	os.Exit(m.Run())
+}
+
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(service, account string) (string, error) {
+	secret, ok := k[service+":"+account]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k memoryKeyring) Set(service, account, secret string) error {
+	k[service+":"+account] = secret
+	return nil
+}
+
+func (k memoryKeyring) Delete(service, account string) error {
+	if _, ok := k[service+":"+account]; !ok {
+		return keyring.ErrNotFound
+	}
+	delete(k, service+":"+account)
+	return nil
+}
+
+type failingKeyring struct{ memoryKeyring }
+
+func (failingKeyring) Set(string, string, string) error {
+	return errors.New("no session bus")
+}
+
+func useKeyring(t *testing.T, k keyring.Keyring) {
+	systemKeyring = k
+	t.Cleanup(func() { systemKeyring = nil })
+}
+
+func fileToken(t *testing.T, path string) (token, store string) {
+	var w struct {
+		AccessToken     string `yaml:"access_token"`
+		CredentialStore string `yaml:"credential_store"`
+	}
+	require.NoError(t, unmarshal(path, &w))
+	return w.AccessToken, w.CredentialStore
+}
+
+func TestAccessTokenInKeyring(t *testing.T) {
+	k := memoryKeyring{}
+	useKeyring(t, k)
+	path := filepath.Join(t.TempDir(), FileName)
+
+	require.NoError(t, SetAccessToken(path, "secret"))
+
+	token, store := fileToken(t, path)
+	assert.Empty(t, token)
+	assert.Equal(t, CredentialStoreKeyring, store)
+	assert.Equal(t, "secret", k[keyringService+":"+path])
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Equal(t, "secret", cfg.AccessToken)
+	assert.Equal(t, CredentialStoreKeyring, cfg.CredentialStore)
+
+	require.NoError(t, Clear(path))
+	assert.Empty(t, k)
+}
+
+func TestInsecureFileStore(t *testing.T) {
+	k := memoryKeyring{}
+	useKeyring(t, k)
+	path := filepath.Join(t.TempDir(), FileName)
+
+	require.NoError(t, SetAccessToken(path, "secret"))
+	require.NoError(t, SetCredentialStore(path, CredentialStoreFile))
+
+	token, store := fileToken(t, path)
+	assert.Equal(t, "secret", token)
+	assert.Equal(t, CredentialStoreFile, store)
+	assert.Empty(t, k)
+
+	// Opting out sticks until the store is set back
+	require.NoError(t, SetAccessToken(path, "rotated"))
+	token, _ = fileToken(t, path)
+	assert.Equal(t, "rotated", token)
+
+	require.NoError(t, SetCredentialStore(path, CredentialStoreKeyring))
+	token, store = fileToken(t, path)
+	assert.Empty(t, token)
+	assert.Equal(t, CredentialStoreKeyring, store)
+	assert.Equal(t, "rotated", k[keyringService+":"+path])
+}
+
+func TestMigrateAccessToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, SetAccessToken(path, "plain"))
+
+	// Without keyring the token stays in the file
+	migrated, err := MigrateAccessToken(path)
+	require.NoError(t, err)
+	assert.False(t, migrated)
+
+	k := memoryKeyring{}
+	useKeyring(t, k)
+
+	migrated, err = MigrateAccessToken(path)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.Equal(t, "plain", k[keyringService+":"+path])
+
+	migrated, err = MigrateAccessToken(path)
+	require.NoError(t, err)
+	assert.False(t, migrated)
+}
+
+func TestMigrateAccessTokenFallsBackToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, SetAccessToken(path, "plain"))
+
+	useKeyring(t, failingKeyring{memoryKeyring{}})
+
+	migrated, err := MigrateAccessToken(path)
+	var fallback *KeyringFallbackError
+	require.ErrorAs(t, err, &fallback)
+	assert.EqualError(t, fallback.Err, "no session bus")
+	assert.False(t, migrated)
+
+	token, store := fileToken(t, path)
+	assert.Equal(t, "plain", token)
+	assert.Equal(t, CredentialStoreFile, store)
+}
+
+func TestUnavailableKeyring(t *testing.T) {
+	useKeyring(t, memoryKeyring{})
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, SetAccessToken(path, "secret"))
+	require.NoError(t, set(path, map[string]interface{}{OrganizationFileKey: "acme"}))
+
+	systemKeyring = nil
+
+	// The token isn't mistaken for a logout
+	cfg := New()
+	require.ErrorIs(t, cfg.ApplyFile(path), ErrKeyringUnavailable)
+	assert.Empty(t, cfg.AccessToken)
+	assert.Equal(t, "acme", cfg.Organization)
+
+	// Logging in again stores the token in the file, with a warning
+	var fallback *KeyringFallbackError
+	require.ErrorAs(t, SetAccessToken(path, "renewed"), &fallback)
+	token, store := fileToken(t, path)
+	assert.Equal(t, "renewed", token)
+	assert.Equal(t, CredentialStoreFile, store)
+
+	cfg = New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Equal(t, "renewed", cfg.AccessToken)
+}
+
+func fileRefreshToken(t *testing.T, path string) string {
+	var w struct {
+		SSOSession *SSOSession `yaml:"sso_session"`
+	}
+	require.NoError(t, unmarshal(path, &w))
+	require.NotNil(t, w.SSOSession)
+	return w.SSOSession.RefreshToken
+}
+
+func TestRefreshTokenInKeyring(t *testing.T) {
+	k := memoryKeyring{}
+	useKeyring(t, k)
+	path := filepath.Join(t.TempDir(), FileName)
+
+	require.NoError(t, SetSSOSession(path, "short-lived", &SSOSession{Organization: "acme", RefreshToken: "refresh"}))
+
+	assert.Empty(t, fileRefreshToken(t, path))
+	assert.Equal(t, "refresh", k[keyringService+":"+refreshTokenAccount(path)])
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(path))
+	require.NotNil(t, cfg.SSOSession)
+	assert.Equal(t, "refresh", cfg.SSOSession.RefreshToken)
+	assert.Equal(t, "acme", cfg.SSOSession.Organization)
+
+	require.NoError(t, SetCredentialStore(path, CredentialStoreFile))
+	assert.Equal(t, "refresh", fileRefreshToken(t, path))
+	assert.Empty(t, k)
+
+	require.NoError(t, SetCredentialStore(path, CredentialStoreKeyring))
+	assert.Empty(t, fileRefreshToken(t, path))
+	assert.Equal(t, "refresh", k[keyringService+":"+refreshTokenAccount(path)])
+
+	require.NoError(t, Clear(path))
+	assert.Empty(t, k)
+}
+
+func TestMigrateRefreshToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, SetSSOSession(path, "short-lived", &SSOSession{Organization: "acme", RefreshToken: "refresh"}))
+	assert.Equal(t, "refresh", fileRefreshToken(t, path))
+
+	k := memoryKeyring{}
+	useKeyring(t, k)
+
+	migrated, err := MigrateAccessToken(path)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.Empty(t, fileRefreshToken(t, path))
+	assert.Equal(t, "short-lived", k[keyringService+":"+path])
+	assert.Equal(t, "refresh", k[keyringService+":"+refreshTokenAccount(path)])
+
+	// Refresh tokens left in plain text next to a token in the keyring move too
+	require.NoError(t, set(path, map[string]interface{}{
+		SSOSessionFileKey: &SSOSession{Organization: "acme", RefreshToken: "renewed"},
+	}))
+	migrated, err = MigrateAccessToken(path)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.Empty(t, fileRefreshToken(t, path))
+	assert.Equal(t, "renewed", k[keyringService+":"+refreshTokenAccount(path)])
+
+	migrated, err = MigrateAccessToken(path)
+	require.NoError(t, err)
+	assert.False(t, migrated)
+}
@@ -6,17 +6,24 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
 
-	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/filemu"
 )
 
 // SetAccessToken sets the value of the access token at the configuration file
-// found at path.
+// found at path, or of the keyring when the file uses it.
 func SetAccessToken(path, token string) error {
-	return set(path, map[string]interface{}{
-		AccessTokenFileKey: token,
+	return setWithAccessToken(path, token, map[string]interface{}{})
+}
+
+// SetSSOSession sets the access token and the SSO session it was issued for
+// at the configuration file found at path.
+func SetSSOSession(path, token string, session *SSOSession) error {
+	return setWithAccessToken(path, token, map[string]interface{}{
+		SSOSessionFileKey: session,
 	})
 }
 
@@ -36,11 +43,124 @@ func SetSendMetrics(path string, sendMetrics bool) error {
 	})
 }
 
-// Clear clears the access token, metrics token, and wireguard-related keys of the configuration
+// SetOfflineQueue sets whether mutations are queued when the API is unreachable
+// at the configuration file found at path.
+func SetOfflineQueue(path string, enabled bool) error {
+	return set(path, map[string]interface{}{
+		OfflineQueueFileKey: enabled,
+	})
+}
+
+// Kinds of resources that may be protected from deletion.
+const (
+	ProtectedApp     = "apps"
+	ProtectedVolume  = "volumes"
+	ProtectedMachine = "machines"
+)
+
+// SetProtected adds or removes the resource of the given kind and name to the
+// protected resources of the configuration file found at path.
+func SetProtected(path, kind, name string, protected bool) error {
+	var w struct {
+		Protected map[string][]string `yaml:"protected_resources"`
+	}
+
+	switch err := unmarshal(path, &w); {
+	case err == nil, os.IsNotExist(err):
+		break
+	default:
+		return err
+	}
+
+	if w.Protected == nil {
+		w.Protected = map[string][]string{}
+	}
+
+	names := lo.Without(w.Protected[kind], name)
+	if protected {
+		names = append(names, name)
+		slices.Sort(names)
+	}
+	w.Protected[kind] = names
+
+	return set(path, map[string]interface{}{
+		ProtectedFileKey: w.Protected,
+	})
+}
+
+// Kinds of resources that may have a budget.
+const (
+	BudgetOrg = "orgs"
+	BudgetApp = "apps"
+)
+
+// SetBudget sets the monthly budget in USD of the organization or app of the
+// given kind and name at the configuration file found at path. A zero amount
+// removes the budget.
+func SetBudget(path, kind, name string, amount float64) error {
+	var w struct {
+		Budgets map[string]map[string]float64 `yaml:"budgets"`
+	}
+
+	switch err := unmarshal(path, &w); {
+	case err == nil, os.IsNotExist(err):
+		break
+	default:
+		return err
+	}
+
+	if w.Budgets == nil {
+		w.Budgets = map[string]map[string]float64{}
+	}
+	if w.Budgets[kind] == nil {
+		w.Budgets[kind] = map[string]float64{}
+	}
+
+	if amount > 0 {
+		w.Budgets[kind][name] = amount
+	} else {
+		delete(w.Budgets[kind], name)
+	}
+
+	return set(path, map[string]interface{}{
+		BudgetsFileKey: w.Budgets,
+	})
+}
+
+// SetOrgRegion sets the default region of the organization orgSlug at the
+// configuration file found at path. An empty region removes it.
+func SetOrgRegion(path, orgSlug, region string) error {
+	var w struct {
+		OrgRegions map[string]string `yaml:"org_regions"`
+	}
+
+	switch err := unmarshal(path, &w); {
+	case err == nil, os.IsNotExist(err):
+		break
+	default:
+		return err
+	}
+
+	if w.OrgRegions == nil {
+		w.OrgRegions = map[string]string{}
+	}
+
+	if region != "" {
+		w.OrgRegions[orgSlug] = region
+	} else {
+		delete(w.OrgRegions, orgSlug)
+	}
+
+	return set(path, map[string]interface{}{
+		OrgRegionsFileKey: w.OrgRegions,
+	})
+}
+
+// Clear clears the access token, SSO session, metrics token, and wireguard-related keys of the configuration
 // file found at path.
 func Clear(path string) (err error) {
-	return set(path, map[string]interface{}{
-		AccessTokenFileKey:    "",
+	return setWithAccessToken(path, "", map[string]interface{}{
+		SSOSessionFileKey:     nil,
 		MetricsTokenFileKey:   "",
 		WireGuardStateFileKey: map[string]interface{}{},
 	})
@@ -63,13 +183,15 @@ func set(path string, vals map[string]interface{}) error {
 	return marshal(path, m)
 }
 
-func lockPath() string {
-	return filepath.Join(flyctl.ConfigDir(), "flyctl.config.lock")
+// lockPath returns the path of the lock guarding the files of the directory
+// path is in, the config directory outside of tests.
+func lockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "flyctl.config.lock")
 }
 
 func unmarshal(path string, v interface{}) (err error) {
 	var unlock filemu.UnlockFunc
-	if unlock, err = filemu.RLock(context.Background(), lockPath()); err != nil {
+	if unlock, err = filemu.RLock(context.Background(), lockPath(path)); err != nil {
 		return
 	}
 	defer func() {
@@ -101,7 +223,7 @@ func unmarshalUnlocked(path string, v interface{}) (err error) {
 
 func marshal(path string, v interface{}) (err error) {
 	var unlock filemu.UnlockFunc
-	if unlock, err = filemu.Lock(context.Background(), lockPath()); err != nil {
+	if unlock, err = filemu.Lock(context.Background(), lockPath(path)); err != nil {
 		return
 	}
 	defer func() {
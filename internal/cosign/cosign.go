@@ -0,0 +1,119 @@
+// Package cosign signs and verifies images with the cosign CLI.
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/registry"
+)
+
+// keyRefSchemes prefix key references cosign resolves through a KMS or a
+// secret store rather than from a local file.
+var keyRefSchemes = []string{
+	"awskms://",
+	"azurekms://",
+	"gcpkms://",
+	"hashivault://",
+	"k8s://",
+	"gitlab://",
+}
+
+// IsKeyRef reports whether signer refers to a key, as opposed to the
+// identity of a keyless signer such as an email address or a workflow URL.
+func IsKeyRef(signer string) bool {
+	for _, scheme := range keyRefSchemes {
+		if strings.HasPrefix(signer, scheme) {
+			return true
+		}
+	}
+	if strings.HasSuffix(signer, ".pub") || strings.HasSuffix(signer, ".key") || strings.HasSuffix(signer, ".pem") {
+		return true
+	}
+	_, err := os.Stat(signer)
+	return err == nil
+}
+
+// DigestRef returns the reference of the image imageRef names by digest,
+// which signatures are bound to, unlike tags that can be pushed again.
+func DigestRef(imageRef, digest string) string {
+	repository, _, _ := strings.Cut(imageRef, "@")
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	return repository + "@" + digest
+}
+
+// Sign signs imageRef, which should name the image by digest, with key, or
+// keylessly when key is empty.
+func Sign(ctx context.Context, imageRef, key string) error {
+	args := []string{"sign", "--yes"}
+	if key != "" {
+		args = append(args, "--key", key)
+	}
+	args = append(args, imageRef)
+
+	if out, err := run(ctx, args...); err != nil {
+		return fmt.Errorf("failed signing %s: %w\n%s", imageRef, err, out)
+	}
+	return nil
+}
+
+// Verify checks that imageRef, which should name the image by digest, carries
+// a valid signature from signer. signer is either a key reference or the
+// certificate identity of a keyless signer, whose certificate must have been
+// issued by the OIDC provider issuer.
+func Verify(ctx context.Context, imageRef, signer, issuer string) error {
+	args, err := verifyArgs(imageRef, signer, issuer)
+	if err != nil {
+		return err
+	}
+
+	if out, err := run(ctx, args...); err != nil {
+		return fmt.Errorf("%s is not signed by %s: %w\n%s", imageRef, signer, err, out)
+	}
+	return nil
+}
+
+func verifyArgs(imageRef, signer, issuer string) ([]string, error) {
+	args := []string{"verify"}
+	switch {
+	case IsKeyRef(signer):
+		args = append(args, "--key", signer)
+	case issuer == "":
+		return nil, fmt.Errorf("keyless signer %s needs the OIDC issuer of its certificate, e.g. https://token.actions.githubusercontent.com", signer)
+	default:
+		args = append(args,
+			"--certificate-identity", signer,
+			"--certificate-oidc-issuer", issuer,
+		)
+	}
+	return append(args, imageRef), nil
+}
+
+func run(ctx context.Context, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath("cosign")
+	if err != nil {
+		return nil, fmt.Errorf("cosign cli not found - install it from https://docs.sigstore.dev/cosign/installation and try again: %w", err)
+	}
+
+	dockerConfig, cleanup, err := registry.AuthConfigDir(config.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+dockerConfig)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err = cmd.Run()
+	return out.Bytes(), err
+}
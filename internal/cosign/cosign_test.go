@@ -0,0 +1,52 @@
+package cosign
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsKeyRef(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "signing")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("key"), 0o600))
+
+	assert.True(t, IsKeyRef("cosign.pub"))
+	assert.True(t, IsKeyRef("awskms:///arn:aws:kms:us-east-1:1234:key/abcd"))
+	assert.True(t, IsKeyRef("k8s://namespace/secret"))
+	assert.True(t, IsKeyRef(keyFile))
+
+	assert.False(t, IsKeyRef("release@example.com"))
+	assert.False(t, IsKeyRef("https://github.com/org/repo/.github/workflows/release.yml@refs/heads/main"))
+}
+
+func TestDigestRef(t *testing.T) {
+	const digest = "sha256:abcd"
+
+	assert.Equal(t, "registry.fly.io/app@sha256:abcd", DigestRef("registry.fly.io/app:deployment-1", digest))
+	assert.Equal(t, "localhost:5000/app@sha256:abcd", DigestRef("localhost:5000/app", digest))
+	assert.Equal(t, "localhost:5000/app@sha256:abcd", DigestRef("localhost:5000/app:v1@sha256:old", digest))
+}
+
+func TestVerifyArgs(t *testing.T) {
+	const ref = "registry.fly.io/app@sha256:abcd"
+
+	args, err := verifyArgs(ref, "cosign.pub", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"verify", "--key", "cosign.pub", ref}, args)
+
+	args, err = verifyArgs(ref, "release@example.com", "https://accounts.google.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"verify",
+		"--certificate-identity", "release@example.com",
+		"--certificate-oidc-issuer", "https://accounts.google.com",
+		ref,
+	}, args)
+
+	_, err = verifyArgs(ref, "release@example.com", "")
+	assert.ErrorContains(t, err, "needs the OIDC issuer")
+}
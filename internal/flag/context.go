@@ -121,6 +121,16 @@ func GetYes(ctx context.Context) bool {
 	return GetBool(ctx, flagnames.Yes)
 }
 
+// GetJSONSchemaVersion is shorthand for GetInt(ctx, JSONSchemaVersion).
+func GetJSONSchemaVersion(ctx context.Context) int {
+	return GetInt(ctx, flagnames.JSONSchemaVersion)
+}
+
+// GetContinueOnError is shorthand for GetBool(ctx, ContinueOnError).
+func GetContinueOnError(ctx context.Context) bool {
+	return GetBool(ctx, flagnames.ContinueOnError)
+}
+
 // GetApp is shorthand for GetString(ctx, App).
 func GetApp(ctx context.Context) string {
 	return GetString(ctx, flagnames.App)
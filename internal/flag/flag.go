@@ -444,10 +444,33 @@ func NoCache() Bool {
 	}
 }
 
+// NoMachinesCache returns a no-cache flag listing the current machines of an
+// app instead of those cached by the last commands.
+func NoMachinesCache() Bool {
+	return Bool{
+		Name:        "no-cache",
+		Description: "List the current machines instead of those cached in the last 15 seconds",
+	}
+}
+
 func BuildSecret() StringArray {
 	return StringArray{
 		Name:        "build-secret",
-		Description: "Set of build secrets of NAME=VALUE pairs. Can be specified multiple times. See https://docs.docker.com/develop/develop-images/build_enhancements/#new-docker-build-secret-information",
+		Description: "Set of build secrets of NAME=VALUE pairs, or NAME to read the value from the environment variable NAME. Secrets are mounted with RUN --mount=type=secret,id=NAME and never stored in the image. Can be specified multiple times. See https://docs.docker.com/develop/develop-images/build_enhancements/#new-docker-build-secret-information",
+	}
+}
+
+func BuildSecretFile() StringArray {
+	return StringArray{
+		Name:        "build-secret-file",
+		Description: "Set of build secrets of NAME=PATH pairs, read from the file at PATH. Can be specified multiple times.",
+	}
+}
+
+func RegistryAuth() StringArray {
+	return StringArray{
+		Name:        "registry-auth",
+		Description: "Pass the local Docker credentials of this private registry, e.g. ghcr.io, to the builder to pull base images from. ECR registries without credentials use a token of the AWS CLI. Can be specified multiple times.",
 	}
 }
 
@@ -458,6 +481,13 @@ func BuildArg() StringArray {
 	}
 }
 
+func BuildArgFile() StringArray {
+	return StringArray{
+		Name:        "build-arg-file",
+		Description: "Read build time variables from a file of NAME=VALUE lines, like a .env file. Build args of --build-arg take precedence. Can be specified multiple times.",
+	}
+}
+
 func BuildTarget() String {
 	return String{
 		Name:        "build-target",
@@ -488,3 +518,47 @@ func JSONOutput() Bool {
 		Default:     false,
 	}
 }
+
+// JSONSchemaVersion returns an int flag picking the schema version of the
+// JSON output of a command. Setting it implies JSON output.
+func JSONSchemaVersion() Int {
+	return Int{
+		Name:        flagnames.JSONSchemaVersion,
+		Description: "JSON output in the shape of this schema version, which stays compatible across flyctl upgrades. See 'fly schema'.",
+	}
+}
+
+// ContinueOnError returns a boolean flag carrying commands acting on several
+// resources on past the ones they fail for.
+func ContinueOnError() Bool {
+	return Bool{
+		Name:        flagnames.ContinueOnError,
+		Description: "Carry on with the remaining resources after a failure rather than stop at the first one. See 'fly help exit-codes'.",
+	}
+}
+
+// StopSignal returns the flag overriding the signal machines are stopped with.
+func StopSignal() String {
+	return String{
+		Name:        "stop-signal",
+		Description: "Signal to stop machines with, e.g. SIGTERM, overriding kill_signal for this operation only",
+	}
+}
+
+// StopGracePeriod returns the flag overriding how long stopping machines get
+// before they're killed.
+func StopGracePeriod() Duration {
+	return Duration{
+		Name:        "stop-grace-period",
+		Description: "How long to wait after the stop signal before killing machines, e.g. 30s, overriding kill_timeout for this operation only",
+	}
+}
+
+// PreStopCommand returns the flag of the command run inside machines before
+// they're stopped.
+func PreStopCommand() String {
+	return String{
+		Name:        "pre-stop-command",
+		Description: "Command to run inside each machine before stopping it, e.g. to drain connections",
+	}
+}
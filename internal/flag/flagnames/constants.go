@@ -4,6 +4,9 @@ const (
 	// AccessToken denotes the name of the access token flag.
 	AccessToken = "access-token"
 
+	// Context denotes the name of the auth context flag.
+	Context = "context"
+
 	// Verbose denotes the name of the verbose flag.
 	Verbose = "verbose"
 
@@ -42,4 +45,10 @@ const (
 
 	// DetachName denotes the name of the detach flag.
 	Detach = "detach"
+
+	// JSONSchemaVersion denotes the name of the json schema version flag.
+	JSONSchemaVersion = "json-schema-version"
+
+	// ContinueOnError denotes the name of the continue on error flag.
+	ContinueOnError = "continue-on-error"
 )
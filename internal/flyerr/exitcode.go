@@ -0,0 +1,201 @@
+package flyerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/graphql"
+)
+
+// The exit codes of flyctl, by kind of failure. Scripts branch on them, so
+// they never change meaning across releases; new kinds of failure get new
+// codes.
+const (
+	ExitCodeOK             = 0
+	ExitCodeError          = 1
+	ExitCodeValidation     = 2
+	ExitCodeAuth           = 3
+	ExitCodeNotFound       = 4
+	ExitCodePartialSuccess = 5
+	ExitCodeTimeout        = 126
+	ExitCodeCanceled       = 127
+)
+
+// ExitCodeDoc documents an exit code.
+type ExitCodeDoc struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ExitCodeDocs document the exit codes of flyctl, as 'fly help exit-codes'
+// lists them.
+var ExitCodeDocs = []ExitCodeDoc{
+	{ExitCodeOK, "ok", "The command succeeded"},
+	{ExitCodeError, "error", "The command failed for a reason without a more specific code"},
+	{ExitCodeValidation, "validation", "Invalid arguments, flags or configuration, or a request the API rejected as invalid"},
+	{ExitCodeAuth, "auth", "Not logged in, an expired or revoked token, or missing permissions"},
+	{ExitCodeNotFound, "not-found", "The app, machine, volume or other resource doesn't exist"},
+	{ExitCodePartialSuccess, "partial-success", "The command acted on several resources and failed for some of them"},
+	{ExitCodeTimeout, "timeout", "The command timed out"},
+	{ExitCodeCanceled, "canceled", "The command was interrupted"},
+}
+
+// exitCoder is an error picking the exit code of flyctl.
+type exitCoder interface {
+	error
+	ExitCode() int
+}
+
+type exitCodeError struct {
+	error
+	code int
+}
+
+func (e *exitCodeError) Unwrap() error { return e.error }
+
+func (e *exitCodeError) ExitCode() int { return e.code }
+
+// Validation marks err as a validation error, exiting with
+// ExitCodeValidation.
+func Validation(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{err, ExitCodeValidation}
+}
+
+// NotFound marks err as a resource not being found, exiting with
+// ExitCodeNotFound.
+func NotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{err, ExitCodeNotFound}
+}
+
+// PartialError is the error of an operation over several items which failed
+// for some of them only.
+type PartialError struct {
+	// Succeeded counts the items the operation succeeded for.
+	Succeeded int
+
+	// Failed counts the items the operation failed for.
+	Failed int
+
+	// Skipped counts the items left out after a failure, without
+	// --continue-on-error.
+	Skipped int
+
+	// Err is the error of the items the operation failed for.
+	Err error
+}
+
+func (e *PartialError) Error() string {
+	msg := fmt.Sprintf("succeeded for %d, failed for %d", e.Succeeded, e.Failed)
+	if e.Skipped > 0 {
+		msg += fmt.Sprintf(", skipped %d", e.Skipped)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+func (*PartialError) ExitCode() int { return ExitCodePartialSuccess }
+
+// ForEach calls fn for each of items. It stops at the first failure, unless
+// continueOnError is set. It returns a *PartialError when fn failed for some
+// items only, and the joined errors of fn when it failed for all of them.
+func ForEach[T any](items []T, continueOnError bool, fn func(T) error) error {
+	var (
+		errs      []error
+		succeeded int
+	)
+	for i, item := range items {
+		if err := fn(item); err != nil {
+			errs = append(errs, err)
+			if !continueOnError {
+				if succeeded == 0 {
+					return err
+				}
+				return &PartialError{Succeeded: succeeded, Failed: 1, Skipped: len(items) - i - 1, Err: err}
+			}
+			continue
+		}
+		succeeded++
+	}
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case succeeded == 0:
+		return errors.Join(errs...)
+	default:
+		return &PartialError{Succeeded: succeeded, Failed: len(errs), Err: errors.Join(errs...)}
+	}
+}
+
+// ExitCode returns the exit code flyctl exits with when failing with err.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	var coder exitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	if errors.Is(err, client.ErrNoAuthToken) {
+		return ExitCodeAuth
+	}
+
+	var apiErr *api.ApiError
+	if errors.As(err, &apiErr) {
+		if code := statusExitCode(apiErr.Status); code != ExitCodeError {
+			return code
+		}
+	}
+
+	var flapsErr *flaps.FlapsError
+	if errors.As(err, &flapsErr) {
+		if code := statusExitCode(flapsErr.ResponseStatusCode); code != ExitCodeError {
+			return code
+		}
+	}
+
+	var gqlErr *graphql.GraphQLError
+	if errors.As(err, &gqlErr) {
+		switch gqlErr.Extensions.Code {
+		case "UNAUTHORIZED", "UNAUTHENTICATED":
+			return ExitCodeAuth
+		case "NOT_FOUND":
+			return ExitCodeNotFound
+		case "VALIDATION", "BAD_USER_INPUT":
+			return ExitCodeValidation
+		}
+		if strings.HasPrefix(strings.ToLower(gqlErr.Message), "could not find") {
+			return ExitCodeNotFound
+		}
+	}
+
+	return ExitCodeError
+}
+
+func statusExitCode(status int) int {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ExitCodeAuth
+	case http.StatusNotFound:
+		return ExitCodeNotFound
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ExitCodeValidation
+	default:
+		return ExitCodeError
+	}
+}
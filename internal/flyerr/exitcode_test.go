@@ -0,0 +1,82 @@
+package flyerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/graphql"
+)
+
+func TestExitCode(t *testing.T) {
+	gqlErr := func(code, message string) error {
+		return &graphql.GraphQLError{Message: message, Extensions: graphql.GraphQLErrorExtensions{Code: code}}
+	}
+
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitCodeOK},
+		{errors.New("boom"), ExitCodeError},
+		{Validation(errors.New("bad flag")), ExitCodeValidation},
+		{fmt.Errorf("wrapped: %w", NotFound(errors.New("gone"))), ExitCodeNotFound},
+		{fmt.Errorf("failed: %w", client.ErrNoAuthToken), ExitCodeAuth},
+		{&api.ApiError{Status: http.StatusUnauthorized}, ExitCodeAuth},
+		{&api.ApiError{Status: http.StatusInternalServerError}, ExitCodeError},
+		{fmt.Errorf("could not get machine: %w", &flaps.FlapsError{ResponseStatusCode: http.StatusNotFound}), ExitCodeNotFound},
+		{&flaps.FlapsError{ResponseStatusCode: http.StatusUnprocessableEntity}, ExitCodeValidation},
+		{gqlErr("UNAUTHORIZED", "denied"), ExitCodeAuth},
+		{gqlErr("", "Could not find App \"nope\""), ExitCodeNotFound},
+		{gqlErr("UNCHANGED", "no changes"), ExitCodeError},
+		{&PartialError{Succeeded: 1, Err: errors.New("boom")}, ExitCodePartialSuccess},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.code, ExitCode(c.err), "%v", c.err)
+	}
+
+	assert.Nil(t, Validation(nil))
+	assert.Nil(t, NotFound(nil))
+}
+
+func TestForEach(t *testing.T) {
+	var seen []int
+	failOdd := func(i int) error {
+		seen = append(seen, i)
+		if i%2 == 1 {
+			return fmt.Errorf("failed for %d", i)
+		}
+		return nil
+	}
+
+	assert.NoError(t, ForEach([]int{0, 2, 4}, true, failOdd))
+
+	seen = nil
+	err := ForEach([]int{0, 1, 2, 3}, true, failOdd)
+	assert.Equal(t, []int{0, 1, 2, 3}, seen)
+	var partial *PartialError
+	assert.ErrorAs(t, err, &partial)
+	assert.Equal(t, 2, partial.Succeeded)
+	assert.Equal(t, "succeeded for 2, failed for 2: failed for 1\nfailed for 3", err.Error())
+	assert.Equal(t, ExitCodePartialSuccess, ExitCode(err))
+
+	seen = nil
+	err = ForEach([]int{0, 1, 2, 3}, false, failOdd)
+	assert.Equal(t, []int{0, 1}, seen)
+	assert.Equal(t, "succeeded for 1, failed for 1, skipped 2: failed for 1", err.Error())
+
+	nf := NotFound(errors.New("gone"))
+	err = ForEach([]int{1, 3}, false, func(int) error { return nf })
+	assert.Same(t, nf, err)
+	assert.Equal(t, ExitCodeNotFound, ExitCode(err))
+
+	err = ForEach([]int{1, 3}, true, failOdd)
+	assert.False(t, errors.As(err, &partial))
+	assert.EqualError(t, err, "failed for 1\nfailed for 3")
+}
@@ -48,6 +48,8 @@ func PrintCLIOutput(err error) {
 		return
 	}
 
+	err = Remediate(err)
+
 	fmt.Println()
 	fmt.Println(aurora.Red("Error"), err)
 
@@ -0,0 +1,115 @@
+package flyerr
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/superfly/graphql"
+)
+
+// Remediation maps the errors the API and flaps commonly return to an
+// actionable description and suggestion.
+type Remediation struct {
+	// Codes are the GraphQL error codes the remediation applies to.
+	Codes []string
+
+	// Messages are the lowercase fragments of error messages the remediation
+	// applies to.
+	Messages []string
+
+	Description string
+	Suggestion  string
+}
+
+func (r *Remediation) matches(err error) bool {
+	var gqlErr *graphql.GraphQLError
+	if errors.As(err, &gqlErr) {
+		for _, code := range r.Codes {
+			if gqlErr.Extensions.Code == code {
+				return true
+			}
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range r.Messages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+var remediations = []Remediation{
+	{
+		Messages: []string{
+			"insufficient resources",
+			"insufficient capacity",
+			"no capacity",
+			"not enough capacity",
+			"could not reserve resource",
+		},
+		Description: "The region doesn't have enough capacity for the requested machine size right now.",
+		Suggestion: "Try again in another region with --region (list them with 'fly platform regions'), " +
+			"or with a smaller size (list them with 'fly platform vm-sizes').",
+	},
+	{
+		Messages: []string{
+			"name has already been taken",
+			"name is already taken",
+		},
+		Description: "Names are unique across all of Fly.io, so the name may belong to an app in another organization.",
+		Suggestion:  "Pick another name, or check whether the app is yours with 'fly apps list'.",
+	},
+	{
+		Codes: []string{"UNVERIFIED_ORGANIZATION", "BILLING_REQUIRED"},
+		Messages: []string{
+			"restricted access until it's verified",
+			"needs a payment method",
+			"add a credit card",
+			"verify your account",
+			"unverified organization",
+		},
+		Description: "The organization has to be verified with a payment method before it can create resources.",
+		Suggestion:  "Add a payment method at https://fly.io/dashboard, then check the organization with 'fly orgs show'.",
+	},
+	{
+		Codes: []string{"UNAUTHORIZED", "UNAUTHENTICATED"},
+		Messages: []string{
+			"caveat",
+			"token has expired",
+			"token is expired",
+			"invalid token",
+		},
+		Description: "The access token in use is expired or doesn't allow this operation.",
+		Suggestion: "Inspect what the token allows with 'fly auth whoami --verbose', " +
+			"then log in again with 'fly auth login' or create a suitable token with 'fly tokens create'.",
+	},
+}
+
+type remediatedError struct {
+	error
+	remediation *Remediation
+}
+
+func (e *remediatedError) Unwrap() error { return e.error }
+
+func (e *remediatedError) Description() string { return e.remediation.Description }
+
+func (e *remediatedError) Suggestion() string { return e.remediation.Suggestion }
+
+// Remediate returns err annotated with the description and suggestion of the
+// first remediation matching it. Errors that carry a description or suggestion
+// of their own, and errors no remediation matches, are returned as they are.
+func Remediate(err error) error {
+	if err == nil || GetErrorDescription(err) != "" || GetErrorSuggestion(err) != "" {
+		return err
+	}
+
+	for i := range remediations {
+		if r := &remediations[i]; r.matches(err) {
+			return &remediatedError{error: err, remediation: r}
+		}
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package flyerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/graphql"
+)
+
+func TestRemediate(t *testing.T) {
+	assert.Nil(t, Remediate(nil))
+
+	plain := errors.New("something else")
+	assert.Same(t, plain, Remediate(plain))
+
+	err := fmt.Errorf("failed launching machine: %w", errors.New("insufficient resources available to fulfill request"))
+	remediated := Remediate(err)
+	assert.ErrorIs(t, remediated, err)
+	assert.Equal(t, err.Error(), remediated.Error())
+	assert.Contains(t, GetErrorSuggestion(remediated), "--region")
+
+	remediated = Remediate(errors.New("Name has already been taken"))
+	assert.Contains(t, GetErrorSuggestion(remediated), "fly apps list")
+
+	gqlErr := &graphql.GraphQLError{Message: "denied", Extensions: graphql.GraphQLErrorExtensions{Code: "UNAUTHORIZED"}}
+	remediated = Remediate(gqlErr)
+	assert.Contains(t, GetErrorSuggestion(remediated), "fly auth whoami --verbose")
+}
+
+type suggestedError struct{ error }
+
+func (suggestedError) Suggestion() string { return "do this instead" }
+
+func TestRemediateKeepsOwnSuggestion(t *testing.T) {
+	err := suggestedError{errors.New("no capacity in ord")}
+	assert.Equal(t, "do this instead", GetErrorSuggestion(Remediate(err)))
+}
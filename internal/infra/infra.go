@@ -0,0 +1,164 @@
+// Package infra tracks the images of the machines flyctl runs on behalf of
+// users, like log shippers and cron runners, on release channels.
+package infra
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/buildinfo"
+)
+
+// Release channels of infra images.
+const (
+	// ChannelStable runs the images matching this version of flyctl.
+	ChannelStable = "stable"
+	// ChannelEdge runs the latest images.
+	ChannelEdge = "edge"
+)
+
+// Components, the kinds of infra machines.
+const (
+	// ComponentFlyctl runs flyctl subcommands: cron runners, autoscalers,
+	// uptime probers and tunnel relays.
+	ComponentFlyctl = "flyctl"
+	// ComponentLogShipper ships logs of an organization.
+	ComponentLogShipper = "log-shipper"
+	// ComponentVault holds the secrets of an organization in its environment.
+	ComponentVault = "vault"
+)
+
+// Metadata keys recording the image of infra machines.
+const (
+	MetadataKeyComponent = "fly_infra_component"
+	MetadataKeyChannel   = "fly_infra_channel"
+	MetadataKeyImage     = "fly_infra_image"
+)
+
+const (
+	flyctlRepository     = "flyio/flyctl"
+	logShipperRepository = "flyio/log-shipper"
+	vaultRepository      = "busybox"
+
+	// logShipperStableTag is the log shipper release flyctl was tested with.
+	logShipperStableTag = "auto-a14aa63"
+
+	// vaultTag is the release vaults run on every channel. Vaults only idle
+	// and print their environment, which needs no flyctl.
+	vaultTag = "1.36.1"
+)
+
+var repositories = map[string]string{
+	ComponentFlyctl:     flyctlRepository,
+	ComponentLogShipper: logShipperRepository,
+	ComponentVault:      vaultRepository,
+}
+
+// pinnedTags are the tags of components that don't follow release channels.
+var pinnedTags = map[string]string{
+	ComponentVault: vaultTag,
+}
+
+// Channels returns the release channels.
+func Channels() []string {
+	return []string{ChannelStable, ChannelEdge}
+}
+
+// Image returns the image of component on channel.
+func Image(component, channel string) (string, error) {
+	repository, ok := repositories[component]
+	if !ok {
+		return "", fmt.Errorf("unknown infra component %s", component)
+	}
+
+	switch channel {
+	case ChannelEdge:
+		if tag, ok := pinnedTags[component]; ok {
+			return repository + ":" + tag, nil
+		}
+		return repository + ":latest", nil
+	case ChannelStable:
+		return repository + ":" + stableTag(component), nil
+	default:
+		return "", fmt.Errorf("unknown release channel %s, must be one of %s", channel, strings.Join(Channels(), ", "))
+	}
+}
+
+func stableTag(component string) string {
+	switch {
+	case component == ComponentLogShipper:
+		return logShipperStableTag
+	case component == ComponentVault:
+		return vaultTag
+	case buildinfo.IsDev():
+		// Development builds have no matching image
+		return "latest"
+	default:
+		return "v" + buildinfo.Version().String()
+	}
+}
+
+// Configure sets the image of config to the one of component, and records it
+// in its metadata. Machines keep the channel of current, the machine config
+// replaces the config of, and new machines start on the stable channel.
+func Configure(config *api.MachineConfig, component string, current *api.Machine) {
+	channel := ChannelStable
+	if current != nil {
+		channel = ChannelOf(current)
+	}
+	SetChannel(config, component, channel)
+}
+
+// SetChannel sets the image of config to the one of component on channel, or
+// on the stable channel when channel is unknown.
+func SetChannel(config *api.MachineConfig, component, channel string) {
+	image, err := Image(component, channel)
+	if err != nil {
+		channel = ChannelStable
+		image, _ = Image(component, channel)
+	}
+
+	config.Image = image
+	if config.Metadata == nil {
+		config.Metadata = map[string]string{}
+	}
+	config.Metadata[MetadataKeyComponent] = component
+	config.Metadata[MetadataKeyChannel] = channel
+	config.Metadata[MetadataKeyImage] = image
+}
+
+// ComponentOf returns the component m runs, or an empty string when it isn't
+// an infra machine. Machines launched before their channel was recorded are
+// recognized by their image, except for components running images flyctl
+// doesn't publish.
+func ComponentOf(m *api.Machine) string {
+	if m.Config == nil {
+		return ""
+	}
+	if component := m.Config.Metadata[MetadataKeyComponent]; component != "" {
+		return component
+	}
+
+	repository, _, _ := strings.Cut(m.Config.Image, ":")
+	for _, component := range maps.Keys(repositories) {
+		if _, isPinned := pinnedTags[component]; isPinned {
+			continue
+		}
+		if repositories[component] == repository {
+			return component
+		}
+	}
+	return ""
+}
+
+// ChannelOf returns the channel m is on.
+func ChannelOf(m *api.Machine) string {
+	if m.Config != nil && slices.Contains(Channels(), m.Config.Metadata[MetadataKeyChannel]) {
+		return m.Config.Metadata[MetadataKeyChannel]
+	}
+	return ChannelStable
+}
@@ -0,0 +1,57 @@
+package infra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestImage(t *testing.T) {
+	image, err := Image(ComponentLogShipper, ChannelStable)
+	require.NoError(t, err)
+	assert.Equal(t, "flyio/log-shipper:"+logShipperStableTag, image)
+
+	image, err = Image(ComponentFlyctl, ChannelEdge)
+	require.NoError(t, err)
+	assert.Equal(t, "flyio/flyctl:latest", image)
+
+	_, err = Image(ComponentFlyctl, "nightly")
+	assert.Error(t, err)
+}
+
+func TestConfigureKeepsChannel(t *testing.T) {
+	config := &api.MachineConfig{Metadata: map[string]string{"other": "kept"}}
+	Configure(config, ComponentFlyctl, nil)
+	assert.Equal(t, ChannelStable, config.Metadata[MetadataKeyChannel])
+	assert.Equal(t, "kept", config.Metadata["other"])
+
+	current := &api.Machine{Config: &api.MachineConfig{Metadata: map[string]string{MetadataKeyChannel: ChannelEdge}}}
+	Configure(config, ComponentFlyctl, current)
+	assert.Equal(t, "flyio/flyctl:latest", config.Image)
+	assert.Equal(t, ChannelEdge, config.Metadata[MetadataKeyChannel])
+	assert.Equal(t, config.Image, config.Metadata[MetadataKeyImage])
+}
+
+func TestComponentOf(t *testing.T) {
+	legacy := &api.Machine{Config: &api.MachineConfig{Image: "flyio/log-shipper:auto-a14aa63"}}
+	assert.Equal(t, ComponentLogShipper, ComponentOf(legacy))
+	assert.Equal(t, ChannelStable, ChannelOf(legacy))
+
+	app := &api.Machine{Config: &api.MachineConfig{Image: "registry.fly.io/app:deployment-1"}}
+	assert.Equal(t, "", ComponentOf(app))
+}
+
+func TestVaultImageIsPinned(t *testing.T) {
+	stable, err := Image(ComponentVault, ChannelStable)
+	require.NoError(t, err)
+	edge, err := Image(ComponentVault, ChannelEdge)
+	require.NoError(t, err)
+	assert.Equal(t, "busybox:"+vaultTag, stable)
+	assert.Equal(t, stable, edge)
+
+	userMachine := &api.Machine{Config: &api.MachineConfig{Image: "busybox:" + vaultTag}}
+	assert.Equal(t, "", ComponentOf(userMachine))
+}
@@ -0,0 +1,29 @@
+// Package keyring stores secrets in the credential store of the operating
+// system: the Keychain on macOS, the Credential Manager on Windows and the
+// Secret Service, through libsecret, on Linux.
+package keyring
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the keyring holds no secret for a service
+	// and account.
+	ErrNotFound = errors.New("secret not found in keyring")
+
+	// ErrTooLarge is returned when a secret exceeds the size the keyring can
+	// hold.
+	ErrTooLarge = errors.New("secret too large for the keyring")
+)
+
+// Keyring stores secrets by service and account.
+type Keyring interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// System returns the keyring of the operating system, or nil when it has none
+// flyctl can use.
+func System() Keyring {
+	return system()
+}
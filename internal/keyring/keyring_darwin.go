@@ -0,0 +1,64 @@
+//go:build darwin
+// +build darwin
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit code of security when no item matches.
+const errSecItemNotFound = 44
+
+// keychain stores secrets as generic passwords of the login keychain, through
+// the security tool.
+type keychain struct{}
+
+func system() Keyring {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil
+	}
+	return keychain{}
+}
+
+func (keychain) Get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (keychain) Set(service, account, secret string) error {
+	if strings.ContainsAny(secret, "\"\\\n") {
+		return errors.New("secret can't be stored in the keychain")
+	}
+
+	// Commands are passed on stdin, so the secret doesn't show in the
+	// arguments of the process
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -w \"%s\"\n", service, account, secret))
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (keychain) Delete(service, account string) error {
+	return securityError(exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run())
+}
+
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
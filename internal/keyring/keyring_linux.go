@@ -0,0 +1,60 @@
+//go:build linux
+// +build linux
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// secretService stores secrets in the Secret Service of the desktop session,
+// like GNOME Keyring or KWallet, through the secret-tool of libsecret.
+type secretService struct{}
+
+func system() Keyring {
+	// The Secret Service is only reachable from a desktop session
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil
+	}
+	return secretService{}
+}
+
+func (secretService) Get(service, account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	switch {
+	case err != nil && len(out) == 0:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 {
+			return "", ErrNotFound
+		}
+		return "", err
+	case err != nil:
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (secretService) Set(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", fmt.Sprintf("%s (%s)", service, account),
+		"service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (secretService) Delete(service, account string) error {
+	return exec.Command("secret-tool", "clear", "service", service, "account", account).Run()
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package keyring
+
+func system() Keyring {
+	return nil
+}
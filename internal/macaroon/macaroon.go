@@ -0,0 +1,318 @@
+// Package macaroon decodes Fly.io macaroon tokens to show what they grant,
+// without verifying them.
+package macaroon
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Locations of the macaroons of a token.
+const (
+	// LocationPermission is the location of permission macaroons, granting
+	// access to resources.
+	LocationPermission = "https://api.fly.io/v1"
+	// LocationAuthentication is the location of discharge macaroons, proving
+	// who uses a permission macaroon.
+	LocationAuthentication = "https://api.fly.io/aaa/v1"
+)
+
+// Caveat types of Fly.io macaroons.
+const (
+	CaveatOrganization        = 0
+	CaveatVolumes             = 1
+	CaveatApps                = 2
+	CaveatFeatureSet          = 3
+	CaveatValidityWindow      = 4
+	CaveatMutations           = 5
+	CaveatMachines            = 6
+	CaveatConfineUser         = 7
+	CaveatConfineOrganization = 8
+	CaveatIsUser              = 9
+	CaveatThirdParty          = 10
+	CaveatBindToParentToken   = 11
+	CaveatIfPresent           = 12
+)
+
+var caveatNames = map[uint64]string{
+	CaveatOrganization:        "organization",
+	CaveatVolumes:             "volumes",
+	CaveatApps:                "apps",
+	CaveatFeatureSet:          "feature set",
+	CaveatValidityWindow:      "validity window",
+	CaveatMutations:           "mutations",
+	CaveatMachines:            "machines",
+	CaveatConfineUser:         "confine user",
+	CaveatConfineOrganization: "confine organization",
+	CaveatIsUser:              "is user",
+	CaveatThirdParty:          "third party",
+	CaveatBindToParentToken:   "bind to parent token",
+	CaveatIfPresent:           "if present",
+}
+
+// Actions, as a bit mask.
+const (
+	ActionRead    = 1 << 0
+	ActionWrite   = 1 << 1
+	ActionCreate  = 1 << 2
+	ActionDelete  = 1 << 3
+	ActionControl = 1 << 4
+)
+
+// ActionString returns the names of the actions of mask.
+func ActionString(mask uint64) string {
+	var names []string
+	for i, name := range []string{"read", "write", "create", "delete", "control"} {
+		if mask&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// Macaroon is a decoded macaroon.
+type Macaroon struct {
+	Location string   `json:"location"`
+	Caveats  []Caveat `json:"caveats"`
+}
+
+// Caveat is a caveat of a macaroon, attenuating what it grants.
+type Caveat struct {
+	Type uint64 `json:"type"`
+	Name string `json:"name"`
+	// Body holds the fields of the caveat.
+	Body []any `json:"-"`
+}
+
+// String describes c.
+func (c Caveat) String() string {
+	field := func(i int) any {
+		if i < len(c.Body) {
+			return c.Body[i]
+		}
+		return nil
+	}
+
+	switch c.Type {
+	case CaveatOrganization:
+		mask, _ := toUint(field(1))
+		return fmt.Sprintf("organization %v: %s", field(0), ActionString(mask))
+	case CaveatApps:
+		apps, _ := field(0).(map[any]any)
+		var parts []string
+		for id, mask := range apps {
+			m, _ := toUint(mask)
+			parts = append(parts, fmt.Sprintf("%v: %s", id, ActionString(m)))
+		}
+		sort.Strings(parts)
+		return "apps " + strings.Join(parts, "; ")
+	case CaveatValidityWindow:
+		notBefore, _ := toInt(field(0))
+		notAfter, _ := toInt(field(1))
+		return fmt.Sprintf("valid from %s until %s",
+			time.Unix(notBefore, 0).UTC().Format(time.RFC3339), time.Unix(notAfter, 0).UTC().Format(time.RFC3339))
+	case CaveatThirdParty:
+		return fmt.Sprintf("third party discharge from %v", field(0))
+	case CaveatBindToParentToken:
+		return "bound to its permission token"
+	}
+
+	name := c.Name
+	if name == "" {
+		name = fmt.Sprintf("caveat %d", c.Type)
+	}
+	return fmt.Sprintf("%s %v", name, c.Body)
+}
+
+// IsMacaroon reports whether token is a macaroon token.
+func IsMacaroon(token string) bool {
+	token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(token), "FlyV1 "))
+	return strings.HasPrefix(token, "fm1r_") || strings.HasPrefix(token, "fm1a_") || strings.HasPrefix(token, "fm2_")
+}
+
+// Parse decodes the macaroons of token: a permission macaroon, followed by the
+// macaroons discharging its third party caveats.
+func Parse(token string) ([]*Macaroon, error) {
+	token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(token), "FlyV1 "))
+	if !IsMacaroon(token) {
+		return nil, errors.New("not a macaroon token")
+	}
+
+	var macaroons []*Macaroon
+	for _, part := range strings.Split(token, ",") {
+		_, encoded, ok := strings.Cut(strings.TrimSpace(part), "_")
+		if !ok {
+			return nil, errors.New("malformed macaroon token")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("malformed macaroon token: %w", err)
+		}
+
+		m, err := decodeMacaroon(data)
+		if err != nil {
+			return nil, err
+		}
+		macaroons = append(macaroons, m)
+	}
+
+	return macaroons, nil
+}
+
+// decodeMacaroon decodes a macaroon encoded as the msgpack array of its nonce,
+// location, caveats and signature.
+func decodeMacaroon(data []byte) (*Macaroon, error) {
+	v, err := unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("malformed macaroon: %w", err)
+	}
+
+	fields, ok := v.([]any)
+	if !ok || len(fields) < 3 {
+		return nil, errors.New("malformed macaroon")
+	}
+
+	location, _ := fields[1].(string)
+	caveats, err := decodeCaveats(fields[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Macaroon{Location: location, Caveats: caveats}, nil
+}
+
+// decodeCaveats decodes caveats encoded as an array alternating their types
+// and bodies.
+func decodeCaveats(v any) ([]Caveat, error) {
+	fields, ok := v.([]any)
+	if !ok || len(fields)%2 != 0 {
+		return nil, errors.New("malformed macaroon caveats")
+	}
+
+	caveats := make([]Caveat, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		typ, ok := toUint(fields[i])
+		if !ok {
+			return nil, errors.New("malformed macaroon caveat type")
+		}
+
+		body, ok := fields[i+1].([]any)
+		if !ok {
+			body = []any{fields[i+1]}
+		}
+
+		caveats = append(caveats, Caveat{Type: typ, Name: caveatNames[typ], Body: body})
+	}
+	return caveats, nil
+}
+
+// Expiry returns the earliest end of the validity windows of macaroons, and
+// whether they have one.
+func Expiry(macaroons []*Macaroon) (time.Time, bool) {
+	var expiry time.Time
+	for _, m := range macaroons {
+		for _, c := range m.Caveats {
+			if c.Type != CaveatValidityWindow || len(c.Body) < 2 {
+				continue
+			}
+			notAfter, ok := toInt(c.Body[1])
+			if !ok {
+				continue
+			}
+			if t := time.Unix(notAfter, 0).UTC(); expiry.IsZero() || t.Before(expiry) {
+				expiry = t
+			}
+		}
+	}
+	return expiry, !expiry.IsZero()
+}
+
+// Organizations returns the numeric IDs of the organizations the macaroons
+// grant access to, and the actions allowed on them.
+func Organizations(macaroons []*Macaroon) map[uint64]uint64 {
+	orgs := map[uint64]uint64{}
+	for _, m := range macaroons {
+		for _, c := range m.Caveats {
+			if c.Type != CaveatOrganization || len(c.Body) < 2 {
+				continue
+			}
+			id, ok := toUint(c.Body[0])
+			mask, _ := toUint(c.Body[1])
+			if !ok {
+				continue
+			}
+			if current, seen := orgs[id]; seen {
+				mask &= current
+			}
+			orgs[id] = mask
+		}
+	}
+	return orgs
+}
+
+// Apps returns the numeric IDs of the apps the macaroons restrict access to,
+// and the actions allowed on them. It returns nil when access isn't
+// restricted to apps.
+func Apps(macaroons []*Macaroon) map[uint64]uint64 {
+	var apps map[uint64]uint64
+	for _, m := range macaroons {
+		for _, c := range m.Caveats {
+			if c.Type != CaveatApps || len(c.Body) == 0 {
+				continue
+			}
+			set, _ := c.Body[0].(map[any]any)
+			allowed := map[uint64]uint64{}
+			for k, v := range set {
+				id, ok := toUint(k)
+				mask, _ := toUint(v)
+				if ok {
+					allowed[id] = mask
+				}
+			}
+
+			// Each caveat further restricts the previous ones
+			if apps == nil {
+				apps = allowed
+				continue
+			}
+			for id, mask := range apps {
+				if m, ok := allowed[id]; ok {
+					apps[id] = mask & m
+				} else {
+					delete(apps, id)
+				}
+			}
+		}
+	}
+	return apps
+}
+
+func toUint(v any) (uint64, bool) {
+	switch n := v.(type) {
+	case uint64:
+		return n, true
+	case int64:
+		return uint64(n), n >= 0
+	default:
+		return 0, false
+	}
+}
+
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,105 @@
+package macaroon
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encode encodes v as msgpack, supporting the types macaroons use.
+func encode(v any) []byte {
+	switch v := v.(type) {
+	case int:
+		if v >= 0 && v <= 0x7f {
+			return []byte{byte(v)}
+		}
+		b := make([]byte, 9)
+		b[0] = 0xd3
+		binary.BigEndian.PutUint64(b[1:], uint64(v))
+		return b
+	case bool:
+		if v {
+			return []byte{0xc3}
+		}
+		return []byte{0xc2}
+	case string:
+		return append([]byte{0xd9, byte(len(v))}, v...)
+	case []byte:
+		return append([]byte{0xc4, byte(len(v))}, v...)
+	case []any:
+		b := []byte{0xdc, 0, byte(len(v))}
+		for _, e := range v {
+			b = append(b, encode(e)...)
+		}
+		return b
+	case map[int]int:
+		b := []byte{0x80 | byte(len(v))}
+		for k, e := range v {
+			b = append(b, encode(k)...)
+			b = append(b, encode(e)...)
+		}
+		return b
+	default:
+		panic("unsupported type")
+	}
+}
+
+func token(prefix string, location string, caveats ...any) string {
+	nonce := []any{[]byte("kid"), []byte("rnd"), false}
+	data := encode([]any{nonce, location, caveats, []byte("tail")})
+	return prefix + base64.StdEncoding.EncodeToString(data)
+}
+
+func TestParse(t *testing.T) {
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	permission := token("fm2_", LocationPermission,
+		CaveatOrganization, []any{1234, ActionRead | ActionWrite},
+		CaveatApps, []any{map[int]int{300: ActionRead | ActionWrite, 301: ActionRead}},
+		CaveatValidityWindow, []any{1700000000, int(notAfter.Unix())},
+		CaveatApps, []any{map[int]int{300: ActionRead}},
+		CaveatThirdParty, []any{"https://api.fly.io/aaa/v1", []byte("key"), []byte("ticket")},
+	)
+	discharge := token("fm2_", LocationAuthentication,
+		CaveatValidityWindow, []any{1700000000, int(notAfter.Add(-time.Hour).Unix())},
+	)
+
+	macaroons, err := Parse("FlyV1 " + permission + "," + discharge)
+	require.NoError(t, err)
+	require.Len(t, macaroons, 2)
+
+	assert.Equal(t, LocationPermission, macaroons[0].Location)
+	require.Len(t, macaroons[0].Caveats, 5)
+	assert.Equal(t, "apps", macaroons[0].Caveats[1].Name)
+	assert.Equal(t, "organization 1234: read,write", macaroons[0].Caveats[0].String())
+	assert.Equal(t, "apps 300: read,write; 301: read", macaroons[0].Caveats[1].String())
+	assert.Equal(t, "third party discharge from https://api.fly.io/aaa/v1", macaroons[0].Caveats[4].String())
+
+	expiry, ok := Expiry(macaroons)
+	assert.True(t, ok)
+	assert.Equal(t, notAfter.Add(-time.Hour), expiry)
+
+	assert.Equal(t, map[uint64]uint64{1234: ActionRead | ActionWrite}, Organizations(macaroons))
+	// App caveats restrict each other
+	assert.Equal(t, map[uint64]uint64{300: ActionRead}, Apps(macaroons))
+}
+
+func TestParseInvalid(t *testing.T) {
+	assert.False(t, IsMacaroon("Bearer abc"))
+	assert.True(t, IsMacaroon("fm1r_abc"))
+
+	_, err := Parse("abc")
+	assert.Error(t, err)
+
+	_, err = Parse("fm2_" + base64.StdEncoding.EncodeToString([]byte{0xdc, 0, 9}))
+	assert.Error(t, err)
+}
+
+func TestActionString(t *testing.T) {
+	assert.Equal(t, "none", ActionString(0))
+	assert.Equal(t, "read,delete,control", ActionString(ActionRead|ActionDelete|ActionControl))
+}
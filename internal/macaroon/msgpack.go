@@ -0,0 +1,187 @@
+package macaroon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var errShort = errors.New("unexpected end of msgpack data")
+
+// decoder decodes msgpack values into nil, bool, int64, uint64, float64,
+// string, []byte, []any and map[any]any.
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || len(d.data) < n {
+		return nil, errShort
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.take(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+func (d *decoder) int(size int) (int64, error) {
+	n, err := d.uint(size)
+	switch size {
+	case 1:
+		return int64(int8(n)), err
+	case 2:
+		return int64(int16(n)), err
+	case 4:
+		return int64(int32(n)), err
+	default:
+		return int64(n), err
+	}
+}
+
+func (d *decoder) length(size int) (int, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)) {
+		return 0, errShort
+	}
+	return int(n), nil
+}
+
+func (d *decoder) decode() (any, error) {
+	b, err := d.take(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.decodeMap(int(c & 0x0f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.decodeArray(int(c & 0x0f))
+	case c >= 0xa0 && c <= 0xbf:
+		s, err := d.take(int(c & 0x1f))
+		return string(s), err
+	}
+
+	switch c := b[0]; c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.take(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.length(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		// Extensions are kept as their raw data, type included
+		return d.take(n + 1)
+	case 0xca:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.uint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return d.int(1 << (c - 0xd0))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.take(1<<(c-0xd4) + 1)
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(n)
+		return string(s), err
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+	default:
+		return nil, fmt.Errorf("invalid msgpack type 0x%x", c)
+	}
+}
+
+func (d *decoder) decodeArray(n int) ([]any, error) {
+	if n > len(d.data) {
+		return nil, errShort
+	}
+	a := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (d *decoder) decodeMap(n int) (map[any]any, error) {
+	if n > len(d.data) {
+		return nil, errShort
+	}
+	m := make(map[any]any, n)
+	for i := 0; i < n; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		switch k.(type) {
+		case []any, map[any]any, []byte:
+			return nil, errors.New("unsupported msgpack map key")
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// unmarshal decodes the msgpack value data holds.
+func unmarshal(data []byte) (any, error) {
+	d := &decoder{data: data}
+	return d.decode()
+}
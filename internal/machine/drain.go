@@ -0,0 +1,86 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// DefaultPreStopTimeout bounds how long a pre-stop hook may run.
+const DefaultPreStopTimeout = 5 * time.Minute
+
+// DrainOptions controls how a machine is brought down ahead of a restart or
+// update so stateful services get a chance to drain.
+type DrainOptions struct {
+	// Signal sent to the machine's main process, e.g. SIGTERM.
+	Signal string
+	// GracePeriod to wait after the signal before the machine is killed.
+	GracePeriod time.Duration
+	// PreStopCommand is executed inside the machine before it is stopped.
+	PreStopCommand string
+}
+
+// stopInput returns the input stopping a machine with the signal and grace
+// period of o, and whether o overrides either.
+func (o DrainOptions) stopInput() (api.StopMachineInput, bool) {
+	input := api.StopMachineInput{
+		Signal:  strings.ToUpper(o.Signal),
+		Timeout: api.Duration{Duration: o.GracePeriod},
+	}
+	return input, o.Signal != "" || o.GracePeriod != 0
+}
+
+// Stop stops lm with the signal and grace period of o ahead of an update, and
+// waits up to timeout for it to stop. The overrides only apply to this stop,
+// the machine's config keeps its own. Machines that aren't started, and
+// options overriding neither, are left alone.
+func (o DrainOptions) Stop(ctx context.Context, lm LeasableMachine, timeout time.Duration, logPrefix string) error {
+	input, ok := o.stopInput()
+	if !ok || lm.Machine().State != api.MachineStateStarted {
+		return nil
+	}
+
+	if err := lm.Stop(ctx, input); err != nil {
+		return fmt.Errorf("failed stopping machine %s: %w", lm.Machine().ID, err)
+	}
+
+	if timeout < o.GracePeriod {
+		timeout = o.GracePeriod + time.Minute
+	}
+	return lm.WaitForState(ctx, api.MachineStateStopped, timeout, logPrefix, false)
+}
+
+// RunPreStopHook executes the pre-stop command of o inside m, failing when the
+// command exits with a non-zero code. Machines that aren't running are skipped.
+func RunPreStopHook(ctx context.Context, m *api.Machine, o DrainOptions) error {
+	if o.PreStopCommand == "" || m.State != api.MachineStateStarted {
+		return nil
+	}
+
+	var (
+		flapsClient = flaps.FromContext(ctx)
+		io          = iostreams.FromContext(ctx)
+		colorize    = io.ColorScheme()
+	)
+
+	fmt.Fprintf(io.ErrOut, "Running pre-stop command on machine %s\n", colorize.Bold(m.ID))
+
+	out, err := flapsClient.Exec(ctx, m.ID, &api.MachineExecRequest{
+		Cmd:     o.PreStopCommand,
+		Timeout: int(DefaultPreStopTimeout.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed running pre-stop command on machine %s: %w", m.ID, err)
+	}
+	if out.ExitCode != 0 {
+		fmt.Fprint(io.ErrOut, out.StdErr)
+		return fmt.Errorf("pre-stop command on machine %s exited with code %d", m.ID, out.ExitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package machine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestDrainOptionsStopInput(t *testing.T) {
+	_, ok := DrainOptions{PreStopCommand: "drain"}.stopInput()
+	assert.False(t, ok)
+
+	input, ok := DrainOptions{GracePeriod: 30 * time.Second}.stopInput()
+	assert.True(t, ok)
+	assert.Equal(t, api.StopMachineInput{Timeout: api.Duration{Duration: 30 * time.Second}}, input)
+
+	input, ok = DrainOptions{Signal: "sigterm"}.stopInput()
+	assert.True(t, ok)
+	assert.Equal(t, "SIGTERM", input.Signal)
+	assert.Zero(t, input.Timeout.Duration)
+}
@@ -0,0 +1,40 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+)
+
+// cudaImageHints are substrings of image references known to ship the CUDA
+// runtime GPU machines need.
+var cudaImageHints = []string{
+	"cuda",
+	"nvidia",
+	"pytorch",
+	"tensorflow",
+	"huggingface",
+	"ollama",
+	"vllm",
+}
+
+// LooksLikeCUDAImage reports whether imageRef appears to be built on a CUDA
+// base image. It's a heuristic based on the image name only.
+func LooksLikeCUDAImage(imageRef string) bool {
+	imageRef = strings.ToLower(imageRef)
+	return lo.SomeBy(cudaImageHints, func(hint string) bool {
+		return strings.Contains(imageRef, hint)
+	})
+}
+
+// ValidateGPURegion returns an error when gpuKind isn't available in region.
+func ValidateGPURegion(gpuKind, region string) error {
+	regions, ok := api.MachineGPUKinds[gpuKind]
+	if !ok || region == "" || lo.Contains(regions, region) {
+		return nil
+	}
+	return fmt.Errorf("gpu kind %s is not available in region %s, choose one of: %v", gpuKind, region, regions)
+}
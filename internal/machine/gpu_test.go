@@ -0,0 +1,21 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLooksLikeCUDAImage(t *testing.T) {
+	assert.True(t, LooksLikeCUDAImage("nvidia/cuda:12.2.0-runtime-ubuntu22.04"))
+	assert.True(t, LooksLikeCUDAImage("pytorch/pytorch:latest"))
+	assert.True(t, LooksLikeCUDAImage("ghcr.io/org/app-CUDA:v1"))
+	assert.False(t, LooksLikeCUDAImage("nginx:latest"))
+	assert.False(t, LooksLikeCUDAImage("registry.fly.io/app:deployment-01H"))
+}
+
+func TestValidateGPURegion(t *testing.T) {
+	assert.NoError(t, ValidateGPURegion("a100-sxm4-80gb", "iad"))
+	assert.NoError(t, ValidateGPURegion("a100-sxm4-80gb", ""))
+	assert.Error(t, ValidateGPURegion("a100-sxm4-80gb", "cdg"))
+}
@@ -0,0 +1,76 @@
+package machine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/cmdutil"
+)
+
+// ValidateLabel returns an error when key can't be a label.
+func ValidateLabel(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("label keys can't be empty")
+	case strings.ContainsAny(key, "=/ "):
+		return fmt.Errorf("invalid label key '%s', it can't contain '=', '/' or spaces", key)
+	case api.IsReservedMetadataKey(key):
+		return fmt.Errorf("'%s' is reserved for machines managed by Fly.io", key)
+	default:
+		return nil
+	}
+}
+
+// Labels returns the labels of m: its metadata, without reserved keys.
+func Labels(m *api.Machine) map[string]string {
+	labels := map[string]string{}
+	if m.Config == nil {
+		return labels
+	}
+	for k, v := range m.Config.Metadata {
+		if !api.IsReservedMetadataKey(k) {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// FormatLabels formats labels as sorted, comma separated key=value pairs.
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ParseSelector parses key=value pairs, each of which may also be a comma
+// separated list of pairs, into the labels machines must have.
+func ParseSelector(pairs []string) (map[string]string, error) {
+	var split []string
+	for _, p := range pairs {
+		split = append(split, strings.Split(p, ",")...)
+	}
+
+	selector, err := cmdutil.ParseKVStringsToMap(split)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %s", err)
+	}
+	return selector, nil
+}
+
+// MatchesSelector reports whether m has all the labels of selector.
+func MatchesSelector(m *api.Machine, selector map[string]string) bool {
+	if m.Config == nil {
+		return len(selector) == 0
+	}
+	for k, v := range selector {
+		if actual, ok := m.Config.Metadata[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+)
+
+func TestLabels(t *testing.T) {
+	m := &api.Machine{Config: &api.MachineConfig{Metadata: map[string]string{
+		"team": "core",
+		"tier": "web",
+		api.MachineConfigMetadataKeyFlyProcessGroup:    "app",
+		api.MachineConfigMetadataKeyManagedByFlyDeploy: "true",
+	}}}
+
+	assert.Equal(t, map[string]string{"team": "core", "tier": "web"}, Labels(m))
+	assert.Equal(t, "team=core,tier=web", FormatLabels(Labels(m)))
+
+	selector, err := ParseSelector([]string{"team=core,tier=web"})
+	require.NoError(t, err)
+	assert.True(t, MatchesSelector(m, selector))
+
+	selector, err = ParseSelector([]string{"team=core", "fly_process_group=worker"})
+	require.NoError(t, err)
+	assert.False(t, MatchesSelector(m, selector))
+
+	_, err = ParseSelector([]string{"team"})
+	assert.Error(t, err)
+
+	assert.NoError(t, ValidateLabel("team"))
+	assert.Error(t, ValidateLabel("fly_process_group"))
+	assert.Error(t, ValidateLabel("managed-by-fly-deploy"))
+	assert.Error(t, ValidateLabel("a=b"))
+}
@@ -25,6 +25,7 @@ type LeasableMachine interface {
 	StartBackgroundLeaseRefresh(context.Context, time.Duration, time.Duration)
 	Update(context.Context, api.LaunchMachineInput) error
 	Start(context.Context) error
+	Stop(context.Context, api.StopMachineInput) error
 	Destroy(context.Context, bool) error
 	WaitForState(context.Context, string, time.Duration, string, bool) error
 	WaitForSmokeChecksToPass(context.Context, string) error
@@ -86,6 +87,15 @@ func (lm *leasableMachine) Destroy(ctx context.Context, kill bool) error {
 	return nil
 }
 
+// Stop stops the machine with input, under its lease.
+func (lm *leasableMachine) Stop(ctx context.Context, input api.StopMachineInput) error {
+	if lm.IsDestroyed() {
+		return fmt.Errorf("error cannot stop machine %s that was already destroyed", lm.machine.ID)
+	}
+	input.ID = lm.machine.ID
+	return lm.flapsClient.Stop(ctx, input, lm.leaseNonce)
+}
+
 func (lm *leasableMachine) FormattedMachineId() string {
 	res := lm.Machine().ID
 	if lm.Machine().Config.Metadata == nil {
@@ -17,7 +17,8 @@ func ListActive(ctx context.Context) ([]*api.Machine, error) {
 	}
 
 	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
-		return m.Config != nil && m.IsActive() && !m.IsReleaseCommandMachine() && !m.IsFlyAppsConsole()
+		return m.Config != nil && m.IsActive() && !m.IsReleaseCommandMachine() && !m.IsFlyAppsConsole() &&
+			!m.IsFlyAppsMaintenance() && !m.IsFlyAppsTrafficProxy() && !m.IsFlyAppsMirrorCandidate()
 	})
 
 	return machines, nil
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/samber/lo"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/watch"
 	"github.com/superfly/flyctl/iostreams"
+	"golang.org/x/sync/errgroup"
 )
 
 func RollingRestart(ctx context.Context, input *api.RestartMachineInput) error {
@@ -25,6 +27,47 @@ func RollingRestart(ctx context.Context, input *api.RestartMachineInput) error {
 	return nil
 }
 
+// RollingRestartOptions controls a rolling restart of a set of machines.
+type RollingRestartOptions struct {
+	Input *api.RestartMachineInput
+	Drain DrainOptions
+	// MaxUnavailable is the number of machines restarted at once.
+	MaxUnavailable int
+}
+
+// RollingRestartMachines restarts machines in batches of at most
+// opts.MaxUnavailable, waiting for every machine in a batch to start and pass
+// its health checks before moving on to the next batch.
+func RollingRestartMachines(ctx context.Context, machines []*api.Machine, opts RollingRestartOptions) error {
+	batchSize := opts.MaxUnavailable
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	for _, batch := range lo.Chunk(machines, batchSize) {
+		eg, egCtx := errgroup.WithContext(ctx)
+
+		for _, m := range batch {
+			m := m
+			// Restart sets the machine ID on its input, so each one gets a copy
+			input := *opts.Input
+
+			eg.Go(func() error {
+				if err := RunPreStopHook(egCtx, m, opts.Drain); err != nil {
+					return err
+				}
+				return Restart(egCtx, m, &input, m.LeaseNonce)
+			})
+		}
+
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func Restart(ctx context.Context, m *api.Machine, input *api.RestartMachineInput, nonce string) error {
 	var (
 		flapsClient = flaps.FromContext(ctx)
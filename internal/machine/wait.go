@@ -13,22 +13,100 @@ import (
 	"github.com/superfly/flyctl/flaps"
 )
 
-func WaitForStartOrStop(ctx context.Context, machine *api.Machine, action string, timeout time.Duration) error {
-	var flapsClient = flaps.FromContext(ctx)
+// States a machine may be awaited in.
+const (
+	StateStarted       = "started"
+	StateStopped       = "stopped"
+	StateDestroyed     = "destroyed"
+	StateChecksPassing = "checks-passing"
+)
 
+// AwaitStates returns the states a machine may be awaited in.
+func AwaitStates() []string {
+	return []string{StateStarted, StateStopped, StateDestroyed, StateChecksPassing}
+}
+
+func WaitForStartOrStop(ctx context.Context, machine *api.Machine, action string, timeout time.Duration) error {
 	waitCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	var waitOnAction string
 	switch action {
 	case "start":
-		waitOnAction = "started"
+		waitOnAction = StateStarted
 	case "stop":
-		waitOnAction = "stopped"
+		waitOnAction = StateStopped
 	default:
 		return fmt.Errorf("action must be either start or stop")
 	}
 
+	return waitForState(waitCtx, machine, waitOnAction)
+}
+
+// Await blocks until machine reaches state, one of AwaitStates, or timeout
+// elapses. It returns the machine as it was last seen.
+func Await(ctx context.Context, machine *api.Machine, state string, timeout time.Duration) (*api.Machine, error) {
+	flapsClient := flaps.FromContext(ctx)
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	waitOn := state
+	switch state {
+	case StateStarted, StateStopped, StateDestroyed:
+		break
+	case StateChecksPassing:
+		waitOn = StateStarted
+	default:
+		return machine, fmt.Errorf("unknown machine state %s, must be one of %s", state, strings.Join(AwaitStates(), ", "))
+	}
+
+	if err := waitForState(waitCtx, machine, waitOn); err != nil {
+		return machine, err
+	}
+
+	if state != StateChecksPassing {
+		updated, err := flapsClient.Get(ctx, machine.ID)
+		if err != nil {
+			if state == StateDestroyed {
+				destroyed := *machine
+				destroyed.State = StateDestroyed
+				return &destroyed, nil
+			}
+			return machine, fmt.Errorf("error getting machine %s from api: %w", machine.ID, err)
+		}
+		return updated, nil
+	}
+
+	b := &backoff.Backoff{
+		Min:    time.Second,
+		Max:    5 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+	for {
+		updated, err := flapsClient.Get(waitCtx, machine.ID)
+		switch {
+		case errors.Is(waitCtx.Err(), context.Canceled):
+			return machine, err
+		case errors.Is(waitCtx.Err(), context.DeadlineExceeded):
+			return machine, fmt.Errorf("timeout reached waiting for health checks of machine %s to pass", machine.ID)
+		case err != nil:
+			return machine, fmt.Errorf("error getting machine %s from api: %w", machine.ID, err)
+		}
+
+		machine = updated
+		if machine.AllHealthChecks().AllPassing() {
+			return machine, nil
+		}
+		time.Sleep(b.Duration())
+	}
+}
+
+// waitForState waits until machine reaches state, until waitCtx is done.
+func waitForState(waitCtx context.Context, machine *api.Machine, state string) error {
+	flapsClient := flaps.FromContext(waitCtx)
+
 	b := &backoff.Backoff{
 		Min:    500 * time.Millisecond,
 		Max:    2 * time.Second,
@@ -36,7 +114,7 @@ func WaitForStartOrStop(ctx context.Context, machine *api.Machine, action string
 		Jitter: false,
 	}
 	for {
-		err := flapsClient.Wait(waitCtx, machine, waitOnAction, 60*time.Second)
+		err := flapsClient.Wait(waitCtx, machine, state, 60*time.Second)
 		if err == nil {
 			return nil
 		}
@@ -45,11 +123,11 @@ func WaitForStartOrStop(ctx context.Context, machine *api.Machine, action string
 		case errors.Is(waitCtx.Err(), context.Canceled):
 			return err
 		case errors.Is(waitCtx.Err(), context.DeadlineExceeded):
-			return fmt.Errorf("timeout reached waiting for machine to %s %w", waitOnAction, err)
+			return fmt.Errorf("timeout reached waiting for machine to %s %w", state, err)
 		default:
 			var flapsErr *flaps.FlapsError
-			if strings.Contains(err.Error(), "machine failed to reach desired state") && machine.Config.Restart.Policy == api.MachineRestartPolicyNo {
-				return fmt.Errorf("machine failed to reach desired start state, and restart policy was set to %s restart", machine.Config.Restart.Policy)
+			if strings.Contains(err.Error(), "machine failed to reach desired state") && machine.Config != nil && machine.Config.Restart.Policy == api.MachineRestartPolicyNo {
+				return fmt.Errorf("machine failed to reach desired %s state, and restart policy was set to %s restart", state, machine.Config.Restart.Policy)
 			}
 			if errors.As(err, &flapsErr) && flapsErr.ResponseStatusCode == http.StatusBadRequest {
 				return fmt.Errorf("failed waiting for machine: %w", err)
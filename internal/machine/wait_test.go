@@ -0,0 +1,59 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+func TestAwaitChecksPassing(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/wait"):
+			assert.Equal(t, StateStarted, r.URL.Query().Get("state"))
+		case strings.HasSuffix(r.URL.Path, "/m1"):
+			status := api.Critical
+			if gets.Add(1) > 1 {
+				status = api.Passing
+			}
+			json.NewEncoder(w).Encode(api.Machine{
+				ID:     "m1",
+				State:  StateStarted,
+				Checks: []*api.MachineCheckStatus{{Name: "http", Status: status}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("FLY_FLAPS_BASE_URL", server.URL)
+	ctx := config.NewContext(context.Background(), config.New())
+	ctx = logger.NewContext(ctx, logger.FromEnv(io.Discard))
+	flapsClient, err := flaps.NewFromAppName(ctx, "app")
+	require.NoError(t, err)
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	machine, err := Await(ctx, &api.Machine{ID: "m1"}, StateChecksPassing, 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, machine.AllHealthChecks().AllPassing())
+	assert.Equal(t, int32(2), gets.Load())
+
+	_, err = Await(ctx, &api.Machine{ID: "m1"}, "sleeping", time.Second)
+	assert.ErrorContains(t, err, "unknown machine state")
+}
@@ -0,0 +1,248 @@
+// Package notify posts deploy results and alerts to Slack and Discord
+// channels, configured per app or organization.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// Kinds of channels.
+const (
+	KindSlack   = "slack"
+	KindDiscord = "discord"
+)
+
+// Events posted to channels.
+const (
+	EventDeploySucceeded  = "deploy.succeeded"
+	EventDeployFailed     = "deploy.failed"
+	EventDeployRolledBack = "deploy.rolled_back"
+	EventAlert            = "alert"
+	EventTest             = "test"
+)
+
+// Kinds are the kinds of channels.
+var Kinds = []string{KindSlack, KindDiscord}
+
+// Events are the events channels subscribe to.
+var Events = []string{EventDeploySucceeded, EventDeployFailed, EventDeployRolledBack, EventAlert}
+
+// Channel is a Slack or Discord channel notified of the events of an app, or
+// of all the apps of an organization.
+type Channel struct {
+	Name       string `toml:"name" json:"name"`
+	Kind       string `toml:"kind" json:"kind"`
+	WebhookURL string `toml:"webhook_url" json:"webhook_url"`
+	// Org or App is the scope of the channel.
+	Org string `toml:"org,omitempty" json:"org,omitempty"`
+	App string `toml:"app,omitempty" json:"app,omitempty"`
+	// Events are the events posted to the channel, all of them when empty.
+	Events []string `toml:"events,omitempty" json:"events,omitempty"`
+}
+
+// Subscribes reports whether event is posted to c.
+func (c Channel) Subscribes(event string) bool {
+	return event == EventTest || len(c.Events) == 0 || slices.Contains(c.Events, event)
+}
+
+// Scope returns the app or organization of c.
+func (c Channel) Scope() string {
+	if c.App != "" {
+		return "app " + c.App
+	}
+	return "org " + c.Org
+}
+
+// Validate checks c is complete.
+func (c Channel) Validate() error {
+	if !slices.Contains(Kinds, c.Kind) {
+		return fmt.Errorf("unknown channel kind %s, expected slack or discord", c.Kind)
+	}
+	if c.WebhookURL == "" {
+		return errors.New("--webhook-url is required")
+	}
+	if parsed, err := url.Parse(c.WebhookURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%s is not an absolute https url", c.WebhookURL)
+	}
+	if (c.App == "") == (c.Org == "") {
+		return errors.New("a channel is notified of the events of either an app or an organization")
+	}
+	for _, event := range c.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("unknown event %s", event)
+		}
+	}
+	return nil
+}
+
+type file struct {
+	Channels []Channel `toml:"channels"`
+}
+
+// Path returns the path of the file configuring channels.
+func Path(ctx context.Context) string {
+	return filepath.Join(state.ConfigDirectory(ctx), "notifications.toml")
+}
+
+// Load returns the configured channels.
+func Load(ctx context.Context) ([]Channel, error) {
+	var f file
+	if _, err := toml.DecodeFile(Path(ctx), &f); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed reading notification channels: %w", err)
+	}
+	return f.Channels, nil
+}
+
+// Save replaces the configured channels.
+func Save(ctx context.Context, channels []Channel) error {
+	path := Path(ctx)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// webhook URLs are credentials
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return toml.NewEncoder(f).Encode(file{Channels: channels})
+}
+
+// Matching returns the channels of the app or its organization subscribing to
+// event.
+func Matching(channels []Channel, orgSlug, appName, event string) []Channel {
+	var matching []Channel
+	for _, c := range channels {
+		inScope := (c.App != "" && c.App == appName) || (c.Org != "" && c.Org == orgSlug)
+		if inScope && c.Subscribes(event) {
+			matching = append(matching, c)
+		}
+	}
+	return matching
+}
+
+// Message is a notification of an event of an app.
+type Message struct {
+	Event string `json:"event"`
+	App   string `json:"app"`
+	Title string `json:"title"`
+	Text  string `json:"text,omitempty"`
+	URL   string `json:"url,omitempty"`
+	// Resolved marks alerts which stopped firing.
+	Resolved bool `json:"resolved,omitempty"`
+}
+
+func (m Message) emoji() string {
+	switch {
+	case m.Event == EventDeploySucceeded, m.Resolved:
+		return ":white_check_mark:"
+	case m.Event == EventDeployFailed, m.Event == EventAlert:
+		return ":red_circle:"
+	case m.Event == EventDeployRolledBack:
+		return ":rewind:"
+	default:
+		return ":bell:"
+	}
+}
+
+func (m Message) color() int {
+	switch {
+	case m.Event == EventDeploySucceeded, m.Resolved:
+		return 0x2eb67d
+	case m.Event == EventDeployFailed, m.Event == EventAlert:
+		return 0xe01e5a
+	case m.Event == EventDeployRolledBack:
+		return 0xecb22e
+	default:
+		return 0x7b3be2
+	}
+}
+
+// payload returns the body posted to the incoming webhook of c.
+func (c Channel) payload(m Message) any {
+	if c.Kind == KindDiscord {
+		return map[string]any{
+			"embeds": []map[string]any{{
+				"title":       m.Title,
+				"description": m.Text,
+				"url":         m.URL,
+				"color":       m.color(),
+			}},
+		}
+	}
+
+	text := fmt.Sprintf("%s *%s*", m.emoji(), m.Title)
+	if m.URL != "" {
+		text = fmt.Sprintf("%s *<%s|%s>*", m.emoji(), m.URL, m.Title)
+	}
+	if m.Text != "" {
+		text += "\n" + m.Text
+	}
+	return map[string]string{"text": text}
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Post posts m to c.
+func Post(ctx context.Context, c Channel, m Message) error {
+	body, err := json.Marshal(c.payload(m))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with %s", c.Kind, resp.Status)
+	}
+	return nil
+}
+
+// Notify posts m to the channels of the app or its organization subscribing
+// to its event. Failures are printed as warnings, as notifications never fail
+// the commands sending them.
+func Notify(ctx context.Context, orgSlug string, m Message) {
+	errOut := iostreams.FromContext(ctx).ErrOut
+
+	channels, err := Load(ctx)
+	if err != nil {
+		fmt.Fprintf(errOut, "Warning: %v\n", err)
+		return
+	}
+
+	for _, c := range Matching(channels, orgSlug, m.App, m.Event) {
+		if err := Post(ctx, c, m); err != nil {
+			fmt.Fprintf(errOut, "Warning: failed notifying %s: %v\n", c.Name, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/state"
+)
+
+func TestMatching(t *testing.T) {
+	channels := []Channel{
+		{Name: "app", App: "my-app"},
+		{Name: "org", Org: "my-org", Events: []string{EventDeployFailed}},
+		{Name: "other", App: "other-app"},
+	}
+
+	names := func(cs []Channel) []string {
+		var names []string
+		for _, c := range cs {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"app", "org"}, names(Matching(channels, "my-org", "my-app", EventDeployFailed)))
+	assert.Equal(t, []string{"app"}, names(Matching(channels, "my-org", "my-app", EventDeploySucceeded)))
+	assert.Equal(t, []string{"org"}, names(Matching(channels, "my-org", "another-app", EventTest)))
+	assert.Empty(t, Matching(channels, "other-org", "another-app", EventAlert))
+}
+
+func TestValidate(t *testing.T) {
+	valid := Channel{Kind: KindSlack, WebhookURL: "https://hooks.slack.com/services/x", App: "my-app"}
+	assert.NoError(t, valid.Validate())
+
+	c := valid
+	c.Kind = "teams"
+	assert.ErrorContains(t, c.Validate(), "unknown channel kind teams")
+
+	c = valid
+	c.WebhookURL = "http://hooks.slack.com/services/x"
+	assert.ErrorContains(t, c.Validate(), "https")
+
+	c = valid
+	c.Org = "my-org"
+	assert.ErrorContains(t, c.Validate(), "either an app or an organization")
+
+	c = valid
+	c.Events = []string{"deploy.exploded"}
+	assert.ErrorContains(t, c.Validate(), "unknown event deploy.exploded")
+}
+
+func TestPost(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	m := Message{Event: EventDeployFailed, App: "my-app", Title: "Deploy failed", Text: "health checks failed", URL: "https://fly.io"}
+
+	require.NoError(t, Post(context.Background(), Channel{Kind: KindSlack, WebhookURL: server.URL}, m))
+	assert.Equal(t, ":red_circle: *<https://fly.io|Deploy failed>*\nhealth checks failed", payload["text"])
+
+	require.NoError(t, Post(context.Background(), Channel{Kind: KindDiscord, WebhookURL: server.URL}, m))
+	embeds := payload["embeds"].([]any)
+	require.Len(t, embeds, 1)
+	embed := embeds[0].(map[string]any)
+	assert.Equal(t, "Deploy failed", embed["title"])
+	assert.Equal(t, "health checks failed", embed["description"])
+	assert.EqualValues(t, 0xe01e5a, embed["color"])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+
+	assert.ErrorContains(t, Post(context.Background(), Channel{Kind: KindSlack, WebhookURL: failing.URL}, m), "slack responded with 403")
+}
+
+func TestLoadSave(t *testing.T) {
+	ctx := state.WithConfigDirectory(context.Background(), t.TempDir())
+
+	channels, err := Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+
+	saved := []Channel{{Name: "alerts", Kind: KindDiscord, WebhookURL: "https://discord.com/api/webhooks/x", Org: "my-org", Events: []string{EventAlert}}}
+	require.NoError(t, Save(ctx, saved))
+
+	info, err := os.Stat(Path(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	channels, err = Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, saved, channels)
+}
@@ -0,0 +1,264 @@
+// Package offlinequeue queues idempotent mutations locally when the API is
+// unreachable, for fly queue flush to replay them later.
+package offlinequeue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/filemu"
+	"github.com/superfly/flyctl/internal/keyring"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// Kinds of mutations that may be queued.
+const (
+	KindSecretsSet = "secrets_set"
+	KindScaleCount = "scale_count"
+)
+
+// fileName is the name of the queue file in the configuration directory.
+// Secret values are kept out of it, in the keyring.
+const fileName = "queue.json"
+
+// keyringService is the keyring service the secret values of queued
+// mutations are stored under, by mutation ID.
+const keyringService = "fly.io/queue"
+
+// systemKeyring keeps the secret values of queued mutations. Mutations with
+// secret values aren't queued when it's nil.
+var systemKeyring = keyring.System()
+
+// ErrNoKeyring is returned when queueing secret values without a keyring to
+// keep them in.
+var ErrNoKeyring = errors.New("no keyring to keep the secret values in until the mutation is applied")
+
+// ErrConflict is wrapped by the errors of mutations whose target changed
+// remotely since they were queued.
+var ErrConflict = errors.New("changed remotely since it was queued")
+
+// Mutation is a queued mutation.
+type Mutation struct {
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"`
+	App      string          `json:"app"`
+	Summary  string          `json:"summary"`
+	Params   json.RawMessage `json:"params"`
+	QueuedAt time.Time       `json:"queued_at"`
+	// HasSecrets records the mutation's secret values are in the keyring.
+	HasSecrets bool `json:"has_secrets,omitempty"`
+}
+
+// Conflict returns an error wrapping ErrConflict.
+func Conflict(format string, a ...any) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, a...), ErrConflict)
+}
+
+// Enabled reports whether the user opted into queueing mutations.
+func Enabled(ctx context.Context) bool {
+	return config.FromContext(ctx).OfflineQueue
+}
+
+// IsOffline reports whether err means the API couldn't be reached.
+func IsOffline(err error) bool {
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+		urlErr *url.Error
+	)
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &dnsErr):
+		return true
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return true
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ECONNREFUSED):
+		return true
+	case errors.As(err, &urlErr) && urlErr.Timeout():
+		return true
+	default:
+		return false
+	}
+}
+
+// ShouldQueue reports whether a mutation that failed with err should be
+// queued.
+func ShouldQueue(ctx context.Context, err error) bool {
+	return Enabled(ctx) && IsOffline(err)
+}
+
+func path(ctx context.Context) string {
+	return filepath.Join(state.ConfigDirectory(ctx), fileName)
+}
+
+// Enqueue queues a mutation of kind to app with params, and tells the user.
+func Enqueue(ctx context.Context, kind, app, summary string, params any) error {
+	return EnqueueWithSecrets(ctx, kind, app, summary, params, nil)
+}
+
+// EnqueueWithSecrets queues a mutation like Enqueue, keeping secrets in the
+// keyring rather than the queue file. It fails with ErrNoKeyring when there's
+// no keyring. Replays read secrets back with Secrets.
+func EnqueueWithSecrets(ctx context.Context, kind, app, summary string, params any, secrets map[string]string) error {
+	if len(secrets) > 0 && systemKeyring == nil {
+		return fmt.Errorf("can't queue %s: %w", summary, ErrNoKeyring)
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	id, err := helpers.RandString(8)
+	if err != nil {
+		return err
+	}
+
+	m := Mutation{
+		ID:         strings.ToLower(id),
+		Kind:       kind,
+		App:        app,
+		Summary:    summary,
+		Params:     data,
+		QueuedAt:   time.Now().UTC(),
+		HasSecrets: len(secrets) > 0,
+	}
+
+	if m.HasSecrets {
+		data, err := json.Marshal(secrets)
+		if err != nil {
+			return err
+		}
+		if err := systemKeyring.Set(keyringService, m.ID, string(data)); err != nil {
+			return fmt.Errorf("can't queue %s, failed storing its secret values in the keyring: %w", summary, err)
+		}
+	}
+
+	if err := Update(ctx, func(queue []Mutation) []Mutation { return append(queue, m) }); err != nil {
+		if m.HasSecrets {
+			_ = systemKeyring.Delete(keyringService, m.ID)
+		}
+		return fmt.Errorf("failed queueing %s: %w", summary, err)
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.ErrOut, "The Fly.io API is unreachable, queued %s as %s\n", summary, m.ID)
+	fmt.Fprintf(io.ErrOut, "Run fly queue flush once you're back online to apply it\n")
+	return nil
+}
+
+// Secrets returns the secret values of m from the keyring.
+func Secrets(m Mutation) (map[string]string, error) {
+	if !m.HasSecrets {
+		return nil, nil
+	}
+	if systemKeyring == nil {
+		return nil, ErrNoKeyring
+	}
+
+	data, err := systemKeyring.Get(keyringService, m.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the secret values of %s from the keyring: %w", m.ID, err)
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal([]byte(data), &secrets); err != nil {
+		return nil, fmt.Errorf("failed decoding the secret values of %s: %w", m.ID, err)
+	}
+	return secrets, nil
+}
+
+// Load returns the queued mutations, oldest first.
+func Load(ctx context.Context) ([]Mutation, error) {
+	return load(path(ctx))
+}
+
+func load(path string) ([]Mutation, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var queue []Mutation
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("failed decoding %s: %w", path, err)
+	}
+	return queue, nil
+}
+
+// Update replaces the queued mutations with those fn returns, holding a lock
+// on the queue meanwhile.
+func Update(ctx context.Context, fn func([]Mutation) []Mutation) error {
+	p := path(ctx)
+
+	unlock, err := filemu.Lock(ctx, p+".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	queue, err := load(p)
+	if err != nil {
+		return err
+	}
+
+	queue = fn(queue)
+	if len(queue) == 0 {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o600)
+}
+
+// Remove removes the mutation with id from the queue, along with its secret
+// values.
+func Remove(ctx context.Context, id string) error {
+	var removed []Mutation
+	err := Update(ctx, func(queue []Mutation) []Mutation {
+		kept := queue[:0]
+		for _, m := range queue {
+			if m.ID != id {
+				kept = append(kept, m)
+			} else {
+				removed = append(removed, m)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range removed {
+		if !m.HasSecrets || systemKeyring == nil {
+			continue
+		}
+		if err := systemKeyring.Delete(keyringService, m.ID); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("failed deleting the secret values of %s from the keyring: %w", m.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package offlinequeue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/keyring"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestIsOffline(t *testing.T) {
+	assert.False(t, IsOffline(nil))
+	assert.False(t, IsOffline(errors.New("app not found")))
+	assert.True(t, IsOffline(fmt.Errorf("query: %w", &net.DNSError{Err: "no such host", Name: "api.fly.io"})))
+	assert.True(t, IsOffline(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}))
+	assert.True(t, IsOffline(fmt.Errorf("post: %w", syscall.ENETUNREACH)))
+	assert.False(t, IsOffline(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("reset")}))
+}
+
+func TestUpdateAndRemove(t *testing.T) {
+	ctx := state.WithConfigDirectory(context.Background(), t.TempDir())
+
+	queue, err := Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+
+	for _, id := range []string{"a", "b", "c"} {
+		m := Mutation{ID: id, Kind: KindScaleCount, App: "app"}
+		require.NoError(t, Update(ctx, func(queue []Mutation) []Mutation { return append(queue, m) }))
+	}
+
+	require.NoError(t, Remove(ctx, "b"))
+
+	queue, err = Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, []string{queue[0].ID, queue[1].ID})
+
+	require.NoError(t, Remove(ctx, "a"))
+	require.NoError(t, Remove(ctx, "c"))
+
+	queue, err = Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+	assert.True(t, errors.Is(Conflict("secret %s", "FOO"), ErrConflict))
+}
+
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(service, account string) (string, error) {
+	secret, ok := k[service+"/"+account]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k memoryKeyring) Set(service, account, secret string) error {
+	k[service+"/"+account] = secret
+	return nil
+}
+
+func (k memoryKeyring) Delete(service, account string) error {
+	delete(k, service+"/"+account)
+	return nil
+}
+
+func TestEnqueueWithSecrets(t *testing.T) {
+	dir := t.TempDir()
+	ctx := state.WithConfigDirectory(context.Background(), dir)
+	io, _, _, _ := iostreams.Test()
+	ctx = iostreams.NewContext(ctx, io)
+
+	defer func(k keyring.Keyring) { systemKeyring = k }(systemKeyring)
+
+	systemKeyring = nil
+	err := EnqueueWithSecrets(ctx, KindSecretsSet, "app", "secrets set TOKEN -a app", nil, map[string]string{"TOKEN": "hunter2"})
+	assert.ErrorIs(t, err, ErrNoKeyring)
+
+	kr := memoryKeyring{}
+	systemKeyring = kr
+	require.NoError(t, EnqueueWithSecrets(ctx, KindSecretsSet, "app", "secrets set TOKEN -a app", nil, map[string]string{"TOKEN": "hunter2"}))
+
+	data, err := os.ReadFile(filepath.Join(dir, fileName))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+
+	queue, err := Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.True(t, queue[0].HasSecrets)
+
+	secrets, err := Secrets(queue[0])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TOKEN": "hunter2"}, secrets)
+
+	require.NoError(t, Remove(ctx, queue[0].ID))
+	assert.Empty(t, kr)
+}
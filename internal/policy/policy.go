@@ -0,0 +1,268 @@
+// Package policy implements local organization policies, rules flyctl checks
+// before commands add or change an organization's machines. Policies are kept
+// in flyctl's configuration directory: they are a safeguard of this flyctl,
+// not enforced by the platform, so the API, the dashboard and other flyctl
+// installs bypass them.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// shipperAppRole is the role of log shipper apps, see fly logs ship.
+const shipperAppRole = "log-shipper"
+
+// Policy holds the rules of an organization. Zero values disable a rule.
+type Policy struct {
+	// AllowedRegions lists the regions machines may run in.
+	AllowedRegions []string `toml:"allowed_regions,omitempty" json:"allowed_regions,omitempty"`
+	// MaxCPUs is the largest number of CPUs of a machine.
+	MaxCPUs int `toml:"max_cpus,omitempty" json:"max_cpus,omitempty"`
+	// MaxMemoryMB is the largest amount of memory of a machine.
+	MaxMemoryMB int `toml:"max_memory_mb,omitempty" json:"max_memory_mb,omitempty"`
+	// AllowPerformanceCPUs permits performance CPU kinds, shared-cpu sizes
+	// only otherwise.
+	AllowPerformanceCPUs *bool `toml:"allow_performance_cpus,omitempty" json:"allow_performance_cpus,omitempty"`
+	// RequiredMetadata lists the metadata keys every machine must set.
+	RequiredMetadata []string `toml:"required_metadata,omitempty" json:"required_metadata,omitempty"`
+	// RequireLogShipping requires the organization to run a log shipper.
+	RequireLogShipping bool `toml:"require_log_shipping,omitempty" json:"require_log_shipping,omitempty"`
+	// Enforce fails commands violating the policy, which only print
+	// warnings otherwise.
+	Enforce bool `toml:"enforce,omitempty" json:"enforce,omitempty"`
+}
+
+// Plan describes the machines a command is about to create or update.
+type Plan struct {
+	AppName  string
+	Machines []*api.LaunchMachineInput
+}
+
+// Violation is a rule a plan breaks.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Machine string `json:"machine,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Machine != "" {
+		return fmt.Sprintf("%s: machine %s %s", v.Rule, v.Machine, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Path returns the path of the policy file of the organization.
+func Path(ctx context.Context, orgSlug string) string {
+	return filepath.Join(state.ConfigDirectory(ctx), "policies", orgSlug+".toml")
+}
+
+// Load returns the policy of the organization, or nil when it has none.
+func Load(ctx context.Context, orgSlug string) (*Policy, error) {
+	p, err := LoadFile(Path(ctx, orgSlug))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// LoadFile parses the policy file at path.
+func LoadFile(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(b))
+}
+
+// Parse parses and validates a policy document.
+func Parse(doc string) (*Policy, error) {
+	var p Policy
+	md, err := toml.Decode(doc, &p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("invalid policy: unknown rule %s", undecoded[0])
+	}
+	if p.MaxCPUs < 0 || p.MaxMemoryMB < 0 {
+		return nil, errors.New("invalid policy: machine size limits must be positive")
+	}
+	return &p, nil
+}
+
+// Save writes p as the policy of the organization.
+func Save(ctx context.Context, orgSlug string, p *Policy) error {
+	path := Path(ctx, orgSlug)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return toml.NewEncoder(f).Encode(p)
+}
+
+// Remove deletes the policy of the organization.
+func Remove(ctx context.Context, orgSlug string) error {
+	err := os.Remove(Path(ctx, orgSlug))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Evaluate returns the rules the plan breaks. hasLogShipper tells whether the
+// organization runs a log shipper.
+func (p *Policy) Evaluate(plan Plan, hasLogShipper bool) []Violation {
+	var violations []Violation
+
+	if p.RequireLogShipping && !hasLogShipper {
+		violations = append(violations, Violation{
+			Rule:    "require_log_shipping",
+			Message: "the organization has no log shipper, set one up with `fly logs ship`",
+		})
+	}
+
+	for _, m := range plan.Machines {
+		if m == nil || m.Config == nil {
+			continue
+		}
+		id := m.ID
+		if id == "" {
+			id = "(new)"
+		}
+
+		if len(p.AllowedRegions) > 0 && m.Region != "" && !lo.Contains(p.AllowedRegions, m.Region) {
+			violations = append(violations, Violation{
+				Rule:    "allowed_regions",
+				Machine: id,
+				Message: fmt.Sprintf("runs in %s, allowed regions are %s", m.Region, strings.Join(p.AllowedRegions, ", ")),
+			})
+		}
+
+		if guest := m.Config.Guest; guest != nil {
+			if p.MaxCPUs > 0 && guest.CPUs > p.MaxCPUs {
+				violations = append(violations, Violation{
+					Rule:    "max_cpus",
+					Machine: id,
+					Message: fmt.Sprintf("has %d CPUs, the maximum is %d", guest.CPUs, p.MaxCPUs),
+				})
+			}
+			if p.MaxMemoryMB > 0 && guest.MemoryMB > p.MaxMemoryMB {
+				violations = append(violations, Violation{
+					Rule:    "max_memory_mb",
+					Machine: id,
+					Message: fmt.Sprintf("has %dMB of memory, the maximum is %dMB", guest.MemoryMB, p.MaxMemoryMB),
+				})
+			}
+			if p.AllowPerformanceCPUs != nil && !*p.AllowPerformanceCPUs && guest.CPUKind == "performance" {
+				violations = append(violations, Violation{
+					Rule:    "allow_performance_cpus",
+					Machine: id,
+					Message: "uses performance CPUs, only shared CPUs are allowed",
+				})
+			}
+		}
+
+		var missing []string
+		for _, key := range p.RequiredMetadata {
+			if m.Config.Metadata[key] == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			violations = append(violations, Violation{
+				Rule:    "required_metadata",
+				Machine: id,
+				Message: fmt.Sprintf("lacks metadata %s", strings.Join(missing, ", ")),
+			})
+		}
+	}
+
+	return violations
+}
+
+// Check evaluates the plan against the policy of the organization, if it has
+// one. See Enforce.
+func Check(ctx context.Context, orgSlug string, plan Plan) error {
+	p, err := Load(ctx, orgSlug)
+	if err != nil {
+		return fmt.Errorf("failed loading the policy of %s: %w", orgSlug, err)
+	}
+	if p == nil {
+		return nil
+	}
+	return Enforce(ctx, p, orgSlug, plan)
+}
+
+// Enforce evaluates the plan against p. Violations are returned as an error
+// when p is enforced, and printed as warnings otherwise.
+func Enforce(ctx context.Context, p *Policy, orgSlug string, plan Plan) error {
+	violations, err := Test(ctx, p, orgSlug, plan)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	io := iostreams.FromContext(ctx)
+	colorize := io.ColorScheme()
+	for _, v := range violations {
+		fmt.Fprintf(io.ErrOut, "%s %s\n", colorize.Yellow("Policy violation:"), v)
+	}
+
+	if p.Enforce {
+		return fmt.Errorf("%s breaks %d rule(s) of the %s policy", plan.AppName, len(violations), orgSlug)
+	}
+	return nil
+}
+
+// Test evaluates the plan against p, looking up the organization's log
+// shippers if p requires one.
+func Test(ctx context.Context, p *Policy, orgSlug string, plan Plan) ([]Violation, error) {
+	hasLogShipper := true
+	if p.RequireLogShipping {
+		var err error
+		if hasLogShipper, err = orgHasLogShipper(ctx, orgSlug); err != nil {
+			return nil, err
+		}
+	}
+	return p.Evaluate(plan, hasLogShipper), nil
+}
+
+func orgHasLogShipper(ctx context.Context, orgSlug string) (bool, error) {
+	apiClient := client.FromContext(ctx).API()
+
+	org, err := apiClient.GetOrganizationBySlug(ctx, orgSlug)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := gql.GetAppsByRole(ctx, apiClient.GenqClient, shipperAppRole, org.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed looking up log shippers: %w", err)
+	}
+	return len(resp.Apps.Nodes) > 0, nil
+}
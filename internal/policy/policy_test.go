@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse(`
+allowed_regions = ["ams", "fra"]
+max_cpus = 2
+required_metadata = ["team"]
+enforce = true
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ams", "fra"}, p.AllowedRegions)
+	assert.Equal(t, 2, p.MaxCPUs)
+	assert.True(t, p.Enforce)
+
+	_, err = Parse(`max_cpu = 2`)
+	assert.ErrorContains(t, err, "unknown rule max_cpu")
+}
+
+func TestEvaluate(t *testing.T) {
+	no := false
+	p := &Policy{
+		AllowedRegions:       []string{"ams"},
+		MaxCPUs:              2,
+		MaxMemoryMB:          2048,
+		AllowPerformanceCPUs: &no,
+		RequiredMetadata:     []string{"team", "cost_center"},
+		RequireLogShipping:   true,
+	}
+
+	plan := Plan{
+		AppName: "app",
+		Machines: []*api.LaunchMachineInput{
+			{
+				ID:     "m1",
+				Region: "ams",
+				Config: &api.MachineConfig{
+					Guest:    &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256},
+					Metadata: map[string]string{"team": "web", "cost_center": "42"},
+				},
+			},
+			{
+				Region: "iad",
+				Config: &api.MachineConfig{
+					Guest:    &api.MachineGuest{CPUKind: "performance", CPUs: 4, MemoryMB: 8192},
+					Metadata: map[string]string{"team": "web"},
+				},
+			},
+		},
+	}
+
+	violations := p.Evaluate(plan, true)
+	rules := make([]string, 0, len(violations))
+	for _, v := range violations {
+		assert.Equal(t, "(new)", v.Machine)
+		rules = append(rules, v.Rule)
+	}
+	assert.Equal(t, []string{"allowed_regions", "max_cpus", "max_memory_mb", "allow_performance_cpus", "required_metadata"}, rules)
+	assert.Equal(t, "required_metadata: machine (new) lacks metadata cost_center", violations[4].String())
+
+	violations = p.Evaluate(Plan{AppName: "app"}, false)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "require_log_shipping", violations[0].Rule)
+
+	assert.Empty(t, (&Policy{}).Evaluate(plan, false))
+}
+
+func TestEnforce(t *testing.T) {
+	ios, _, _, errOut := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	plan := Plan{
+		AppName:  "app",
+		Machines: []*api.LaunchMachineInput{{ID: "m1", Region: "fra", Config: &api.MachineConfig{}}},
+	}
+
+	p := &Policy{AllowedRegions: []string{"ams"}}
+	require.NoError(t, Enforce(ctx, p, "org", plan))
+	assert.Contains(t, errOut.String(), "Policy violation:")
+
+	p.Enforce = true
+	assert.ErrorContains(t, Enforce(ctx, p, "org", plan), "app breaks 1 rule(s) of the org policy")
+
+	assert.NoError(t, Enforce(ctx, p, "org", Plan{AppName: "app"}))
+}
@@ -0,0 +1,152 @@
+// Package prometheus queries the metrics Fly.io scrapes from machines,
+// through the Prometheus API of their organization.
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/config"
+)
+
+// Sample is the value of a series at an instant.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Point is a value of a series over time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is the values of a series over a range of time.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// response is the response of the Prometheus query APIs.
+type response struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]any            `json:"value"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Query returns the samples of the instant query against the metrics of
+// orgSlug.
+func Query(ctx context.Context, orgSlug, query string) ([]Sample, error) {
+	resp, err := get(ctx, orgSlug, "query", url.Values{"query": {query}})
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]Sample, 0, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		if p, ok := decodePoint(r.Value); ok {
+			samples = append(samples, Sample{Labels: r.Metric, Value: p.Value})
+		}
+	}
+	return samples, nil
+}
+
+// QueryRange returns the series of the range query against the metrics of
+// orgSlug, from start to end, a point every step.
+func QueryRange(ctx context.Context, orgSlug, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	resp, err := get(ctx, orgSlug, "query_range", url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]Series, 0, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		s := Series{Labels: r.Metric, Points: make([]Point, 0, len(r.Values))}
+		for _, v := range r.Values {
+			if p, ok := decodePoint(v); ok {
+				s.Points = append(s.Points, p)
+			}
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+func get(ctx context.Context, orgSlug, path string, params url.Values) (*response, error) {
+	cfg := config.FromContext(ctx)
+	endpoint := fmt.Sprintf("%s/prometheus/%s/api/v1/%s?%s", cfg.APIBaseURL, url.PathEscape(orgSlug), path, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", api.AuthorizationHeader(cfg.AccessToken))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed querying the metrics of %s: %w", orgSlug, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prometheus reports invalid queries in the body of client errors
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return decode(data)
+	default:
+		return nil, &api.ApiError{
+			Message: fmt.Sprintf("failed querying the metrics of %s: %s", orgSlug, resp.Status),
+			Status:  resp.StatusCode,
+		}
+	}
+}
+
+func decode(data []byte) (*response, error) {
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed decoding metrics: %w", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("failed querying metrics: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// decodePoint decodes a [timestamp, "value"] pair of the Prometheus APIs,
+// leaving out values which aren't numbers, such as the ones of divisions by
+// zero.
+func decodePoint(v [2]any) (Point, bool) {
+	ts, ok := v[0].(float64)
+	if !ok {
+		return Point{}, false
+	}
+	s, _ := v[1].(string)
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return Point{}, false
+	}
+	return Point{Time: time.Unix(0, int64(ts*float64(time.Second))).UTC(), Value: f}, true
+}
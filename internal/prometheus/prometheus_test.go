@@ -0,0 +1,75 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/config"
+)
+
+func TestQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prometheus/acme/api/v1/query", r.URL.Path)
+		assert.Equal(t, `up{app="web"}`, r.URL.Query().Get("query"))
+		assert.NotEmpty(t, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"instance":"m1"},"value":[1700000000.5,"42.5"]},
+			{"metric":{"instance":"m2"},"value":[1700000000.5,"NaN"]},
+			{"metric":{"instance":"m3"},"value":[1700000000.5,"oops"]}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	ctx := config.NewContext(context.Background(), &config.Config{APIBaseURL: srv.URL, AccessToken: "token"})
+
+	samples, err := Query(ctx, "acme", `up{app="web"}`)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "m1", samples[0].Labels["instance"])
+	assert.Equal(t, 42.5, samples[0].Value)
+}
+
+func TestQueryRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prometheus/acme/api/v1/query_range", r.URL.Path)
+		assert.Equal(t, "3600", r.URL.Query().Get("step"))
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"instance":"m1"},"values":[[1700000000,"1"],[1700003600,"2"]]}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	ctx := config.NewContext(context.Background(), &config.Config{APIBaseURL: srv.URL})
+
+	end := time.Unix(1700003600, 0)
+	series, err := QueryRange(ctx, "acme", "up", end.Add(-time.Hour), end, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, []Point{{Time: end.Add(-time.Hour).UTC(), Value: 1}, {Time: end.UTC(), Value: 2}}, series[0].Points)
+}
+
+func TestQueryErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":"error","error":"parse error"}`))
+	}))
+	defer srv.Close()
+
+	ctx := config.NewContext(context.Background(), &config.Config{APIBaseURL: srv.URL})
+
+	_, err := Query(ctx, "acme", "up{")
+	assert.EqualError(t, err, "failed querying metrics: parse error")
+
+	status = http.StatusUnauthorized
+	_, err = Query(ctx, "acme", "up")
+	var apiErr *api.ApiError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.Status)
+}
@@ -25,6 +25,9 @@ import (
 type RegionParams struct {
 	Message             string
 	ExcludedRegionCodes []string
+	// OrgSlug, when set, preselects the default region of the organization
+	// instead of the closest one.
+	OrgSlug string
 }
 
 func String(ctx context.Context, dst *string, msg, def string, required bool) error {
@@ -382,6 +385,9 @@ func Region(ctx context.Context, splitPaid bool, params RegionParams) (*api.Regi
 		if defaultRegion != nil {
 			defaultRegionCode = defaultRegion.Code
 		}
+		if orgRegion := config.FromContext(ctx).OrgRegion(params.OrgSlug); orgRegion != "" {
+			defaultRegionCode = orgRegion
+		}
 
 		switch region, err := SelectRegion(ctx, params.Message, paidOnly, regions, defaultRegionCode); {
 		case err == nil:
@@ -0,0 +1,55 @@
+// Package protect implements the local deletion locks of apps, volumes and
+// machines. Locks are kept in flyctl's configuration file, shared by all auth
+// contexts, and only guard the commands of this flyctl destroying resources;
+// the platform doesn't enforce them.
+package protect
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// ForceFlagName is the name of the flag overriding deletion locks.
+const ForceFlagName = "force-unprotect"
+
+// ForceFlag returns the flag destroy commands use to override deletion locks.
+func ForceFlag() flag.Bool {
+	return flag.Bool{
+		Name:        ForceFlagName,
+		Description: "Destroy the resource even if it's protected, after re-typing its name",
+	}
+}
+
+// CheckDestroy returns an error when the resource of the given kind and name
+// is protected, unless --force-unprotect was passed and the user re-types its
+// name.
+func CheckDestroy(ctx context.Context, kind, name string) error {
+	if !config.FromContext(ctx).IsProtected(kind, name) {
+		return nil
+	}
+
+	if !flag.GetBool(ctx, ForceFlagName) {
+		return fmt.Errorf("%s is protected from deletion in your flyctl configuration, pass --%s to destroy it anyway or run `fly apps unprotect` first", name, ForceFlagName)
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintln(io.ErrOut, io.ColorScheme().Red(fmt.Sprintf("%s is protected from deletion.", name)))
+
+	var typed string
+	switch err := prompt.String(ctx, &typed, fmt.Sprintf("Type %s to confirm:", name), "", true); {
+	case err == nil:
+		if typed != name {
+			return fmt.Errorf("typed name %q does not match %s", typed, name)
+		}
+		return nil
+	case prompt.IsNonInteractive(err):
+		return prompt.NonInteractiveError("protected resources can only be destroyed interactively")
+	default:
+		return err
+	}
+}
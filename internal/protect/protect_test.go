@@ -0,0 +1,24 @@
+package protect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+func TestCheckDestroy(t *testing.T) {
+	fs := pflag.NewFlagSet("destroy", pflag.ContinueOnError)
+	fs.Bool(ForceFlagName, false, "")
+
+	cfg := &config.Config{Protected: map[string][]string{config.ProtectedApp: {"prod"}}}
+	ctx := config.NewContext(flag.NewContext(context.Background(), fs), cfg)
+
+	assert.NoError(t, CheckDestroy(ctx, config.ProtectedApp, "staging"))
+	assert.NoError(t, CheckDestroy(ctx, config.ProtectedVolume, "prod"))
+	assert.ErrorContains(t, CheckDestroy(ctx, config.ProtectedApp, "prod"), "prod is protected from deletion in your flyctl configuration")
+}
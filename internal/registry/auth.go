@@ -0,0 +1,42 @@
+// Package registry implements helpers for tools that talk to the Fly image
+// registry.
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/superfly/flyctl/internal/config"
+)
+
+// AuthConfigDir writes a docker config authenticating against the Fly
+// registry to a temporary directory, so that external tools pointed at it
+// through DOCKER_CONFIG can pull and push images without touching the user's
+// own docker config. The returned function removes the directory.
+func AuthConfigDir(cfg *config.Config) (dir string, cleanup func(), err error) {
+	if dir, err = os.MkdirTemp("", "flyctl-registry-"); err != nil {
+		return
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	dockerConfig := map[string]interface{}{
+		"auths": map[string]interface{}{
+			cfg.RegistryHost: map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte("x:" + cfg.AccessToken)),
+			},
+		},
+	}
+
+	b, err := json.Marshal(dockerConfig)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "config.json"), b, 0o600)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return dir, cleanup, nil
+}
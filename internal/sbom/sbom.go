@@ -0,0 +1,169 @@
+// Package sbom generates and compares software bills of materials for images
+// with the syft CLI.
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/registry"
+)
+
+// Supported SBOM formats.
+const (
+	CycloneDX = "cyclonedx"
+	SPDX      = "spdx"
+)
+
+// Formats lists the supported SBOM formats.
+var Formats = []string{CycloneDX, SPDX}
+
+// Generate returns the SBOM of the image at imageRef, pulled from its
+// registry, in the given format.
+func Generate(ctx context.Context, imageRef, format string) ([]byte, error) {
+	if format != CycloneDX && format != SPDX {
+		return nil, fmt.Errorf("unsupported sbom format %s, expected one of %v", format, Formats)
+	}
+
+	binary, err := exec.LookPath("syft")
+	if err != nil {
+		return nil, fmt.Errorf("syft cli not found - install it from https://github.com/anchore/syft#installation and try again: %w", err)
+	}
+
+	dockerConfig, cleanup, err := registry.AuthConfigDir(config.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, "registry:"+imageRef, "--quiet", "--output", format+"-json")
+	cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+dockerConfig)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed generating sbom for %s: %w\n%s", imageRef, err, stderr.String())
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Package is a package listed in an SBOM.
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Packages returns the packages listed in doc, an SBOM in the given format,
+// sorted by name and version.
+func Packages(doc []byte, format string) ([]Package, error) {
+	var packages []Package
+
+	switch format {
+	case CycloneDX:
+		var bom struct {
+			Components []Package `json:"components"`
+		}
+		if err := json.Unmarshal(doc, &bom); err != nil {
+			return nil, fmt.Errorf("failed parsing cyclonedx sbom: %w", err)
+		}
+		packages = bom.Components
+	case SPDX:
+		var bom struct {
+			Packages []struct {
+				Name        string `json:"name"`
+				VersionInfo string `json:"versionInfo"`
+			} `json:"packages"`
+		}
+		if err := json.Unmarshal(doc, &bom); err != nil {
+			return nil, fmt.Errorf("failed parsing spdx sbom: %w", err)
+		}
+		for _, p := range bom.Packages {
+			packages = append(packages, Package{Name: p.Name, Version: p.VersionInfo})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported sbom format %s, expected one of %v", format, Formats)
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name == packages[j].Name {
+			return packages[i].Version < packages[j].Version
+		}
+		return packages[i].Name < packages[j].Name
+	})
+
+	return packages, nil
+}
+
+// Change is a package whose version differs between two SBOMs.
+type Change struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Difference is the result of comparing two SBOMs.
+type Difference struct {
+	Added   []Package `json:"added"`
+	Removed []Package `json:"removed"`
+	Changed []Change  `json:"changed"`
+}
+
+// Diff compares the packages of the previous and current SBOMs. Packages are
+// matched by name; a package listed with several versions is compared on the
+// set of its versions.
+func Diff(prev, cur []Package) Difference {
+	var (
+		diff        Difference
+		prevVersion = versionsByName(prev)
+		curVersion  = versionsByName(cur)
+		names       = map[string]struct{}{}
+	)
+
+	for name := range prevVersion {
+		names[name] = struct{}{}
+	}
+	for name := range curVersion {
+		names[name] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		from, inPrev := prevVersion[name]
+		to, inCur := curVersion[name]
+		switch {
+		case !inPrev:
+			diff.Added = append(diff.Added, Package{Name: name, Version: to})
+		case !inCur:
+			diff.Removed = append(diff.Removed, Package{Name: name, Version: from})
+		case from != to:
+			diff.Changed = append(diff.Changed, Change{Name: name, From: from, To: to})
+		}
+	}
+
+	return diff
+}
+
+func versionsByName(packages []Package) map[string]string {
+	versions := map[string]string{}
+	for _, p := range packages {
+		if v, ok := versions[p.Name]; ok && v != p.Version {
+			versions[p.Name] = v + ", " + p.Version
+			continue
+		}
+		versions[p.Name] = p.Version
+	}
+	return versions
+}
@@ -0,0 +1,34 @@
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackages(t *testing.T) {
+	cyclonedx := []byte(`{"bomFormat":"CycloneDX","components":[{"name":"zlib","version":"1.2.13"},{"name":"busybox","version":"1.36.0"}]}`)
+	packages, err := Packages(cyclonedx, CycloneDX)
+	require.NoError(t, err)
+	assert.Equal(t, []Package{{"busybox", "1.36.0"}, {"zlib", "1.2.13"}}, packages)
+
+	spdx := []byte(`{"spdxVersion":"SPDX-2.3","packages":[{"name":"openssl","versionInfo":"3.0.8"}]}`)
+	packages, err = Packages(spdx, SPDX)
+	require.NoError(t, err)
+	assert.Equal(t, []Package{{"openssl", "3.0.8"}}, packages)
+
+	_, err = Packages(spdx, "syft")
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	prev := []Package{{"busybox", "1.36.0"}, {"openssl", "3.0.7"}, {"zlib", "1.2.13"}}
+	cur := []Package{{"busybox", "1.36.0"}, {"curl", "8.0.1"}, {"openssl", "3.0.8"}}
+
+	assert.Equal(t, Difference{
+		Added:   []Package{{"curl", "8.0.1"}},
+		Removed: []Package{{"zlib", "1.2.13"}},
+		Changed: []Change{{"openssl", "3.0.7", "3.0.8"}},
+	}, Diff(prev, cur))
+}
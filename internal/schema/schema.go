@@ -0,0 +1,189 @@
+// Package schema implements the versioned JSON outputs of flyctl.
+//
+// Commands print the JSON shape of a schema version when given
+// --json-schema-version. Within a version, fields are only ever added: they
+// are never removed, renamed or retyped, and the meaning of their values does
+// not change. Breaking changes ship as a new version, and flyctl keeps
+// printing the versions it supported for at least six months after the
+// release replacing them.
+package schema
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// Output describes a versioned JSON output.
+type Output struct {
+	// Name identifies the output, as in 'fly schema print <name>'.
+	Name string
+
+	// Command is the command printing the output.
+	Command string
+
+	// Versions are the types of the output by schema version.
+	Versions map[int]reflect.Type
+}
+
+// Outputs are the versioned JSON outputs of flyctl.
+var Outputs = []Output{
+	{
+		Name:     "status",
+		Command:  "fly status --json",
+		Versions: map[int]reflect.Type{1: reflect.TypeOf(StatusV1{})},
+	},
+	{
+		Name:     "machines",
+		Command:  "fly machine list --json",
+		Versions: map[int]reflect.Type{1: reflect.TypeOf([]MachineV1{})},
+	},
+	{
+		Name:     "apps",
+		Command:  "fly apps list --json",
+		Versions: map[int]reflect.Type{1: reflect.TypeOf([]AppV1{})},
+	},
+}
+
+// Names returns the names of the versioned outputs.
+func Names() []string {
+	names := make([]string, len(Outputs))
+	for i, o := range Outputs {
+		names[i] = o.Name
+	}
+	return names
+}
+
+// Find returns the output of the given name.
+func Find(name string) (*Output, error) {
+	for i := range Outputs {
+		if Outputs[i].Name == name {
+			return &Outputs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output %q, valid options are: %s", name, strings.Join(Names(), ", "))
+}
+
+// SupportedVersions returns the schema versions of o, in increasing order.
+func (o *Output) SupportedVersions() []int {
+	versions := make([]int, 0, len(o.Versions))
+	for v := range o.Versions {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Latest returns the latest schema version of o.
+func (o *Output) Latest() int {
+	versions := o.SupportedVersions()
+	return versions[len(versions)-1]
+}
+
+// Check returns an error when o has no schema of the given version.
+func (o *Output) Check(version int) error {
+	if _, ok := o.Versions[version]; ok {
+		return nil
+	}
+	versions := make([]string, 0, len(o.Versions))
+	for _, v := range o.SupportedVersions() {
+		versions = append(versions, strconv.Itoa(v))
+	}
+	return fmt.Errorf("%s has no JSON schema version %d, supported versions are: %s", o.Command, version, strings.Join(versions, ", "))
+}
+
+// CheckVersion returns an error when the output of the given name has no
+// schema of version, unless version is 0, which picks the unversioned output.
+func CheckVersion(name string, version int) error {
+	if version == 0 {
+		return nil
+	}
+	o, err := Find(name)
+	if err != nil {
+		return err
+	}
+	return o.Check(version)
+}
+
+// Document returns the JSON Schema of the given version of o.
+func (o *Output) Document(version int) (map[string]any, error) {
+	if err := o.Check(version); err != nil {
+		return nil, err
+	}
+
+	doc := typeSchema(o.Versions[version])
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	doc["title"] = fmt.Sprintf("%s v%d", o.Command, version)
+	return doc, nil
+}
+
+// Render writes v, in the shape of the given version of the output of the
+// given name, to w.
+func Render(w io.Writer, name string, version int, v any) error {
+	o, err := Find(name)
+	if err != nil {
+		return err
+	}
+	if err := o.Check(version); err != nil {
+		return err
+	}
+	if got, want := reflect.TypeOf(v), o.Versions[version]; got != want {
+		return fmt.Errorf("%s renders %s for schema version %d, not %s", name, want, version, got)
+	}
+	return render.JSON(w, v)
+}
+
+// typeSchema returns the JSON Schema of the values of t, as encoding/json
+// encodes them.
+func typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return typeSchema(t.Elem())
+	}
+	if t.PkgPath() == "time" && t.Name() == "Time" {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = typeSchema(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestCheckVersion(t *testing.T) {
+	assert.NoError(t, CheckVersion("status", 0))
+	assert.NoError(t, CheckVersion("status", 1))
+	assert.ErrorContains(t, CheckVersion("status", 7), "supported versions are: 1")
+	assert.ErrorContains(t, CheckVersion("nope", 1), "valid options are: status, machines, apps")
+}
+
+func TestDocument(t *testing.T) {
+	o, err := Find("status")
+	require.NoError(t, err)
+
+	doc, err := o.Document(1)
+	require.NoError(t, err)
+	assert.Equal(t, "object", doc["type"])
+	assert.Contains(t, doc["required"], "machines")
+
+	properties := doc["properties"].(map[string]any)
+	machines := properties["machines"].(map[string]any)
+	assert.Equal(t, "array", machines["type"])
+	machine := machines["items"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string"}, machine["private_ip"])
+}
+
+// TestRenderMatchesDocument guards the compatibility policy: every field of
+// the rendered output is described by the schema, under the same name.
+func TestRenderMatchesDocument(t *testing.T) {
+	status := NewStatusV1(
+		&api.AppCompact{ID: "1", Name: "web", Organization: &api.OrganizationBasic{ID: "o", Slug: "acme"}},
+		[]*api.Machine{{ID: "m1", Region: "ams", Checks: []*api.MachineCheckStatus{{Name: "http", Status: "passing"}}}},
+		3,
+	)
+
+	var b bytes.Buffer
+	require.NoError(t, Render(&b, "status", 1, status))
+
+	var rendered map[string]any
+	require.NoError(t, json.Unmarshal(b.Bytes(), &rendered))
+
+	o, _ := Find("status")
+	doc, _ := o.Document(1)
+	properties := doc["properties"].(map[string]any)
+	for name := range rendered {
+		assert.Contains(t, properties, name)
+	}
+	assert.Equal(t, "acme", rendered["organization"].(map[string]any)["slug"])
+
+	assert.ErrorContains(t, Render(&b, "status", 1, []AppV1{}), "renders")
+}
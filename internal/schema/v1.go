@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"github.com/superfly/flyctl/api"
+)
+
+// OrganizationV1 is an organization in version 1 outputs.
+type OrganizationV1 struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+}
+
+// CheckV1 is the status of a machine health check in version 1 outputs.
+type CheckV1 struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Output string `json:"output"`
+}
+
+// MachineV1 is a machine in version 1 outputs.
+type MachineV1 struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	State        string    `json:"state"`
+	Region       string    `json:"region"`
+	Image        string    `json:"image"`
+	ProcessGroup string    `json:"process_group"`
+	InstanceID   string    `json:"instance_id"`
+	PrivateIP    string    `json:"private_ip"`
+	CreatedAt    string    `json:"created_at"`
+	UpdatedAt    string    `json:"updated_at"`
+	Checks       []CheckV1 `json:"checks"`
+}
+
+// StatusV1 is version 1 of the output of 'fly status --json'.
+type StatusV1 struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	Organization    OrganizationV1 `json:"organization"`
+	Status          string         `json:"status"`
+	Deployed        bool           `json:"deployed"`
+	Hostname        string         `json:"hostname"`
+	AppURL          string         `json:"app_url"`
+	PlatformVersion string         `json:"platform_version"`
+	Version         int            `json:"version"`
+	Machines        []MachineV1    `json:"machines"`
+}
+
+// AppV1 is an app of version 1 of the output of 'fly apps list --json'.
+type AppV1 struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	Organization    OrganizationV1 `json:"organization"`
+	Status          string         `json:"status"`
+	Deployed        bool           `json:"deployed"`
+	Hostname        string         `json:"hostname"`
+	PlatformVersion string         `json:"platform_version"`
+}
+
+// NewMachinesV1 returns machines in their version 1 shape.
+func NewMachinesV1(machines []*api.Machine) []MachineV1 {
+	out := make([]MachineV1, 0, len(machines))
+	for _, m := range machines {
+		checks := make([]CheckV1, 0, len(m.Checks))
+		for _, c := range m.Checks {
+			checks = append(checks, CheckV1{Name: c.Name, Status: string(c.Status), Output: c.Output})
+		}
+
+		out = append(out, MachineV1{
+			ID:           m.ID,
+			Name:         m.Name,
+			State:        m.State,
+			Region:       m.Region,
+			Image:        m.FullImageRef(),
+			ProcessGroup: m.ProcessGroup(),
+			InstanceID:   m.InstanceID,
+			PrivateIP:    m.PrivateIP,
+			CreatedAt:    m.CreatedAt,
+			UpdatedAt:    m.UpdatedAt,
+			Checks:       checks,
+		})
+	}
+	return out
+}
+
+// NewStatusV1 returns the status of app, running machines at release
+// version, in its version 1 shape.
+func NewStatusV1(app *api.AppCompact, machines []*api.Machine, version int) StatusV1 {
+	status := StatusV1{
+		ID:              app.ID,
+		Name:            app.Name,
+		Status:          app.Status,
+		Deployed:        app.Deployed,
+		Hostname:        app.Hostname,
+		AppURL:          app.AppURL,
+		PlatformVersion: app.PlatformVersion,
+		Version:         version,
+		Machines:        NewMachinesV1(machines),
+	}
+	if app.Organization != nil {
+		status.Organization = OrganizationV1{ID: app.Organization.ID, Slug: app.Organization.Slug}
+	}
+	return status
+}
+
+// NewAppsV1 returns apps in their version 1 shape.
+func NewAppsV1(apps []api.App) []AppV1 {
+	out := make([]AppV1, 0, len(apps))
+	for _, app := range apps {
+		out = append(out, AppV1{
+			ID:              app.ID,
+			Name:            app.Name,
+			Organization:    OrganizationV1{ID: app.Organization.ID, Slug: app.Organization.Slug},
+			Status:          app.Status,
+			Deployed:        app.Deployed,
+			Hostname:        app.Hostname,
+			PlatformVersion: app.PlatformVersion,
+		})
+	}
+	return out
+}
@@ -0,0 +1,218 @@
+// Package secretscan looks for values that look like leaked secrets in build
+// contexts and image layers.
+package secretscan
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// maxFileSize bounds the size of the files scanned; larger files are mostly
+// binaries and data which would only slow the scan down.
+const maxFileSize = 1 << 20
+
+// pattern matches a kind of secret.
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe secret key", regexp.MustCompile(`\bsk_live_[A-Za-z0-9]{24,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Fly.io token", regexp.MustCompile(`\bFlyV1 fm[0-9]_[A-Za-z0-9_+/=,-]{20,}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (RSA |EC |DSA |OPENSSH |ENCRYPTED )?PRIVATE KEY-----`)},
+}
+
+// skippedImageDirs hold files installed by system packages, which are left
+// out of image scans.
+var skippedImageDirs = []string{
+	"bin/", "lib/", "lib64/", "sbin/", "usr/", "proc/", "sys/", "var/lib/",
+}
+
+// Finding is a value that looks like a secret.
+type Finding struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+}
+
+// Scanner looks for secrets in files.
+type Scanner struct {
+	assigned *regexp.Regexp
+}
+
+// New returns a Scanner which, along with well-known kinds of secrets,
+// reports values assigned to the given names of Fly secrets, e.g. in .env
+// files.
+func New(secretNames []string) *Scanner {
+	return &Scanner{assigned: assignmentPattern(secretNames)}
+}
+
+// ScanTar scans the files of the tar archive read from r, such as a build
+// context.
+func (s *Scanner) ScanTar(r io.Reader) ([]Finding, error) {
+	return s.scanTar(r, nil)
+}
+
+// ScanBuildContext scans the files of the build context rooted at
+// workingDir, leaving out those excluded by its .dockerignore or ignoreFile.
+func (s *Scanner) ScanBuildContext(workingDir, dockerfile, ignoreFile string) ([]Finding, error) {
+	if dockerfile == "" {
+		dockerfile = filepath.Join(workingDir, "Dockerfile")
+	}
+
+	archive, err := imgsrc.CreateArchive(dockerfile, workingDir, ignoreFile, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading build context: %w", err)
+	}
+
+	return s.ScanTar(bytes.NewReader(archive.Content))
+}
+
+// ScanImage scans the files of the flattened filesystem of the image at
+// imageRef, pulled from its registry. Files installed by system packages are
+// skipped.
+func (s *Scanner) ScanImage(ctx context.Context, imageRef string) ([]Finding, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %s: %w", imageRef, err)
+	}
+
+	var auth authn.Authenticator = authn.Anonymous
+	if cfg := config.FromContext(ctx); ref.Context().RegistryStr() == cfg.RegistryHost {
+		auth = &authn.Basic{Username: "x", Password: cfg.AccessToken}
+	} else if auth, err = authn.DefaultKeychain.Resolve(ref.Context()); err != nil {
+		return nil, err
+	}
+
+	img, err := remote.Image(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching image %s: %w", imageRef, err)
+	}
+
+	rc := mutate.Extract(img)
+	defer rc.Close()
+
+	return s.scanTar(rc, func(p string) bool {
+		for _, dir := range skippedImageDirs {
+			if strings.HasPrefix(p, dir) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *Scanner) scanTar(r io.Reader, skip func(string) bool) ([]Finding, error) {
+	var (
+		findings []Finding
+		tr       = tar.NewReader(r)
+	)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxFileSize || (skip != nil && skip(p)) {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		findings = append(findings, s.Scan(p, content)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+
+	return findings, nil
+}
+
+// Scan returns the secrets found in content, the contents of the file at
+// path.
+func (s *Scanner) Scan(path string, content []byte) []Finding {
+	// Skip binaries
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil
+	}
+
+	var (
+		findings []Finding
+		lines    = bufio.NewScanner(bytes.NewReader(content))
+	)
+	lines.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+
+	for n := 1; lines.Scan(); n++ {
+		line := lines.Text()
+		for _, p := range patterns {
+			if p.re.MatchString(line) {
+				findings = append(findings, Finding{Path: path, Line: n, Kind: p.kind})
+			}
+		}
+		if s.assigned != nil {
+			if m := s.assigned.FindStringSubmatch(line); m != nil {
+				findings = append(findings, Finding{Path: path, Line: n, Kind: fmt.Sprintf("value of Fly secret %s", m[1])})
+			}
+		}
+	}
+
+	return findings
+}
+
+// assignmentPattern matches non-empty assignments to the given secret names,
+// e.g. DATABASE_URL=postgres://... or export API_KEY="...".
+func assignmentPattern(secretNames []string) *regexp.Regexp {
+	if len(secretNames) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(secretNames))
+	for i, n := range secretNames {
+		names[i] = regexp.QuoteMeta(n)
+	}
+
+	return regexp.MustCompile(`^\s*(?:export\s+)?(` + strings.Join(names, "|") + `)\s*[=:]\s*["']?[^\s"'$]{4,}`)
+}
+
+// Render writes a table of findings to w.
+func Render(w io.Writer, findings []Finding) error {
+	rows := make([][]string, 0, len(findings))
+	for _, f := range findings {
+		rows = append(rows, []string{f.Path, strconv.Itoa(f.Line), f.Kind})
+	}
+	return render.Table(w, "", rows, "Path", "Line", "Kind")
+}
@@ -0,0 +1,270 @@
+// Package smoketest runs the [[smoke_tests]] of fly.toml against deployed apps.
+package smoketest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// maxBodySize caps how much of responses is read to match body_contains.
+const maxBodySize = 1 << 20
+
+// defaultRetryDelay is how long failing tests wait before being retried.
+const defaultRetryDelay = 5 * time.Second
+
+// Result is the outcome of a smoke test.
+type Result struct {
+	Name     string        `json:"name"`
+	Kind     string        `json:"kind"`
+	Target   string        `json:"target"`
+	Passed   bool          `json:"passed"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Runner runs smoke tests against an app.
+type Runner struct {
+	App   *api.AppCompact
+	Flaps *flaps.Client
+
+	// Machine pins the tests to a machine, e.g. a canary: commands run on it
+	// and HTTP requests are routed to it. Otherwise requests go through the
+	// proxy and commands run on a started machine of the tests' groups.
+	Machine *api.Machine
+
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// RetryDelay is how long failing tests wait before being retried,
+	// defaulting to 5 seconds.
+	RetryDelay time.Duration
+}
+
+// Run runs tests in order and returns their results.
+func (r *Runner) Run(ctx context.Context, tests []appconfig.SmokeTest) []Result {
+	retryDelay := r.RetryDelay
+	if retryDelay == 0 {
+		retryDelay = defaultRetryDelay
+	}
+
+	results := make([]Result, 0, len(tests))
+	for _, t := range tests {
+		var result Result
+		for attempt := 0; attempt <= t.Retries; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(retryDelay):
+				}
+			}
+			if result = r.runOnce(ctx, t); result.Passed || ctx.Err() != nil {
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (r *Runner) runOnce(ctx context.Context, t appconfig.SmokeTest) Result {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, t.TimeoutOrDefault())
+	defer cancel()
+
+	var result Result
+	if t.Kind() == appconfig.SmokeTestCommand {
+		result = r.runCommand(ctx, t)
+	} else {
+		result = r.runHTTP(ctx, t)
+	}
+
+	result.Name = t.Name
+	result.Kind = t.Kind()
+	result.Duration = time.Since(start)
+	return result
+}
+
+func (r *Runner) runHTTP(ctx context.Context, t appconfig.SmokeTest) Result {
+	target := t.URL
+	if target == "" {
+		hostname := r.App.Hostname
+		if hostname == "" {
+			hostname = r.App.Name + ".fly.dev"
+		}
+		target = "https://" + hostname + t.Path
+	}
+	result := Result{Target: target}
+
+	req, err := http.NewRequestWithContext(ctx, t.HTTPMethod(), target, nil)
+	if err != nil {
+		return result.fail(err.Error())
+	}
+	for name, value := range t.Headers {
+		req.Header.Set(name, value)
+	}
+	if r.Machine != nil {
+		// Have the proxy route the request to the pinned machine
+		req.Header.Set("fly-force-instance-id", r.Machine.ID)
+		result.Target += " on " + r.Machine.ID
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return result.fail(err.Error())
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+
+	if resp.StatusCode != t.ExpectedStatus() {
+		return result.fail(fmt.Sprintf("expected status %d, got %s", t.ExpectedStatus(), resp.Status))
+	}
+	if t.MaxLatency != nil && t.MaxLatency.Duration > 0 && latency > t.MaxLatency.Duration {
+		return result.fail(fmt.Sprintf("responded in %s, over the max latency of %s", latency.Round(time.Millisecond), t.MaxLatency.Duration))
+	}
+
+	if t.BodyContains != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err != nil {
+			return result.fail(fmt.Sprintf("failed reading response: %v", err))
+		}
+		if !strings.Contains(string(body), t.BodyContains) {
+			return result.fail(fmt.Sprintf("response doesn't contain %q", t.BodyContains))
+		}
+	}
+
+	result.Passed = true
+	return result
+}
+
+func (r *Runner) runCommand(ctx context.Context, t appconfig.SmokeTest) Result {
+	machine := r.Machine
+	if machine == nil {
+		var err error
+		if machine, err = r.commandMachine(ctx, t); err != nil {
+			return Result{Target: "-"}.fail(err.Error())
+		}
+	}
+	result := Result{Target: machine.ID}
+
+	timeout := int(t.TimeoutOrDefault().Seconds())
+	if timeout < 1 {
+		timeout = 1
+	}
+
+	resp, err := r.Flaps.Exec(ctx, machine.ID, &api.MachineExecRequest{
+		Cmd:     t.Command,
+		Timeout: timeout,
+	})
+	if err != nil {
+		return result.fail(err.Error())
+	}
+	if resp.ExitCode != 0 {
+		return result.fail(fmt.Sprintf("exited with code %d%s", resp.ExitCode, lastLine(resp.StdErr+resp.StdOut)))
+	}
+
+	result.Passed = true
+	return result
+}
+
+// commandMachine returns a started machine of the groups of t.
+func (r *Runner) commandMachine(ctx context.Context, t appconfig.SmokeTest) (*api.Machine, error) {
+	machines, err := r.Flaps.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range machines {
+		if m.State == api.MachineStateStarted && (len(t.Processes) == 0 || slices.Contains(t.Processes, m.ProcessGroup())) {
+			return m, nil
+		}
+	}
+	if len(t.Processes) == 0 {
+		return nil, fmt.Errorf("no started machine to run the command on")
+	}
+	return nil, fmt.Errorf("no started machine in %s to run the command on", strings.Join(t.Processes, ", "))
+}
+
+func (r Result) fail(message string) Result {
+	r.Passed = false
+	r.Message = message
+	return r
+}
+
+// lastLine returns the last non-empty line of output, as a suffix to an
+// error message.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+	if line == "" {
+		return ""
+	}
+	if len(line) > 200 {
+		line = line[:200] + "..."
+	}
+	return ": " + line
+}
+
+// ForGroup returns the tests to run against a machine of group. Tests
+// without processes apply to every group, except HTTP tests, which only
+// apply to groups serving HTTP.
+func ForGroup(tests []appconfig.SmokeTest, group string, servesHTTP bool) []appconfig.SmokeTest {
+	var matching []appconfig.SmokeTest
+	for _, t := range tests {
+		switch {
+		case len(t.Processes) > 0:
+			if slices.Contains(t.Processes, group) {
+				matching = append(matching, t)
+			}
+		case t.Kind() == appconfig.SmokeTestCommand, servesHTTP:
+			matching = append(matching, t)
+		}
+	}
+	return matching
+}
+
+// Failed returns the number of failed results.
+func Failed(results []Result) (n int) {
+	for _, r := range results {
+		if !r.Passed {
+			n++
+		}
+	}
+	return
+}
+
+// Render writes results as a table.
+func Render(w io.Writer, results []Result) error {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status := "passed"
+		if !r.Passed {
+			status = "failed"
+		}
+		rows = append(rows, []string{
+			r.Name,
+			r.Kind,
+			r.Target,
+			status,
+			r.Duration.Round(time.Millisecond).String(),
+			r.Message,
+		})
+	}
+	return render.Table(w, "", rows, "Name", "Kind", "Target", "Result", "Duration", "Message")
+}
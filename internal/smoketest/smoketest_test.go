@@ -0,0 +1,94 @@
+package smoketest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestRunHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, "Welcome to %s", r.Header.Get("fly-force-instance-id"))
+		case "/admin":
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	runner := &Runner{App: &api.AppCompact{Name: "my-app"}, Machine: &api.Machine{ID: "148e"}}
+	results := runner.Run(context.Background(), []appconfig.SmokeTest{
+		{Name: "home", URL: server.URL + "/", BodyContains: "Welcome to 148e"},
+		{Name: "admin", URL: server.URL + "/admin", Status: http.StatusUnauthorized},
+		{Name: "authorized", URL: server.URL + "/admin", Headers: map[string]string{"Authorization": "Bearer x"}, Status: http.StatusUnauthorized},
+		{Name: "missing", URL: server.URL + "/missing"},
+		{Name: "body", URL: server.URL + "/", BodyContains: "Goodbye"},
+	})
+
+	assert.Equal(t, 3, Failed(results))
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, server.URL+"/ on 148e", results[0].Target)
+	assert.True(t, results[1].Passed)
+	assert.Equal(t, "expected status 401, got 200 OK", results[2].Message)
+	assert.Equal(t, "expected status 200, got 404 Not Found", results[3].Message)
+	assert.Equal(t, `response doesn't contain "Goodbye"`, results[4].Message)
+}
+
+func TestForGroup(t *testing.T) {
+	tests := []appconfig.SmokeTest{
+		{Name: "home", Path: "/"},
+		{Name: "ping", Command: "ping"},
+		{Name: "queue", Command: "queue-status", Processes: []string{"worker"}},
+	}
+
+	names := func(tests []appconfig.SmokeTest) (names []string) {
+		for _, t := range tests {
+			names = append(names, t.Name)
+		}
+		return
+	}
+
+	assert.Equal(t, []string{"home", "ping"}, names(ForGroup(tests, "app", true)))
+	assert.Equal(t, []string{"ping", "queue"}, names(ForGroup(tests, "worker", false)))
+}
+
+func TestRunHTTPRetriesAndLatency(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			requests++
+			if requests < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	runner := &Runner{App: &api.AppCompact{Name: "my-app"}, RetryDelay: time.Millisecond}
+	results := runner.Run(context.Background(), []appconfig.SmokeTest{
+		{Name: "flaky", URL: server.URL + "/flaky", Retries: 2},
+		{Name: "slow", URL: server.URL + "/slow", MaxLatency: &api.Duration{Duration: 10 * time.Millisecond}, Retries: 1},
+		{Name: "fast enough", URL: server.URL + "/slow", MaxLatency: &api.Duration{Duration: time.Minute}},
+	})
+
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, 3, requests)
+	assert.False(t, results[1].Passed)
+	assert.Contains(t, results[1].Message, "over the max latency of 10ms")
+	assert.True(t, results[2].Passed)
+}
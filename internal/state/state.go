@@ -3,7 +3,6 @@ package state
 
 import (
 	"context"
-	"path/filepath"
 
 	"github.com/superfly/flyctl/internal/config"
 )
@@ -16,6 +15,7 @@ const (
 	workDirKey
 	userHomeDirKey
 	configDirKey
+	authContextKey
 )
 
 // WithHostname returns a copy of ctx that carries hostname.
@@ -65,10 +65,32 @@ func ConfigDirectory(ctx context.Context) string {
 	return get(ctx, configDirKey).(string)
 }
 
-// ConfigFile returns the config file ctx carries. It panics in case
-// ctx carries no config directory.
+// WithAuthContext derives a Context that carries the name of the given auth
+// context from ctx.
+func WithAuthContext(ctx context.Context, name string) context.Context {
+	return set(ctx, authContextKey, name)
+}
+
+// AuthContext returns the name of the auth context ctx carries, or the default
+// one in case it carries none.
+func AuthContext(ctx context.Context) string {
+	if name, ok := get(ctx, authContextKey).(string); ok && name != "" {
+		return name
+	}
+	return config.DefaultContext
+}
+
+// ConfigFile returns the config file of the auth context ctx carries. It
+// panics in case ctx carries no config directory.
 func ConfigFile(ctx context.Context) string {
-	return filepath.Join(ConfigDirectory(ctx), config.FileName)
+	return config.ContextFile(ConfigDirectory(ctx), AuthContext(ctx))
+}
+
+// SharedConfigFile returns the config file holding the settings shared by all
+// auth contexts, the one of the default auth context. It panics in case ctx
+// carries no config directory.
+func SharedConfigFile(ctx context.Context) string {
+	return config.ContextFile(ConfigDirectory(ctx), config.DefaultContext)
 }
 
 func get(ctx context.Context, key contextKeyType) interface{} {
@@ -0,0 +1,164 @@
+package update
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cli/safeexec"
+)
+
+// InstallMethod is how flyctl was installed, which decides how it's upgraded.
+type InstallMethod string
+
+const (
+	InstallScript     InstallMethod = "install script"
+	InstallHomebrew   InstallMethod = "homebrew"
+	InstallScoop      InstallMethod = "scoop"
+	InstallChocolatey InstallMethod = "chocolatey"
+	InstallSnap       InstallMethod = "snap"
+	InstallApt        InstallMethod = "apt"
+	InstallRPM        InstallMethod = "rpm"
+	InstallNix        InstallMethod = "nix"
+)
+
+// Installation is how the running flyctl was installed.
+type Installation struct {
+	Method InstallMethod
+	// Package is the name of the system package flyctl was installed with,
+	// for apt and rpm.
+	Package string
+}
+
+// SelfUpgrades reports whether flyctl upgrades itself by running
+// UpgradeCommand. Others need privileges or are managed declaratively, so
+// flyctl only prints how to upgrade them.
+func (i Installation) SelfUpgrades() bool {
+	switch i.Method {
+	case InstallScript, InstallHomebrew, InstallScoop:
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsPrereleases reports whether prereleases can be installed this way.
+func (i Installation) SupportsPrereleases() bool {
+	return i.Method == InstallScript
+}
+
+// UpgradeCommand returns the command upgrading flyctl, or instructions when
+// there's none.
+func (i Installation) UpgradeCommand(prerelease bool) string {
+	switch i.Method {
+	case InstallHomebrew:
+		return "brew upgrade flyctl"
+	case InstallScoop:
+		return "scoop update flyctl"
+	case InstallChocolatey:
+		return "choco upgrade flyctl"
+	case InstallSnap:
+		return "sudo snap refresh flyctl"
+	case InstallApt:
+		return "sudo apt-get update && sudo apt-get install --only-upgrade " + i.Package
+	case InstallRPM:
+		return "sudo dnf upgrade " + i.Package
+	case InstallNix:
+		return "update flyctl in your Nix configuration, e.g. with nix profile upgrade"
+	}
+
+	if runtime.GOOS == "windows" {
+		cmd := "iwr https://fly.io/install.ps1 -useb | iex"
+		if prerelease {
+			cmd = "$v=\"pre\"; " + cmd
+		}
+		return cmd
+	}
+	cmd := "curl -L \"https://fly.io/install.sh\" | sh"
+	if prerelease {
+		cmd = cmd + " -s pre"
+	}
+	return cmd
+}
+
+// DetectInstallation returns how the running flyctl was installed, from
+// where its binary is. It defaults to the install script, which installs
+// flyctl to ~/.fly/bin.
+func DetectInstallation() Installation {
+	exe, err := os.Executable()
+	if err != nil {
+		return Installation{Method: InstallScript}
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	if IsUnderHomebrew() {
+		return Installation{Method: InstallHomebrew}
+	}
+	if inst, ok := installationFromPath(exe, runtime.GOOS, os.Getenv); ok {
+		return inst
+	}
+	if runtime.GOOS == "linux" {
+		if pkg, ok := systemPackage("dpkg", "-S", exe); ok {
+			return Installation{Method: InstallApt, Package: pkg}
+		}
+		if pkg, ok := systemPackage("rpm", "-qf", "--queryformat", "%{NAME}", exe); ok {
+			return Installation{Method: InstallRPM, Package: pkg}
+		}
+	}
+	return Installation{Method: InstallScript}
+}
+
+// installationFromPath detects installations from the path of the flyctl
+// binary alone.
+func installationFromPath(exe, goos string, getenv func(string) string) (Installation, bool) {
+	path := exe
+	if goos == "windows" {
+		path = strings.ToLower(strings.ReplaceAll(exe, `\`, "/"))
+	}
+
+	within := func(dir string) bool {
+		if dir == "" {
+			return false
+		}
+		if goos == "windows" {
+			dir = strings.ToLower(strings.ReplaceAll(dir, `\`, "/"))
+		}
+		return strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+	}
+
+	switch {
+	case strings.Contains(path, "/Cellar/flyctl/"), strings.Contains(path, "/linuxbrew/"):
+		return Installation{Method: InstallHomebrew}, true
+	case strings.HasPrefix(path, "/snap/"), getenv("SNAP_NAME") == "flyctl":
+		return Installation{Method: InstallSnap}, true
+	case strings.HasPrefix(path, "/nix/store/"):
+		return Installation{Method: InstallNix}, true
+	case goos != "windows":
+		return Installation{}, false
+	case within(getenv("SCOOP")), strings.Contains(path, "/scoop/apps/flyctl/"), strings.Contains(path, "/scoop/shims/"):
+		return Installation{Method: InstallScoop}, true
+	case within(getenv("ChocolateyInstall")), strings.Contains(path, "/chocolatey/"):
+		return Installation{Method: InstallChocolatey}, true
+	}
+	return Installation{}, false
+}
+
+// systemPackage returns the name of the system package owning a file, from
+// the output of name with args, like dpkg -S, which prints "flyctl: <path>".
+func systemPackage(name string, args ...string) (string, bool) {
+	exe, err := safeexec.LookPath(name)
+	if err != nil {
+		return "", false
+	}
+	out, err := exec.Command(exe, args...).Output()
+	if err != nil {
+		return "", false
+	}
+	pkg, _, _ := strings.Cut(strings.TrimSpace(string(out)), ":")
+	pkg = strings.TrimSpace(pkg)
+	return pkg, pkg != "" && !strings.ContainsAny(pkg, " \n")
+}
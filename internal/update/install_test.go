@@ -0,0 +1,49 @@
+package update
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallationFromPath(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+
+	cases := []struct {
+		exe    string
+		goos   string
+		method InstallMethod
+	}{
+		{"/opt/homebrew/Cellar/flyctl/0.1.80/bin/flyctl", "darwin", InstallHomebrew},
+		{"/home/linuxbrew/.linuxbrew/bin/flyctl", "linux", InstallHomebrew},
+		{"/snap/flyctl/112/bin/flyctl", "linux", InstallSnap},
+		{"/nix/store/3xyz-flyctl-0.1.80/bin/flyctl", "linux", InstallNix},
+		{`C:\Users\me\scoop\apps\flyctl\current\flyctl.exe`, "windows", InstallScoop},
+		{`C:\ProgramData\chocolatey\lib\flyctl\tools\flyctl.exe`, "windows", InstallChocolatey},
+		{"/home/me/.fly/bin/flyctl", "linux", ""},
+		{`C:\Users\me\.fly\bin\flyctl.exe`, "windows", ""},
+	}
+	for _, tc := range cases {
+		inst, ok := installationFromPath(tc.exe, tc.goos, getenv)
+		assert.Equal(t, tc.method != "", ok, tc.exe)
+		assert.Equal(t, tc.method, inst.Method, tc.exe)
+	}
+
+	env["SCOOP"] = `D:\Tools\Pkgs`
+	inst, _ := installationFromPath(`d:\tools\pkgs\apps\flyctl\current\flyctl.exe`, "windows", getenv)
+	assert.Equal(t, InstallScoop, inst.Method)
+
+	env["SNAP_NAME"] = "flyctl"
+	inst, _ = installationFromPath("/usr/bin/flyctl", "linux", getenv)
+	assert.Equal(t, InstallSnap, inst.Method)
+}
+
+func TestInstallationUpgradeCommand(t *testing.T) {
+	assert.Equal(t, "brew upgrade flyctl", Installation{Method: InstallHomebrew}.UpgradeCommand(false))
+	assert.Equal(t, "sudo apt-get update && sudo apt-get install --only-upgrade flyctl", Installation{Method: InstallApt, Package: "flyctl"}.UpgradeCommand(false))
+	assert.True(t, Installation{Method: InstallScoop}.SelfUpgrades())
+	assert.False(t, Installation{Method: InstallChocolatey}.SelfUpgrades())
+	assert.False(t, Installation{Method: InstallHomebrew}.SupportsPrereleases())
+	assert.True(t, Installation{Method: InstallScript}.SupportsPrereleases())
+}
@@ -135,28 +135,9 @@ func IsUnderHomebrew() bool {
 	return strings.HasPrefix(flyBinary, brewBinPrefix)
 }
 
-func upgradeCommand(prerelease bool) string {
-	if IsUnderHomebrew() {
-		return "brew upgrade flyctl"
-	}
-
-	if runtime.GOOS == "windows" {
-		cmd := "iwr https://fly.io/install.ps1 -useb | iex"
-		if prerelease {
-			cmd = "$v=\"pre\"; " + cmd
-		}
-		return cmd
-	} else {
-		cmd := "curl -L \"https://fly.io/install.sh\" | sh"
-		if prerelease {
-			cmd = cmd + " -s pre"
-		}
-		return cmd
-	}
-}
-
-func UpgradeInPlace(ctx context.Context, io *iostreams.IOStreams, prelease bool) error {
-	if runtime.GOOS == "windows" {
+// UpgradeInPlace upgrades flyctl with the upgrade command of inst.
+func UpgradeInPlace(ctx context.Context, io *iostreams.IOStreams, inst Installation, prelease bool) error {
+	if runtime.GOOS == "windows" && inst.Method == InstallScript {
 		if err := renameCurrentBinaries(); err != nil {
 			return err
 		}
@@ -187,7 +168,7 @@ func UpgradeInPlace(ctx context.Context, io *iostreams.IOStreams, prelease bool)
 	}
 	fmt.Println(shellToUse, switchToUse)
 
-	command := upgradeCommand(prelease)
+	command := inst.UpgradeCommand(prelease)
 
 	fmt.Fprintf(io.ErrOut, "Running automatic upgrade [%s]\n", command)
 
@@ -0,0 +1,248 @@
+// Package vulnscan scans images for known vulnerabilities with grype or
+// trivy.
+package vulnscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/registry"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// Supported scanners.
+const (
+	Grype = "grype"
+	Trivy = "trivy"
+)
+
+// Scanners lists the supported scanners in order of preference.
+var Scanners = []string{Grype, Trivy}
+
+// Severities, from least to most severe.
+var Severities = []string{"unknown", "negligible", "low", "medium", "high", "critical"}
+
+// ParseSeverity returns the normalized severity and its rank in Severities.
+func ParseSeverity(severity string) (string, int, error) {
+	severity = strings.ToLower(severity)
+	for i, s := range Severities {
+		if s == severity {
+			return s, i, nil
+		}
+	}
+	return "", -1, fmt.Errorf("invalid severity %s, expected one of %v", severity, Severities)
+}
+
+func severityRank(severity string) int {
+	_, rank, err := ParseSeverity(severity)
+	if err != nil {
+		return 0
+	}
+	return rank
+}
+
+// Vulnerability is a vulnerability found in a package of an image.
+type Vulnerability struct {
+	ID       string `json:"id"`
+	Package  string `json:"package"`
+	Version  string `json:"version"`
+	FixedIn  string `json:"fixed_in,omitempty"`
+	Severity string `json:"severity"`
+}
+
+// Report is the result of scanning an image.
+type Report struct {
+	Image           string          `json:"image"`
+	Scanner         string          `json:"scanner"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// AtOrAbove returns the vulnerabilities of r at least as severe as severity.
+func (r *Report) AtOrAbove(severity string) []Vulnerability {
+	min := severityRank(severity)
+
+	var vulns []Vulnerability
+	for _, v := range r.Vulnerabilities {
+		if severityRank(v.Severity) >= min {
+			vulns = append(vulns, v)
+		}
+	}
+	return vulns
+}
+
+// Counts returns the number of vulnerabilities of r by severity.
+func (r *Report) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, v := range r.Vulnerabilities {
+		counts[v.Severity]++
+	}
+	return counts
+}
+
+// Scan scans the image at imageRef, pulled from its registry, with scanner,
+// or with the first supported scanner found when scanner is empty.
+func Scan(ctx context.Context, imageRef, scanner string) (*Report, error) {
+	binary, scanner, err := lookPath(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerConfig, cleanup, err := registry.AuthConfigDir(config.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	var args []string
+	switch scanner {
+	case Grype:
+		args = []string{"registry:" + imageRef, "--quiet", "--output", "json"}
+	case Trivy:
+		args = []string{"image", "--quiet", "--format", "json", imageRef}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+dockerConfig)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed scanning %s with %s: %w\n%s", imageRef, scanner, err, stderr.String())
+	}
+
+	vulns, err := parse(scanner, stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Image:           imageRef,
+		Scanner:         scanner,
+		Vulnerabilities: vulns,
+	}, nil
+}
+
+func lookPath(scanner string) (binary, name string, err error) {
+	candidates := Scanners
+	if scanner != "" {
+		if scanner != Grype && scanner != Trivy {
+			return "", "", fmt.Errorf("unsupported scanner %s, expected one of %v", scanner, Scanners)
+		}
+		candidates = []string{scanner}
+	}
+
+	for _, name := range candidates {
+		if binary, err := exec.LookPath(name); err == nil {
+			return binary, name, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("no vulnerability scanner found - install one of %v and try again", candidates)
+}
+
+func parse(scanner string, out []byte) ([]Vulnerability, error) {
+	var vulns []Vulnerability
+
+	switch scanner {
+	case Grype:
+		var report struct {
+			Matches []struct {
+				Vulnerability struct {
+					ID       string `json:"id"`
+					Severity string `json:"severity"`
+					Fix      struct {
+						Versions []string `json:"versions"`
+					} `json:"fix"`
+				} `json:"vulnerability"`
+				Artifact struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"artifact"`
+			} `json:"matches"`
+		}
+		if err := json.Unmarshal(out, &report); err != nil {
+			return nil, fmt.Errorf("failed parsing grype report: %w", err)
+		}
+		for _, m := range report.Matches {
+			vulns = append(vulns, Vulnerability{
+				ID:       m.Vulnerability.ID,
+				Package:  m.Artifact.Name,
+				Version:  m.Artifact.Version,
+				FixedIn:  strings.Join(m.Vulnerability.Fix.Versions, ", "),
+				Severity: strings.ToLower(m.Vulnerability.Severity),
+			})
+		}
+	case Trivy:
+		var report struct {
+			Results []struct {
+				Vulnerabilities []struct {
+					VulnerabilityID  string
+					PkgName          string
+					InstalledVersion string
+					FixedVersion     string
+					Severity         string
+				}
+			}
+		}
+		if err := json.Unmarshal(out, &report); err != nil {
+			return nil, fmt.Errorf("failed parsing trivy report: %w", err)
+		}
+		for _, r := range report.Results {
+			for _, v := range r.Vulnerabilities {
+				vulns = append(vulns, Vulnerability{
+					ID:       v.VulnerabilityID,
+					Package:  v.PkgName,
+					Version:  v.InstalledVersion,
+					FixedIn:  v.FixedVersion,
+					Severity: strings.ToLower(v.Severity),
+				})
+			}
+		}
+	}
+
+	// Most severe first
+	sort.SliceStable(vulns, func(i, j int) bool {
+		return severityRank(vulns[i].Severity) > severityRank(vulns[j].Severity)
+	})
+
+	return vulns, nil
+}
+
+// Render writes a table of the vulnerabilities of r at least as severe as
+// severity, followed by a summary of all vulnerabilities by severity.
+func (r *Report) Render(w io.Writer, severity string) error {
+	var rows [][]string
+	for _, v := range r.AtOrAbove(severity) {
+		rows = append(rows, []string{v.Severity, v.ID, v.Package, v.Version, v.FixedIn})
+	}
+
+	if len(rows) > 0 {
+		if err := render.Table(w, "", rows, "Severity", "ID", "Package", "Version", "Fixed In"); err != nil {
+			return err
+		}
+	}
+
+	counts := r.Counts()
+	var summary []string
+	for i := len(Severities) - 1; i >= 0; i-- {
+		if n := counts[Severities[i]]; n > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", n, Severities[i]))
+		}
+	}
+	if len(summary) == 0 {
+		_, err := fmt.Fprintf(w, "No vulnerabilities found in %s\n", r.Image)
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "Found %s vulnerabilities in %s\n", strings.Join(summary, ", "), r.Image)
+	return err
+}
@@ -0,0 +1,49 @@
+package vulnscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrype(t *testing.T) {
+	out := []byte(`{"matches":[
+		{"vulnerability":{"id":"CVE-2023-0001","severity":"Low","fix":{"versions":[]}},"artifact":{"name":"zlib","version":"1.2.13"}},
+		{"vulnerability":{"id":"CVE-2023-0002","severity":"Critical","fix":{"versions":["3.0.8"]}},"artifact":{"name":"openssl","version":"3.0.7"}}
+	]}`)
+
+	vulns, err := parse(Grype, out)
+	require.NoError(t, err)
+	assert.Equal(t, []Vulnerability{
+		{ID: "CVE-2023-0002", Package: "openssl", Version: "3.0.7", FixedIn: "3.0.8", Severity: "critical"},
+		{ID: "CVE-2023-0001", Package: "zlib", Version: "1.2.13", Severity: "low"},
+	}, vulns)
+}
+
+func TestParseTrivy(t *testing.T) {
+	out := []byte(`{"Results":[{"Vulnerabilities":[
+		{"VulnerabilityID":"CVE-2023-0003","PkgName":"curl","InstalledVersion":"7.88.0","FixedVersion":"8.0.1","Severity":"HIGH"}
+	]}]}`)
+
+	vulns, err := parse(Trivy, out)
+	require.NoError(t, err)
+	assert.Equal(t, []Vulnerability{
+		{ID: "CVE-2023-0003", Package: "curl", Version: "7.88.0", FixedIn: "8.0.1", Severity: "high"},
+	}, vulns)
+}
+
+func TestReportAtOrAbove(t *testing.T) {
+	r := &Report{Vulnerabilities: []Vulnerability{
+		{ID: "a", Severity: "critical"},
+		{ID: "b", Severity: "high"},
+		{ID: "c", Severity: "medium"},
+	}}
+
+	assert.Len(t, r.AtOrAbove("critical"), 1)
+	assert.Len(t, r.AtOrAbove("high"), 2)
+	assert.Len(t, r.AtOrAbove("low"), 3)
+
+	_, _, err := ParseSeverity("severe")
+	assert.Error(t, err)
+}
@@ -3,10 +3,13 @@ package ssh
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net"
+	"os"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 type Client struct {
@@ -17,8 +20,14 @@ type Client struct {
 
 	PrivateKey, Certificate string
 
+	// ForwardAgent forwards the local SSH agent, at SSH_AUTH_SOCK, to the
+	// sessions of the client.
+	ForwardAgent bool
+
 	Client *ssh.Client
 	conn   ssh.Conn
+
+	forwardingAgent bool
 }
 
 func (c *Client) Close() error {
@@ -116,5 +125,29 @@ func (c *Client) Shell(ctx context.Context, sessIO *SessionIO, cmd string) error
 	}
 	defer sess.Close()
 
+	if c.ForwardAgent {
+		if err := c.forwardAgent(sess); err != nil {
+			return err
+		}
+	}
+
 	return sessIO.attach(ctx, sess, cmd)
 }
+
+func (c *Client) forwardAgent(sess *ssh.Session) error {
+	if !c.forwardingAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return errors.New("can't forward the SSH agent: SSH_AUTH_SOCK isn't set")
+		}
+		if err := agent.ForwardToRemote(c.Client, sock); err != nil {
+			return fmt.Errorf("can't forward the SSH agent: %w", err)
+		}
+		c.forwardingAgent = true
+	}
+
+	if err := agent.RequestAgentForwarding(sess); err != nil {
+		return fmt.Errorf("can't forward the SSH agent: %w", err)
+	}
+	return nil
+}